@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// adminFlags are shared by the commands that talk to a running OCM's admin
+// API rather than opening the database.
+var adminFlags struct {
+	adminURL string
+	token    string
+	json     bool
+}
+
+func addAdminClientFlags(fs *pflag.FlagSet) {
+	defaultURL := os.Getenv("OCM_ADMIN_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080"
+	}
+	fs.StringVar(&adminFlags.adminURL, "admin-url", defaultURL, "Admin API base URL (or set OCM_ADMIN_URL)")
+	fs.StringVar(&adminFlags.token, "token", "", "Bearer token for the admin API (default: OCM_ADMIN_TOKEN env)")
+	fs.BoolVar(&adminFlags.json, "json", false, "Print JSON instead of text")
+}
+
+// adminClient calls the admin API.
+type adminClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newAdminClient() *adminClient {
+	token := adminFlags.token
+	if token == "" {
+		token = os.Getenv("OCM_ADMIN_TOKEN")
+	}
+	return &adminClient{
+		baseURL: strings.TrimRight(adminFlags.adminURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: time.Minute}, // Creates and updates wait for the Gateway
+	}
+}
+
+// do sends body as JSON and decodes the response into out. A status other
+// than 2xx is returned as an error carrying the API's message.
+func (c *adminClient) do(method, path string, body, out interface{}) error {
+	_, err := c.doHeader(method, path, body, out)
+	return err
+}
+
+// doHeader is do, also returning the response headers.
+func (c *adminClient) doHeader(method, path string, body, out interface{}) (http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError(method, path, resp, data)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// stream opens a server-sent events stream. It isn't subject to the client
+// timeout; cancel ctx to close it.
+func (c *adminClient) stream(ctx context.Context, path string) (*http.Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := (&http.Client{Transport: c.client.Transport}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, responseError(http.MethodGet, path, resp, data)
+	}
+	return resp, nil
+}
+
+func (c *adminClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/admin/api/v1"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// responseError turns an error response into an error carrying the API's
+// message, if it sent one.
+func responseError(method, path string, resp *http.Response, data []byte) error {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+		return fmt.Errorf("%s (%s)", apiErr.Error, resp.Status)
+	}
+	return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
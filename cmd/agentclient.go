@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// agentFlags are shared by the commands that act as an agent against a
+// running OCM's agent API.
+var agentFlags struct {
+	agentURL string
+}
+
+func addAgentClientFlags(fs *pflag.FlagSet) {
+	defaultURL := os.Getenv("OCM_AGENT_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:9999"
+	}
+	fs.StringVar(&agentFlags.agentURL, "agent-url", defaultURL, "Agent API base URL (or set OCM_AGENT_URL)")
+}
+
+// agentClient calls the agent API.
+type agentClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newAgentClient() *agentClient {
+	return &agentClient{
+		baseURL: strings.TrimRight(agentFlags.agentURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends body as JSON and decodes the response into out. A status other
+// than 2xx is returned as an error carrying the API's message.
+func (c *agentClient) do(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+"/api/v1"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if resp.StatusCode/100 != 2 {
+		return responseError(method, path, resp, data)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
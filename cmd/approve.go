@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+var approveFlags struct {
+	ttl    string
+	preset string
+	reason string
+}
+
+var requestsCmd = &cobra.Command{
+	Use:   "requests",
+	Short: "List pending elevation requests",
+	Long: `List pending elevation requests through a running OCM's admin API.
+
+Approve or deny them with 'ocm approve' and 'ocm deny'. A request can be
+named by a unique prefix of its ID.`,
+	Args:          cobra.NoArgs,
+	RunE:          runRequests,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <request-id>",
+	Short: "Approve a pending elevation request",
+	Long: `Approve a pending elevation request through a running OCM's admin API.
+The read-write credential is injected for --ttl, or for the TTL of a named
+--preset for the service. Without either, the elevation lasts as long as the
+agent asked for (capped at the credential's max TTL), or 30 minutes.
+
+  ocm approve elev_17 --ttl 1h`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runApprove,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var denyCmd = &cobra.Command{
+	Use:   "deny <request-id>",
+	Short: "Deny a pending elevation request",
+	Long: `Deny a pending elevation request through a running OCM's admin API.
+--reason is recorded in the audit log.
+
+  ocm deny elev_17 --reason "not during the freeze"`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runDeny,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	for _, c := range []*cobra.Command{requestsCmd, approveCmd, denyCmd} {
+		addAdminClientFlags(c.Flags())
+	}
+	approveCmd.Flags().StringVar(&approveFlags.ttl, "ttl", "", "How long the elevation lasts (default: the requested TTL)")
+	approveCmd.Flags().StringVar(&approveFlags.preset, "preset", "", "Named TTL preset for the service (overrides --ttl)")
+	denyCmd.Flags().StringVar(&approveFlags.reason, "reason", "", "Reason for the denial, recorded in the audit log")
+}
+
+func runRequests(cmd *cobra.Command, args []string) error {
+	pending, err := listPending(newAdminClient())
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if adminFlags.json {
+		return printJSON(out, pending)
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(out, "No pending requests")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSERVICE\tSCOPE\tAGE\tREQUESTED BY\tREASON")
+	for _, elev := range pending {
+		age := time.Since(elev.RequestedAt).Truncate(time.Second)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", elev.ID, elev.Service, elev.Scope, age,
+			firstNonEmpty(elev.RequestedBy, "-"), elev.Reason)
+	}
+	return tw.Flush()
+}
+
+func runApprove(cmd *cobra.Command, args []string) error {
+	if err := validTTL(approveFlags.ttl); err != nil {
+		return err
+	}
+	c := newAdminClient()
+	elev, err := resolvePending(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		ExpiresAt *time.Time `json:"expiresAt"`
+		TTL       string     `json:"ttl"`
+	}
+	req := &api.ApproveRequest{TTL: approveFlags.ttl, Preset: approveFlags.preset}
+	if err := c.do(http.MethodPost, "/requests/"+url.PathEscape(elev.ID)+"/approve", req, &resp); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Approved %s (%s %s)", elev.ID, elev.Service, elev.Scope)
+	if resp.TTL != "" {
+		fmt.Fprintf(out, " for %s", resp.TTL)
+	}
+	if resp.ExpiresAt != nil {
+		fmt.Fprintf(out, " until %s", resp.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// validTTL checks a --ttl flag; empty means the requested TTL.
+func validTTL(ttl string) error {
+	if ttl == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(ttl); err != nil {
+		return fmt.Errorf("invalid --ttl: %w", err)
+	} else if d <= 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+	return nil
+}
+
+func runDeny(cmd *cobra.Command, args []string) error {
+	c := newAdminClient()
+	elev, err := resolvePending(c, args[0])
+	if err != nil {
+		return err
+	}
+	req := &api.DenyRequest{Reason: approveFlags.reason}
+	if err := c.do(http.MethodPost, "/requests/"+url.PathEscape(elev.ID)+"/deny", req, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Denied %s (%s %s)\n", elev.ID, elev.Service, elev.Scope)
+	return nil
+}
+
+func listPending(c *adminClient) ([]*store.Elevation, error) {
+	var pending []*store.Elevation
+	if err := c.do(http.MethodGet, "/requests", nil, &pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// resolvePending finds the pending request whose ID is id or, failing that,
+// the only one whose ID starts with it.
+func resolvePending(c *adminClient, id string) (*store.Elevation, error) {
+	pending, err := listPending(c)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*store.Elevation
+	for _, elev := range pending {
+		if elev.ID == id {
+			return elev, nil
+		}
+		if strings.HasPrefix(elev.ID, id) {
+			matches = append(matches, elev)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no pending request %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%s matches %d pending requests; give more of the ID", id, len(matches))
+	}
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// tailReconnect is how long `ocm audit tail -f` waits before reopening a
+// dropped event stream.
+const tailReconnect = 5 * time.Second
+
+var auditTailFlags struct {
+	lines    int
+	follow   bool
+	service  string
+	action   string
+	actor    string
+	interval time.Duration
+}
+
+var auditTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print recent audit entries, optionally following new ones",
+	Long: `Print the most recent audit entries from a running OCM's admin API, oldest
+first. With -f, keep printing new entries as they are written.
+
+New entries are fetched when the admin event stream reports activity, and
+every --interval regardless, since not every audited action raises an
+event. --json prints one entry per line, for piping into jq:
+
+  ocm audit tail -f --service github
+  ocm audit tail -f --action credential_access --json | jq .details`,
+	Args:          cobra.NoArgs,
+	RunE:          runAuditTail,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	f := auditTailCmd.Flags()
+	addAdminClientFlags(f)
+	f.IntVarP(&auditTailFlags.lines, "lines", "n", 20, "Number of recent entries to print first")
+	f.BoolVarP(&auditTailFlags.follow, "follow", "f", false, "Keep printing new entries")
+	f.StringVar(&auditTailFlags.service, "service", "", "Only entries for this service")
+	f.StringVar(&auditTailFlags.action, "action", "", "Only entries with this action (see GET /admin/api/v1/audit/actions)")
+	f.StringVar(&auditTailFlags.actor, "actor", "", "Only entries by this actor")
+	f.DurationVar(&auditTailFlags.interval, "interval", 5*time.Second, "With -f, how often to check for new entries without an event")
+	auditCmd.AddCommand(auditTailCmd)
+}
+
+func runAuditTail(cmd *cobra.Command, args []string) error {
+	if auditTailFlags.action != "" {
+		if _, err := store.ParseAuditAction(auditTailFlags.action); err != nil {
+			return err
+		}
+	}
+	if auditTailFlags.lines < 0 || auditTailFlags.lines > store.MaxAuditLimit {
+		return fmt.Errorf("--lines must be between 0 and %d", store.MaxAuditLimit)
+	}
+	if auditTailFlags.follow && auditTailFlags.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	c := newAdminClient()
+	t := &auditTailer{client: c, out: cmd.OutOrStdout(), json: adminFlags.json}
+
+	if auditTailFlags.lines > 0 {
+		var entries []*store.AuditEntry
+		q := t.query()
+		q.Set("limit", strconv.Itoa(auditTailFlags.lines))
+		if err := c.do(http.MethodGet, "/audit?"+q.Encode(), nil, &entries); err != nil {
+			return err
+		}
+		// Newest first from the API
+		for i := len(entries) - 1; i >= 0; i-- {
+			t.print(entries[i])
+		}
+	}
+	if !auditTailFlags.follow {
+		return nil
+	}
+	if t.since.IsZero() {
+		// Nothing printed yet; follow from now
+		t.since = time.Now()
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	wake := make(chan struct{}, 1)
+	go watchEvents(ctx, c, wake, cmd.ErrOrStderr())
+
+	ticker := time.NewTicker(auditTailFlags.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-ticker.C:
+		}
+		if err := t.poll(); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+		}
+	}
+}
+
+// auditTailer prints audit entries, remembering the newest one printed so
+// that each poll only prints what is new.
+type auditTailer struct {
+	client *adminClient
+	out    io.Writer
+	json   bool
+
+	since     time.Time       // Timestamp of the newest entry printed
+	seenSince map[string]bool // IDs printed with that timestamp
+}
+
+func (t *auditTailer) query() url.Values {
+	q := url.Values{}
+	for name, v := range map[string]string{
+		"service": auditTailFlags.service,
+		"action":  auditTailFlags.action,
+		"actor":   auditTailFlags.actor,
+	} {
+		if v != "" {
+			q.Set(name, v)
+		}
+	}
+	return q
+}
+
+// poll prints the entries written since the last one printed, oldest first.
+func (t *auditTailer) poll() error {
+	q := t.query()
+	// from is inclusive; entries already printed at that instant are skipped
+	q.Set("from", t.since.Format(time.RFC3339Nano))
+	q.Set("limit", strconv.Itoa(store.MaxAuditLimit))
+
+	var all []*store.AuditEntry
+	for cursor := ""; ; {
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		var page []*store.AuditEntry
+		header, err := t.client.doHeader(http.MethodGet, "/audit?"+q.Encode(), nil, &page)
+		if err != nil {
+			return err
+		}
+		all = append(all, page...)
+		if cursor = header.Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if e := all[i]; !(e.Timestamp.Equal(t.since) && t.seenSince[e.ID]) {
+			t.print(e)
+		}
+	}
+	return nil
+}
+
+func (t *auditTailer) print(e *store.AuditEntry) {
+	if e.Timestamp.After(t.since) || t.seenSince == nil {
+		t.since = e.Timestamp
+		t.seenSince = map[string]bool{}
+	}
+	if e.Timestamp.Equal(t.since) {
+		t.seenSince[e.ID] = true
+	}
+
+	if t.json {
+		json.NewEncoder(t.out).Encode(e)
+		return
+	}
+	fmt.Fprintf(t.out, "%s  %-22s %-14s %-6s %-12s %s\n",
+		e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Action,
+		firstNonEmpty(e.Service, "-"), firstNonEmpty(e.Scope, "-"), firstNonEmpty(e.Actor, "-"), e.Details)
+}
+
+// watchEvents signals wake whenever the admin event stream delivers an event,
+// reconnecting until ctx is done. Without a stream (e.g. notifications not
+// configured) the caller's polling still finds new entries.
+func watchEvents(ctx context.Context, c *adminClient, wake chan<- struct{}, errOut io.Writer) {
+	warned := false
+	for {
+		err := readEvents(ctx, c, wake)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !warned {
+			fmt.Fprintf(errOut, "Warning: event stream unavailable, polling every %s: %v\n", auditTailFlags.interval, err)
+			warned = true
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(tailReconnect):
+		}
+	}
+}
+
+func readEvents(ctx context.Context, c *adminClient, wake chan<- struct{}) error {
+	resp, err := c.stream(ctx, "/events")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event:") {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/backup"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// minPassphraseLen is the shortest passphrase `ocm export` accepts.
+const minPassphraseLen = 12
+
+var backupFlags struct {
+	dbPath         string
+	masterKeyFile  string
+	output         string
+	passphraseFile string
+	keyFile        string
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export credentials, elevations and audit history to an encrypted backup",
+	Long: `Export every credential (with its tokens), elevation preset, elevation,
+approval receipt and audit entry to a single archive, encrypted with
+AES-256-GCM under a passphrase or a 32-byte key. Restore it with 'ocm import'.
+
+The passphrase is read from --passphrase-file ("-" for stdin) or
+OCM_BACKUP_PASSPHRASE; a key from --key-file (32 bytes raw or 64 hex
+characters). Settings, webhooks and notification configuration are not
+included.
+
+  ocm export --db /data/ocm.db -o ocm-backup.json --passphrase-file ~/.ocm/backup-pass`,
+	Args:          cobra.NoArgs,
+	RunE:          runExport,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Restore an encrypted backup into a fresh database",
+	Long: `Restore an archive written by 'ocm export' into a database with no
+credentials, elevations or audit entries, e.g. a new instance's. Run it
+before starting 'ocm serve' on that database.
+
+Credentials are re-encrypted with this instance's master key, and the audit
+log is re-chained under it, so 'ocm audit verify' passes afterwards. Run
+'ocm audit verify' on the old instance before exporting to confirm its
+history was intact.`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runImport,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	for _, c := range []*cobra.Command{exportCmd, importCmd} {
+		f := c.Flags()
+		f.StringVar(&backupFlags.dbPath, "db", "ocm.db", "Database path")
+		f.StringVar(&backupFlags.masterKeyFile, "master-key-file", "", "Path to master key file (default: "+defaultMasterKeyPath+", or set OCM_MASTER_KEY env)")
+		f.StringVar(&backupFlags.passphraseFile, "passphrase-file", "", `File holding the backup passphrase, or "-" for stdin (default: OCM_BACKUP_PASSPHRASE env)`)
+		f.StringVar(&backupFlags.keyFile, "key-file", "", "File holding a 32-byte backup key (raw or 64 hex characters) instead of a passphrase")
+	}
+	exportCmd.Flags().StringVarP(&backupFlags.output, "output", "o", "", `Archive to write, or "-" for stdout (required)`)
+	exportCmd.MarkFlagRequired("output")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	secret, err := loadBackupSecret(cmd)
+	if err != nil {
+		return err
+	}
+	if secret.Passphrase != "" && len(secret.Passphrase) < minPassphraseLen {
+		return fmt.Errorf("backup passphrase must be at least %d characters", minPassphraseLen)
+	}
+	db, err := openBackupStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snap, err := db.ExportSnapshot()
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if backupFlags.output != "-" {
+		// Never overwrite an existing backup
+		f, err := os.OpenFile(backupFlags.output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := backup.Write(out, snap, secret); err != nil {
+		if backupFlags.output != "-" {
+			os.Remove(backupFlags.output)
+		}
+		return err
+	}
+	if f, ok := out.(*os.File); ok {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d credentials, %d elevations and %d audit entries\n",
+		len(snap.Credentials), len(snap.Elevations), len(snap.AuditEntries))
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	secret, err := loadBackupSecret(cmd)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	snap, err := backup.Read(f, secret)
+	if err != nil {
+		return err
+	}
+
+	db, err := openBackupStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.ImportSnapshot(snap); err != nil {
+		if errors.Is(err, store.ErrStoreNotEmpty) {
+			return fmt.Errorf("%s already has data; import only into a fresh database", backupFlags.dbPath)
+		}
+		return fmt.Errorf("import: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported %d credentials, %d elevations and %d audit entries from a backup taken %s\n",
+		len(snap.Credentials), len(snap.Elevations), len(snap.AuditEntries), snap.CreatedAt.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+func openBackupStore() (*store.Store, error) {
+	masterKey, err := loadMasterKey(backupFlags.masterKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load master key: %w", err)
+	}
+	db, err := store.New(backupFlags.dbPath, masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+// loadBackupSecret reads the backup key or passphrase from the flags or
+// OCM_BACKUP_PASSPHRASE.
+func loadBackupSecret(cmd *cobra.Command) (backup.Secret, error) {
+	if backupFlags.keyFile != "" {
+		if backupFlags.passphraseFile != "" {
+			return backup.Secret{}, fmt.Errorf("use --passphrase-file or --key-file, not both")
+		}
+		data, err := os.ReadFile(backupFlags.keyFile)
+		if err != nil {
+			return backup.Secret{}, err
+		}
+		key := data
+		if len(data) != 32 {
+			if key, err = hexDecode(strings.TrimSpace(string(data))); err != nil || len(key) != 32 {
+				return backup.Secret{}, fmt.Errorf("%s must be 32 bytes or 64 hex characters", backupFlags.keyFile)
+			}
+		}
+		return backup.Secret{Key: key}, nil
+	}
+
+	if backupFlags.passphraseFile != "" {
+		var data []byte
+		var err error
+		if backupFlags.passphraseFile == "-" {
+			data, err = io.ReadAll(io.LimitReader(cmd.InOrStdin(), 4096))
+		} else {
+			data, err = os.ReadFile(backupFlags.passphraseFile)
+		}
+		if err != nil {
+			return backup.Secret{}, err
+		}
+		pass := strings.TrimRight(string(data), "\r\n")
+		if pass == "" {
+			return backup.Secret{}, fmt.Errorf("backup passphrase is empty")
+		}
+		return backup.Secret{Passphrase: pass}, nil
+	}
+	if pass := os.Getenv("OCM_BACKUP_PASSPHRASE"); pass != "" {
+		return backup.Secret{Passphrase: pass}, nil
+	}
+	return backup.Secret{}, fmt.Errorf("a backup passphrase (--passphrase-file or OCM_BACKUP_PASSPHRASE) or --key-file is required")
+}
@@ -0,0 +1,533 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// credentialEdit holds the create/update flags. Secrets are only read from
+// files or stdin, never from the command line, so they stay out of shell
+// history and process listings.
+var credentialEdit struct {
+	displayName    string
+	credType       string
+	gateway        string
+	readEnv        string
+	readConfig     string
+	readTokenFile  string
+	writeEnv       string
+	writeConfig    string
+	writeTokenFile string
+	maxTTL         string
+	readExpires    string
+	writeExpires   string
+	removeWrite    bool
+}
+
+var credentialCmd = &cobra.Command{
+	Use:     "credential",
+	Aliases: []string{"credentials", "cred"},
+	Short:   "Manage credentials through the admin API",
+	Long: `Manage credentials through a running OCM's admin API.
+
+The admin API is found at --admin-url (or OCM_ADMIN_URL). If --token (or
+OCM_ADMIN_TOKEN) is set, it is sent as a bearer token, e.g. for an
+authenticating proxy in front of the admin API.
+
+Services in the catalog (GET /admin/api/v1/catalog) default --read-env or
+--read-config and --type to the catalog's, and the read token is checked
+against the catalog's pattern.
+
+Token values are read from a file or from stdin ("-"), never from flags:
+
+  op read op://vault/github/token | ocm credential create github \
+      --display-name GitHub --type pat --read-env GITHUB_TOKEN --read-token-file -
+
+Stored token values are never printed.`,
+}
+
+var credentialListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List credentials",
+	Args:  cobra.NoArgs,
+	RunE:  runCredentialList,
+}
+
+var credentialShowCmd = &cobra.Command{
+	Use:   "show <service>",
+	Short: "Show a credential (token values masked)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialShow,
+}
+
+var credentialCreateCmd = &cobra.Command{
+	Use:   "create <service>",
+	Short: "Create a credential",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialCreate,
+}
+
+var credentialUpdateCmd = &cobra.Command{
+	Use:   "update <service>",
+	Short: "Update a credential; only the given flags change",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialUpdate,
+}
+
+var credentialRotateCmd = &cobra.Command{
+	Use:   "rotate <service>",
+	Short: "Mint new secrets through the credential's rotation hook and re-inject them",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialRotate,
+}
+
+var credentialDeleteCmd = &cobra.Command{
+	Use:   "delete <service>",
+	Short: "Delete a credential and remove it from the Gateway",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialDelete,
+}
+
+func init() {
+	addAdminClientFlags(credentialCmd.PersistentFlags())
+
+	for _, c := range []*cobra.Command{credentialCreateCmd, credentialUpdateCmd} {
+		f := c.Flags()
+		f.StringVar(&credentialEdit.displayName, "display-name", "", "Display name")
+		f.StringVar(&credentialEdit.credType, "type", "", "Credential type (e.g., pat, token, api_key, oauth2)")
+		f.StringVar(&credentialEdit.gateway, "gateway", "", "Gateway to inject into (default gateway if empty)")
+		f.StringVar(&credentialEdit.readEnv, "read-env", "", "Env var the read token is injected as")
+		f.StringVar(&credentialEdit.readConfig, "read-config", "", "Config path the read token is injected at (instead of --read-env)")
+		f.StringVar(&credentialEdit.readTokenFile, "read-token-file", "", `File holding the read token, or "-" for stdin`)
+		f.StringVar(&credentialEdit.writeEnv, "write-env", "", "Env var the read-write token is injected as during elevation")
+		f.StringVar(&credentialEdit.writeConfig, "write-config", "", "Config path the read-write token is injected at (instead of --write-env)")
+		f.StringVar(&credentialEdit.writeTokenFile, "write-token-file", "", `File holding the read-write token, or "-" for stdin`)
+		f.StringVar(&credentialEdit.maxTTL, "max-ttl", "", "Maximum elevation TTL for read-write access (default 30m)")
+		f.StringVar(&credentialEdit.readExpires, "read-expires", "", `When the read token expires (RFC 3339 or YYYY-MM-DD; "" to clear), for expiry warnings`)
+		f.StringVar(&credentialEdit.writeExpires, "write-expires", "", `When the read-write token expires (RFC 3339 or YYYY-MM-DD; "" to clear)`)
+	}
+	credentialUpdateCmd.Flags().BoolVar(&credentialEdit.removeWrite, "remove-write", false, "Remove read-write access")
+
+	for _, c := range []*cobra.Command{credentialListCmd, credentialShowCmd, credentialCreateCmd, credentialUpdateCmd, credentialRotateCmd, credentialDeleteCmd} {
+		c.SilenceUsage = true
+		c.SilenceErrors = true // Execute prints the error
+		credentialCmd.AddCommand(c)
+	}
+}
+
+func credentialPath(service string) string {
+	return "/credentials/" + url.PathEscape(service)
+}
+
+func runCredentialList(cmd *cobra.Command, args []string) error {
+	var creds []*store.Credential
+	if err := newAdminClient().do(http.MethodGet, "/credentials", nil, &creds); err != nil {
+		return err
+	}
+	for _, cred := range creds {
+		maskCredential(cred)
+	}
+	out := cmd.OutOrStdout()
+	if adminFlags.json {
+		return printJSON(out, creds)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tNAME\tTYPE\tREAD\tWRITE\tGATEWAY\tLAST USED")
+	for _, cred := range creds {
+		gw := cred.Gateway
+		if gw == "" {
+			gw = "default"
+		}
+		lastUsed := "never"
+		if cred.LastUsedAt != nil {
+			lastUsed = cred.LastUsedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", cred.Service, cred.DisplayName, cred.Type,
+			injectionTarget(cred.Read), injectionTarget(cred.ReadWrite), gw, lastUsed)
+	}
+	return tw.Flush()
+}
+
+func runCredentialShow(cmd *cobra.Command, args []string) error {
+	var cred store.Credential
+	if err := newAdminClient().do(http.MethodGet, credentialPath(args[0]), nil, &cred); err != nil {
+		return err
+	}
+	maskCredential(&cred)
+	out := cmd.OutOrStdout()
+	if adminFlags.json {
+		return printJSON(out, &cred)
+	}
+
+	fmt.Fprintf(out, "Service:      %s\n", cred.Service)
+	fmt.Fprintf(out, "Display name: %s\n", cred.DisplayName)
+	fmt.Fprintf(out, "Type:         %s\n", cred.Type)
+	if cred.Gateway != "" {
+		fmt.Fprintf(out, "Gateway:      %s\n", cred.Gateway)
+	}
+	for _, level := range []struct {
+		name   string
+		access *store.AccessLevel
+	}{{"Read", cred.Read}, {"Read-write", cred.ReadWrite}} {
+		if level.access == nil {
+			continue
+		}
+		fmt.Fprintf(out, "%s:\n  target: %s\n  token:  %s\n", level.name, injectionTarget(level.access), level.access.Token)
+		if level.access.MaxTTL > 0 {
+			fmt.Fprintf(out, "  maxTTL: %s\n", level.access.MaxTTL)
+		}
+		if level.access.ExpiresAt != nil {
+			fmt.Fprintf(out, "  expires: %s\n", level.access.ExpiresAt.Format(time.RFC3339))
+		}
+		for _, f := range level.access.AdditionalFields {
+			fmt.Fprintf(out, "  + %s: %s\n", f.Name, firstNonEmpty(f.EnvVar, f.ConfigPath))
+		}
+	}
+	if cred.Injection != nil {
+		fmt.Fprintf(out, "Injection:    %s %s\n", cred.Injection.Status, cred.Injection.Detail)
+	}
+	for i, use := range cred.Usage {
+		label := ""
+		if i == 0 {
+			label = "Last used:"
+		}
+		fmt.Fprintf(out, "%-14s%s (%s, %s)\n", label, use.UsedAt.Format(time.RFC3339), use.Scope, use.Via)
+	}
+	fmt.Fprintf(out, "Updated:      %s\n", cred.UpdatedAt.Format(time.RFC3339))
+	return nil
+}
+
+func runCredentialCreate(cmd *cobra.Command, args []string) error {
+	e := &credentialEdit
+	if e.displayName == "" {
+		e.displayName = args[0]
+	}
+	if e.readTokenFile == "" {
+		return fmt.Errorf("--read-token-file is required")
+	}
+
+	// Services in the catalog default to its injection target and type
+	var provider *catalog.Provider
+	if e.readEnv == "" && e.readConfig == "" || e.credType == "" {
+		provider = catalogProvider(args[0])
+	}
+	if e.readEnv == "" && e.readConfig == "" {
+		if provider == nil || len(provider.Fields) == 0 || provider.Fields[0].Injection == nil {
+			return fmt.Errorf("--read-env or --read-config is required")
+		}
+		switch in := provider.Fields[0].Injection; in.Type {
+		case "config":
+			e.readConfig = in.Path
+		default:
+			e.readEnv = in.Var
+		}
+	}
+	if e.credType == "" && provider != nil {
+		e.credType = provider.CredentialType
+	}
+
+	req := &api.CreateCredentialRequest{Service: args[0], DisplayName: e.displayName, Type: e.credType, Read: &api.AccessLevelConfig{}}
+	if cmd.Flags().Changed("gateway") {
+		req.Gateway = &e.gateway
+	}
+	if err := applyCredentialEdit(cmd, req); err != nil {
+		return err
+	}
+	if provider != nil && len(provider.Fields) > 0 {
+		if err := provider.Fields[0].Validate(req.Read.Token); err != nil {
+			return err
+		}
+	}
+	return saveCredential(cmd, http.MethodPost, "/credentials", req, "created")
+}
+
+// catalogProvider returns service's entry in the admin API's service
+// catalog, or nil if it has none.
+func catalogProvider(service string) *catalog.Provider {
+	var p catalog.Provider
+	if err := newAdminClient().do(http.MethodGet, "/catalog/"+url.PathEscape(service), nil, &p); err != nil {
+		return nil
+	}
+	return &p
+}
+
+func runCredentialUpdate(cmd *cobra.Command, args []string) error {
+	// PUT replaces the credential, so start from the stored one
+	c := newAdminClient()
+	var cred store.Credential
+	if err := c.do(http.MethodGet, credentialPath(args[0]), nil, &cred); err != nil {
+		return err
+	}
+	req := credentialRequest(&cred)
+
+	flags := cmd.Flags()
+	if flags.Changed("display-name") {
+		req.DisplayName = credentialEdit.displayName
+	}
+	if flags.Changed("type") {
+		req.Type = credentialEdit.credType
+	}
+	if flags.Changed("gateway") {
+		req.Gateway = &credentialEdit.gateway
+	}
+	if credentialEdit.removeWrite {
+		req.ReadWrite = nil
+	}
+	if err := applyCredentialEdit(cmd, req); err != nil {
+		return err
+	}
+	return saveCredential(cmd, http.MethodPut, credentialPath(args[0]), req, "updated")
+}
+
+func runCredentialRotate(cmd *cobra.Command, args []string) error {
+	var result elevation.RotationResult
+	if err := newAdminClient().do(http.MethodPost, credentialPath(args[0])+"/rotate", nil, &result); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Credential %s rotated: %s\n", args[0], strings.Join(result.Rotated, ", "))
+	for _, failed := range result.Failed {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Failed: %s\n", failed)
+	}
+	if result.Warning != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", result.Warning)
+	}
+	return nil
+}
+
+func runCredentialDelete(cmd *cobra.Command, args []string) error {
+	if err := newAdminClient().do(http.MethodDelete, credentialPath(args[0]), nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Credential %s deleted\n", args[0])
+	return nil
+}
+
+// applyCredentialEdit applies the injection and token flags to req.
+func applyCredentialEdit(cmd *cobra.Command, req *api.CreateCredentialRequest) error {
+	e := &credentialEdit
+	if e.readTokenFile == "-" && e.writeTokenFile == "-" {
+		return fmt.Errorf("only one of --read-token-file and --write-token-file can read stdin")
+	}
+	if e.readEnv != "" && e.readConfig != "" || e.writeEnv != "" && e.writeConfig != "" {
+		return fmt.Errorf("use either an env var or a config path per access level, not both")
+	}
+
+	setTarget(req.Read, e.readEnv, e.readConfig)
+	if e.readTokenFile != "" {
+		token, err := readSecret(cmd, e.readTokenFile)
+		if err != nil {
+			return fmt.Errorf("read token: %w", err)
+		}
+		req.Read.Token = token
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("read-expires") {
+		expiresAt, err := parseExpiry(e.readExpires)
+		if err != nil {
+			return fmt.Errorf("invalid --read-expires: %w", err)
+		}
+		req.Read.ExpiresAt = expiresAt
+	}
+	if e.writeEnv == "" && e.writeConfig == "" && e.writeTokenFile == "" && !flags.Changed("max-ttl") && !flags.Changed("write-expires") {
+		return nil
+	}
+	if e.removeWrite {
+		return fmt.Errorf("--remove-write can't be combined with read-write flags")
+	}
+	if req.ReadWrite == nil {
+		if e.writeEnv == "" && e.writeConfig == "" || e.writeTokenFile == "" {
+			return fmt.Errorf("read-write access needs --write-env or --write-config, and --write-token-file")
+		}
+		req.ReadWrite = &api.AccessLevelConfig{}
+	}
+	setTarget(req.ReadWrite, e.writeEnv, e.writeConfig)
+	if e.writeTokenFile != "" {
+		token, err := readSecret(cmd, e.writeTokenFile)
+		if err != nil {
+			return fmt.Errorf("read-write token: %w", err)
+		}
+		req.ReadWrite.Token = token
+	}
+	if flags.Changed("max-ttl") {
+		if _, err := time.ParseDuration(e.maxTTL); err != nil {
+			return fmt.Errorf("invalid --max-ttl: %w", err)
+		}
+		req.ReadWrite.MaxTTL = e.maxTTL
+	}
+	if flags.Changed("write-expires") {
+		expiresAt, err := parseExpiry(e.writeExpires)
+		if err != nil {
+			return fmt.Errorf("invalid --write-expires: %w", err)
+		}
+		req.ReadWrite.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// parseExpiry parses a token expiry given as RFC 3339 or a local date, or
+// nil for "".
+func parseExpiry(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.ParseInLocation(time.DateOnly, s, time.Local); err != nil {
+			return nil, fmt.Errorf("want RFC 3339 or YYYY-MM-DD, got %q", s)
+		}
+	}
+	return &t, nil
+}
+
+// setTarget points level at an env var or a config path, if either is given.
+func setTarget(level *api.AccessLevelConfig, envVar, configPath string) {
+	switch {
+	case envVar != "":
+		level.InjectionType, level.EnvVar, level.ConfigPath = "env", envVar, ""
+	case configPath != "":
+		level.InjectionType, level.EnvVar, level.ConfigPath = "config", "", configPath
+	}
+}
+
+// saveCredential sends a create or update and reports the result, including
+// any Gateway injection warning.
+func saveCredential(cmd *cobra.Command, method, path string, req *api.CreateCredentialRequest, verb string) error {
+	var resp struct {
+		Warning string `json:"warning"`
+	}
+	if err := newAdminClient().do(method, path, req, &resp); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Credential %s %s\n", req.Service, verb)
+	if resp.Warning != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", resp.Warning)
+	}
+	return nil
+}
+
+// credentialRequest converts a stored credential back into the request that
+// would recreate it.
+func credentialRequest(cred *store.Credential) *api.CreateCredentialRequest {
+	req := &api.CreateCredentialRequest{
+		Service:     cred.Service,
+		DisplayName: cred.DisplayName,
+		Type:        cred.Type,
+		Read:        accessLevelConfig(cred.Read),
+		ReadWrite:   accessLevelConfig(cred.ReadWrite),
+	}
+	if req.Read == nil {
+		req.Read = &api.AccessLevelConfig{}
+	}
+	return req
+}
+
+func accessLevelConfig(level *store.AccessLevel) *api.AccessLevelConfig {
+	if level == nil {
+		return nil
+	}
+	cfg := &api.AccessLevelConfig{
+		InjectionType: string(level.InjectionType),
+		EnvVar:        level.EnvVar,
+		ConfigPath:    level.ConfigPath,
+		Token:         level.Token,
+		RefreshToken:  level.RefreshToken,
+		ExpiresAt:     level.ExpiresAt,
+	}
+	if level.MaxTTL > 0 {
+		cfg.MaxTTL = level.MaxTTL.String()
+	}
+	for _, f := range level.AdditionalFields {
+		cfg.AdditionalFields = append(cfg.AdditionalFields, api.AdditionalFieldConfig{
+			Name:          f.Name,
+			InjectionType: string(f.InjectionType),
+			EnvVar:        f.EnvVar,
+			ConfigPath:    f.ConfigPath,
+			Value:         f.Value,
+		})
+	}
+	return cfg
+}
+
+// readSecret reads a token from path, or stdin for "-", dropping the
+// trailing newline most tools add.
+func readSecret(cmd *cobra.Command, path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(io.LimitReader(cmd.InOrStdin(), 1<<20))
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
+
+// maskCredential replaces every secret value in cred with a placeholder.
+func maskCredential(cred *store.Credential) {
+	for _, level := range []*store.AccessLevel{cred.Read, cred.ReadWrite} {
+		if level == nil {
+			continue
+		}
+		level.Token = maskSecret(level.Token)
+		level.RefreshToken = maskSecret(level.RefreshToken)
+		for i := range level.AdditionalFields {
+			level.AdditionalFields[i].Value = maskSecret(level.AdditionalFields[i].Value)
+		}
+	}
+	if cred.AccessWebhook != nil {
+		cred.AccessWebhook.Secret = maskSecret(cred.AccessWebhook.Secret)
+	}
+	if cred.Rotation != nil {
+		cred.Rotation.Secret = maskSecret(cred.Rotation.Secret)
+		cred.Rotation.ClientSecret = maskSecret(cred.Rotation.ClientSecret)
+	}
+}
+
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf("******** (%d chars)", len(s))
+}
+
+// injectionTarget describes where level is injected.
+func injectionTarget(level *store.AccessLevel) string {
+	if level == nil {
+		return "-"
+	}
+	if level.ConfigPath != "" {
+		return "config:" + level.ConfigPath
+	}
+	return "env:" + level.EnvVar
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// dbVerifyExitProblems is the exit status of `ocm db verify` when the
+// database answered but has problems.
+const dbVerifyExitProblems = 2
+
+var dbFlags struct {
+	dbPath        string
+	masterKeyFile string
+	json          bool
+}
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance without starting the server",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the database schema to this version of OCM",
+	Long: `Apply any schema changes this version of OCM needs. 'ocm serve' does this
+on start; run it ahead of an upgrade to find problems before the restart.
+Migrations only add tables, columns and indexes, so it is safe to repeat.`,
+	Args:          cobra.NoArgs,
+	RunE:          runDBMigrate,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var dbVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check database integrity and that every secret decrypts",
+	Long: `Run SQLite's integrity check, then try to decrypt every value stored under
+the master key: credentials, webhook secrets, queued gateway operations and
+the receipt signing key. Nothing is changed. Undecryptable values mean a
+wrong master key, values written under another key, or corruption.
+
+The audit log's hash chain is checked separately by 'ocm audit verify'.
+
+Exit status: 0 if everything checks out, 2 if problems were found, 1 if the
+check couldn't run.`,
+	Args:          cobra.NoArgs,
+	RunE:          runDBVerify,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var dbCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Checkpoint the write-ahead log and reclaim free space",
+	Long: `Fold the write-ahead log into the database file and truncate it, then
+VACUUM to reclaim space left by deleted rows, e.g. after audit log pruning.
+It can run while 'ocm serve' is up: writes on either side wait up to a minute
+for the other. A database big enough to take longer than that to VACUUM
+should be compacted with 'ocm serve' stopped. Needs free disk space about the
+size of the database.`,
+	Args:          cobra.NoArgs,
+	RunE:          runDBCompact,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	for _, c := range []*cobra.Command{dbMigrateCmd, dbVerifyCmd, dbCompactCmd} {
+		c.Flags().StringVar(&dbFlags.dbPath, "db", "ocm.db", "Database path")
+		c.Flags().StringVar(&dbFlags.masterKeyFile, "master-key-file", "", "Path to master key file (default: "+defaultMasterKeyPath+", or set OCM_MASTER_KEY env)")
+		dbCmd.AddCommand(c)
+	}
+	dbVerifyCmd.Flags().BoolVar(&dbFlags.json, "json", false, "Print the report as JSON")
+}
+
+// openExistingStore opens the database at --db, which must already exist;
+// store.New would create an empty one.
+func openExistingStore() (*store.Store, error) {
+	if _, err := os.Stat(dbFlags.dbPath); err != nil {
+		return nil, fmt.Errorf("no database at %s: %w", dbFlags.dbPath, err)
+	}
+	masterKey, err := loadMasterKey(dbFlags.masterKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load master key: %w", err)
+	}
+	db, err := store.New(dbFlags.dbPath, masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	db, err := openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if db.SchemaUpgraded() {
+		fmt.Fprintf(cmd.OutOrStdout(), "Upgraded %s to the current schema\n", dbFlags.dbPath)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is already up to date\n", dbFlags.dbPath)
+	}
+	return nil
+}
+
+func runDBVerify(cmd *cobra.Command, args []string) error {
+	db, err := openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	integrity := db.Check()
+	report, err := db.VerifyEncryption()
+	if err != nil {
+		return fmt.Errorf("verify encryption: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if dbFlags.json {
+		result := struct {
+			OK         bool                    `json:"ok"`
+			Integrity  string                  `json:"integrity"`
+			Encryption *store.EncryptionReport `json:"encryption"`
+		}{OK: integrity == nil && report.OK(), Integrity: "ok", Encryption: report}
+		if integrity != nil {
+			result.Integrity = integrity.Error()
+		}
+		if err := printJSON(out, &result); err != nil {
+			return err
+		}
+	} else {
+		if integrity != nil {
+			fmt.Fprintf(out, "Integrity:  %v\n", integrity)
+		} else {
+			fmt.Fprintln(out, "Integrity:  ok")
+		}
+		fmt.Fprintf(out, "Encrypted:  %d values, %d undecryptable\n", report.Checked, len(report.Failures))
+		for _, f := range report.Failures {
+			fmt.Fprintf(out, "  %s %s: %s\n", f.Table, f.Key, f.Error)
+		}
+	}
+
+	if integrity != nil || !report.OK() {
+		return exitError{code: dbVerifyExitProblems, err: fmt.Errorf("database has problems")}
+	}
+	return nil
+}
+
+func runDBCompact(cmd *cobra.Command, args []string) error {
+	db, err := openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	before := dbFileSize(dbFlags.dbPath)
+	if err := db.Compact(); err != nil {
+		return err
+	}
+	after := dbFileSize(dbFlags.dbPath)
+	fmt.Fprintf(cmd.OutOrStdout(), "Compacted %s: %s -> %s\n", dbFlags.dbPath, formatBytes(before), formatBytes(after))
+	return nil
+}
+
+// dbFileSize is the size of the database file and its write-ahead log.
+func dbFileSize(path string) int64 {
+	var size int64
+	for _, p := range []string{path, path + "-wal"} {
+		if fi, err := os.Stat(p); err == nil {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/gateway"
+)
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "List devices pending pairing with, or paired to, the Gateway",
+	Long: `List the Gateway's pending pairing requests and paired devices, through a
+running OCM's admin API and its Gateway RPC connection.
+
+Approve or reject a pending request with 'ocm devices approve' and
+'ocm devices reject', naming it by a unique prefix of its request ID. OCM's
+own device has to be paired before any of this works; 'ocm status' shows the
+command for that.`,
+	Args:          cobra.NoArgs,
+	RunE:          runDevices,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var devicesApproveCmd = &cobra.Command{
+	Use:           "approve <request-id>",
+	Short:         "Approve a pending device pairing request",
+	Args:          cobra.ExactArgs(1),
+	RunE:          runDevicesApprove,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+var devicesRejectCmd = &cobra.Command{
+	Use:           "reject <request-id>",
+	Short:         "Reject a pending device pairing request",
+	Args:          cobra.ExactArgs(1),
+	RunE:          runDevicesReject,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	// Subcommands inherit --admin-url, --token and --json
+	addAdminClientFlags(devicesCmd.PersistentFlags())
+	devicesCmd.AddCommand(devicesApproveCmd)
+	devicesCmd.AddCommand(devicesRejectCmd)
+}
+
+// deviceList is the response of GET /admin/api/v1/devices.
+type deviceList struct {
+	Pending []gateway.PendingDevice `json:"pending"`
+	Paired  []gateway.PairedDevice  `json:"paired"`
+	Error   string                  `json:"error,omitempty"` // The Gateway couldn't be asked
+}
+
+func listDevices(c *adminClient) (*deviceList, error) {
+	var list deviceList
+	if err := c.do(http.MethodGet, "/devices", nil, &list); err != nil {
+		return nil, err
+	}
+	if list.Error != "" {
+		return nil, fmt.Errorf("%s", list.Error)
+	}
+	return &list, nil
+}
+
+func runDevices(cmd *cobra.Command, args []string) error {
+	list, err := listDevices(newAdminClient())
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if adminFlags.json {
+		return printJSON(out, list)
+	}
+
+	if len(list.Pending) == 0 {
+		fmt.Fprintln(out, "No pending pairing requests")
+	} else {
+		fmt.Fprintln(out, "Pending:")
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  REQUEST ID\tDEVICE\tROLE\tAGE\tORIGIN\tUSER AGENT")
+		for _, d := range list.Pending {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n", d.RequestID, shortDeviceID(d.DeviceID), d.Role,
+				deviceAge(d.CreatedAt), firstNonEmpty(d.Origin, "-"), firstNonEmpty(d.UserAgent, "-"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out)
+	if len(list.Paired) == 0 {
+		fmt.Fprintln(out, "No paired devices")
+		return nil
+	}
+	fmt.Fprintln(out, "Paired:")
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  DEVICE\tROLE\tPAIRED")
+	for _, d := range list.Paired {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", d.DeviceID, d.Role, deviceTime(d.CreatedAt))
+	}
+	return tw.Flush()
+}
+
+func runDevicesApprove(cmd *cobra.Command, args []string) error {
+	return decideDevice(cmd, args[0], "approve", "Approved")
+}
+
+func runDevicesReject(cmd *cobra.Command, args []string) error {
+	return decideDevice(cmd, args[0], "reject", "Rejected")
+}
+
+func decideDevice(cmd *cobra.Command, id, action, done string) error {
+	c := newAdminClient()
+	d, err := resolvePendingDevice(c, id)
+	if err != nil {
+		return err
+	}
+	if err := c.do(http.MethodPost, "/devices/"+url.PathEscape(d.RequestID)+"/"+action, nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s pairing %s (device %s, %s)\n", done, d.RequestID, shortDeviceID(d.DeviceID), d.Role)
+	return nil
+}
+
+// resolvePendingDevice finds the pending pairing request whose ID is id or,
+// failing that, the only one whose ID starts with it.
+func resolvePendingDevice(c *adminClient, id string) (*gateway.PendingDevice, error) {
+	list, err := listDevices(c)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*gateway.PendingDevice
+	for i := range list.Pending {
+		d := &list.Pending[i]
+		if d.RequestID == id {
+			return d, nil
+		}
+		if strings.HasPrefix(d.RequestID, id) {
+			matches = append(matches, d)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no pending pairing request %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%s matches %d pending pairing requests; give more of the ID", id, len(matches))
+	}
+}
+
+// shortDeviceID abbreviates a device ID (a hex public key hash) for tables.
+func shortDeviceID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// deviceAge and deviceTime format the Gateway's millisecond timestamps.
+func deviceAge(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.Since(time.UnixMilli(ms)).Truncate(time.Second).String()
+}
+
+func deviceTime(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+)
+
+// Exit codes of `ocm elevate`.
+const (
+	elevateExitRefused = 2 // Denied, expired or revoked
+	elevateExitTimeout = 3 // Still undecided when --timeout ran out
+)
+
+var elevateFlags struct {
+	scope    string
+	reason   string
+	ttl      string
+	id       string
+	wait     bool
+	timeout  time.Duration
+	interval time.Duration
+	json     bool
+}
+
+var elevateCmd = &cobra.Command{
+	Use:   "elevate <service>",
+	Short: "Request elevated access as an agent would, and wait for the decision",
+	Long: `Request elevated access to a service through the agent API, exactly as an
+agent would, and print the outcome. With --wait, poll until the request is
+approved or denied. Use --id to check on (or wait for) an earlier request
+instead of making a new one.
+
+Useful for driving semi-automated workflows by hand and for testing
+approval policies: routing, presets, concurrency limits and auto-approval.
+
+  ocm elevate github --reason "tag v1.4.0" --ttl 15m --wait
+  ocm elevate --id elev_2ee2c0 --wait --timeout 30m
+
+Exit status: 0 if approved (or pending, without --wait), 2 if denied,
+expired or revoked, 3 if --timeout ran out first, 1 on any other error.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runElevate,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	f := elevateCmd.Flags()
+	addAgentClientFlags(f)
+	f.StringVar(&elevateFlags.scope, "scope", "write", "Scope to elevate to")
+	f.StringVar(&elevateFlags.reason, "reason", "", "Reason shown to approvers")
+	f.StringVar(&elevateFlags.ttl, "ttl", "", "Requested duration, e.g. 30m (default: the approver's choice)")
+	f.StringVar(&elevateFlags.id, "id", "", "Check an existing request instead of making one")
+	f.BoolVarP(&elevateFlags.wait, "wait", "w", false, "Wait until the request is approved or denied")
+	f.DurationVar(&elevateFlags.timeout, "timeout", 0, "Give up waiting after this long (default: wait indefinitely)")
+	f.DurationVar(&elevateFlags.interval, "interval", 2*time.Second, "How often to poll while waiting")
+	f.BoolVar(&elevateFlags.json, "json", false, "Print the final status as JSON")
+}
+
+func runElevate(cmd *cobra.Command, args []string) error {
+	if (len(args) == 1) == (elevateFlags.id != "") {
+		return fmt.Errorf("give a service to request, or --id to check an existing request")
+	}
+	if elevateFlags.ttl != "" {
+		if _, err := time.ParseDuration(elevateFlags.ttl); err != nil {
+			return fmt.Errorf("invalid --ttl: %w", err)
+		}
+	}
+	if elevateFlags.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	client := newAgentClient()
+	out := cmd.OutOrStdout()
+	var elev api.ElevationResponse
+	if elevateFlags.id != "" {
+		if err := client.do(http.MethodGet, "/elevate/"+url.PathEscape(elevateFlags.id), nil, &elev); err != nil {
+			return err
+		}
+	} else {
+		req := api.ElevationRequest{
+			Service:      args[0],
+			Scope:        elevateFlags.scope,
+			Reason:       elevateFlags.reason,
+			RequestedTTL: elevateFlags.ttl,
+		}
+		if err := client.do(http.MethodPost, "/elevate", req, &elev); err != nil {
+			return err
+		}
+		if !elevateFlags.json {
+			fmt.Fprintf(out, "Requested %s access to %s: %s\n", req.Scope, req.Service, elev.RequestID)
+		}
+	}
+
+	if elevateFlags.wait && undecided(elev.Status) {
+		if !elevateFlags.json {
+			fmt.Fprintf(out, "Waiting for a decision (%s)...\n", elev.Status)
+		}
+		var deadline <-chan time.Time
+		if elevateFlags.timeout > 0 {
+			deadline = time.After(elevateFlags.timeout)
+		}
+		ticker := time.NewTicker(elevateFlags.interval)
+		defer ticker.Stop()
+	poll:
+		for undecided(elev.Status) {
+			select {
+			case <-deadline:
+				break poll
+			case <-ticker.C:
+			}
+			var next api.ElevationResponse
+			if err := client.do(http.MethodGet, "/elevate/"+url.PathEscape(elev.RequestID), nil, &next); err != nil {
+				return err
+			}
+			elev = next
+		}
+	}
+
+	if elevateFlags.json {
+		if err := printJSON(out, &elev); err != nil {
+			return err
+		}
+	} else {
+		printElevationOutcome(cmd, &elev)
+	}
+	switch {
+	case elev.Status == "approved":
+		return nil
+	case undecided(elev.Status):
+		if elevateFlags.wait {
+			return exitError{code: elevateExitTimeout, err: fmt.Errorf("no decision on %s after %s", elev.RequestID, elevateFlags.timeout)}
+		}
+		return nil
+	default:
+		return exitError{code: elevateExitRefused}
+	}
+}
+
+// undecided reports whether an elevation with status is still waiting for
+// an approver.
+func undecided(status string) bool {
+	return status == "pending" || status == "queued"
+}
+
+func printElevationOutcome(cmd *cobra.Command, elev *api.ElevationResponse) {
+	out := cmd.OutOrStdout()
+	switch {
+	case elev.Status == "approved" && elev.ExpiresAt != nil:
+		fmt.Fprintf(out, "%s: approved until %s (%s left)\n", elev.RequestID,
+			elev.ExpiresAt.Local().Format("15:04:05"), time.Until(*elev.ExpiresAt).Round(time.Second))
+	case elev.Status == "queued":
+		fmt.Fprintf(out, "%s: queued behind the concurrent elevation limit\n", elev.RequestID)
+	default:
+		fmt.Fprintf(out, "%s: %s\n", elev.RequestID, elev.Status)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmd
+
+import "context"
+
+// There is no SIGUSR1 outside unix; use the admin API to change the level.
+func toggleDebugOnSignal(ctx context.Context) {}
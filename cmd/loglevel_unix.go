@@ -0,0 +1,30 @@
+//go:build unix
+
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/openclaw/ocm/internal/logging"
+)
+
+// toggleDebugOnSignal switches between debug and the configured log level
+// on each SIGUSR1 until ctx is done.
+func toggleDebugOnSignal(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1)
+	defer signal.Stop(sigCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			level := logging.Toggle()
+			slog.Info("log level changed", "to", logging.Name(level), "signal", "SIGUSR1")
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -21,8 +22,29 @@ It stores credentials outside the agent's process and requires human approval
 for sensitive operations, injecting credentials via environment variables.`,
 }
 
+// exitError makes Execute exit with code rather than 1. With a nil err
+// nothing is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exit exitError
+		if errors.As(err, &exit) {
+			if exit.err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(exit.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -32,4 +54,19 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(keygenCmd)
+	rootCmd.AddCommand(selftestCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(credentialCmd)
+	rootCmd.AddCommand(requestsCmd)
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(denyCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(rotateKeyCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(elevateCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(devicesCmd)
+	rootCmd.AddCommand(tuiCmd)
 }
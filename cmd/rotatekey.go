@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+var rotateKeyFlags struct {
+	dbPath        string
+	masterKeyFile string
+	newKeyFile    string
+	backupPath    string
+}
+
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Re-encrypt the database with a new master key",
+	Long: `Re-encrypt everything stored under the master key (credentials, webhook
+secrets, queued gateway operations and the receipt signing key) with a new
+key, and re-hash the audit chain with it. Stop 'ocm serve' first.
+
+A copy of the database under the old key is written before anything
+changes, to --backup or <db>.<timestamp>.bak. The rotation runs in one
+transaction, so an interrupted run leaves the database on the old key. It
+refuses to run if the audit chain doesn't verify, since re-hashing would
+hide the damage. Outstanding guest invite links stop working.
+
+  ocm keygen -o /data/master.key.new
+  ocm rotate-key --db /data/ocm.db --master-key-file /data/master.key --new-key-file /data/master.key.new
+  mv /data/master.key.new /data/master.key`,
+	Args:          cobra.NoArgs,
+	RunE:          runRotateKey,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	f := rotateKeyCmd.Flags()
+	f.StringVar(&rotateKeyFlags.dbPath, "db", "ocm.db", "Database path")
+	f.StringVar(&rotateKeyFlags.masterKeyFile, "master-key-file", "", "Path to the current master key file (default: "+defaultMasterKeyPath+", or set OCM_MASTER_KEY env)")
+	f.StringVar(&rotateKeyFlags.newKeyFile, "new-key-file", "", "Path to the new master key file, e.g. from 'ocm keygen -o' (required)")
+	f.StringVar(&rotateKeyFlags.backupPath, "backup", "", "Where to write the copy of the database under the old key (default: <db>.<timestamp>.bak)")
+	rotateKeyCmd.MarkFlagRequired("new-key-file")
+}
+
+func runRotateKey(cmd *cobra.Command, args []string) error {
+	oldKey, err := loadMasterKey(rotateKeyFlags.masterKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load current master key: %w", err)
+	}
+	newKey, err := readKeyFile(rotateKeyFlags.newKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load new master key: %w", err)
+	}
+	if bytes.Equal(oldKey, newKey) {
+		return fmt.Errorf("the new key is the same as the current one")
+	}
+
+	db, err := store.New(rotateKeyFlags.dbPath, oldKey)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	// Fail on a wrong current key before writing anything
+	if _, err := db.ExportSnapshot(); err != nil {
+		return fmt.Errorf("can't read the database with the current key: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	backupPath := rotateKeyFlags.backupPath
+	if backupPath == "" {
+		backupPath = fmt.Sprintf("%s.%s.bak", rotateKeyFlags.dbPath, time.Now().Format("20060102-150405"))
+	}
+	fmt.Fprintf(out, "Backing up the database to %s\n", backupPath)
+	if err := db.BackupTo(backupPath); err != nil {
+		return fmt.Errorf("backup failed, nothing was changed: %w", err)
+	}
+
+	last := ""
+	err = db.Rekey(newKey, func(p store.RekeyProgress) {
+		if p.Stage != last && last != "" {
+			fmt.Fprintln(out)
+		}
+		last = p.Stage
+		fmt.Fprintf(out, "\rRe-encrypting %s: %d/%d", p.Stage, p.Done, p.Total)
+	})
+	if last != "" {
+		fmt.Fprintln(out)
+	}
+	if err != nil {
+		return fmt.Errorf("rotation failed, the database is still on the current key: %w", err)
+	}
+
+	fmt.Fprintf(out, "Done. Start OCM with the new key (--master-key-file %s or OCM_MASTER_KEY).\n", rotateKeyFlags.newKeyFile)
+	fmt.Fprintf(out, "%s still opens with the old key; keep both until you have checked the result.\n", backupPath)
+	return nil
+}
+
+// readKeyFile reads a 32-byte key, raw or as 64 hex characters, from path.
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	key, err := hexDecode(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be 32 bytes or 64 hex characters", path)
+	}
+	return key, nil
+}
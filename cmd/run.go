@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+)
+
+var runFlags struct {
+	services []string
+	purpose  string
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- <command> [args...]",
+	Short: "Run a command with read credentials in its environment",
+	Long: `Fetch read credentials from the agent API and run a command with them set
+as environment variables, under the names the credentials are configured
+with. The values are only passed to the child process; nothing is written
+to disk. Each fetch is audited as a credential access.
+
+By default every service with read access is injected; use --service to
+pick some. Credentials injected into the OpenClaw config rather than the
+environment are skipped. The command's exit status is passed through.
+
+  ocm run -- ./scripts/sync-issues.sh
+  ocm run -s github -s linear --purpose "weekly report" -- python report.py`,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          runRun,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	f := runCmd.Flags()
+	addAgentClientFlags(f)
+	f.StringArrayVarP(&runFlags.services, "service", "s", nil, "Service to inject (repeatable; default: all with read access)")
+	f.StringVar(&runFlags.purpose, "purpose", "", `Purpose recorded in the audit log (default: "ocm run <command>")`)
+	// Flags after the command belong to it
+	f.SetInterspersed(false)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	client := newAgentClient()
+	// Don't fetch anything for a command that can't run
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	purpose := runFlags.purpose
+	if purpose == "" {
+		purpose = "ocm run " + filepath.Base(args[0])
+	}
+
+	services, err := client.readServices(runFlags.services)
+	if err != nil {
+		return err
+	}
+	inject := make(map[string]string)
+	for _, service := range services {
+		var cred api.CredentialResponse
+		if err := client.do(http.MethodGet, "/credentials/"+url.PathEscape(service)+"/read?purpose="+url.QueryEscape(purpose), nil, &cred); err != nil {
+			return fmt.Errorf("%s: %w", service, err)
+		}
+		if cred.EnvVar == "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "ocm run: skipping %s: not injected as an environment variable\n", service)
+			continue
+		}
+		inject[cred.EnvVar] = cred.Token
+		for name, value := range cred.AdditionalEnv {
+			inject[name] = value
+		}
+	}
+
+	child := exec.Command(path, args[1:]...)
+	child.Env = mergeEnv(os.Environ(), inject)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	// The child gets terminal signals itself; pass on the ones sent to us
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	go func() {
+		for sig := range sigs {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		code := exit.ExitCode()
+		if code < 0 { // Killed by a signal
+			code = 128
+			if ws, ok := exit.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+				code += int(ws.Signal())
+			}
+		}
+		return exitError{code: code}
+	}
+	return err
+}
+
+// mergeEnv returns env with the variables in set added, replacing any
+// already there.
+func mergeEnv(env []string, set map[string]string) []string {
+	out := make([]string, 0, len(env)+len(set))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := set[name]; !ok {
+			out = append(out, kv)
+		}
+	}
+	for name, value := range set {
+		out = append(out, name+"="+value)
+	}
+	return out
+}
+
+// readServices returns the services in want, or all services if want is
+// empty, that have read access.
+func (c *agentClient) readServices(want []string) ([]string, error) {
+	var scopes api.ScopesResponse
+	if err := c.do(http.MethodGet, "/scopes", nil, &scopes); err != nil {
+		return nil, err
+	}
+	readable := make(map[string]bool)
+	var all []string
+	for _, svc := range scopes.Services {
+		for _, scope := range svc.Scopes {
+			if scope == "read" {
+				readable[svc.ID] = true
+				all = append(all, svc.ID)
+			}
+		}
+	}
+	if len(want) == 0 {
+		return all, nil
+	}
+	for _, service := range want {
+		if !readable[service] {
+			return nil, fmt.Errorf("%s: no such service, or it has no read access", service)
+		}
+	}
+	return want, nil
+}
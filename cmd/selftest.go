@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+	"github.com/openclaw/ocm/internal/audit"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+var selftestFlags struct {
+	ttl     time.Duration
+	keep    bool
+	verbose bool
+}
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Run an end-to-end sanity check against a throwaway instance",
+	Long: `Start OCM in-process against a temporary database and a fake Gateway
+(a temporary .env file, no RPC), then walk through the full credential
+lifecycle:
+
+  create credential → read → agent elevates → approve → fetch write token
+  → elevation expires → verify the write token was removed
+
+Each step is reported as PASS or FAIL. The exit status is non-zero if any step
+fails, so it can be used as a post-upgrade check. Nothing outside the
+temporary directory is touched.`,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+	RunE:          runSelftest,
+}
+
+func init() {
+	selftestCmd.Flags().DurationVar(&selftestFlags.ttl, "ttl", 2*time.Second, "Elevation TTL used for the expiry step")
+	selftestCmd.Flags().BoolVar(&selftestFlags.keep, "keep", false, "Keep the temporary directory for inspection")
+	selftestCmd.Flags().BoolVarP(&selftestFlags.verbose, "verbose", "v", false, "Show server logs")
+}
+
+const (
+	selftestService    = "selftest"
+	selftestReadVar    = "OCM_SELFTEST_TOKEN"
+	selftestWriteVar   = "OCM_SELFTEST_WRITE_TOKEN"
+	selftestReadToken  = "selftest-read-token"
+	selftestWriteToken = "selftest-write-token"
+)
+
+// selftest holds the in-process instance under test.
+type selftest struct {
+	agentURL string
+	adminURL string
+	envPath  string
+	client   *http.Client
+	out      io.Writer
+
+	elevationID string
+	failed      int
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+
+	dir, err := os.MkdirTemp("", "ocm-selftest-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	if selftestFlags.keep {
+		fmt.Fprintf(out, "temporary directory: %s\n", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	logOut := io.Discard
+	if selftestFlags.verbose {
+		logOut = os.Stderr
+	}
+	logger := slog.New(slog.NewTextHandler(logOut, nil))
+
+	// Throwaway master key - nothing here outlives the run
+	masterKey := make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+
+	db, err := store.New(filepath.Join(dir, "ocm.db"), masterKey)
+	if err != nil {
+		return fmt.Errorf("initialize store: %w", err)
+	}
+	defer db.Close()
+
+	auditBroker := audit.NewBroker(db, logger)
+	if err := auditBroker.Load(); err != nil {
+		return fmt.Errorf("initialize audit devices: %w", err)
+	}
+	defer auditBroker.Close()
+	db.SetAuditSink(auditBroker)
+
+	// Fake gateway: a private .env file and no RPC client, so restarts are skipped
+	envPath := filepath.Join(dir, ".env")
+	gwClient := gateway.NewClient("", envPath, nil, logger)
+	elevSvc := elevation.NewService(db, gwClient, logger)
+
+	agentServer := httptest.NewServer(api.NewAgentRouter(db, nil, logger))
+	defer agentServer.Close()
+	adminServer := httptest.NewServer(api.NewAdminRouter(db, elevSvc, nil, auditBroker, nil, logger))
+	defer adminServer.Close()
+
+	t := &selftest{
+		agentURL: agentServer.URL,
+		adminURL: adminServer.URL,
+		envPath:  envPath,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		out:      out,
+	}
+
+	start := time.Now()
+	fmt.Fprintf(out, "ocm selftest (%s)\n", Version)
+	t.step("create credential", t.createCredential)
+	t.step("read access without elevation", t.readAccess)
+	t.step("write access denied before elevation", t.writeDenied)
+	t.step("agent requests elevation", t.requestElevation)
+	t.step("admin approves elevation", t.approve)
+	t.step("write token injected into gateway env", t.injected)
+	t.step("agent fetches write token", t.writeAccess)
+	t.step("elevation expires", t.expire)
+	t.step("write token removed after expiry", t.cleanedUp)
+	t.step("audit trail recorded", t.auditTrail)
+
+	if t.failed > 0 {
+		return fmt.Errorf("selftest failed: %d step(s) failed", t.failed)
+	}
+	fmt.Fprintf(out, "selftest passed in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// step runs fn and reports its outcome. Steps after a failure are skipped,
+// since each depends on the state the previous one left behind.
+func (t *selftest) step(name string, fn func() error) {
+	if t.failed > 0 {
+		fmt.Fprintf(t.out, "  SKIP  %s\n", name)
+		return
+	}
+	start := time.Now()
+	if err := fn(); err != nil {
+		t.failed++
+		fmt.Fprintf(t.out, "  FAIL  %s: %v\n", name, err)
+		return
+	}
+	fmt.Fprintf(t.out, "  PASS  %s (%s)\n", name, time.Since(start).Round(time.Millisecond))
+}
+
+func (t *selftest) createCredential() error {
+	body := api.CreateCredentialRequest{
+		Service:     selftestService,
+		DisplayName: "OCM Selftest",
+		Type:        "api_key",
+		Read:        &api.AccessLevelConfig{EnvVar: selftestReadVar, Token: selftestReadToken},
+		ReadWrite:   &api.AccessLevelConfig{EnvVar: selftestWriteVar, Token: selftestWriteToken, MaxTTL: "1h"},
+	}
+	return t.do(http.MethodPost, t.adminURL+"/admin/api/v1/credentials", body, http.StatusCreated, nil)
+}
+
+func (t *selftest) readAccess() error {
+	var resp api.CredentialResponse
+	if err := t.do(http.MethodGet, t.agentURL+"/api/v1/credentials/"+selftestService+"/read", nil, http.StatusOK, &resp); err != nil {
+		return err
+	}
+	if resp.Token != selftestReadToken {
+		return fmt.Errorf("got token %q, want the read token", resp.Token)
+	}
+	return nil
+}
+
+func (t *selftest) writeDenied() error {
+	return t.do(http.MethodGet, t.agentURL+"/api/v1/credentials/"+selftestService+"/write", nil, http.StatusForbidden, nil)
+}
+
+func (t *selftest) requestElevation() error {
+	body := api.ElevationRequest{Service: selftestService, Scope: "write", Reason: "ocm selftest"}
+	var resp api.ElevationResponse
+	if err := t.do(http.MethodPost, t.agentURL+"/api/v1/elevate", body, http.StatusOK, &resp); err != nil {
+		return err
+	}
+	if resp.Status != "pending" || resp.RequestID == "" {
+		return fmt.Errorf("got status %q (id %q), want a pending request", resp.Status, resp.RequestID)
+	}
+	t.elevationID = resp.RequestID
+	return nil
+}
+
+func (t *selftest) approve() error {
+	body := api.ApproveRequest{TTL: selftestFlags.ttl.String()}
+	if err := t.do(http.MethodPost, t.adminURL+"/admin/api/v1/requests/"+t.elevationID+"/approve", body, http.StatusOK, nil); err != nil {
+		return err
+	}
+	status, err := t.elevationStatus()
+	if err != nil {
+		return err
+	}
+	if status != "approved" {
+		return fmt.Errorf("elevation status %q, want approved", status)
+	}
+	return nil
+}
+
+func (t *selftest) injected() error {
+	env, err := os.ReadFile(t.envPath)
+	if err != nil {
+		return fmt.Errorf("read env file: %w", err)
+	}
+	if !strings.Contains(string(env), selftestWriteVar) {
+		return fmt.Errorf("%s not found in %s", selftestWriteVar, t.envPath)
+	}
+	return nil
+}
+
+func (t *selftest) writeAccess() error {
+	var resp api.CredentialResponse
+	if err := t.do(http.MethodGet, t.agentURL+"/api/v1/credentials/"+selftestService+"/write", nil, http.StatusOK, &resp); err != nil {
+		return err
+	}
+	if resp.Token != selftestWriteToken {
+		return fmt.Errorf("got token %q, want the write token", resp.Token)
+	}
+	return nil
+}
+
+func (t *selftest) expire() error {
+	ctx, cancel := context.WithTimeout(context.Background(), selftestFlags.ttl+10*time.Second)
+	defer cancel()
+
+	for {
+		status, err := t.elevationStatus()
+		if err != nil {
+			return err
+		}
+		if status == "expired" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("still %q after waiting past the TTL", status)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func (t *selftest) cleanedUp() error {
+	env, err := os.ReadFile(t.envPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read env file: %w", err)
+	}
+	if strings.Contains(string(env), selftestWriteToken) {
+		return fmt.Errorf("write token still present in %s", t.envPath)
+	}
+	return t.do(http.MethodGet, t.agentURL+"/api/v1/credentials/"+selftestService+"/write", nil, http.StatusForbidden, nil)
+}
+
+func (t *selftest) auditTrail() error {
+	var entries []store.AuditEntry
+	if err := t.do(http.MethodGet, t.adminURL+"/admin/api/v1/audit?service="+selftestService, nil, http.StatusOK, &entries); err != nil {
+		return err
+	}
+	seen := make(map[store.AuditAction]bool)
+	for _, e := range entries {
+		seen[e.Action] = true
+	}
+	for _, action := range []store.AuditAction{store.ActionCredentialAccess, store.ActionElevationRequested, store.ActionElevationApproved, store.ActionElevationExpired} {
+		if !seen[action] {
+			return fmt.Errorf("no %s audit entry", action)
+		}
+	}
+	return nil
+}
+
+func (t *selftest) elevationStatus() (string, error) {
+	var resp api.ElevationResponse
+	if err := t.do(http.MethodGet, t.agentURL+"/api/v1/elevate/"+t.elevationID, nil, http.StatusOK, &resp); err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// do sends a JSON request and checks the response status, decoding the body into out if set.
+func (t *selftest) do(method, url string, body interface{}, wantStatus int, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != wantStatus {
+		return fmt.Errorf("%s %s: status %d, want %d: %s", method, req.URL.Path, resp.StatusCode, wantStatus, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -2,20 +2,30 @@ package cmd
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/openclaw/ocm/internal/api"
+	"github.com/openclaw/ocm/internal/audit"
 	"github.com/openclaw/ocm/internal/elevation"
 	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/logging"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/rotate"
+	"github.com/openclaw/ocm/internal/sentry"
 	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/tracing"
 )
 
 const defaultMasterKeyPath = "~/.ocm/master.key"
@@ -27,6 +37,51 @@ var serveFlags struct {
 	masterKeyFile string
 	gatewayURL    string
 	envFile       string
+	gatewaysFile  string
+	gatewayTLS    gateway.TLSOptions
+	cacheTTL      time.Duration
+	slackChannel  string
+	slackTTL      time.Duration
+	smtpAddr      string
+	smtpFrom      string
+	smtpTo        []string
+	expiryWarning time.Duration
+	reconcile     time.Duration
+	rotationCmds  bool
+	blockDupes    bool
+	healthCheck   time.Duration
+	leakScan      time.Duration
+	leakScanLogs  bool
+	auditDays     int
+	auditArchive  string
+	auditS3       audit.S3Config
+	auditS3URL    string
+	auditSegment  time.Duration
+	receiptKey    string
+	ntfyURL       string
+	telegramChat  int64
+	telegramTTL   time.Duration
+	discordChan   string
+	discordKey    string
+	discordTTL    time.Duration
+	restartAlert  int
+	restartWindow time.Duration
+	restartCtr    string
+	restartCmd    string
+	dockerHost    string
+	secretsDir    string
+	matrixHS      string
+	matrixRoom    string
+	matrixEvents  []string
+	digest        string
+	digestHour    int
+	staleDays     int
+	logLevel      string
+	sentryDSN     string
+	sentryEnv     string
+	corsOrigins   []string
+	callbackAllow []string
+	otlpEndpoint  string
 }
 
 var serveCmd = &cobra.Command{
@@ -43,15 +98,141 @@ func init() {
 	serveCmd.Flags().StringVar(&serveFlags.masterKeyFile, "master-key-file", "", "Path to master key file (default: "+defaultMasterKeyPath+", or set OCM_MASTER_KEY env)")
 	serveCmd.Flags().StringVar(&serveFlags.gatewayURL, "gateway-url", "http://localhost:18789", "OpenClaw Gateway RPC URL")
 	serveCmd.Flags().StringVar(&serveFlags.envFile, "env-file", "", "Path to .env file for credential injection (default: ~/.openclaw/.env)")
+	serveCmd.Flags().StringVar(&serveFlags.gatewayTLS.CAFile, "gateway-ca-file", "", "PEM CA bundle to trust for a wss:// (https://) Gateway URL, in addition to the system roots")
+	serveCmd.Flags().StringVar(&serveFlags.gatewayTLS.CertFile, "gateway-client-cert", "", "PEM client certificate presented to the Gateway (requires --gateway-client-key)")
+	serveCmd.Flags().StringVar(&serveFlags.gatewayTLS.KeyFile, "gateway-client-key", "", "PEM private key for --gateway-client-cert")
+	serveCmd.Flags().BoolVar(&serveFlags.gatewayTLS.InsecureSkipVerify, "gateway-insecure-skip-verify", false, "Don't verify the Gateway's TLS certificate (testing only)")
+	serveCmd.Flags().StringVar(&serveFlags.secretsDir, "secrets-dir", "", "Write each env credential to its own file in this directory (use a tmpfs, e.g., /run/ocm/creds) and put <VAR>_FILE references in the .env instead of values")
+	serveCmd.Flags().StringVar(&serveFlags.gatewaysFile, "gateways-file", "", "JSON file listing additional OpenClaw Gateways (name, url, tokenEnv, envFile) that credentials can target")
+	serveCmd.Flags().DurationVar(&serveFlags.cacheTTL, "cache-ttl", store.DefaultCacheTTL, "TTL for cached credential metadata and elevation lookups (0 disables)")
+	serveCmd.Flags().StringVar(&serveFlags.slackChannel, "slack-channel", "", "Slack channel for elevation notifications (requires OCM_SLACK_BOT_TOKEN and OCM_SLACK_SIGNING_SECRET)")
+	serveCmd.Flags().DurationVar(&serveFlags.slackTTL, "slack-approve-ttl", 30*time.Minute, "TTL granted by the Slack Approve button")
+	serveCmd.Flags().StringVar(&serveFlags.smtpAddr, "smtp-addr", "", "SMTP server host:port for email notifications (auth via OCM_SMTP_USERNAME/OCM_SMTP_PASSWORD)")
+	serveCmd.Flags().StringVar(&serveFlags.smtpFrom, "smtp-from", "", "From address for email notifications")
+	serveCmd.Flags().StringSliceVar(&serveFlags.smtpTo, "smtp-to", nil, "Default recipients for email notifications")
+	serveCmd.Flags().Int64Var(&serveFlags.telegramChat, "telegram-chat-id", 0, "Telegram admin chat for elevation notifications and /approve, /deny commands (requires OCM_TELEGRAM_BOT_TOKEN)")
+	serveCmd.Flags().DurationVar(&serveFlags.telegramTTL, "telegram-approve-ttl", 30*time.Minute, "TTL granted by /approve when none is given")
+	serveCmd.Flags().StringVar(&serveFlags.discordChan, "discord-channel", "", "Discord channel ID for interactive approvals (requires OCM_DISCORD_BOT_TOKEN and --discord-public-key); set OCM_DISCORD_WEBHOOK_URL instead for plain notifications")
+	serveCmd.Flags().StringVar(&serveFlags.discordKey, "discord-public-key", "", "Discord application public key (hex) for verifying button clicks")
+	serveCmd.Flags().DurationVar(&serveFlags.discordTTL, "discord-approve-ttl", 30*time.Minute, "TTL granted by the Discord Approve button")
+	serveCmd.Flags().DurationVar(&serveFlags.restartWindow, "restart-debounce", 3*time.Second, "Batch Gateway config patches and restarts that arrive within this window into a single restart (0 applies each immediately)")
+	serveCmd.Flags().StringVar(&serveFlags.restartCtr, "restart-container", "", "Docker container to restart when the Gateway can't restart itself (restart disabled or config file locked)")
+	serveCmd.Flags().StringVar(&serveFlags.restartCmd, "restart-command", "", "Shell command that restarts OpenClaw when the Gateway can't restart itself, e.g., \"systemctl restart openclaw\"")
+	serveCmd.Flags().StringVar(&serveFlags.dockerHost, "docker-host", "", "Docker API address for --restart-container (default: $DOCKER_HOST or "+gateway.DefaultDockerHost+")")
+	serveCmd.Flags().IntVar(&serveFlags.restartAlert, "restart-failure-alert", 3, "Raise gateway.restart_failed after this many consecutive failed Gateway restarts")
+	serveCmd.Flags().StringVar(&serveFlags.matrixHS, "matrix-homeserver", "", "Matrix homeserver URL for notifications (requires --matrix-room and OCM_MATRIX_ACCESS_TOKEN)")
+	serveCmd.Flags().StringVar(&serveFlags.matrixRoom, "matrix-room", "", "Matrix room ID to post to, e.g., !abc123:example.org")
+	serveCmd.Flags().StringSliceVar(&serveFlags.matrixEvents, "matrix-events", notify.DefaultMatrixEvents, "Event filters posted to Matrix")
+	serveCmd.Flags().StringVar(&serveFlags.digest, "digest", "", "Send an activity digest by email/webhook: daily or weekly (Mondays)")
+	serveCmd.Flags().IntVar(&serveFlags.digestHour, "digest-hour", 8, "Local hour (0-23) at which digests are sent")
+	serveCmd.Flags().IntVar(&serveFlags.staleDays, "stale-report-days", 0, "Send a weekly report (Mondays, at --digest-hour) of credentials not accessed or elevated in this many days (0 disables)")
+	serveCmd.Flags().StringVar(&serveFlags.ntfyURL, "ntfy-url", "", "ntfy topic URL for push notifications, e.g., https://ntfy.sh/my-topic (token via OCM_NTFY_TOKEN)")
+	serveCmd.Flags().DurationVar(&serveFlags.expiryWarning, "credential-expiry-warning", 72*time.Hour, "Notify and flag on the dashboard when a credential token expires within this window (0 disables notifications)")
+	serveCmd.Flags().BoolVar(&serveFlags.rotationCmds, "allow-rotation-commands", false, "Let credentials rotate through a command run on this host; anyone with admin API access can then run commands as OCM")
+	serveCmd.Flags().BoolVar(&serveFlags.blockDupes, "block-duplicate-secrets", false, "Refuse to save a credential whose token is already stored under another service, instead of warning")
+	serveCmd.Flags().DurationVar(&serveFlags.healthCheck, "credential-check-interval", 6*time.Hour, "Check stored credentials against their providers at this interval and alert when one is rejected (0 disables)")
+	serveCmd.Flags().DurationVar(&serveFlags.leakScan, "leak-scan-interval", time.Hour, "Scan gateway configs for stored secrets outside their injection points at this interval and alert on leaks (0 disables)")
+	serveCmd.Flags().BoolVar(&serveFlags.leakScanLogs, "leak-scan-logs", false, "Also scan each gateway's recent log lines for stored secrets (needs logs.tail)")
+	serveCmd.Flags().DurationVar(&serveFlags.reconcile, "reconcile-interval", 5*time.Minute, "Repair drift between stored credentials and what is injected into the Gateway at this interval (0 disables)")
+	serveCmd.Flags().IntVar(&serveFlags.auditDays, "audit-retention-days", 0, "Delete audit log entries older than this many days (0 keeps them forever)")
+	serveCmd.Flags().StringVar(&serveFlags.auditArchive, "audit-archive-dir", "", "Archive pruned audit entries to gzipped JSONL files in this directory before deleting them")
+	serveCmd.Flags().StringVar(&serveFlags.auditS3URL, "audit-s3-url", "", "Upload sealed audit log segments to this S3-compatible bucket, e.g., s3://bucket/ocm/audit (keys via OCM_S3_ACCESS_KEY_ID/OCM_S3_SECRET_ACCESS_KEY)")
+	serveCmd.Flags().StringVar(&serveFlags.auditS3.Endpoint, "audit-s3-endpoint", "", "S3 API endpoint for --audit-s3-url, e.g., https://storage.googleapis.com or http://minio:9000 (default: AWS S3 in --audit-s3-region)")
+	serveCmd.Flags().StringVar(&serveFlags.auditS3.Region, "audit-s3-region", "us-east-1", "Signing region for --audit-s3-url")
+	serveCmd.Flags().StringVar(&serveFlags.auditS3.ServerSideEncryption, "audit-s3-sse", "AES256", "Server-side encryption for archived segments: AES256, aws:kms, or empty for the bucket default (required for GCS)")
+	serveCmd.Flags().StringVar(&serveFlags.auditS3.KMSKeyID, "audit-s3-kms-key", "", "KMS key ID for --audit-s3-sse aws:kms")
+	serveCmd.Flags().DurationVar(&serveFlags.auditSegment, "audit-s3-segment", time.Hour, "Length of each archived audit segment")
+	serveCmd.Flags().StringVar(&serveFlags.receiptKey, "receipt-key-file", "", "Ed25519 seed (32 bytes or 64 hex characters) to sign approval receipts with, e.g. the Gateway device key (default: a key generated and kept in the database)")
+	serveCmd.Flags().StringSliceVar(&serveFlags.corsOrigins, "cors-origin", nil, "Origin allowed to call the admin API from a browser, e.g., http://localhost:5173 for the web UI dev server (repeatable); other cross-origin writes are refused")
+	serveCmd.Flags().StringSliceVar(&serveFlags.callbackAllow, "agent-callback-allow", nil, "URL prefix agents may ask to be called back on when an elevation is decided, e.g., https://agent.internal/ocm/ (repeatable; callbacks are signed with OCM_AGENT_CALLBACK_SECRET)")
+	serveCmd.Flags().StringVar(&serveFlags.otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "Export traces to this OTLP/HTTP collector, e.g., http://otel-collector:4318 (or set OTEL_EXPORTER_OTLP_ENDPOINT; headers from OTEL_EXPORTER_OTLP_HEADERS)")
+	serveCmd.Flags().StringVar(&serveFlags.sentryDSN, "sentry-dsn", os.Getenv("SENTRY_DSN"), "Report errors and panics to this Sentry or GlitchTip DSN (or set SENTRY_DSN); events are scrubbed of secrets")
+	serveCmd.Flags().StringVar(&serveFlags.sentryEnv, "sentry-environment", os.Getenv("SENTRY_ENVIRONMENT"), "Environment to tag reported errors with, e.g., production (or set SENTRY_ENVIRONMENT)")
+	serveCmd.Flags().StringVar(&serveFlags.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
 	// Logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	level, err := logging.Parse(serveFlags.logLevel)
+	if err != nil {
+		return fmt.Errorf("--log-level: %w", err)
+	}
+	// The level can be changed at runtime through the admin API or SIGUSR1
+	logging.Configure(level)
+	// Every record passes through the redactor, so a secret never reaches
+	// stdout whatever the level; secrets from the environment are known
+	// up front, credential and webhook secrets as the store loads them.
+	// Errors are also sent to the error tracker, if one is configured
+	redact.RegisterEnv()
+	logger := slog.New(redact.NewHandler(sentry.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logging.Leveler(),
+	}))))
 	slog.SetDefault(logger)
 
+	// Tracing
+	if serveFlags.otlpEndpoint != "" {
+		headers, err := tracing.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+		if err != nil {
+			return fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+		}
+		for _, v := range headers {
+			redact.Register(v)
+		}
+		tracer, err := tracing.New(tracing.Config{
+			Endpoint:    serveFlags.otlpEndpoint,
+			Headers:     headers,
+			ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("--otlp-endpoint: %w", err)
+		}
+		tracing.SetDefault(tracer)
+		// Runs past shutdown so the final flushes are exported too
+		traceCtx, stopTracing := context.WithCancel(context.Background())
+		exported := make(chan struct{})
+		go func() {
+			tracer.Run(traceCtx)
+			close(exported)
+		}()
+		defer func() {
+			stopTracing()
+			<-exported
+		}()
+		slog.Info("tracing enabled", "endpoint", serveFlags.otlpEndpoint)
+	}
+
+	// Error reporting
+	if serveFlags.sentryDSN != "" {
+		redact.Register(serveFlags.sentryDSN)
+		reporter, err := sentry.New(sentry.Config{
+			DSN:         serveFlags.sentryDSN,
+			Environment: serveFlags.sentryEnv,
+			Release:     "ocm@" + Version,
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("--sentry-dsn: %w", err)
+		}
+		sentry.SetDefault(reporter)
+		// Like tracing, runs past shutdown so late errors are sent
+		reportCtx, stopReporting := context.WithCancel(context.Background())
+		reported := make(chan struct{})
+		go func() {
+			reporter.Run(reportCtx)
+			close(reported)
+		}()
+		defer func() {
+			stopReporting()
+			<-reported
+		}()
+		slog.Info("error reporting enabled", "environment", serveFlags.sentryEnv)
+	}
+
+	// Cross-origin policy for the admin server
+	crossOrigin, err := api.CrossOrigin(serveFlags.corsOrigins, logger)
+	if err != nil {
+		return fmt.Errorf("--cors-origin: %w", err)
+	}
+
 	// Master key
 	masterKey, err := loadMasterKey(serveFlags.masterKeyFile)
 	if err != nil {
@@ -64,12 +245,28 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
 	defer db.Close()
+	db.SetCacheTTL(serveFlags.cacheTTL)
+
+	// Route audit entries through the configured audit devices
+	auditBroker := audit.NewBroker(db, logger)
+	if err := auditBroker.Load(); err != nil {
+		return fmt.Errorf("failed to initialize audit devices: %w", err)
+	}
+	defer auditBroker.Close()
+	db.SetAuditSink(auditBroker)
 
 	// Initialize RPC client (for device pairing and gateway restart)
+	gatewayTLS, err := serveFlags.gatewayTLS.Config()
+	if err != nil {
+		return fmt.Errorf("gateway TLS: %w", err)
+	}
+	if serveFlags.gatewayTLS.InsecureSkipVerify {
+		slog.Warn("gateway TLS certificate verification disabled")
+	}
 	gatewayToken := os.Getenv("OPENCLAW_GATEWAY_TOKEN")
 	var rpcClient *gateway.RPCClient
 	if gatewayToken != "" {
-		rpcClient = gateway.NewRPCClient(serveFlags.gatewayURL, gatewayToken)
+		rpcClient = gateway.NewRPCClient(serveFlags.gatewayURL, gatewayToken, gatewayTLS, logger)
 		slog.Info("gateway RPC client configured", "url", serveFlags.gatewayURL)
 	} else {
 		slog.Warn("OPENCLAW_GATEWAY_TOKEN not set - device pairing and gateway restart disabled")
@@ -78,13 +275,253 @@ func runServe(cmd *cobra.Command, args []string) error {
 	// Initialize gateway client (for env file management + restart via RPC)
 	gwClient := gateway.NewClient(serveFlags.gatewayURL, serveFlags.envFile, rpcClient, logger)
 	slog.Info("gateway client configured", "url", serveFlags.gatewayURL, "envFile", gwClient.EnvFilePath)
+	if err := gwClient.SetSecretsDir(serveFlags.secretsDir); err != nil {
+		return err
+	}
+	restarter, err := gateway.NewRestarter(serveFlags.restartCtr, serveFlags.restartCmd, serveFlags.dockerHost)
+	if err != nil {
+		return fmt.Errorf("restart fallback: %w", err)
+	}
+	if restarter != nil {
+		gwClient.SetRestartFallback(restarter)
+		slog.Info("gateway restart fallback configured", "restarter", restarter.String())
+	}
 
 	// Initialize elevation service
 	elevSvc := elevation.NewService(db, gwClient, logger)
 
+	// Additional gateways, selected per credential
+	if serveFlags.gatewaysFile != "" {
+		configs, err := gateway.LoadConfigs(serveFlags.gatewaysFile)
+		if err != nil {
+			return fmt.Errorf("failed to load gateways: %w", err)
+		}
+		for _, cfg := range configs {
+			var rpc *gateway.RPCClient
+			if token := os.Getenv(cfg.TokenEnv); cfg.TokenEnv != "" && token != "" {
+				// TokenEnv needn't have a secret-looking name, so RegisterEnv may miss it
+				redact.Register(token)
+				tlsConfig, _ := cfg.TLS.Config() // Validated by LoadConfigs
+				if cfg.TLS.InsecureSkipVerify {
+					slog.Warn("gateway TLS certificate verification disabled", "gateway", cfg.Name)
+				}
+				rpc = gateway.NewRPCClient(cfg.URL, token, tlsConfig, logger)
+			} else {
+				slog.Warn("gateway token not set - restart and config injection disabled", "gateway", cfg.Name, "tokenEnv", cfg.TokenEnv)
+			}
+			gw := gateway.NewClient(cfg.URL, cfg.EnvFile, rpc, logger)
+			if err := gw.SetSecretsDir(cfg.SecretsDir); err != nil {
+				return fmt.Errorf("gateway %q: %w", cfg.Name, err)
+			}
+			r, err := cfg.Restarter(serveFlags.dockerHost)
+			if err != nil {
+				return fmt.Errorf("gateway %q: %w", cfg.Name, err)
+			}
+			if r != nil {
+				gw.SetRestartFallback(r)
+			}
+			elevSvc.AddGateway(cfg.Name, gw)
+			slog.Info("gateway client configured", "gateway", cfg.Name, "url", cfg.URL, "envFile", cfg.EnvFile)
+		}
+	}
+
+	switch notify.DigestPeriod(serveFlags.digest) {
+	case "", notify.DigestDaily, notify.DigestWeekly:
+	default:
+		return fmt.Errorf("--digest must be daily or weekly")
+	}
+	if serveFlags.digestHour < 0 || serveFlags.digestHour > 23 {
+		return fmt.Errorf("--digest-hour must be between 0 and 23")
+	}
+	if serveFlags.staleDays < 0 {
+		return fmt.Errorf("--stale-report-days must not be negative")
+	}
+	if serveFlags.auditDays < 0 {
+		return fmt.Errorf("--audit-retention-days must not be negative")
+	}
+	if serveFlags.auditArchive != "" && serveFlags.auditDays == 0 {
+		return fmt.Errorf("--audit-archive-dir requires --audit-retention-days")
+	}
+
+	// Notifications
+	notifier := notify.NewDispatcher(logger)
+	defer notifier.Wait()
+	notifier.UseRouting(db)
+	elevSvc.SetNotifier(notifier)
+
+	// Approval receipts
+	receiptKey, err := loadReceiptKey(db, serveFlags.receiptKey)
+	if err != nil {
+		return fmt.Errorf("failed to load receipt signing key: %w", err)
+	}
+	elevSvc.SetReceiptKey(receiptKey)
+	slog.Info("approval receipts enabled", "keyId", elevation.ReceiptKeyID(receiptKey.Public().(ed25519.PublicKey)))
+
+	// Admin-registered outbound webhooks
+	webhooks := notify.NewWebhooks(db, logger)
+	defer webhooks.Close()
+	notifier.Register(webhooks)
+
+	// Callbacks to the agent that requested an elevation
+	if len(serveFlags.callbackAllow) > 0 {
+		callbacks, err := notify.NewAgentCallbacks(db, notify.AgentCallbackConfig{
+			Allow:  serveFlags.callbackAllow,
+			Secret: os.Getenv("OCM_AGENT_CALLBACK_SECRET"),
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("--agent-callback-allow: %w", err)
+		}
+		defer callbacks.Close()
+		notifier.UseAgentCallbacks(callbacks)
+	}
+
+	var slack *notify.Slack
+	if serveFlags.slackChannel != "" {
+		botToken, signingSecret := os.Getenv("OCM_SLACK_BOT_TOKEN"), os.Getenv("OCM_SLACK_SIGNING_SECRET")
+		if botToken == "" || signingSecret == "" {
+			return fmt.Errorf("--slack-channel requires OCM_SLACK_BOT_TOKEN and OCM_SLACK_SIGNING_SECRET")
+		}
+		slack = notify.NewSlack(notify.SlackConfig{
+			BotToken:      botToken,
+			SigningSecret: signingSecret,
+			Channel:       serveFlags.slackChannel,
+			ApproveTTL:    serveFlags.slackTTL,
+		}, logger)
+		notifier.Register(slack)
+		slog.Info("slack notifications enabled", "channel", serveFlags.slackChannel)
+	}
+
+	if serveFlags.smtpAddr != "" {
+		if serveFlags.smtpFrom == "" {
+			return fmt.Errorf("--smtp-addr requires --smtp-from")
+		}
+		emailCfg := notify.EmailConfig{
+			Addr:     serveFlags.smtpAddr,
+			Username: os.Getenv("OCM_SMTP_USERNAME"),
+			Password: os.Getenv("OCM_SMTP_PASSWORD"),
+			From:     serveFlags.smtpFrom,
+			To:       serveFlags.smtpTo,
+		}
+		if err := emailCfg.Validate(); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+		notifier.Register(notify.NewEmail(emailCfg, db, logger))
+		slog.Info("email notifications enabled", "smtp", serveFlags.smtpAddr)
+	}
+
+	var telegram *notify.Telegram
+	if serveFlags.telegramChat != 0 {
+		botToken := os.Getenv("OCM_TELEGRAM_BOT_TOKEN")
+		if botToken == "" {
+			return fmt.Errorf("--telegram-chat-id requires OCM_TELEGRAM_BOT_TOKEN")
+		}
+		telegram = notify.NewTelegram(notify.TelegramConfig{
+			BotToken:   botToken,
+			ChatID:     serveFlags.telegramChat,
+			ApproveTTL: serveFlags.telegramTTL,
+		}, logger)
+		notifier.Register(telegram)
+		slog.Info("telegram notifications enabled", "chat", serveFlags.telegramChat)
+	}
+
+	var discord *notify.Discord
+	if webhookURL := os.Getenv("OCM_DISCORD_WEBHOOK_URL"); webhookURL != "" || serveFlags.discordChan != "" {
+		discord, err = notify.NewDiscord(notify.DiscordConfig{
+			WebhookURL: webhookURL,
+			BotToken:   os.Getenv("OCM_DISCORD_BOT_TOKEN"),
+			ChannelID:  serveFlags.discordChan,
+			PublicKey:  serveFlags.discordKey,
+			ApproveTTL: serveFlags.discordTTL,
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("discord: %w", err)
+		}
+		notifier.Register(discord)
+		slog.Info("discord notifications enabled", "interactive", serveFlags.discordChan != "")
+	}
+
+	if serveFlags.matrixHS != "" {
+		token := os.Getenv("OCM_MATRIX_ACCESS_TOKEN")
+		if token == "" || serveFlags.matrixRoom == "" {
+			return fmt.Errorf("--matrix-homeserver requires --matrix-room and OCM_MATRIX_ACCESS_TOKEN")
+		}
+		for _, f := range serveFlags.matrixEvents {
+			if !notify.ValidFilter(f) {
+				return fmt.Errorf("--matrix-events: unknown event filter %q", f)
+			}
+		}
+		notifier.Register(notify.NewMatrix(notify.MatrixConfig{
+			Homeserver:  serveFlags.matrixHS,
+			AccessToken: token,
+			RoomID:      serveFlags.matrixRoom,
+			Events:      serveFlags.matrixEvents,
+		}))
+		slog.Info("matrix notifications enabled", "room", serveFlags.matrixRoom)
+	}
+
+	if serveFlags.ntfyURL != "" {
+		notifier.Register(notify.NewNtfy(notify.NtfyConfig{TopicURL: serveFlags.ntfyURL, Token: os.Getenv("OCM_NTFY_TOKEN")}))
+		slog.Info("ntfy notifications enabled", "topic", serveFlags.ntfyURL)
+	}
+
+	// Pushover is configured entirely from the environment (both values are secrets)
+	if token, user := os.Getenv("OCM_PUSHOVER_TOKEN"), os.Getenv("OCM_PUSHOVER_USER"); token != "" && user != "" {
+		notifier.Register(notify.NewPushover(notify.PushoverConfig{Token: token, User: user}))
+		slog.Info("pushover notifications enabled")
+	}
+
+	// Incident management (failures only; see notify.incidentSeverity)
+	if key := os.Getenv("OCM_PAGERDUTY_ROUTING_KEY"); key != "" {
+		notifier.Register(notify.NewPagerDuty(key))
+		slog.Info("pagerduty incidents enabled")
+	}
+	if key := os.Getenv("OCM_OPSGENIE_API_KEY"); key != "" {
+		notifier.Register(notify.NewOpsgenie(key, os.Getenv("OCM_OPSGENIE_API_URL")))
+		slog.Info("opsgenie alerts enabled")
+	}
+
+	// Raise operational failures as events
+	// Decrypt alerts are throttled: delivering one may itself decrypt (e.g.,
+	// webhook secrets), which must not feed back into a storm of events.
+	var lastDecryptAlert atomic.Int64
+	db.OnDecryptError(func(err error) {
+		now := time.Now().Unix()
+		if last := lastDecryptAlert.Load(); now-last < 60 || !lastDecryptAlert.CompareAndSwap(last, now) {
+			return
+		}
+		notifier.Publish(notify.Event{Type: notify.EventStoreDecryptFailed, Actor: "system", Details: err.Error()})
+	})
+	for name, gw := range elevSvc.Gateways() {
+		name := name
+		// Queue restarts and config patches while the Gateway is unreachable
+		gw.UseQueue(db, name)
+		gw.SetRestartDebounce(serveFlags.restartWindow)
+		gw.OnRestartFailure(func(consecutive int, err error) {
+			if serveFlags.restartAlert > 0 && consecutive >= serveFlags.restartAlert {
+				notifier.Publish(notify.Event{
+					Type:    notify.EventGatewayRestartFailed,
+					Actor:   "system",
+					Details: fmt.Sprintf("gateway %s: %d consecutive failures, last: %v", name, consecutive, err),
+				})
+			}
+		})
+	}
+
 	// Create routers
-	agentRouter := api.NewAgentRouter(db, logger)
-	adminRouter := api.NewAdminRouter(db, elevSvc, rpcClient, logger)
+	api.SetBuild(Version, Commit)
+	api.SetExpiryWarning(serveFlags.expiryWarning)
+	api.SetBlockDuplicateSecrets(serveFlags.blockDupes)
+	rotate.AllowCommands(serveFlags.rotationCmds)
+	agentRouter := api.NewAgentRouter(db, notifier, logger)
+	adminRouter := api.NewAdminRouter(db, elevSvc, rpcClient, auditBroker, notifier, logger)
+	if slack != nil {
+		// Slack interactivity callbacks (authenticated by Slack's request signature)
+		adminRouter.Post("/slack/interactions", slack.InteractionHandler(elevSvc).ServeHTTP)
+	}
+	if discord != nil && serveFlags.discordChan != "" {
+		// Discord interactions endpoint (authenticated by the application's Ed25519 signature)
+		adminRouter.Post("/discord/interactions", discord.InteractionHandler(elevSvc).ServeHTTP)
+	}
 
 	// Start servers
 	agentServer := &http.Server{
@@ -96,7 +533,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	adminServer := &http.Server{
 		Addr:         serveFlags.adminAddr,
-		Handler:      adminRouter,
+		Handler:      crossOrigin(adminRouter),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -105,6 +542,112 @@ func runServe(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Route pending elevations to on-call approvers
+	go elevSvc.RunRouter(ctx)
+
+	// Rotate credentials on their rotation hooks' schedules
+	go elevSvc.RunRotations(ctx)
+
+	// Catch revoked tokens before the agent hits a 401
+	if serveFlags.healthCheck > 0 {
+		go elevSvc.RunHealthChecks(ctx, serveFlags.healthCheck)
+	}
+
+	// Catch tokens pasted into config notes or written to Gateway logs
+	if serveFlags.leakScan > 0 {
+		go elevSvc.RunLeakScans(ctx, serveFlags.leakScan, serveFlags.leakScanLogs)
+	}
+
+	// Repair hand edits and injections left behind by missed expiries
+	if serveFlags.reconcile > 0 {
+		go elevSvc.RunReconciler(ctx, serveFlags.reconcile)
+	}
+
+	// Off-host copies of the audit log
+	var objectArchiver *audit.ObjectArchiver
+	if serveFlags.auditS3URL != "" {
+		cfg := serveFlags.auditS3
+		bucket, prefix, err := audit.ParseS3URL(serveFlags.auditS3URL)
+		if err != nil {
+			return fmt.Errorf("--audit-s3-url: %w", err)
+		}
+		cfg.Bucket = bucket
+		cfg.AccessKeyID = envOr("OCM_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = envOr("OCM_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+		client, err := audit.NewS3Client(cfg)
+		if err != nil {
+			return fmt.Errorf("audit archive: %w", err)
+		}
+		if serveFlags.auditSegment < time.Minute {
+			return fmt.Errorf("--audit-s3-segment must be at least 1m")
+		}
+		objectArchiver = audit.NewObjectArchiver(db, client, prefix, serveFlags.auditSegment, logger)
+		go objectArchiver.Run(ctx)
+		slog.Info("audit archiving to object storage", "bucket", bucket, "prefix", prefix, "segment", serveFlags.auditSegment)
+	}
+
+	// Keep the audit log from growing without bound
+	if serveFlags.auditDays > 0 {
+		retention := time.Duration(serveFlags.auditDays) * 24 * time.Hour
+		pruner := audit.NewPruner(db, retention, serveFlags.auditArchive, logger)
+		if objectArchiver != nil {
+			// Never prune what hasn't been uploaded
+			pruner.SetFloor(objectArchiver.SealedUntil)
+		}
+		go pruner.Run(ctx)
+	}
+
+	// Publish Gateway connection changes (live dashboard, webhooks)
+	if rpcClient != nil {
+		rpcClient.OnStateChange(func(from, to string) {
+			notifier.Publish(notify.Event{Type: notify.EventGatewayStatus, Actor: "system", Details: to})
+			slog.Info("gateway connection state changed", "from", from, "to", to)
+		})
+
+		// Announce device pairing requests instead of waiting for an admin to poll
+		rpcClient.OnPairRequest(func(d gateway.PendingDevice) {
+			db.AddAuditEntry(&store.AuditEntry{
+				ID:        store.NewID("audit"),
+				Timestamp: time.Now(),
+				Action:    store.ActionDevicePairRequested,
+				Details:   fmt.Sprintf("requestId: %s, deviceId: %s, role: %s", d.RequestID, d.DeviceID, d.Role),
+				Actor:     "gateway",
+			})
+			notifier.Publish(notify.Event{
+				Type:    notify.EventDeviceRequested,
+				Actor:   "gateway",
+				Details: fmt.Sprintf("%s (role %s, device %.12s)", d.RequestID, d.Role, d.DeviceID),
+			})
+			slog.Info("device pairing requested", "requestId", d.RequestID, "deviceId", d.DeviceID, "role", d.Role)
+		})
+	}
+
+	// Receive Telegram /approve and /deny commands
+	if telegram != nil {
+		go telegram.Run(ctx, elevSvc)
+	}
+
+	// Scheduled activity digests
+	if serveFlags.digest != "" {
+		go notify.NewDigestScheduler(db, notifier, notify.DigestPeriod(serveFlags.digest), serveFlags.digestHour, logger).Run(ctx)
+	}
+
+	// Weekly report of credentials nobody uses
+	if serveFlags.staleDays > 0 {
+		go notify.NewStaleScheduler(db, notifier, serveFlags.staleDays, serveFlags.digestHour, logger).Run(ctx)
+	}
+
+	// Alert on suspicious patterns in the audit log
+	go notify.NewAnomalyDetector(db, notifier, logger).Run(ctx)
+
+	// Warn about credential tokens nearing expiry
+	if serveFlags.expiryWarning > 0 {
+		go notify.NewExpiryWatcher(db, notifier, serveFlags.expiryWarning, logger).Run(ctx)
+	}
+
+	// SIGUSR1 toggles debug logging
+	go toggleDebugOnSignal(ctx)
+
 	go func() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -139,6 +682,12 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	slog.Info("ocm started", "version", Version, "agent", serveFlags.agentAddr, "admin", serveFlags.adminAddr)
 	<-ctx.Done()
+	// Apply changes still waiting out the restart debounce
+	for _, gw := range elevSvc.Gateways() {
+		if gw != nil {
+			gw.Flush()
+		}
+	}
 	slog.Info("ocm stopped")
 	return nil
 }
@@ -214,3 +763,32 @@ func hexDecode(s string) ([]byte, error) {
 	}
 	return b, nil
 }
+
+// loadReceiptKey reads the receipt signing key's seed from keyFile, or
+// returns the database's own key if keyFile is empty.
+func loadReceiptKey(db *store.Store, keyFile string) (ed25519.PrivateKey, error) {
+	if keyFile == "" {
+		return db.ReceiptSigningKey()
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	seed := data
+	if len(data) != ed25519.SeedSize {
+		if seed, err = hexDecode(strings.TrimSpace(string(data))); err != nil {
+			return nil, fmt.Errorf("%s must be 32 bytes or 64 hex characters", keyFile)
+		}
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// envOr returns the first of the named environment variables that is set.
+func envOr(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+)
+
+// statusExitUnhealthy is the exit status of `ocm status` when OCM answered
+// but reported a problem.
+const statusExitUnhealthy = 2
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show gateway connectivity, pending and active elevations, and database health",
+	Long: `Show a running OCM's status through its admin API: the Gateway RPC connection
+and pairing state, each configured gateway, pending elevation requests,
+active elevations with their remaining TTL, and database health.
+
+Exit status: 0 if healthy, 2 if the database check failed or a gateway is
+degraded, 1 if OCM couldn't be reached.`,
+	Args:          cobra.NoArgs,
+	RunE:          runStatus,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	addAdminClientFlags(statusCmd.Flags())
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	var st api.StatusResponse
+	if err := newAdminClient().do(http.MethodGet, "/status", nil, &st); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	unhealthy := exitError{code: statusExitUnhealthy, err: fmt.Errorf("OCM is unhealthy")}
+	if adminFlags.json {
+		if err := printJSON(out, &st); err != nil {
+			return err
+		}
+		if !st.Healthy {
+			return unhealthy
+		}
+		return nil
+	}
+
+	health := "healthy"
+	if !st.Healthy {
+		health = "UNHEALTHY"
+	}
+	fmt.Fprintf(out, "OCM at %s: %s\n\n", adminFlags.adminURL, health)
+
+	if st.Database.OK {
+		fmt.Fprintf(out, "Database:  ok (%d credentials)\n", st.Database.Credentials)
+	} else {
+		fmt.Fprintf(out, "Database:  FAILED: %s\n", st.Database.Error)
+	}
+
+	switch gw := st.Gateway; {
+	case gw == nil:
+		fmt.Fprintln(out, "Gateway:   RPC not configured")
+	case gw.State == "connected":
+		fmt.Fprintf(out, "Gateway:   connected (device %s)\n", gw.DeviceID)
+	case gw.State == "pairing_needed":
+		fmt.Fprintf(out, "Gateway:   pairing needed for device %s. Approve it with:\n  %s\n",
+			gw.DeviceID, strings.ReplaceAll(gw.ApproveCommand, "\n", "\n  "))
+	case gw.State == "token_mismatch":
+		fmt.Fprintf(out, "Gateway:   token mismatch. Fix it with:\n  %s\n", gw.FixCommand)
+	default:
+		fmt.Fprintln(out, "Gateway:   disconnected")
+	}
+	if len(st.Gateways) > 0 {
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, gw := range st.Gateways {
+			var notes []string
+			if gw.Queued > 0 {
+				notes = append(notes, fmt.Sprintf("%d queued", gw.Queued))
+			}
+			if gw.Degraded {
+				notes = append(notes, "DEGRADED")
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", gw.Name, gw.State, gw.URL, strings.Join(notes, ", "))
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintf(out, "\nPending requests:  %d\n", st.PendingRequests)
+	fmt.Fprintf(out, "Active elevations: %d\n", len(st.ActiveElevations))
+	if len(st.ActiveElevations) > 0 {
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, elev := range st.ActiveElevations {
+			remaining := time.Duration(elev.RemainingSeconds) * time.Second
+			fmt.Fprintf(tw, "  %s\t%s\t%s left\t(approved by %s)\t%s\n", elev.Service, elev.Scope, remaining,
+				firstNonEmpty(elev.ApprovedBy, "-"), elev.ID)
+		}
+		tw.Flush()
+	}
+
+	if !st.Healthy {
+		return unhealthy
+	}
+	return nil
+}
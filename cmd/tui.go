@@ -0,0 +1,429 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/openclaw/ocm/internal/api"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// tuiAuditLimit is how many recent audit entries `ocm tui` fetches; as many
+// as fit are shown.
+const tuiAuditLimit = 50
+
+var tuiFlags struct {
+	ttl      string
+	interval time.Duration
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Interactive terminal console for approving elevation requests",
+	Long: `A full-screen terminal console on a running OCM's admin API, for operators
+who live in tmux rather than a browser. It shows pending elevation requests,
+active grants counting down to expiry, and the most recent audit entries,
+refreshing when the admin event stream reports activity and every
+--interval regardless.
+
+Keys:
+  up/down, k/j   select a pending request
+  a              approve it for --ttl, or the requested TTL (asks to confirm)
+  d              deny it, with an optional reason
+  r              refresh now
+  q, ctrl+c      quit`,
+	Args:          cobra.NoArgs,
+	RunE:          runTUI,
+	SilenceUsage:  true,
+	SilenceErrors: true, // Execute prints the error
+}
+
+func init() {
+	f := tuiCmd.Flags()
+	addAdminClientFlags(f)
+	f.MarkHidden("json")
+	f.StringVar(&tuiFlags.ttl, "ttl", "", "How long approved elevations last (default: the requested TTL)")
+	f.DurationVar(&tuiFlags.interval, "interval", 5*time.Second, "How often to refresh without an event")
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	if err := validTTL(tuiFlags.ttl); err != nil {
+		return err
+	}
+	if tuiFlags.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	c := newAdminClient()
+	wake := make(chan struct{}, 1)
+	// Stream warnings would garble the screen; polling covers for a lost stream
+	go watchEvents(ctx, c, wake, io.Discard)
+
+	m := &tuiModel{client: c, wake: wake, now: time.Now()}
+	_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err == tea.ErrProgramKilled && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// tuiMode is what keys currently do.
+type tuiMode int
+
+const (
+	tuiBrowse  tuiMode = iota
+	tuiConfirm         // y/n to approve the selected request
+	tuiReason          // Typing a denial reason
+)
+
+// Messages
+type (
+	tuiTickMsg    time.Time
+	tuiWakeMsg    struct{}
+	tuiRefreshMsg struct {
+		pending []*store.Elevation
+		active  []api.ActiveElevation
+		audit   []*store.AuditEntry
+		err     error
+	}
+	tuiDecidedMsg struct {
+		message string
+		err     error
+	}
+)
+
+var (
+	tuiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	tuiHeadingStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	tuiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	tuiDimStyle      = lipgloss.NewStyle().Faint(true)
+	tuiErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiPromptStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+)
+
+type tuiModel struct {
+	client *adminClient
+	wake   <-chan struct{}
+
+	pending []*store.Elevation
+	active  []api.ActiveElevation
+	audit   []*store.AuditEntry
+
+	cursor    int
+	mode      tuiMode
+	reason    []rune
+	message   string // Outcome of the last decision
+	err       error  // From the last refresh or decision
+	refreshed time.Time
+	loading   bool
+	now       time.Time
+
+	width, height int
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	m.loading = true
+	return tea.Batch(m.refresh(), tuiTick(), m.waitWake())
+}
+
+func tuiTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+func (m *tuiModel) waitWake() tea.Cmd {
+	return func() tea.Msg {
+		<-m.wake
+		return tuiWakeMsg{}
+	}
+}
+
+// refresh fetches pending requests, active grants and recent audit entries.
+func (m *tuiModel) refresh() tea.Cmd {
+	c := m.client
+	return func() tea.Msg {
+		var msg tuiRefreshMsg
+		if msg.err = c.do(http.MethodGet, "/requests", nil, &msg.pending); msg.err != nil {
+			return msg
+		}
+		var st api.StatusResponse
+		if msg.err = c.do(http.MethodGet, "/status", nil, &st); msg.err != nil {
+			return msg
+		}
+		msg.active = st.ActiveElevations
+		msg.err = c.do(http.MethodGet, fmt.Sprintf("/audit?limit=%d", tuiAuditLimit), nil, &msg.audit)
+		return msg
+	}
+}
+
+func (m *tuiModel) approve(elev *store.Elevation) tea.Cmd {
+	c, ttl := m.client, tuiFlags.ttl
+	label := tuiApprovalTTL(elev)
+	return func() tea.Msg {
+		req := &api.ApproveRequest{TTL: ttl}
+		if err := c.do(http.MethodPost, "/requests/"+url.PathEscape(elev.ID)+"/approve", req, nil); err != nil {
+			return tuiDecidedMsg{err: fmt.Errorf("approve %s: %w", elev.ID, err)}
+		}
+		return tuiDecidedMsg{message: fmt.Sprintf("Approved %s (%s %s) for %s", elev.ID, elev.Service, elev.Scope, label)}
+	}
+}
+
+// tuiApprovalTTL describes how long approving elev lasts: --ttl if set, else
+// what the agent requested.
+func tuiApprovalTTL(elev *store.Elevation) string {
+	switch {
+	case tuiFlags.ttl != "":
+		return tuiFlags.ttl
+	case elev.RequestedTTL > 0:
+		return elev.RequestedTTL.String()
+	default:
+		return "the default TTL"
+	}
+}
+
+func (m *tuiModel) deny(elev *store.Elevation, reason string) tea.Cmd {
+	c := m.client
+	return func() tea.Msg {
+		req := &api.DenyRequest{Reason: reason}
+		if err := c.do(http.MethodPost, "/requests/"+url.PathEscape(elev.ID)+"/deny", req, nil); err != nil {
+			return tuiDecidedMsg{err: fmt.Errorf("deny %s: %w", elev.ID, err)}
+		}
+		return tuiDecidedMsg{message: fmt.Sprintf("Denied %s (%s %s)", elev.ID, elev.Service, elev.Scope)}
+	}
+}
+
+func (m *tuiModel) selected() *store.Elevation {
+	if m.cursor < 0 || m.cursor >= len(m.pending) {
+		return nil
+	}
+	return m.pending[m.cursor]
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tuiTickMsg:
+		m.now = time.Time(msg)
+		cmds := []tea.Cmd{tuiTick()}
+		if !m.loading && m.now.Sub(m.refreshed) >= tuiFlags.interval {
+			m.loading = true
+			cmds = append(cmds, m.refresh())
+		}
+		return m, tea.Batch(cmds...)
+
+	case tuiWakeMsg:
+		cmds := []tea.Cmd{m.waitWake()}
+		if !m.loading {
+			m.loading = true
+			cmds = append(cmds, m.refresh())
+		}
+		return m, tea.Batch(cmds...)
+
+	case tuiRefreshMsg:
+		m.loading, m.refreshed, m.err = false, time.Now(), msg.err
+		if msg.err == nil {
+			// Keep the selection on the same request as the list changes
+			var selectedID string
+			if elev := m.selected(); elev != nil {
+				selectedID = elev.ID
+			}
+			m.pending, m.active, m.audit = msg.pending, msg.active, msg.audit
+			m.cursor = min(m.cursor, max(len(m.pending)-1, 0))
+			for i, elev := range m.pending {
+				if elev.ID == selectedID {
+					m.cursor = i
+				}
+			}
+			if m.selected() == nil && m.mode != tuiBrowse {
+				m.mode, m.message = tuiBrowse, "The request was decided elsewhere"
+			}
+		}
+
+	case tuiDecidedMsg:
+		m.message, m.err = msg.message, msg.err
+		m.loading = true
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+
+	switch m.mode {
+	case tuiConfirm:
+		switch msg.String() {
+		case "y", "Y":
+			m.mode = tuiBrowse
+			if elev := m.selected(); elev != nil {
+				return m, m.approve(elev)
+			}
+		case "n", "N", "esc", "q":
+			m.mode = tuiBrowse
+		}
+		return m, nil
+
+	case tuiReason:
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.mode = tuiBrowse
+			if elev := m.selected(); elev != nil {
+				return m, m.deny(elev, strings.TrimSpace(string(m.reason)))
+			}
+		case tea.KeyEsc:
+			m.mode = tuiBrowse
+		case tea.KeyBackspace:
+			if len(m.reason) > 0 {
+				m.reason = m.reason[:len(m.reason)-1]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.reason = append(m.reason, msg.Runes...)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.pending)-1 {
+			m.cursor++
+		}
+	case "a":
+		if m.selected() != nil {
+			m.mode, m.message, m.err = tuiConfirm, "", nil
+		}
+	case "d":
+		if m.selected() != nil {
+			m.mode, m.reason, m.message, m.err = tuiReason, nil, "", nil
+		}
+	case "r":
+		if !m.loading {
+			m.loading = true
+			return m, m.refresh()
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	var lines []string
+	add := func(s string) { lines = append(lines, s) }
+
+	state := "refreshed " + m.refreshed.Local().Format("15:04:05")
+	if m.refreshed.IsZero() {
+		state = "connecting..."
+	}
+	add(tuiTitleStyle.Render("OCM") + "  " + adminFlags.adminURL + "  " + tuiDimStyle.Render(state))
+	add("")
+
+	add(tuiHeadingStyle.Render(fmt.Sprintf("Pending requests (%d)", len(m.pending))))
+	if len(m.pending) == 0 {
+		add(tuiDimStyle.Render("  none"))
+	}
+	for i, elev := range m.pending {
+		age := m.now.Sub(elev.RequestedAt).Truncate(time.Second)
+		line := fmt.Sprintf("  %-28s %-14s %-6s %8s ago  %-10s %s", elev.ID, elev.Service, elev.Scope, age,
+			firstNonEmpty(elev.RequestedBy, "-"), elev.Reason)
+		line = m.fit(line)
+		if i == m.cursor {
+			line = tuiSelectedStyle.Render(line)
+		}
+		add(line)
+	}
+	add("")
+
+	add(tuiHeadingStyle.Render(fmt.Sprintf("Active grants (%d)", len(m.active))))
+	if len(m.active) == 0 {
+		add(tuiDimStyle.Render("  none"))
+	}
+	for _, a := range m.active {
+		left := "expired"
+		if remaining := a.ExpiresAt.Sub(m.now); remaining > 0 {
+			left = formatCountdown(remaining) + " left"
+		}
+		add(m.fit(fmt.Sprintf("  %-14s %-6s %-12s approved by %-10s %s", a.Service, a.Scope, left,
+			firstNonEmpty(a.ApprovedBy, "-"), tuiDimStyle.Render(a.ID))))
+	}
+	add("")
+
+	footer := m.footer()
+	add(tuiHeadingStyle.Render("Recent audit entries"))
+	// Whatever room is left, less the footer and the blank line above it
+	room := m.height - len(lines) - len(footer) - 1
+	for i := 0; i < len(m.audit) && i < room; i++ {
+		e := m.audit[i]
+		add(m.fit(fmt.Sprintf("  %s  %-22s %-14s %-6s %-10s %s", e.Timestamp.Local().Format("15:04:05"), e.Action,
+			firstNonEmpty(e.Service, "-"), firstNonEmpty(e.Scope, "-"), firstNonEmpty(e.Actor, "-"), e.Details)))
+	}
+
+	for len(lines) < m.height-len(footer) {
+		add("")
+	}
+	lines = append(lines, footer...)
+	return strings.Join(lines, "\n")
+}
+
+// footer is the status line and key help.
+func (m *tuiModel) footer() []string {
+	var status string
+	switch {
+	case m.mode == tuiConfirm && m.selected() != nil:
+		elev := m.selected()
+		status = tuiPromptStyle.Render(fmt.Sprintf("Approve %s (%s %s) for %s? [y/n]", elev.ID, elev.Service, elev.Scope, tuiApprovalTTL(elev)))
+	case m.mode == tuiReason && m.selected() != nil:
+		status = tuiPromptStyle.Render(fmt.Sprintf("Deny %s. Reason (enter to deny, esc to cancel): ", m.selected().ID)) + string(m.reason) + "_"
+	case m.err != nil:
+		status = tuiErrorStyle.Render(m.fit(m.err.Error()))
+	default:
+		status = m.message
+	}
+	help := tuiDimStyle.Render("a approve  d deny  ↑/↓ select  r refresh  q quit")
+	return []string{status, help}
+}
+
+// fit cuts s to the terminal width.
+func (m *tuiModel) fit(s string) string {
+	if m.width > 0 && lipgloss.Width(s) > m.width {
+		r := []rune(s)
+		for len(r) > 0 && lipgloss.Width(string(r)) > m.width {
+			r = r[:len(r)-1]
+		}
+		return string(r)
+	}
+	return s
+}
+
+// formatCountdown formats d as m:ss, or h:mm:ss from an hour up.
+func formatCountdown(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	h, mins, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mins, s)
+	}
+	return fmt.Sprintf("%d:%02d", mins, s)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/tracing"
+)
+
+// logRequests writes one structured line per request once it completes.
+// Requests are logged by route pattern rather than path, so path parameters
+// such as guest invite tokens never reach the log, and query strings are
+// never logged. actor names who made the request. Probes and scrapes are
+// logged at debug, server errors at warn.
+func logRequests(logger *slog.Logger, actor func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				route := r.URL.Path
+				if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+					route = rctx.RoutePattern()
+				}
+				remote := r.RemoteAddr
+				if host, _, err := net.SplitHostPort(remote); err == nil {
+					remote = host
+				}
+
+				level := slog.LevelInfo
+				switch {
+				case status >= 500:
+					level = slog.LevelWarn
+				case quietRoutes[route]:
+					level = slog.LevelDebug
+				}
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("route", redact.String(route)),
+					slog.Int("status", status),
+					slog.Duration("duration", time.Since(start)),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.String("actor", actor(r)),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("remote_ip", remote),
+				}
+				if id := tracing.TraceID(r.Context()); id != "" {
+					attrs = append(attrs, slog.String("trace_id", id))
+				}
+				logger.LogAttrs(r.Context(), level, "request", attrs...)
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// quietRoutes are polled by orchestrators and scrapers, so they are only
+// logged at debug.
+var quietRoutes = map[string]bool{"/health": true, "/healthz": true, "/readyz": true, "/metrics": true}
+
+// agentActor is the actor for every request to the agent API.
+func agentActor(*http.Request) string { return "agent" }
+
+// adminActor distinguishes guest approvers, who reach the admin listener
+// through signed invite links, from admins.
+func adminActor(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/guest/") {
+		return "guest"
+	}
+	return "admin"
+}
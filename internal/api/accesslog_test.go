@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogRequests(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	token := "guest_0123456789abcdef_42." + strings.Repeat("A", 43)
+	req := httptest.NewRequest("GET", "/guest/api/invites/"+token+"/?secret=1", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Msg       string `json:"msg"`
+		Method    string `json:"method"`
+		Route     string `json:"route"`
+		Status    int    `json:"status"`
+		Actor     string `json:"actor"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("decode log line: %v: %s", err, buf.String())
+	}
+	if line.Msg != "request" || line.Method != "GET" || line.Route != "/guest/api/invites/{token}" || line.Actor != "guest" || line.RequestID != "req-1" {
+		t.Errorf("log line = %+v", line)
+	}
+	if line.Status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", line.Status, http.StatusNotFound)
+	}
+	if strings.Contains(buf.String(), strings.Repeat("A", 43)) || strings.Contains(buf.String(), "secret=1") {
+		t.Errorf("log line leaks the token or query: %s", buf.String())
+	}
+}
@@ -2,17 +2,26 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/openclaw/ocm/internal"
+	"github.com/openclaw/ocm/internal/audit"
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/derive"
 	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/format"
 	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/metrics"
+	"github.com/openclaw/ocm/internal/notify"
 	"github.com/openclaw/ocm/internal/store"
 )
 
@@ -23,59 +32,50 @@ import (
 // - Device pairing management
 // - Audit log viewing
 // - Web UI serving
-func NewAdminRouter(db *store.Store, elevSvc *elevation.Service, rpcClient *gateway.RPCClient, logger *slog.Logger) chi.Router {
+func NewAdminRouter(db *store.Store, elevSvc *elevation.Service, rpcClient *gateway.RPCClient, auditBroker *audit.Broker, notifier *notify.Dispatcher, logger *slog.Logger) chi.Router {
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(traceRequests)
+	r.Use(logRequests(logger, adminActor)) // Outside Recoverer, so panics log as 500s
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(reportPanics)
+	r.Use(timeoutUnlessStreaming(r, 30*time.Second))
 
-	h := &adminHandler{store: db, elevation: elevSvc, rpc: rpcClient, logger: logger}
+	h := &adminHandler{store: db, elevation: elevSvc, rpc: rpcClient, audit: auditBroker, notifier: notifier, logger: logger}
 
-	// API routes (protected by auth middleware)
+	// API routes (protected by auth middleware). The unversioned paths are
+	// deprecated aliases of /admin/api/v1.
 	r.Route("/admin/api", func(r chi.Router) {
 		// TODO: Add auth middleware
 		// r.Use(adminAuthMiddleware)
 
-		// Setup (bootstrap flow)
-		r.Get("/setup/status", h.getSetupStatus)
-		r.Post("/setup/complete", h.completeSetup)
-
-		// Dashboard
-		r.Get("/dashboard", h.getDashboard)
-
-		// Credentials
-		r.Get("/credentials", h.listCredentials)
-		r.Post("/credentials", h.createCredential)
-		r.Get("/credentials/{service}", h.getCredential)
-		r.Put("/credentials/{service}", h.updateCredential)
-		r.Delete("/credentials/{service}", h.deleteCredential)
-
-		// Elevations
-		r.Get("/requests", h.listPendingRequests)
-		r.Post("/requests/{id}/approve", h.approveRequest)
-		r.Post("/requests/{id}/deny", h.denyRequest)
-		r.Post("/revoke/{service}/{scope}", h.revokeElevation)
+		r.Route("/v1", h.routes)
+		r.Group(func(r chi.Router) {
+			r.Use(deprecated(legacyAdminAPI, logger))
+			h.routes(r)
+		})
+	})
 
-		// Audit
-		r.Get("/audit", h.listAuditEntries)
+	// Guest approver links (signed, time-boxed, single elevation - no admin auth)
+	r.Route("/guest/api/invites/{token}", func(r chi.Router) {
+		r.Get("/", h.getGuestInvite)
+		r.Post("/approve", h.guestApprove)
+		r.Post("/deny", h.guestDeny)
+	})
 
-		// Device pairing (OpenClaw integration)
-		r.Get("/devices", h.listDevices)
-		r.Post("/devices/{requestId}/approve", h.approveDevice)
-		r.Post("/devices/{requestId}/reject", h.rejectDevice)
+	// Health check. OCM itself stays healthy while a Gateway is degraded, so
+	// the status is still 200; the body names the degraded gateways.
+	r.Get("/health", h.health)
 
-		// Channel status (OpenClaw channel configuration detection)
-		r.Get("/channels/status", h.getChannelStatus)
-	})
+	// Probes for container orchestrators
+	r.Get("/healthz", healthz)
+	r.Get("/readyz", readyz(db, elevSvc, true))
 
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
+	// Prometheus metrics
+	r.Get("/metrics", metrics.Handler().ServeHTTP)
 
 	// Serve SPA (fallback to index.html for client-side routing)
 	r.Handle("/*", spaHandler())
@@ -87,16 +87,136 @@ type adminHandler struct {
 	store     *store.Store
 	elevation *elevation.Service
 	rpc       *gateway.RPCClient
+	audit     *audit.Broker
+	notifier  *notify.Dispatcher
 	logger    *slog.Logger
 }
 
+// routes registers the admin API, relative to its version prefix.
+func (h *adminHandler) routes(r chi.Router) {
+	// Setup (bootstrap flow)
+	r.Get("/setup/status", h.getSetupStatus)
+	r.Post("/setup/complete", h.completeSetup)
+	r.Post("/setup/reset", h.resetSetup)
+	r.Post("/setup/steps/{step}/complete", h.completeSetupStep)
+	r.Post("/setup/steps/{step}/skip", h.skipSetupStep)
+
+	// Dashboard
+	r.Get("/dashboard", h.getDashboard)
+	r.Get("/dashboard/timeseries", h.getTimeSeries)
+	r.Get("/events", h.streamEvents)
+	r.Get("/status", h.getStatus)
+	r.Get("/system/status", h.getSystemStatus)
+	r.Get("/log-level", h.getLogLevel)
+	r.Put("/log-level", h.setLogLevel)
+
+	// Service catalog
+	r.Get("/catalog", h.getCatalog)
+	r.Get("/catalog/{id}", h.getCatalogProvider)
+
+	// API description
+	r.Get("/openapi.json", serveOpenAPI("OCM Admin API", adminOperations, &adminOpenAPIOnce, &adminOpenAPI))
+
+	// Credentials
+	r.Get("/credentials", h.listCredentials)
+	r.Post("/credentials", h.createCredential)
+	r.Post("/credentials/bulk", h.createCredentials)
+	r.Get("/credentials/{service}", h.getCredential)
+	r.Put("/credentials/{service}", h.updateCredential)
+	r.Delete("/credentials/{service}", h.deleteCredential)
+	r.Post("/credentials/{service}/preview-injection", h.previewInjection)
+	r.Post("/credentials/{service}/rotate", h.rotateCredential)
+	r.Post("/credentials/{service}/check", h.checkCredentialHealth)
+	r.Get("/credentials/{service}/versions", h.listCredentialVersions)
+	r.Get("/credentials/{service}/presets", h.listPresets)
+	r.Post("/credentials/{service}/presets", h.savePreset)
+	r.Delete("/credentials/{service}/presets/{name}", h.deletePreset)
+
+	// Elevations
+	r.Get("/requests", h.listPendingRequests)
+	r.Get("/requests/queued/{service}", h.listQueuedRequests)
+	r.Get("/elevations/active", h.listActiveElevations)
+	r.Post("/requests/{id}/approve", h.approveRequest)
+	r.Post("/requests/{id}/deny", h.denyRequest)
+	r.Post("/requests/{id}/guest-invites", h.createGuestInvite)
+	r.Get("/requests/{id}/receipt", h.getApprovalReceipt)
+	r.Get("/receipts/key", h.getReceiptKey)
+	r.Post("/revoke/{service}/{scope}", h.revokeElevation)
+	r.Get("/leases", h.listLeases)
+	r.Delete("/leases/{id}", h.revokeLease)
+	r.Get("/checkouts", h.listCheckouts)
+	r.Delete("/checkouts/{id}", h.breakCheckout)
+
+	// On-call routing
+	r.Get("/routing", h.getRoutingPolicy)
+	r.Put("/routing", h.setRoutingPolicy)
+	r.Get("/routing/oncall", h.getOnCall)
+
+	// Notifications
+	r.Get("/notifications/email", h.getEmailSettings)
+	r.Put("/notifications/email", h.setEmailSettings)
+	r.Get("/notifications/routing", h.getRouting)
+	r.Put("/notifications/routing", h.setRouting)
+	r.Get("/notifications/anomalies", h.getAnomalyRules)
+	r.Put("/notifications/anomalies", h.setAnomalyRules)
+	r.Post("/notifications/test", h.testNotification)
+
+	// Outbound webhooks
+	r.Get("/webhooks", h.listWebhooks)
+	r.Post("/webhooks", h.createWebhook)
+	r.Put("/webhooks/{id}", h.updateWebhook)
+	r.Delete("/webhooks/{id}", h.deleteWebhook)
+	r.Get("/webhooks/{id}/deliveries", h.listWebhookDeliveries)
+
+	// Reports
+	r.Get("/reports/digest", h.getDigest)
+	r.Get("/reports/stale", h.getStaleReport)
+
+	// Audit
+	r.Get("/audit", h.listAuditEntries)
+	r.Get("/audit/export", h.exportAuditEntries)
+	r.Get("/audit/actions", h.listAuditActions)
+	r.Get("/audit/devices", h.listAuditDevices)
+	r.Put("/audit/devices/{name}", h.putAuditDevice)
+	r.Delete("/audit/devices/{name}", h.deleteAuditDevice)
+
+	// Stats
+	r.Get("/stats/cache", h.getCacheStats)
+	r.Get("/stats/access", h.getAccessStats)
+
+	// OpenClaw Gateways credentials can be injected into
+	r.Get("/gateways", h.listGateways)
+	r.Delete("/gateways/{name}/failed", h.deleteFailedGatewayOps)
+	r.Get("/gateway/injected", h.listInjected)
+	r.Get("/gateway/stats", h.gatewayStats)
+	r.Post("/gateway/leaks/scan", h.scanLeaks)
+
+	// Device pairing (OpenClaw integration)
+	r.Get("/devices", h.listDevices)
+	r.Post("/devices/{requestId}/approve", h.approveDevice)
+	r.Post("/devices/{requestId}/reject", h.rejectDevice)
+
+	// Channel status (OpenClaw channel configuration detection)
+	r.Get("/channels/status", h.getChannelStatus)
+}
+
+// expiryWarning is how far ahead of a token's expiry the dashboard lists
+// it; serve sets it to match the expiry notifications.
+var expiryWarning = 72 * time.Hour
+
+// SetExpiryWarning sets how far ahead of expiry the dashboard lists tokens.
+func SetExpiryWarning(within time.Duration) {
+	expiryWarning = within
+}
+
 // DashboardResponse contains summary data for the admin dashboard.
 type DashboardResponse struct {
-	TotalCredentials   int                   `json:"totalCredentials"`
-	PendingRequests    int                   `json:"pendingRequests"`
-	ActiveElevations   int                   `json:"activeElevations"`
-	RecentAuditEntries []*store.AuditEntry   `json:"recentAudit"`
-	Pending            []*store.Elevation    `json:"pending"`
+	TotalCredentials   int                    `json:"totalCredentials"`
+	PendingRequests    int                    `json:"pendingRequests"`
+	ActiveElevations   int                    `json:"activeElevations"`
+	RecentAuditEntries []*store.AuditEntry    `json:"recentAudit"`
+	Pending            []*store.Elevation     `json:"pending"`
+	Expiring           []notify.ExpiringToken `json:"expiring"` // Within the expiry warning window, soonest first
 }
 
 // CreateCredentialRequest is the request body for creating credentials.
@@ -106,6 +226,163 @@ type CreateCredentialRequest struct {
 	Type        string             `json:"type"`
 	Read        *AccessLevelConfig `json:"read"`               // Required - always available
 	ReadWrite   *AccessLevelConfig `json:"readWrite,omitempty"` // Optional - requires elevation
+
+	// Optional webhook fired on every agent access (set url to "" on update to remove)
+	AccessWebhook *AccessWebhookConfig `json:"accessWebhook,omitempty"`
+
+	// Optional concurrency limit (0 = unlimited) and overflow policy ("reject" or "queue").
+	// Omitted on update = unchanged.
+	MaxConcurrentElevations *int    `json:"maxConcurrentElevations,omitempty"`
+	ElevationOverflow       *string `json:"elevationOverflow,omitempty"`
+
+	// Optional exclusive checkout of write access. Omitted on update = unchanged.
+	ExclusiveCheckout *bool `json:"exclusiveCheckout,omitempty"`
+
+	// Optional gateway to inject into (empty = default). Omitted on update = unchanged.
+	Gateway *string `json:"gateway,omitempty"`
+
+	// Optional rotation hook (omitted on update = unchanged, no command or url = removed)
+	Rotation *RotationConfig `json:"rotation,omitempty"`
+}
+
+// applyLimits validates and copies the concurrency and checkout settings
+// onto cred.
+func (req *CreateCredentialRequest) applyLimits(cred *store.Credential) error {
+	if req.MaxConcurrentElevations != nil {
+		if *req.MaxConcurrentElevations < 0 {
+			return fmt.Errorf("maxConcurrentElevations must not be negative")
+		}
+		cred.MaxConcurrentElevations = *req.MaxConcurrentElevations
+	}
+	if req.ElevationOverflow != nil {
+		switch overflow := store.ElevationOverflow(*req.ElevationOverflow); overflow {
+		case "", store.OverflowReject, store.OverflowQueue:
+			cred.ElevationOverflow = overflow
+		default:
+			return fmt.Errorf("elevationOverflow must be \"reject\" or \"queue\"")
+		}
+	}
+	if req.ExclusiveCheckout != nil {
+		cred.ExclusiveCheckout = *req.ExclusiveCheckout
+	}
+	return nil
+}
+
+// applyGateway validates the gateway selector and copies it onto cred.
+func (h *adminHandler) applyGateway(req *CreateCredentialRequest, cred *store.Credential) error {
+	if req.Gateway == nil {
+		return nil
+	}
+	if h.elevation != nil {
+		if _, err := h.elevation.GatewayFor(*req.Gateway); err != nil {
+			return err
+		}
+	}
+	cred.Gateway = *req.Gateway
+	return nil
+}
+
+// credentialGateway returns the gateway cred is injected into, or nil if
+// injection is unavailable.
+func (h *adminHandler) credentialGateway(cred *store.Credential) *gateway.Client {
+	if h.elevation == nil {
+		return nil
+	}
+	gw, _ := h.elevation.GatewayFor(cred.Gateway)
+	return gw
+}
+
+// injectionTargets returns the env vars and config paths cred is injected into.
+func injectionTargets(cred *store.Credential) (envVars, configPaths []string) {
+	seen := make(map[string]bool)
+	for _, level := range []*store.AccessLevel{cred.Read, cred.ReadWrite} {
+		env, config := gateway.LevelTargets(level)
+		for _, name := range env {
+			if !seen["env:"+name] {
+				seen["env:"+name] = true
+				envVars = append(envVars, name)
+			}
+		}
+		for _, path := range config {
+			if !seen["config:"+path] {
+				seen["config:"+path] = true
+				configPaths = append(configPaths, path)
+			}
+		}
+	}
+	return envVars, configPaths
+}
+
+// droppedTargets returns the targets of previous that cred no longer injects
+// into, so an update that renames an env var or config path removes the old
+// one.
+func droppedTargets(previous, cred *store.Credential) (envVars, configPaths []string) {
+	oldEnv, oldConfig := gateway.LevelTargets(previous.Read)
+	newEnv, newConfig := injectionTargets(cred)
+	keep := make(map[string]bool)
+	for _, name := range newEnv {
+		keep["env:"+name] = true
+	}
+	for _, path := range newConfig {
+		keep["config:"+path] = true
+	}
+	for _, name := range oldEnv {
+		if !keep["env:"+name] {
+			envVars = append(envVars, name)
+		}
+	}
+	for _, path := range oldConfig {
+		if !keep["config:"+path] {
+			configPaths = append(configPaths, path)
+		}
+	}
+	return envVars, configPaths
+}
+
+// clearInjection removes cred's env vars and config paths from gw. Cleanup
+// is best-effort: failures are logged, not returned.
+func (h *adminHandler) clearInjection(gw *gateway.Client, cred *store.Credential) {
+	envVars, configPaths := injectionTargets(cred)
+	inj := gateway.Injection{ClearEnv: envVars, ClearConfig: configPaths}
+	if err := gw.Apply(inj, "OCM credential removal"); err != nil {
+		h.logger.Error("failed to clear credential injection", "error", err, "envVars", envVars, "paths", configPaths)
+	}
+}
+
+// injectionWarning explains to the admin why a saved credential couldn't be
+// injected, or returns "" if there's nothing they can do about err.
+func injectionWarning(err error) string {
+	var rateLimited *gateway.ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		return fmt.Sprintf("Gateway restart rate limited. The credential was saved but OpenClaw will pick it up on the next restart (or wait %v and try again).", rateLimited.RetryAfter)
+	case errors.Is(err, gateway.ErrRestartDisabled):
+		return "Gateway restart disabled. The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
+	case errors.Is(err, gateway.ErrConfigFileLocked):
+		return "Gateway config file is locked (WSL2 issue). The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
+	case errors.Is(err, gateway.ErrQueued):
+		return "Gateway is unreachable. The credential was saved and the change is queued; it will be applied automatically when OCM reconnects to the Gateway."
+	case errors.Is(err, gateway.ErrDegraded):
+		return "Gateway is degraded (recent calls failed). The credential was saved but OpenClaw will pick it up on the next restart."
+	case errors.Is(err, gateway.ErrUnsupported):
+		return "This Gateway doesn't support config patches. The credential was saved but must be added to the OpenClaw config manually."
+	}
+	return ""
+}
+
+// AccessWebhookConfig configures a per-credential access webhook.
+type AccessWebhookConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"` // HMAC signing secret; kept unchanged on update if empty
+}
+
+// validate checks the webhook URL is an absolute http(s) URL.
+func (c *AccessWebhookConfig) validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("accessWebhook.url must be an absolute http(s) URL")
+	}
+	return nil
 }
 
 // AccessLevelConfig is the configuration for a single access level.
@@ -121,8 +398,32 @@ type AccessLevelConfig struct {
 	RefreshToken string `json:"refreshToken,omitempty"` // For OAuth
 	MaxTTL       string `json:"maxTTL,omitempty"`       // e.g., "1h" - only for ReadWrite
 
+	// When the token itself expires, to warn ahead of it (optional)
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
 	// Additional fields injected alongside the primary token (e.g., Slack cookie)
 	AdditionalFields []AdditionalFieldConfig `json:"additionalFields,omitempty"`
+
+	// Derive has agents get short-lived tokens minted from Token (or
+	// RefreshToken) instead; the level is then not injected into the Gateway
+	Derive *DerivationConfig `json:"derive,omitempty"`
+}
+
+// DerivationConfig configures minting short-lived tokens for a level.
+type DerivationConfig struct {
+	Kind string `json:"kind"` // github_app, aws_sts or oauth_refresh
+
+	AppID          string `json:"appId,omitempty"`          // github_app
+	InstallationID string `json:"installationId,omitempty"` // github_app
+	AccessKeyID    string `json:"accessKeyId,omitempty"`    // aws_sts
+	RoleARN        string `json:"roleArn,omitempty"`        // aws_sts
+	Region         string `json:"region,omitempty"`         // aws_sts
+	TokenURL       string `json:"tokenUrl,omitempty"`       // oauth_refresh
+	ClientID       string `json:"clientId,omitempty"`       // oauth_refresh
+	ClientSecret   string `json:"clientSecret,omitempty"`   // oauth_refresh; empty keeps the current one on update
+
+	Endpoint string `json:"endpoint,omitempty"` // Provider API base URL override
+	TTL      string `json:"ttl,omitempty"`      // e.g. "1h", where the provider takes one
 }
 
 // AdditionalFieldConfig is an extra field injected with the primary token.
@@ -134,6 +435,58 @@ type AdditionalFieldConfig struct {
 	Value         string `json:"value"`
 }
 
+// storeAdditionalFields converts the additional fields for storage.
+func (a *AccessLevelConfig) storeAdditionalFields() []store.AdditionalField {
+	var fields []store.AdditionalField
+	for _, af := range a.AdditionalFields {
+		injType := store.InjectionEnv
+		if af.InjectionType == "config" {
+			injType = store.InjectionConfig
+		}
+		fields = append(fields, store.AdditionalField{
+			Name:          af.Name,
+			InjectionType: injType,
+			EnvVar:        af.EnvVar,
+			ConfigPath:    af.ConfigPath,
+			Value:         af.Value,
+		})
+	}
+	return fields
+}
+
+// applyDerive sets level's derivation from the request and validates it.
+// previous is the level being replaced on update, or nil.
+func (a *AccessLevelConfig) applyDerive(level, previous *store.AccessLevel) error {
+	if a.Derive == nil {
+		return nil
+	}
+	c := a.Derive
+	d := &store.Derivation{
+		Kind:           store.DerivationKind(c.Kind),
+		AppID:          c.AppID,
+		InstallationID: c.InstallationID,
+		AccessKeyID:    c.AccessKeyID,
+		RoleARN:        c.RoleARN,
+		Region:         c.Region,
+		TokenURL:       c.TokenURL,
+		ClientID:       c.ClientID,
+		ClientSecret:   c.ClientSecret,
+		Endpoint:       c.Endpoint,
+	}
+	if c.TTL != "" {
+		ttl, err := time.ParseDuration(c.TTL)
+		if err != nil || ttl <= 0 {
+			return errors.New("invalid derive ttl")
+		}
+		d.TTL = ttl
+	}
+	if d.ClientSecret == "" && previous != nil && previous.Derive != nil {
+		d.ClientSecret = previous.Derive.ClientSecret
+	}
+	level.Derive = d
+	return derive.Validate(level)
+}
+
 // GetInjectionType returns the injection type, defaulting to "env".
 func (a *AccessLevelConfig) GetInjectionType() store.InjectionType {
 	if a.InjectionType == "config" {
@@ -150,35 +503,65 @@ func (a *AccessLevelConfig) GetInjectionKey() string {
 	return a.EnvVar
 }
 
+// validateConfigPaths checks that every config path parses.
+func (a *AccessLevelConfig) validateConfigPaths() error {
+	if a == nil {
+		return nil
+	}
+	if a.GetInjectionType() == store.InjectionConfig {
+		if _, err := gateway.ParseConfigPath(a.ConfigPath); err != nil {
+			return err
+		}
+	}
+	for _, af := range a.AdditionalFields {
+		if af.InjectionType == "config" && af.ConfigPath != "" {
+			if _, err := gateway.ParseConfigPath(af.ConfigPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // ApproveRequest is the request body for approving an elevation.
 type ApproveRequest struct {
-	TTL string `json:"ttl"` // e.g., "30m", "1h"
+	TTL    string `json:"ttl"`              // e.g., "30m", "1h"
+	Preset string `json:"preset,omitempty"` // Named preset for the service; overrides TTL
 }
 
-// SetupStatusResponse indicates whether initial setup is complete.
+// DenyRequest is the optional body of a deny.
+type DenyRequest struct {
+	Reason string `json:"reason,omitempty"` // Recorded in the audit log
+}
+
+// SetupStatusResponse reports the setup wizard's progress.
 type SetupStatusResponse struct {
 	SetupComplete    bool              `json:"setupComplete"`
+	CurrentStep      store.SetupStep   `json:"currentStep,omitempty"` // First step neither completed nor skipped
+	Steps            []SetupStepStatus `json:"steps"`
+	StartedAt        time.Time         `json:"startedAt"`
+	CompletedAt      *time.Time        `json:"completedAt,omitempty"`
 	MissingKeys      []string          `json:"missingKeys"`      // Required credentials not yet configured
 	ConfiguredKeys   []string          `json:"configuredKeys"`   // Already configured credentials
-	GatewayStatus    *GatewayStatusInfo `json:"gatewayStatus,omitempty"` // Gateway connection status
-}
-
-// GatewayStatusInfo contains Gateway connection and pairing status.
-type GatewayStatusInfo struct {
-	Connected      bool   `json:"connected"`
-	PairingNeeded  bool   `json:"pairingNeeded"`
-	TokenMismatch  bool   `json:"tokenMismatch"`
-	DeviceID       string `json:"deviceId,omitempty"`
-	ApproveCommand string `json:"approveCommand,omitempty"` // Exact command to run
-	FixCommand     string `json:"fixCommand,omitempty"`     // Command to fix token mismatch
 }
 
 // requiredModelProviders lists the services that provide LLM API keys.
 // At least one must be configured for OpenClaw to work.
-// NOTE: Keep in sync with modelProviderIds in SetupWizard.svelte
-var requiredModelProviders = []string{"anthropic", "openai", "openrouter", "groq", "google", "azure-openai"}
+var requiredModelProviders = catalog.ModelProviders()
 
 func (h *adminHandler) getSetupStatus(w http.ResponseWriter, r *http.Request) {
+	st, err := h.setupState()
+	if err != nil {
+		h.logger.Error("setup status: failed to load state", "error", err)
+		h.jsonError(w, "failed to check setup state", http.StatusInternalServerError)
+		return
+	}
+	h.writeSetupStatus(w, st)
+}
+
+// writeSetupStatus responds with the wizard's progress st and the
+// configured credentials.
+func (h *adminHandler) writeSetupStatus(w http.ResponseWriter, st *store.SetupState) {
 	creds, err := h.store.ListCredentials()
 	if err != nil {
 		h.logger.Error("setup status: failed to list credentials", "error", err)
@@ -186,73 +569,63 @@ func (h *adminHandler) getSetupStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if any model provider is configured
-	// Note: ListCredentials() clears tokens for security, so we just check
-	// if a provider credential exists (if it was created, it has a token)
-	var configuredKeys []string
+	resp := SetupStatusResponse{
+		SetupComplete:  st.CompletedAt != nil,
+		CurrentStep:    st.Current(),
+		Steps:          setupSteps(st),
+		StartedAt:      st.StartedAt,
+		CompletedAt:    st.CompletedAt,
+		MissingKeys:    []string{},
+		ConfiguredKeys: []string{},
+	}
 	hasModelProvider := false
-
-	h.logger.Info("setup status check", "credentialCount", len(creds))
 	for _, cred := range creds {
-		configuredKeys = append(configuredKeys, cred.Service)
-		h.logger.Info("checking credential", "service", cred.Service, "hasRead", cred.Read != nil, "hasReadWrite", cred.ReadWrite != nil)
-		for _, provider := range requiredModelProviders {
-			if cred.Service == provider {
-				// Provider credential exists - setup is complete
-				h.logger.Info("found model provider", "provider", provider)
-				hasModelProvider = true
-				break
-			}
-		}
-	}
-
-	// Ensure empty slice instead of nil
-	if configuredKeys == nil {
-		configuredKeys = []string{}
-	}
-
-	resp := SetupStatusResponse{
-		SetupComplete:  hasModelProvider,
-		ConfiguredKeys: configuredKeys,
+		resp.ConfiguredKeys = append(resp.ConfiguredKeys, cred.Service)
+		hasModelProvider = hasModelProvider || isModelProvider(cred.Service)
 	}
-
 	if !hasModelProvider {
 		resp.MissingKeys = []string{"anthropic OR openai OR google OR azure-openai"}
-	} else {
-		resp.MissingKeys = []string{}
 	}
 
-	// Add Gateway connection status
-	if h.rpc != nil {
-		gwStatus := &GatewayStatusInfo{
-			Connected:     h.rpc.IsConnected(),
-			PairingNeeded: h.rpc.NeedsPairing(),
-			TokenMismatch: h.rpc.TokenMismatch(),
-			DeviceID:      h.rpc.GetDeviceID(),
+	h.jsonResponse(w, resp)
+}
+
+// completeSetup finishes the wizard and restarts the Gateway. Steps not yet
+// done are completed on the way, which a model provider step can only be
+// once a model provider credential exists.
+func (h *adminHandler) completeSetup(w http.ResponseWriter, r *http.Request) {
+	st, err := h.setupState()
+	if err != nil {
+		h.logger.Error("setup: load state failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	for _, step := range store.SetupSteps {
+		if st.Done(step) {
+			continue
 		}
-		
-		if gwStatus.PairingNeeded {
-			// Provide exact command to approve
-			if reqID := h.rpc.GetPendingRequestID(); reqID != "" {
-				gwStatus.ApproveCommand = fmt.Sprintf("docker exec -it openclaw node /app/dist/index.js devices approve %s", reqID)
-			} else {
-				// Don't know the request ID yet, show list command first
-				gwStatus.ApproveCommand = "docker exec -it openclaw node /app/dist/index.js devices list\n# Then: docker exec -it openclaw node /app/dist/index.js devices approve <requestId>"
+		if step == store.SetupModelProvider {
+			hasProvider, err := h.hasModelProvider()
+			if err != nil {
+				h.logger.Error("setup: list credentials failed", "error", err)
+				h.jsonError(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !hasProvider {
+				h.jsonError(w, "add a model provider credential first", http.StatusConflict)
+				return
 			}
 		}
-		
-		if gwStatus.TokenMismatch {
-			// Provide a simple script command, similar to device approval
-			gwStatus.FixCommand = "./scripts/sync-token.sh"
-		}
-		
-		resp.GatewayStatus = gwStatus
+		st.Completed[step] = now
+	}
+	st.CompletedAt = &now
+	if err := h.store.SaveSetupState(st); err != nil {
+		h.logger.Error("setup: save state failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
 	}
 
-	h.jsonResponse(w, resp)
-}
-
-func (h *adminHandler) completeSetup(w http.ResponseWriter, r *http.Request) {
 	// Trigger Gateway restart to pick up any new credentials
 	if h.elevation != nil && h.elevation.Gateway() != nil {
 		if err := h.elevation.Gateway().SyncAndRestart("setup complete"); err != nil {
@@ -262,12 +635,12 @@ func (h *adminHandler) completeSetup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "setup_completed",
+		Action:    store.ActionSetupCompleted,
 		Actor:     "admin",
-	})
+	}))
 
 	h.logger.Info("setup completed, gateway restart triggered")
 
@@ -296,8 +669,11 @@ func (h *adminHandler) getDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Count active elevations (simplified - would need a real query)
-	activeCount := 0
+	active, err := h.store.ListActiveElevations()
+	if err != nil {
+		h.jsonError(w, "failed to list active elevations", http.StatusInternalServerError)
+		return
+	}
 
 	// Ensure we return empty slices instead of nil (JSON: [] not null)
 	if audit == nil {
@@ -310,9 +686,10 @@ func (h *adminHandler) getDashboard(w http.ResponseWriter, r *http.Request) {
 	h.jsonResponse(w, DashboardResponse{
 		TotalCredentials:   len(creds),
 		PendingRequests:    len(pending),
-		ActiveElevations:   activeCount,
+		ActiveElevations:   len(active),
 		RecentAuditEntries: audit,
 		Pending:            pending,
+		Expiring:           notify.Expiring(creds, time.Now(), expiryWarning),
 	})
 }
 
@@ -337,31 +714,66 @@ func (h *adminHandler) createCredential(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.Service == "" || req.DisplayName == "" {
-		h.jsonError(w, "service and displayName are required", http.StatusBadRequest)
+	cred, err := h.credentialFromRequest(&req)
+	if err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	duplicate := h.duplicateSecrets(cred)
+	if duplicate != "" && blockDuplicateSecrets {
+		h.jsonError(w, duplicate, http.StatusConflict)
 		return
 	}
 
-	// Validate read access has an injection target
-	if req.Read == nil || req.Read.GetInjectionKey() == "" {
-		h.jsonError(w, "read access with envVar or configPath is required", http.StatusBadRequest)
+	if err := h.store.SaveCredential(cred); err != nil {
+		h.logger.Error("save credential failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	// Convert additional fields
-	var additionalFields []store.AdditionalField
-	for _, af := range req.Read.AdditionalFields {
-		injType := store.InjectionEnv
-		if af.InjectionType == "config" {
-			injType = store.InjectionConfig
+	// Sync read credentials to Gateway and restart
+	var restartWarning string
+	if gw := h.credentialGateway(cred); gw != nil {
+		env, config := gateway.LevelCredentials(cred.Read)
+		inj := gateway.Injection{Env: env, Config: config}
+		if err := gw.Apply(inj, "credential created: "+req.Service); err != nil {
+			h.logger.Error("failed to inject credential", "error", err)
+			restartWarning = injectionWarning(err)
+		} else {
+			h.elevation.VerifyInjection(cred, cred.Read)
 		}
-		additionalFields = append(additionalFields, store.AdditionalField{
-			Name:          af.Name,
-			InjectionType: injType,
-			EnvVar:        af.EnvVar,
-			ConfigPath:    af.ConfigPath,
-			Value:         af.Value,
+	}
+
+	h.credentialCreated(r, cred)
+
+	w.WriteHeader(http.StatusCreated)
+	
+	// Include warning in response if restart failed or the token is shared
+	if warning := joinWarnings(duplicate, restartWarning); warning != "" {
+		h.jsonResponse(w, map[string]interface{}{
+			"credential": cred,
+			"warning":    warning,
 		})
+		return
+	}
+	h.jsonResponse(w, cred)
+}
+
+// credentialFromRequest validates req and converts it to a new credential.
+// Errors are meant for the client.
+func (h *adminHandler) credentialFromRequest(req *CreateCredentialRequest) (*store.Credential, error) {
+	if req.Service == "" || req.DisplayName == "" {
+		return nil, errors.New("service and displayName are required")
+	}
+
+	// Validate read access has an injection target
+	if req.Read == nil || req.Read.GetInjectionKey() == "" {
+		return nil, errors.New("read access with envVar or configPath is required")
+	}
+	for _, level := range []*AccessLevelConfig{req.Read, req.ReadWrite} {
+		if err := level.validateConfigPaths(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Convert to store.Credential with new Read/ReadWrite model
@@ -376,11 +788,32 @@ func (h *adminHandler) createCredential(w http.ResponseWriter, r *http.Request)
 			ConfigPath:       req.Read.ConfigPath,
 			Token:            req.Read.Token,
 			RefreshToken:     req.Read.RefreshToken,
-			AdditionalFields: additionalFields,
+			ExpiresAt:        req.Read.ExpiresAt,
+			AdditionalFields: req.Read.storeAdditionalFields(),
 		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	if err := req.Read.applyDerive(cred.Read, nil); err != nil {
+		return nil, err
+	}
+
+	if req.AccessWebhook != nil && req.AccessWebhook.URL != "" {
+		if err := req.AccessWebhook.validate(); err != nil {
+			return nil, err
+		}
+		cred.AccessWebhook = &store.AccessWebhook{URL: req.AccessWebhook.URL, Secret: req.AccessWebhook.Secret}
+	}
+
+	if err := req.applyLimits(cred); err != nil {
+		return nil, err
+	}
+	if err := req.applyRotation(cred); err != nil {
+		return nil, err
+	}
+	if err := h.applyGateway(req, cred); err != nil {
+		return nil, err
+	}
 
 	// Add ReadWrite access if provided
 	if req.ReadWrite != nil && req.ReadWrite.GetInjectionKey() != "" {
@@ -389,105 +822,46 @@ func (h *adminHandler) createCredential(w http.ResponseWriter, r *http.Request)
 			var err error
 			maxTTL, err = time.ParseDuration(req.ReadWrite.MaxTTL)
 			if err != nil {
-				h.jsonError(w, "invalid maxTTL format for readWrite", http.StatusBadRequest)
-				return
+				return nil, errors.New("invalid maxTTL format for readWrite")
 			}
 		} else {
 			maxTTL = 30 * time.Minute // Default max TTL
 		}
 
 		cred.ReadWrite = &store.AccessLevel{
-			InjectionType: req.ReadWrite.GetInjectionType(),
-			EnvVar:        req.ReadWrite.EnvVar,
-			ConfigPath:    req.ReadWrite.ConfigPath,
-			Token:         req.ReadWrite.Token,
-			RefreshToken:  req.ReadWrite.RefreshToken,
-			MaxTTL:        maxTTL,
+			InjectionType:    req.ReadWrite.GetInjectionType(),
+			EnvVar:           req.ReadWrite.EnvVar,
+			ConfigPath:       req.ReadWrite.ConfigPath,
+			Token:            req.ReadWrite.Token,
+			RefreshToken:     req.ReadWrite.RefreshToken,
+			ExpiresAt:        req.ReadWrite.ExpiresAt,
+			MaxTTL:           maxTTL,
+			AdditionalFields: req.ReadWrite.storeAdditionalFields(),
 		}
-	}
-
-	if err := h.store.SaveCredential(cred); err != nil {
-		h.logger.Error("save credential failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
-	}
-
-	// Sync read credentials to Gateway and restart
-	var restartWarning string
-	if h.elevation != nil && h.elevation.Gateway() != nil {
-		if cred.Read != nil && cred.Read.Token != "" {
-			injType := cred.Read.GetInjectionType()
-			injKey := cred.Read.GetInjectionKey()
-
-			if injKey != "" {
-				var writeErr error
-				if injType == store.InjectionConfig {
-					// Config injection - patch the config file (triggers restart)
-					// Collect all config credentials (primary + additional fields)
-					configCreds := []gateway.ConfigCredential{
-						{Path: injKey, Value: cred.Read.Token},
-					}
-					for _, af := range cred.Read.AdditionalFields {
-						if af.InjectionType == store.InjectionConfig && af.ConfigPath != "" {
-							configCreds = append(configCreds, gateway.ConfigCredential{
-								Path: af.ConfigPath, Value: af.Value,
-							})
-						}
-					}
-					writeErr = h.elevation.Gateway().SetConfigCredentials(configCreds)
-				} else {
-					// Env injection - write to .env file
-					writeErr = h.elevation.Gateway().WriteCredentialToEnv(injKey, cred.Read.Token)
-					// Also write additional env fields
-					for _, af := range cred.Read.AdditionalFields {
-						if af.InjectionType == store.InjectionEnv && af.EnvVar != "" && writeErr == nil {
-							writeErr = h.elevation.Gateway().WriteCredentialToEnv(af.EnvVar, af.Value)
-						}
-					}
-					if writeErr == nil {
-						// Trigger Gateway restart to pick up new credential
-						writeErr = h.elevation.Gateway().RestartGateway("credential created: " + req.Service)
-					}
-				}
-
-				if writeErr != nil {
-					h.logger.Error("failed to inject credential", "error", writeErr)
-					switch e := writeErr.(type) {
-					case *gateway.ErrRateLimited:
-						restartWarning = fmt.Sprintf("Gateway restart rate limited. The credential was saved but OpenClaw will pick it up on the next restart (or wait %v and try again).", e.RetryAfter)
-					default:
-						if writeErr == gateway.ErrRestartDisabled {
-							restartWarning = "Gateway restart disabled. The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
-						} else if writeErr == gateway.ErrConfigFileLocked {
-							restartWarning = "Gateway config file is locked (WSL2 issue). The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
-						}
-					}
-				}
-			}
+		if err := req.ReadWrite.applyDerive(cred.ReadWrite, nil); err != nil {
+			return nil, err
 		}
 	}
+	if err := format.Validate(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
 
-	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+// credentialCreated records a saved credential in the audit log and
+// notifies subscribers.
+func (h *adminHandler) credentialCreated(r *http.Request, cred *store.Credential) {
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "credential_created",
-		Service:   req.Service,
+		Action:    store.ActionCredentialCreated,
+		Service:   cred.Service,
 		Actor:     "admin",
-	})
+	}))
 
-	h.logger.Info("credential created", "service", req.Service)
-	w.WriteHeader(http.StatusCreated)
-	
-	// Include warning in response if restart failed
-	if restartWarning != "" {
-		h.jsonResponse(w, map[string]interface{}{
-			"credential": cred,
-			"warning":    restartWarning,
-		})
-		return
-	}
-	h.jsonResponse(w, cred)
+	h.notifier.Publish(notify.Event{Type: notify.EventCredentialCreated, Service: cred.Service, Actor: "admin"})
+
+	h.logger.Info("credential created", "service", cred.Service)
 }
 
 func (h *adminHandler) getCredential(w http.ResponseWriter, r *http.Request) {
@@ -513,6 +887,12 @@ func (h *adminHandler) updateCredential(w http.ResponseWriter, r *http.Request)
 		h.jsonError(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	for _, level := range []*AccessLevelConfig{req.Read, req.ReadWrite} {
+		if err := level.validateConfigPaths(); err != nil {
+			h.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Get existing
 	existing, err := h.store.GetCredential(service)
@@ -525,6 +905,9 @@ func (h *adminHandler) updateCredential(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Remember where it was injected, in case it moves gateway
+	previous := *existing
+
 	// Update fields
 	existing.DisplayName = req.DisplayName
 	existing.Type = req.Type
@@ -533,11 +916,17 @@ func (h *adminHandler) updateCredential(w http.ResponseWriter, r *http.Request)
 	// Update Read access
 	if req.Read != nil {
 		existing.Read = &store.AccessLevel{
-			InjectionType: req.Read.GetInjectionType(),
-			EnvVar:        req.Read.EnvVar,
-			ConfigPath:    req.Read.ConfigPath,
-			Token:         req.Read.Token,
-			RefreshToken:  req.Read.RefreshToken,
+			InjectionType:    req.Read.GetInjectionType(),
+			EnvVar:           req.Read.EnvVar,
+			ConfigPath:       req.Read.ConfigPath,
+			Token:            req.Read.Token,
+			RefreshToken:     req.Read.RefreshToken,
+			ExpiresAt:        req.Read.ExpiresAt,
+			AdditionalFields: req.Read.storeAdditionalFields(),
+		}
+		if err := req.Read.applyDerive(existing.Read, previous.Read); err != nil {
+			h.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 	}
 
@@ -550,76 +939,107 @@ func (h *adminHandler) updateCredential(w http.ResponseWriter, r *http.Request)
 			maxTTL = 30 * time.Minute
 		}
 		existing.ReadWrite = &store.AccessLevel{
-			InjectionType: req.ReadWrite.GetInjectionType(),
-			EnvVar:        req.ReadWrite.EnvVar,
-			ConfigPath:    req.ReadWrite.ConfigPath,
-			Token:         req.ReadWrite.Token,
-			RefreshToken:  req.ReadWrite.RefreshToken,
-			MaxTTL:        maxTTL,
+			InjectionType:    req.ReadWrite.GetInjectionType(),
+			EnvVar:           req.ReadWrite.EnvVar,
+			ConfigPath:       req.ReadWrite.ConfigPath,
+			Token:            req.ReadWrite.Token,
+			RefreshToken:     req.ReadWrite.RefreshToken,
+			ExpiresAt:        req.ReadWrite.ExpiresAt,
+			MaxTTL:           maxTTL,
+			AdditionalFields: req.ReadWrite.storeAdditionalFields(),
+		}
+		if err := req.ReadWrite.applyDerive(existing.ReadWrite, previous.ReadWrite); err != nil {
+			h.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 	} else {
 		existing.ReadWrite = nil // Clear if not provided
 	}
 
+	// Update access webhook (omitted = unchanged, empty url = removed)
+	if req.AccessWebhook != nil {
+		if req.AccessWebhook.URL == "" {
+			existing.AccessWebhook = nil
+		} else {
+			if err := req.AccessWebhook.validate(); err != nil {
+				h.jsonError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			secret := req.AccessWebhook.Secret
+			if secret == "" && existing.AccessWebhook != nil {
+				secret = existing.AccessWebhook.Secret
+			}
+			existing.AccessWebhook = &store.AccessWebhook{URL: req.AccessWebhook.URL, Secret: secret}
+		}
+	}
+
+	if err := req.applyLimits(existing); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.applyRotation(existing); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.applyGateway(&req, existing); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := format.Validate(existing); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	duplicate := h.duplicateSecrets(existing)
+	if duplicate != "" && blockDuplicateSecrets {
+		h.jsonError(w, duplicate, http.StatusConflict)
+		return
+	}
+
 	if err := h.store.SaveCredential(existing); err != nil {
 		h.jsonError(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
 	// Sync read credentials to Gateway and restart
-	var restartWarning string
-	if h.elevation != nil && h.elevation.Gateway() != nil {
-		if existing.Read != nil && existing.Read.Token != "" {
-			injType := existing.Read.GetInjectionType()
-			injKey := existing.Read.GetInjectionKey()
-
-			if injKey != "" {
-				var writeErr error
-				if injType == store.InjectionConfig {
-					// Config injection - patch the config file (triggers restart)
-					writeErr = h.elevation.Gateway().SetConfigCredentials([]gateway.ConfigCredential{
-						{Path: injKey, Value: existing.Read.Token},
-					})
-				} else {
-					// Env injection - write to .env file
-					writeErr = h.elevation.Gateway().WriteCredentialToEnv(injKey, existing.Read.Token)
-					if writeErr == nil {
-						// Trigger Gateway restart to pick up updated credential
-						writeErr = h.elevation.Gateway().RestartGateway("credential updated: " + service)
-					}
-				}
+	// Moved to another gateway: remove it from the old one
+	if previous.Gateway != existing.Gateway {
+		if old := h.credentialGateway(&previous); old != nil {
+			h.clearInjection(old, &previous)
+		}
+	}
 
-				if writeErr != nil {
-					h.logger.Error("failed to inject credential", "error", writeErr)
-					switch e := writeErr.(type) {
-					case *gateway.ErrRateLimited:
-						restartWarning = fmt.Sprintf("Gateway restart rate limited. The credential was saved but OpenClaw will pick it up on the next restart (or wait %v and try again).", e.RetryAfter)
-					default:
-						if writeErr == gateway.ErrRestartDisabled {
-							restartWarning = "Gateway restart disabled. The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
-						} else if writeErr == gateway.ErrConfigFileLocked {
-							restartWarning = "Gateway config file is locked (WSL2 issue). The credential was saved but OpenClaw needs to be restarted manually.\n\nRestart with: docker compose restart openclaw"
-						}
-					}
-				}
-			}
+	var restartWarning string
+	if gw := h.credentialGateway(existing); gw != nil {
+		env, config := gateway.LevelCredentials(existing.Read)
+		inj := gateway.Injection{Env: env, Config: config}
+		if previous.Gateway == existing.Gateway {
+			inj.ClearEnv, inj.ClearConfig = droppedTargets(&previous, existing)
+		}
+		if err := gw.Apply(inj, "credential updated: "+service); err != nil {
+			h.logger.Error("failed to inject credential", "error", err)
+			restartWarning = injectionWarning(err)
+		} else {
+			h.elevation.VerifyInjection(existing, existing.Read)
 		}
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "credential_updated",
+		Action:    store.ActionCredentialUpdated,
 		Service:   service,
 		Actor:     "admin",
-	})
+	}))
+
+	h.notifier.Publish(notify.Event{Type: notify.EventCredentialUpdated, Service: service, Actor: "admin"})
+	h.revokeServiceLeases(service, "credential updated")
 
-	// Include warning in response if restart failed
-	if restartWarning != "" {
+	// Include warning in response if restart failed or the token is shared
+	if warning := joinWarnings(duplicate, restartWarning); warning != "" {
 		h.jsonResponse(w, map[string]interface{}{
 			"credential": existing,
-			"warning":    restartWarning,
+			"warning":    warning,
 		})
 		return
 	}
@@ -640,29 +1060,7 @@ func (h *adminHandler) deleteCredential(w http.ResponseWriter, r *http.Request)
 	var envVarsToClear []string
 	var configPathsToClear []string
 	if cred != nil {
-		if cred.Read != nil {
-			injType := cred.Read.GetInjectionType()
-			injKey := cred.Read.GetInjectionKey()
-			if injKey != "" {
-				if injType == store.InjectionConfig {
-					configPathsToClear = append(configPathsToClear, injKey)
-				} else {
-					envVarsToClear = append(envVarsToClear, injKey)
-				}
-			}
-		}
-		if cred.ReadWrite != nil {
-			injType := cred.ReadWrite.GetInjectionType()
-			injKey := cred.ReadWrite.GetInjectionKey()
-			// Only add if different from read's target
-			if injKey != "" && (cred.Read == nil || injKey != cred.Read.GetInjectionKey()) {
-				if injType == store.InjectionConfig {
-					configPathsToClear = append(configPathsToClear, injKey)
-				} else {
-					envVarsToClear = append(envVarsToClear, injKey)
-				}
-			}
-		}
+		envVarsToClear, configPathsToClear = injectionTargets(cred)
 	}
 
 	// Delete from database
@@ -671,30 +1069,25 @@ func (h *adminHandler) deleteCredential(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Clear from .env and restart Gateway
-	if h.elevation != nil && h.elevation.Gateway() != nil {
-		if len(envVarsToClear) > 0 {
-			if err := h.elevation.Gateway().ClearCredentials(envVarsToClear); err != nil {
-				h.logger.Error("failed to clear credentials from env", "error", err, "envVars", envVarsToClear)
-				// Don't fail - credential is deleted from DB, env cleanup is best-effort
-			}
-		}
-		if len(configPathsToClear) > 0 {
-			if err := h.elevation.Gateway().ClearConfigCredentials(configPathsToClear); err != nil {
-				h.logger.Error("failed to clear credentials from config", "error", err, "paths", configPathsToClear)
-				// Don't fail - credential is deleted from DB, config cleanup is best-effort
-			}
+	// Clear from .env and restart Gateway. Don't fail - the credential is
+	// deleted from the DB, injection cleanup is best-effort.
+	if cred != nil {
+		if gw := h.credentialGateway(cred); gw != nil {
+			h.clearInjection(gw, cred)
 		}
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "credential_deleted",
+		Action:    store.ActionCredentialDeleted,
 		Service:   service,
 		Actor:     "admin",
-	})
+	}))
+
+	h.notifier.Publish(notify.Event{Type: notify.EventCredentialDeleted, Service: service, Actor: "admin"})
+	h.revokeServiceLeases(service, "credential deleted")
 
 	h.logger.Info("credential deleted", "service", service, "clearedEnvVars", envVarsToClear, "clearedConfigPaths", configPathsToClear)
 	w.WriteHeader(http.StatusNoContent)
@@ -712,38 +1105,84 @@ func (h *adminHandler) listPendingRequests(w http.ResponseWriter, r *http.Reques
 	h.jsonResponse(w, pending)
 }
 
+func (h *adminHandler) listQueuedRequests(w http.ResponseWriter, r *http.Request) {
+	queued, err := h.store.ListElevationsByStatus("queued", chi.URLParam(r, "service"))
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if queued == nil {
+		queued = []*store.Elevation{}
+	}
+	h.jsonResponse(w, queued)
+}
+
 func (h *adminHandler) approveRequest(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	// Without a TTL or preset, the elevation service grants the TTL the
+	// requester asked for, or its default
 	var req ApproveRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Default to 30 minutes if not specified
-		req.TTL = "30m"
+	json.NewDecoder(r.Body).Decode(&req)
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			h.jsonError(w, "invalid ttl duration", http.StatusBadRequest)
+			return
+		}
+		ttl = d
 	}
 
-	ttl, err := time.ParseDuration(req.TTL)
-	if err != nil {
-		ttl = 30 * time.Minute
+	// Resolve named preset for the request's service
+	if req.Preset != "" {
+		pending, err := h.store.GetElevation(id)
+		if err != nil {
+			h.jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if pending == nil {
+			h.jsonError(w, "not found", http.StatusNotFound)
+			return
+		}
+		preset, err := h.store.GetPreset(pending.Service, req.Preset)
+		if err != nil {
+			h.jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if preset == nil {
+			h.jsonError(w, fmt.Sprintf("unknown preset %q for service %s", req.Preset, pending.Service), http.StatusBadRequest)
+			return
+		}
+		ttl = preset.TTL
 	}
 
 	// Use elevation service to approve and inject credential
-	if err := h.elevation.ApproveElevation(id, ttl, "admin"); err != nil {
+	if err := h.elevation.ApproveElevationContext(r.Context(), id, ttl, "admin"); err != nil {
 		h.logger.Error("approve elevation failed", "error", err)
-		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, elevation.ErrConcurrencyLimit) {
+			status = http.StatusConflict
+		}
+		h.jsonError(w, err.Error(), status)
 		return
 	}
 
 	// Get updated elevation for response
 	elev, _ := h.store.GetElevation(id)
 	
+	granted := elevationResponse(elev).GrantedTTL
 	h.logger.Info("elevation approved via admin API",
 		"request_id", id,
-		"ttl", ttl,
+		"ttl", granted,
+		"preset", req.Preset,
 	)
 
 	h.jsonResponse(w, map[string]interface{}{
 		"status":    "approved",
 		"expiresAt": elev.ExpiresAt,
+		"ttl":       granted,
 	})
 }
 
@@ -760,29 +1199,21 @@ func (h *adminHandler) denyRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.UpdateElevation(id, "denied", "admin", nil); err != nil {
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
+	// The body is optional
+	var req DenyRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if err := h.elevation.DenyElevation(id, "admin", req.Reason); err != nil {
+		h.jsonError(w, err.Error(), http.StatusConflict)
 		return
 	}
 
-	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "elevation_denied",
-		Service:   elev.Service,
-		Scope:     elev.Scope,
-		Actor:     "admin",
-	})
-
-	h.logger.Info("elevation denied", "request_id", id)
-
 	h.jsonResponse(w, map[string]string{"status": "denied"})
 }
 
 func (h *adminHandler) revokeElevation(w http.ResponseWriter, r *http.Request) {
 	service := chi.URLParam(r, "service")
-	scope := chi.URLParam(r, "scope")
+	scope := canonicalScope(chi.URLParam(r, "scope"))
 
 	// Use elevation service to revoke and remove credential from Gateway
 	if err := h.elevation.RevokeElevation(service, scope, "admin revocation"); err != nil {
@@ -796,19 +1227,55 @@ func (h *adminHandler) revokeElevation(w http.ResponseWriter, r *http.Request) {
 	h.jsonResponse(w, map[string]string{"status": "revoked"})
 }
 
+// listAuditEntries returns audit entries newest first, filtered by the
+// service, action, actor, from and to (RFC 3339) query params. At most limit
+// entries are returned; if there are more, the X-Next-Cursor header holds
+// the cursor param for the next page.
 func (h *adminHandler) listAuditEntries(w http.ResponseWriter, r *http.Request) {
-	service := r.URL.Query().Get("service")
-	entries, err := h.store.ListAuditEntries(100, service)
+	q, err := parseAuditQuery(r)
 	if err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.Cursor = r.URL.Query().Get("cursor")
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			h.jsonError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	entries, next, err := h.store.QueryAuditEntries(q)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("list audit entries failed", "error", err)
 		h.jsonError(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 	if entries == nil {
 		entries = []*store.AuditEntry{}
 	}
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
 	h.jsonResponse(w, entries)
 }
 
+// listAuditActions returns the catalog of audit actions, the values the
+// action filter accepts.
+func (h *adminHandler) listAuditActions(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, store.AuditActions())
+}
+
+func (h *adminHandler) getCacheStats(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, h.store.CacheStats())
+}
+
 func (h *adminHandler) jsonResponse(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
@@ -876,13 +1343,15 @@ func (h *adminHandler) approveDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "device_approved",
+		Action:    store.ActionDeviceApproved,
 		Details:   fmt.Sprintf("requestId: %s", requestID),
 		Actor:     "admin",
-	})
+	}))
+
+	h.notifier.Publish(notify.Event{Type: notify.EventDeviceApproved, Details: requestID, Actor: "admin"})
 
 	h.logger.Info("device pairing approved", "requestId", requestID)
 	h.jsonResponse(w, map[string]string{"status": "approved", "requestId": requestID})
@@ -907,13 +1376,15 @@ func (h *adminHandler) rejectDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
 		ID:        generateID("audit"),
 		Timestamp: time.Now(),
-		Action:    "device_rejected",
+		Action:    store.ActionDeviceRejected,
 		Details:   fmt.Sprintf("requestId: %s", requestID),
 		Actor:     "admin",
-	})
+	}))
+
+	h.notifier.Publish(notify.Event{Type: notify.EventDeviceRejected, Details: requestID, Actor: "admin"})
 
 	h.logger.Info("device pairing rejected", "requestId", requestID)
 	h.jsonResponse(w, map[string]string{"status": "rejected", "requestId": requestID})
@@ -1102,7 +1573,7 @@ func spaHandler() http.Handler {
 <body>
 <h1>OCM Admin UI</h1>
 <p>Frontend not yet built. Run <code>make web</code> to build the SvelteKit app.</p>
-<p><a href="/admin/api/dashboard">API Dashboard</a></p>
+<p><a href="/admin/api/v1/dashboard">API Dashboard</a></p>
 </body>
 </html>`))
 		})
@@ -0,0 +1,857 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestAdminAPI_AdditionalFields(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	router := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+
+	req := CreateCredentialRequest{
+		Service: "slack", DisplayName: "Slack", Type: "token",
+		Read: &AccessLevelConfig{EnvVar: "SLACK_TOKEN", Token: "xoxc-read", AdditionalFields: []AdditionalFieldConfig{
+			{Name: "cookie", EnvVar: "SLACK_COOKIE", Value: "d-cookie"},
+		}},
+		ReadWrite: &AccessLevelConfig{EnvVar: "SLACK_WRITE_TOKEN", Token: "xoxc-write", AdditionalFields: []AdditionalFieldConfig{
+			{Name: "cookie", EnvVar: "SLACK_WRITE_COOKIE", Value: "d-write-cookie"},
+		}},
+	}
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", req); w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	cred, err := db.GetCredential("slack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cred.ReadWrite.AdditionalFields) != 1 || cred.ReadWrite.AdditionalFields[0].Value != "d-write-cookie" {
+		t.Errorf("stored readWrite fields = %+v", cred.ReadWrite.AdditionalFields)
+	}
+	env, _ := gw.GetCurrentCredentials()
+	if env["SLACK_TOKEN"] != "xoxc-read" || env["SLACK_COOKIE"] != "d-cookie" {
+		t.Errorf("env after create = %v, want token and cookie", env)
+	}
+	if _, ok := env["SLACK_WRITE_COOKIE"]; ok {
+		t.Errorf("env after create = %v, read-write fields must wait for elevation", env)
+	}
+
+	// Renaming a field removes the old variable
+	req.Read.AdditionalFields[0].EnvVar = "SLACK_D_COOKIE"
+	if w := doJSON(t, router, http.MethodPut, "/admin/api/v1/credentials/slack", req); w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", w.Code, w.Body.String())
+	}
+	env, _ = gw.GetCurrentCredentials()
+	if _, ok := env["SLACK_COOKIE"]; ok || env["SLACK_D_COOKIE"] != "d-cookie" {
+		t.Errorf("env after update = %v, want cookie moved to SLACK_D_COOKIE", env)
+	}
+
+	if w := doJSON(t, router, http.MethodDelete, "/admin/api/v1/credentials/slack", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d", w.Code)
+	}
+	if env, _ := gw.GetCurrentCredentials(); len(env) != 0 {
+		t.Errorf("env after delete = %v, want empty", env)
+	}
+}
+
+func TestAdminAPI_TokenFormat(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	// An OpenAI key pasted into the Anthropic credential
+	req := CreateCredentialRequest{
+		Service: "anthropic", DisplayName: "Anthropic", Type: "api_key",
+		Read: &AccessLevelConfig{EnvVar: "ANTHROPIC_API_KEY", Token: "sk-proj-abc"},
+	}
+	w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "expected sk-ant-") || strings.Contains(w.Body.String(), "sk-proj-abc") {
+		t.Fatalf("create: status = %d: %s, want 400 naming the expected prefix", w.Code, w.Body.String())
+	}
+
+	req.Read.Token = "sk-ant-api03-abc"
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", req); w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body.String())
+	}
+	req.Read.Token = "sk-proj-abc"
+	if w := doJSON(t, router, http.MethodPut, "/admin/api/v1/credentials/anthropic", req); w.Code != http.StatusBadRequest {
+		t.Fatalf("update: status = %d, want 400", w.Code)
+	}
+	if cred, _ := db.GetCredential("anthropic"); cred.Read.Token != "sk-ant-api03-abc" {
+		t.Errorf("token = %q, want the rejected update not saved", cred.Read.Token)
+	}
+}
+
+func TestAdminAPI_DuplicateSecrets(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	// create returns the status and any warning
+	create := func(service string) (int, string) {
+		w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", CreateCredentialRequest{
+			Service: service, DisplayName: service, Type: "token",
+			Read: &AccessLevelConfig{EnvVar: strings.ToUpper(service) + "_TOKEN", Token: "shared-token"},
+		})
+		var resp struct {
+			Warning string `json:"warning"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Warning
+	}
+	if code, warning := create("ci"); code != http.StatusCreated || warning != "" {
+		t.Fatalf("create ci: status = %d, warning %q", code, warning)
+	}
+	code, warning := create("deploy")
+	if code != http.StatusCreated || !strings.Contains(warning, "the read token is also ci's read token") {
+		t.Fatalf("create deploy: status = %d, warning %q, want one naming ci", code, warning)
+	}
+	if strings.Contains(warning, "shared-token") {
+		t.Error("warning contains the token")
+	}
+
+	SetBlockDuplicateSecrets(true)
+	defer SetBlockDuplicateSecrets(false)
+	if code, _ := create("release"); code != http.StatusConflict {
+		t.Fatalf("create release: status = %d, want 409", code)
+	}
+	if cred, _ := db.GetCredential("release"); cred != nil {
+		t.Error("blocked credential was saved")
+	}
+}
+
+func TestAdminAPI_BulkCreateCredentials(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	dir := t.TempDir()
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(dir, ".env"), nil, logger)
+	restarts := filepath.Join(dir, "restarts")
+	gw.SetRestartFallback(&gateway.CommandRestarter{Command: `printf '%s\n' "$OCM_RESTART_REASON" >> ` + restarts})
+	router := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+
+	reqs := []CreateCredentialRequest{
+		{Service: "github", DisplayName: "GitHub", Read: &AccessLevelConfig{EnvVar: "GITHUB_TOKEN", Token: "ghp_read"}},
+		{Service: "broken", DisplayName: "Broken"},
+		{Service: "slack", DisplayName: "Slack", Read: &AccessLevelConfig{EnvVar: "SLACK_TOKEN", Token: "xoxc-read"}},
+		{Service: "github", DisplayName: "GitHub again", Read: &AccessLevelConfig{EnvVar: "GH_TOKEN", Token: "ghp_again"}},
+	}
+	w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials/bulk", reqs)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp BulkCredentialResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Created != 2 || resp.Failed != 2 || len(resp.Results) != 4 {
+		t.Fatalf("response = %+v, want 2 created and 2 failed", resp)
+	}
+	for i, want := range []string{"created", "error", "created", "error"} {
+		if got := resp.Results[i].Status; got != want {
+			t.Errorf("results[%d].status = %q (%s), want %q", i, got, resp.Results[i].Error, want)
+		}
+	}
+	if resp.Results[0].Credential == nil || resp.Results[0].Credential.Service != "github" {
+		t.Errorf("results[0].credential = %+v", resp.Results[0].Credential)
+	}
+
+	if cred, _ := db.GetCredential("github"); cred == nil || cred.Read.EnvVar != "GITHUB_TOKEN" {
+		t.Errorf("github credential = %+v, duplicate must not overwrite it", cred)
+	}
+	if cred, _ := db.GetCredential("broken"); cred != nil {
+		t.Error("invalid item was saved")
+	}
+	env, _ := gw.GetCurrentCredentials()
+	if env["GITHUB_TOKEN"] != "ghp_read" || env["SLACK_TOKEN"] != "xoxc-read" {
+		t.Errorf("env = %v, want both read tokens", env)
+	}
+
+	data, err := os.ReadFile(restarts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || lines[0] != "credentials imported: github, slack" {
+		t.Errorf("restarts = %q, want one for both credentials", lines)
+	}
+
+	for _, body := range []interface{}{[]CreateCredentialRequest{}, reqs[0]} {
+		if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials/bulk", body); w.Code != http.StatusBadRequest {
+			t.Errorf("body %+v: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestAdminAPI_SetupWizard(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	status := func(method, path string, want int) SetupStatusResponse {
+		t.Helper()
+		w := doJSON(t, router, method, path, nil)
+		if w.Code != want {
+			t.Fatalf("%s %s: status = %d, want %d: %s", method, path, w.Code, want, w.Body.String())
+		}
+		var resp SetupStatusResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	got := status(http.MethodGet, "/admin/api/v1/setup/status", http.StatusOK)
+	if got.SetupComplete || got.CurrentStep != store.SetupWelcome || len(got.Steps) != 2 {
+		t.Fatalf("fresh status = %+v, want the welcome step", got)
+	}
+
+	status(http.MethodPost, "/admin/api/v1/setup/steps/model_provider/skip", http.StatusConflict)
+	status(http.MethodPost, "/admin/api/v1/setup/steps/model_provider/complete", http.StatusConflict)
+	status(http.MethodPost, "/admin/api/v1/setup/steps/nope/complete", http.StatusNotFound)
+	status(http.MethodPost, "/admin/api/v1/setup/complete", http.StatusConflict)
+
+	got = status(http.MethodPost, "/admin/api/v1/setup/steps/welcome/skip", http.StatusOK)
+	if got.CurrentStep != store.SetupModelProvider || got.Steps[0].Status != "skipped" {
+		t.Errorf("after skipping welcome = %+v", got)
+	}
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "anthropic", DisplayName: "Anthropic",
+		Read: &store.AccessLevel{EnvVar: "ANTHROPIC_API_KEY", Token: "sk-ant"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got = status(http.MethodPost, "/admin/api/v1/setup/steps/model_provider/complete", http.StatusOK)
+	if got.CurrentStep != "" || got.SetupComplete {
+		t.Errorf("after model provider = %+v, want every step done but setup not finished", got)
+	}
+
+	// Progress survives a restart
+	router = NewAdminRouter(db, nil, nil, nil, nil, logger)
+	got = status(http.MethodGet, "/admin/api/v1/setup/status", http.StatusOK)
+	if got.Steps[0].Status != "skipped" || got.Steps[1].Status != "completed" {
+		t.Errorf("after restart = %+v", got.Steps)
+	}
+
+	status(http.MethodPost, "/admin/api/v1/setup/complete", http.StatusOK)
+	if got = status(http.MethodGet, "/admin/api/v1/setup/status", http.StatusOK); !got.SetupComplete || got.CompletedAt == nil {
+		t.Errorf("after complete = %+v", got)
+	}
+
+	got = status(http.MethodPost, "/admin/api/v1/setup/reset", http.StatusOK)
+	if got.SetupComplete || got.CurrentStep != store.SetupWelcome {
+		t.Errorf("after reset = %+v", got)
+	}
+	if cred, _ := db.GetCredential("anthropic"); cred == nil {
+		t.Error("reset deleted credentials")
+	}
+}
+
+func TestAdminAPI_SetupStateUpgrade(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	// Set up before the wizard's progress was recorded
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "openai", DisplayName: "OpenAI",
+		Read: &store.AccessLevel{EnvVar: "OPENAI_API_KEY", Token: "sk"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/setup/status", nil)
+	var got SetupStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.SetupComplete || got.CurrentStep != "" {
+		t.Errorf("upgraded install = %+v, want setup complete", got)
+	}
+	if _, found, _ := db.GetSetupState(); !found {
+		t.Error("seeded state wasn't saved")
+	}
+}
+
+func TestAdminAPI_ListInjected(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("GITHUB_TOKEN=ghp_handedited123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", envFile, nil, logger)
+	svc := elevation.NewService(db, gw, logger)
+	router := NewAdminRouter(db, svc, nil, nil, nil, logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_fromthestore456"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/gateway/injected", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp []GatewayInjectedInfo
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 1 || resp[0].Drift != 1 || len(resp[0].Targets) != 1 {
+		t.Fatalf("response = %+v, want one drifted target on the default gateway", resp)
+	}
+	target := resp[0].Targets[0]
+	if target.Status != elevation.DriftMismatch || target.Actual != "ghp_h********" || target.Desired != "ghp_f********" {
+		t.Errorf("target = %+v, want masked mismatch", target)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/gateway/injected?gateway=qa", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown gateway: status = %d, want 404", w.Code)
+	}
+}
+
+func TestAdminAPI_ListAuditEntries(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, actor := range []string{"agent", "admin", "agent"} {
+		db.AddAuditEntry(&store.AuditEntry{
+			ID:        fmt.Sprintf("audit-%d", i+1),
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Action:    "credential_access",
+			Service:   "github",
+			Actor:     actor,
+		})
+	}
+
+	list := func(query string) ([]store.AuditEntry, string) {
+		t.Helper()
+		w := doJSON(t, router, http.MethodGet, "/admin/api/v1/audit"+query, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", query, w.Code, w.Body.String())
+		}
+		var entries []store.AuditEntry
+		if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries, w.Header().Get("X-Next-Cursor")
+	}
+
+	entries, next := list("?limit=2")
+	if len(entries) != 2 || entries[0].ID != "audit-3" || next == "" {
+		t.Fatalf("first page = %+v, next %q", entries, next)
+	}
+	entries, next = list("?limit=2&cursor=" + next)
+	if len(entries) != 1 || entries[0].ID != "audit-1" || next != "" {
+		t.Errorf("second page = %+v, next %q", entries, next)
+	}
+
+	if entries, _ := list("?actor=agent&from=" + base.Add(time.Hour).Format(time.RFC3339)); len(entries) != 1 || entries[0].ID != "audit-3" {
+		t.Errorf("filtered = %+v", entries)
+	}
+
+	for _, query := range []string{"?limit=0", "?from=yesterday", "?cursor=bogus", "?action=elevation_approve"} {
+		if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/audit"+query, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestAdminAPI_ExportAuditEntries(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < store.MaxAuditLimit+5; i++ {
+		db.AddAuditEntry(&store.AuditEntry{
+			ID:        fmt.Sprintf("audit-%04d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Action:    "credential_access",
+			Service:   "github",
+			Details:   `purpose: "deploy", ok`,
+			Actor:     "agent",
+		})
+	}
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/audit/export?format=csv&to="+base.Add(time.Hour).Format(time.RFC3339), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header plus every entry across pages
+	if len(records) != store.MaxAuditLimit+6 {
+		t.Fatalf("got %d records, want %d", len(records), store.MaxAuditLimit+6)
+	}
+	if records[1][0] != fmt.Sprintf("audit-%04d", store.MaxAuditLimit+4) || records[1][6] != `purpose: "deploy", ok` {
+		t.Errorf("first record = %v", records[1])
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/admin/api/v1/audit/export?action=audit_exported", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("jsonl: status = %d", w.Code)
+	}
+	var e store.AuditEntry
+	if err := json.NewDecoder(w.Body).Decode(&e); err != nil || e.Details != fmt.Sprintf("format: csv, entries: %d", store.MaxAuditLimit+5) {
+		t.Errorf("export not audited: %+v, %v", e, err)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/audit/export?format=xml", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("format=xml: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAdminAPI_TimeSeries(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	now := time.Now()
+	for i, e := range []struct {
+		action  store.AuditAction
+		service string
+		ago     time.Duration
+	}{
+		{store.ActionCredentialAccess, "github", 0},
+		{store.ActionCredentialAccess, "github", 3 * time.Hour},
+		{store.ActionCredentialAccess, "slack", 3 * time.Hour},
+		{store.ActionElevationRequested, "github", 3 * time.Hour},
+		{store.ActionElevationApproved, "github", 3 * time.Hour},
+		{store.ActionElevationDenied, "github", 48 * time.Hour},
+		{store.ActionCredentialCreated, "github", 0},
+	} {
+		db.AddAuditEntry(&store.AuditEntry{ID: fmt.Sprintf("a-%d", i), Timestamp: now.Add(-e.ago), Action: e.action, Service: e.service, Actor: "agent"})
+	}
+
+	get := func(query string) TimeSeries {
+		t.Helper()
+		w := doJSON(t, router, http.MethodGet, "/admin/api/v1/dashboard/timeseries?tz=UTC"+query, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", query, w.Code, w.Body.String())
+		}
+		var ts TimeSeries
+		if err := json.Unmarshal(w.Body.Bytes(), &ts); err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	hourly := get("")
+	if hourly.Bucket != "hour" || len(hourly.Buckets) != 24 {
+		t.Fatalf("default window = %s with %d buckets, want 24 hours", hourly.Bucket, len(hourly.Buckets))
+	}
+	if got := hourly.Buckets[23]; got.Accesses != 1 || got.Requested != 0 {
+		t.Errorf("current hour = %+v, want 1 access", got)
+	}
+	if got := hourly.Buckets[20]; got.Accesses != 2 || got.Requested != 1 || got.Approved != 1 {
+		t.Errorf("3 hours ago = %+v, want 2 accesses, 1 requested, 1 approved", got)
+	}
+	if got := get("&service=github").Buckets[20]; got.Accesses != 1 {
+		t.Errorf("3 hours ago for github = %+v, want 1 access", got)
+	}
+
+	daily := get("&window=7d")
+	if daily.Bucket != "day" || len(daily.Buckets) != 7 {
+		t.Fatalf("7d window = %s with %d buckets", daily.Bucket, len(daily.Buckets))
+	}
+	if got := daily.Buckets[4]; got.Denied != 1 {
+		t.Errorf("2 days ago = %+v, want 1 denied", got)
+	}
+	if start := daily.Buckets[6].Start; !start.Equal(now.UTC().Truncate(24 * time.Hour)) {
+		t.Errorf("last bucket starts %v, want midnight UTC today", start)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/dashboard/timeseries?window=1y", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown window: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAdminAPI_AccessStats(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	base := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+	audit := func(id string, action store.AuditAction, service, scope, elevationID string, at time.Time) {
+		db.AddAuditEntry(&store.AuditEntry{ID: id, Timestamp: at, Action: action, Service: service, Scope: scope, Actor: "agent", ElevationID: elevationID})
+	}
+	for i, scope := range []string{"read", "read", "write"} {
+		audit(fmt.Sprintf("a-gh-%d", i), "credential_access", "github", scope, "", base.Add(time.Duration(i)*time.Minute))
+	}
+	audit("a-slack", "credential_access", "slack", "read", "", base.Add(5*time.Hour))
+	audit("a-created", "credential_created", "slack", "", "", base)
+
+	// One elevation approved after 10 minutes, one denied
+	if err := db.SaveCredential(&store.Credential{ID: "cred-gh", Service: "github", DisplayName: "GitHub", Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "t"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*store.Elevation{
+		{ID: "elev-1", Service: "github", Scope: "write", Reason: "deploy", Status: "pending", RequestedAt: base},
+		{ID: "elev-2", Service: "github", Scope: "write", Reason: "cleanup", Status: "pending", RequestedAt: base},
+	} {
+		if err := db.CreateElevation(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audit("a-req-1", "elevation_requested", "github", "write", "elev-1", base)
+	audit("a-req-2", "elevation_requested", "github", "write", "elev-2", base)
+	audit("a-ok", "elevation_approved", "github", "write", "elev-1", base.Add(10*time.Minute))
+	audit("a-no", "elevation_denied", "github", "write", "elev-2", base.Add(time.Minute))
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/stats/access?tz=UTC&from="+base.Add(-time.Hour).Format(time.RFC3339)+"&to="+base.Add(24*time.Hour).Format(time.RFC3339), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var stats AccessStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Accesses != 4 || len(stats.Services) != 2 {
+		t.Fatalf("accesses = %d, services = %+v", stats.Accesses, stats.Services)
+	}
+	gh := stats.Services[0]
+	if gh.Service != "github" || gh.Accesses != 3 || gh.ReadAccesses != 2 || gh.WriteAccesses != 1 {
+		t.Errorf("github = %+v", gh)
+	}
+	if gh.Requested != 2 || gh.Approved != 1 || gh.Denied != 1 || gh.ApprovalRate == nil || *gh.ApprovalRate != 0.5 {
+		t.Errorf("github elevations = %+v", gh.ElevationStats)
+	}
+	if m := stats.Elevations.MedianSecondsToApprove; m == nil || *m != 600 {
+		t.Errorf("median time to approve = %v, want 600", m)
+	}
+	if m := gh.MedianSecondsToDeny; m == nil || *m != 60 {
+		t.Errorf("median time to deny = %v, want 60", m)
+	}
+	if stats.AccessesByHour[9] != 3 || stats.AccessesByHour[14] != 1 || len(stats.BusiestHours) != 2 || stats.BusiestHours[0] != 9 {
+		t.Errorf("hours = %v, busiest %v", stats.AccessesByHour, stats.BusiestHours)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/stats/access?tz=Nowhere/City", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad tz: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAdminAPI_ApprovalReceipt(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	svc := elevation.NewService(db, gw, logger)
+	key, err := db.ReceiptSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.SetReceiptKey(key)
+	router := NewAdminRouter(db, svc, nil, nil, nil, logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/requests/elev-1/receipt", nil); w.Code != http.StatusNotFound {
+		t.Errorf("before approval: status = %d, want 404", w.Code)
+	}
+	if err := svc.ApproveElevation("elev-1", time.Hour, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/requests/elev-1/receipt?download=1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "receipt-elev-1.json") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	var got ReceiptResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := base64.StdEncoding.DecodeString(got.Payload)
+	sig, _ := base64.StdEncoding.DecodeString(got.Signature)
+	pub, _ := base64.StdEncoding.DecodeString(got.PublicKey)
+	if !ed25519.Verify(pub, payload, sig) {
+		t.Error("receipt signature doesn't verify")
+	}
+	if got.Algorithm != "Ed25519" || got.Receipt.ApprovedBy != "admin" || got.Receipt.TTL != "1h0m0s" {
+		t.Errorf("receipt = %+v", got)
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/admin/api/v1/receipts/key", nil)
+	var keyResp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &keyResp)
+	if keyResp["publicKey"] != got.PublicKey || keyResp["keyId"] != got.KeyID {
+		t.Errorf("key = %v, want %s / %s", keyResp, got.PublicKey, got.KeyID)
+	}
+}
+
+func TestAdminAPI_DenyReason(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	router := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"elev-1", "elev-2"} {
+		if err := db.CreateElevation(&store.Elevation{
+			ID: id, Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/requests/elev-1/deny", DenyRequest{Reason: "change freeze"}); w.Code != http.StatusOK {
+		t.Fatalf("deny: status = %d: %s", w.Code, w.Body.String())
+	}
+	// The body is optional
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/requests/elev-2/deny", nil); w.Code != http.StatusOK {
+		t.Fatalf("deny without body: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	entries, _, err := db.QueryAuditEntries(store.AuditQuery{Action: store.ActionElevationDenied})
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := map[string]string{}
+	for _, e := range entries {
+		details[e.ElevationID] = e.Details
+	}
+	if details["elev-1"] != "change freeze" || details["elev-2"] != "" {
+		t.Errorf("denial details = %v", details)
+	}
+}
+
+func TestAdminAPI_Status(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, e := range []struct {
+		id      string
+		status  string
+		expires time.Time
+	}{
+		{"elev-pending", "pending", time.Time{}},
+		{"elev-late", "approved", now.Add(time.Hour)},
+		{"elev-soon", "approved", now.Add(10 * time.Minute)},
+		{"elev-expired", "approved", now.Add(-time.Minute)},
+	} {
+		if err := db.CreateElevation(&store.Elevation{
+			ID: e.id, Service: "github", Scope: "write", Status: "pending", RequestedAt: now,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if e.status == "approved" {
+			if err := db.UpdateElevation(e.id, "approved", "admin", &e.expires); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/status", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var got StatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Healthy || !got.Database.OK || got.Database.Credentials != 1 {
+		t.Errorf("health = %v, database = %+v", got.Healthy, got.Database)
+	}
+	if got.PendingRequests != 1 {
+		t.Errorf("pendingRequests = %d, want 1", got.PendingRequests)
+	}
+	if len(got.ActiveElevations) != 2 || got.ActiveElevations[0].ID != "elev-soon" || got.ActiveElevations[1].ID != "elev-late" {
+		t.Fatalf("activeElevations = %+v, want elev-soon then elev-late", got.ActiveElevations)
+	}
+	if r := got.ActiveElevations[0].RemainingSeconds; r <= 540 || r > 600 {
+		t.Errorf("remainingSeconds = %d, want about 600", r)
+	}
+	w = doJSON(t, router, http.MethodGet, "/admin/api/v1/elevations/active", nil)
+	var active []ActiveElevation
+	if err := json.Unmarshal(w.Body.Bytes(), &active); err != nil {
+		t.Fatalf("elevations/active: %v: %s", err, w.Body.String())
+	}
+	if len(active) != 2 || active[0].ID != "elev-soon" || active[0].ApprovedBy != "admin" {
+		t.Errorf("elevations/active = %+v, want elev-soon first", active)
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/admin/api/v1/dashboard", nil)
+	var dash DashboardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &dash); err != nil {
+		t.Fatal(err)
+	}
+	if dash.ActiveElevations != 2 {
+		t.Errorf("dashboard activeElevations = %d, want 2", dash.ActiveElevations)
+	}
+}
+
+func TestAdminAPI_DashboardExpiring(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	soon, later := time.Now().Add(24*time.Hour).UTC().Truncate(time.Second), time.Now().Add(30*24*time.Hour)
+	tokens := map[string]string{"github": "ghp_t", "notion": "ntn_t"}
+	for service, expiresAt := range map[string]time.Time{"github": soon, "notion": later} {
+		req := CreateCredentialRequest{
+			Service: service, DisplayName: service, Type: "token",
+			Read: &AccessLevelConfig{EnvVar: strings.ToUpper(service) + "_TOKEN", Token: tokens[service], ExpiresAt: &expiresAt},
+		}
+		if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", req); w.Code != http.StatusCreated {
+			t.Fatalf("create %s: status = %d: %s", service, w.Code, w.Body.String())
+		}
+	}
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/dashboard", nil)
+	var dash DashboardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &dash); err != nil {
+		t.Fatal(err)
+	}
+	if len(dash.Expiring) != 1 {
+		t.Fatalf("expiring = %+v, want github only", dash.Expiring)
+	}
+	if e := dash.Expiring[0]; e.Service != "github" || e.Level != "read" || !e.ExpiresAt.Equal(soon) || e.Expired {
+		t.Errorf("expiring = %+v, want github read expiring at %s", e, soon)
+	}
+}
+
+func TestAdminAPI_LegacyAliases(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/credentials", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "" {
+		t.Errorf("v1: status = %d, Deprecation = %q", w.Code, w.Header().Get("Deprecation"))
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/admin/api/credentials", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("legacy: status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Deprecation"); !strings.HasPrefix(got, "@") {
+		t.Errorf("Deprecation = %q, want an RFC 9745 date", got)
+	}
+	if got, want := w.Header().Get("Link"), `</admin/api/v1/credentials>; rel="successor-version"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+}
+
+func TestAdminAPI_Catalog(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/catalog", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("catalog: status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp CatalogResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Categories) == 0 || len(resp.Providers) == 0 {
+		t.Fatalf("catalog = %+v, want categories and providers", resp)
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/admin/api/v1/catalog/github", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("catalog/github: status = %d: %s", w.Code, w.Body.String())
+	}
+	var github struct {
+		CredentialType string `json:"credentialType"`
+		Fields         []struct {
+			Pattern   string `json:"pattern"`
+			Injection struct {
+				Var string `json:"var"`
+			} `json:"injection"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &github); err != nil {
+		t.Fatal(err)
+	}
+	if github.CredentialType != "pat" || len(github.Fields) != 1 ||
+		github.Fields[0].Injection.Var != "GITHUB_TOKEN" || github.Fields[0].Pattern == "" {
+		t.Errorf("catalog/github = %s", w.Body.String())
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/v1/catalog/nope", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown provider: status = %d, want 404", w.Code)
+	}
+}
@@ -2,14 +2,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/openclaw/ocm/internal/derive"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/notify"
 	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/tracing"
+	"github.com/openclaw/ocm/internal/webhook"
 )
 
 // NewAgentRouter creates the router for agent API (internal, :9999).
@@ -18,22 +25,38 @@ import (
 // - Check elevation status
 // - Get credentials (if permanent or elevated)
 // - List available scopes
-func NewAgentRouter(db *store.Store, logger *slog.Logger) chi.Router {
+func NewAgentRouter(db *store.Store, notifier *notify.Dispatcher, logger *slog.Logger) chi.Router {
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(traceRequests)
+	r.Use(logRequests(logger, agentActor)) // Outside Recoverer, so panics log as 500s
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(reportPanics)
+	r.Use(timeoutUnlessStreaming(r, 30*time.Second, "/api/v1/elevate/{id}", "/api/v2/elevate/{id}"))
 
-	h := &agentHandler{store: db, logger: logger}
+	h := &agentHandler{store: db, notifier: notifier, logger: logger}
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Post("/elevate", h.requestElevation)
 		r.Get("/elevate/{id}", h.getElevationStatus)
+		r.Get("/elevate/{id}/events", h.streamElevation)
 		r.Get("/credentials/{service}/{scope}", h.getCredential)
+		r.Post("/credentials:batch", h.getCredentials)
+		r.Get("/leases/{id}", h.getLease)
+		r.Post("/leases/{id}/renew", h.renewLease)
+		r.Delete("/leases/{id}", h.releaseLease)
+		r.Post("/credentials/{service}/checkout", h.checkOut)
+		r.Delete("/checkouts/{id}", h.checkIn)
 		r.Get("/scopes", h.listScopes)
+		r.Get("/openapi.json", serveOpenAPI("OCM Agent API", agentOperations, &agentOpenAPIOnce, &agentOpenAPI))
+	})
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Post("/elevate", h.requestElevationV2)
+		r.Get("/elevate/{id}", h.getElevationStatusV2)
+		r.Get("/credentials/{service}/{scope}", h.getCredentialV2)
 	})
 
 	// Health check
@@ -41,13 +64,16 @@ func NewAgentRouter(db *store.Store, logger *slog.Logger) chi.Router {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	r.Get("/healthz", healthz)
+	r.Get("/readyz", readyz(db, nil, false))
 
 	return r
 }
 
 type agentHandler struct {
-	store  *store.Store
-	logger *slog.Logger
+	store    *store.Store
+	notifier *notify.Dispatcher
+	logger   *slog.Logger
 }
 
 // ElevationRequest is the request body for POST /elevate.
@@ -56,6 +82,10 @@ type ElevationRequest struct {
 	Scope        string `json:"scope"`
 	Reason       string `json:"reason"`
 	RequestedTTL string `json:"requestedTTL,omitempty"` // e.g., "30m", "1h"
+
+	// CallbackURL is POSTed a signed notice when the request is approved,
+	// denied or revoked. It must fall under an allowlisted prefix.
+	CallbackURL string `json:"callbackUrl,omitempty"`
 }
 
 // ElevationResponse is the response for elevation requests.
@@ -63,6 +93,23 @@ type ElevationResponse struct {
 	RequestID string     `json:"requestId"`
 	Status    string     `json:"status"`
 	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// RequestedTTL is the TTL asked for, capped at the credential's max;
+	// GrantedTTL is what the approver granted, once approved
+	RequestedTTL string `json:"requestedTTL,omitempty"`
+	GrantedTTL   string `json:"grantedTTL,omitempty"`
+}
+
+// elevationResponse describes elev to the agent that requested it.
+func elevationResponse(elev *store.Elevation) ElevationResponse {
+	resp := ElevationResponse{RequestID: elev.ID, Status: elev.Status, ExpiresAt: elev.ExpiresAt}
+	if elev.RequestedTTL > 0 {
+		resp.RequestedTTL = elev.RequestedTTL.String()
+	}
+	if elev.Status == "approved" && elev.ApprovedAt != nil && elev.ExpiresAt != nil {
+		resp.GrantedTTL = elev.ExpiresAt.Sub(*elev.ApprovedAt).Round(time.Second).String()
+	}
+	return resp
 }
 
 // CredentialResponse is the response for credential requests.
@@ -70,6 +117,31 @@ type CredentialResponse struct {
 	Token        string     `json:"token,omitempty"`
 	RefreshToken string     `json:"refreshToken,omitempty"`
 	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+
+	// Where the token and env-injected additional fields go, for clients
+	// that inject them themselves (ocm run)
+	EnvVar        string            `json:"envVar,omitempty"`
+	AdditionalEnv map[string]string `json:"additionalEnv,omitempty"` // Env var -> value
+
+	// Derived is set when Token was minted for this request from a secret
+	// that stays in OCM; fetch another once ExpiresAt passes
+	Derived bool `json:"derived,omitempty"`
+
+	// Set when the agent asked for a lease (?lease=true): renew it before
+	// LeaseExpiresAt to keep using the token
+	LeaseID        string     `json:"leaseId,omitempty"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt,omitempty"`
+}
+
+// AccessWebhookPayload is POSTed to a credential's access webhook on every access.
+type AccessWebhookPayload struct {
+	Event     string    `json:"event"` // always "credential_access"
+	Service   string    `json:"service"`
+	Scope     string    `json:"scope"`
+	Actor     string    `json:"actor"`
+	Purpose   string    `json:"purpose,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	AuditID   string    `json:"auditId"`
 }
 
 // ScopesResponse is the response for listing available scopes.
@@ -79,10 +151,50 @@ type ScopesResponse struct {
 
 // ServiceScopes describes available scopes for a service.
 type ServiceScopes struct {
-	ID          string   `json:"id"`
-	DisplayName string   `json:"displayName"`
-	Scopes      []string `json:"scopes"`
-	Elevated    []string `json:"elevated"` // Currently elevated scopes
+	ID          string        `json:"id"`
+	DisplayName string        `json:"displayName"`
+	Type        string        `json:"type"` // Credential type, e.g. pat or api_key
+	Scopes      []string      `json:"scopes"`
+	Elevated    []string      `json:"elevated"` // Currently elevated scopes
+	Details     []ScopeDetail `json:"details"`  // One per scope, in Scopes order
+}
+
+// ScopeDetail is what an agent needs to plan its use of a scope, e.g. to
+// request a TTL the approver can actually grant.
+type ScopeDetail struct {
+	Scope             string     `json:"scope"`
+	ElevationRequired bool       `json:"elevationRequired"`
+	MaxTTL            string     `json:"maxTTL,omitempty"`        // Longest elevation that can be granted; unset if uncapped
+	ElevatedUntil     *time.Time `json:"elevatedUntil,omitempty"` // Expiry of the current elevation
+	InjectionType     string     `json:"injectionType"`           // env or config
+	EnvVar            string     `json:"envVar,omitempty"`
+	ConfigPath        string     `json:"configPath,omitempty"`
+}
+
+// scopeDetail describes level as scope; elevation is its current
+// elevation, if any.
+func scopeDetail(scope string, level *store.AccessLevel, elevation *store.Elevation) ScopeDetail {
+	d := ScopeDetail{
+		Scope:         scope,
+		InjectionType: string(level.GetInjectionType()),
+		EnvVar:        level.EnvVar,
+		ConfigPath:    level.ConfigPath,
+	}
+	if d.InjectionType == string(store.InjectionConfig) {
+		d.EnvVar = ""
+	} else {
+		d.ConfigPath = ""
+	}
+	if scope == "write" {
+		d.ElevationRequired = true
+		if level.MaxTTL > 0 {
+			d.MaxTTL = level.MaxTTL.String()
+		}
+		if elevation != nil {
+			d.ElevatedUntil = elevation.ExpiresAt
+		}
+	}
+	return d
 }
 
 func (h *agentHandler) requestElevation(w http.ResponseWriter, r *http.Request) {
@@ -91,182 +203,534 @@ func (h *agentHandler) requestElevation(w http.ResponseWriter, r *http.Request)
 		h.jsonError(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	elev, aerr := h.createElevation(r, req)
+	if aerr != nil {
+		h.jsonError(w, aerr.message, aerr.status)
+		return
+	}
+	h.jsonResponse(w, elevationResponse(elev))
+}
 
-	// Validate
+// createElevation files req, or returns the elevation already active for
+// its service and scope.
+func (h *agentHandler) createElevation(r *http.Request, req ElevationRequest) (*store.Elevation, *agentError) {
 	if req.Service == "" {
-		h.jsonError(w, "service is required", http.StatusBadRequest)
-		return
+		return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "service is required")
 	}
-	// Scope is now always "write" or "readwrite" for the new model
+	// Scope is now always "write" (or an alias of it) for the new model,
+	// stored canonically so any alias finds the elevation
 	if req.Scope == "" {
 		req.Scope = "write"
 	}
+	req.Scope = canonicalScope(req.Scope)
+	var requestedTTL time.Duration
+	if req.RequestedTTL != "" {
+		d, err := time.ParseDuration(req.RequestedTTL)
+		if err != nil || d <= 0 {
+			return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "invalid requestedTTL duration")
+		}
+		requestedTTL = d
+	}
+	if req.CallbackURL != "" {
+		callbacks := h.notifier.AgentCallbacks()
+		if callbacks == nil {
+			return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "callbacks are not enabled")
+		}
+		if err := callbacks.Allowed(req.CallbackURL); err != nil {
+			return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "callbackUrl: "+err.Error())
+		}
+	}
 
 	// Check credential exists
 	cred, err := h.store.GetCredential(req.Service)
 	if err != nil {
 		h.logger.Error("get credential failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
+		return nil, errInternal
 	}
 	if cred == nil {
-		h.jsonError(w, "service not found", http.StatusNotFound)
-		return
+		return nil, newAgentError(http.StatusNotFound, codeNotFound, "service not found")
 	}
 
 	// Check if read-write access is configured
 	if cred.ReadWrite == nil {
-		h.jsonError(w, "service has no write access configured", http.StatusBadRequest)
-		return
+		return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "service has no write access configured")
 	}
 
 	// Check if already elevated
 	active, err := h.store.GetActiveElevation(req.Service, req.Scope)
 	if err != nil {
 		h.logger.Error("get active elevation failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
+		return nil, errInternal
 	}
 	if active != nil {
-		h.jsonResponse(w, ElevationResponse{
-			RequestID: active.ID,
-			Status:    "approved",
-			ExpiresAt: active.ExpiresAt,
-		})
-		return
+		return active, nil
+	}
+
+	// Ask for no more than an approver could grant
+	if cred.ReadWrite.MaxTTL > 0 && requestedTTL > cred.ReadWrite.MaxTTL {
+		requestedTTL = cred.ReadWrite.MaxTTL
+	}
+
+	// Enforce the per-credential concurrency limit
+	status := "pending"
+	if cred.MaxConcurrentElevations > 0 {
+		full, err := h.elevationsFull(cred)
+		if err != nil {
+			h.logger.Error("count elevations failed", "error", err)
+			return nil, errInternal
+		}
+		if full {
+			if cred.ElevationOverflow != store.OverflowQueue {
+				h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+					ID:        generateID("audit"),
+					Timestamp: time.Now(),
+					Action:    store.ActionElevationRejected,
+					Service:   req.Service,
+					Scope:     req.Scope,
+					Details:   fmt.Sprintf("concurrent elevation limit (%d) reached", cred.MaxConcurrentElevations),
+					Actor:     "system",
+				}))
+				elevation.RecordRequest(req.Service, elevation.OutcomeRejected)
+				return nil, &agentError{status: http.StatusConflict, code: codeElevationLimit, message: "concurrent elevation limit reached", retryAfter: h.nextSlot(cred.Service)}
+			}
+			status = "queued"
+		}
 	}
 
 	// Create elevation request
 	elev := &store.Elevation{
-		ID:          generateID("elev"),
-		Service:     req.Service,
-		Scope:       req.Scope,
-		Reason:      req.Reason,
-		Status:      "pending",
-		RequestedAt: time.Now(),
+		ID:           generateID("elev"),
+		Service:      req.Service,
+		Scope:        req.Scope,
+		Reason:       req.Reason,
+		Status:       status,
+		RequestedAt:  time.Now(),
+		RequestedBy:  "agent",
+		RequestedTTL: requestedTTL,
+		CallbackURL:  req.CallbackURL,
 	}
 
 	if err := h.store.CreateElevation(elev); err != nil {
 		h.logger.Error("create elevation failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
+		return nil, errInternal
 	}
 
 	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "elevation_requested",
-		Service:   req.Service,
-		Scope:     req.Scope,
-		Details:   req.Reason,
-		Actor:     "agent",
-	})
-
-	// TODO: Send push notification
+	action, outcome := store.ActionElevationRequested, elevation.OutcomeRequested
+	if status == "queued" {
+		action, outcome = store.ActionElevationQueued, elevation.OutcomeQueued
+	}
+	elevation.RecordRequest(req.Service, outcome)
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      action,
+		Service:     req.Service,
+		Scope:       req.Scope,
+		Details:     req.Reason,
+		Actor:       "agent",
+		ElevationID: elev.ID,
+	}))
+
+	// Queued requests are announced once they are promoted to pending
+	if status == "pending" {
+		h.notifier.Publish(notify.Event{
+			Type:        notify.EventElevationRequested,
+			Service:     elev.Service,
+			Scope:       elev.Scope,
+			ElevationID: elev.ID,
+			Reason:      elev.Reason,
+			Actor:       elev.RequestedBy,
+		})
+	}
 
 	h.logger.Info("elevation requested",
 		"request_id", elev.ID,
+		"status", status,
 		"service", req.Service,
 		"scope", req.Scope,
 	)
+	return elev, nil
+}
 
-	h.jsonResponse(w, ElevationResponse{
-		RequestID: elev.ID,
-		Status:    "pending",
-	})
+// elevationsFull reports whether a new request for cred would exceed its
+// concurrency limit. With queueing, pending and queued requests count too so
+// that new requests line up behind them.
+func (h *agentHandler) elevationsFull(cred *store.Credential) (bool, error) {
+	n, err := h.store.CountActiveElevations(cred.Service)
+	if err != nil {
+		return false, err
+	}
+	if cred.ElevationOverflow == store.OverflowQueue {
+		for _, status := range []string{"pending", "queued"} {
+			elevs, err := h.store.ListElevationsByStatus(status, cred.Service)
+			if err != nil {
+				return false, err
+			}
+			n += len(elevs)
+		}
+	}
+	return n >= cred.MaxConcurrentElevations, nil
+}
+
+// nextSlot estimates when an elevation slot for service frees up: when the
+// first of its active elevations expires. 0 if none will by itself.
+func (h *agentHandler) nextSlot(service string) time.Duration {
+	approved, err := h.store.ListElevationsByStatus("approved", service)
+	if err != nil {
+		return 0
+	}
+	var next time.Duration
+	for _, elev := range approved {
+		if elev.ExpiresAt == nil {
+			continue
+		}
+		if d := time.Until(*elev.ExpiresAt); d > 0 && (next == 0 || d < next) {
+			next = d
+		}
+	}
+	return next
 }
 
 func (h *agentHandler) getElevationStatus(w http.ResponseWriter, r *http.Request) {
+	elev, aerr := h.pollElevation(w, r)
+	if aerr != nil {
+		h.jsonError(w, aerr.message, aerr.status)
+		return
+	}
+	h.jsonResponse(w, elevationResponse(elev))
+}
+
+// pollElevation returns the elevation named in the path, first waiting for
+// a decision if the request has a wait parameter.
+func (h *agentHandler) pollElevation(w http.ResponseWriter, r *http.Request) (*store.Elevation, *agentError) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		h.jsonError(w, "id is required", http.StatusBadRequest)
-		return
+		return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "id is required")
+	}
+
+	var wait time.Duration
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "invalid wait duration")
+		}
+		wait = min(d, maxElevationWait)
 	}
 
 	elev, err := h.store.GetElevation(id)
+	if err == nil && elev != nil && wait > 0 && undecided(elev.Status) {
+		elev, err = h.awaitDecision(w, r, elev, wait)
+	}
 	if err != nil {
 		h.logger.Error("get elevation failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
+		return nil, errInternal
 	}
 	if elev == nil {
-		h.jsonError(w, "elevation not found", http.StatusNotFound)
-		return
+		return nil, newAgentError(http.StatusNotFound, codeNotFound, "elevation not found")
 	}
+	return elev, nil
+}
 
-	h.jsonResponse(w, ElevationResponse{
-		RequestID: elev.ID,
-		Status:    elev.Status,
-		ExpiresAt: elev.ExpiresAt,
-	})
+// maxElevationWait caps the wait of a long poll on an elevation's status.
+const maxElevationWait = 60 * time.Second
+
+// elevationPollInterval is how often a long poll re-reads the elevation in
+// case no event wakes it, e.g. without a notifier or for a queued request
+// that was promoted.
+var elevationPollInterval = time.Second
+
+// undecided reports whether an elevation in status is still waiting for an
+// approver or a free slot.
+func undecided(status string) bool {
+	return status == "pending" || status == "queued"
+}
+
+// awaitDecision blocks until elev is decided, wait elapses or the client
+// leaves, and returns the elevation as it then stands.
+func (h *agentHandler) awaitDecision(w http.ResponseWriter, r *http.Request, elev *store.Elevation, wait time.Duration) (*store.Elevation, error) {
+	// The wait may outlast the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	var events <-chan notify.Event
+	if h.notifier != nil {
+		var cancel func()
+		events, cancel = h.notifier.Subscribe()
+		defer cancel()
+	}
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(elevationPollInterval)
+	defer ticker.Stop()
+
+	for undecided(elev.Status) {
+		select {
+		case <-r.Context().Done():
+			return elev, nil
+		case <-timeout.C:
+			return elev, nil
+		case e := <-events:
+			if e.ElevationID != elev.ID {
+				continue
+			}
+		case <-ticker.C:
+		}
+		next, err := h.store.GetElevation(elev.ID)
+		if err != nil || next == nil {
+			return next, err
+		}
+		elev = next
+	}
+	return elev, nil
 }
 
 func (h *agentHandler) getCredential(w http.ResponseWriter, r *http.Request) {
-	service := chi.URLParam(r, "service")
-	scopeName := chi.URLParam(r, "scope") // "read" or "write"
+	resp, aerr := h.lookupCredential(r, chi.URLParam(r, "service"), chi.URLParam(r, "scope"))
+	if aerr != nil {
+		h.jsonError(w, aerr.message, aerr.status)
+		return
+	}
+	h.jsonResponse(w, resp)
+}
+
+// agentError is a request that failed, with what the agent gets: v1 routes
+// send the status and message, v2 routes the code and retry hint as well.
+type agentError struct {
+	status     int
+	code       string
+	message    string
+	retryAfter time.Duration // When trying again may succeed; 0 if it won't by itself
+	requestID  string        // The elevation to wait on, for elevation_pending
+}
+
+// Codes of agent errors, stable for clients to switch on.
+const (
+	codeInvalidRequest    = "invalid_request"
+	codeNotFound          = "not_found"
+	codeElevationRequired = "elevation_required"
+	codeElevationPending  = "elevation_pending"
+	codeElevationLimit    = "elevation_limit"
+	codeAuditUnavailable  = "audit_unavailable"
+	codeMintFailed        = "mint_failed"
+	codeCheckoutRequired  = "checkout_required"
+	codeCheckedOut        = "checked_out"
+	codeInternal          = "internal"
+)
+
+// auditRetryAfter is the retry hint when the audit log can't record an
+// access, and mintRetryAfter when a provider wouldn't mint a token.
+const (
+	auditRetryAfter = 5 * time.Second
+	mintRetryAfter  = 30 * time.Second
+)
+
+func newAgentError(status int, code, message string) *agentError {
+	return &agentError{status: status, code: code, message: message}
+}
 
+// errInternal is the error for failures the agent can't do anything about.
+var errInternal = newAgentError(http.StatusInternalServerError, codeInternal, "internal error")
+
+// canonicalScope maps the scope aliases agents may use (r; rw, readwrite)
+// to the scope elevations are stored and looked up under (read; write).
+// Other scopes are returned as they are.
+func canonicalScope(scope string) string {
+	switch scope {
+	case "r":
+		return "read"
+	case "rw", "readwrite":
+		return "write"
+	}
+	return scope
+}
+
+// lookupCredential returns service's token for scope ("read" or "write"),
+// recording the access. Write access needs an active elevation.
+func (h *agentHandler) lookupCredential(r *http.Request, service, scopeName string) (*CredentialResponse, *agentError) {
+	ctx := r.Context()
+	_, span := tracing.Start(ctx, "store.GetCredential", "service", service)
 	cred, err := h.store.GetCredential(service)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		h.logger.Error("get credential failed", "error", err)
-		h.jsonError(w, "internal error", http.StatusInternalServerError)
-		return
+		return nil, errInternal
 	}
 	if cred == nil {
-		h.jsonError(w, "service not found", http.StatusNotFound)
-		return
+		return nil, newAgentError(http.StatusNotFound, codeNotFound, "service not found")
 	}
 
 	var accessLevel *store.AccessLevel
+	var elevationID string
+	var elevationExpiresAt *time.Time
 
-	switch scopeName {
-	case "read", "r":
+	// Aliases are looked up, audited and tracked under their canonical name
+	switch scopeName = canonicalScope(scopeName); scopeName {
+	case "read":
 		// Read access is always available
 		if cred.Read == nil {
-			h.jsonError(w, "no read access configured", http.StatusNotFound)
-			return
+			return nil, newAgentError(http.StatusNotFound, codeNotFound, "no read access configured")
 		}
 		accessLevel = cred.Read
 
-	case "write", "rw", "readwrite":
+	case "write":
 		// Write access requires elevation
 		if cred.ReadWrite == nil {
-			h.jsonError(w, "no write access configured", http.StatusNotFound)
-			return
+			return nil, newAgentError(http.StatusNotFound, codeNotFound, "no write access configured")
 		}
 		// Check for active elevation
+		_, span := tracing.Start(ctx, "store.GetActiveElevation", "service", service)
 		active, err := h.store.GetActiveElevation(service, scopeName)
+		span.RecordError(err)
+		span.End()
 		if err != nil {
 			h.logger.Error("get active elevation failed", "error", err)
-			h.jsonError(w, "internal error", http.StatusInternalServerError)
-			return
+			return nil, errInternal
 		}
 		if active == nil {
-			h.jsonError(w, "elevation required for write access", http.StatusForbidden)
-			return
+			return nil, newAgentError(http.StatusForbidden, codeElevationRequired, "elevation required for write access")
+		}
+		if cred.ExclusiveCheckout {
+			if aerr := h.checkWriteHold(r, cred); aerr != nil {
+				return nil, aerr
+			}
 		}
 		accessLevel = cred.ReadWrite
+		elevationID = active.ID
+		elevationExpiresAt = active.ExpiresAt
 
 	default:
-		h.jsonError(w, "scope must be 'read' or 'write'", http.StatusBadRequest)
-		return
+		return nil, newAgentError(http.StatusBadRequest, codeInvalidRequest, "scope must be 'read' or 'write'")
 	}
 
-	// Audit log
-	h.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "credential_access",
-		Service:   service,
-		Scope:     scopeName,
-		Actor:     "agent",
-	})
+	var minted *derive.Token
+	if accessLevel.Derive != nil {
+		_, span := tracing.Start(ctx, "derive.mint", "service", service, "kind", string(accessLevel.Derive.Kind))
+		minted, err = h.mint(r, cred, accessLevel)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			h.logger.Error("mint derived token failed", "error", err, "service", service)
+			return nil, &agentError{status: http.StatusBadGateway, code: codeMintFailed, message: "could not mint a token for " + service, retryAfter: mintRetryAfter}
+		}
+	}
 
-	h.jsonResponse(w, CredentialResponse{
+	// Never hand out a credential whose access could not be audited
+	_, span = tracing.Start(ctx, "audit.record_access")
+	err = h.recordAccess(r, cred, scopeName, elevationID)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		h.logger.Error("audit write failed, withholding credential", "error", err, "service", service)
+		return nil, &agentError{status: http.StatusServiceUnavailable, code: codeAuditUnavailable, message: "audit log unavailable", retryAfter: auditRetryAfter}
+	}
+
+	resp := &CredentialResponse{
 		Token:        accessLevel.Token,
 		RefreshToken: accessLevel.RefreshToken,
 		ExpiresAt:    accessLevel.ExpiresAt,
-	})
+	}
+	if minted != nil {
+		resp.Token, resp.RefreshToken, resp.ExpiresAt, resp.Derived = minted.Value, "", &minted.ExpiresAt, true
+		for name, value := range minted.Env {
+			if resp.AdditionalEnv == nil {
+				resp.AdditionalEnv = make(map[string]string)
+			}
+			resp.AdditionalEnv[name] = value
+		}
+	}
+	if wantsLease(r) {
+		lease, err := h.issueLease(service, scopeName, elevationID, elevationExpiresAt)
+		if err != nil {
+			h.logger.Error("create lease failed", "error", err, "service", service)
+			return nil, errInternal
+		}
+		resp.LeaseID, resp.LeaseExpiresAt = lease.ID, &lease.ExpiresAt
+	}
+	if accessLevel.InjectionType != store.InjectionConfig {
+		resp.EnvVar = accessLevel.EnvVar
+	}
+	for _, f := range accessLevel.AdditionalFields {
+		if f.InjectionType == store.InjectionConfig || f.EnvVar == "" {
+			continue
+		}
+		if resp.AdditionalEnv == nil {
+			resp.AdditionalEnv = make(map[string]string)
+		}
+		resp.AdditionalEnv[f.EnvVar] = f.Value
+	}
+	return resp, nil
+}
+
+// mint returns a short-lived token derived from level, one of cred's
+// access levels, storing the refresh token if the provider rotated it.
+func (h *agentHandler) mint(r *http.Request, cred *store.Credential, level *store.AccessLevel) (*derive.Token, error) {
+	tok, err := derive.Mint(r.Context(), level)
+	if err != nil {
+		return nil, err
+	}
+	if tok.RefreshToken != "" {
+		level.RefreshToken = tok.RefreshToken
+		if err := h.store.SaveCredential(cred); err != nil {
+			// The old refresh token may no longer work: don't hand out a
+			// token we can't mint again
+			return nil, fmt.Errorf("store rotated refresh token: %w", err)
+		}
+	}
+	return tok, nil
+}
+
+// recordAccess writes the credential_access audit entry, records the use
+// and fires the credential's access webhook, if one is configured. Returns an error if a
+// blocking audit device failed to record the access. elevationID is the
+// elevation granting write access ("" for read access).
+// Agents may state a purpose via ?purpose= or the X-OCM-Purpose header.
+func (h *agentHandler) recordAccess(r *http.Request, cred *store.Credential, scope, elevationID string) error {
+	purpose := r.URL.Query().Get("purpose")
+	if purpose == "" {
+		purpose = r.Header.Get("X-OCM-Purpose")
+	}
+
+	entry := &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionCredentialAccess,
+		Service:     cred.Service,
+		Scope:       scope,
+		Actor:       "agent",
+		ElevationID: elevationID,
+	}
+	if purpose != "" {
+		entry.Details = "purpose: " + purpose
+	}
+	if err := h.store.AddAuditEntry(withRequest(r, entry)); err != nil {
+		return err
+	}
+	if err := h.store.RecordCredentialUse(cred.Service, scope, store.UseAgent); err != nil {
+		h.logger.Error("failed to record credential use", "service", cred.Service, "error", err)
+	}
+
+	if cred.AccessWebhook == nil || cred.AccessWebhook.URL == "" {
+		return nil
+	}
+	payload := AccessWebhookPayload{
+		Event:     "credential_access",
+		Service:   cred.Service,
+		Scope:     scope,
+		Actor:     entry.Actor,
+		Purpose:   purpose,
+		Timestamp: entry.Timestamp,
+		AuditID:   entry.ID,
+	}
+	hook := *cred.AccessWebhook
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := webhook.Post(ctx, nil, hook.URL, hook.Secret, payload); err != nil {
+			h.logger.Warn("access webhook failed", "service", payload.Service, "error", err)
+		}
+	}()
+	return nil
 }
 
 func (h *agentHandler) listScopes(w http.ResponseWriter, r *http.Request) {
@@ -285,13 +749,16 @@ func (h *agentHandler) listScopes(w http.ResponseWriter, r *http.Request) {
 		svc := ServiceScopes{
 			ID:          cred.Service,
 			DisplayName: cred.DisplayName,
+			Type:        cred.Type,
 			Scopes:      []string{},
 			Elevated:    []string{},
+			Details:     []ScopeDetail{},
 		}
 
 		// Add "read" if available
 		if cred.Read != nil {
 			svc.Scopes = append(svc.Scopes, "read")
+			svc.Details = append(svc.Details, scopeDetail("read", cred.Read, nil))
 		}
 
 		// Add "write" if available
@@ -303,6 +770,7 @@ func (h *agentHandler) listScopes(w http.ResponseWriter, r *http.Request) {
 			if active != nil {
 				svc.Elevated = append(svc.Elevated, "write")
 			}
+			svc.Details = append(svc.Details, scopeDetail("write", cred.ReadWrite, active))
 		}
 
 		resp.Services = append(resp.Services, svc)
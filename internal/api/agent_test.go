@@ -1,16 +1,25 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 	"log/slog"
 
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
 	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
 )
 
 func setupTestStore(t *testing.T) (*store.Store, func()) {
@@ -46,7 +55,7 @@ func TestAgentAPI_ListScopes(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	// Add a test credential
 	cred := &store.Credential{
@@ -54,10 +63,8 @@ func TestAgentAPI_ListScopes(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail Test",
 		Type:        "oauth2",
-		Scopes: map[string]*store.Scope{
-			"read":  {Name: "read", Permanent: true, Token: "read-token"},
-			"write": {Name: "write", RequiresApproval: true, Token: "write-token"},
-		},
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "read-token"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
 	}
 	if err := db.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -82,6 +89,38 @@ func TestAgentAPI_ListScopes(t *testing.T) {
 	if resp.Services[0].ID != "gmail" {
 		t.Errorf("ListScopes service ID = %s, want gmail", resp.Services[0].ID)
 	}
+	svc := resp.Services[0]
+	if svc.Type != "oauth2" || len(svc.Details) != 2 {
+		t.Fatalf("ListScopes service = %+v, want type and two scope details", svc)
+	}
+	if d := svc.Details[0]; d.Scope != "read" || d.ElevationRequired || d.InjectionType != "env" || d.EnvVar != "GMAIL_TOKEN" {
+		t.Errorf("read detail = %+v", d)
+	}
+	if d := svc.Details[1]; d.Scope != "write" || !d.ElevationRequired || d.MaxTTL != "" || d.ElevatedUntil != nil || d.EnvVar != "GMAIL_WRITE_TOKEN" {
+		t.Errorf("write detail = %+v", d)
+	}
+
+	// An elevation shows up with its expiry, and a capped TTL as maxTTL
+	cred.ReadWrite.MaxTTL = time.Hour
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(10 * time.Minute)
+	if err := db.CreateElevation(&store.Elevation{ID: "elev-1", Service: "gmail", Scope: "write", Status: "pending", RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateElevation("elev-1", "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/scopes", nil))
+	resp = ScopesResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if d := resp.Services[0].Details[1]; d.MaxTTL != "1h0m0s" || d.ElevatedUntil == nil || !d.ElevatedUntil.Equal(expires) {
+		t.Errorf("elevated write detail = %+v, want maxTTL 1h and expiry %v", d, expires)
+	}
 }
 
 func TestAgentAPI_GetCredential_Permanent(t *testing.T) {
@@ -89,7 +128,7 @@ func TestAgentAPI_GetCredential_Permanent(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	// Add a test credential with permanent scope
 	cred := &store.Credential{
@@ -97,9 +136,7 @@ func TestAgentAPI_GetCredential_Permanent(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail Test",
 		Type:        "oauth2",
-		Scopes: map[string]*store.Scope{
-			"read": {Name: "read", Permanent: true, Token: "secret-read-token"},
-		},
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "secret-read-token"},
 	}
 	if err := db.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -121,6 +158,27 @@ func TestAgentAPI_GetCredential_Permanent(t *testing.T) {
 	if resp.Token != "secret-read-token" {
 		t.Errorf("GetCredential token = %s, want secret-read-token", resp.Token)
 	}
+	if resp.EnvVar != "GMAIL_TOKEN" {
+		t.Errorf("GetCredential envVar = %s, want GMAIL_TOKEN", resp.EnvVar)
+	}
+
+	stored, err := db.GetCredential("gmail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastUsedAt == nil || len(stored.Usage) != 1 || stored.Usage[0].Via != store.UseAgent {
+		t.Errorf("usage = %+v, want the agent read", stored.Usage)
+	}
+
+	// An alias is tracked as the scope it stands for
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/credentials/gmail/r", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetCredential via alias status = %d", w.Code)
+	}
+	if stored, _ = db.GetCredential("gmail"); len(stored.Usage) != 1 || stored.Usage[0].Scope != "read" {
+		t.Errorf("usage after alias = %+v, want only read", stored.Usage)
+	}
 }
 
 func TestAgentAPI_GetCredential_RequiresElevation(t *testing.T) {
@@ -128,7 +186,7 @@ func TestAgentAPI_GetCredential_RequiresElevation(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	// Add a test credential with non-permanent scope
 	cred := &store.Credential{
@@ -136,9 +194,8 @@ func TestAgentAPI_GetCredential_RequiresElevation(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail Test",
 		Type:        "oauth2",
-		Scopes: map[string]*store.Scope{
-			"write": {Name: "write", Permanent: false, RequiresApproval: true, Token: "secret-write-token"},
-		},
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "secret-write-token"},
 	}
 	if err := db.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -159,7 +216,7 @@ func TestAgentAPI_RequestElevation(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	// Add a test credential
 	cred := &store.Credential{
@@ -167,9 +224,8 @@ func TestAgentAPI_RequestElevation(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail Test",
 		Type:        "oauth2",
-		Scopes: map[string]*store.Scope{
-			"write": {Name: "write", Permanent: false, RequiresApproval: true, Token: "write-token"},
-		},
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
 	}
 	if err := db.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -205,12 +261,415 @@ func TestAgentAPI_RequestElevation(t *testing.T) {
 	}
 }
 
+func TestAgentAPI_ElevationScopeAliases(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	cred := &store.Credential{
+		ID:          "test-cred",
+		Service:     "gmail",
+		DisplayName: "Gmail Test",
+		Type:        "oauth2",
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", Scope: "readwrite"})
+	var resp ElevationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("elevate: %v: %s", err, w.Body.String())
+	}
+	expires := time.Now().Add(time.Hour)
+	if err := db.UpdateElevation(resp.RequestID, "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+
+	// An elevation requested under one alias serves them all
+	for _, scope := range []string{"readwrite", "write", "rw"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/credentials/gmail/"+scope, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET /credentials/gmail/%s = %d: %s", scope, w.Code, w.Body.String())
+		}
+	}
+
+	// And asking again under another alias finds it
+	w = doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", Scope: "rw"})
+	var again ElevationResponse
+	json.Unmarshal(w.Body.Bytes(), &again)
+	if again.RequestID != resp.RequestID {
+		t.Errorf("rw request = %q, want the active %q", again.RequestID, resp.RequestID)
+	}
+}
+
+func TestAgentAPI_RequestedTTL(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	admin := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+
+	cred := &store.Credential{
+		ID:          "test-cred",
+		Service:     "gmail",
+		DisplayName: "Gmail Test",
+		Type:        "oauth2",
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token", MaxTTL: time.Hour},
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", Scope: "write", RequestedTTL: "soon"}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid requestedTTL: status = %d, want 400", w.Code)
+	}
+
+	// A request over the credential's max is capped
+	w := doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", Scope: "write", RequestedTTL: "2h"})
+	var resp ElevationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("elevate: %v: %s", err, w.Body.String())
+	}
+	if resp.RequestedTTL != "1h0m0s" {
+		t.Errorf("requestedTTL = %q, want capped at 1h0m0s", resp.RequestedTTL)
+	}
+
+	// Approving without a TTL grants the requested one
+	if w := doJSON(t, admin, "POST", "/admin/api/v1/requests/"+resp.RequestID+"/approve", nil); w.Code != http.StatusOK {
+		t.Fatalf("approve: status = %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, router, "GET", "/api/v1/elevate/"+resp.RequestID, nil)
+	resp = ElevationResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "approved" || resp.GrantedTTL != "1h0m0s" {
+		t.Errorf("after approval = %+v, want 1h0m0s granted", resp)
+	}
+
+	// An explicit TTL from the approver wins
+	if err := db.UpdateElevation(resp.RequestID, "revoked", "admin", nil); err != nil {
+		t.Fatal(err)
+	}
+	w = doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", Scope: "write", RequestedTTL: "45m"})
+	resp = ElevationResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w := doJSON(t, admin, "POST", "/admin/api/v1/requests/"+resp.RequestID+"/approve", ApproveRequest{TTL: "10m"}); w.Code != http.StatusOK {
+		t.Fatalf("approve with ttl: status = %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, router, "GET", "/api/v1/elevate/"+resp.RequestID, nil)
+	resp = ElevationResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.RequestedTTL != "45m0s" || resp.GrantedTTL != "10m0s" {
+		t.Errorf("after approval with ttl = %+v, want 45m requested and 10m granted", resp)
+	}
+}
+
+func TestAgentAPI_CallbackURL(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cred := &store.Credential{
+		ID:          "test-cred",
+		Service:     "gmail",
+		DisplayName: "Gmail Test",
+		Type:        "oauth2",
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "read-token"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refused while callbacks aren't enabled
+	router := NewAgentRouter(db, notify.NewDispatcher(logger), logger)
+	if w := doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", CallbackURL: "https://agent.internal/ocm/1"}); w.Code != http.StatusBadRequest {
+		t.Errorf("callbacks disabled: status = %d, want 400", w.Code)
+	}
+
+	notifier := notify.NewDispatcher(logger)
+	callbacks, err := notify.NewAgentCallbacks(db, notify.AgentCallbackConfig{Allow: []string{"https://agent.internal/ocm/"}, Secret: "s"}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.UseAgentCallbacks(callbacks)
+	router = NewAgentRouter(db, notifier, logger)
+
+	if w := doJSON(t, router, "POST", "/api/v2/elevate", ElevationRequest{Service: "gmail", CallbackURL: "https://evil.example/ocm/1"}); w.Code != http.StatusBadRequest {
+		t.Errorf("disallowed callback: status = %d, want 400", w.Code)
+	}
+	w := doJSON(t, router, "POST", "/api/v1/elevate", ElevationRequest{Service: "gmail", CallbackURL: "https://agent.internal/ocm/1"})
+	var resp ElevationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.RequestID == "" {
+		t.Fatalf("elevate: status = %d: %s", w.Code, w.Body.String())
+	}
+	elev, err := db.GetElevation(resp.RequestID)
+	if err != nil || elev == nil {
+		t.Fatal(err)
+	}
+	if elev.CallbackURL != "https://agent.internal/ocm/1" {
+		t.Errorf("stored callback URL = %q", elev.CallbackURL)
+	}
+}
+
+func TestAgentAPI_V2(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	cred := &store.Credential{
+		ID:                      "test-cred",
+		Service:                 "gmail",
+		DisplayName:             "Gmail Test",
+		Type:                    "oauth2",
+		Read:                    &store.AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "read-token"},
+		ReadWrite:               &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
+		MaxConcurrentElevations: 1,
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	decodeError := func(w *httptest.ResponseRecorder) ErrorDetail {
+		t.Helper()
+		var body ErrorV2
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("error body: %v: %s", err, w.Body.String())
+		}
+		return body.Error
+	}
+
+	// Without a request, write access needs one and waiting won't help
+	w := doJSON(t, router, "GET", "/api/v2/credentials/gmail/write", nil)
+	if e := decodeError(w); w.Code != http.StatusForbidden || e.Code != "elevation_required" || e.RetryAfter != 0 || w.Header().Get("Retry-After") != "" {
+		t.Errorf("unelevated write: status = %d, error = %+v", w.Code, e)
+	}
+
+	w = doJSON(t, router, "POST", "/api/v2/elevate", ElevationRequest{Service: "gmail", Scope: "write"})
+	var elev ElevationV2
+	if err := json.Unmarshal(w.Body.Bytes(), &elev); err != nil {
+		t.Fatal(err)
+	}
+	if elev.Status != "pending" || elev.Terminal || elev.PollInterval != 5 {
+		t.Errorf("new elevation = %+v, want pending with a 5s poll interval", elev)
+	}
+
+	// While it's pending, the agent is told to wait on it
+	w = doJSON(t, router, "GET", "/api/v2/credentials/gmail/write", nil)
+	if e := decodeError(w); e.Code != "elevation_pending" || e.RequestID != elev.RequestID || e.RetryAfter != 5 || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("pending write: error = %+v, Retry-After = %q", e, w.Header().Get("Retry-After"))
+	}
+
+	// An approved elevation fills the only slot; another request is told
+	// when it frees up
+	expires := time.Now().Add(10 * time.Minute)
+	if err := db.UpdateElevation(elev.RequestID, "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+	w = doJSON(t, router, "POST", "/api/v2/elevate", ElevationRequest{Service: "gmail", Scope: "send"})
+	if e := decodeError(w); w.Code != http.StatusConflict || e.Code != "elevation_limit" || e.RetryAfter < 590 || e.RetryAfter > 600 {
+		t.Errorf("over the limit: status = %d, error = %+v, want retryAfter of about 600", w.Code, e)
+	}
+
+	// Ended elevations are terminal, including approved ones past expiry
+	past := time.Now().Add(-time.Minute)
+	if err := db.UpdateElevation(elev.RequestID, "approved", "admin", &past); err != nil {
+		t.Fatal(err)
+	}
+	w = doJSON(t, router, "GET", "/api/v2/elevate/"+elev.RequestID, nil)
+	elev = ElevationV2{}
+	json.Unmarshal(w.Body.Bytes(), &elev)
+	if elev.Status != "expired" || !elev.Terminal || elev.PollInterval != 0 {
+		t.Errorf("lapsed elevation = %+v, want terminal expired", elev)
+	}
+
+	w = doJSON(t, router, "GET", "/api/v2/elevate/elev-missing", nil)
+	if e := decodeError(w); w.Code != http.StatusNotFound || e.Code != "not_found" {
+		t.Errorf("missing elevation: status = %d, error = %+v", w.Code, e)
+	}
+}
+
+func TestAgentAPI_DerivedToken(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("refresh_token") != "refresh-1" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"access_token":"minted-access","expires_in":3600,"refresh_token":"refresh-2"}`)
+	}))
+	defer tokenServer.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	envFile := filepath.Join(t.TempDir(), ".env")
+	gw := gateway.NewClient("http://localhost:18789", envFile, nil, logger)
+	admin := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+	router := NewAgentRouter(db, nil, logger)
+
+	req := CreateCredentialRequest{
+		Service: "gdrive", DisplayName: "Google Drive", Type: "oauth2",
+		Read: &AccessLevelConfig{EnvVar: "GDRIVE_TOKEN", RefreshToken: "refresh-1", Derive: &DerivationConfig{Kind: "oauth_refresh"}},
+	}
+	if w := doJSON(t, admin, "POST", "/admin/api/v1/credentials", req); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "tokenUrl") {
+		t.Errorf("incomplete derivation: status = %d: %s", w.Code, w.Body.String())
+	}
+	req.Read.Derive.TokenURL = tokenServer.URL
+	if w := doJSON(t, admin, "POST", "/admin/api/v1/credentials", req); w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body.String())
+	}
+	if env, _ := gw.GetCurrentCredentials(); len(env) != 0 {
+		t.Errorf("gateway env = %v, derived levels must not be injected", env)
+	}
+
+	w := doJSON(t, router, "GET", "/api/v1/credentials/gdrive/read", nil)
+	var resp CredentialResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("get: %v: %s", err, w.Body.String())
+	}
+	if resp.Token != "minted-access" || !resp.Derived || resp.RefreshToken != "" || resp.ExpiresAt == nil {
+		t.Errorf("credential = %+v, want the minted token without the refresh token", resp)
+	}
+
+	// The rotated refresh token replaces the stored one
+	cred, err := db.GetCredential("gdrive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Read.RefreshToken != "refresh-2" {
+		t.Errorf("stored refresh token = %q, want the rotated one", cred.Read.RefreshToken)
+	}
+}
+
+func TestAgentAPI_ExclusiveCheckout(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	cred := &store.Credential{
+		ID:                "test-cred",
+		Service:           "github",
+		DisplayName:       "GitHub",
+		Read:              &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite:         &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "write-token"},
+		ExclusiveCheckout: true,
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkouts need an elevation
+	if w := doJSON(t, router, "POST", "/api/v1/credentials/github/checkout", CheckoutRequest{Holder: "bot-a"}); w.Code != http.StatusForbidden {
+		t.Errorf("checkout without elevation: status = %d, want 403", w.Code)
+	}
+	expires := time.Now().Add(time.Hour)
+	if err := db.CreateElevation(&store.Elevation{ID: "elev-1", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateElevation("elev-1", "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+
+	getWrite := func(path, checkout string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if checkout != "" {
+			req.Header.Set("X-OCM-Checkout", checkout)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := getWrite("/api/v1/credentials/github/write", ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("write without checkout: status = %d, want 428", w.Code)
+	}
+
+	w := doJSON(t, router, "POST", "/api/v1/credentials/github/checkout", CheckoutRequest{Holder: "bot-a", TTL: "10m"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("checkout: status = %d: %s", w.Code, w.Body.String())
+	}
+	var checkout store.Checkout
+	json.Unmarshal(w.Body.Bytes(), &checkout)
+	if checkout.Holder != "bot-a" || checkout.ElevationID != "elev-1" || time.Until(checkout.ExpiresAt) > 10*time.Minute {
+		t.Errorf("checkout = %+v", checkout)
+	}
+
+	// Another agent is locked out, and told when to try again
+	if w := doJSON(t, router, "POST", "/api/v1/credentials/github/checkout", CheckoutRequest{Holder: "bot-b"}); w.Code != http.StatusLocked || !strings.Contains(w.Body.String(), "bot-a") {
+		t.Errorf("second holder: status = %d: %s, want 423 naming bot-a", w.Code, w.Body.String())
+	}
+	w = getWrite("/api/v2/credentials/github/write", "")
+	var v2 ErrorV2
+	json.Unmarshal(w.Body.Bytes(), &v2)
+	if w.Code != http.StatusLocked || v2.Error.Code != "checked_out" || v2.Error.RetryAfter < 590 {
+		t.Errorf("write while checked out: status = %d, error = %+v", w.Code, v2.Error)
+	}
+
+	// The holder gets the token; checking out again returns its checkout
+	if w := getWrite("/api/v1/credentials/github/write", checkout.ID); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "write-token") {
+		t.Errorf("holder write: status = %d: %s", w.Code, w.Body.String())
+	}
+	// Claiming the holder's name isn't enough to get its checkout
+	w = doJSON(t, router, "POST", "/api/v1/credentials/github/checkout", CheckoutRequest{Holder: "bot-a"})
+	if w.Code != http.StatusLocked || strings.Contains(w.Body.String(), checkout.ID) {
+		t.Errorf("same holder without the ID: status = %d: %s, want 423 without the ID", w.Code, w.Body.String())
+	}
+	w = doJSON(t, router, "POST", "/api/v1/credentials/github/checkout?checkout="+checkout.ID, CheckoutRequest{Holder: "bot-a"})
+	var again store.Checkout
+	json.Unmarshal(w.Body.Bytes(), &again)
+	if w.Code != http.StatusOK || again.ID != checkout.ID {
+		t.Errorf("repeat checkout: status = %d, id = %s, want %s", w.Code, again.ID, checkout.ID)
+	}
+
+	// After check-in the next agent can take it
+	if w := doJSON(t, router, "DELETE", "/api/v1/checkouts/"+checkout.ID, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("check in: status = %d", w.Code)
+	}
+	if w := doJSON(t, router, "POST", "/api/v1/credentials/github/checkout", CheckoutRequest{Holder: "bot-b"}); w.Code != http.StatusCreated {
+		t.Errorf("checkout after check-in: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	// Ending the elevation ends its checkouts
+	if n, err := db.CheckInElevation("elev-1", "elevation revoked"); err != nil || n != 1 {
+		t.Errorf("CheckInElevation = %d, %v, want 1", n, err)
+	}
+
+	entries, err := db.ListAuditEntries(50, "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var holders []string
+	for _, e := range entries {
+		if e.Action == store.ActionCredentialCheckedOut || e.Action == store.ActionCredentialCheckedIn {
+			holders = append(holders, string(e.Action)+" "+e.Details)
+		}
+	}
+	if len(holders) != 3 || !strings.Contains(strings.Join(holders, "\n"), "holder: bot-a") {
+		t.Errorf("checkout audit entries = %q, want bot-a out and in, bot-b out", holders)
+	}
+}
+
 func TestAgentAPI_GetCredential_WithElevation(t *testing.T) {
 	db, cleanup := setupTestStore(t)
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	// Add a test credential
 	cred := &store.Credential{
@@ -218,9 +677,8 @@ func TestAgentAPI_GetCredential_WithElevation(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail Test",
 		Type:        "oauth2",
-		Scopes: map[string]*store.Scope{
-			"write": {Name: "write", Permanent: false, RequiresApproval: true, Token: "secret-write-token"},
-		},
+		Read:        &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "secret-write-token"},
 	}
 	if err := db.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -245,6 +703,8 @@ func TestAgentAPI_GetCredential_WithElevation(t *testing.T) {
 
 	// Now should be able to get credential
 	req := httptest.NewRequest("GET", "/api/v1/credentials/gmail/write", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("User-Agent", "openclaw-agent/1.0")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -260,6 +720,16 @@ func TestAgentAPI_GetCredential_WithElevation(t *testing.T) {
 	if resp.Token != "secret-write-token" {
 		t.Errorf("GetCredential token = %s, want secret-write-token", resp.Token)
 	}
+
+	// The access is audited with its request context and elevation
+	entries, err := db.ListAuditEntries(10, "gmail")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("audit entries = %v, %v", entries, err)
+	}
+	e := entries[0]
+	if e.SourceIP != "203.0.113.7" || e.UserAgent != "openclaw-agent/1.0" || e.RequestID == "" || e.ElevationID != "test-elev" {
+		t.Errorf("audit entry context = %+v", e)
+	}
 }
 
 func TestAgentAPI_Health(t *testing.T) {
@@ -267,7 +737,7 @@ func TestAgentAPI_Health(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	router := NewAgentRouter(db, logger)
+	router := NewAgentRouter(db, nil, logger)
 
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
@@ -277,3 +747,439 @@ func TestAgentAPI_Health(t *testing.T) {
 		t.Errorf("Health status = %d, want %d", w.Code, http.StatusOK)
 	}
 }
+
+func TestAgentAPI_GetCredential_AccessWebhook(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	received := make(chan AccessWebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+		if r.Header.Get(webhook.SignatureHeader) != webhook.Sign("hook-secret", ts, body) {
+			t.Error("access webhook signature mismatch")
+		}
+		var payload AccessWebhookPayload
+		json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	cred := &store.Credential{
+		ID:            "test-cred",
+		Service:       "gmail",
+		DisplayName:   "Gmail Test",
+		Type:          "oauth2",
+		Read:          &store.AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "secret-read-token"},
+		AccessWebhook: &store.AccessWebhook{URL: server.URL, Secret: "hook-secret"},
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/credentials/gmail/read?purpose=sync+inbox", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetCredential status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	select {
+	case payload := <-received:
+		if payload.Service != "gmail" || payload.Scope != "read" || payload.Purpose != "sync inbox" {
+			t.Errorf("webhook payload = %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("access webhook was not called")
+	}
+}
+
+func TestAgentAPI_RequestElevation_ConcurrencyLimit(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	for _, cred := range []*store.Credential{
+		{
+			ID: "cred-q", Service: "github", DisplayName: "GitHub", Type: "pat",
+			Read:                    &store.AccessLevel{EnvVar: "GITHUB_TOKEN"},
+			ReadWrite:               &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+			MaxConcurrentElevations: 1,
+			ElevationOverflow:       store.OverflowQueue,
+		},
+		{
+			ID: "cred-r", Service: "gmail", DisplayName: "Gmail", Type: "oauth2",
+			Read:                    &store.AccessLevel{EnvVar: "GMAIL_TOKEN"},
+			ReadWrite:               &store.AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "write-token"},
+			MaxConcurrentElevations: 1,
+		},
+	} {
+		if err := db.SaveCredential(cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	elevate := func(service, scope string) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(ElevationRequest{Service: service, Scope: scope, Reason: "test"})
+		req := httptest.NewRequest("POST", "/api/v1/elevate", bytes.NewReader(bodyBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Queue policy: the second request lines up behind the pending one
+	for i, want := range []string{"pending", "queued"} {
+		w := elevate("github", "write")
+		var resp ElevationResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Status != want {
+			t.Errorf("github request %d: code=%d status=%q, want 200 %q", i, w.Code, resp.Status, want)
+		}
+	}
+
+	// Reject policy: refused while another elevation is active. A request
+	// for the active elevation's own scope (or an alias) would join it
+	expiresAt := time.Now().Add(time.Hour)
+	if err := db.CreateElevation(&store.Elevation{ID: "elev-active", Service: "gmail", Scope: "admin", Status: "pending", RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateElevation("elev-active", "approved", "admin", &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := elevate("gmail", "readwrite"); w.Code != http.StatusConflict {
+		t.Errorf("gmail request over limit: code=%d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+type failingAuditSink struct{}
+
+func (failingAuditSink) WriteAudit(*store.AuditEntry) error { return io.ErrClosedPipe }
+
+func TestAgentAPI_GetCredential_AuditFailureWithholds(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "test-cred", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.SetAuditSink(failingAuditSink{})
+
+	req := httptest.NewRequest("GET", "/api/v1/credentials/github/read", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GetCredential status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if strings.Contains(w.Body.String(), "read-token") {
+		t.Error("token returned despite audit failure")
+	}
+}
+
+func TestAgentAPI_ElevationLongPoll(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	// Only events wake the wait
+	defer func(d time.Duration) { elevationPollInterval = d }(elevationPollInterval)
+	elevationPollInterval = time.Hour
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	dispatcher := notify.NewDispatcher(logger)
+	srv := httptest.NewServer(NewAgentRouter(db, dispatcher, logger))
+	defer srv.Close()
+
+	cred := &store.Credential{ID: "cred-gh", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_read"}}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	elev := &store.Elevation{ID: "elev-wait", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now()}
+	if err := db.CreateElevation(elev); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) (int, ElevationResponse) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/v1/elevate/elev-wait" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body ElevationResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	if code, _ := get("?wait=soon"); code != http.StatusBadRequest {
+		t.Errorf("invalid wait: status = %d, want 400", code)
+	}
+	start := time.Now()
+	if code, body := get("?wait=50ms"); code != http.StatusOK || body.Status != "pending" {
+		t.Errorf("timed out wait = %d %+v, want 200 pending", code, body)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("wait returned before it ran out")
+	}
+
+	done := make(chan ElevationResponse, 1)
+	go func() {
+		_, body := get("?wait=30s")
+		done <- body
+	}()
+	expires := time.Now().Add(time.Hour)
+	if err := db.UpdateElevation("elev-wait", "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+	// Publish until the waiter, which may not have subscribed yet, answers
+	for {
+		dispatcher.Publish(notify.Event{Type: notify.EventElevationApproved, ElevationID: "elev-wait"})
+		select {
+		case body := <-done:
+			if body.Status != "approved" || body.ExpiresAt == nil {
+				t.Errorf("woken wait = %+v, want approved with expiry", body)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("long poll wasn't woken by the approval")
+		}
+	}
+}
+
+func TestAgentAPI_ElevationEvents(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	defer func(d time.Duration) { elevationPollInterval = d }(elevationPollInterval)
+	elevationPollInterval = 10 * time.Millisecond
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	srv := httptest.NewServer(NewAgentRouter(db, nil, logger))
+	defer srv.Close()
+
+	cred := &store.Credential{ID: "cred-gh", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_read"}}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	elev := &store.Elevation{ID: "elev-sse", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now()}
+	if err := db.CreateElevation(elev); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := http.Get(srv.URL + "/api/v1/elevate/nope/events"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown elevation: %v %v, want 404", resp.StatusCode, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/elevate/elev-sse/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		lines := bufio.NewScanner(resp.Body)
+		for lines.Scan() {
+			if strings.HasPrefix(lines.Text(), "event: ") {
+				events <- strings.TrimPrefix(lines.Text(), "event: ")
+			}
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return ""
+		}
+	}
+
+	if ev := next(); ev != "elevation.pending" {
+		t.Errorf("first event = %q, want the current status", ev)
+	}
+	expires := time.Now().Add(time.Hour)
+	if err := db.UpdateElevation("elev-sse", "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev != "elevation.approved" {
+		t.Errorf("event = %q, want elevation.approved", ev)
+	}
+	if err := db.UpdateElevation("elev-sse", "revoked", "admin", nil); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev != "elevation.revoked" {
+		t.Errorf("event = %q, want elevation.revoked", ev)
+	}
+	// The stream ends with the elevation
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Errorf("event %q after revocation", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("stream still open after revocation")
+	}
+}
+
+func TestAgentAPI_BatchCredentials(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	for _, cred := range []*store.Credential{
+		{ID: "cred-gh", Service: "github", DisplayName: "GitHub", Type: "pat",
+			Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_read"},
+			ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_write"}},
+		{ID: "cred-oa", Service: "openai", DisplayName: "OpenAI", Type: "api_key",
+			Read: &store.AccessLevel{EnvVar: "OPENAI_API_KEY", Token: "sk-read"}},
+	} {
+		if err := db.SaveCredential(cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := BatchCredentialsRequest{Credentials: []BatchCredentialItem{
+		{Service: "github", Scope: "read"},
+		{Service: "openai", Scope: "read"},
+		{Service: "github", Scope: "write"},
+		{Service: "nope", Scope: "read"},
+	}}
+	w := doJSON(t, router, http.MethodPost, "/api/v1/credentials:batch?purpose=startup", req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp BatchCredentialsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		status int
+		token  string
+	}{{200, "ghp_read"}, {200, "sk-read"}, {403, ""}, {404, ""}}
+	if len(resp.Credentials) != len(want) {
+		t.Fatalf("got %d results, want %d", len(resp.Credentials), len(want))
+	}
+	for i, got := range resp.Credentials {
+		var token string
+		if got.Credential != nil {
+			token = got.Credential.Token
+		}
+		if got.Status != want[i].status || token != want[i].token || (got.Status != 200) != (got.Error != "") {
+			t.Errorf("result %d = %+v, want status %d token %q", i, got, want[i].status, want[i].token)
+		}
+	}
+
+	// Each credential handed out is audited
+	entries, err := db.ListAuditEntries(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accesses int
+	for _, e := range entries {
+		if e.Action == store.ActionCredentialAccess && e.Details == "purpose: startup" {
+			accesses++
+		}
+	}
+	if accesses != 2 {
+		t.Errorf("audited %d accesses, want 2", accesses)
+	}
+
+	if w := doJSON(t, router, http.MethodPost, "/api/v1/credentials:batch", BatchCredentialsRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAgentAPI_CredentialLeases(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAgentRouter(db, nil, logger)
+
+	cred := &store.Credential{ID: "cred-gh", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_read"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_write"}}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(5 * time.Minute)
+	elev := &store.Elevation{ID: "elev-lease", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now()}
+	if err := db.CreateElevation(elev); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateElevation(elev.ID, "approved", "admin", &expires); err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func(scope string) CredentialResponse {
+		t.Helper()
+		w := doJSON(t, router, http.MethodGet, "/api/v1/credentials/github/"+scope+"?lease=true", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get %s: status = %d: %s", scope, w.Code, w.Body.String())
+		}
+		var resp CredentialResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.LeaseID == "" || resp.LeaseExpiresAt == nil {
+			t.Fatalf("get %s = %+v, want a lease", scope, resp)
+		}
+		return resp
+	}
+	read, write := fetch("read"), fetch("write")
+	if write.LeaseExpiresAt.After(expires.Add(time.Second)) {
+		t.Errorf("write lease expires %v, after its elevation (%v)", write.LeaseExpiresAt, expires)
+	}
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/leases/"+read.LeaseID+"/renew", nil)
+	var renewed LeaseResponse
+	json.Unmarshal(w.Body.Bytes(), &renewed)
+	if w.Code != http.StatusOK || renewed.Status != store.LeaseLive || renewed.RenewedAt == nil {
+		t.Errorf("renew: %d %s", w.Code, w.Body.String())
+	}
+
+	// Ending the elevation revokes the write lease but not the read one
+	if n, err := db.RevokeElevationLeases(elev.ID, "elevation revoked"); err != nil || n != 1 {
+		t.Fatalf("RevokeElevationLeases = %d, %v; want 1", n, err)
+	}
+	w = doJSON(t, router, http.MethodPost, "/api/v1/leases/"+write.LeaseID+"/renew", nil)
+	if w.Code != http.StatusGone || !strings.Contains(w.Body.String(), "elevation revoked") {
+		t.Errorf("renew revoked lease: %d %s, want 410 with the reason", w.Code, w.Body.String())
+	}
+	if live, _ := db.ListLiveLeases(); len(live) != 1 || live[0].ID != read.LeaseID {
+		t.Errorf("live leases = %+v, want only the read lease", live)
+	}
+
+	if w := doJSON(t, router, http.MethodDelete, "/api/v1/leases/"+read.LeaseID, nil); w.Code != http.StatusNoContent {
+		t.Errorf("release: status = %d", w.Code)
+	}
+	w = doJSON(t, router, http.MethodGet, "/api/v1/leases/"+read.LeaseID, nil)
+	var released LeaseResponse
+	json.Unmarshal(w.Body.Bytes(), &released)
+	if released.Status != store.LeaseRevoked || released.RevokeReason != "released" {
+		t.Errorf("released lease = %s", w.Body.String())
+	}
+	if w := doJSON(t, router, http.MethodGet, "/api/v1/leases/nope", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown lease: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchCredentials caps the credentials one batch request can fetch.
+const maxBatchCredentials = 50
+
+// BatchCredentialsRequest is the request body for POST /credentials:batch.
+type BatchCredentialsRequest struct {
+	Credentials []BatchCredentialItem `json:"credentials"`
+}
+
+// BatchCredentialItem names one credential to fetch.
+type BatchCredentialItem struct {
+	Service string `json:"service"`
+	Scope   string `json:"scope"` // read or write
+}
+
+// BatchCredentialsResponse holds one result per requested credential, in
+// request order.
+type BatchCredentialsResponse struct {
+	Credentials []BatchCredentialResult `json:"credentials"`
+}
+
+// BatchCredentialResult is either the credential or why it wasn't returned;
+// Status is the status GET /credentials/{service}/{scope} would have had.
+type BatchCredentialResult struct {
+	Service    string              `json:"service"`
+	Scope      string              `json:"scope"`
+	Status     int                 `json:"status"`
+	Credential *CredentialResponse `json:"credential,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// getCredentials fetches several credentials at once, e.g. the read tokens
+// an agent needs at startup. Each is looked up, and its access audited, as
+// if fetched on its own; one failing doesn't fail the others. A purpose
+// applies to all of them.
+func (h *agentHandler) getCredentials(w http.ResponseWriter, r *http.Request) {
+	var req BatchCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Credentials) == 0 {
+		h.jsonError(w, "credentials is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Credentials) > maxBatchCredentials {
+		h.jsonError(w, fmt.Sprintf("at most %d credentials per batch", maxBatchCredentials), http.StatusBadRequest)
+		return
+	}
+
+	resp := BatchCredentialsResponse{Credentials: make([]BatchCredentialResult, 0, len(req.Credentials))}
+	for _, item := range req.Credentials {
+		result := BatchCredentialResult{Service: item.Service, Scope: item.Scope, Status: http.StatusOK}
+		if item.Service == "" {
+			result.Status, result.Error = http.StatusBadRequest, "service is required"
+		} else if cred, aerr := h.lookupCredential(r, item.Service, item.Scope); aerr != nil {
+			result.Status, result.Error = aerr.status, aerr.message
+		} else {
+			result.Credential = cred
+		}
+		resp.Credentials = append(resp.Credentials, result)
+	}
+	h.jsonResponse(w, resp)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/notify"
+)
+
+// streamElevation is a server-sent events stream of one elevation's status:
+// the current status on connect, then every change until the elevation ends
+// (denied, expired or revoked), so agents can subscribe instead of polling
+// and drop write credentials the moment an elevation is revoked. Each
+// message's "event" field is "elevation.<status>" and its data is the
+// ElevationResponse JSON.
+func (h *agentHandler) streamElevation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	elev, err := h.store.GetElevation(id)
+	if err != nil {
+		h.logger.Error("get elevation failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if elev == nil {
+		h.jsonError(w, "elevation not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		h.jsonError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var events <-chan notify.Event
+	if h.notifier != nil {
+		var cancel func()
+		events, cancel = h.notifier.Subscribe()
+		defer cancel()
+	}
+	flusher := startEventStream(w)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	poll := time.NewTicker(elevationPollInterval)
+	defer poll.Stop()
+	// Fires when an approved elevation runs out, in case the expiry isn't
+	// recorded or published right away
+	expiry := time.NewTimer(time.Hour)
+	expiry.Stop()
+	defer expiry.Stop()
+
+	var last ElevationResponse
+	var seq int
+	for {
+		resp := elevationResponse(elev)
+		if resp.Status == "approved" && resp.ExpiresAt != nil && !time.Now().Before(*resp.ExpiresAt) {
+			resp.Status = "expired"
+		}
+		if seq == 0 || resp.Status != last.Status || !sameTime(resp.ExpiresAt, last.ExpiresAt) {
+			data, err := json.Marshal(resp)
+			if err != nil {
+				return
+			}
+			seq++
+			fmt.Fprintf(w, "id: %d\nevent: elevation.%s\ndata: %s\n\n", seq, resp.Status, data)
+			flusher.Flush()
+			if elevationEnded(resp.Status) {
+				return
+			}
+			if resp.Status == "approved" && resp.ExpiresAt != nil {
+				if !expiry.Stop() {
+					select {
+					case <-expiry.C:
+					default:
+					}
+				}
+				expiry.Reset(time.Until(*resp.ExpiresAt))
+			}
+			last = resp
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+			continue
+		case e := <-events:
+			if e.ElevationID != id {
+				continue
+			}
+		case <-poll.C:
+		case <-expiry.C:
+		}
+		next, err := h.store.GetElevation(id)
+		if err != nil {
+			h.logger.Error("elevation stream: get elevation failed", "error", err, "elevation_id", id)
+			return
+		}
+		if next == nil {
+			return
+		}
+		elev = next
+	}
+}
+
+// elevationEnded reports whether an elevation in status can't change again.
+func elevationEnded(status string) bool {
+	return status == "denied" || status == "expired" || status == "revoked"
+}
+
+// sameTime reports whether a and b are both unset or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
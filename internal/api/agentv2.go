@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// The v2 agent routes behave like their v1 counterparts but tell clients
+// how to back off instead of leaving them to guess: errors carry a stable
+// code and, when waiting will help, retryAfter (and a Retry-After header);
+// elevations say whether their status is final and, while undecided, how
+// often to poll. Routes without a v2 version stay on v1.
+
+// elevationPollHint is the pollInterval suggested for undecided elevations.
+const elevationPollHint = 5 * time.Second
+
+// ElevationV2 is an elevation as the v2 routes describe it.
+type ElevationV2 struct {
+	ElevationResponse
+	// Terminal is set once the status can't change again: denied, expired
+	// or revoked. An approved elevation whose expiry has passed is reported
+	// as expired.
+	Terminal bool `json:"terminal"`
+	// PollInterval is how many seconds to wait before polling an undecided
+	// (pending or queued) elevation again
+	PollInterval int `json:"pollInterval,omitempty"`
+}
+
+// ErrorV2 is the body of every v2 error response.
+type ErrorV2 struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failed v2 request.
+type ErrorDetail struct {
+	// Code is one of invalid_request, not_found, elevation_required,
+	// elevation_pending, elevation_limit, audit_unavailable, mint_failed or
+	// internal
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RetryAfter is the number of seconds after which the same request may
+	// succeed; absent if it won't without some other action
+	RetryAfter int `json:"retryAfter,omitempty"`
+	// RequestID is the undecided elevation an elevation_pending error is
+	// waiting on
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// elevationV2 describes elev for the v2 routes.
+func elevationV2(elev *store.Elevation) ElevationV2 {
+	resp := ElevationV2{ElevationResponse: elevationResponse(elev)}
+	if resp.Status == "approved" && resp.ExpiresAt != nil && !time.Now().Before(*resp.ExpiresAt) {
+		resp.Status = "expired"
+		resp.GrantedTTL = ""
+	}
+	resp.Terminal = elevationEnded(resp.Status)
+	if undecided(resp.Status) {
+		resp.PollInterval = seconds(elevationPollHint)
+	}
+	return resp
+}
+
+// seconds rounds d up to whole seconds, for retry hints.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (h *agentHandler) requestElevationV2(w http.ResponseWriter, r *http.Request) {
+	var req ElevationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorV2(w, newAgentError(http.StatusBadRequest, codeInvalidRequest, "invalid request body"))
+		return
+	}
+	elev, aerr := h.createElevation(r, req)
+	if aerr != nil {
+		h.errorV2(w, aerr)
+		return
+	}
+	h.jsonResponse(w, elevationV2(elev))
+}
+
+func (h *agentHandler) getElevationStatusV2(w http.ResponseWriter, r *http.Request) {
+	elev, aerr := h.pollElevation(w, r)
+	if aerr != nil {
+		h.errorV2(w, aerr)
+		return
+	}
+	h.jsonResponse(w, elevationV2(elev))
+}
+
+func (h *agentHandler) getCredentialV2(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	resp, aerr := h.lookupCredential(r, service, chi.URLParam(r, "scope"))
+	if aerr != nil {
+		if aerr.code == codeElevationRequired {
+			aerr = h.pendingElevation(service, aerr)
+		}
+		h.errorV2(w, aerr)
+		return
+	}
+	h.jsonResponse(w, resp)
+}
+
+// pendingElevation turns an elevation_required error into elevation_pending
+// if service already has an undecided elevation, so the agent waits on it
+// rather than filing another.
+func (h *agentHandler) pendingElevation(service string, aerr *agentError) *agentError {
+	for _, status := range []string{"pending", "queued"} {
+		elevs, err := h.store.ListElevationsByStatus(status, service)
+		if err != nil {
+			h.logger.Error("list elevations failed", "error", err, "service", service)
+			return aerr
+		}
+		if len(elevs) > 0 {
+			return &agentError{
+				status:     http.StatusForbidden,
+				code:       codeElevationPending,
+				message:    "elevation requested and awaiting a decision",
+				retryAfter: elevationPollHint,
+				requestID:  elevs[0].ID,
+			}
+		}
+	}
+	return aerr
+}
+
+// errorV2 writes aerr as a v2 error response.
+func (h *agentHandler) errorV2(w http.ResponseWriter, aerr *agentError) {
+	detail := ErrorDetail{Code: aerr.code, Message: aerr.message, RequestID: aerr.requestID}
+	if aerr.retryAfter > 0 {
+		detail.RetryAfter = seconds(aerr.retryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(detail.RetryAfter))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(aerr.status)
+	json.NewEncoder(w).Encode(ErrorV2{Error: detail})
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/audit"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func (h *adminHandler) listAuditDevices(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.jsonError(w, "audit devices not configured", http.StatusServiceUnavailable)
+		return
+	}
+	h.jsonResponse(w, h.audit.Devices())
+}
+
+func (h *adminHandler) putAuditDevice(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.jsonError(w, "audit devices not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var cfg audit.DeviceConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	cfg.Name = chi.URLParam(r, "name")
+
+	if err := h.audit.Put(cfg); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionAuditDeviceUpdated,
+		Details:   "device: " + cfg.Name + ", type: " + cfg.Type,
+		Actor:     "admin",
+	}))
+
+	h.logger.Info("audit device updated", "device", cfg.Name, "type", cfg.Type, "enabled", cfg.Enabled)
+
+	cfg.Secret = ""
+	h.jsonResponse(w, cfg)
+}
+
+func (h *adminHandler) deleteAuditDevice(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.jsonError(w, "audit devices not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	found, err := h.audit.Remove(name)
+	if !found {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionAuditDeviceRemoved,
+		Details:   "device: " + name,
+		Actor:     "admin",
+	}))
+
+	h.logger.Info("audit device removed", "device", name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseAuditQuery reads the service, action, actor, from and to (RFC 3339)
+// filters shared by the audit list and export endpoints.
+func parseAuditQuery(r *http.Request) (store.AuditQuery, error) {
+	params := r.URL.Query()
+	q := store.AuditQuery{
+		Service: params.Get("service"),
+		Actor:   params.Get("actor"),
+	}
+	if v := params.Get("action"); v != "" {
+		action, err := store.ParseAuditAction(v)
+		if err != nil {
+			return q, err
+		}
+		q.Action = action
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if v := params.Get(p.name); v != "" {
+			ts, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+			}
+			*p.t = ts
+		}
+	}
+	return q, nil
+}
+
+// exportAuditEntries streams every audit entry matching the list filters,
+// newest first, as CSV (?format=csv) or JSON lines (the default), for
+// compliance evidence.
+func (h *adminHandler) exportAuditEntries(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = "jsonl"
+	case "csv", "jsonl":
+	default:
+		h.jsonError(w, "format must be csv or jsonl", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the first page before committing to a 200
+	q.Limit = store.MaxAuditLimit
+	entries, next, err := h.store.QueryAuditEntries(q)
+	if err != nil {
+		h.logger.Error("export audit entries failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Large exports outlive the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	filename := "ocm-audit-" + time.Now().UTC().Format("20060102T150405Z") + "." + format
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	var write func(*store.AuditEntry) error
+	var flush func() error
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "timestamp", "action", "service", "scope", "actor", "details",
+			"elevation_id", "request_id", "source_ip", "user_agent"})
+		write = func(e *store.AuditEntry) error {
+			return cw.Write([]string{e.ID, e.Timestamp.UTC().Format(time.RFC3339Nano), string(e.Action), e.Service, e.Scope, e.Actor, e.Details,
+				e.ElevationID, e.RequestID, e.SourceIP, e.UserAgent})
+		}
+		flush = func() error { cw.Flush(); return cw.Error() }
+	} else {
+		enc := json.NewEncoder(w)
+		write = func(e *store.AuditEntry) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	}
+
+	count := 0
+	for {
+		for _, e := range entries {
+			if err := write(e); err != nil {
+				h.logger.Warn("audit export aborted", "error", err, "exported", count)
+				return
+			}
+			count++
+		}
+		if err := flush(); err != nil {
+			h.logger.Warn("audit export aborted", "error", err, "exported", count)
+			return
+		}
+		if next == "" || r.Context().Err() != nil {
+			break
+		}
+		q.Cursor = next
+		if entries, next, err = h.store.QueryAuditEntries(q); err != nil {
+			// The status is already sent; a truncated file is all we can signal
+			h.logger.Error("audit export failed", "error", err, "exported", count)
+			return
+		}
+	}
+
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionAuditExported,
+		Details:   fmt.Sprintf("format: %s, entries: %d", format, count),
+		Actor:     "admin",
+	}))
+	h.logger.Info("audit log exported", "format", format, "entries", count)
+}
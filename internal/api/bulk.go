@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// maxBulkCredentials caps one bulk import; it's meant for setting up a
+// handful of providers, not for migrating a vault.
+const maxBulkCredentials = 100
+
+// BulkCredentialResult is the outcome of one item of a bulk import.
+type BulkCredentialResult struct {
+	Service    string            `json:"service"`
+	Status     string            `json:"status"` // created or error
+	Credential *store.Credential `json:"credential,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Warning    string            `json:"warning,omitempty"` // Set if its token is also stored under another service
+}
+
+// BulkCredentialResponse is the response to POST /credentials/bulk. Results
+// are in request order.
+type BulkCredentialResponse struct {
+	Results []BulkCredentialResult `json:"results"`
+	Created int                    `json:"created"`
+	Failed  int                    `json:"failed"`
+	Warning string                 `json:"warning,omitempty"` // Set if a Gateway couldn't be updated
+}
+
+// createCredentials creates several credentials at once. Items are
+// validated and saved independently, so one bad item doesn't fail the rest,
+// and the read credentials of everything created are applied with a single
+// restart per Gateway.
+func (h *adminHandler) createCredentials(w http.ResponseWriter, r *http.Request) {
+	var reqs []CreateCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		h.jsonError(w, "invalid request body: expected an array of credentials", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) == 0 {
+		h.jsonError(w, "no credentials given", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) > maxBulkCredentials {
+		h.jsonError(w, fmt.Sprintf("at most %d credentials can be imported at once", maxBulkCredentials), http.StatusBadRequest)
+		return
+	}
+
+	resp := BulkCredentialResponse{Results: make([]BulkCredentialResult, len(reqs))}
+	var created []*store.Credential
+	seen := make(map[string]bool)
+	for i := range reqs {
+		req := &reqs[i]
+		result := &resp.Results[i]
+		result.Service = req.Service
+
+		cred, err := h.credentialFromRequest(req)
+		if err == nil && seen[req.Service] {
+			err = fmt.Errorf("duplicate service %q", req.Service)
+		}
+		if err == nil {
+			// Earlier items are saved already, so a token shared within the import is caught too
+			result.Warning = h.duplicateSecrets(cred)
+			if result.Warning != "" && blockDuplicateSecrets {
+				err, result.Warning = errors.New(result.Warning), ""
+			}
+		}
+		if err != nil {
+			result.Status, result.Error = "error", err.Error()
+			resp.Failed++
+			continue
+		}
+		seen[req.Service] = true
+
+		if err := h.store.SaveCredential(cred); err != nil {
+			h.logger.Error("save credential failed", "service", req.Service, "error", err)
+			result.Status, result.Error = "error", "internal error"
+			resp.Failed++
+			continue
+		}
+		h.credentialCreated(r, cred)
+		result.Status, result.Credential = "created", cred
+		resp.Created++
+		created = append(created, cred)
+	}
+
+	resp.Warning = h.injectCreated(created)
+	h.jsonResponse(w, resp)
+}
+
+// injectCreated applies the read credentials of newly created creds,
+// coalesced into one Apply (and so at most one restart) per Gateway. It
+// returns a warning for the client if any Gateway couldn't be updated.
+func (h *adminHandler) injectCreated(creds []*store.Credential) string {
+	type batch struct {
+		inj      gateway.Injection
+		services []string
+		creds    []*store.Credential
+	}
+	var order []*gateway.Client
+	batches := make(map[*gateway.Client]*batch)
+	for _, cred := range creds {
+		gw := h.credentialGateway(cred)
+		if gw == nil {
+			continue
+		}
+		b := batches[gw]
+		if b == nil {
+			b = &batch{}
+			batches[gw] = b
+			order = append(order, gw)
+		}
+		env, config := gateway.LevelCredentials(cred.Read)
+		b.inj.Env = append(b.inj.Env, env...)
+		b.inj.Config = append(b.inj.Config, config...)
+		b.services = append(b.services, cred.Service)
+		b.creds = append(b.creds, cred)
+	}
+
+	var warnings []string
+	for _, gw := range order {
+		b := batches[gw]
+		if err := gw.Apply(b.inj, "credentials imported: "+strings.Join(b.services, ", ")); err != nil {
+			h.logger.Error("failed to inject imported credentials", "services", b.services, "error", err)
+			if warning := injectionWarning(err); warning != "" {
+				warnings = append(warnings, warning)
+			}
+			continue
+		}
+		for _, cred := range b.creds {
+			h.elevation.VerifyInjection(cred, cred.Read)
+		}
+	}
+	return strings.Join(warnings, "\n\n")
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/catalog"
+)
+
+// CatalogResponse lists the services OCM knows how to set up.
+type CatalogResponse struct {
+	Categories []catalog.CategoryInfo `json:"categories"` // In display order
+	Providers  []catalog.Provider     `json:"providers"`  // Grouped by category
+}
+
+func (h *adminHandler) getCatalog(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, CatalogResponse{Categories: catalog.Categories, Providers: catalog.Providers()})
+}
+
+func (h *adminHandler) getCatalogProvider(w http.ResponseWriter, r *http.Request) {
+	p, ok := catalog.Lookup(chi.URLParam(r, "id"))
+	if !ok {
+		h.jsonError(w, "unknown provider", http.StatusNotFound)
+		return
+	}
+	h.jsonResponse(w, p)
+}
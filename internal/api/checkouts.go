@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// checkoutTTL is how long a checkout holds its credential unless the agent
+// asks for another TTL. Checkouts never outlast their elevation.
+const checkoutTTL = 15 * time.Minute
+
+// CheckoutRequest is the request body for checking a credential out.
+type CheckoutRequest struct {
+	Holder string `json:"holder"`        // Names the agent, e.g. "deploy-bot"; recorded in the audit log
+	TTL    string `json:"ttl,omitempty"` // e.g. "5m" (default 15m)
+}
+
+// checkoutID is the checkout a request presents, from the X-OCM-Checkout
+// header or ?checkout=.
+func checkoutID(r *http.Request) string {
+	if id := r.Header.Get("X-OCM-Checkout"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("checkout")
+}
+
+// checkWriteHold returns an error unless the request holds the checkout on
+// cred, which uses exclusive checkout.
+func (h *agentHandler) checkWriteHold(r *http.Request, cred *store.Credential) *agentError {
+	held, err := h.store.ActiveCheckout(cred.Service)
+	if err != nil {
+		h.logger.Error("get checkout failed", "error", err, "service", cred.Service)
+		return errInternal
+	}
+	if held == nil {
+		return newAgentError(http.StatusPreconditionRequired, codeCheckoutRequired, "write access requires checking the credential out")
+	}
+	if held.ID != checkoutID(r) {
+		return lockedError(held)
+	}
+	return nil
+}
+
+// lockedError is the 423 for a credential held by another checkout.
+func lockedError(held *store.Checkout) *agentError {
+	return &agentError{
+		status:     http.StatusLocked,
+		code:       codeCheckedOut,
+		message:    fmt.Sprintf("checked out by %s until %s", held.Holder, held.ExpiresAt.UTC().Format(time.RFC3339)),
+		retryAfter: time.Until(held.ExpiresAt),
+	}
+}
+
+// checkOut gives the agent an exclusive hold on a credential's write
+// access. Others get 423 until it is checked in or expires. Holder names
+// aren't secret, so only a request presenting the checkout's ID gets the
+// existing checkout back; everyone else, whatever holder they claim, gets
+// the 423.
+func (h *agentHandler) checkOut(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	var req CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Holder == "" {
+		h.jsonError(w, "holder is required", http.StatusBadRequest)
+		return
+	}
+	ttl := checkoutTTL
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			h.jsonError(w, "invalid ttl duration", http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	cred, err := h.store.GetCredential(service)
+	if err != nil {
+		h.logger.Error("get credential failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cred == nil {
+		h.jsonError(w, "service not found", http.StatusNotFound)
+		return
+	}
+	if !cred.ExclusiveCheckout {
+		h.jsonError(w, "service doesn't use exclusive checkout", http.StatusBadRequest)
+		return
+	}
+	active, err := h.store.GetActiveElevation(service, "write")
+	if err != nil {
+		h.logger.Error("get active elevation failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if active == nil {
+		h.jsonError(w, "elevation required for write access", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	if active.ExpiresAt != nil && active.ExpiresAt.Before(expiresAt) {
+		expiresAt = *active.ExpiresAt
+	}
+	c := &store.Checkout{
+		ID:           generateID("checkout"),
+		Service:      service,
+		Holder:       req.Holder,
+		ElevationID:  active.ID,
+		CheckedOutAt: now,
+		ExpiresAt:    expiresAt,
+	}
+	held, err := h.store.CheckOut(c)
+	if err != nil {
+		h.logger.Error("check out failed", "error", err, "service", service)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if held.ID != c.ID {
+		if held.ID == checkoutID(r) {
+			h.jsonResponse(w, held)
+			return
+		}
+		aerr := lockedError(held)
+		h.jsonError(w, aerr.message, aerr.status)
+		return
+	}
+
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   now,
+		Action:      store.ActionCredentialCheckedOut,
+		Service:     service,
+		Scope:       "write",
+		Details:     fmt.Sprintf("holder: %s, until %s", c.Holder, c.ExpiresAt.UTC().Format(time.RFC3339)),
+		Actor:       "agent",
+		ElevationID: c.ElevationID,
+	}))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// checkIn releases a checkout so other agents can check the credential out.
+func (h *agentHandler) checkIn(w http.ResponseWriter, r *http.Request) {
+	c, err := h.store.GetCheckout(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Error("get checkout failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if c == nil {
+		h.jsonError(w, "checkout not found", http.StatusNotFound)
+		return
+	}
+	ended, err := h.store.CheckIn(c.ID, "checked in")
+	if err != nil {
+		h.logger.Error("check in failed", "error", err, "checkout_id", c.ID)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if ended {
+		h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+			ID:          generateID("audit"),
+			Timestamp:   time.Now(),
+			Action:      store.ActionCredentialCheckedIn,
+			Service:     c.Service,
+			Scope:       "write",
+			Details:     "holder: " + c.Holder,
+			Actor:       "agent",
+			ElevationID: c.ElevationID,
+		}))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listCheckouts lists the checkouts holding credentials.
+func (h *adminHandler) listCheckouts(w http.ResponseWriter, r *http.Request) {
+	checkouts, err := h.store.ListActiveCheckouts()
+	if err != nil {
+		h.logger.Error("list checkouts failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if checkouts == nil {
+		checkouts = []*store.Checkout{}
+	}
+	h.jsonResponse(w, checkouts)
+}
+
+// breakCheckout ends a checkout on an agent's behalf, e.g. one left behind
+// by an agent that crashed.
+func (h *adminHandler) breakCheckout(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	c, err := h.store.GetCheckout(id)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if c == nil {
+		h.jsonError(w, "checkout not found", http.StatusNotFound)
+		return
+	}
+	ended, err := h.store.CheckIn(id, "checked in by admin")
+	if err != nil {
+		h.logger.Error("check in failed", "error", err, "checkout_id", id)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if ended {
+		h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+			ID:          generateID("audit"),
+			Timestamp:   time.Now(),
+			Action:      store.ActionCredentialCheckedIn,
+			Service:     c.Service,
+			Scope:       "write",
+			Details:     "holder: " + c.Holder + " (checked in by admin)",
+			Actor:       "admin",
+			ElevationID: c.ElevationID,
+		}))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
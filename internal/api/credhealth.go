@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/health"
+)
+
+// checkCredentialHealth checks a credential's token against its provider
+// now, records the result and returns it. A rejected token is a 200 with
+// status invalid; a credential the catalog has no check for is a 409.
+func (h *adminHandler) checkCredentialHealth(w http.ResponseWriter, r *http.Request) {
+	if h.elevation == nil {
+		h.jsonError(w, "health checks unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	service := chi.URLParam(r, "service")
+
+	result, err := h.elevation.CheckHealth(r.Context(), service)
+	switch {
+	case errors.Is(err, elevation.ErrCredentialNotFound):
+		h.jsonError(w, "not found", http.StatusNotFound)
+	case errors.Is(err, health.ErrNoCheck):
+		h.jsonError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		h.logger.Error("credential health check failed", "service", service, "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+	default:
+		h.jsonResponse(w, result)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+)
+
+func TestAdminAPI_CheckCredential(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	router := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+
+	req := CreateCredentialRequest{
+		Service: "internal-api", DisplayName: "Internal API", Type: "api_key",
+		Read: &AccessLevelConfig{EnvVar: "INTERNAL_API_KEY", Token: "k"},
+	}
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials", req); w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials/internal-api/check", nil); w.Code != http.StatusConflict {
+		t.Errorf("no catalog check: status = %d, want 409", w.Code)
+	}
+	if w := doJSON(t, router, http.MethodPost, "/admin/api/v1/credentials/missing/check", nil); w.Code != http.StatusNotFound {
+		t.Errorf("missing: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CrossOrigin returns middleware that blocks cross-site request forgery
+// against the admin server and answers CORS requests from allowed origins,
+// e.g. the SvelteKit dev server pointed at a remote OCM.
+//
+// A request that changes state (anything but GET, HEAD and OPTIONS) is
+// refused with 403 if the browser marks it as cross-origin, by
+// Sec-Fetch-Site or, from older browsers, an Origin that isn't the request's
+// host, unless that origin is allowed. Requests without either header don't
+// come from a browser page (the CLI, curl, Slack and Discord callbacks) and
+// pass. Allowed origins must be exact scheme://host[:port] values; "*" is
+// refused because it would turn the protection off.
+func CrossOrigin(allowed []string, logger *slog.Logger) (func(http.Handler) http.Handler, error) {
+	origins := make(map[string]bool, len(allowed))
+	for _, o := range allowed {
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return nil, fmt.Errorf("%q is not an origin (scheme://host[:port])", o)
+		}
+		origins[u.Scheme+"://"+u.Host] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origins[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Add("Vary", "Origin")
+				if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+					w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+					if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+						w.Header().Set("Access-Control-Allow-Headers", headers)
+					}
+					w.Header().Set("Access-Control-Max-Age", "600")
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				if crossOrigin(r) {
+					logger.Warn("blocked cross-origin request", "method", r.Method, "path", r.URL.Path, "origin", origin)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"error":"cross-origin request blocked; allow the origin with --cors-origin"}`))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// crossOrigin reports whether a browser marked r as coming from another
+// origin.
+func crossOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "":
+	case "same-origin", "none": // "none": typed in, bookmarked
+		return false
+	default:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || !strings.EqualFold(u.Host, r.Host)
+}
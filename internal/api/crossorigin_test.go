@@ -0,0 +1,71 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCrossOrigin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mw, err := CrossOrigin([]string{"http://localhost:5173"}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{"no browser headers", "POST", nil, http.StatusOK},
+		{"same origin", "POST", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://ocm.local:8080"}, http.StatusOK},
+		{"cross site", "POST", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"same site", "DELETE", map[string]string{"Sec-Fetch-Site": "same-site", "Origin": "http://other.ocm.local"}, http.StatusForbidden},
+		{"cross site read", "GET", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusOK},
+		{"old browser, same host", "PUT", map[string]string{"Origin": "http://ocm.local:8080"}, http.StatusOK},
+		{"old browser, other host", "PUT", map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"opaque origin", "POST", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"allowed origin", "POST", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "http://localhost:5173"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://ocm.local:8080/admin/api/v1/credentials", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			allowed := w.Header().Get("Access-Control-Allow-Origin")
+			if (tt.headers["Origin"] == "http://localhost:5173") != (allowed != "") {
+				t.Errorf("Access-Control-Allow-Origin = %q", allowed)
+			}
+		})
+	}
+
+	// Preflight from an allowed origin is answered here
+	req := httptest.NewRequest("OPTIONS", "http://ocm.local:8080/admin/api/v1/credentials", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Headers") != "content-type" {
+		t.Errorf("preflight: status = %d, headers = %v", w.Code, w.Header())
+	}
+
+	for _, origin := range []string{"*", "localhost:5173", "http://localhost:5173/app", "ftp://x"} {
+		if _, err := CrossOrigin([]string{origin}, logger); err == nil {
+			t.Errorf("CrossOrigin(%q) accepted", origin)
+		}
+	}
+}
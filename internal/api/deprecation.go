@@ -0,0 +1,53 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deprecation describes routes that still work but are going away.
+// Responses carry it as Deprecation (RFC 9745), Sunset (RFC 8594) and a
+// successor-version Link, so clients can notice before the routes are
+// removed.
+type deprecation struct {
+	Since     time.Time                // When the routes were deprecated
+	Sunset    time.Time                // When they'll be removed; zero if not yet scheduled
+	Successor func(path string) string // The replacement for a deprecated path
+}
+
+// legacyAdminAPI covers the unversioned /admin/api paths, which alias
+// /admin/api/v1 while the web UI and external automations move over.
+var legacyAdminAPI = deprecation{
+	Since: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+	Successor: func(path string) string {
+		return adminAPIPrefix + strings.TrimPrefix(path, "/admin/api")
+	},
+}
+
+// adminAPIPrefix is the current version of the admin API.
+const adminAPIPrefix = "/admin/api/v1"
+
+// deprecated returns middleware that marks responses with d. The first use
+// is logged, once, so operators can find clients still on the old routes.
+func deprecated(d deprecation, logger *slog.Logger) func(http.Handler) http.Handler {
+	var once sync.Once
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			successor := d.Successor(r.URL.Path)
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			if !d.Sunset.IsZero() {
+				w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+			once.Do(func() {
+				logger.Warn("deprecated API route used; further uses aren't logged",
+					"path", r.URL.Path, "successor", successor, "user_agent", r.UserAgent())
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
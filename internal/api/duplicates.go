@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// blockDuplicateSecrets makes saving a credential whose token is already
+// stored under another service fail with a 409 instead of warning; serve
+// sets it.
+var blockDuplicateSecrets = false
+
+// SetBlockDuplicateSecrets sets whether duplicate secrets are refused.
+func SetBlockDuplicateSecrets(block bool) {
+	blockDuplicateSecrets = block
+}
+
+// duplicateSecrets describes which of cred's tokens are already stored
+// under another service, or returns "" if none are. A shared token can't be
+// revoked for one service alone.
+func (h *adminHandler) duplicateSecrets(cred *store.Credential) string {
+	dups, err := h.store.FindDuplicateSecrets(cred)
+	if err != nil {
+		// Not worth failing the save over
+		h.logger.Error("duplicate secret check failed", "service", cred.Service, "error", err)
+		return ""
+	}
+	if len(dups) == 0 {
+		return ""
+	}
+	var where []string
+	for _, d := range dups {
+		where = append(where, fmt.Sprintf("the %s token is also %s's %s token", d.Level, d.Service, d.ServiceLevel))
+	}
+	h.logger.Warn("duplicate secret", "service", cred.Service, "duplicates", len(dups), "blocked", blockDuplicateSecrets)
+	return strings.Join(where, "; ") + ". Revoking a shared token revokes it for every service that holds it"
+}
+
+// joinWarnings joins the non-empty warnings for the client.
+func joinWarnings(warnings ...string) string {
+	var out []string
+	for _, w := range warnings {
+		if w != "" {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
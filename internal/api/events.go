@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// sseHeartbeat keeps idle event streams from being closed by proxies.
+const sseHeartbeat = 25 * time.Second
+
+// streamEvents is a server-sent events stream of every notification event
+// (elevations, credential changes, device pairing, gateway status), so the
+// admin UI can update live. Each message's "event" field is the event type
+// and its data is the notify.Event JSON.
+func (h *adminHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if h.notifier == nil {
+		h.jsonError(w, "event stream not available", http.StatusServiceUnavailable)
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		h.jsonError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := h.notifier.Subscribe()
+	defer cancel()
+	flusher := startEventStream(w)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	var id int
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			id++
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// startEventStream writes the headers and retry preamble of a server-sent
+// events stream. w must be an http.Flusher.
+func startEventStream(w http.ResponseWriter) http.Flusher {
+	// The stream outlives the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+	return flusher
+}
+
+// timeoutUnlessStreaming is middleware.Timeout for every route of routes
+// except event streams (GET routes whose pattern ends in /events), which
+// stay open until the client leaves, and the long polls named by longPolls
+// (GET route patterns such as "/api/v1/elevate/{id}"), whose timeout
+// allows for the longest wait.
+func timeoutUnlessStreaming(routes chi.Routes, d time.Duration, longPolls ...string) func(http.Handler) http.Handler {
+	timeout := middleware.Timeout(d)
+	longPollTimeout := middleware.Timeout(maxElevationWait + d)
+	return func(next http.Handler) http.Handler {
+		withTimeout, withLongPollTimeout := timeout(next), longPollTimeout(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var pattern string
+			if r.Method == http.MethodGet {
+				pattern = routePattern(routes, r)
+			}
+			switch {
+			case strings.HasSuffix(pattern, "/events"):
+				next.ServeHTTP(w, r)
+			case slices.Contains(longPolls, pattern):
+				withLongPollTimeout.ServeHTTP(w, r)
+			default:
+				withTimeout.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// routePattern returns the pattern of the route of routes that r is for,
+// or "" if there is none.
+func routePattern(routes chi.Routes, r *http.Request) string {
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, r.Method, r.URL.Path) {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+)
+
+func TestAdminAPI_EventStream(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	dispatcher := notify.NewDispatcher(logger)
+	srv := httptest.NewServer(NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, dispatcher, logger))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/admin/api/v1/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// The subscription exists once the retry preamble has been flushed
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != "retry: 5000" {
+		t.Fatalf("unexpected preamble %q", lines.Text())
+	}
+	dispatcher.Publish(notify.Event{Type: notify.EventCredentialDeleted, Service: "github", Actor: "admin"})
+
+	got := make(chan string, 1)
+	go func() {
+		for lines.Scan() {
+			if strings.HasPrefix(lines.Text(), "event: ") {
+				got <- strings.TrimPrefix(lines.Text(), "event: ")
+				return
+			}
+		}
+	}()
+	select {
+	case ev := <-got:
+		if ev != string(notify.EventCredentialDeleted) {
+			t.Errorf("event = %q", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestTimeoutUnlessStreaming(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(timeoutUnlessStreaming(r, 20*time.Millisecond, "/poll/{id}"))
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	r.Get("/poll/{id}", slow)
+	r.Get("/other", slow)
+	r.Get("/other/events", slow)
+
+	for path, want := range map[string]int{
+		"/poll/1?wait=1s": http.StatusOK,
+		"/other?wait=1s":  http.StatusGatewayTimeout, // Only the named long polls may wait
+		"/other/events":   http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "text/event-stream") // Only the /events routes stream
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// GatewayInfo describes a configured OpenClaw Gateway.
+type GatewayInfo struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	EnvFile  string `json:"envFile"`
+	State    string `json:"state"`    // RPC connection state, or "disabled"
+	Queued   int    `json:"queued"`   // Operations waiting for the Gateway to reconnect
+	Failed   int    `json:"failed"`   // Queued operations given up on after repeated failures
+	Degraded bool   `json:"degraded"` // RPC calls are failing fast after repeated failures
+
+	Capabilities *gateway.Capabilities `json:"capabilities,omitempty"` // As negotiated on the last connect
+}
+
+// listGateways returns the gateways credentials can be injected into,
+// default first.
+func (h *adminHandler) listGateways(w http.ResponseWriter, r *http.Request) {
+	gateways, err := h.gatewayInfos()
+	if err != nil {
+		h.logger.Error("list gateway queue failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.jsonResponse(w, gateways)
+}
+
+// gatewayInfos describes each configured gateway, default first.
+func (h *adminHandler) gatewayInfos() ([]GatewayInfo, error) {
+	ops, err := h.store.ListGatewayOps("")
+	if err != nil {
+		return nil, err
+	}
+	queued, failed := make(map[string]int), make(map[string]int)
+	for _, op := range ops {
+		if op.FailedAt != nil {
+			failed[op.Gateway]++
+		} else {
+			queued[op.Gateway]++
+		}
+	}
+
+	gateways := []GatewayInfo{}
+	if h.elevation != nil {
+		for name, gw := range h.elevation.Gateways() {
+			if gw == nil {
+				continue
+			}
+			gateways = append(gateways, GatewayInfo{
+				Name:     name,
+				URL:      gw.GatewayURL,
+				EnvFile:  gw.EnvFilePath,
+				State:    gw.ConnectionState(),
+				Queued:   queued[name],
+				Failed:   failed[name],
+				Degraded: gw.Degraded(),
+
+				Capabilities: gw.Capabilities(),
+			})
+		}
+	}
+	sort.Slice(gateways, func(i, j int) bool {
+		if (gateways[i].Name == gateway.DefaultName) != (gateways[j].Name == gateway.DefaultName) {
+			return gateways[i].Name == gateway.DefaultName
+		}
+		return gateways[i].Name < gateways[j].Name
+	})
+	return gateways, nil
+}
+
+// deleteFailedGatewayOps discards a gateway's failed queued operations once
+// an admin has seen them.
+func (h *adminHandler) deleteFailedGatewayOps(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.store.DeleteFailedGatewayOps(chi.URLParam(r, "name")); err != nil {
+		h.logger.Error("delete failed gateway operations failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// health reports "ok", or "degraded: " and the gateways whose RPC calls
+// are failing fast.
+func (h *adminHandler) health(w http.ResponseWriter, r *http.Request) {
+	var degraded []string
+	if h.elevation != nil {
+		for name, gw := range h.elevation.Gateways() {
+			if gw != nil && gw.Degraded() {
+				degraded = append(degraded, name)
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if len(degraded) == 0 {
+		w.Write([]byte("ok"))
+		return
+	}
+	sort.Strings(degraded)
+	w.Write([]byte("degraded: " + strings.Join(degraded, ", ")))
+}
+
+// InjectedTargetInfo is an env var or config path OCM manages in a gateway,
+// with masked values.
+type InjectedTargetInfo struct {
+	Type     store.InjectionType `json:"type"`
+	Key      string              `json:"key"`
+	Service  string              `json:"service"`
+	Level    string              `json:"level"`
+	Status   string              `json:"status"` // ok, missing, mismatch, stale or unknown
+	Injected bool                `json:"injected"`
+	Desired  string              `json:"desired,omitempty"`
+	Actual   string              `json:"actual,omitempty"`
+}
+
+// GatewayInjectedInfo is what OCM has injected into one gateway.
+type GatewayInjectedInfo struct {
+	Gateway     string               `json:"gateway"`
+	Targets     []InjectedTargetInfo `json:"targets"`
+	Drift       int                  `json:"drift"` // Targets that differ from the desired state
+	EnvError    string               `json:"envError,omitempty"`
+	ConfigError string               `json:"configError,omitempty"`
+}
+
+// GatewayStats is one gateway's operation counts since OCM started.
+type GatewayStats struct {
+	Name     string `json:"name"`
+	State    string `json:"state"` // RPC connection state, or "disabled"
+	Degraded bool   `json:"degraded"`
+	gateway.Stats
+}
+
+// gatewayStats returns restart, config patch and connection counts for
+// each gateway, classified by result, default first.
+func (h *adminHandler) gatewayStats(w http.ResponseWriter, r *http.Request) {
+	stats := []GatewayStats{}
+	if h.elevation != nil {
+		for name, gw := range h.elevation.Gateways() {
+			if gw == nil {
+				continue
+			}
+			stats = append(stats, GatewayStats{Name: name, State: gw.ConnectionState(), Degraded: gw.Degraded(), Stats: gw.Stats()})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].Name == gateway.DefaultName) != (stats[j].Name == gateway.DefaultName) {
+			return stats[i].Name == gateway.DefaultName
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	h.jsonResponse(w, stats)
+}
+
+// listInjected returns the env vars and config paths OCM manages in each
+// gateway (or just ?gateway=name), compared with what the store says should
+// be injected. Values are masked.
+func (h *adminHandler) listInjected(w http.ResponseWriter, r *http.Request) {
+	if h.elevation == nil {
+		h.jsonError(w, "gateway injection is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var names []string
+	if name := r.URL.Query().Get("gateway"); name != "" {
+		if _, err := h.elevation.GatewayFor(name); err != nil {
+			h.jsonError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		names = []string{name}
+	} else {
+		for name, gw := range h.elevation.Gateways() {
+			if gw != nil {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == gateway.DefaultName) != (names[j] == gateway.DefaultName) {
+			return names[i] == gateway.DefaultName
+		}
+		return names[i] < names[j]
+	})
+
+	resp := []GatewayInjectedInfo{}
+	for _, name := range names {
+		state, err := h.elevation.InjectedState(name)
+		if errors.Is(err, elevation.ErrUnknownGateway) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error("read injected state failed", "gateway", name, "error", err)
+			h.jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		info := GatewayInjectedInfo{
+			Gateway:     name,
+			Targets:     make([]InjectedTargetInfo, 0, len(state.Targets)),
+			Drift:       len(state.Drifted()),
+			EnvError:    state.EnvError,
+			ConfigError: state.ConfigError,
+		}
+		for _, t := range state.Targets {
+			info.Targets = append(info.Targets, InjectedTargetInfo{
+				Type:     t.Type,
+				Key:      t.Key,
+				Service:  t.Service,
+				Level:    t.Level,
+				Status:   t.Status,
+				Injected: t.Injected,
+				Desired:  maskSecret(t.Desired),
+				Actual:   maskSecret(t.Actual),
+			})
+		}
+		resp = append(resp, info)
+	}
+	h.jsonResponse(w, resp)
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+const (
+	// guestInviteMACPurpose domain-separates guest link signatures.
+	guestInviteMACPurpose = "guest-invite"
+	defaultGuestValidFor  = time.Hour
+	maxGuestValidFor      = 24 * time.Hour
+	defaultGuestMaxTTL    = 30 * time.Minute
+)
+
+// CreateGuestInviteRequest is the request body for POST /requests/{id}/guest-invites.
+type CreateGuestInviteRequest struct {
+	Guest    string `json:"guest"`              // Identity the guest must present (e.g., email)
+	ValidFor string `json:"validFor,omitempty"` // How long the link works, e.g., "1h" (max 24h)
+	MaxTTL   string `json:"maxTTL,omitempty"`   // Max elevation TTL the guest may grant, e.g., "30m"
+}
+
+// GuestInviteResponse is returned when a guest invite is created.
+type GuestInviteResponse struct {
+	InviteID  string    `json:"inviteId"`
+	URL       string    `json:"url"` // Relative path to share with the guest
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// GuestDecisionRequest is the request body for guest approve/deny.
+// Identity and name are mandatory so the decision is attributable.
+type GuestDecisionRequest struct {
+	Identity string `json:"identity"`         // Must match the invited identity
+	Name     string `json:"name"`             // Full name of the person deciding
+	TTL      string `json:"ttl,omitempty"`    // Approve only; clamped to the invite's maxTTL
+	Reason   string `json:"reason,omitempty"` // Deny only
+}
+
+// GuestRequestView is what a guest sees about the request they were invited to decide.
+type GuestRequestView struct {
+	Service     string    `json:"service"`
+	Scope       string    `json:"scope"`
+	Reason      string    `json:"reason"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requestedAt"`
+	MaxTTL      string    `json:"maxTTL"`
+	InviteBy    string    `json:"invitedBy"`
+	ExpiresAt   time.Time `json:"linkExpiresAt"`
+}
+
+func (h *adminHandler) createGuestInvite(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req CreateGuestInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Guest = strings.TrimSpace(req.Guest)
+	if req.Guest == "" {
+		h.jsonError(w, "guest identity is required", http.StatusBadRequest)
+		return
+	}
+
+	validFor := defaultGuestValidFor
+	if req.ValidFor != "" {
+		d, err := time.ParseDuration(req.ValidFor)
+		if err != nil || d <= 0 {
+			h.jsonError(w, "invalid validFor duration", http.StatusBadRequest)
+			return
+		}
+		validFor = d
+	}
+	if validFor > maxGuestValidFor {
+		h.jsonError(w, fmt.Sprintf("validFor cannot exceed %s", maxGuestValidFor), http.StatusBadRequest)
+		return
+	}
+
+	maxTTL := defaultGuestMaxTTL
+	if req.MaxTTL != "" {
+		d, err := time.ParseDuration(req.MaxTTL)
+		if err != nil || d <= 0 {
+			h.jsonError(w, "invalid maxTTL duration", http.StatusBadRequest)
+			return
+		}
+		maxTTL = d
+	}
+
+	elev, err := h.store.GetElevation(id)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if elev == nil {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if elev.Status != "pending" {
+		h.jsonError(w, fmt.Sprintf("elevation not pending (status: %s)", elev.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	inv := &store.GuestInvite{
+		ID:          generateID("guest"),
+		ElevationID: elev.ID,
+		Guest:       req.Guest,
+		MaxTTL:      maxTTL,
+		CreatedBy:   "admin",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(validFor),
+	}
+	if err := h.store.CreateGuestInvite(inv); err != nil {
+		h.logger.Error("create guest invite failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionGuestInviteCreated,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     fmt.Sprintf("invite: %s, elevation: %s, guest: %s, valid for: %s", inv.ID, elev.ID, inv.Guest, validFor),
+		Actor:       "admin",
+		ElevationID: elev.ID,
+	}))
+
+	h.logger.Info("guest invite created", "invite_id", inv.ID, "elevation_id", elev.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	h.jsonResponse(w, GuestInviteResponse{
+		InviteID:  inv.ID,
+		URL:       "/guest/api/invites/" + h.signGuestInvite(inv),
+		ExpiresAt: inv.ExpiresAt,
+	})
+}
+
+// signGuestInvite produces the opaque link token "<inviteID>.<signature>".
+// The signature binds the invite ID to its expiry, so neither can be altered.
+func (h *adminHandler) signGuestInvite(inv *store.GuestInvite) string {
+	mac := h.store.MAC(guestInviteMACPurpose, []byte(fmt.Sprintf("%s|%d", inv.ID, inv.ExpiresAt.Unix())))
+	return inv.ID + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// resolveGuestInvite verifies a link token and returns the live invite and its elevation.
+// On failure it writes the error response and returns nil.
+func (h *adminHandler) resolveGuestInvite(w http.ResponseWriter, token string) (*store.GuestInvite, *store.Elevation) {
+	inviteID, sig, ok := strings.Cut(token, ".")
+	if !ok || inviteID == "" {
+		h.jsonError(w, "invalid link", http.StatusNotFound)
+		return nil, nil
+	}
+
+	inv, err := h.store.GetGuestInvite(inviteID)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return nil, nil
+	}
+	if inv == nil || !hmac.Equal([]byte(h.signGuestInvite(inv)), []byte(inviteID+"."+sig)) {
+		h.jsonError(w, "invalid link", http.StatusNotFound)
+		return nil, nil
+	}
+	if inv.UsedAt != nil {
+		h.jsonError(w, "link already used", http.StatusGone)
+		return nil, nil
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		h.jsonError(w, "link expired", http.StatusGone)
+		return nil, nil
+	}
+
+	elev, err := h.store.GetElevation(inv.ElevationID)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return nil, nil
+	}
+	if elev == nil {
+		h.jsonError(w, "invalid link", http.StatusNotFound)
+		return nil, nil
+	}
+	return inv, elev
+}
+
+func (h *adminHandler) getGuestInvite(w http.ResponseWriter, r *http.Request) {
+	inv, elev := h.resolveGuestInvite(w, chi.URLParam(r, "token"))
+	if inv == nil {
+		return
+	}
+
+	h.jsonResponse(w, GuestRequestView{
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Reason:      elev.Reason,
+		Status:      elev.Status,
+		RequestedAt: elev.RequestedAt,
+		MaxTTL:      inv.MaxTTL.String(),
+		InviteBy:    inv.CreatedBy,
+		ExpiresAt:   inv.ExpiresAt,
+	})
+}
+
+// decodeGuestDecision parses and checks the guest's identification.
+// On failure it writes the error response and returns nil.
+func (h *adminHandler) decodeGuestDecision(w http.ResponseWriter, r *http.Request, inv *store.GuestInvite) *GuestDecisionRequest {
+	var req GuestDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return nil
+	}
+	req.Identity = strings.TrimSpace(req.Identity)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Identity == "" || req.Name == "" {
+		h.jsonError(w, "identity and name are required", http.StatusBadRequest)
+		return nil
+	}
+	if !strings.EqualFold(req.Identity, inv.Guest) {
+		h.logger.Warn("guest identity mismatch", "invite_id", inv.ID)
+		h.jsonError(w, "identity does not match this invitation", http.StatusForbidden)
+		return nil
+	}
+	return &req
+}
+
+func (h *adminHandler) guestApprove(w http.ResponseWriter, r *http.Request) {
+	inv, elev := h.resolveGuestInvite(w, chi.URLParam(r, "token"))
+	if inv == nil {
+		return
+	}
+	req := h.decodeGuestDecision(w, r, inv)
+	if req == nil {
+		return
+	}
+
+	ttl := inv.MaxTTL
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			h.jsonError(w, "invalid ttl duration", http.StatusBadRequest)
+			return
+		}
+		if d < ttl {
+			ttl = d
+		}
+	}
+
+	// Claim the invite first so concurrent submissions can't both act
+	if err := h.store.RedeemGuestInvite(inv.ID, "approved"); err != nil {
+		h.jsonError(w, "link already used", http.StatusGone)
+		return
+	}
+
+	actor := "guest:" + inv.Guest
+	if err := h.elevation.ApproveElevationContext(r.Context(), elev.ID, ttl, actor); err != nil {
+		h.store.ReleaseGuestInvite(inv.ID)
+		h.logger.Error("guest approve elevation failed", "error", err, "invite_id", inv.ID)
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionGuestInviteUsed,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     fmt.Sprintf("invite: %s, elevation: %s, decision: approved, name: %s, invited by: %s", inv.ID, elev.ID, req.Name, inv.CreatedBy),
+		Actor:       actor,
+		ElevationID: elev.ID,
+	}))
+
+	h.logger.Info("elevation approved via guest link", "invite_id", inv.ID, "elevation_id", elev.ID, "ttl", ttl)
+
+	updated, err := h.store.GetElevation(elev.ID)
+	if err != nil {
+		h.logger.Error("get approved elevation failed", "error", err, "elevation_id", elev.ID)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if updated == nil {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+	h.jsonResponse(w, map[string]interface{}{
+		"status":    "approved",
+		"expiresAt": updated.ExpiresAt,
+	})
+}
+
+func (h *adminHandler) guestDeny(w http.ResponseWriter, r *http.Request) {
+	inv, elev := h.resolveGuestInvite(w, chi.URLParam(r, "token"))
+	if inv == nil {
+		return
+	}
+	req := h.decodeGuestDecision(w, r, inv)
+	if req == nil {
+		return
+	}
+	if elev.Status != "pending" {
+		h.jsonError(w, fmt.Sprintf("elevation not pending (status: %s)", elev.Status), http.StatusConflict)
+		return
+	}
+
+	if err := h.store.RedeemGuestInvite(inv.ID, "denied"); err != nil {
+		h.jsonError(w, "link already used", http.StatusGone)
+		return
+	}
+
+	actor := "guest:" + inv.Guest
+	if err := h.elevation.DenyElevation(elev.ID, actor, req.Reason); err != nil {
+		h.store.ReleaseGuestInvite(inv.ID)
+		h.jsonError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionGuestInviteUsed,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     fmt.Sprintf("invite: %s, elevation: %s, decision: denied, name: %s, invited by: %s", inv.ID, elev.ID, req.Name, inv.CreatedBy),
+		Actor:       actor,
+		ElevationID: elev.ID,
+	}))
+
+	h.logger.Info("elevation denied via guest link", "invite_id", inv.ID, "elevation_id", elev.ID)
+
+	h.jsonResponse(w, map[string]string{"status": "denied"})
+}
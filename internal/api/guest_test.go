@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func setupAdminRouter(t *testing.T, db *store.Store) http.Handler {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	elevSvc := elevation.NewService(db, gw, logger)
+	return NewAdminRouter(db, elevSvc, nil, nil, nil, logger)
+}
+
+func createPendingElevation(t *testing.T, db *store.Store) {
+	t.Helper()
+	cred := &store.Credential{
+		ID:          "test-cred",
+		Service:     "github",
+		DisplayName: "GitHub",
+		Type:        "pat",
+		Read:        &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite:   &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token", MaxTTL: time.Hour},
+	}
+	if err := db.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	elev := &store.Elevation{
+		ID:          "elev-1",
+		Service:     "github",
+		Scope:       "write",
+		Reason:      "Merge release PR",
+		Status:      "pending",
+		RequestedAt: time.Now(),
+	}
+	if err := db.CreateElevation(elev); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func doJSON(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGuestInvite_ApproveFlow(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	router := setupAdminRouter(t, db)
+	createPendingElevation(t, db)
+
+	w := doJSON(t, router, "POST", "/admin/api/v1/requests/elev-1/guest-invites", CreateGuestInviteRequest{
+		Guest:  "alice@example.com",
+		MaxTTL: "15m",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create invite status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var invite GuestInviteResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &invite); err != nil {
+		t.Fatal(err)
+	}
+
+	// Viewing the link works without identification
+	w = doJSON(t, router, "GET", invite.URL, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get invite status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Tampered signature is rejected
+	w = doJSON(t, router, "GET", invite.URL+"x", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("tampered link status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Wrong identity is rejected
+	w = doJSON(t, router, "POST", invite.URL+"/approve", GuestDecisionRequest{Identity: "mallory@example.com", Name: "Mallory"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("wrong identity status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// Matching identity approves, TTL clamped to the invite's maxTTL
+	w = doJSON(t, router, "POST", invite.URL+"/approve", GuestDecisionRequest{Identity: "Alice@Example.com", Name: "Alice Smith", TTL: "2h"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	elev, err := db.GetElevation("elev-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elev.Status != "approved" {
+		t.Errorf("elevation status = %s, want approved", elev.Status)
+	}
+	if elev.ApprovedBy != "guest:alice@example.com" {
+		t.Errorf("approvedBy = %s, want guest:alice@example.com", elev.ApprovedBy)
+	}
+	if elev.ExpiresAt == nil || time.Until(*elev.ExpiresAt) > 16*time.Minute {
+		t.Errorf("expiresAt = %v, want within invite maxTTL", elev.ExpiresAt)
+	}
+
+	// Links are single-use
+	w = doJSON(t, router, "POST", invite.URL+"/deny", GuestDecisionRequest{Identity: "alice@example.com", Name: "Alice Smith"})
+	if w.Code != http.StatusGone {
+		t.Errorf("reused link status = %d, want %d", w.Code, http.StatusGone)
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// scanLeaks scans every gateway's config, and its recent logs with
+// ?logs=true, for stored secrets outside their injection points now, and
+// returns what it found. Surfaces that couldn't be read are listed in
+// errors rather than failing the scan.
+func (h *adminHandler) scanLeaks(w http.ResponseWriter, r *http.Request) {
+	if h.elevation == nil {
+		h.jsonError(w, "leak scans unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	logs, _ := strconv.ParseBool(r.URL.Query().Get("logs"))
+
+	report, err := h.elevation.ScanLeaks(r.Context(), logs)
+	if err != nil {
+		h.logger.Error("leak scan failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.jsonResponse(w, report)
+}
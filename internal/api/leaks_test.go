@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+)
+
+func TestAdminAPI_ScanLeaks(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	if w := doJSON(t, NewAdminRouter(db, nil, nil, nil, nil, logger), http.MethodPost, "/admin/api/v1/gateway/leaks/scan", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no elevation service: status = %d, want 503", w.Code)
+	}
+
+	// Without a Gateway RPC connection, nothing can be read
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	router := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+	w := doJSON(t, router, http.MethodPost, "/admin/api/v1/gateway/leaks/scan?logs=true", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var report elevation.LeakReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Leaks) != 0 || len(report.Errors) != 2 || !strings.HasPrefix(report.Errors[0], "default config: ") {
+		t.Errorf("report = %+v, want no leaks and config and logs errors", report)
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// leaseTTL is how long a credential lease lasts between renewals. Write
+// leases never outlast their elevation.
+const leaseTTL = 15 * time.Minute
+
+// LeaseResponse is a lease and its status (live, expired or revoked).
+type LeaseResponse struct {
+	store.Lease
+	Status string `json:"status"`
+}
+
+func leaseResponse(l *store.Lease) LeaseResponse {
+	return LeaseResponse{Lease: *l, Status: l.Status(time.Now())}
+}
+
+// wantsLease reports whether the agent asked for its credentials to be
+// leased (?lease=true).
+func wantsLease(r *http.Request) bool {
+	lease, _ := strconv.ParseBool(r.URL.Query().Get("lease"))
+	return lease
+}
+
+// issueLease records a lease on a credential handed out for scope. until,
+// if set, is when the granting elevation expires.
+func (h *agentHandler) issueLease(service, scope, elevationID string, until *time.Time) (*store.Lease, error) {
+	now := time.Now()
+	l := &store.Lease{
+		ID:          generateID("lease"),
+		Service:     service,
+		Scope:       scope,
+		ElevationID: elevationID,
+		IssuedAt:    now,
+		ExpiresAt:   leaseExpiry(now, until),
+	}
+	return l, h.store.CreateLease(l)
+}
+
+// leaseExpiry is when a lease issued or renewed at now runs out.
+func leaseExpiry(now time.Time, until *time.Time) time.Time {
+	if until != nil && until.Before(now.Add(leaseTTL)) {
+		return *until
+	}
+	return now.Add(leaseTTL)
+}
+
+// leaseFromRequest loads the lease named in the URL, writing a 404 if there
+// is none.
+func (h *agentHandler) leaseFromRequest(w http.ResponseWriter, r *http.Request) *store.Lease {
+	l, err := h.store.GetLease(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Error("get lease failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+	if l == nil {
+		h.jsonError(w, "lease not found", http.StatusNotFound)
+	}
+	return l
+}
+
+func (h *agentHandler) getLease(w http.ResponseWriter, r *http.Request) {
+	if l := h.leaseFromRequest(w, r); l != nil {
+		h.jsonResponse(w, leaseResponse(l))
+	}
+}
+
+// renewLease extends a live lease by leaseTTL. Expired and revoked leases
+// can't be renewed (410); the agent must fetch the credential again. A
+// write lease whose elevation has ended is revoked instead.
+func (h *agentHandler) renewLease(w http.ResponseWriter, r *http.Request) {
+	l := h.leaseFromRequest(w, r)
+	if l == nil {
+		return
+	}
+	now := time.Now()
+	if status := l.Status(now); status != store.LeaseLive {
+		msg := "lease " + status
+		if l.RevokeReason != "" {
+			msg += ": " + l.RevokeReason
+		}
+		h.jsonError(w, msg, http.StatusGone)
+		return
+	}
+
+	var until *time.Time
+	if l.ElevationID != "" {
+		elev, err := h.store.GetElevation(l.ElevationID)
+		if err != nil {
+			h.logger.Error("get elevation failed", "error", err)
+			h.jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if elev == nil || elev.Status != "approved" || elev.ExpiresAt == nil || !now.Before(*elev.ExpiresAt) {
+			if _, err := h.store.RevokeLease(l.ID, "elevation ended"); err != nil {
+				h.logger.Error("revoke lease failed", "error", err, "lease_id", l.ID)
+			}
+			h.jsonError(w, "lease revoked: elevation ended", http.StatusGone)
+			return
+		}
+		until = elev.ExpiresAt
+	}
+
+	expiresAt := leaseExpiry(now, until)
+	ok, err := h.store.RenewLease(l.ID, expiresAt)
+	if err != nil {
+		h.logger.Error("renew lease failed", "error", err, "lease_id", l.ID)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.jsonError(w, "lease no longer live", http.StatusGone)
+		return
+	}
+	l.ExpiresAt, l.RenewedAt = expiresAt, &now
+	h.jsonResponse(w, leaseResponse(l))
+}
+
+// releaseLease ends a lease the agent no longer needs.
+func (h *agentHandler) releaseLease(w http.ResponseWriter, r *http.Request) {
+	l := h.leaseFromRequest(w, r)
+	if l == nil {
+		return
+	}
+	if _, err := h.store.RevokeLease(l.ID, "released"); err != nil {
+		h.logger.Error("release lease failed", "error", err, "lease_id", l.ID)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listLeases lists the live leases: the handed-out credential copies that
+// may still be in use.
+func (h *adminHandler) listLeases(w http.ResponseWriter, r *http.Request) {
+	leases, err := h.store.ListLiveLeases()
+	if err != nil {
+		h.logger.Error("list leases failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]LeaseResponse, 0, len(leases))
+	for _, l := range leases {
+		resp = append(resp, leaseResponse(l))
+	}
+	h.jsonResponse(w, resp)
+}
+
+func (h *adminHandler) revokeLease(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	l, err := h.store.GetLease(id)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if l == nil {
+		h.jsonError(w, "lease not found", http.StatusNotFound)
+		return
+	}
+	if _, err := h.store.RevokeLease(id, "revoked by admin"); err != nil {
+		h.logger.Error("revoke lease failed", "error", err, "lease_id", id)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionLeaseRevoked,
+		Service:     l.Service,
+		Scope:       l.Scope,
+		Actor:       "admin",
+		ElevationID: l.ElevationID,
+		Details:     "lease " + id,
+	}))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeServiceLeases revokes the live leases on service's credential after
+// it changed, so agents holding old copies find out when they next renew.
+func (h *adminHandler) revokeServiceLeases(service, reason string) {
+	if n, err := h.store.RevokeServiceLeases(service, reason); err != nil {
+		h.logger.Error("revoke leases failed", "error", err, "service", service)
+	} else if n > 0 {
+		h.logger.Info("leases revoked", "service", service, "count", n, "reason", reason)
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/openclaw/ocm/internal/logging"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// LogLevel is OCM's current log level: debug, info, warn or error.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+func (h *adminHandler) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, LogLevel{Level: logging.Name(logging.Level())})
+}
+
+// setLogLevel changes the log level until OCM restarts or it is changed
+// again, e.g., to get debug logs of a Gateway connection without
+// reconnecting.
+func (h *adminHandler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	level, err := logging.Parse(req.Level)
+	if err != nil {
+		h.jsonError(w, "level must be debug, info, warn or error", http.StatusBadRequest)
+		return
+	}
+	prev := logging.Set(level)
+	h.logger.Info("log level changed", "from", logging.Name(prev), "to", logging.Name(level))
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionLogLevelChanged,
+		Details:   fmt.Sprintf("%s -> %s", logging.Name(prev), logging.Name(level)),
+		Actor:     "admin",
+	}))
+
+	h.jsonResponse(w, LogLevel{Level: logging.Name(level)})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/logging"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestAdminAPI_LogLevel(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logging.Configure(slog.LevelInfo)
+	defer logging.Configure(slog.LevelInfo)
+	router := NewAdminRouter(db, nil, nil, nil, nil, slog.Default())
+
+	w := doJSON(t, router, "PUT", "/admin/api/v1/log-level", LogLevel{Level: "debug"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if logging.Level() != slog.LevelDebug {
+		t.Errorf("level = %v, want DEBUG", logging.Level())
+	}
+
+	w = doJSON(t, router, "GET", "/admin/api/v1/log-level", nil)
+	var got LogLevel
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Level != "debug" {
+		t.Errorf("level = %q, want debug", got.Level)
+	}
+
+	if w := doJSON(t, router, "PUT", "/admin/api/v1/log-level", LogLevel{Level: "verbose"}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid level: status = %d, want 400", w.Code)
+	}
+
+	entries, _, err := db.QueryAuditEntries(store.AuditQuery{Action: store.ActionLogLevelChanged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Details != "info -> debug" {
+		t.Errorf("audit entries = %+v, want one info -> debug", entries)
+	}
+}
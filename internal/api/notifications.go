@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func (h *adminHandler) getEmailSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := notify.LoadEmailSettings(h.store)
+	if err != nil {
+		h.logger.Error("load email settings failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if settings.To == nil {
+		settings.To = []string{}
+	}
+	h.jsonResponse(w, settings)
+}
+
+func (h *adminHandler) setEmailSettings(w http.ResponseWriter, r *http.Request) {
+	var settings notify.EmailSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.PutSetting(notify.EmailSettingKey, &settings); err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionNotificationsUpdated,
+		Details:   "channel: email",
+		Actor:     "admin",
+	}))
+
+	h.getEmailSettings(w, r)
+}
+
+func (h *adminHandler) getRouting(w http.ResponseWriter, r *http.Request) {
+	cfg, err := notify.LoadRouting(h.store)
+	if err != nil {
+		h.logger.Error("load notification routing failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cfg.Routes == nil {
+		cfg.Routes = []notify.Route{}
+	}
+	h.jsonResponse(w, map[string]interface{}{
+		"routes":    cfg.Routes,
+		"notifiers": h.notifier.Names(),
+	})
+}
+
+func (h *adminHandler) setRouting(w http.ResponseWriter, r *http.Request) {
+	var cfg notify.RoutingConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := cfg.Validate(h.notifier.Names()); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.PutSetting(notify.RoutingSettingKey, &cfg); err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionNotificationsUpdated,
+		Details:   fmt.Sprintf("routing: %d routes", len(cfg.Routes)),
+		Actor:     "admin",
+	}))
+
+	h.getRouting(w, r)
+}
+
+func (h *adminHandler) getAnomalyRules(w http.ResponseWriter, r *http.Request) {
+	cfg, err := notify.LoadAnomalyConfig(h.store)
+	if err != nil {
+		h.logger.Error("load anomaly rules failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = []notify.AnomalyRule{}
+	}
+	h.jsonResponse(w, cfg)
+}
+
+func (h *adminHandler) setAnomalyRules(w http.ResponseWriter, r *http.Request) {
+	var cfg notify.AnomalyConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.PutSetting(notify.AnomalySettingKey, &cfg); err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionNotificationsUpdated,
+		Details:   fmt.Sprintf("anomaly rules: %d rules", len(cfg.Rules)),
+		Actor:     "admin",
+	}))
+
+	h.getAnomalyRules(w, r)
+}
+
+// TestNotificationRequest test-fires a sample event through the routing config.
+type TestNotificationRequest struct {
+	Event    notify.EventType `json:"event"`              // e.g., "elevation.requested"
+	Notifier string           `json:"notifier,omitempty"` // Only this notifier
+	Service  string           `json:"service,omitempty"`
+}
+
+func (h *adminHandler) testNotification(w http.ResponseWriter, r *http.Request) {
+	if h.notifier == nil {
+		h.jsonError(w, "notifications not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req TestNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Event == "" {
+		req.Event = notify.EventElevationRequested
+	}
+	if strings.Contains(string(req.Event), "*") || !notify.ValidFilter(string(req.Event)) {
+		h.jsonError(w, fmt.Sprintf("unknown event %q", req.Event), http.StatusBadRequest)
+		return
+	}
+	if req.Service == "" {
+		req.Service = "example"
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	results, err := h.notifier.Test(r.Context(), notify.Event{
+		Type:        req.Event,
+		Time:        time.Now(),
+		Service:     req.Service,
+		Scope:       "readwrite",
+		ElevationID: "test",
+		Reason:      "OCM notification test",
+		Actor:       "admin",
+		ExpiresAt:   &expiresAt,
+		Details:     "test",
+	}, req.Notifier)
+	if err != nil {
+		h.logger.Error("test notification failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionNotificationsTested,
+		Details:   fmt.Sprintf("event: %s, notifiers: %d", req.Event, len(results)),
+		Actor:     "admin",
+	}))
+
+	h.jsonResponse(w, results)
+}
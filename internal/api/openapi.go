@@ -0,0 +1,595 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/openclaw/ocm/internal/audit"
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// The OpenAPI documents are built from the operation tables below. Request
+// and response bodies are given as values of their Go types and the schemas
+// are derived from those types, so they can't drift from what the handlers
+// encode; TestOpenAPICoversRoutes checks that every route is listed and
+// every listed operation is routed. Clients (including the web UI's types)
+// can be generated from /api/v1/openapi.json and /admin/api/v1/openapi.json.
+
+// openAPIOperation documents one route.
+type openAPIOperation struct {
+	Method   string
+	Path     string // chi pattern, e.g. /admin/api/v1/credentials/{service}
+	Tag      string
+	Summary  string
+	Query    []openAPIParam
+	Request  interface{} // JSON body, or nil
+	Response interface{} // JSON body of the success response, or nil
+	Status   int         // Success status; 200 if zero
+	Error    interface{} // JSON body of error responses; errorBody if nil
+	// For responses that aren't JSON, e.g. text/event-stream
+	ContentType string
+}
+
+// openAPIParam is a query parameter.
+type openAPIParam struct {
+	Name, Description string
+}
+
+// errorBody is the body of every error response.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+var auditFilterParams = []openAPIParam{
+	{"service", "Only entries for this service"},
+	{"action", "Only entries with this action (see /admin/api/v1/audit/actions)"},
+	{"actor", "Only entries by this actor"},
+	{"from", "Only entries at or after this time (RFC 3339)"},
+	{"to", "Only entries before this time (RFC 3339)"},
+}
+
+// statusBody is the {"status": ...} body several actions return.
+type statusBody struct {
+	Status string `json:"status"`
+}
+
+var agentOperations = []openAPIOperation{
+	{Method: "POST", Path: "/api/v1/elevate", Tag: "elevation", Summary: "Request elevated access to a service, optionally for a requested TTL",
+		Request: ElevationRequest{}, Response: ElevationResponse{}},
+	{Method: "GET", Path: "/api/v1/elevate/{id}", Tag: "elevation", Summary: "Poll an elevation request",
+		Query:    []openAPIParam{{"wait", "Block until the request is decided or this long passes (e.g. 60s; at most 60s)"}},
+		Response: ElevationResponse{}},
+	{Method: "GET", Path: "/api/v1/elevate/{id}/events", Tag: "elevation",
+		Summary:     "Stream an elevation's status changes until it is denied, expires or is revoked (server-sent events)",
+		ContentType: "text/event-stream"},
+	{Method: "GET", Path: "/api/v1/credentials/{service}/{scope}", Tag: "credentials",
+		Summary: "Get a credential (read, or write while elevated); the purpose may also be sent as X-OCM-Purpose and a checkout as X-OCM-Checkout",
+		Query: []openAPIParam{
+			{"purpose", "Why the credential is needed, recorded in the audit log"},
+			{"lease", "true to lease the credential; renew the lease before leaseExpiresAt"},
+			{"checkout", "The checkout holding the credential, for write access to credentials with exclusive checkout"},
+		},
+		Response: CredentialResponse{}},
+	{Method: "POST", Path: "/api/v1/credentials:batch", Tag: "credentials",
+		Summary: "Get several credentials at once, with a status and error per item (at most 50)",
+		Query: []openAPIParam{
+			{"purpose", "Why the credentials are needed, recorded in the audit log"},
+			{"lease", "true to lease the credentials"},
+		},
+		Request:  BatchCredentialsRequest{},
+		Response: BatchCredentialsResponse{}},
+	{Method: "GET", Path: "/api/v1/leases/{id}", Tag: "leases", Summary: "Check a credential lease",
+		Response: LeaseResponse{}},
+	{Method: "POST", Path: "/api/v1/leases/{id}/renew", Tag: "leases",
+		Summary:  "Renew a live lease; 410 once it has expired or been revoked",
+		Response: LeaseResponse{}},
+	{Method: "DELETE", Path: "/api/v1/leases/{id}", Tag: "leases", Summary: "Release a lease that is no longer needed",
+		Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/v1/credentials/{service}/checkout", Tag: "checkouts",
+		Summary: "Check out a credential's write access exclusively; 423 while it is held, unless the checkout's ID is presented",
+		Request: CheckoutRequest{}, Response: store.Checkout{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/api/v1/checkouts/{id}", Tag: "checkouts", Summary: "Check a credential back in",
+		Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/v1/scopes", Tag: "credentials", Summary: "List services and their scopes",
+		Response: ScopesResponse{}},
+	{Method: "POST", Path: "/api/v2/elevate", Tag: "elevation",
+		Summary: "Request elevated access to a service; the response says how often to poll",
+		Request: ElevationRequest{}, Response: ElevationV2{}, Error: ErrorV2{}},
+	{Method: "GET", Path: "/api/v2/elevate/{id}", Tag: "elevation",
+		Summary:  "Poll an elevation request, with a poll interval while undecided and whether its status is final",
+		Query:    []openAPIParam{{"wait", "Block until the request is decided or this long passes (e.g. 60s; at most 60s)"}},
+		Response: ElevationV2{}, Error: ErrorV2{}},
+	{Method: "GET", Path: "/api/v2/credentials/{service}/{scope}", Tag: "credentials",
+		Summary: "Get a credential; errors carry a code and, when waiting helps, retryAfter",
+		Query: []openAPIParam{
+			{"purpose", "Why the credential is needed, recorded in the audit log"},
+			{"lease", "true to lease the credential; renew the lease before leaseExpiresAt"},
+		},
+		Response: CredentialResponse{}, Error: ErrorV2{}},
+	{Method: "GET", Path: "/api/v1/openapi.json", Tag: "meta", Summary: "This document",
+		Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/health", Tag: "meta", Summary: "Liveness check", ContentType: "text/plain"},
+	{Method: "GET", Path: "/healthz", Tag: "meta", Summary: "Liveness probe", Response: ProbeResponse{}},
+	{Method: "GET", Path: "/readyz", Tag: "meta", Summary: "Readiness probe: the database answers and decrypts; 503 if not",
+		Response: ProbeResponse{}, Error: ProbeResponse{}},
+}
+
+var adminOperations = []openAPIOperation{
+	// Setup and overview
+	{Method: "GET", Path: "/admin/api/v1/setup/status", Tag: "setup", Summary: "Bootstrap progress",
+		Response: SetupStatusResponse{}},
+	{Method: "POST", Path: "/admin/api/v1/setup/complete", Tag: "setup", Summary: "Finish setup, completing any remaining steps, and restart the Gateway",
+		Response: struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}{}},
+	{Method: "POST", Path: "/admin/api/v1/setup/steps/{step}/complete", Tag: "setup",
+		Summary:  "Mark a wizard step completed (model_provider needs a model provider credential)",
+		Response: SetupStatusResponse{}},
+	{Method: "POST", Path: "/admin/api/v1/setup/steps/{step}/skip", Tag: "setup", Summary: "Skip a skippable wizard step",
+		Response: SetupStatusResponse{}},
+	{Method: "POST", Path: "/admin/api/v1/setup/reset", Tag: "setup", Summary: "Start the wizard over; credentials are kept",
+		Response: SetupStatusResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/catalog", Tag: "setup", Summary: "Services OCM knows how to set up, with their fields and defaults",
+		Response: CatalogResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/catalog/{id}", Tag: "setup", Summary: "One service from the catalog",
+		Response: catalog.Provider{}},
+	{Method: "GET", Path: "/admin/api/v1/dashboard", Tag: "overview", Summary: "Dashboard summary",
+		Response: DashboardResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/dashboard/timeseries", Tag: "overview",
+		Summary: "Accesses and elevation decisions per hour or day, for charts",
+		Query: []openAPIParam{
+			{"window", "24h (default, hourly), 7d or 30d (daily)"},
+			{"service", "Only this service"},
+			{"tz", "IANA time zone days start in"},
+		},
+		Response: TimeSeries{}},
+	{Method: "GET", Path: "/admin/api/v1/events", Tag: "overview", Summary: "Live event stream (server-sent events)",
+		ContentType: "text/event-stream"},
+	{Method: "GET", Path: "/admin/api/v1/status", Tag: "overview", Summary: "Gateway, elevation and database status",
+		Response: StatusResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/system/status", Tag: "overview",
+		Summary:  "Store, gateway, env file and notifier status, with the version and commit",
+		Response: SystemStatusResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/log-level", Tag: "overview", Summary: "Current log level",
+		Response: LogLevel{}},
+	{Method: "PUT", Path: "/admin/api/v1/log-level", Tag: "overview",
+		Summary: "Change the log level (debug, info, warn or error) until restart; SIGUSR1 toggles debug",
+		Request: LogLevel{}, Response: LogLevel{}},
+	{Method: "GET", Path: "/admin/api/v1/openapi.json", Tag: "meta", Summary: "This document",
+		Response: map[string]interface{}{}},
+
+	// Credentials
+	{Method: "GET", Path: "/admin/api/v1/credentials", Tag: "credentials", Summary: "List credentials (without tokens)",
+		Response: []*store.Credential{}},
+	{Method: "POST", Path: "/admin/api/v1/credentials", Tag: "credentials",
+		Summary: "Create a credential; if the Gateway couldn't be restarted, the credential is wrapped with a warning",
+		Request: CreateCredentialRequest{}, Response: store.Credential{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/admin/api/v1/credentials/bulk", Tag: "credentials",
+		Summary: "Create several credentials with one Gateway restart; each item succeeds or fails on its own",
+		Request: []CreateCredentialRequest{}, Response: BulkCredentialResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/credentials/{service}", Tag: "credentials", Summary: "Get a credential",
+		Response: store.Credential{}},
+	{Method: "PUT", Path: "/admin/api/v1/credentials/{service}", Tag: "credentials",
+		Summary: "Update a credential; if the Gateway couldn't be restarted, the credential is wrapped with a warning",
+		Request: CreateCredentialRequest{}, Response: store.Credential{}},
+	{Method: "DELETE", Path: "/admin/api/v1/credentials/{service}", Tag: "credentials", Summary: "Delete a credential",
+		Status: http.StatusNoContent},
+	{Method: "POST", Path: "/admin/api/v1/credentials/{service}/preview-injection", Tag: "credentials",
+		Summary:  "Preview what injecting a credential would change, with secrets masked",
+		Query:    []openAPIParam{{"level", "read (default) or readWrite"}},
+		Response: InjectionPreviewResponse{}},
+	{Method: "POST", Path: "/admin/api/v1/credentials/{service}/rotate", Tag: "credentials",
+		Summary:  "Mint new secrets through the credential's rotation hook, store and re-inject them, and archive the old ones",
+		Response: elevation.RotationResult{}},
+	{Method: "POST", Path: "/admin/api/v1/credentials/{service}/check", Tag: "credentials",
+		Summary:  "Check the credential's token against its provider and record the result",
+		Response: store.CredentialHealth{}},
+	{Method: "GET", Path: "/admin/api/v1/credentials/{service}/versions", Tag: "credentials",
+		Summary: "List archived versions, newest first, without secrets", Response: []CredentialVersionResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/credentials/{service}/presets", Tag: "credentials", Summary: "List elevation TTL presets",
+		Response: []PresetResponse{}},
+	{Method: "POST", Path: "/admin/api/v1/credentials/{service}/presets", Tag: "credentials", Summary: "Create or replace a preset",
+		Request: PresetRequest{}, Response: PresetResponse{}},
+	{Method: "DELETE", Path: "/admin/api/v1/credentials/{service}/presets/{name}", Tag: "credentials", Summary: "Delete a preset",
+		Status: http.StatusNoContent},
+
+	// Elevations
+	{Method: "GET", Path: "/admin/api/v1/requests", Tag: "elevations", Summary: "List pending elevation requests",
+		Response: []*store.Elevation{}},
+	{Method: "GET", Path: "/admin/api/v1/requests/queued/{service}", Tag: "elevations",
+		Summary: "List requests queued behind a service's concurrency limit", Response: []*store.Elevation{}},
+	{Method: "GET", Path: "/admin/api/v1/elevations/active", Tag: "elevations",
+		Summary: "List approved, unexpired elevations, soonest to expire first", Response: []ActiveElevation{}},
+	{Method: "POST", Path: "/admin/api/v1/requests/{id}/approve", Tag: "elevations", Summary: "Approve a request",
+		Request: ApproveRequest{}, Response: struct {
+			Status    string     `json:"status"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+			TTL       string     `json:"ttl"`
+		}{}},
+	{Method: "POST", Path: "/admin/api/v1/requests/{id}/deny", Tag: "elevations", Summary: "Deny a request",
+		Request: DenyRequest{}, Response: statusBody{}},
+	{Method: "POST", Path: "/admin/api/v1/requests/{id}/guest-invites", Tag: "elevations",
+		Summary: "Create a guest approver link", Request: CreateGuestInviteRequest{}, Response: GuestInviteResponse{},
+		Status: http.StatusCreated},
+	{Method: "GET", Path: "/admin/api/v1/requests/{id}/receipt", Tag: "elevations", Summary: "Signed approval receipt",
+		Query:    []openAPIParam{{"download", "Set to send it as a file attachment"}},
+		Response: ReceiptResponse{}},
+	{Method: "GET", Path: "/admin/api/v1/receipts/key", Tag: "elevations", Summary: "Public key receipts are signed with",
+		Response: struct {
+			Algorithm string `json:"algorithm"`
+			PublicKey string `json:"publicKey"` // Base64
+			KeyID     string `json:"keyId"`
+		}{}},
+	{Method: "POST", Path: "/admin/api/v1/revoke/{service}/{scope}", Tag: "elevations", Summary: "Revoke an active elevation",
+		Response: statusBody{}},
+	{Method: "GET", Path: "/admin/api/v1/leases", Tag: "elevations", Summary: "Live credential leases",
+		Response: []LeaseResponse{}},
+	{Method: "DELETE", Path: "/admin/api/v1/leases/{id}", Tag: "elevations", Summary: "Revoke a credential lease",
+		Status: http.StatusNoContent},
+	{Method: "GET", Path: "/admin/api/v1/checkouts", Tag: "elevations", Summary: "Active credential checkouts",
+		Response: []*store.Checkout{}},
+	{Method: "DELETE", Path: "/admin/api/v1/checkouts/{id}", Tag: "elevations", Summary: "Check a credential in on its holder's behalf",
+		Status: http.StatusNoContent},
+
+	// On-call routing
+	{Method: "GET", Path: "/admin/api/v1/routing", Tag: "routing", Summary: "Approval routing policy",
+		Response: elevation.RoutingPolicy{}},
+	{Method: "PUT", Path: "/admin/api/v1/routing", Tag: "routing", Summary: "Replace the approval routing policy",
+		Request: elevation.RoutingPolicy{}, Response: elevation.RoutingPolicy{}},
+	{Method: "GET", Path: "/admin/api/v1/routing/oncall", Tag: "routing", Summary: "Who is on call now",
+		Response: OnCallResponse{}},
+
+	// Notifications
+	{Method: "GET", Path: "/admin/api/v1/notifications/email", Tag: "notifications", Summary: "Email settings",
+		Response: notify.EmailSettings{}},
+	{Method: "PUT", Path: "/admin/api/v1/notifications/email", Tag: "notifications", Summary: "Replace email settings",
+		Request: notify.EmailSettings{}, Response: notify.EmailSettings{}},
+	{Method: "GET", Path: "/admin/api/v1/notifications/routing", Tag: "notifications", Summary: "Notification routes",
+		Response: struct {
+			Routes    []notify.Route `json:"routes"`
+			Notifiers []string       `json:"notifiers"`
+		}{}},
+	{Method: "PUT", Path: "/admin/api/v1/notifications/routing", Tag: "notifications", Summary: "Replace notification routes",
+		Request: notify.RoutingConfig{}, Response: notify.RoutingConfig{}},
+	{Method: "GET", Path: "/admin/api/v1/notifications/anomalies", Tag: "notifications", Summary: "Anomaly alert rules",
+		Response: notify.AnomalyConfig{}},
+	{Method: "PUT", Path: "/admin/api/v1/notifications/anomalies", Tag: "notifications", Summary: "Replace anomaly alert rules",
+		Request: notify.AnomalyConfig{}, Response: notify.AnomalyConfig{}},
+	{Method: "POST", Path: "/admin/api/v1/notifications/test", Tag: "notifications", Summary: "Send a test notification",
+		Request: TestNotificationRequest{}, Response: []notify.TestResult{}},
+
+	// Outbound webhooks
+	{Method: "GET", Path: "/admin/api/v1/webhooks", Tag: "webhooks", Summary: "List webhooks", Response: []*store.Webhook{}},
+	{Method: "POST", Path: "/admin/api/v1/webhooks", Tag: "webhooks", Summary: "Create a webhook; the secret is only returned here",
+		Request: WebhookRequest{}, Response: webhookResponse{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/admin/api/v1/webhooks/{id}", Tag: "webhooks", Summary: "Update a webhook",
+		Request: WebhookRequest{}, Response: store.Webhook{}},
+	{Method: "DELETE", Path: "/admin/api/v1/webhooks/{id}", Tag: "webhooks", Summary: "Delete a webhook",
+		Status: http.StatusNoContent},
+	{Method: "GET", Path: "/admin/api/v1/webhooks/{id}/deliveries", Tag: "webhooks", Summary: "Recent delivery attempts",
+		Response: []*store.WebhookDelivery{}},
+
+	// Reports, audit and stats
+	{Method: "GET", Path: "/admin/api/v1/reports/digest", Tag: "audit", Summary: "Activity digest for the period ending now",
+		Query: []openAPIParam{{"period", "daily (default) or weekly"}}, Response: notify.DigestReport{}},
+	{Method: "GET", Path: "/admin/api/v1/reports/stale", Tag: "audit", Summary: "Credentials not accessed or elevated in N days, candidates for removal",
+		Query: []openAPIParam{{"days", "Days without access (default 90)"}}, Response: notify.StaleReport{}},
+	{Method: "GET", Path: "/admin/api/v1/audit", Tag: "audit",
+		Summary: "Audit entries, newest first; the X-Next-Cursor header holds the cursor for the next page",
+		Query: append(append([]openAPIParam{}, auditFilterParams...),
+			openAPIParam{"cursor", "X-Next-Cursor from the previous page"},
+			openAPIParam{"limit", "Page size"}),
+		Response: []*store.AuditEntry{}},
+	{Method: "GET", Path: "/admin/api/v1/audit/export", Tag: "audit",
+		Summary:     "Every matching audit entry as JSON lines or CSV",
+		Query:       append(append([]openAPIParam{}, auditFilterParams...), openAPIParam{"format", "jsonl (default) or csv"}),
+		ContentType: "application/x-ndjson"},
+	{Method: "GET", Path: "/admin/api/v1/audit/actions", Tag: "audit", Summary: "Every audit action",
+		Response: []store.AuditAction{}},
+	{Method: "GET", Path: "/admin/api/v1/audit/devices", Tag: "audit", Summary: "Audit devices",
+		Response: []audit.DeviceConfig{}},
+	{Method: "PUT", Path: "/admin/api/v1/audit/devices/{name}", Tag: "audit", Summary: "Add or replace an audit device",
+		Request: audit.DeviceConfig{}, Response: audit.DeviceConfig{}},
+	{Method: "DELETE", Path: "/admin/api/v1/audit/devices/{name}", Tag: "audit", Summary: "Remove an audit device",
+		Status: http.StatusNoContent},
+	{Method: "GET", Path: "/admin/api/v1/stats/cache", Tag: "stats", Summary: "Read cache statistics",
+		Response: store.CacheStats{}},
+	{Method: "GET", Path: "/admin/api/v1/stats/access", Tag: "stats", Summary: "Credential access statistics",
+		Query: append(append([]openAPIParam{}, auditFilterParams...),
+			openAPIParam{"tz", "IANA time zone for the hour buckets"}),
+		Response: AccessStats{}},
+
+	// Gateways and devices
+	{Method: "GET", Path: "/admin/api/v1/gateways", Tag: "gateways", Summary: "Configured gateways and their state",
+		Response: []GatewayInfo{}},
+	{Method: "DELETE", Path: "/admin/api/v1/gateways/{name}/failed", Tag: "gateways",
+		Summary: "Discard a gateway's queued operations that were given up on", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/admin/api/v1/gateway/injected", Tag: "gateways", Summary: "What each gateway has injected",
+		Query:    []openAPIParam{{"gateway", "Only this gateway"}},
+		Response: []GatewayInjectedInfo{}},
+	{Method: "GET", Path: "/admin/api/v1/gateway/stats", Tag: "gateways",
+		Summary:  "Restarts, config patches and connections per gateway since OCM started, by result",
+		Response: []GatewayStats{}},
+	{Method: "POST", Path: "/admin/api/v1/gateway/leaks/scan", Tag: "gateways",
+		Summary:  "Scan gateway configs, and with logs=true recent logs, for stored secrets outside their injection points",
+		Query:    []openAPIParam{{"logs", "Also scan each gateway's recent log lines (true/false)"}},
+		Response: elevation.LeakReport{}},
+	{Method: "GET", Path: "/admin/api/v1/devices", Tag: "gateways",
+		Summary: "Devices pending pairing and paired; error is set if the Gateway couldn't be asked",
+		Response: struct {
+			Pending []gateway.PendingDevice `json:"pending"`
+			Paired  []gateway.PairedDevice  `json:"paired"`
+			Error   string                  `json:"error,omitempty"`
+		}{}},
+	{Method: "POST", Path: "/admin/api/v1/devices/{requestId}/approve", Tag: "gateways", Summary: "Approve a device pairing",
+		Response: struct {
+			Status    string `json:"status"`
+			RequestID string `json:"requestId"`
+		}{}},
+	{Method: "POST", Path: "/admin/api/v1/devices/{requestId}/reject", Tag: "gateways", Summary: "Reject a device pairing",
+		Response: struct {
+			Status    string `json:"status"`
+			RequestID string `json:"requestId"`
+		}{}},
+	{Method: "GET", Path: "/admin/api/v1/channels/status", Tag: "gateways", Summary: "OpenClaw channel configuration",
+		Response: ChannelStatusResponse{}},
+
+	// Guest approver links
+	{Method: "GET", Path: "/guest/api/invites/{token}/", Tag: "guest", Summary: "The request a guest link is for",
+		Response: GuestRequestView{}},
+	{Method: "POST", Path: "/guest/api/invites/{token}/approve", Tag: "guest", Summary: "Approve as a guest",
+		Request: GuestDecisionRequest{}, Response: struct {
+			Status    string     `json:"status"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		}{}},
+	{Method: "POST", Path: "/guest/api/invites/{token}/deny", Tag: "guest", Summary: "Deny as a guest",
+		Request: GuestDecisionRequest{}, Response: statusBody{}},
+
+	{Method: "GET", Path: "/health", Tag: "meta", Summary: "Liveness check; the body names degraded gateways",
+		ContentType: "text/plain"},
+	{Method: "GET", Path: "/healthz", Tag: "meta", Summary: "Liveness probe", Response: ProbeResponse{}},
+	{Method: "GET", Path: "/readyz", Tag: "meta", Summary: "Readiness probe: the database answers and decrypts and the gateway client is initialized; 503 if not",
+		Query:    []openAPIParam{{"gateway", "required to also fail while a gateway isn't connected"}},
+		Response: ProbeResponse{}, Error: ProbeResponse{}},
+	{Method: "GET", Path: "/metrics", Tag: "meta", Summary: "Prometheus metrics", ContentType: "text/plain"},
+}
+
+var (
+	agentOpenAPIOnce, adminOpenAPIOnce sync.Once
+	agentOpenAPI, adminOpenAPI         []byte
+)
+
+// serveOpenAPI returns a handler serving the document for ops, built once.
+func serveOpenAPI(title string, ops []openAPIOperation, once *sync.Once, doc *[]byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			*doc, _ = json.MarshalIndent(buildOpenAPI(title, ops), "", "  ")
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(*doc)
+	}
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// buildOpenAPI builds an OpenAPI 3 document for ops.
+func buildOpenAPI(title string, ops []openAPIOperation) map[string]interface{} {
+	g := &schemaGenerator{components: map[string]interface{}{}, names: map[reflect.Type]string{}}
+	defaultErrorRef := g.schema(reflect.TypeOf(errorBody{}))
+
+	paths := map[string]interface{}{}
+	for _, op := range ops {
+		var params []interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			params = append(params, map[string]interface{}{
+				"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		switch {
+		case op.ContentType != "":
+			success["content"] = map[string]interface{}{op.ContentType: map[string]interface{}{}}
+		case op.Response != nil:
+			success["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response))},
+			}
+		}
+		errorRef := defaultErrorRef
+		if op.Error != nil {
+			errorRef = g.schema(reflect.TypeOf(op.Error))
+		}
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+			"tags":        []string{op.Tag},
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): success,
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+				},
+			},
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Request))},
+				},
+			}
+		}
+
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": title, "version": "1"},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": g.components,
+		},
+	}
+}
+
+// operationID derives a stable ID from the method and path, e.g.
+// getAdminApiCredentialsService.
+func operationID(op openAPIOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+// schemaGenerator derives JSON schemas from Go types the way encoding/json
+// encodes them. Named structs become components referenced by $ref.
+type schemaGenerator struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawJSONType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	case rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.componentName(t)
+			g.names[t] = name
+			g.components[name] = map[string]interface{}{} // Placeholder for recursive types
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	// Interfaces: anything
+	return map[string]interface{}{}
+}
+
+// componentName is the type's name, qualified by its package if another
+// type already has it.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := g.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	g.addFields(t, props, &required)
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+// addFields adds t's JSON fields to props, flattening embedded structs as
+// encoding/json does.
+func (g *schemaGenerator) addFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(ft, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.Func, reflect.Chan:
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
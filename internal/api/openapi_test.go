@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	tests := []struct {
+		name   string
+		router http.Handler
+		ops    []openAPIOperation
+	}{
+		{"agent", NewAgentRouter(db, nil, logger), agentOperations},
+		{"admin", NewAdminRouter(db, nil, nil, nil, nil, logger), adminOperations},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routed := map[string]bool{}
+			err := chi.Walk(tt.router.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+				route = strings.ReplaceAll(route, "/*/", "/")
+				switch {
+				case route == "/*": // The web UI
+				case strings.HasPrefix(route, "/admin/api/") && !strings.HasPrefix(route, adminAPIPrefix+"/"):
+					// Deprecated aliases are documented by their successors
+					routed[method+" "+legacyAdminAPI.Successor(route)] = true
+				default:
+					routed[method+" "+route] = true
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			documented := map[string]bool{}
+			for _, op := range tt.ops {
+				documented[op.Method+" "+op.Path] = true
+			}
+			var missing, extra []string
+			for route := range routed {
+				if !documented[route] {
+					missing = append(missing, route)
+				}
+			}
+			for op := range documented {
+				if !routed[op] {
+					extra = append(extra, op)
+				}
+			}
+			sort.Strings(missing)
+			sort.Strings(extra)
+			if len(missing) > 0 {
+				t.Errorf("routes missing from the OpenAPI document: %v", missing)
+			}
+			if len(extra) > 0 {
+				t.Errorf("documented operations with no route: %v", extra)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	router := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	req := httptest.NewRequest("GET", "/admin/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/admin/api/v1/credentials/{service}"]["put"]; !ok {
+		t.Error("missing PUT /admin/api/v1/credentials/{service}")
+	}
+
+	var cred struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(doc.Components.Schemas["Credential"], &cred); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cred.Properties["service"]; !ok {
+		t.Errorf("Credential schema has no service property: %s", doc.Components.Schemas["Credential"])
+	}
+
+	// Every $ref resolves
+	for _, ref := range strings.Split(w.Body.String(), `"$ref": "#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("unresolved $ref %q", name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/openclaw/ocm/internal/sentry"
+)
+
+// reportPanics reports a panicking handler to the error tracker, then
+// panics again for middleware.Recoverer, which sits outside it, to answer
+// 500. Only the route pattern is reported, never the path or query.
+func reportPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec != http.ErrAbortHandler {
+				route := ""
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = rctx.RoutePattern()
+				}
+				sentry.CapturePanic(r.Context(), rec,
+					"method", r.Method, "route", route, "request_id", middleware.GetReqID(r.Context()))
+			}
+			panic(rec)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// PresetRequest is the request body for creating or updating an elevation preset.
+type PresetRequest struct {
+	Name string `json:"name"` // e.g., "quick fix", "deploy window"
+	TTL  string `json:"ttl"`  // e.g., "10m", "1h"
+}
+
+// PresetResponse is the API representation of an elevation preset.
+type PresetResponse struct {
+	Name string `json:"name"`
+	TTL  string `json:"ttl"`
+}
+
+func (h *adminHandler) listPresets(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+
+	presets, err := h.store.ListPresets(service)
+	if err != nil {
+		h.logger.Error("list presets failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]PresetResponse, 0, len(presets))
+	for _, p := range presets {
+		resp = append(resp, PresetResponse{Name: p.Name, TTL: p.TTL.String()})
+	}
+	h.jsonResponse(w, resp)
+}
+
+func (h *adminHandler) savePreset(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+
+	var req PresetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.jsonError(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil || ttl <= 0 {
+		h.jsonError(w, "invalid ttl format", http.StatusBadRequest)
+		return
+	}
+
+	cred, err := h.store.GetCredential(service)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cred == nil {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+	if cred.ReadWrite == nil {
+		h.jsonError(w, "service has no write access configured", http.StatusBadRequest)
+		return
+	}
+	if cred.ReadWrite.MaxTTL > 0 && ttl > cred.ReadWrite.MaxTTL {
+		h.jsonError(w, fmt.Sprintf("ttl exceeds maxTTL (%s) for this service", cred.ReadWrite.MaxTTL), http.StatusBadRequest)
+		return
+	}
+
+	preset := &store.ElevationPreset{
+		Service:   service,
+		Name:      req.Name,
+		TTL:       ttl,
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.SavePreset(preset); err != nil {
+		h.logger.Error("save preset failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionPresetSaved,
+		Service:   service,
+		Details:   fmt.Sprintf("preset: %s, TTL: %s", req.Name, ttl),
+		Actor:     "admin",
+	}))
+
+	h.jsonResponse(w, PresetResponse{Name: preset.Name, TTL: preset.TTL.String()})
+}
+
+func (h *adminHandler) deletePreset(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	name := chi.URLParam(r, "name")
+
+	deleted, err := h.store.DeletePreset(service, name)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionPresetDeleted,
+		Service:   service,
+		Details:   fmt.Sprintf("preset: %s", name),
+		Actor:     "admin",
+	}))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// InjectionPreviewResponse is the response for previewing a credential's injection.
+type InjectionPreviewResponse struct {
+	Service string `json:"service"`
+	Level   string `json:"level"` // "read" or "readWrite"
+	Gateway string `json:"gateway"`
+	*gateway.InjectionPreview
+}
+
+// previewInjection returns the .env changes and config.patch body injecting
+// a credential would apply, without writing anything. ?level=readWrite
+// previews what approving an elevation would inject. Secret values are
+// masked.
+func (h *adminHandler) previewInjection(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	cred, err := h.store.GetCredential(service)
+	if err != nil {
+		h.logger.Error("get credential failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cred == nil {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	levelName := r.URL.Query().Get("level")
+	var level *store.AccessLevel
+	switch levelName {
+	case "", "read":
+		levelName, level = "read", cred.Read
+	case "readWrite":
+		level = cred.ReadWrite
+	default:
+		h.jsonError(w, "level must be read or readWrite", http.StatusBadRequest)
+		return
+	}
+	if level == nil {
+		h.jsonError(w, levelName+" access is not configured", http.StatusBadRequest)
+		return
+	}
+
+	gw := h.credentialGateway(cred)
+	if gw == nil {
+		h.jsonError(w, "gateway injection is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	env, config := gateway.LevelCredentials(level)
+	preview, err := gw.PreviewInjection(env, config)
+	if err != nil {
+		h.logger.Error("preview injection failed", "service", service, "error", err)
+		h.jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// Arrays in the patch are copied from the current config, so they can
+	// hold any credential's values, not just this level's
+	secrets, err := h.storedSecrets()
+	if err != nil {
+		h.logger.Error("list credentials failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	for _, e := range env {
+		secrets[e.Value] = true
+	}
+	for _, c := range config {
+		secrets[c.Value] = true
+	}
+	for i := range preview.Env {
+		preview.Env[i].Old = maskSecret(preview.Env[i].Old)
+		preview.Env[i].New = maskSecret(preview.Env[i].New)
+	}
+	if preview.ConfigPatch != nil {
+		preview.ConfigPatch = maskSecrets(preview.ConfigPatch, secrets).(map[string]interface{})
+	}
+
+	gwName := cred.Gateway
+	if gwName == "" {
+		gwName = gateway.DefaultName
+	}
+	h.jsonResponse(w, InjectionPreviewResponse{
+		Service:          service,
+		Level:            levelName,
+		Gateway:          gwName,
+		InjectionPreview: preview,
+	})
+}
+
+// maskSecret hides all but a short prefix of a secret value, enough to tell
+// tokens apart (e.g. "xoxp-********").
+func maskSecret(v string) string {
+	if v == "" {
+		return ""
+	}
+	if len(v) < 12 {
+		return strings.Repeat("*", 8)
+	}
+	return v[:5] + strings.Repeat("*", 8)
+}
+
+// storedSecrets returns every secret value OCM stores: each access level's
+// token, refresh token and additional field values.
+func (h *adminHandler) storedSecrets() (map[string]bool, error) {
+	listed, err := h.store.ListCredentials()
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string]bool)
+	for _, l := range listed {
+		// Listed credentials have their secrets cleared
+		cred, err := h.store.GetCredential(l.Service)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			continue
+		}
+		for _, level := range []*store.AccessLevel{cred.Read, cred.ReadWrite} {
+			if level == nil {
+				continue
+			}
+			for _, v := range []string{level.Token, level.RefreshToken} {
+				if v != "" {
+					secrets[v] = true
+				}
+			}
+			for _, f := range level.AdditionalFields {
+				if f.Value != "" {
+					secrets[f.Value] = true
+				}
+			}
+		}
+	}
+	return secrets, nil
+}
+
+// maskSecrets returns v with every string in secrets masked, at any depth,
+// including inside arrays.
+func maskSecrets(v interface{}, secrets map[string]bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = maskSecrets(e, secrets)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = maskSecrets(e, secrets)
+		}
+		return out
+	case string:
+		if secrets[t] {
+			return maskSecret(t)
+		}
+	}
+	return v
+}
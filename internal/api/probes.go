@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Probe component statuses. A component that is "warn" doesn't make OCM
+// unready; one that is "fail" does.
+const (
+	probeOK       = "ok"
+	probeDisabled = "disabled"
+	probeWarn     = "warn"
+	probeFail     = "fail"
+)
+
+// ProbeResponse is the body of /healthz and /readyz.
+type ProbeResponse struct {
+	Status     string           `json:"status"` // ok, or unavailable with a 503
+	Components []ComponentProbe `json:"components,omitempty"`
+}
+
+// ComponentProbe is one readiness check.
+type ComponentProbe struct {
+	Name   string `json:"name"`   // database, gateway or gateway:<name>
+	Status string `json:"status"` // ok, disabled, warn or fail
+	Detail string `json:"detail,omitempty"`
+}
+
+// healthz is the liveness probe: it answers as long as the process can
+// serve HTTP, and checks nothing else, so an orchestrator only restarts OCM
+// when restarting could help.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, ProbeResponse{Status: probeOK})
+}
+
+// readyz returns the readiness probe. The database must answer and the
+// master key must decrypt. With checkGateways (the admin listener), the
+// gateway client must be initialized too, and each gateway's RPC connection
+// is reported. A gateway that isn't connected only fails the probe when the
+// request asks for ?gateway=required, since OCM queues gateway operations
+// while one is away.
+func readyz(db *store.Store, elevSvc *elevation.Service, checkGateways bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var components []ComponentProbe
+		if err := db.Ready(); err != nil {
+			components = append(components, ComponentProbe{Name: "database", Status: probeFail, Detail: err.Error()})
+		} else {
+			components = append(components, ComponentProbe{Name: "database", Status: probeOK})
+		}
+
+		if checkGateways {
+			components = append(components, gatewayProbes(elevSvc, r.URL.Query().Get("gateway") == "required")...)
+		}
+
+		resp := ProbeResponse{Status: probeOK, Components: components}
+		for _, c := range components {
+			if c.Status == probeFail {
+				resp.Status = "unavailable"
+			}
+		}
+		writeProbe(w, resp)
+	}
+}
+
+// gatewayProbes reports whether the default gateway client is initialized
+// and the connection state of every gateway.
+func gatewayProbes(elevSvc *elevation.Service, requireConnected bool) []ComponentProbe {
+	if elevSvc == nil || elevSvc.Gateway() == nil {
+		return []ComponentProbe{{Name: "gateway", Status: probeFail, Detail: "gateway client not initialized"}}
+	}
+	probes := []ComponentProbe{{Name: "gateway", Status: probeOK}}
+
+	gateways := elevSvc.Gateways()
+	names := make([]string, 0, len(gateways))
+	for name, gw := range gateways {
+		if gw != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		gw := gateways[name]
+		p := ComponentProbe{Name: "gateway:" + name, Status: probeOK, Detail: gw.ConnectionState()}
+		switch {
+		case p.Detail == "disabled":
+			p.Status = probeDisabled
+		case p.Detail != "connected" && requireConnected:
+			p.Status = probeFail
+		case p.Detail != "connected" || gw.Degraded():
+			p.Status = probeWarn
+		}
+		if gw.Degraded() {
+			p.Detail += ", degraded"
+		}
+		probes = append(probes, p)
+	}
+	return probes
+}
+
+func writeProbe(w http.ResponseWriter, resp ProbeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != probeOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+)
+
+func TestProbes(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	logger := slog.Default()
+	gw := gateway.NewClient("http://localhost:18789", filepath.Join(t.TempDir(), ".env"), nil, logger)
+	agent := NewAgentRouter(db, nil, logger)
+	admin := NewAdminRouter(db, elevation.NewService(db, gw, logger), nil, nil, nil, logger)
+	uninitialized := NewAdminRouter(db, nil, nil, nil, nil, logger)
+
+	for _, tc := range []struct {
+		name       string
+		router     http.Handler
+		path       string
+		wantStatus int
+		want       map[string]string // Component statuses
+	}{
+		{"agent liveness", agent, "/healthz", http.StatusOK, map[string]string{}},
+		{"agent readiness", agent, "/readyz", http.StatusOK, map[string]string{"database": probeOK}},
+		{"admin liveness", admin, "/healthz", http.StatusOK, map[string]string{}},
+		{"admin readiness", admin, "/readyz", http.StatusOK,
+			map[string]string{"database": probeOK, "gateway": probeOK, "gateway:default": probeDisabled}},
+		// Without RPC, there is no connection to require
+		{"admin readiness requiring gateway", admin, "/readyz?gateway=required", http.StatusOK,
+			map[string]string{"database": probeOK, "gateway": probeOK, "gateway:default": probeDisabled}},
+		{"no gateway client", uninitialized, "/readyz", http.StatusServiceUnavailable,
+			map[string]string{"database": probeOK, "gateway": probeFail}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doJSON(t, tc.router, "GET", tc.path, nil)
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.wantStatus, w.Body.String())
+			}
+			var resp ProbeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, c := range resp.Components {
+				got[c.Name] = c.Status
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("components = %+v, want %v", resp.Components, tc.want)
+			}
+			for name, status := range tc.want {
+				if got[name] != status {
+					t.Errorf("%s = %q, want %q", name, got[name], status)
+				}
+			}
+		})
+	}
+}
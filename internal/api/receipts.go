@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/openclaw/ocm/internal/elevation"
+)
+
+// ReceiptResponse is a signed approval receipt. Payload is the exact bytes
+// that were signed; Receipt is their decoded form, for convenience.
+type ReceiptResponse struct {
+	Receipt   *elevation.ReceiptPayload `json:"receipt"`
+	Payload   string                    `json:"payload"`   // Base64
+	Signature string                    `json:"signature"` // Base64 Ed25519 signature of payload
+	PublicKey string                    `json:"publicKey"` // Base64
+	Algorithm string                    `json:"algorithm"`
+	KeyID     string                    `json:"keyId"`
+}
+
+// getApprovalReceipt returns the signed receipt issued when an elevation was
+// approved. ?download=1 serves it as a file.
+func (h *adminHandler) getApprovalReceipt(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	receipt, err := h.store.GetApprovalReceipt(id)
+	if err != nil {
+		h.logger.Error("get approval receipt failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if receipt == nil {
+		h.jsonError(w, "no receipt for this request", http.StatusNotFound)
+		return
+	}
+
+	payload, err := elevation.VerifyReceipt(receipt, nil)
+	if err != nil {
+		h.logger.Error("stored approval receipt doesn't verify", "elevation_id", id, "error", err)
+		h.jsonError(w, "receipt failed verification", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="receipt-`+id+`.json"`)
+	}
+	h.jsonResponse(w, ReceiptResponse{
+		Receipt:   payload,
+		Payload:   base64.StdEncoding.EncodeToString(receipt.Payload),
+		Signature: base64.StdEncoding.EncodeToString(receipt.Signature),
+		PublicKey: base64.StdEncoding.EncodeToString(receipt.PublicKey),
+		Algorithm: "Ed25519",
+		KeyID:     payload.KeyID,
+	})
+}
+
+// getReceiptKey returns the public key approval receipts are signed with,
+// so they can be verified independently.
+func (h *adminHandler) getReceiptKey(w http.ResponseWriter, r *http.Request) {
+	if h.elevation == nil {
+		h.jsonError(w, "elevation service not available", http.StatusServiceUnavailable)
+		return
+	}
+	pub := h.elevation.ReceiptPublicKey()
+	if pub == nil {
+		h.jsonError(w, "approval receipts are not enabled", http.StatusNotFound)
+		return
+	}
+	h.jsonResponse(w, map[string]string{
+		"algorithm": "Ed25519",
+		"publicKey": base64.StdEncoding.EncodeToString(pub),
+		"keyId":     elevation.ReceiptKeyID(pub),
+	})
+}
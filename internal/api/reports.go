@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openclaw/ocm/internal/notify"
+)
+
+// getDigest returns the activity digest for the period ending now
+// (?period=daily, the default, or weekly).
+func (h *adminHandler) getDigest(w http.ResponseWriter, r *http.Request) {
+	period := notify.DigestPeriod(r.URL.Query().Get("period"))
+	switch period {
+	case "":
+		period = notify.DigestDaily
+	case notify.DigestDaily, notify.DigestWeekly:
+	default:
+		h.jsonError(w, "period must be daily or weekly", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	report, err := notify.BuildDigest(h.store, now.Add(-period.Duration()), now)
+	if err != nil {
+		h.logger.Error("build digest failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.jsonResponse(w, report)
+}
+
+// getStaleReport lists credentials not accessed or elevated in ?days days
+// (default 90), least recently accessed first.
+func (h *adminHandler) getStaleReport(w http.ResponseWriter, r *http.Request) {
+	days := notify.DefaultStaleDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			h.jsonError(w, "days must be a positive number", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	report, err := notify.BuildStaleReport(h.store, time.Now(), days)
+	if err != nil {
+		h.logger.Error("build stale report failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.jsonResponse(w, report)
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// withRequest fills in entry's request ID, source IP and user agent from r
+// and returns entry. The source IP is the client address as resolved by
+// middleware.RealIP, so it honours X-Forwarded-For / X-Real-IP.
+func withRequest(r *http.Request, entry *store.AuditEntry) *store.AuditEntry {
+	entry.RequestID = middleware.GetReqID(r.Context())
+	entry.SourceIP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.SourceIP = host
+	}
+	entry.UserAgent = r.UserAgent()
+	return entry
+}
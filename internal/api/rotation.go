@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/rotate"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// RotationConfig configures how a credential is rotated: a command or a
+// webhook that mints a replacement secret, or a built-in provider rotator.
+type RotationConfig struct {
+	Command  []string `json:"command,omitempty"`  // Argv, run without a shell; needs --allow-rotation-commands
+	URL      string   `json:"url,omitempty"`      // Webhook, instead of command
+	Secret   string   `json:"secret,omitempty"`   // HMAC signing secret for url; kept unchanged on update if empty
+	Interval string   `json:"interval,omitempty"` // e.g., "720h"; empty = on demand only
+
+	// Built-in rotator, instead of command or url: github, aws_iam or
+	// slack. Defaults to the one for the credential's type.
+	Provider     string `json:"provider,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`     // github, slack
+	ClientSecret string `json:"clientSecret,omitempty"` // Kept unchanged on update if empty
+	Endpoint     string `json:"endpoint,omitempty"`     // API base URL override, e.g. for GitHub Enterprise
+}
+
+// CredentialVersionResponse is an archived version of a credential's access
+// level, without its secrets.
+type CredentialVersionResponse struct {
+	ID         string    `json:"id"`
+	Level      string    `json:"level"`
+	Reason     string    `json:"reason,omitempty"`
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// applyRotation validates and copies the rotation config onto cred.
+// Omitted leaves it unchanged and an empty one removes it. Without a
+// command, url or provider, the built-in rotator for the credential's type
+// is used.
+func (req *CreateCredentialRequest) applyRotation(cred *store.Credential) error {
+	c := req.Rotation
+	if c == nil {
+		return nil
+	}
+	if len(c.Command) == 0 && c.URL == "" && c.Provider == "" && c.Interval == "" && c.ClientID == "" && c.Endpoint == "" {
+		cred.Rotation = nil
+		return nil
+	}
+	rotation := &store.Rotation{
+		Command:      c.Command,
+		URL:          c.URL,
+		Secret:       c.Secret,
+		Provider:     c.Provider,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Endpoint:     c.Endpoint,
+	}
+	if len(c.Command) == 0 && c.URL == "" && c.Provider == "" {
+		if rotation.Provider = rotate.ProviderFor(cred.Type); rotation.Provider == "" {
+			return fmt.Errorf("rotation needs a command, a url or a provider (no built-in rotator for type %q)", cred.Type)
+		}
+	}
+	if previous := cred.Rotation; previous != nil {
+		if rotation.Secret == "" && previous.URL == c.URL {
+			rotation.Secret = previous.Secret
+		}
+		if rotation.ClientSecret == "" && previous.Provider == rotation.Provider && previous.ClientID == c.ClientID {
+			rotation.ClientSecret = previous.ClientSecret
+		}
+	}
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid rotation.interval: %w", err)
+		}
+		rotation.Interval = interval
+	}
+	if err := rotate.Validate(rotation); err != nil {
+		return err
+	}
+	cred.Rotation = rotation
+	return nil
+}
+
+// rotateCredential mints, stores and re-injects new secrets through the
+// credential's rotation hook. A partial rotation is a 200 listing the
+// levels that failed; a total failure is a 502.
+func (h *adminHandler) rotateCredential(w http.ResponseWriter, r *http.Request) {
+	if h.elevation == nil {
+		h.jsonError(w, "rotation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	service := chi.URLParam(r, "service")
+
+	result, err := h.elevation.Rotate(r.Context(), service, "admin", "rotated by admin")
+	switch {
+	case errors.Is(err, elevation.ErrCredentialNotFound):
+		h.jsonError(w, "not found", http.StatusNotFound)
+	case errors.Is(err, elevation.ErrNoRotation), errors.Is(err, elevation.ErrRotationInProgress):
+		h.jsonError(w, err.Error(), http.StatusConflict)
+	case err != nil && result != nil:
+		h.jsonError(w, err.Error(), http.StatusBadGateway)
+	case err != nil:
+		h.logger.Error("rotate credential failed", "service", service, "error", err)
+		h.jsonError(w, err.Error(), http.StatusInternalServerError)
+	default:
+		h.jsonResponse(w, result)
+	}
+}
+
+// listCredentialVersions lists a credential's archived versions, newest
+// first.
+func (h *adminHandler) listCredentialVersions(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+
+	versions, err := h.store.ListCredentialVersions(service)
+	if err != nil {
+		h.logger.Error("list credential versions failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]CredentialVersionResponse, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, CredentialVersionResponse{ID: v.ID, Level: v.Level, Reason: v.Reason, ArchivedAt: v.ArchivedAt})
+	}
+	h.jsonResponse(w, resp)
+}
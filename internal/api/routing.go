@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// OnCallResponse lists the approvers currently responsible for new requests.
+type OnCallResponse struct {
+	Approvers []string  `json:"approvers"`
+	At        time.Time `json:"at"`
+}
+
+func (h *adminHandler) getRoutingPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.elevation.RoutingPolicy()
+	if err != nil {
+		h.logger.Error("get routing policy failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	// Ensure empty slices instead of nil
+	if policy.Shifts == nil {
+		policy.Shifts = []elevation.Shift{}
+	}
+	if policy.Delegations == nil {
+		policy.Delegations = []elevation.Delegation{}
+	}
+	if policy.Fallback == nil {
+		policy.Fallback = []string{}
+	}
+	h.jsonResponse(w, policy)
+}
+
+func (h *adminHandler) setRoutingPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy elevation.RoutingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.elevation.SetRoutingPolicy(&policy); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionRoutingUpdated,
+		Actor:     "admin",
+	}))
+
+	h.jsonResponse(w, policy)
+}
+
+func (h *adminHandler) getOnCall(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.elevation.RoutingPolicy()
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	approvers := policy.OnCall(now)
+	if approvers == nil {
+		approvers = []string{}
+	}
+	h.jsonResponse(w, OnCallResponse{Approvers: approvers, At: now})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// skippableSetupSteps are the wizard steps that may be skipped. OpenClaw
+// doesn't work without a model provider, so that one must be completed.
+var skippableSetupSteps = map[store.SetupStep]bool{
+	store.SetupWelcome: true,
+}
+
+// SetupStepStatus is one wizard step's progress.
+type SetupStepStatus struct {
+	Step      store.SetupStep `json:"step"`
+	Status    string          `json:"status"` // pending, completed or skipped
+	At        *time.Time      `json:"at,omitempty"`
+	Skippable bool            `json:"skippable"`
+}
+
+// setupState returns the wizard's progress, recording a fresh state the
+// first time. An install that was set up before progress was recorded
+// (setup_completed in the audit log, or a model provider credential) starts
+// out finished, so upgrading doesn't send it back through the wizard.
+func (h *adminHandler) setupState() (*store.SetupState, error) {
+	st, found, err := h.store.GetSetupState()
+	if err != nil || found {
+		return st, err
+	}
+
+	now := time.Now()
+	st = store.NewSetupState(now)
+	done, _, err := h.store.QueryAuditEntries(store.AuditQuery{Action: store.ActionSetupCompleted, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	hasProvider, err := h.hasModelProvider()
+	if err != nil {
+		return nil, err
+	}
+	if len(done) > 0 || hasProvider {
+		for _, step := range store.SetupSteps {
+			st.Completed[step] = now
+		}
+		st.CompletedAt = &now
+	}
+	return st, h.store.SaveSetupState(st)
+}
+
+// hasModelProvider reports whether a credential for an LLM provider exists.
+func (h *adminHandler) hasModelProvider() (bool, error) {
+	creds, err := h.store.ListCredentials()
+	if err != nil {
+		return false, err
+	}
+	for _, cred := range creds {
+		if isModelProvider(cred.Service) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isModelProvider reports whether service provides LLM API keys.
+func isModelProvider(service string) bool {
+	for _, provider := range requiredModelProviders {
+		if service == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// setupSteps describes every wizard step's progress, in order.
+func setupSteps(st *store.SetupState) []SetupStepStatus {
+	steps := make([]SetupStepStatus, 0, len(store.SetupSteps))
+	for _, step := range store.SetupSteps {
+		s := SetupStepStatus{Step: step, Status: "pending", Skippable: skippableSetupSteps[step]}
+		if at, ok := st.Completed[step]; ok {
+			s.Status, s.At = "completed", &at
+		} else if at, ok := st.Skipped[step]; ok {
+			s.Status, s.At = "skipped", &at
+		}
+		steps = append(steps, s)
+	}
+	return steps
+}
+
+// completeSetupStep marks a wizard step completed. The model provider step
+// needs a model provider credential first.
+func (h *adminHandler) completeSetupStep(w http.ResponseWriter, r *http.Request) {
+	h.advanceSetup(w, r, false)
+}
+
+// skipSetupStep marks a skippable wizard step skipped.
+func (h *adminHandler) skipSetupStep(w http.ResponseWriter, r *http.Request) {
+	h.advanceSetup(w, r, true)
+}
+
+func (h *adminHandler) advanceSetup(w http.ResponseWriter, r *http.Request, skip bool) {
+	step, ok := store.ParseSetupStep(chi.URLParam(r, "step"))
+	if !ok {
+		h.jsonError(w, "unknown setup step", http.StatusNotFound)
+		return
+	}
+	if skip && !skippableSetupSteps[step] {
+		h.jsonError(w, string(step)+" can't be skipped", http.StatusConflict)
+		return
+	}
+	if !skip && step == store.SetupModelProvider {
+		hasProvider, err := h.hasModelProvider()
+		if err != nil {
+			h.logger.Error("setup: list credentials failed", "error", err)
+			h.jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if !hasProvider {
+			h.jsonError(w, "add a model provider credential first", http.StatusConflict)
+			return
+		}
+	}
+
+	st, err := h.setupState()
+	if err != nil {
+		h.logger.Error("setup: load state failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	delete(st.Completed, step)
+	delete(st.Skipped, step)
+	if skip {
+		st.Skipped[step] = now
+	} else {
+		st.Completed[step] = now
+	}
+	if err := h.store.SaveSetupState(st); err != nil {
+		h.logger.Error("setup: save state failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.writeSetupStatus(w, st)
+}
+
+// resetSetup starts the wizard over. Credentials are kept.
+func (h *adminHandler) resetSetup(w http.ResponseWriter, r *http.Request) {
+	st := store.NewSetupState(time.Now())
+	if err := h.store.SaveSetupState(st); err != nil {
+		h.logger.Error("setup: save state failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionSetupReset,
+		Actor:     "admin",
+	}))
+	h.logger.Info("setup wizard reset")
+
+	h.writeSetupStatus(w, st)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// defaultStatsWindow is the period /stats/access covers without ?from.
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// statsActions are the audit actions AccessStats counts.
+var statsActions = map[store.AuditAction]bool{
+	store.ActionCredentialAccess:   true,
+	store.ActionElevationRequested: true,
+	store.ActionElevationQueued:    true,
+	store.ActionElevationApproved:  true,
+	store.ActionElevationDenied:    true,
+	store.ActionElevationRejected:  true,
+}
+
+// AccessStats aggregates the audit log over a period.
+type AccessStats struct {
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	Timezone       string         `json:"timezone"` // Zone of the hour buckets
+	Accesses       int            `json:"accesses"`
+	Services       []ServiceStats `json:"services"` // Busiest first
+	Elevations     ElevationStats `json:"elevations"`
+	AccessesByHour [24]int        `json:"accessesByHour"` // Credential accesses per hour of day
+	BusiestHours   []int          `json:"busiestHours"`   // Hours of day, busiest first (those with any access)
+}
+
+// ServiceStats is one service's share of AccessStats.
+type ServiceStats struct {
+	Service       string `json:"service"`
+	Accesses      int    `json:"accesses"`
+	ReadAccesses  int    `json:"readAccesses"`
+	WriteAccesses int    `json:"writeAccesses"`
+	ElevationStats
+}
+
+// ElevationStats counts elevation decisions. Time to approval (or denial)
+// runs from the request to the decision, for decisions whose elevation is
+// known.
+type ElevationStats struct {
+	Requested              int      `json:"requested"`
+	Approved               int      `json:"approved"`
+	Denied                 int      `json:"denied"`
+	Rejected               int      `json:"rejected"`               // Refused by a concurrency limit
+	ApprovalRate           *float64 `json:"approvalRate,omitempty"` // Approved / (approved + denied)
+	MedianSecondsToApprove *float64 `json:"medianSecondsToApprove,omitempty"`
+	P90SecondsToApprove    *float64 `json:"p90SecondsToApprove,omitempty"`
+	MedianSecondsToDeny    *float64 `json:"medianSecondsToDeny,omitempty"`
+	P90SecondsToDeny       *float64 `json:"p90SecondsToDeny,omitempty"`
+
+	waits     []float64
+	denyWaits []float64
+}
+
+func (e *ElevationStats) count(action store.AuditAction) {
+	switch action {
+	case store.ActionElevationRequested, store.ActionElevationQueued:
+		e.Requested++
+	case store.ActionElevationApproved:
+		e.Approved++
+	case store.ActionElevationDenied:
+		e.Denied++
+	case store.ActionElevationRejected:
+		e.Rejected++
+	}
+}
+
+// finish computes the rates and percentiles from the counts.
+func (e *ElevationStats) finish() {
+	if decided := e.Approved + e.Denied; decided > 0 {
+		rate := float64(e.Approved) / float64(decided)
+		e.ApprovalRate = &rate
+	}
+	if len(e.waits) > 0 {
+		sort.Float64s(e.waits)
+		median := percentile(e.waits, 50)
+		p90 := percentile(e.waits, 90)
+		e.MedianSecondsToApprove, e.P90SecondsToApprove = &median, &p90
+	}
+	if len(e.denyWaits) > 0 {
+		sort.Float64s(e.denyWaits)
+		median := percentile(e.denyWaits, 50)
+		p90 := percentile(e.denyWaits, 90)
+		e.MedianSecondsToDeny, e.P90SecondsToDeny = &median, &p90
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []float64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// getAccessStats aggregates the audit log into per-service access counts,
+// elevation approval rates, time to decision and accesses per hour of day.
+// ?from and ?to (RFC 3339) bound the period, the last 30 days by default;
+// ?service narrows it to one service; ?tz (IANA name) sets the zone of the
+// hour buckets, the server's by default.
+func (h *adminHandler) getAccessStats(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-defaultStatsWindow)
+	}
+	loc := time.Local
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			h.jsonError(w, "unknown tz "+tz, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.store.ListAuditEntriesBetween(q.From, q.To)
+	if err != nil {
+		h.logger.Error("list audit entries failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	stats := &AccessStats{From: q.From, To: q.To, Timezone: loc.String(), Services: []ServiceStats{}, BusiestHours: []int{}}
+	services := make(map[string]*ServiceStats)
+	service := func(name string) *ServiceStats {
+		if s, ok := services[name]; ok {
+			return s
+		}
+		s := &ServiceStats{Service: name}
+		services[name] = s
+		return s
+	}
+	requested := make(map[string]*store.Elevation)
+
+	for _, e := range entries {
+		if e.Service == "" || (q.Service != "" && e.Service != q.Service) || !statsActions[e.Action] {
+			continue
+		}
+		svc := service(e.Service)
+		switch e.Action {
+		case store.ActionCredentialAccess:
+			stats.Accesses++
+			svc.Accesses++
+			if e.Scope == "read" || e.Scope == "r" {
+				svc.ReadAccesses++
+			} else {
+				svc.WriteAccesses++
+			}
+			stats.AccessesByHour[e.Timestamp.In(loc).Hour()]++
+			continue
+		case store.ActionElevationApproved, store.ActionElevationDenied:
+			if e.ElevationID == "" {
+				break
+			}
+			elev, ok := requested[e.ElevationID]
+			if !ok {
+				if elev, err = h.store.GetElevation(e.ElevationID); err != nil {
+					h.logger.Error("get elevation failed", "error", err)
+					h.jsonError(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+				requested[e.ElevationID] = elev
+			}
+			if elev != nil && !e.Timestamp.Before(elev.RequestedAt) {
+				wait := e.Timestamp.Sub(elev.RequestedAt).Seconds()
+				if e.Action == store.ActionElevationApproved {
+					svc.waits = append(svc.waits, wait)
+					stats.Elevations.waits = append(stats.Elevations.waits, wait)
+				} else {
+					svc.denyWaits = append(svc.denyWaits, wait)
+					stats.Elevations.denyWaits = append(stats.Elevations.denyWaits, wait)
+				}
+			}
+		}
+		svc.count(e.Action)
+		stats.Elevations.count(e.Action)
+	}
+
+	for _, s := range services {
+		s.finish()
+		stats.Services = append(stats.Services, *s)
+	}
+	sort.Slice(stats.Services, func(i, j int) bool {
+		if stats.Services[i].Accesses != stats.Services[j].Accesses {
+			return stats.Services[i].Accesses > stats.Services[j].Accesses
+		}
+		return stats.Services[i].Service < stats.Services[j].Service
+	})
+	stats.Elevations.finish()
+
+	for hour, n := range stats.AccessesByHour {
+		if n > 0 {
+			stats.BusiestHours = append(stats.BusiestHours, hour)
+		}
+	}
+	sort.SliceStable(stats.BusiestHours, func(i, j int) bool {
+		return stats.AccessesByHour[stats.BusiestHours[i]] > stats.AccessesByHour[stats.BusiestHours[j]]
+	})
+
+	h.jsonResponse(w, stats)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// StatusResponse is a one-shot summary of OCM's health, for `ocm status`.
+type StatusResponse struct {
+	Healthy          bool              `json:"healthy"`           // Database OK and no gateway degraded
+	Gateway          *GatewayComponent `json:"gateway,omitempty"` // Default gateway's RPC and pairing state
+	Gateways         []GatewayInfo     `json:"gateways"`          // Every configured gateway, default first
+	PendingRequests  int               `json:"pendingRequests"`
+	ActiveElevations []ActiveElevation `json:"activeElevations"` // Soonest to expire first
+	Database         DatabaseStatus    `json:"database"`
+}
+
+// ActiveElevation is an approved, unexpired elevation.
+type ActiveElevation struct {
+	ID               string    `json:"id"`
+	Service          string    `json:"service"`
+	Scope            string    `json:"scope"`
+	ApprovedBy       string    `json:"approvedBy,omitempty"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RemainingSeconds int       `json:"remainingSeconds"`
+}
+
+// DatabaseStatus reports whether the database passes its integrity check.
+type DatabaseStatus struct {
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+	Credentials int    `json:"credentials"`
+}
+
+// getStatus gathers the gateway connectivity and pairing state, pending and
+// active elevations, and database health that the setup status, gateways
+// and dashboard endpoints report separately. Failures are reported in the
+// body rather than as an error status, so the rest of the summary is still
+// shown.
+func (h *adminHandler) getStatus(w http.ResponseWriter, r *http.Request) {
+	resp := StatusResponse{
+		Gateway:          h.defaultGatewayComponent(),
+		Gateways:         []GatewayInfo{},
+		ActiveElevations: []ActiveElevation{},
+	}
+
+	if err := h.store.Check(); err != nil {
+		resp.Database.Error = err.Error()
+	} else if creds, err := h.store.ListCredentials(); err != nil {
+		resp.Database.Error = err.Error()
+	} else {
+		resp.Database.OK = true
+		resp.Database.Credentials = len(creds)
+	}
+
+	if gateways, err := h.gatewayInfos(); err != nil {
+		h.logger.Error("list gateway queue failed", "error", err)
+	} else {
+		resp.Gateways = gateways
+	}
+
+	if pending, err := h.store.ListPendingElevations(); err != nil {
+		h.logger.Error("list pending elevations failed", "error", err)
+	} else {
+		resp.PendingRequests = len(pending)
+	}
+
+	active, err := h.store.ListActiveElevations()
+	if err != nil {
+		h.logger.Error("list active elevations failed", "error", err)
+	}
+	now := time.Now()
+	for _, elev := range active {
+		resp.ActiveElevations = append(resp.ActiveElevations, activeElevation(elev, now))
+	}
+
+	resp.Healthy = resp.Database.OK
+	for _, gw := range resp.Gateways {
+		if gw.Degraded {
+			resp.Healthy = false
+		}
+	}
+	h.jsonResponse(w, resp)
+}
+
+// listActiveElevations lists approved, unexpired elevations, soonest to
+// expire first.
+func (h *adminHandler) listActiveElevations(w http.ResponseWriter, r *http.Request) {
+	active, err := h.store.ListActiveElevations()
+	if err != nil {
+		h.logger.Error("list active elevations failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]ActiveElevation, 0, len(active))
+	now := time.Now()
+	for _, elev := range active {
+		resp = append(resp, activeElevation(elev, now))
+	}
+	h.jsonResponse(w, resp)
+}
+
+func activeElevation(elev *store.Elevation, now time.Time) ActiveElevation {
+	return ActiveElevation{
+		ID:               elev.ID,
+		Service:          elev.Service,
+		Scope:            elev.Scope,
+		ApprovedBy:       elev.ApprovedBy,
+		ExpiresAt:        *elev.ExpiresAt,
+		RemainingSeconds: int(elev.ExpiresAt.Sub(now).Seconds()),
+	}
+}
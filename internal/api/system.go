@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+)
+
+// Build identifies the running binary; serve sets it from the values
+// linked in at build time.
+var (
+	buildVersion = "dev"
+	buildCommit  = "none"
+)
+
+// SetBuild records the version and commit OCM was built from.
+func SetBuild(version, commit string) {
+	buildVersion, buildCommit = version, commit
+}
+
+// SystemStatusResponse is the state of each of OCM's components, for the
+// UI. Component statuses are ok, disabled, warn or fail; Status is the
+// worst of them.
+type SystemStatusResponse struct {
+	Status    string              `json:"status"`
+	Version   string              `json:"version"`
+	Commit    string              `json:"commit"`
+	Store     StoreComponent      `json:"store"`
+	Gateways  []GatewayComponent  `json:"gateways"` // Default first
+	Notifiers []NotifierComponent `json:"notifiers"`
+}
+
+// StoreComponent reports whether the database passes its integrity check
+// and the master key decrypts it.
+type StoreComponent struct {
+	Status      string `json:"status"`
+	Detail      string `json:"detail,omitempty"`
+	Credentials int    `json:"credentials"`
+}
+
+// GatewayComponent is a gateway's RPC connection and whether OCM can write
+// its .env file. Only the default gateway reports its device and pairing
+// command.
+type GatewayComponent struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	Detail         string `json:"detail,omitempty"`
+	State          string `json:"state"` // connected, disconnected, pairing_needed, token_mismatch or disabled
+	Degraded       bool   `json:"degraded"`
+	DeviceID       string `json:"deviceId,omitempty"`
+	ApproveCommand string `json:"approveCommand,omitempty"` // Exact command to approve pairing
+	FixCommand     string `json:"fixCommand,omitempty"`     // Command to fix a token mismatch
+	EnvFile        string `json:"envFile"`
+	EnvWritable    bool   `json:"envWritable"`
+	EnvError       string `json:"envError,omitempty"`
+}
+
+// NotifierComponent is a notifier's delivery health. One whose last
+// delivery failed is warn.
+type NotifierComponent struct {
+	Status string `json:"status"`
+	notify.NotifierHealth
+}
+
+// getSystemStatus reports each component's status. Like getStatus, failures
+// are reported in the body rather than as an error status.
+func (h *adminHandler) getSystemStatus(w http.ResponseWriter, r *http.Request) {
+	resp := SystemStatusResponse{
+		Version:   buildVersion,
+		Commit:    buildCommit,
+		Store:     h.storeComponent(),
+		Gateways:  h.gatewayComponents(),
+		Notifiers: []NotifierComponent{},
+	}
+	for _, health := range h.notifier.Health() {
+		c := NotifierComponent{Status: probeOK, NotifierHealth: health}
+		if health.ConsecutiveFailures > 0 {
+			c.Status = probeWarn
+		}
+		resp.Notifiers = append(resp.Notifiers, c)
+	}
+
+	statuses := []string{resp.Store.Status}
+	for _, gw := range resp.Gateways {
+		statuses = append(statuses, gw.Status)
+	}
+	for _, n := range resp.Notifiers {
+		statuses = append(statuses, n.Status)
+	}
+	resp.Status = worstStatus(statuses)
+	h.jsonResponse(w, resp)
+}
+
+func (h *adminHandler) storeComponent() StoreComponent {
+	if err := h.store.Check(); err != nil {
+		return StoreComponent{Status: probeFail, Detail: err.Error()}
+	}
+	if err := h.store.Ready(); err != nil {
+		return StoreComponent{Status: probeFail, Detail: err.Error()}
+	}
+	creds, err := h.store.ListCredentials()
+	if err != nil {
+		return StoreComponent{Status: probeFail, Detail: err.Error()}
+	}
+	return StoreComponent{Status: probeOK, Credentials: len(creds)}
+}
+
+// gatewayComponents describes each configured gateway, default first.
+func (h *adminHandler) gatewayComponents() []GatewayComponent {
+	components := []GatewayComponent{}
+	if h.elevation == nil {
+		return components
+	}
+	for name, gw := range h.elevation.Gateways() {
+		if gw != nil {
+			components = append(components, h.gatewayComponent(name, gw))
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if (components[i].Name == gateway.DefaultName) != (components[j].Name == gateway.DefaultName) {
+			return components[i].Name == gateway.DefaultName
+		}
+		return components[i].Name < components[j].Name
+	})
+	return components
+}
+
+// defaultGatewayComponent describes the default gateway, or is nil without
+// an RPC client.
+func (h *adminHandler) defaultGatewayComponent() *GatewayComponent {
+	if h.rpc == nil || h.elevation == nil || h.elevation.Gateway() == nil {
+		return nil
+	}
+	c := h.gatewayComponent(gateway.DefaultName, h.elevation.Gateway())
+	return &c
+}
+
+func (h *adminHandler) gatewayComponent(name string, gw *gateway.Client) GatewayComponent {
+	c := GatewayComponent{
+		Name:     name,
+		State:    gw.ConnectionState(),
+		Degraded: gw.Degraded(),
+		EnvFile:  gw.EnvFilePath,
+	}
+	if err := gw.CheckEnvWritable(); err != nil {
+		c.EnvError = err.Error()
+	} else {
+		c.EnvWritable = true
+	}
+
+	// Device pairing covers the default gateway only
+	if name == gateway.DefaultName && h.rpc != nil {
+		c.DeviceID = h.rpc.GetDeviceID()
+		if c.State == "pairing_needed" {
+			if reqID := h.rpc.GetPendingRequestID(); reqID != "" {
+				c.ApproveCommand = fmt.Sprintf("docker exec -it openclaw node /app/dist/index.js devices approve %s", reqID)
+			} else {
+				// Don't know the request ID yet, show list command first
+				c.ApproveCommand = "docker exec -it openclaw node /app/dist/index.js devices list\n# Then: docker exec -it openclaw node /app/dist/index.js devices approve <requestId>"
+			}
+		}
+	}
+	if c.State == "token_mismatch" {
+		c.FixCommand = "./scripts/sync-token.sh"
+	}
+
+	switch {
+	case !c.EnvWritable:
+		c.Status, c.Detail = probeFail, "env file not writable: "+c.EnvError
+	case c.State == "token_mismatch":
+		c.Status, c.Detail = probeFail, "gateway token mismatch"
+	case c.State == "pairing_needed":
+		c.Status, c.Detail = probeWarn, "device pairing needed"
+	case c.Degraded:
+		c.Status, c.Detail = probeWarn, "RPC calls failing fast after repeated failures"
+	case c.State == "disconnected":
+		c.Status, c.Detail = probeWarn, "RPC disconnected; operations are queued"
+	case c.State == "disabled":
+		c.Status, c.Detail = probeDisabled, "RPC not configured"
+	default:
+		c.Status = probeOK
+	}
+	return c
+}
+
+// worstStatus returns fail if any status is fail, else warn if any is warn,
+// else ok.
+func worstStatus(statuses []string) string {
+	worst := probeOK
+	for _, s := range statuses {
+		switch {
+		case s == probeFail:
+			return probeFail
+		case s == probeWarn:
+			worst = probeWarn
+		}
+	}
+	return worst
+}
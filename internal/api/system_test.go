@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/elevation"
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+)
+
+type downNotifier struct{}
+
+func (downNotifier) Name() string { return "down" }
+func (downNotifier) Notify(ctx context.Context, e notify.Event) error {
+	return errors.New("connection refused")
+}
+
+func TestAdminAPI_SystemStatus(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+	logger := slog.Default()
+
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	elevSvc := elevation.NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	elevSvc.AddGateway("staging", gateway.NewClient("", filepath.Join(blocker, ".env"), nil, logger))
+
+	notifier := notify.NewDispatcher(logger)
+	notifier.Register(downNotifier{})
+	notifier.Test(context.Background(), notify.Event{Type: notify.EventCredentialDeleted, Service: "github"}, "")
+
+	SetBuild("1.2.3", "abc123")
+	defer SetBuild("dev", "none")
+	router := NewAdminRouter(db, elevSvc, nil, nil, notifier, logger)
+
+	w := doJSON(t, router, http.MethodGet, "/admin/api/v1/system/status", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var got SystemStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != probeFail || got.Version != "1.2.3" || got.Commit != "abc123" {
+		t.Errorf("status = %q, version = %q, commit = %q", got.Status, got.Version, got.Commit)
+	}
+	if got.Store.Status != probeOK {
+		t.Errorf("store = %+v", got.Store)
+	}
+	if len(got.Gateways) != 2 {
+		t.Fatalf("gateways = %+v", got.Gateways)
+	}
+	if gw := got.Gateways[0]; gw.Name != "default" || gw.Status != probeDisabled || gw.State != "disabled" || !gw.EnvWritable {
+		t.Errorf("default gateway = %+v", gw)
+	}
+	if gw := got.Gateways[1]; gw.Name != "staging" || gw.Status != probeFail || gw.EnvWritable || gw.EnvError == "" {
+		t.Errorf("staging gateway = %+v", gw)
+	}
+	if len(got.Notifiers) != 1 || got.Notifiers[0].Status != probeWarn || got.Notifiers[0].LastError != "connection refused" {
+		t.Errorf("notifiers = %+v", got.Notifiers)
+	}
+
+	// Also served at the deprecated unversioned path
+	if w := doJSON(t, router, http.MethodGet, "/admin/api/system/status", nil); w.Code != http.StatusOK {
+		t.Errorf("unversioned status = %d", w.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// timeSeriesWindow is a period /dashboard/timeseries can chart.
+type timeSeriesWindow struct {
+	buckets int
+	hourly  bool // Hour buckets; day buckets otherwise
+}
+
+var timeSeriesWindows = map[string]timeSeriesWindow{
+	"24h": {buckets: 24, hourly: true},
+	"7d":  {buckets: 7},
+	"30d": {buckets: 30},
+}
+
+// TimeSeries is audit activity counted into consecutive buckets, oldest
+// first. The last bucket is the current, partial hour or day.
+type TimeSeries struct {
+	Window   string            `json:"window"`   // 24h, 7d or 30d
+	Bucket   string            `json:"bucket"`   // hour or day
+	Timezone string            `json:"timezone"` // Zone the buckets are aligned to
+	Buckets  []TimeSeriesPoint `json:"buckets"`
+}
+
+// TimeSeriesPoint counts the activity in one bucket. Requested includes
+// requests that were queued behind a concurrency limit.
+type TimeSeriesPoint struct {
+	Start     time.Time `json:"start"`
+	Accesses  int       `json:"accesses"`
+	Requested int       `json:"requested"`
+	Approved  int       `json:"approved"`
+	Denied    int       `json:"denied"`
+}
+
+// getTimeSeries counts credential accesses and elevation requests,
+// approvals and denials from the audit log into hour or day buckets for the
+// dashboard's activity charts. ?window picks the period (24h, the default,
+// in hours; 7d or 30d in days); ?service narrows it to one service; ?tz
+// (IANA name) sets the zone days start in, the server's by default.
+func (h *adminHandler) getTimeSeries(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	name := params.Get("window")
+	if name == "" {
+		name = "24h"
+	}
+	window, ok := timeSeriesWindows[name]
+	if !ok {
+		names := make([]string, 0, len(timeSeriesWindows))
+		for n := range timeSeriesWindows {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		h.jsonError(w, "window must be one of "+strings.Join(names, ", "), http.StatusBadRequest)
+		return
+	}
+	loc := time.Local
+	if tz := params.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			h.jsonError(w, "unknown tz "+tz, http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now().In(loc)
+	ts := &TimeSeries{Window: name, Timezone: loc.String(), Buckets: make([]TimeSeriesPoint, window.buckets)}
+	if window.hourly {
+		ts.Bucket = "hour"
+		last := now.Truncate(time.Hour)
+		for i := range ts.Buckets {
+			ts.Buckets[i].Start = last.Add(-time.Duration(window.buckets-1-i) * time.Hour)
+		}
+	} else {
+		ts.Bucket = "day"
+		last := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		for i := range ts.Buckets {
+			ts.Buckets[i].Start = last.AddDate(0, 0, -(window.buckets - 1 - i))
+		}
+	}
+
+	entries, err := h.store.ListAuditEntriesBetween(ts.Buckets[0].Start, now.Add(time.Second))
+	if err != nil {
+		h.logger.Error("list audit entries failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	service := params.Get("service")
+	for _, e := range entries {
+		if service != "" && e.Service != service {
+			continue
+		}
+		// Last bucket starting at or before the entry
+		i := sort.Search(len(ts.Buckets), func(i int) bool { return ts.Buckets[i].Start.After(e.Timestamp) }) - 1
+		if i < 0 {
+			continue
+		}
+		b := &ts.Buckets[i]
+		switch e.Action {
+		case store.ActionCredentialAccess:
+			b.Accesses++
+		case store.ActionElevationRequested, store.ActionElevationQueued:
+			b.Requested++
+		case store.ActionElevationApproved:
+			b.Approved++
+		case store.ActionElevationDenied:
+			b.Denied++
+		}
+	}
+
+	h.jsonResponse(w, ts)
+}
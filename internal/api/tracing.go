@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/tracing"
+)
+
+// traceRequests records a server span for each request, named after the
+// matched route, continuing the caller's trace if it sent a traceparent
+// header. Handlers pass r.Context() on so their spans nest under it.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartServer(tracing.Extract(r.Context(), r.Header), r.Method,
+			"http.method", r.Method, "http.target", redact.String(r.URL.Path), "request_id", middleware.GetReqID(r.Context()))
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttributes("http.route", rctx.RoutePattern())
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes("http.status_code", status)
+			if status >= 500 {
+				span.RecordError(errors.New(http.StatusText(status)))
+			}
+			span.End()
+		}()
+		next.ServeHTTP(ww, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/tracing"
+)
+
+func TestTraceRequests(t *testing.T) {
+	db, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	var mu sync.Mutex
+	var body string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		body += string(b)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	tracer, err := tracing.New(tracing.Config{Endpoint: collector.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracer.Run(ctx)
+		close(done)
+	}()
+	tracing.SetDefault(tracer)
+	defer func() {
+		tracing.SetDefault(nil)
+		cancel()
+		<-done
+	}()
+
+	router := NewAgentRouter(db, nil, slog.Default())
+	req := httptest.NewRequest("GET", "/api/v1/elevate/elev_missing", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	tracer.Flush(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	var export struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.NewDecoder(strings.NewReader(body)).Decode(&export); err != nil {
+		t.Fatalf("decode export: %v: %s", err, body)
+	}
+	spans := export.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1: %s", len(spans), body)
+	}
+	if s := spans[0]; s.Name != "GET /api/v1/elevate/{id}" || s.TraceID != "0af7651916cd43dd8448eb211c80319c" || s.ParentSpanID != "b7ad6b7169203331" {
+		t.Errorf("span = %+v, want the route name under the caller's trace", s)
+	}
+}
@@ -1,15 +1,8 @@
 package api
 
-import (
-	"crypto/rand"
-	"encoding/hex"
-	"fmt"
-	"time"
-)
+import "github.com/openclaw/ocm/internal/store"
 
 // generateID creates a unique ID with the given prefix.
 func generateID(prefix string) string {
-	b := make([]byte, 8)
-	rand.Read(b)
-	return fmt.Sprintf("%s_%s_%d", prefix, hex.EncodeToString(b), time.Now().UnixMilli()%10000)
+	return store.NewID(prefix)
 }
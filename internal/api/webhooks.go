@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// WebhookRequest creates or updates an outbound webhook.
+type WebhookRequest struct {
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"` // Generated on create if empty; kept on update if empty
+	Events  []string `json:"events"`           // e.g., ["elevation.*", "credential.deleted"]
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+func (req *WebhookRequest) validate() error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if len(req.Events) == 0 {
+		return fmt.Errorf("events is required")
+	}
+	for _, f := range req.Events {
+		if !notify.ValidFilter(f) {
+			return fmt.Errorf("unknown event filter %q", f)
+		}
+	}
+	return nil
+}
+
+// webhookResponse includes the signing secret, which is only returned on create.
+type webhookResponse struct {
+	*store.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+func (h *adminHandler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.store.ListWebhooks()
+	if err != nil {
+		h.logger.Error("list webhooks failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if hooks == nil {
+		hooks = []*store.Webhook{}
+	}
+	h.jsonResponse(w, hooks)
+}
+
+func (h *adminHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook := &store.Webhook{
+		ID:        generateID("wh"),
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedAt: time.Now(),
+	}
+	if hook.Secret == "" {
+		hook.Secret = notify.NewWebhookSecret()
+	}
+	if err := h.store.SaveWebhook(hook); err != nil {
+		h.logger.Error("save webhook failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionWebhookCreated,
+		Details:   fmt.Sprintf("webhook: %s, url: %s", hook.ID, hook.URL),
+		Actor:     "admin",
+	}))
+
+	w.WriteHeader(http.StatusCreated)
+	h.jsonResponse(w, webhookResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+func (h *adminHandler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	hook, err := h.store.GetWebhook(id)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if hook == nil {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		h.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook.URL = req.URL
+	hook.Events = req.Events
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if err := h.store.SaveWebhook(hook); err != nil {
+		h.logger.Error("save webhook failed", "error", err)
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionWebhookUpdated,
+		Details:   fmt.Sprintf("webhook: %s, url: %s", hook.ID, hook.URL),
+		Actor:     "admin",
+	}))
+
+	h.jsonResponse(w, hook)
+}
+
+func (h *adminHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	found, err := h.store.DeleteWebhook(id)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		h.jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	// Audit log
+	h.store.AddAuditEntry(withRequest(r, &store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionWebhookDeleted,
+		Details:   fmt.Sprintf("webhook: %s", id),
+		Actor:     "admin",
+	}))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliveries, err := h.store.ListWebhookDeliveries(id, 100)
+	if err != nil {
+		h.jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*store.WebhookDelivery{}
+	}
+	h.jsonResponse(w, deliveries)
+}
@@ -0,0 +1,414 @@
+// Package audit fans audit entries out to configurable audit devices.
+//
+// Devices are enabled and disabled at runtime through the admin API. Each has
+// its own output format and failure policy: a "block" device that fails makes
+// the audited operation fail, a "best-effort" device only logs the error.
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// devicesSettingKey is the settings key holding the device configurations.
+const devicesSettingKey = "audit_devices"
+
+// Device types.
+const (
+	TypeSQLite = "sqlite" // The built-in audit_log table (backs GET /admin/api/v1/audit)
+	TypeFile   = "file"   // Append-only file, or "stdout"
+	TypeSocket = "socket" // Unix domain socket or TCP/UDP endpoint, one entry per line
+	TypeSyslog = "syslog" // RFC 5424 syslog over UDP or TCP
+	TypeHTTP   = "http"   // JSON POST per entry, optionally HMAC-signed
+)
+
+// Format is how a device serializes entries.
+type Format string
+
+const (
+	FormatJSON Format = "json" // One JSON object per line (default)
+	FormatText Format = "text" // key=value line
+)
+
+// FailurePolicy decides what a device failure means for the audited operation.
+type FailurePolicy string
+
+const (
+	PolicyBlock      FailurePolicy = "block"       // Fail the operation (default)
+	PolicyBestEffort FailurePolicy = "best-effort" // Log and carry on
+)
+
+const defaultTimeout = 5 * time.Second
+
+// DeviceConfig configures one audit device.
+type DeviceConfig struct {
+	Name          string        `json:"name"`
+	Type          string        `json:"type"`
+	Enabled       bool          `json:"enabled"`
+	Format        Format        `json:"format,omitempty"`
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+
+	Path    string `json:"path,omitempty"`    // file: path or "stdout"; socket: unix socket path
+	Network string `json:"network,omitempty"` // socket: "unix" (default), "tcp" or "udp"; syslog: "udp" (default) or "tcp"
+	Address string `json:"address,omitempty"` // socket (tcp/udp), syslog: host:port
+	URL     string `json:"url,omitempty"`     // http: endpoint
+	Secret  string `json:"secret,omitempty"`  // http: HMAC signing secret, stored encrypted apart from the config
+	Timeout string `json:"timeout,omitempty"` // socket/syslog/http: per-entry timeout (default 5s)
+
+	Facility string `json:"facility,omitempty"` // syslog: facility name (default "local0")
+	AppName  string `json:"appName,omitempty"`  // syslog: APP-NAME (default "ocm")
+}
+
+// Validate checks the config and fills in defaults.
+func (c *DeviceConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch c.Format {
+	case "":
+		c.Format = FormatJSON
+	case FormatJSON, FormatText:
+	default:
+		return fmt.Errorf("format must be \"json\" or \"text\"")
+	}
+	switch c.FailurePolicy {
+	case "":
+		c.FailurePolicy = PolicyBlock
+	case PolicyBlock, PolicyBestEffort:
+	default:
+		return fmt.Errorf("failurePolicy must be \"block\" or \"best-effort\"")
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", c.Timeout)
+		}
+	}
+
+	switch c.Type {
+	case TypeSQLite:
+	case TypeFile:
+		if c.Path == "" {
+			return fmt.Errorf("file device needs a path")
+		}
+	case TypeSocket:
+		switch c.Network {
+		case "":
+			c.Network = "unix"
+			fallthrough
+		case "unix":
+			if c.Path == "" {
+				return fmt.Errorf("unix socket device needs a path")
+			}
+		case "tcp", "udp":
+			if c.Address == "" {
+				return fmt.Errorf("%s socket device needs an address", c.Network)
+			}
+		default:
+			return fmt.Errorf("network must be \"unix\", \"tcp\" or \"udp\"")
+		}
+	case TypeSyslog:
+		switch c.Network {
+		case "":
+			c.Network = "udp"
+		case "udp", "tcp":
+		default:
+			return fmt.Errorf("syslog network must be \"udp\" or \"tcp\"")
+		}
+		if c.Address == "" {
+			return fmt.Errorf("syslog device needs an address")
+		}
+		if c.Facility == "" {
+			c.Facility = "local0"
+		}
+		if _, ok := syslogFacilities[c.Facility]; !ok {
+			return fmt.Errorf("unknown syslog facility %q", c.Facility)
+		}
+		if c.AppName == "" {
+			c.AppName = "ocm"
+		}
+	case TypeHTTP:
+		if c.URL == "" {
+			return fmt.Errorf("http device needs a url")
+		}
+		if c.Format != FormatJSON {
+			return fmt.Errorf("http device only supports json format")
+		}
+	default:
+		return fmt.Errorf("unknown device type %q", c.Type)
+	}
+	return nil
+}
+
+func (c *DeviceConfig) timeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultTimeout
+}
+
+// Device writes audit entries to one destination. Implementations must be
+// safe for concurrent use.
+type Device interface {
+	Log(entry *store.AuditEntry) error
+	Close() error
+}
+
+// Broker implements store.AuditSink by writing each entry to every enabled device.
+type Broker struct {
+	store  *store.Store
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	configs map[string]DeviceConfig
+	devices map[string]Device // Enabled devices only
+}
+
+// NewBroker creates a broker with no devices. Call Load before use.
+func NewBroker(s *store.Store, logger *slog.Logger) *Broker {
+	return &Broker{
+		store:   s,
+		logger:  logger,
+		configs: make(map[string]DeviceConfig),
+		devices: make(map[string]Device),
+	}
+}
+
+// Load opens the stored devices. With nothing stored, a single blocking
+// sqlite device is enabled, matching the behaviour before devices existed.
+func (b *Broker) Load() error {
+	var configs []DeviceConfig
+	found, err := b.store.GetSetting(devicesSettingKey, &configs)
+	if err != nil {
+		return fmt.Errorf("load audit devices: %w", err)
+	}
+	if !found {
+		configs = []DeviceConfig{{Name: "sqlite", Type: TypeSQLite, Enabled: true}}
+	}
+	secrets := make(map[string]string)
+	if _, err := b.store.GetEncryptedSetting(store.AuditDeviceSecretsKey, &secrets); err != nil {
+		return fmt.Errorf("load audit device secrets: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	plaintext := false
+	for _, cfg := range configs {
+		if cfg.Secret != "" {
+			// Stored in the config by an earlier OCM
+			plaintext = true
+		} else {
+			cfg.Secret = secrets[cfg.Name]
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("audit device %s: %w", cfg.Name, err)
+		}
+		b.configs[cfg.Name] = cfg
+		if !cfg.Enabled {
+			continue
+		}
+		dev, err := b.open(cfg)
+		if err != nil {
+			return fmt.Errorf("open audit device %s: %w", cfg.Name, err)
+		}
+		b.devices[cfg.Name] = dev
+	}
+	if plaintext {
+		if err := b.persist(); err != nil {
+			return fmt.Errorf("encrypt audit device secrets: %w", err)
+		}
+	}
+	return nil
+}
+
+// Devices returns all configured devices sorted by name, with secrets removed.
+func (b *Broker) Devices() []DeviceConfig {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := make([]DeviceConfig, 0, len(b.configs))
+	for _, cfg := range b.configs {
+		cfg.Secret = ""
+		out = append(out, cfg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Put creates or replaces a device. An empty secret keeps the existing one.
+func (b *Broker) Put(cfg DeviceConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if old, ok := b.configs[cfg.Name]; ok && cfg.Secret == "" {
+		cfg.Secret = old.Secret
+	}
+	if !cfg.Enabled && !b.othersEnabled(cfg.Name) {
+		return fmt.Errorf("at least one audit device must stay enabled")
+	}
+
+	var dev Device
+	if cfg.Enabled {
+		var err error
+		if dev, err = b.open(cfg); err != nil {
+			return fmt.Errorf("open device: %w", err)
+		}
+	}
+
+	prevCfg, hadCfg := b.configs[cfg.Name]
+	prevDev := b.devices[cfg.Name]
+	b.configs[cfg.Name] = cfg
+	if dev != nil {
+		b.devices[cfg.Name] = dev
+	} else {
+		delete(b.devices, cfg.Name)
+	}
+
+	if err := b.persist(); err != nil {
+		// Roll back to the previous device
+		if dev != nil {
+			dev.Close()
+		}
+		if hadCfg {
+			b.configs[cfg.Name] = prevCfg
+		} else {
+			delete(b.configs, cfg.Name)
+		}
+		if prevDev != nil {
+			b.devices[cfg.Name] = prevDev
+		} else {
+			delete(b.devices, cfg.Name)
+		}
+		return err
+	}
+
+	if prevDev != nil {
+		prevDev.Close()
+	}
+	return nil
+}
+
+// Remove deletes a device. Returns false if it does not exist.
+func (b *Broker) Remove(name string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cfg, ok := b.configs[name]
+	if !ok {
+		return false, nil
+	}
+	if cfg.Enabled && !b.othersEnabled(name) {
+		return true, fmt.Errorf("at least one audit device must stay enabled")
+	}
+
+	dev := b.devices[name]
+	delete(b.configs, name)
+	delete(b.devices, name)
+	if err := b.persist(); err != nil {
+		b.configs[name] = cfg
+		if dev != nil {
+			b.devices[name] = dev
+		}
+		return true, err
+	}
+	if dev != nil {
+		dev.Close()
+	}
+	return true, nil
+}
+
+// WriteAudit writes entry to every enabled device. Failures of blocking
+// devices are returned; best-effort failures are only logged.
+func (b *Broker) WriteAudit(entry *store.AuditEntry) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var errs []error
+	for name, dev := range b.devices {
+		if err := dev.Log(entry); err != nil {
+			if b.configs[name].FailurePolicy == PolicyBestEffort {
+				b.logger.Warn("audit device write failed", "device", name, "error", err)
+				continue
+			}
+			b.logger.Error("audit device write failed", "device", name, "error", err)
+			errs = append(errs, fmt.Errorf("audit device %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all open devices.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	for name, dev := range b.devices {
+		if err := dev.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close audit device %s: %w", name, err))
+		}
+		delete(b.devices, name)
+	}
+	return errors.Join(errs...)
+}
+
+// othersEnabled reports whether any device other than name is enabled. Caller holds b.mu.
+func (b *Broker) othersEnabled(name string) bool {
+	for n, cfg := range b.configs {
+		if n != name && cfg.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// persist stores the device configs, and their secrets encrypted under the
+// master key. Caller holds b.mu.
+func (b *Broker) persist() error {
+	configs := make([]DeviceConfig, 0, len(b.configs))
+	secrets := make(map[string]string)
+	for _, cfg := range b.configs {
+		if cfg.Secret != "" {
+			secrets[cfg.Name] = cfg.Secret
+			cfg.Secret = ""
+		}
+		configs = append(configs, cfg)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	// Secrets first, so a config never outlives the secret it needs
+	if err := b.store.PutEncryptedSetting(store.AuditDeviceSecretsKey, secrets); err != nil {
+		return err
+	}
+	return b.store.PutSetting(devicesSettingKey, configs)
+}
+
+func (b *Broker) open(cfg DeviceConfig) (Device, error) {
+	switch cfg.Type {
+	case TypeSQLite:
+		return &sqliteDevice{store: b.store}, nil
+	case TypeFile:
+		return openFileDevice(cfg)
+	case TypeSocket:
+		addr := cfg.Address
+		if cfg.Network == "unix" {
+			addr = cfg.Path
+		}
+		return &socketDevice{cfg: cfg, network: cfg.Network, addr: addr, frame: func(e *store.AuditEntry) ([]byte, error) {
+			return encode(cfg.Format, e)
+		}}, nil
+	case TypeSyslog:
+		return newSyslogDevice(cfg)
+	case TypeHTTP:
+		return &httpDevice{cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown device type %q", cfg.Type)
+}
@@ -0,0 +1,376 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestBroker_Devices(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := NewBroker(db, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	db.SetAuditSink(b)
+
+	// Default: sqlite only, and it can't be the last device disabled
+	if devs := b.Devices(); len(devs) != 1 || devs[0].Type != TypeSQLite {
+		t.Fatalf("default devices = %+v, want a single sqlite device", devs)
+	}
+	if err := b.Put(DeviceConfig{Name: "sqlite", Type: TypeSQLite}); err == nil {
+		t.Error("disabling the only device should fail")
+	}
+
+	logPath := filepath.Join(dir, "audit.log")
+	if err := b.Put(DeviceConfig{Name: "file", Type: TypeFile, Enabled: true, Format: FormatText, Path: logPath}); err != nil {
+		t.Fatal(err)
+	}
+	// Best-effort device pointing at a socket nobody listens on
+	if err := b.Put(DeviceConfig{Name: "siem", Type: TypeSocket, Enabled: true, FailurePolicy: PolicyBestEffort, Path: filepath.Join(dir, "none.sock")}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &store.AuditEntry{ID: "audit-1", Timestamp: time.Now(), Action: "credential_access", Service: "github", Actor: "agent"}
+	if err := db.AddAuditEntry(entry); err != nil {
+		t.Fatalf("AddAuditEntry with best-effort failure = %v, want nil", err)
+	}
+
+	data, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(data), `action="credential_access"`) {
+		t.Errorf("file device output = %q, want text line with action", data)
+	}
+	if entries, _ := db.ListAuditEntries(10, ""); len(entries) != 1 {
+		t.Errorf("sqlite device has %d entries, want 1", len(entries))
+	}
+
+	// The same socket under a blocking policy fails the write
+	if err := b.Put(DeviceConfig{Name: "siem", Type: TypeSocket, Enabled: true, Path: filepath.Join(dir, "none.sock")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddAuditEntry(&store.AuditEntry{ID: "audit-2", Timestamp: time.Now(), Action: "credential_access"}); err == nil {
+		t.Error("AddAuditEntry with blocking failure = nil, want error")
+	}
+
+	// Config survives a reload
+	b2 := NewBroker(db, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err := b2.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer b2.Close()
+	if devs := b2.Devices(); len(devs) != 3 {
+		t.Errorf("reloaded %d devices, want 3", len(devs))
+	}
+}
+
+func TestBroker_EncryptsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ocm.db")
+	db, err := store.New(path, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { db.Close() }()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	// Stored in the clear by an earlier OCM
+	if err := db.PutSetting(devicesSettingKey, []DeviceConfig{
+		{Name: "sqlite", Type: TypeSQLite, Enabled: true},
+		{Name: "siem", Type: TypeHTTP, Enabled: true, URL: "https://siem.example.com/ingest", Secret: "hmac-secret"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := func() bool {
+		t.Helper()
+		var raw []map[string]interface{}
+		if _, err := db.GetSetting(devicesSettingKey, &raw); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(raw)
+		return strings.Contains(string(data), "hmac-secret")
+	}
+	secret := func() string {
+		t.Helper()
+		b := NewBroker(db, logger)
+		if err := b.Load(); err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		return b.configs["siem"].Secret
+	}
+
+	if got := secret(); got != "hmac-secret" {
+		t.Errorf("secret = %q after migration", got)
+	}
+	if plaintext() {
+		t.Error("secret still stored in the clear after Load")
+	}
+
+	// Rekeyed along with everything else
+	newKey := make([]byte, 32)
+	newKey[0] = 1
+	if err := db.Rekey(newKey, nil); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if db, err = store.New(path, newKey); err != nil {
+		t.Fatal(err)
+	}
+	if got := secret(); got != "hmac-secret" {
+		t.Errorf("secret = %q after rekey", got)
+	}
+	if report, err := db.VerifyEncryption(); err != nil || !report.OK() || report.Checked != 1 {
+		t.Errorf("VerifyEncryption = %+v, %v", report, err)
+	}
+}
+
+func TestBroker_NetworkDevices(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := NewBroker(db, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err := b.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	db.SetAuditSink(b)
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := tcp.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	if err := b.Put(DeviceConfig{Name: "syslog", Type: TypeSyslog, Enabled: true, Address: udp.LocalAddr().String(), Facility: "auth"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(DeviceConfig{Name: "siem", Type: TypeSocket, Enabled: true, Network: "tcp", Address: tcp.Addr().String()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(DeviceConfig{Name: "bad", Type: TypeSyslog, Enabled: true, Address: "localhost:514", Facility: "nope"}); err == nil {
+		t.Error("unknown facility accepted")
+	}
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := &store.AuditEntry{ID: "audit-1", Timestamp: ts, Action: "elevation_approved", Service: "github", Actor: "admin"}
+	if err := db.AddAuditEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2048)
+	udp.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := udp.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// auth (4) * 8 + informational (6) = 38
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<38>1 2026-01-02T03:04:05.000000Z ") || !strings.Contains(msg, " ocm ") ||
+		!strings.Contains(msg, ` elevation_approved - {"id":"audit-1"`) {
+		t.Errorf("syslog message = %q", msg)
+	}
+
+	select {
+	case line := <-lines:
+		if !strings.HasPrefix(line, `{"id":"audit-1"`) {
+			t.Errorf("tcp line = %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line on the tcp socket")
+	}
+}
+
+func TestPruner(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, age := range []int{400, 200, 10} {
+		db.AddAuditEntry(&store.AuditEntry{
+			ID:        fmt.Sprintf("audit-%d", i+1),
+			Timestamp: now.AddDate(0, 0, -age),
+			Action:    "credential_access",
+			Actor:     "agent",
+		})
+	}
+
+	archiveDir := filepath.Join(dir, "archive")
+	p := NewPruner(db, 180*24*time.Hour, archiveDir, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	n, err := p.Prune(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("pruned %d entries, want 2", n)
+	}
+
+	// The recent entry and the audit_pruned record remain
+	entries, _ := db.ListAuditEntries(10, "")
+	if len(entries) != 2 || entries[1].ID != "audit-3" || entries[0].Action != "audit_pruned" {
+		t.Errorf("remaining entries = %+v", entries)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(archiveDir, "audit-*.jsonl.gz"))
+	if len(files) != 1 {
+		t.Fatalf("archives = %v, want 1", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archived []string
+	dec := json.NewDecoder(zr)
+	for dec.More() {
+		var e store.AuditEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		archived = append(archived, e.ID)
+	}
+	if strings.Join(archived, ",") != "audit-2,audit-1" {
+		t.Errorf("archived = %v, want audit-2, audit-1", archived)
+	}
+
+	// Nothing left to prune: no new archive
+	if n, err := p.Prune(now); err != nil || n != 0 {
+		t.Errorf("second prune = %d, %v", n, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(archiveDir, "*")); len(files) != 1 {
+		t.Errorf("archives after second prune = %v", files)
+	}
+}
+
+func TestObjectArchiver(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	type object struct {
+		body   []byte
+		header http.Header
+	}
+	var mu sync.Mutex
+	objects := make(map[string]object)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			http.Error(w, "bad request", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.Path] = object{body, r.Header}
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	client, err := NewS3Client(S3Config{Endpoint: srv.URL, Bucket: "audit", AccessKeyID: "key", SecretAccessKey: "secret", ServerSideEncryption: "AES256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	a := NewObjectArchiver(db, client, "ocm/", time.Hour, logger)
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{10 * time.Minute, 20 * time.Minute, 2*time.Hour + 5*time.Minute, 3*time.Hour + 30*time.Minute} {
+		db.AddAuditEntry(&store.AuditEntry{ID: fmt.Sprintf("audit-%d", i+1), Timestamp: base.Add(offset), Action: "credential_access", Actor: "agent"})
+	}
+
+	// 10:00-11:00 and 12:00-13:00 are sealed; 11:00-12:00 is empty and 13:00-14:00 still open
+	n, err := a.ArchiveSealed(context.Background(), base.Add(3*time.Hour+45*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(objects) != 2 {
+		t.Fatalf("uploaded %d segments (%d objects), want 2", n, len(objects))
+	}
+	first := objects["/audit/ocm/2026/03/01/audit-20260301T100000Z-20260301T110000Z.jsonl.gz"]
+	second := objects["/audit/ocm/2026/03/01/audit-20260301T120000Z-20260301T130000Z.jsonl.gz"]
+	if first.body == nil || second.body == nil {
+		t.Fatalf("unexpected objects: %v", objects)
+	}
+	if first.header.Get("X-Amz-Server-Side-Encryption") != "AES256" || first.header.Get("X-Amz-Meta-Entries") != "2" {
+		t.Errorf("first segment headers = %v", first.header)
+	}
+	sum := sha256.Sum256(first.body)
+	if second.header.Get("X-Amz-Meta-Prev-Sha256") != hex.EncodeToString(sum[:]) {
+		t.Error("second segment isn't chained to the first")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(first.body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(zr)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"id":"audit-1"`) {
+		t.Errorf("first segment = %s", data)
+	}
+	if got := a.SealedUntil(); !got.Equal(base.Add(3 * time.Hour)) {
+		t.Errorf("SealedUntil = %v", got)
+	}
+
+	// Nothing new is sealed yet
+	if n, err := a.ArchiveSealed(context.Background(), base.Add(3*time.Hour+50*time.Minute)); err != nil || n != 0 {
+		t.Errorf("second run = %d, %v", n, err)
+	}
+
+	// Pruning stops at what has been uploaded
+	p := NewPruner(db, time.Hour, "", logger)
+	p.SetFloor(a.SealedUntil)
+	if n, err := p.Prune(base.Add(10 * time.Hour)); err != nil || n != 3 {
+		t.Errorf("pruned %d, %v; want the 3 archived entries", n, err)
+	}
+}
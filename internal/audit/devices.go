@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+// encode serializes entry as a single newline-terminated line.
+func encode(f Format, entry *store.AuditEntry) ([]byte, error) {
+	if f == FormatText {
+		var sb strings.Builder
+		sb.WriteString(entry.Timestamp.UTC().Format(time.RFC3339Nano))
+		for _, kv := range [][2]string{
+			{"id", entry.ID},
+			{"action", string(entry.Action)},
+			{"service", entry.Service},
+			{"scope", entry.Scope},
+			{"actor", entry.Actor},
+			{"details", entry.Details},
+			{"elevation_id", entry.ElevationID},
+			{"request_id", entry.RequestID},
+			{"source_ip", entry.SourceIP},
+			{"user_agent", entry.UserAgent},
+		} {
+			if kv[1] == "" {
+				continue
+			}
+			sb.WriteString(" " + kv[0] + "=" + strconv.Quote(kv[1]))
+		}
+		sb.WriteByte('\n')
+		return []byte(sb.String()), nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// sqliteDevice writes to the store's audit_log table.
+type sqliteDevice struct {
+	store *store.Store
+}
+
+func (d *sqliteDevice) Log(entry *store.AuditEntry) error { return d.store.InsertAuditEntry(entry) }
+func (d *sqliteDevice) Close() error                      { return nil }
+
+// fileDevice appends lines to a file (or stdout).
+type fileDevice struct {
+	format Format
+	mu     sync.Mutex
+	f      *os.File
+	owned  bool
+}
+
+func openFileDevice(cfg DeviceConfig) (*fileDevice, error) {
+	if cfg.Path == "stdout" {
+		return &fileDevice{format: cfg.Format, f: os.Stdout}, nil
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileDevice{format: cfg.Format, f: f, owned: true}, nil
+}
+
+func (d *fileDevice) Log(entry *store.AuditEntry) error {
+	line, err := encode(d.format, entry)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = d.f.Write(line)
+	return err
+}
+
+func (d *fileDevice) Close() error {
+	if !d.owned {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.f.Close()
+}
+
+// socketDevice writes framed entries to a unix socket or a TCP/UDP endpoint,
+// reconnecting as needed.
+type socketDevice struct {
+	cfg     DeviceConfig
+	network string
+	addr    string
+	frame   func(*store.AuditEntry) ([]byte, error)
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (d *socketDevice) Log(entry *store.AuditEntry) error {
+	msg, err := d.frame(entry)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// One retry on a fresh connection if the existing one went away
+	for attempt := 0; attempt < 2; attempt++ {
+		if d.conn == nil {
+			conn, err := net.DialTimeout(d.network, d.addr, d.cfg.timeout())
+			if err != nil {
+				return fmt.Errorf("dial %s: %w", d.addr, err)
+			}
+			d.conn = conn
+		}
+		d.conn.SetWriteDeadline(time.Now().Add(d.cfg.timeout()))
+		if _, err = d.conn.Write(msg); err == nil {
+			return nil
+		}
+		d.conn.Close()
+		d.conn = nil
+	}
+	return err
+}
+
+func (d *socketDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// httpDevice POSTs each entry as JSON, signed like access webhooks.
+type httpDevice struct {
+	cfg DeviceConfig
+}
+
+func (d *httpDevice) Log(entry *store.AuditEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.timeout())
+	defer cancel()
+	return webhook.PostRaw(ctx, nil, d.cfg.URL, d.cfg.Secret, body)
+}
+
+func (d *httpDevice) Close() error { return nil }
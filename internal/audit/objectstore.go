@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// objectArchiveSettingKey is the settings key holding the archiver's progress.
+const objectArchiveSettingKey = "audit_object_archive"
+
+const (
+	archiveCheckInterval = 5 * time.Minute
+	// sealGrace is how long after a segment ends it is sealed, so entries
+	// being written as it closes still make it in.
+	sealGrace = time.Minute
+)
+
+// ObjectStore stores archived audit segments. *S3Client implements it.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key, contentType string, body []byte, meta map[string]string) error
+}
+
+// ObjectArchiver uploads the audit_log to object storage in sealed,
+// gzipped JSONL segments of a fixed length, oldest first. Each segment's
+// metadata carries its SHA-256 and the previous segment's, chaining them so
+// a missing or altered segment can be detected.
+type ObjectArchiver struct {
+	store   *store.Store
+	objects ObjectStore
+	prefix  string
+	segment time.Duration
+	logger  *slog.Logger
+}
+
+// archiveState is the archiver's persisted progress.
+type archiveState struct {
+	SealedUntil time.Time `json:"sealedUntil"` // Entries before this are uploaded
+	LastSHA256  string    `json:"lastSha256,omitempty"`
+}
+
+// NewObjectArchiver creates an archiver uploading segment-long segments
+// under prefix (e.g. "ocm/audit/").
+func NewObjectArchiver(s *store.Store, objects ObjectStore, prefix string, segment time.Duration, logger *slog.Logger) *ObjectArchiver {
+	return &ObjectArchiver{store: s, objects: objects, prefix: prefix, segment: segment, logger: logger}
+}
+
+// Run uploads sealed segments every few minutes until ctx is done.
+func (a *ObjectArchiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(archiveCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.ArchiveSealed(ctx, time.Now()); err != nil {
+			a.logger.Error("audit archive upload failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SealedUntil returns the time before which every entry has been uploaded
+// (zero if nothing has been yet).
+func (a *ObjectArchiver) SealedUntil() time.Time {
+	var st archiveState
+	if _, err := a.store.GetSetting(objectArchiveSettingKey, &st); err != nil {
+		a.logger.Error("failed to load audit archive state", "error", err)
+	}
+	return st.SealedUntil
+}
+
+// ArchiveSealed uploads every segment that ended before now (less a short
+// grace period) and hasn't been uploaded yet, and returns how many objects
+// were written. Empty segments are skipped. Progress is saved after each
+// segment, so a failure is retried from where it stopped.
+func (a *ObjectArchiver) ArchiveSealed(ctx context.Context, now time.Time) (int, error) {
+	var st archiveState
+	if _, err := a.store.GetSetting(objectArchiveSettingKey, &st); err != nil {
+		return 0, fmt.Errorf("load archive state: %w", err)
+	}
+	if st.SealedUntil.IsZero() {
+		oldest, ok, err := a.store.OldestAuditTimestamp()
+		if err != nil || !ok {
+			return 0, err
+		}
+		st.SealedUntil = oldest.UTC().Truncate(a.segment)
+	}
+
+	uploaded := 0
+	for start := st.SealedUntil; !start.Add(a.segment).After(now.Add(-sealGrace)); start = st.SealedUntil {
+		if err := ctx.Err(); err != nil {
+			return uploaded, err
+		}
+		end := start.Add(a.segment)
+		entries, err := a.store.ListAuditEntriesBetween(start, end)
+		if err != nil {
+			return uploaded, fmt.Errorf("list audit entries: %w", err)
+		}
+		if len(entries) > 0 {
+			sum, err := a.upload(ctx, start, end, entries, st.LastSHA256)
+			if err != nil {
+				return uploaded, err
+			}
+			st.LastSHA256 = sum
+			uploaded++
+		}
+		st.SealedUntil = end
+		if err := a.store.PutSetting(objectArchiveSettingKey, st); err != nil {
+			return uploaded, fmt.Errorf("save archive state: %w", err)
+		}
+	}
+	return uploaded, nil
+}
+
+// upload writes one segment and returns its SHA-256.
+func (a *ObjectArchiver) upload(ctx context.Context, start, end time.Time, entries []*store.AuditEntry, prevSHA256 string) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	digest := hex.EncodeToString(sum[:])
+
+	const stamp = "20060102T150405Z"
+	key := a.prefix + start.UTC().Format("2006/01/02/") + "audit-" + start.UTC().Format(stamp) + "-" + end.UTC().Format(stamp) + ".jsonl.gz"
+	meta := map[string]string{
+		"Entries": strconv.Itoa(len(entries)),
+		"Sha256":  digest,
+	}
+	if prevSHA256 != "" {
+		meta["Prev-Sha256"] = prevSHA256
+	}
+	if err := a.objects.PutObject(ctx, key, "application/gzip", buf.Bytes(), meta); err != nil {
+		return "", fmt.Errorf("upload %s: %w", key, err)
+	}
+	a.logger.Info("archived audit segment", "key", key, "entries", len(entries))
+	return digest, nil
+}
+
+// ParseS3URL splits "s3://bucket/prefix" into its bucket and prefix. A
+// non-empty prefix always ends in "/".
+func ParseS3URL(raw string) (bucket, prefix string, err error) {
+	rest, ok := strings.CutPrefix(raw, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("%q is not an s3:// URL", raw)
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%q has no bucket", raw)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
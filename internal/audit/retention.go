@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+const pruneInterval = time.Hour
+
+// Pruner deletes audit_log entries older than the retention period,
+// optionally archiving them to gzipped JSONL files first.
+type Pruner struct {
+	store      *store.Store
+	retention  time.Duration
+	archiveDir string // "" deletes without archiving
+	floor      func() time.Time
+	logger     *slog.Logger
+}
+
+// NewPruner creates a pruner keeping entries for retention.
+func NewPruner(s *store.Store, retention time.Duration, archiveDir string, logger *slog.Logger) *Pruner {
+	return &Pruner{store: s, retention: retention, archiveDir: archiveDir, logger: logger}
+}
+
+// SetFloor keeps entries at or after floor() however old they are, e.g. those
+// an ObjectArchiver hasn't uploaded yet (see ObjectArchiver.SealedUntil).
+func (p *Pruner) SetFloor(floor func() time.Time) {
+	p.floor = floor
+}
+
+// Run prunes hourly until ctx is done.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Prune(time.Now()); err != nil {
+			p.logger.Error("audit pruning failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune removes entries older than now minus the retention period and
+// returns how many were removed. If archiving is enabled and fails, nothing
+// is removed.
+func (p *Pruner) Prune(now time.Time) (int64, error) {
+	cutoff := now.Add(-p.retention)
+	if p.floor != nil {
+		if floor := p.floor(); floor.Before(cutoff) {
+			cutoff = floor
+		}
+	}
+
+	archive := ""
+	if p.archiveDir != "" {
+		var err error
+		if archive, err = p.archive(cutoff); err != nil {
+			return 0, fmt.Errorf("archive audit entries: %w", err)
+		}
+	}
+
+	n, err := p.store.DeleteAuditEntriesBefore(cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("delete audit entries: %w", err)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	details := fmt.Sprintf("deleted %d entries before %s", n, cutoff.UTC().Format(time.RFC3339))
+	if archive != "" {
+		details += ", archived to " + archive
+	}
+	p.store.AddAuditEntry(&store.AuditEntry{
+		ID:        store.NewID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionAuditPruned,
+		Details:   details,
+		Actor:     "system",
+	})
+	p.logger.Info("pruned audit log", "deleted", n, "before", cutoff, "archive", archive)
+	return n, nil
+}
+
+// archive writes the entries older than cutoff, newest first, to a new
+// gzipped JSONL file in the archive directory and returns its path ("" if
+// there were none).
+func (p *Pruner) archive(cutoff time.Time) (string, error) {
+	entries, cursor, err := p.store.QueryAuditEntries(store.AuditQuery{To: cutoff, Limit: store.MaxAuditLimit})
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(p.archiveDir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(p.archiveDir, fmt.Sprintf("audit-%s-%d.jsonl.gz", cutoff.UTC().Format("20060102"), time.Now().UnixNano()))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := writeArchive(f, p.store, entries, cursor, cutoff); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+func writeArchive(f *os.File, s *store.Store, entries []*store.AuditEntry, cursor string, cutoff time.Time) error {
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		if cursor == "" {
+			break
+		}
+		var err error
+		entries, cursor, err = s.QueryAuditEntries(store.AuditQuery{To: cutoff, Cursor: cursor, Limit: store.MaxAuditLimit})
+		if err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/sigv4"
+)
+
+// S3Config configures an S3-compatible bucket: AWS S3, MinIO, or Google
+// Cloud Storage through its XML API with HMAC keys.
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com, https://storage.googleapis.com
+	Region          string // SigV4 signing region (GCS: "auto")
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// ServerSideEncryption is sent as x-amz-server-side-encryption, e.g.
+	// "AES256" or "aws:kms" ("" leaves it to the bucket's default, as GCS
+	// requires). KMSKeyID selects the key for "aws:kms".
+	ServerSideEncryption string
+	KMSKeyID             string
+}
+
+// S3Client uploads objects with path-style requests signed with AWS
+// Signature Version 4, so no SDK is needed.
+type S3Client struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Client validates cfg and returns a client for its bucket.
+func NewS3Client(cfg S3Config) (*S3Client, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("access key ID and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+	return &S3Client{cfg: cfg, endpoint: u, client: &http.Client{Timeout: time.Minute}, now: time.Now}, nil
+}
+
+// PutObject uploads body as key, with meta as x-amz-meta-* headers.
+func (c *S3Client) PutObject(ctx context.Context, key, contentType string, body []byte, meta map[string]string) error {
+	u := *c.endpoint
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + c.cfg.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range meta {
+		req.Header.Set("X-Amz-Meta-"+k, v)
+	}
+	if c.cfg.ServerSideEncryption != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption", c.cfg.ServerSideEncryption)
+		if c.cfg.KMSKeyID != "" {
+			req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", c.cfg.KMSKeyID)
+		}
+	}
+	sum := sha256.Sum256(body)
+	sigv4.Sign(req, hex.EncodeToString(sum[:]), c.cfg.Region, "s3", c.cfg.AccessKeyID, c.cfg.SecretAccessKey, c.now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("put %s: %s: %s", key, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// syslogFacilities maps RFC 5424 facility names to their codes.
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity is the severity of every entry (informational).
+const syslogSeverity = 6
+
+// newSyslogDevice returns a socket device that sends each entry as an
+// RFC 5424 message. Over TCP, messages are octet-counted (RFC 6587); over
+// UDP, each is one datagram.
+func newSyslogDevice(cfg DeviceConfig) (*socketDevice, error) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	pri := syslogFacilities[cfg.Facility]*8 + syslogSeverity
+	header := fmt.Sprintf("%s %s %d", printUSASCII(hostname, 255), printUSASCII(cfg.AppName, 48), os.Getpid())
+
+	return &socketDevice{cfg: cfg, network: cfg.Network, addr: cfg.Address, frame: func(e *store.AuditEntry) ([]byte, error) {
+		body, err := encode(cfg.Format, e)
+		if err != nil {
+			return nil, err
+		}
+		msgID := printUSASCII(string(e.Action), 32)
+		if msgID == "" {
+			msgID = "-"
+		}
+		// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+		msg := fmt.Sprintf("<%d>1 %s %s %s - %s", pri,
+			e.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), header, msgID,
+			bytes.TrimRight(body, "\n"))
+		if cfg.Network == "tcp" {
+			return []byte(strconv.Itoa(len(msg)) + " " + msg), nil
+		}
+		return []byte(msg), nil
+	}}, nil
+}
+
+// printUSASCII returns s with characters RFC 5424 doesn't allow in header
+// fields replaced by '_', truncated to max bytes.
+func printUSASCII(s string, max int) string {
+	b := []byte(s)
+	if len(b) > max {
+		b = b[:max]
+	}
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,217 @@
+// Package backup reads and writes encrypted OCM backup archives: a store
+// snapshot, gzipped and sealed with AES-256-GCM under a key derived from a
+// passphrase or given directly.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Format identifies an OCM backup archive.
+const Format = "ocm-backup"
+
+// version is the archive version this package writes.
+const version = 1
+
+// Key derivation functions.
+const (
+	KDFPBKDF2 = "pbkdf2-sha256" // From a passphrase
+	KDFNone   = "none"          // A 32-byte key is used as is
+)
+
+// DefaultIterations is the PBKDF2 iteration count for new archives.
+const DefaultIterations = 600000
+
+// maxArchiveSize bounds how much Read will load.
+const maxArchiveSize = 1 << 30
+
+// ErrDecrypt is returned by Read when the passphrase or key is wrong or the
+// archive has been altered.
+var ErrDecrypt = errors.New("backup: wrong passphrase or key, or archive corrupted")
+
+// Secret unlocks an archive: a passphrase, or a 32-byte key.
+type Secret struct {
+	Passphrase string
+	Key        []byte
+}
+
+func (s Secret) validate() error {
+	switch {
+	case s.Passphrase != "" && s.Key != nil:
+		return fmt.Errorf("backup: use a passphrase or a key, not both")
+	case s.Key != nil && len(s.Key) != 32:
+		return fmt.Errorf("backup: key must be 32 bytes")
+	case s.Passphrase == "" && s.Key == nil:
+		return fmt.Errorf("backup: a passphrase or key is required")
+	}
+	return nil
+}
+
+// header is the cleartext part of an archive. It is authenticated as the
+// GCM additional data, so it can't be changed without detection.
+type header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"createdAt"`
+	KDF        string    `json:"kdf"`
+	Iterations int       `json:"iterations,omitempty"`
+	Salt       []byte    `json:"salt,omitempty"`
+	Nonce      []byte    `json:"nonce"`
+}
+
+// archive is the file written by Write: one JSON object.
+type archive struct {
+	header
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Write seals snap with secret and writes the archive to w.
+func Write(w io.Writer, snap *store.Snapshot, secret Secret) error {
+	if err := secret.validate(); err != nil {
+		return err
+	}
+
+	var plain bytes.Buffer
+	zw := gzip.NewWriter(&plain)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return fmt.Errorf("backup: encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	a := archive{header: header{Format: Format, Version: version, CreatedAt: snap.CreatedAt.UTC(), Nonce: make([]byte, 12)}}
+	key := secret.Key
+	if secret.Passphrase != "" {
+		a.KDF, a.Iterations, a.Salt = KDFPBKDF2, DefaultIterations, make([]byte, 16)
+		if _, err := rand.Read(a.Salt); err != nil {
+			return err
+		}
+		key = pbkdf2SHA256([]byte(secret.Passphrase), a.Salt, a.Iterations, 32)
+	} else {
+		a.KDF = KDFNone
+	}
+	if _, err := rand.Read(a.Nonce); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	aad, err := json.Marshal(a.header)
+	if err != nil {
+		return err
+	}
+	a.Ciphertext = gcm.Seal(nil, a.Nonce, plain.Bytes(), aad)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// Read opens an archive written by Write.
+func Read(r io.Reader, secret Secret) (*store.Snapshot, error) {
+	var a archive
+	if err := json.NewDecoder(io.LimitReader(r, maxArchiveSize)).Decode(&a); err != nil {
+		return nil, fmt.Errorf("backup: not an OCM backup archive: %w", err)
+	}
+	if a.Format != Format {
+		return nil, fmt.Errorf("backup: not an OCM backup archive")
+	}
+	if a.Version != version {
+		return nil, fmt.Errorf("backup: unsupported archive version %d", a.Version)
+	}
+
+	var key []byte
+	switch a.KDF {
+	case KDFPBKDF2:
+		if secret.Passphrase == "" {
+			return nil, fmt.Errorf("backup: archive is passphrase-encrypted; a passphrase is required")
+		}
+		if a.Iterations <= 0 || len(a.Salt) == 0 {
+			return nil, fmt.Errorf("backup: bad key derivation parameters")
+		}
+		key = pbkdf2SHA256([]byte(secret.Passphrase), a.Salt, a.Iterations, 32)
+	case KDFNone:
+		if len(secret.Key) != 32 {
+			return nil, fmt.Errorf("backup: archive is key-encrypted; a 32-byte key is required")
+		}
+		key = secret.Key
+	default:
+		return nil, fmt.Errorf("backup: unknown key derivation %q", a.KDF)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Nonce) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	aad, err := json.Marshal(a.header)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, a.Nonce, a.Ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	var snap store.Snapshot
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("backup: decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a keyLen-byte key from password (RFC 8018, PBKDF2
+// with HMAC-SHA-256).
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	u := make([]byte, prf.Size())
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u = prf.Sum(u[:0])
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
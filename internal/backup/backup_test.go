@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func newStore(t *testing.T, keyByte byte) *store.Store {
+	t.Helper()
+	s, err := store.New(filepath.Join(t.TempDir(), "ocm.db"), bytes.Repeat([]byte{keyByte}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// RFC 7914, section 11
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got := hex.EncodeToString(pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)); got != want {
+		t.Errorf("pbkdf2 = %s, want %s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := newStore(t, 1)
+	if err := src.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "write-token", MaxTTL: time.Hour},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SavePreset(&store.ElevationPreset{Service: "github", Name: "deploy", TTL: 15 * time.Minute, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	requested := time.Now().Add(-time.Hour)
+	if err := src.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release", Status: "pending", RequestedAt: requested, RequestedBy: "agent",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Hour)
+	if err := src.UpdateElevation("elev-1", "approved", "alice", &expires); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SaveApprovalReceipt(&store.ApprovalReceipt{ElevationID: "elev-1", Payload: []byte("{}"), Signature: []byte("sig"), PublicKey: []byte("pub")}); err != nil {
+		t.Fatal(err)
+	}
+	for i, action := range []store.AuditAction{store.ActionCredentialCreated, store.ActionElevationRequested, store.ActionElevationApproved} {
+		if err := src.AddAuditEntry(&store.AuditEntry{
+			ID: "audit-" + string(rune('a'+i)), Timestamp: requested.Add(time.Duration(i) * time.Minute),
+			Action: action, Service: "github", Actor: "admin", ElevationID: "elev-1",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, err := src.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, snap, Secret{Passphrase: "correct horse"}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("read-token")) {
+		t.Fatal("archive contains a token in the clear")
+	}
+
+	if _, err := Read(bytes.NewReader(buf.Bytes()), Secret{Passphrase: "wrong"}); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong passphrase: err = %v, want ErrDecrypt", err)
+	}
+	// The header is authenticated
+	var a map[string]interface{}
+	json.Unmarshal(buf.Bytes(), &a)
+	a["createdAt"] = time.Now().UTC().Format(time.RFC3339)
+	tampered, _ := json.Marshal(a)
+	if _, err := Read(bytes.NewReader(tampered), Secret{Passphrase: "correct horse"}); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered header: err = %v, want ErrDecrypt", err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()), Secret{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A different master key: everything is re-encrypted and re-chained
+	dst := newStore(t, 2)
+	if err := dst.ImportSnapshot(got); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.ImportSnapshot(got); !errors.Is(err, store.ErrStoreNotEmpty) {
+		t.Errorf("second import: err = %v, want ErrStoreNotEmpty", err)
+	}
+
+	cred, err := dst.GetCredential("github")
+	if err != nil || cred == nil {
+		t.Fatalf("credential = %v, %v", cred, err)
+	}
+	if cred.Read.Token != "read-token" || cred.ReadWrite.Token != "write-token" || cred.ReadWrite.MaxTTL != time.Hour {
+		t.Errorf("credential = %+v / %+v", cred.Read, cred.ReadWrite)
+	}
+	if p, _ := dst.GetPreset("github", "deploy"); p == nil || p.TTL != 15*time.Minute {
+		t.Errorf("preset = %+v", p)
+	}
+	elev, _ := dst.GetElevation("elev-1")
+	if elev == nil || elev.Status != "approved" || elev.ApprovedBy != "alice" || elev.RequestedBy != "agent" || elev.ExpiresAt == nil {
+		t.Errorf("elevation = %+v", elev)
+	}
+	if r, _ := dst.GetApprovalReceipt("elev-1"); r == nil || string(r.Signature) != "sig" {
+		t.Errorf("receipt = %+v", r)
+	}
+	report, err := dst.VerifyAuditChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Entries != 3 {
+		t.Errorf("audit chain after import = %+v", report)
+	}
+}
+
+func TestKeySecret(t *testing.T) {
+	snap := &store.Snapshot{CreatedAt: time.Now()}
+	key := bytes.Repeat([]byte{7}, 32)
+	var buf bytes.Buffer
+	if err := Write(&buf, snap, Secret{Key: key}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(bytes.NewReader(buf.Bytes()), Secret{Passphrase: "x"}); err == nil {
+		t.Error("passphrase opened a key-encrypted archive")
+	}
+	if _, err := Read(bytes.NewReader(buf.Bytes()), Secret{Key: key}); err != nil {
+		t.Error(err)
+	}
+	if err := Write(&buf, snap, Secret{Key: key[:16]}); err == nil {
+		t.Error("16-byte key accepted")
+	}
+}
@@ -0,0 +1,192 @@
+// Package catalog describes the services OCM knows how to set up: the
+// fields each credential has, where OpenClaw expects them (env var or config
+// path), what a valid value looks like and sensible elevation defaults. The
+// admin UI and CLI read it from the admin API instead of each keeping a copy.
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Category groups providers in the UI.
+type Category string
+
+const (
+	CategoryChannel     Category = "channel"     // Messaging channels
+	CategoryProvider    Category = "provider"    // AI/LLM providers
+	CategoryTool        Category = "tool"        // Tool APIs
+	CategoryIntegration Category = "integration" // Other integrations
+)
+
+// CategoryInfo names a category for display.
+type CategoryInfo struct {
+	ID    Category `json:"id"`
+	Label string   `json:"label"`
+}
+
+// Categories lists the categories in display order.
+var Categories = []CategoryInfo{
+	{CategoryChannel, "Messaging Channels"},
+	{CategoryProvider, "AI/LLM Providers"},
+	{CategoryTool, "Tool APIs"},
+	{CategoryIntegration, "Integrations"},
+}
+
+// Injection is where OpenClaw expects a field's value: an env var
+// (Type "env", Var) or a config path (Type "config", Path).
+type Injection struct {
+	Type string `json:"type"`
+	Var  string `json:"var,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// Field is one value a credential for a provider needs.
+type Field struct {
+	Name        string     `json:"name"`
+	Label       string     `json:"label"`
+	Type        string     `json:"type"` // text, password or textarea
+	Placeholder string     `json:"placeholder,omitempty"`
+	Required    bool       `json:"required"`
+	HelpText    string     `json:"helpText,omitempty"`
+	Injection   *Injection `json:"injection,omitempty"`
+	Pattern     string     `json:"pattern,omitempty"` // RE2 regexp a valid value matches
+}
+
+// Check is a cheap, read-only request to the provider that succeeds only
+// with a valid value of Field. "{value}" in URL and Header is replaced with
+// the value.
+type Check struct {
+	Field   string            `json:"field"`
+	Method  string            `json:"method,omitempty"` // Default GET
+	URL     string            `json:"url"`
+	Header  map[string]string `json:"header,omitempty"`
+	OKField string            `json:"okField,omitempty"` // JSON boolean that must be true, for APIs that report errors with a 200
+	Invalid []int             `json:"invalid,omitempty"` // Statuses that mean the value was rejected (default 401 and 403)
+}
+
+// ElevationDefaults are the suggested access settings for a provider.
+type ElevationDefaults struct {
+	ReadOnly   bool   `json:"readOnly"`             // API reads only; no elevation needed
+	DefaultTTL string `json:"defaultTTL,omitempty"` // Suggested elevation TTL
+}
+
+// Provider is a service template.
+type Provider struct {
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	Category          Category          `json:"category"`
+	Description       string            `json:"description"`
+	CredentialType    string            `json:"credentialType"`          // Suggested credential type
+	ModelProvider     bool              `json:"modelProvider,omitempty"` // Provides LLM API keys
+	DocsURL           string            `json:"docsUrl,omitempty"`
+	SetupInstructions string            `json:"setupInstructions,omitempty"`
+	Fields            []Field           `json:"fields"`
+	Elevation         ElevationDefaults `json:"elevationConfig"`
+	Check             *Check            `json:"check,omitempty"` // How to check a stored credential still works
+}
+
+// EnvField returns the field OpenClaw reads from env var name.
+func (p Provider) EnvField(name string) (Field, bool) {
+	for _, f := range p.Fields {
+		if f.Injection != nil && f.Injection.Type == "env" && f.Injection.Var == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Field returns the field called name.
+func (p Provider) Field(name string) (Field, bool) {
+	for _, f := range p.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// patterns holds the compiled field patterns, keyed by pattern source.
+var patterns = map[string]*regexp.Regexp{}
+
+func init() {
+	fields := [][]Field{}
+	for _, p := range providers {
+		fields = append(fields, p.Fields)
+	}
+	for _, fs := range typeFields {
+		fields = append(fields, fs)
+	}
+	for _, fs := range fields {
+		for _, f := range fs {
+			if f.Pattern != "" {
+				patterns[f.Pattern] = regexp.MustCompile(f.Pattern)
+			}
+		}
+	}
+}
+
+// Validate checks value against the field's pattern. Empty values and
+// fields without a pattern always pass; whether a field is required is up
+// to the caller.
+func (f Field) Validate(value string) error {
+	if value == "" || f.Pattern == "" {
+		return nil
+	}
+	re, ok := patterns[f.Pattern]
+	if !ok {
+		// A field from another OCM's catalog, e.g. decoded by the CLI
+		var err error
+		if re, err = regexp.Compile(f.Pattern); err != nil {
+			return nil
+		}
+	}
+	if !re.MatchString(value) {
+		if f.Placeholder != "" {
+			return fmt.Errorf("%s doesn't look right (expected %s)", f.Label, f.Placeholder)
+		}
+		return fmt.Errorf("%s doesn't look right", f.Label)
+	}
+	return nil
+}
+
+// Providers returns every provider, grouped by category in display order.
+func Providers() []Provider {
+	out := make([]Provider, 0, len(providers))
+	for _, c := range Categories {
+		out = append(out, ByCategory(c.ID)...)
+	}
+	return out
+}
+
+// ByCategory returns the providers in category c.
+func ByCategory(c Category) []Provider {
+	var out []Provider
+	for _, p := range providers {
+		if p.Category == c {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Lookup returns the provider with the given ID.
+func Lookup(id string) (Provider, bool) {
+	for _, p := range providers {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Provider{}, false
+}
+
+// ModelProviders returns the IDs of the providers that supply LLM API keys.
+func ModelProviders() []string {
+	var ids []string
+	for _, p := range providers {
+		if p.ModelProvider {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProviders(t *testing.T) {
+	ids := map[string]bool{}
+	for _, p := range Providers() {
+		if ids[p.ID] {
+			t.Errorf("duplicate provider %q", p.ID)
+		}
+		ids[p.ID] = true
+		if len(p.Fields) == 0 {
+			t.Errorf("%s: no fields", p.ID)
+		}
+		for _, f := range p.Fields {
+			if f.Injection == nil || f.Injection.Var == "" && f.Injection.Path == "" {
+				t.Errorf("%s.%s: no injection target", p.ID, f.Name)
+			}
+		}
+		if c := p.Check; c != nil {
+			if _, ok := p.Field(c.Field); !ok {
+				t.Errorf("%s: check of unknown field %q", p.ID, c.Field)
+			}
+			if !strings.Contains(c.URL, "{value}") && !strings.Contains(fmt.Sprint(c.Header), "{value}") {
+				t.Errorf("%s: check doesn't send the value", p.ID)
+			}
+		}
+	}
+	if len(ids) != len(providers) {
+		t.Errorf("Providers() returned %d providers, want %d; is a category missing from Categories?", len(ids), len(providers))
+	}
+	for _, id := range ModelProviders() {
+		if p, _ := Lookup(id); p.Category != CategoryProvider {
+			t.Errorf("model provider %s is in category %q", id, p.Category)
+		}
+	}
+}
+
+func TestFieldValidate(t *testing.T) {
+	slack, ok := Lookup("slack")
+	if !ok {
+		t.Fatal("slack not in catalog")
+	}
+	bot, ok := slack.EnvField("SLACK_BOT_TOKEN")
+	if !ok {
+		t.Fatal("SLACK_BOT_TOKEN not a slack field")
+	}
+	if err := bot.Validate("xoxb-123"); err != nil {
+		t.Errorf("Validate(xoxb-123) = %v", err)
+	}
+	if err := bot.Validate(""); err != nil {
+		t.Errorf("Validate(\"\") = %v, want nil", err)
+	}
+	if err := bot.Validate("xoxp-123"); err == nil || !strings.Contains(err.Error(), "xoxb-") {
+		t.Errorf("Validate(xoxp-123) = %v, want error naming the expected form", err)
+	}
+
+	// Every token form each service issues
+	for _, tc := range []struct {
+		service, field string
+		values         []string
+	}{
+		{"github", "token", []string{"ghp_1", "gho_1", "ghu_1", "ghs_1", "ghr_1", "github_pat_1"}},
+		{"slack", "userToken", []string{"xoxp-1", "xoxc-1"}},
+		{"slack-personal", "userToken", []string{"xoxp-1", "xoxc-1"}},
+	} {
+		p, ok := Lookup(tc.service)
+		if !ok {
+			t.Fatalf("%s not in catalog", tc.service)
+		}
+		f, ok := p.Field(tc.field)
+		if !ok {
+			t.Fatalf("%s has no %s field", tc.service, tc.field)
+		}
+		for _, v := range tc.values {
+			if err := f.Validate(v); err != nil {
+				t.Errorf("%s %s: Validate(%s) = %v", tc.service, tc.field, v, err)
+			}
+		}
+		if f.Validate("ghx_1") == nil || f.Validate("xoxb-1") == nil {
+			t.Errorf("%s %s accepts another form", tc.service, tc.field)
+		}
+	}
+
+	// Fields decoded from elsewhere compile their pattern on demand
+	f := Field{Label: "Key", Pattern: `^k-`}
+	if f.Validate("k-1") != nil || f.Validate("x") == nil {
+		t.Error("uncached pattern not applied")
+	}
+}
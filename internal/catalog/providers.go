@@ -0,0 +1,346 @@
+package catalog
+
+import "fmt"
+
+// env and config build a field's injection target.
+func env(name string) *Injection    { return &Injection{Type: "env", Var: name} }
+func config(path string) *Injection { return &Injection{Type: "config", Path: path} }
+
+// Google OAuth needs a Google Cloud project with OAuth credentials. That's
+// straightforward for personal accounts but often blocked for work accounts
+// (an IT admin has to provision OAuth clients or approve the app).
+const gogSetup = `⚠️ Requires Google Cloud Console access (often blocked for work accounts)
+
+Personal account:
+1. Create project at console.cloud.google.com
+2. Enable the %s API, create OAuth "Desktop app" credentials
+3. Download client_secret.json
+
+Then use gogcli:
+  brew install steipete/tap/gogcli
+  gog auth credentials ~/Downloads/client_secret.json
+  gog auth add you@gmail.com%s
+
+Work/Google Workspace:
+  Ask IT admin to provision OAuth credentials or approve the app`
+
+// googleOAuthFields are the fields of a gogcli OAuth account, injected as
+// env vars starting with prefix.
+func googleOAuthFields(prefix, accountLabel string) []Field {
+	return []Field{
+		{Name: "account", Label: accountLabel, Type: "text", Placeholder: "you@gmail.com", Required: true,
+			HelpText: "The account to use", Injection: env(prefix + "_ACCOUNT"), Pattern: `^[^@\s]+@[^@\s]+$`},
+		{Name: "accessToken", Label: "Access Token", Type: "password", Required: true,
+			HelpText: "From: cat ~/.config/gog/accounts/you@gmail.com.json", Injection: env(prefix + "_ACCESS_TOKEN")},
+		{Name: "refreshToken", Label: "Refresh Token", Type: "password", Required: true,
+			HelpText: "From the same gog account JSON file", Injection: env(prefix + "_REFRESH_TOKEN")},
+		{Name: "clientId", Label: "Client ID", Type: "text", Required: true,
+			HelpText: "From your OAuth client_secret.json", Injection: env(prefix + "_CLIENT_ID")},
+		{Name: "clientSecret", Label: "Client Secret", Type: "password", Required: true,
+			HelpText: "From your OAuth client_secret.json", Injection: env(prefix + "_CLIENT_SECRET")},
+	}
+}
+
+// apiKey is the single field of a provider that only needs an API key.
+func apiKey(envVar, placeholder, pattern, help string) []Field {
+	return []Field{{Name: "apiKey", Label: "API Key", Type: "password", Placeholder: placeholder,
+		Required: true, HelpText: help, Injection: env(envVar), Pattern: pattern}}
+}
+
+// bearer checks field by GETting url with it as a bearer token.
+func bearer(field, url string) *Check {
+	return &Check{Field: field, URL: url, Header: map[string]string{"Authorization": "Bearer {value}"}}
+}
+
+var (
+	readOnly = ElevationDefaults{ReadOnly: true}
+	ttl1h    = ElevationDefaults{DefaultTTL: "1h"}
+	ttl4h    = ElevationDefaults{DefaultTTL: "4h"}
+	ttl24h   = ElevationDefaults{DefaultTTL: "24h"}
+)
+
+var providers = []Provider{
+	// Messaging channels
+	{
+		ID: "discord", Name: "Discord", Category: CategoryChannel, CredentialType: "token",
+		Description: "Discord bot for DMs and server channels",
+		DocsURL:     "https://docs.openclaw.ai/channels/discord",
+		Fields: []Field{
+			{Name: "token", Label: "Bot Token", Type: "password", Placeholder: "MTIz...", Required: true,
+				HelpText: "From Discord Developer Portal → Bot → Token", Injection: env("DISCORD_BOT_TOKEN"),
+				Pattern: `^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`},
+		},
+		Elevation: ttl24h,
+		Check:     &Check{Field: "token", URL: "https://discord.com/api/v10/users/@me", Header: map[string]string{"Authorization": "Bot {value}"}},
+	},
+	{
+		ID: "telegram", Name: "Telegram", Category: CategoryChannel, CredentialType: "token",
+		Description: "Telegram bot via BotFather",
+		DocsURL:     "https://docs.openclaw.ai/channels/telegram",
+		Fields: []Field{
+			{Name: "botToken", Label: "Bot Token", Type: "password", Placeholder: "123456:ABC-DEF...", Required: true,
+				HelpText: "From @BotFather on Telegram", Injection: env("TELEGRAM_BOT_TOKEN"),
+				Pattern: `^[0-9]+:[A-Za-z0-9_-]+$`},
+		},
+		Elevation: ttl24h,
+		Check:     &Check{Field: "botToken", URL: "https://api.telegram.org/bot{value}/getMe"},
+	},
+	{
+		ID: "slack", Name: "Slack (Bot App)", Category: CategoryChannel, CredentialType: "token",
+		Description: "Slack bot with socket mode",
+		DocsURL:     "https://docs.openclaw.ai/channels/slack",
+		SetupInstructions: `1. Go to api.slack.com/apps → Create New App → From scratch
+2. Enable Socket Mode (left sidebar) → toggle ON
+3. Basic Information → App-Level Tokens → Generate Token
+   - Add scope: connections:write
+   - Copy the App Token (xapp-...)
+4. OAuth & Permissions → Add bot scopes:
+   - channels:history, channels:read, chat:write
+   - reactions:read, reactions:write, users:read
+   - (add more as needed from the docs)
+5. Install to Workspace → Copy Bot Token (xoxb-...)
+6. Optional: Add User Token Scopes for expanded read access
+   - Reinstall app → Copy User Token (xoxp-...)
+7. Event Subscriptions → Enable → Subscribe to:
+   - message.channels, message.groups, message.im
+   - app_mention, reaction_added
+8. App Home → Enable Messages Tab for DMs`,
+		Fields: []Field{
+			{Name: "appToken", Label: "App Token", Type: "password", Placeholder: "xapp-1-...", Required: true,
+				HelpText: "Basic Information → App-Level Tokens (connections:write scope)", Injection: env("SLACK_APP_TOKEN"),
+				Pattern: `^xapp-`},
+			{Name: "botToken", Label: "Bot Token", Type: "password", Placeholder: "xoxb-...", Required: true,
+				HelpText: "OAuth & Permissions → Bot User OAuth Token", Injection: env("SLACK_BOT_TOKEN"),
+				Pattern: `^xoxb-`},
+			// The user token goes to the config file, not an env var
+			{Name: "userToken", Label: "User Token (optional)", Type: "password", Placeholder: "xoxp-... or xoxc-...",
+				HelpText:  "OAuth & Permissions → User OAuth Token (for reading your messages)",
+				Injection: config("channels.slack.userToken"), Pattern: `^xox[pc]-`},
+		},
+		Elevation: ttl24h,
+		Check:     &Check{Field: "botToken", Method: "POST", URL: "https://slack.com/api/auth.test", Header: map[string]string{"Authorization": "Bearer {value}"}, OKField: "ok"},
+	},
+	{
+		ID: "google-chat", Name: "Google Chat", Category: CategoryChannel, CredentialType: "token",
+		Description: "Google Chat app via service account",
+		DocsURL:     "https://docs.openclaw.ai/channels/googlechat",
+		Fields: []Field{
+			{Name: "serviceAccountFile", Label: "Service Account JSON Path", Type: "text",
+				Placeholder: "~/.openclaw/googlechat-service-account.json", Required: true,
+				HelpText:  "Path to the downloaded service account JSON file",
+				Injection: env("GOOGLE_CHAT_SERVICE_ACCOUNT_FILE"), Pattern: `\.json$`},
+		},
+		Elevation: ttl24h,
+	},
+
+	// AI/LLM providers. API calls only, so no elevation is needed.
+	{
+		ID: "openrouter", Name: "OpenRouter", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "Access to multiple LLM providers via OpenRouter",
+		DocsURL:     "https://openrouter.ai/docs",
+		Fields:      apiKey("OPENROUTER_API_KEY", "sk-or-...", `^sk-or-`, ""),
+		Elevation:   readOnly,
+		Check:       bearer("apiKey", "https://openrouter.ai/api/v1/key"),
+	},
+	{
+		ID: "anthropic", Name: "Anthropic", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "Direct access to Claude models",
+		DocsURL:     "https://console.anthropic.com",
+		SetupInstructions: `Option 1: API Key (recommended for API access)
+1. Go to console.anthropic.com → API Keys
+2. Create a new key and copy it (sk-ant-...)
+
+Option 2: Claude Code Token (for Claude Pro/Max subscribers)
+If you have a Claude subscription and Claude Code CLI installed:
+1. Run: claude setup-token
+2. Follow the prompts to authenticate
+3. Copy the token that's generated
+
+The setup-token is long-lived and works with your subscription.
+Use an API key if you need standard API access with usage-based billing.`,
+		Fields: []Field{
+			{Name: "apiKey", Label: "API Key or Setup Token", Type: "password", Placeholder: "sk-ant-... or setup token",
+				Required: true, HelpText: "API key from console.anthropic.com OR token from `claude setup-token`",
+				Injection: env("ANTHROPIC_API_KEY"), Pattern: `^sk-ant-`},
+		},
+		// No check: setup tokens authenticate differently from API keys
+		Elevation: readOnly,
+	},
+	{
+		ID: "openai", Name: "OpenAI", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "Access to GPT models",
+		DocsURL:     "https://platform.openai.com/docs",
+		Fields:      apiKey("OPENAI_API_KEY", "sk-...", `^sk-`, ""),
+		Elevation:   readOnly,
+		Check:       bearer("apiKey", "https://api.openai.com/v1/models"),
+	},
+	{
+		ID: "groq", Name: "Groq", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "Fast inference with Groq",
+		DocsURL:     "https://console.groq.com/docs",
+		Fields:      apiKey("GROQ_API_KEY", "gsk_...", `^gsk_`, ""),
+		Elevation:   readOnly,
+		Check:       bearer("apiKey", "https://api.groq.com/openai/v1/models"),
+	},
+	{
+		ID: "google", Name: "Google Gemini", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "Access to Gemini models",
+		DocsURL:     "https://ai.google.dev/gemini-api/docs",
+		Fields:      apiKey("GEMINI_API_KEY", "AIza...", `^AIza`, "From aistudio.google.com → Get API key"),
+		Elevation:   readOnly,
+		Check:       &Check{Field: "apiKey", URL: "https://generativelanguage.googleapis.com/v1beta/models", Header: map[string]string{"x-goog-api-key": "{value}"}, Invalid: []int{400, 403}},
+	},
+	{
+		ID: "azure-openai", Name: "Azure OpenAI", Category: CategoryProvider, CredentialType: "api_key", ModelProvider: true,
+		Description: "GPT models deployed in Azure",
+		DocsURL:     "https://learn.microsoft.com/azure/ai-services/openai/",
+		Fields: []Field{
+			{Name: "apiKey", Label: "API Key", Type: "password", Required: true,
+				HelpText: "Azure portal → your OpenAI resource → Keys and Endpoint", Injection: env("AZURE_OPENAI_API_KEY")},
+			{Name: "endpoint", Label: "Endpoint", Type: "text", Placeholder: "https://<resource>.openai.azure.com",
+				Required: true, Injection: env("AZURE_OPENAI_ENDPOINT"), Pattern: `^https://`},
+		},
+		Elevation: readOnly,
+	},
+
+	// Tool APIs
+	{
+		ID: "brave", Name: "Brave Search", Category: CategoryTool, CredentialType: "api_key",
+		Description: "Web search via Brave Search API",
+		DocsURL:     "https://docs.openclaw.ai/brave-search",
+		Fields:      apiKey("BRAVE_API_KEY", "BSA...", `^BSA`, "From brave.com/search/api (use Data for Search plan)"),
+		Elevation:   readOnly,
+	},
+	{
+		ID: "elevenlabs", Name: "ElevenLabs", Category: CategoryTool, CredentialType: "api_key",
+		Description: "Text-to-speech with ElevenLabs",
+		DocsURL:     "https://elevenlabs.io/docs",
+		Fields:      apiKey("ELEVENLABS_API_KEY", "", "", ""),
+		Elevation:   readOnly,
+		Check:       &Check{Field: "apiKey", URL: "https://api.elevenlabs.io/v1/user", Header: map[string]string{"xi-api-key": "{value}"}},
+	},
+	{
+		ID: "deepgram", Name: "Deepgram", Category: CategoryTool, CredentialType: "api_key",
+		Description: "Speech-to-text with Deepgram",
+		DocsURL:     "https://developers.deepgram.com",
+		Fields:      apiKey("DEEPGRAM_API_KEY", "", "", ""),
+		Elevation:   readOnly,
+		Check:       &Check{Field: "apiKey", URL: "https://api.deepgram.com/v1/projects", Header: map[string]string{"Authorization": "Token {value}"}},
+	},
+
+	// Integrations
+	{
+		ID: "slack-personal", Name: "Slack (Personal Token)", Category: CategoryIntegration, CredentialType: "token",
+		Description: "Long-lived token to access Slack as yourself",
+		DocsURL:     "https://docs.openclaw.ai/channels/slack",
+		SetupInstructions: `═══════════════════════════════════════════════════
+OPTION 1: OAuth App (Recommended - Never Expires)
+═══════════════════════════════════════════════════
+1. Go to api.slack.com/apps → Create New App → From scratch
+2. Name it anything (e.g., "My Personal Access")
+3. OAuth & Permissions → User Token Scopes → Add:
+   - channels:history, channels:read
+   - groups:history, groups:read
+   - im:history, im:read
+   - mpim:history, mpim:read
+   - users:read, search:read, files:read
+4. Install to Workspace
+5. Copy User OAuth Token → paste below
+
+   ✅ Just need: User Token (xoxp-...)
+   ❌ Cookie: Not needed
+
+═══════════════════════════════════════════════════
+OPTION 2: Browser Token (Quick but Expires on Logout)
+═══════════════════════════════════════════════════
+⚠️  REQUIRES BOTH TOKEN AND COOKIE
+
+Open Slack in browser → DevTools (F12):
+
+1. GET THE TOKEN:
+   Console tab → paste this:
+   JSON.parse(localStorage.localConfig_v2).teams[Object.keys(JSON.parse(localStorage.localConfig_v2).teams)[0]].token
+
+   Copy the result (starts with xoxc-...)
+
+2. GET THE COOKIE:
+   Application tab → Cookies → slack.com
+   Find the "d" cookie → copy its value (starts with xoxd-...)
+
+   ✅ Need BOTH: User Token (xoxc-...) AND Cookie (xoxd-...)`,
+		Fields: []Field{
+			{Name: "userToken", Label: "User Token", Type: "password", Placeholder: "xoxp-... or xoxc-...", Required: true,
+				HelpText:  "xoxp- from OAuth app, or xoxc- from browser (requires cookie below)",
+				Injection: config("channels.slack.userToken"), Pattern: `^xox[pc]-`},
+			{Name: "cookie", Label: "Cookie (required for xoxc- tokens)", Type: "password", Placeholder: "xoxd-...",
+				HelpText:  `Only needed for browser tokens (xoxc-). The "d" cookie value from slack.com`,
+				Injection: config("channels.slack.cookie"), Pattern: `^xoxd-`},
+		},
+		Elevation: ttl4h,
+	},
+	{
+		ID: "gmail", Name: "Gmail", Category: CategoryIntegration, CredentialType: "oauth2",
+		Description:       "Gmail read/send via Google OAuth (requires Google Cloud setup)",
+		DocsURL:           "https://docs.openclaw.ai/automation/gmail-pubsub",
+		SetupInstructions: fmt.Sprintf(gogSetup, "Gmail", ""),
+		Fields:            googleOAuthFields("GMAIL", "Gmail Account"),
+		Elevation:         ttl1h,
+	},
+	{
+		ID: "google-calendar", Name: "Google Calendar", Category: CategoryIntegration, CredentialType: "oauth2",
+		Description:       "Calendar access via Google OAuth (requires Google Cloud setup)",
+		DocsURL:           "https://gogcli.sh",
+		SetupInstructions: fmt.Sprintf(gogSetup, "Calendar", " --services calendar"),
+		Fields:            googleOAuthFields("GOOGLE_CALENDAR", "Google Account"),
+		Elevation:         readOnly,
+	},
+	{
+		ID: "linear", Name: "Linear", Category: CategoryIntegration, CredentialType: "api_key",
+		Description: "Issue tracking with Linear",
+		DocsURL:     "https://linear.app/docs",
+		Fields:      apiKey("LINEAR_API_KEY", "lin_api_...", `^lin_api_`, "From Linear Settings → API → Personal API Keys"),
+		Elevation:   ttl1h, // Creating issues needs approval
+	},
+	{
+		ID: "github", Name: "GitHub", Category: CategoryIntegration, CredentialType: "pat",
+		Description: "GitHub API access",
+		DocsURL:     "https://docs.github.com/en/rest",
+		Fields: []Field{
+			{Name: "token", Label: "Personal Access Token", Type: "password", Placeholder: "ghp_...", Required: true,
+				HelpText:  "From GitHub Settings → Developer settings → Personal access tokens",
+				Injection: env("GITHUB_TOKEN"), Pattern: `^(gh[pousr]_|github_pat_)`},
+		},
+		Elevation: ttl1h,
+		Check:     bearer("token", "https://api.github.com/user"),
+	},
+	{
+		ID: "twitter", Name: "Twitter / X", Category: CategoryIntegration, CredentialType: "token",
+		Description: "Twitter API access",
+		DocsURL:     "https://developer.twitter.com/en/docs",
+		Fields: []Field{
+			{Name: "bearerToken", Label: "Bearer Token", Type: "password",
+				HelpText: "For read-only API access (v2 API)", Injection: env("TWITTER_BEARER_TOKEN")},
+			{Name: "apiKey", Label: "API Key", Type: "password",
+				HelpText: "Consumer key for OAuth 1.0a", Injection: env("TWITTER_API_KEY")},
+			{Name: "apiSecret", Label: "API Secret", Type: "password",
+				HelpText: "Consumer secret for OAuth 1.0a", Injection: env("TWITTER_API_SECRET")},
+			{Name: "accessToken", Label: "Access Token", Type: "password",
+				HelpText: "User access token for posting", Injection: env("TWITTER_ACCESS_TOKEN")},
+			{Name: "accessSecret", Label: "Access Token Secret", Type: "password",
+				HelpText: "User access token secret", Injection: env("TWITTER_ACCESS_SECRET")},
+		},
+		Elevation: ttl1h,
+	},
+	{
+		ID: "notion", Name: "Notion", Category: CategoryIntegration, CredentialType: "api_key",
+		Description: "Notion API access",
+		DocsURL:     "https://developers.notion.com",
+		Fields: []Field{
+			{Name: "apiKey", Label: "Integration Token", Type: "password", Placeholder: "secret_...", Required: true,
+				HelpText:  "From Notion Settings → Integrations → Develop your own",
+				Injection: env("NOTION_API_KEY"), Pattern: `^(secret_|ntn_)`},
+		},
+		Elevation: ttl1h,
+		Check:     &Check{Field: "apiKey", URL: "https://api.notion.com/v1/users/me", Header: map[string]string{"Authorization": "Bearer {value}", "Notion-Version": "2022-06-28"}},
+	},
+}
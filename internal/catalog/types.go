@@ -0,0 +1,28 @@
+package catalog
+
+// typeFields are the fields of credential types whose values have a fixed
+// format whatever service they're stored under: the types with a built-in
+// rotator.
+var typeFields = map[string][]Field{
+	"aws_access_key": {
+		{Name: "secretAccessKey", Label: "AWS Secret Access Key", Type: "password", Placeholder: "40 characters",
+			Injection: env("AWS_SECRET_ACCESS_KEY"), Pattern: `^[A-Za-z0-9/+]{40}$`},
+		{Name: "accessKeyId", Label: "AWS Access Key ID", Type: "text", Placeholder: "AKIA... (20 characters)",
+			Injection: env("AWS_ACCESS_KEY_ID"), Pattern: `^(AKIA|ASIA)[A-Z0-9]{16}$`},
+	},
+	"github_user_token": {
+		{Name: "token", Label: "GitHub User Access Token", Type: "password", Placeholder: "ghu_...",
+			Injection: env("GITHUB_TOKEN"), Pattern: `^ghu_`},
+	},
+	"slack_app_token": {
+		{Name: "token", Label: "Slack Rotating Token", Type: "password", Placeholder: "xoxe.xoxb-... or xoxe.xoxp-...",
+			Pattern: `^xoxe\.xox[bp]-`},
+	},
+}
+
+// TypeFields returns the fields of credential type t, if its values have a
+// fixed format.
+func TypeFields(t string) ([]Field, bool) {
+	fields, ok := typeFields[t]
+	return fields, ok
+}
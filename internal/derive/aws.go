@@ -0,0 +1,85 @@
+package derive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/sigv4"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// STS session lengths AssumeRole accepts.
+const (
+	minSTSDuration = 15 * time.Minute
+	maxSTSDuration = 12 * time.Hour
+)
+
+// mintAWSSTS assumes d.RoleARN with the stored access key. The minted token
+// is the temporary secret access key, in the level's own env var; the
+// temporary key ID and session token come with it as AWS_ACCESS_KEY_ID and
+// AWS_SESSION_TOKEN.
+func mintAWSSTS(ctx context.Context, d *store.Derivation, secretKey string) (*Token, error) {
+	region := firstNonEmpty(d.Region, "us-east-1")
+	endpoint := d.Endpoint
+	if endpoint == "" {
+		endpoint = "https://sts." + region + ".amazonaws.com"
+	}
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	ttl = min(max(ttl, minSTSDuration), maxSTSDuration)
+
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {d.RoleARN},
+		"RoleSessionName": {"ocm-" + strconv.FormatInt(time.Now().Unix(), 10)},
+		"DurationSeconds": {strconv.Itoa(int(ttl.Seconds()))},
+	}
+	payload := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sum := sha256.Sum256([]byte(payload))
+	sigv4.Sign(req, hex.EncodeToString(sum[:]), region, "sts", d.AccessKeyID, secretKey, time.Now())
+
+	body, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse AssumeRole response: %w", err)
+	}
+	c := resp.Credentials
+	if c.SecretAccessKey == "" || c.SessionToken == "" {
+		return nil, errors.New("no credentials in AssumeRole response")
+	}
+	return &Token{
+		Value:     c.SecretAccessKey,
+		ExpiresAt: c.Expiration,
+		Env: map[string]string{
+			"AWS_ACCESS_KEY_ID": c.AccessKeyID,
+			"AWS_SESSION_TOKEN": c.SessionToken,
+		},
+	}, nil
+}
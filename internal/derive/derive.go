@@ -0,0 +1,176 @@
+// Package derive mints short-lived tokens from long-lived secrets: GitHub
+// App installation tokens, AWS STS session credentials and OAuth access
+// tokens from refresh tokens. Agents are handed the minted token, so the
+// stored secret never leaves OCM.
+package derive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// DefaultTTL is the lifetime asked for when a derivation doesn't set one,
+// and assumed when a provider doesn't say.
+const DefaultTTL = 15 * time.Minute
+
+// reuseMargin is how long a minted token must still be valid to be handed
+// out again rather than minting another.
+const reuseMargin = 5 * time.Minute
+
+// Token is a minted short-lived credential.
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+	// Env holds other env vars the token is used with, e.g. the temporary
+	// AWS_ACCESS_KEY_ID and AWS_SESSION_TOKEN
+	Env map[string]string
+	// RefreshToken is set when an OAuth provider rotated the refresh token;
+	// it replaces the stored one
+	RefreshToken string
+}
+
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// cache holds minted tokens by derivation and secret, so agents asking
+// repeatedly don't each mint one (GitHub rate limits installation tokens).
+var cache = struct {
+	sync.Mutex
+	tokens map[string]*Token
+}{tokens: make(map[string]*Token)}
+
+// Validate checks that level's derivation has what its kind needs.
+func Validate(level *store.AccessLevel) error {
+	d := level.Derive
+	if d == nil {
+		return nil
+	}
+	var missing []string
+	need := func(name, value string) {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	switch d.Kind {
+	case store.DeriveGitHubApp:
+		need("appId", d.AppID)
+		need("installationId", d.InstallationID)
+		need("token (the app's private key)", level.Token)
+	case store.DeriveAWSSTS:
+		need("accessKeyId", d.AccessKeyID)
+		need("roleArn", d.RoleARN)
+		need("token (the secret access key)", level.Token)
+	case store.DeriveOAuthRefresh:
+		need("tokenUrl", d.TokenURL)
+		need("refreshToken", level.RefreshToken)
+	default:
+		return fmt.Errorf("unknown derive kind %q (want github_app, aws_sts or oauth_refresh)", d.Kind)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("derive %s needs %s", d.Kind, strings.Join(missing, ", "))
+	}
+	if d.Endpoint != "" {
+		if u, err := url.Parse(d.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid derive endpoint %q", d.Endpoint)
+		}
+	}
+	if d.TTL < 0 {
+		return errors.New("derive ttl must be positive")
+	}
+	return nil
+}
+
+// Mint returns a short-lived token for level, which must have a Derive
+// configuration. A token minted earlier is reused while it has at least a
+// few minutes left.
+func Mint(ctx context.Context, level *store.AccessLevel) (*Token, error) {
+	if level.Derive == nil {
+		return nil, errors.New("access level has no derivation")
+	}
+	if err := Validate(level); err != nil {
+		return nil, err
+	}
+	key := cacheKey(level.Derive, secretOf(level))
+	cache.Lock()
+	tok := cache.tokens[key]
+	cache.Unlock()
+	if tok != nil && time.Until(tok.ExpiresAt) > reuseMargin {
+		return tok, nil
+	}
+
+	var err error
+	switch level.Derive.Kind {
+	case store.DeriveGitHubApp:
+		tok, err = mintGitHubApp(ctx, level.Derive, level.Token)
+	case store.DeriveAWSSTS:
+		tok, err = mintAWSSTS(ctx, level.Derive, level.Token)
+	case store.DeriveOAuthRefresh:
+		tok, err = mintOAuthRefresh(ctx, level.Derive, level.RefreshToken)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mint %s token: %w", level.Derive.Kind, err)
+	}
+
+	// Once a rotated refresh token is stored, requests carry it instead
+	secret := secretOf(level)
+	if tok.RefreshToken != "" {
+		secret = tok.RefreshToken
+	}
+	cached := *tok
+	cached.RefreshToken = ""
+	cache.Lock()
+	for k, t := range cache.tokens {
+		if time.Now().After(t.ExpiresAt) {
+			delete(cache.tokens, k)
+		}
+	}
+	cache.tokens[cacheKey(level.Derive, secret)] = &cached
+	cache.Unlock()
+	return tok, nil
+}
+
+// secretOf is the stored secret level's tokens are minted from.
+func secretOf(level *store.AccessLevel) string {
+	if level.Derive.Kind == store.DeriveOAuthRefresh {
+		return level.RefreshToken
+	}
+	return level.Token
+}
+
+func cacheKey(d *store.Derivation, secret string) string {
+	config, _ := json.Marshal(d)
+	sum := sha256.Sum256(append(append(config, 0), secret...))
+	return hex.EncodeToString(sum[:])
+}
+
+// send sends req and returns the body of a 2xx response, or an error
+// quoting the start of any other.
+func send(req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		if len(body) > 200 {
+			body = body[:200]
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
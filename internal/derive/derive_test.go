@@ -0,0 +1,181 @@
+package derive
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		level store.AccessLevel
+		want  string // Error substring; "" for valid
+	}{
+		{"github app", store.AccessLevel{Token: "pem", Derive: &store.Derivation{Kind: store.DeriveGitHubApp, AppID: "1", InstallationID: "2"}}, ""},
+		{"github app without installation", store.AccessLevel{Token: "pem", Derive: &store.Derivation{Kind: store.DeriveGitHubApp, AppID: "1"}}, "installationId"},
+		{"sts without key", store.AccessLevel{Derive: &store.Derivation{Kind: store.DeriveAWSSTS, AccessKeyID: "AKIA", RoleARN: "arn"}}, "secret access key"},
+		{"oauth without refresh token", store.AccessLevel{Derive: &store.Derivation{Kind: store.DeriveOAuthRefresh, TokenURL: "https://example.com/token"}}, "refreshToken"},
+		{"bad endpoint", store.AccessLevel{RefreshToken: "r", Derive: &store.Derivation{Kind: store.DeriveOAuthRefresh, TokenURL: "t", Endpoint: "ftp://x"}}, "endpoint"},
+		{"unknown kind", store.AccessLevel{Derive: &store.Derivation{Kind: "vault"}}, "unknown derive kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.level)
+			if tt.want == "" && err != nil {
+				t.Errorf("Validate = %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("Validate = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMintGitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int32
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			http.Error(w, "bad JWT", http.StatusUnauthorized)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig) != nil {
+			http.Error(w, "bad JWT", http.StatusUnauthorized)
+			return
+		}
+		var claims struct {
+			Iss string `json:"iss"`
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		json.Unmarshal(payload, &claims)
+		if claims.Iss != "1234" {
+			http.Error(w, "wrong app", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_minted","expires_at":%q}`, expires.Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	level := &store.AccessLevel{Token: string(pemKey), Derive: &store.Derivation{
+		Kind: store.DeriveGitHubApp, AppID: "1234", InstallationID: "42", Endpoint: srv.URL,
+	}}
+	tok, err := Mint(context.Background(), level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Value != "ghs_minted" || !tok.ExpiresAt.Equal(expires) {
+		t.Errorf("token = %+v", tok)
+	}
+
+	// Still valid for long enough: reused
+	if _, err := Mint(context.Background(), level); err != nil || calls.Load() != 1 {
+		t.Errorf("second mint: err = %v, calls = %d, want the cached token", err, calls.Load())
+	}
+}
+
+func TestMintAWSSTS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIALONGLIVED/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/sts/aws4_request") {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+		if r.Form.Get("Action") != "AssumeRole" || r.Form.Get("RoleArn") != "arn:aws:iam::123:role/agent" || r.Form.Get("DurationSeconds") != "900" {
+			http.Error(w, "bad request: "+r.Form.Encode(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIATEMP</AccessKeyId>
+      <SecretAccessKey>temp-secret</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`)
+	}))
+	defer srv.Close()
+
+	tok, err := Mint(context.Background(), &store.AccessLevel{Token: "long-lived-secret", Derive: &store.Derivation{
+		Kind: store.DeriveAWSSTS, AccessKeyID: "AKIALONGLIVED", RoleARN: "arn:aws:iam::123:role/agent", Region: "eu-west-1", Endpoint: srv.URL,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Value != "temp-secret" || tok.Env["AWS_ACCESS_KEY_ID"] != "ASIATEMP" || tok.Env["AWS_SESSION_TOKEN"] != "session-token" {
+		t.Errorf("token = %+v", tok)
+	}
+	if !tok.ExpiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expiry = %v", tok.ExpiresAt)
+	}
+}
+
+func TestMintOAuthRefresh(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "shh" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.Form.Get("refresh_token") {
+		case "refresh-1":
+			fmt.Fprint(w, `{"access_token":"access-1","expires_in":3600,"refresh_token":"refresh-2"}`)
+		default:
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	derivation := &store.Derivation{Kind: store.DeriveOAuthRefresh, TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "shh"}
+	tok, err := Mint(context.Background(), &store.AccessLevel{RefreshToken: "refresh-1", Derive: derivation})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Value != "access-1" || tok.RefreshToken != "refresh-2" || time.Until(tok.ExpiresAt) < 59*time.Minute {
+		t.Errorf("token = %+v, want access-1 with the rotated refresh token", tok)
+	}
+
+	// Once the rotated refresh token is stored, the minted token is reused
+	tok, err = Mint(context.Background(), &store.AccessLevel{RefreshToken: "refresh-2", Derive: derivation})
+	if err != nil || tok.Value != "access-1" || tok.RefreshToken != "" || calls.Load() != 1 {
+		t.Errorf("after rotation = %+v, %v (calls %d), want the cached token", tok, err, calls.Load())
+	}
+
+	if _, err := Mint(context.Background(), &store.AccessLevel{RefreshToken: "revoked", Derive: derivation}); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("revoked refresh token: err = %v", err)
+	}
+}
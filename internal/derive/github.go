@@ -0,0 +1,112 @@
+package derive
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// mintGitHubApp exchanges a JWT signed with the app's private key for an
+// installation access token, which GitHub makes valid for an hour.
+func mintGitHubApp(ctx context.Context, d *store.Derivation, privateKey string) (*Token, error) {
+	key, err := parseRSAKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// Backdated for clock skew; GitHub refuses JWTs valid for over 10 minutes
+	now := time.Now()
+	jwt, err := signJWT(key, map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": d.AppID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimRight(firstNonEmpty(d.Endpoint, "https://api.github.com"), "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		endpoint+"/app/installations/"+url.PathEscape(d.InstallationID)+"/access_tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	body, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Token == "" {
+		return nil, errors.New("no token in response")
+	}
+	return &Token{Value: resp.Token, ExpiresAt: resp.ExpiresAt}, nil
+}
+
+// parseRSAKey parses a PEM private key in PKCS #1 or PKCS #8 form, as
+// GitHub issues and as converted by openssl respectively.
+func parseRSAKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("private key is not PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA")
+	}
+	return key, nil
+}
+
+// signJWT returns an RS256 JWT with claims.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
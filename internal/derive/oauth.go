@@ -0,0 +1,59 @@
+package derive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// mintOAuthRefresh exchanges the stored refresh token for an access token
+// (RFC 6749 section 6). Providers that rotate refresh tokens return a new
+// one, which the caller must store.
+func mintOAuthRefresh(ctx context.Context, d *store.Derivation, refreshToken string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if d.ClientID != "" {
+		form.Set("client_id", d.ClientID)
+	}
+	if d.ClientSecret != "" {
+		form.Set("client_secret", d.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	body, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("no access_token in response")
+	}
+	lifetime := DefaultTTL
+	if resp.ExpiresIn > 0 {
+		lifetime = time.Duration(resp.ExpiresIn) * time.Second
+	}
+	tok := &Token{Value: resp.AccessToken, ExpiresAt: time.Now().Add(lifetime)}
+	if resp.RefreshToken != refreshToken {
+		tok.RefreshToken = resp.RefreshToken
+	}
+	return tok, nil
+}
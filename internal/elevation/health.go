@@ -0,0 +1,88 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/openclaw/ocm/internal/health"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// checkHealth is health.Check, replaced in tests.
+var checkHealth = health.Check
+
+// CheckHealth checks that service's credential still works with its
+// provider and records the result on it (see health.Check). A credential
+// whose token goes invalid is audited and published as credential.invalid.
+func (s *Service) CheckHealth(ctx context.Context, service string) (*store.CredentialHealth, error) {
+	cred, err := s.store.GetCredential(service)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrCredentialNotFound
+	}
+	return s.checkCredentialHealth(ctx, cred)
+}
+
+func (s *Service) checkCredentialHealth(ctx context.Context, cred *store.Credential) (*store.CredentialHealth, error) {
+	result, err := checkHealth(ctx, cred)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SetCredentialHealth(cred.Service, result); err != nil {
+		s.logger.Error("failed to record credential health", "service", cred.Service, "error", err)
+	}
+
+	// Alert once when a token goes invalid, not on every check after
+	wasInvalid := cred.Health != nil && cred.Health.Status == store.HealthInvalid
+	switch {
+	case result.Status == store.HealthInvalid && !wasInvalid:
+		s.logger.Warn("credential rejected by provider", "service", cred.Service, "detail", result.Detail)
+		s.store.AddAuditEntry(&store.AuditEntry{
+			ID:        generateID("audit"),
+			Timestamp: result.CheckedAt,
+			Action:    store.ActionCredentialInvalid,
+			Service:   cred.Service,
+			Details:   result.Detail,
+			Actor:     "system",
+		})
+		s.notifier.Publish(notify.Event{Type: notify.EventCredentialInvalid, Service: cred.Service, Details: result.Detail, Actor: "system"})
+	case result.Status == store.HealthOK && wasInvalid:
+		s.logger.Info("credential accepted by provider again", "service", cred.Service)
+	case result.Status == store.HealthError:
+		s.logger.Debug("credential health check inconclusive", "service", cred.Service, "detail", result.Detail)
+	}
+	return result, nil
+}
+
+// RunHealthChecks checks every credential that has a health check, now and
+// then every interval, until ctx is done.
+func (s *Service) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		creds, err := s.store.ListCredentials()
+		if err != nil {
+			s.logger.Error("failed to list credentials for health check", "error", err)
+		}
+		for _, listed := range creds {
+			// Listed credentials have their secrets cleared
+			cred, err := s.store.GetCredential(listed.Service)
+			if err != nil || cred == nil {
+				continue
+			}
+			if _, err := s.checkCredentialHealth(ctx, cred); err != nil && !errors.Is(err, health.ErrNoCheck) {
+				s.logger.Error("credential health check failed", "service", cred.Service, "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
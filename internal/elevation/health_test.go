@@ -0,0 +1,90 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/health"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestCheckHealth(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The provider accepts only the token "good"
+	checkHealth = func(ctx context.Context, cred *store.Credential) (*store.CredentialHealth, error) {
+		if cred.Service != "github" {
+			return nil, health.ErrNoCheck
+		}
+		if cred.Read.Token != "good" {
+			return &store.CredentialHealth{Status: store.HealthInvalid, Detail: "read: rejected: 401 Unauthorized"}, nil
+		}
+		return &store.CredentialHealth{Status: store.HealthOK}, nil
+	}
+	t.Cleanup(func() { checkHealth = health.Check })
+
+	save := func(token string) {
+		t.Helper()
+		if err := db.SaveCredential(&store.Credential{
+			ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+			Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: token},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("good")
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-2", Service: "internal", DisplayName: "Internal", Read: &store.AccessLevel{EnvVar: "INTERNAL_KEY", Token: "k"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	ctx := context.Background()
+
+	if _, err := svc.CheckHealth(ctx, "internal"); !errors.Is(err, health.ErrNoCheck) {
+		t.Errorf("internal: err = %v, want ErrNoCheck", err)
+	}
+	if _, err := svc.CheckHealth(ctx, "missing"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("missing: err = %v, want ErrCredentialNotFound", err)
+	}
+	if result, err := svc.CheckHealth(ctx, "github"); err != nil || result.Status != store.HealthOK {
+		t.Fatalf("CheckHealth = %+v, %v", result, err)
+	}
+
+	// Revoked: recorded on the credential, audited once however often it's checked
+	save("revoked")
+	for i := 0; i < 2; i++ {
+		if _, err := svc.CheckHealth(ctx, "github"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cred, _ := db.GetCredential("github")
+	if cred.Health == nil || cred.Health.Status != store.HealthInvalid || cred.Health.CheckedAt.IsZero() {
+		t.Errorf("health = %+v, want invalid", cred.Health)
+	}
+	entries, err := db.ListAuditEntries(10, "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := 0
+	for _, e := range entries {
+		if e.Action == store.ActionCredentialInvalid {
+			invalid++
+		}
+	}
+	if invalid != 1 {
+		t.Errorf("%d credential_invalid entries, want 1", invalid)
+	}
+}
@@ -0,0 +1,272 @@
+package elevation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Leak scan surfaces.
+const (
+	SurfaceConfig = "config" // The Gateway's config, via config.get
+	SurfaceLogs   = "logs"   // Its recent log lines, via logs.tail
+)
+
+// leakLogLines is how many recent log lines a scan reads from each Gateway.
+const leakLogLines = 500
+
+// minLeakLength is the shortest secret scanned for; shorter ones would
+// match by chance.
+const minLeakLength = 8
+
+// gatewayConfig and gatewayLogs fetch a Gateway's surfaces, replaced in
+// tests.
+var (
+	gatewayConfig = (*gateway.Client).CurrentConfig
+	gatewayLogs   = (*gateway.Client).RecentLogs
+)
+
+// Leak is a stored secret found on a Gateway surface where OCM didn't put
+// it. It never contains the secret.
+type Leak struct {
+	Service  string `json:"service"`
+	Level    string `json:"level"`    // read or write
+	Secret   string `json:"secret"`   // token or refreshToken
+	Gateway  string `json:"gateway"`  // Gateway name
+	Surface  string `json:"surface"`  // config or logs
+	Location string `json:"location"` // Config path, or log line number
+}
+
+// LeakReport is the result of a leak scan.
+type LeakReport struct {
+	ScannedAt time.Time `json:"scannedAt"`
+	Leaks     []Leak    `json:"leaks"`
+	Errors    []string  `json:"errors,omitempty"` // Surfaces that couldn't be scanned
+}
+
+// describe says where l was found, for audit entries and alerts.
+func (l Leak) describe() string {
+	secret := "token"
+	if l.Secret == "refreshToken" {
+		secret = "refresh token"
+	}
+	where := "at " + l.Location
+	if l.Surface == SurfaceLogs {
+		where = "(" + l.Location + ")"
+	}
+	return fmt.Sprintf("%s %s found in %s %s on gateway %s", l.Level, secret, l.Surface, where, l.Gateway)
+}
+
+// key identifies l across scans. Log line numbers shift as the Gateway
+// logs more, so a secret in a Gateway's logs is one leak wherever it is.
+func (l Leak) key() string {
+	location := l.Location
+	if l.Surface == SurfaceLogs {
+		location = ""
+	}
+	return l.Gateway + "\x00" + l.Surface + "\x00" + l.Service + "\x00" + l.Level + "\x00" + l.Secret + "\x00" + location
+}
+
+// leakSecret is a stored secret to scan for, and the config paths on its
+// gateway where OCM injects it.
+type leakSecret struct {
+	service, level, secret, value, gateway string
+	allowed                                []string
+}
+
+// leakSecrets returns the secrets of creds worth scanning for. A level's
+// token belongs at its config path unless the level is derived, in which
+// case what's injected there is minted from it; refresh tokens are never
+// injected.
+func leakSecrets(creds []*store.Credential) []leakSecret {
+	var secrets []leakSecret
+	for _, cred := range creds {
+		gw := cred.Gateway
+		if gw == "" {
+			gw = gateway.DefaultName
+		}
+		for _, level := range []struct {
+			name   string
+			access *store.AccessLevel
+		}{{"read", cred.Read}, {"write", cred.ReadWrite}} {
+			if level.access == nil {
+				continue
+			}
+			var allowed []string
+			if level.access.GetInjectionType() == store.InjectionConfig && level.access.Derive == nil {
+				allowed = append(allowed, level.access.ConfigPath)
+			}
+			if len(level.access.Token) >= minLeakLength {
+				secrets = append(secrets, leakSecret{cred.Service, level.name, "token", level.access.Token, gw, allowed})
+			}
+			if len(level.access.RefreshToken) >= minLeakLength {
+				secrets = append(secrets, leakSecret{cred.Service, level.name, "refreshToken", level.access.RefreshToken, gw, nil})
+			}
+		}
+	}
+	return secrets
+}
+
+// findConfigLeaks returns the secrets found in cfg, gateway name's config,
+// other than where OCM injects them.
+func findConfigLeaks(secrets []leakSecret, name string, cfg map[string]interface{}) []Leak {
+	var leaks []Leak
+	for _, sec := range secrets {
+		for _, path := range gateway.FindInConfig(cfg, sec.value) {
+			if sec.gateway == name && injectedAt(sec, cfg, path) {
+				continue
+			}
+			leaks = append(leaks, Leak{Service: sec.service, Level: sec.level, Secret: sec.secret,
+				Gateway: name, Surface: SurfaceConfig, Location: path})
+		}
+	}
+	return leaks
+}
+
+// injectedAt reports whether path is where OCM injects sec, holding
+// exactly it rather than a note that quotes it.
+func injectedAt(sec leakSecret, cfg map[string]interface{}, path string) bool {
+	for _, allowed := range sec.allowed {
+		if gateway.SameConfigPath(path, allowed) {
+			v, _ := gateway.ConfigValue(cfg, path)
+			return v == sec.value
+		}
+	}
+	return false
+}
+
+// findLogLeaks returns the secrets found in lines, gateway name's recent
+// logs. A secret belongs in no log line.
+func findLogLeaks(secrets []leakSecret, name string, lines []string) []Leak {
+	var leaks []Leak
+	for _, sec := range secrets {
+		for i, line := range lines {
+			if strings.Contains(line, sec.value) {
+				leaks = append(leaks, Leak{Service: sec.service, Level: sec.level, Secret: sec.secret,
+					Gateway: name, Surface: SurfaceLogs, Location: fmt.Sprintf("line %d of the last %d", i+1, len(lines))})
+				break
+			}
+		}
+	}
+	return leaks
+}
+
+// ScanLeaks looks for stored secrets in each Gateway's config and, with
+// logs, its recent log lines, anywhere other than where OCM injects them.
+// Each leak is audited and published as credential.leaked when first
+// found, not on every scan that finds it again.
+func (s *Service) ScanLeaks(ctx context.Context, logs bool) (*LeakReport, error) {
+	listed, err := s.store.ListCredentials()
+	if err != nil {
+		return nil, err
+	}
+	var creds []*store.Credential
+	for _, l := range listed {
+		// Listed credentials have their secrets cleared
+		cred, err := s.store.GetCredential(l.Service)
+		if err != nil || cred == nil {
+			continue
+		}
+		creds = append(creds, cred)
+	}
+	secrets := leakSecrets(creds)
+
+	gateways := s.Gateways()
+	names := make([]string, 0, len(gateways))
+	for name := range gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &LeakReport{ScannedAt: time.Now().UTC(), Leaks: []Leak{}}
+	scanned := make(map[string]bool) // gateway + surface
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		gw := gateways[name]
+		if cfg, err := gatewayConfig(gw); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s config: %v", name, err))
+		} else {
+			report.Leaks = append(report.Leaks, findConfigLeaks(secrets, name, cfg)...)
+			scanned[name+"\x00"+SurfaceConfig] = true
+		}
+		if !logs {
+			continue
+		}
+		if lines, err := gatewayLogs(gw, leakLogLines); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s logs: %v", name, err))
+		} else {
+			report.Leaks = append(report.Leaks, findLogLeaks(secrets, name, lines)...)
+			scanned[name+"\x00"+SurfaceLogs] = true
+		}
+	}
+
+	s.alertLeaks(report, scanned)
+	return report, nil
+}
+
+// alertLeaks audits and publishes the leaks in report not found by the
+// previous scan. Leaks on surfaces this scan couldn't read are remembered,
+// so they aren't alerted again once the surface is back.
+func (s *Service) alertLeaks(report *LeakReport, scanned map[string]bool) {
+	s.leakMu.Lock()
+	defer s.leakMu.Unlock()
+
+	seen := make(map[string]Leak)
+	for k, l := range s.leaksSeen {
+		if !scanned[l.Gateway+"\x00"+l.Surface] {
+			seen[k] = l
+		}
+	}
+	for _, l := range report.Leaks {
+		k := l.key()
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = l
+		if _, ok := s.leaksSeen[k]; ok {
+			continue
+		}
+		details := l.describe()
+		s.logger.Warn("credential leaked", "service", l.Service, "detail", details)
+		s.store.AddAuditEntry(&store.AuditEntry{
+			ID:        generateID("audit"),
+			Timestamp: report.ScannedAt,
+			Action:    store.ActionCredentialLeaked,
+			Service:   l.Service,
+			Details:   details,
+			Actor:     "system",
+		})
+		s.notifier.Publish(notify.Event{Type: notify.EventCredentialLeaked, Service: l.Service, Details: details, Actor: "system"})
+	}
+	s.leaksSeen = seen
+}
+
+// RunLeakScans scans for leaks (see ScanLeaks), now and then every
+// interval, until ctx is done.
+func (s *Service) RunLeakScans(ctx context.Context, interval time.Duration, logs bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.ScanLeaks(ctx, logs)
+		switch {
+		case err != nil:
+			s.logger.Error("leak scan failed", "error", err)
+		case len(report.Errors) > 0:
+			s.logger.Debug("leak scan incomplete", "errors", report.Errors)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestScanLeaks(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, cred := range []*store.Credential{
+		{ID: "cred-1", Service: "github", DisplayName: "GitHub",
+			Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_readtoken"}},
+		{ID: "cred-2", Service: "slack", DisplayName: "Slack",
+			Read: &store.AccessLevel{InjectionType: store.InjectionConfig, ConfigPath: "channels.slack.botToken",
+				Token: "xoxb-bot-token", RefreshToken: "xoxe-1-refresh"}},
+		{ID: "cred-3", Service: "short", DisplayName: "Short", Read: &store.AccessLevel{EnvVar: "PIN", Token: "1234"}},
+	} {
+		if err := db.SaveCredential(cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cfgErr error
+	cfg := map[string]interface{}{
+		"channels": map[string]interface{}{"slack": map[string]interface{}{"botToken": "xoxb-bot-token"}},
+		"notes":    "github token is ghp_readtoken, pin 1234",
+		"agents":   []interface{}{map[string]interface{}{"refresh": "xoxe-1-refresh"}},
+	}
+	gatewayConfig = func(*gateway.Client) (map[string]interface{}, error) { return cfg, cfgErr }
+	gatewayLogs = func(*gateway.Client, int) ([]string, error) {
+		return []string{"starting", "slack: connecting with xoxb-bot-token"}, nil
+	}
+	t.Cleanup(func() {
+		gatewayConfig = (*gateway.Client).CurrentConfig
+		gatewayLogs = (*gateway.Client).RecentLogs
+	})
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	ctx := context.Background()
+
+	leaked := func() int {
+		t.Helper()
+		entries, err := db.ListAuditEntries(100, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, e := range entries {
+			if e.Action == store.ActionCredentialLeaked {
+				n++
+			}
+		}
+		return n
+	}
+	scan := func(logs bool, want int) *LeakReport {
+		t.Helper()
+		report, err := svc.ScanLeaks(ctx, logs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Leaks) != want {
+			t.Fatalf("%d leaks, want %d: %+v", len(report.Leaks), want, report.Leaks)
+		}
+		return report
+	}
+
+	// The bot token at its injection point is fine; the note and the
+	// never-injected refresh token aren't
+	report := scan(false, 2)
+	want := []Leak{
+		{Service: "github", Level: "read", Secret: "token", Gateway: "default", Surface: SurfaceConfig, Location: "notes"},
+		{Service: "slack", Level: "read", Secret: "refreshToken", Gateway: "default", Surface: SurfaceConfig, Location: "agents[0].refresh"},
+	}
+	for i := range want {
+		if report.Leaks[i] != want[i] {
+			t.Errorf("leak %d = %+v, want %+v", i, report.Leaks[i], want[i])
+		}
+	}
+	if n := leaked(); n != 2 {
+		t.Errorf("%d credential_leaked entries, want 2", n)
+	}
+
+	// Found again: not alerted again; logs add one
+	report = scan(true, 3)
+	if l := report.Leaks[2]; l.Surface != SurfaceLogs || l.Service != "slack" || l.Location != "line 2 of the last 2" {
+		t.Errorf("log leak = %+v", l)
+	}
+	if n := leaked(); n != 3 {
+		t.Errorf("%d credential_leaked entries, want 3", n)
+	}
+
+	// A config that can't be read forgets nothing
+	cfgErr = errors.New("gateway down")
+	if report = scan(false, 0); len(report.Errors) != 1 {
+		t.Errorf("errors = %v, want one", report.Errors)
+	}
+	cfgErr = nil
+	scan(false, 2)
+	if n := leaked(); n != 3 {
+		t.Errorf("after outage: %d credential_leaked entries, want 3", n)
+	}
+
+	// Cleaned up, then leaked again: alerted again
+	notes := cfg["notes"]
+	delete(cfg, "notes")
+	scan(false, 1)
+	cfg["notes"] = notes
+	scan(false, 2)
+	if n := leaked(); n != 4 {
+		t.Errorf("after re-leak: %d credential_leaked entries, want 4", n)
+	}
+}
@@ -0,0 +1,106 @@
+package elevation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// ErrConcurrencyLimit is returned when approving would exceed a credential's
+// MaxConcurrentElevations.
+var ErrConcurrencyLimit = fmt.Errorf("concurrent elevation limit reached")
+
+// checkCapacity returns ErrConcurrencyLimit if cred already has as many
+// active elevations as it allows. Caller must hold s.mu.
+func (s *Service) checkCapacity(cred *store.Credential) error {
+	if cred.MaxConcurrentElevations <= 0 {
+		return nil
+	}
+	n, err := s.store.CountActiveElevations(cred.Service)
+	if err != nil {
+		return fmt.Errorf("count active elevations: %w", err)
+	}
+	if n >= cred.MaxConcurrentElevations {
+		return ErrConcurrencyLimit
+	}
+	return nil
+}
+
+// PromoteQueued moves queued requests for a service back to pending, oldest
+// first, while the service has room under its concurrency limit. Call after
+// an elevation ends or a pending request is decided.
+func (s *Service) PromoteQueued(service string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.promoteQueued(service)
+}
+
+// promoteQueued is PromoteQueued for callers already holding s.mu.
+func (s *Service) promoteQueued(service string) {
+	queued, err := s.store.ListElevationsByStatus("queued", service)
+	if err != nil {
+		s.logger.Error("failed to list queued elevations", "error", err, "service", service)
+		return
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	cred, err := s.store.GetCredential(service)
+	if err != nil || cred == nil {
+		s.logger.Error("failed to load credential for queue promotion", "error", err, "service", service)
+		return
+	}
+
+	free := len(queued)
+	if cred.MaxConcurrentElevations > 0 {
+		active, err := s.store.CountActiveElevations(service)
+		if err != nil {
+			s.logger.Error("failed to count active elevations", "error", err, "service", service)
+			return
+		}
+		pending, err := s.store.ListElevationsByStatus("pending", service)
+		if err != nil {
+			s.logger.Error("failed to list pending elevations", "error", err, "service", service)
+			return
+		}
+		free = cred.MaxConcurrentElevations - active - len(pending)
+	}
+
+	for i := 0; i < free && i < len(queued); i++ {
+		elev := queued[i]
+		ok, err := s.store.TransitionElevation(elev.ID, "queued", "pending")
+		if err != nil {
+			s.logger.Error("failed to promote queued elevation", "error", err, "elevation_id", elev.ID)
+			continue
+		}
+		if !ok {
+			continue // Decided meanwhile
+		}
+
+		// Audit log
+		s.store.AddAuditEntry(&store.AuditEntry{
+			ID:          generateID("audit"),
+			Timestamp:   time.Now(),
+			Action:      store.ActionElevationDequeued,
+			Service:     elev.Service,
+			Scope:       elev.Scope,
+			Details:     fmt.Sprintf("elevation: %s", elev.ID),
+			Actor:       "system",
+			ElevationID: elev.ID,
+		})
+
+		s.logger.Info("queued elevation now pending", "elevation_id", elev.ID, "service", service)
+
+		s.notifier.Publish(notify.Event{
+			Type:        notify.EventElevationRequested,
+			Service:     elev.Service,
+			Scope:       elev.Scope,
+			ElevationID: elev.ID,
+			Reason:      elev.Reason,
+			Actor:       elev.RequestedBy,
+		})
+	}
+}
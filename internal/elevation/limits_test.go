@@ -0,0 +1,67 @@
+package elevation
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestPromoteQueued(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:                    &store.AccessLevel{EnvVar: "GITHUB_TOKEN"},
+		ReadWrite:               &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN"},
+		MaxConcurrentElevations: 1,
+		ElevationOverflow:       store.OverflowQueue,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	for i, e := range []*store.Elevation{
+		{ID: "elev-1", Service: "github", Scope: "write", Status: "pending", RequestedAt: now},
+		{ID: "elev-2", Service: "github", Scope: "write", Status: "queued", RequestedAt: now.Add(time.Second)},
+		{ID: "elev-3", Service: "github", Scope: "write", Status: "queued", RequestedAt: now.Add(2 * time.Second)},
+	} {
+		if err := db.CreateElevation(e); err != nil {
+			t.Fatalf("create elevation %d: %v", i, err)
+		}
+	}
+	if err := db.UpdateElevation("elev-1", "approved", "admin", &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	// At capacity: nothing moves
+	svc.PromoteQueued("github")
+	if elev, _ := db.GetElevation("elev-2"); elev.Status != "queued" {
+		t.Fatalf("elev-2 status = %s while at capacity, want queued", elev.Status)
+	}
+
+	// Capacity frees up: only the oldest queued request is promoted
+	if err := db.UpdateElevation("elev-1", "expired", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	svc.PromoteQueued("github")
+	if elev, _ := db.GetElevation("elev-2"); elev.Status != "pending" {
+		t.Errorf("elev-2 status = %s, want pending", elev.Status)
+	}
+	if elev, _ := db.GetElevation("elev-3"); elev.Status != "queued" {
+		t.Errorf("elev-3 status = %s, want queued", elev.Status)
+	}
+}
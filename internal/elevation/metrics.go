@@ -0,0 +1,46 @@
+package elevation
+
+import (
+	"time"
+
+	"github.com/openclaw/ocm/internal/metrics"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Elevation funnel outcomes. A request is counted once as requested (or
+// queued, or rejected at the concurrency limit), then once more when it is
+// approved or denied.
+const (
+	OutcomeRequested = "requested"
+	OutcomeQueued    = "queued"
+	OutcomeRejected  = "rejected"
+	OutcomeApproved  = "approved"
+	OutcomeDenied    = "denied"
+)
+
+// decisionBuckets span an instant automated decision to a request that
+// waited a day for a human.
+var decisionBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 4 * 3600, 24 * 3600}
+
+var (
+	elevationOutcomes = metrics.NewCounterVec("ocm_elevation_requests_total",
+		"Elevation requests by service and funnel outcome (requested, queued, rejected, approved, denied).",
+		"service", "outcome")
+	decisionSeconds = metrics.NewHistogramVec("ocm_elevation_decision_seconds",
+		"Time from an elevation request to its approval or denial.",
+		decisionBuckets, "service", "outcome")
+)
+
+// RecordRequest counts a new elevation request for service with outcome
+// OutcomeRequested, OutcomeQueued or OutcomeRejected.
+func RecordRequest(service, outcome string) {
+	elevationOutcomes.Inc(service, outcome)
+}
+
+// recordDecision counts elev's approval or denial and how long it waited.
+func recordDecision(elev *store.Elevation, outcome string, decidedAt time.Time) {
+	elevationOutcomes.Inc(elev.Service, outcome)
+	if wait := decidedAt.Sub(elev.RequestedAt); wait >= 0 {
+		decisionSeconds.Observe(wait.Seconds(), elev.Service, outcome)
+	}
+}
@@ -0,0 +1,122 @@
+package elevation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// ReceiptType identifies the payload of an approval receipt.
+const ReceiptType = "ocm.approval_receipt"
+
+// ReceiptPayload is the signed content of an approval receipt: what was
+// approved, by whom, when and for how long.
+type ReceiptPayload struct {
+	Type        string    `json:"type"`
+	Version     int       `json:"version"`
+	ElevationID string    `json:"elevationId"`
+	Service     string    `json:"service"`
+	Scope       string    `json:"scope"`
+	Reason      string    `json:"reason,omitempty"`
+	RequestedBy string    `json:"requestedBy,omitempty"`
+	RequestedAt time.Time `json:"requestedAt"`
+	ApprovedBy  string    `json:"approvedBy"`
+	ApprovedAt  time.Time `json:"approvedAt"`
+	TTL         string    `json:"ttl"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	KeyID       string    `json:"keyId"`
+}
+
+// ErrBadReceipt is returned by VerifyReceipt when a receipt's signature or
+// payload doesn't check out.
+var ErrBadReceipt = errors.New("invalid approval receipt")
+
+// SetReceiptKey sets the key approval receipts are signed with (nil = no
+// receipts).
+func (s *Service) SetReceiptKey(key ed25519.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receiptKey = key
+}
+
+// ReceiptPublicKey returns the public half of the receipt signing key, or
+// nil if receipts are off.
+func (s *Service) ReceiptPublicKey() ed25519.PublicKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.receiptKey == nil {
+		return nil
+	}
+	return s.receiptKey.Public().(ed25519.PublicKey)
+}
+
+// ReceiptKeyID identifies a receipt signing key: the hex SHA-256 of the
+// public key, as for the Gateway device ID.
+func ReceiptKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
+
+// issueReceipt signs and stores the receipt for an approval. Called with
+// s.mu held.
+func (s *Service) issueReceipt(elev *store.Elevation, approvedBy string, ttl time.Duration, approvedAt, expiresAt time.Time) error {
+	if s.receiptKey == nil {
+		return nil
+	}
+	pub := s.receiptKey.Public().(ed25519.PublicKey)
+	payload, err := json.Marshal(ReceiptPayload{
+		Type:        ReceiptType,
+		Version:     1,
+		ElevationID: elev.ID,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Reason:      elev.Reason,
+		RequestedBy: elev.RequestedBy,
+		RequestedAt: elev.RequestedAt.UTC(),
+		ApprovedBy:  approvedBy,
+		ApprovedAt:  approvedAt.UTC(),
+		TTL:         ttl.String(),
+		ExpiresAt:   expiresAt.UTC(),
+		KeyID:       ReceiptKeyID(pub),
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.SaveApprovalReceipt(&store.ApprovalReceipt{
+		ElevationID: elev.ID,
+		Payload:     payload,
+		Signature:   ed25519.Sign(s.receiptKey, payload),
+		PublicKey:   pub,
+		CreatedAt:   approvedAt,
+	})
+}
+
+// VerifyReceipt checks r's signature and returns its decoded payload. If
+// trusted is non-nil the receipt must also have been signed by it, not just
+// by the key it carries.
+func VerifyReceipt(r *store.ApprovalReceipt, trusted ed25519.PublicKey) (*ReceiptPayload, error) {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: bad public key", ErrBadReceipt)
+	}
+	if trusted != nil && !bytes.Equal(r.PublicKey, trusted) {
+		return nil, fmt.Errorf("%w: signed by an untrusted key", ErrBadReceipt)
+	}
+	if !ed25519.Verify(r.PublicKey, r.Payload, r.Signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrBadReceipt)
+	}
+	var p ReceiptPayload
+	if err := json.Unmarshal(r.Payload, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadReceipt, err)
+	}
+	if p.Type != ReceiptType || p.ElevationID != r.ElevationID {
+		return nil, fmt.Errorf("%w: payload doesn't match", ErrBadReceipt)
+	}
+	return &p, nil
+}
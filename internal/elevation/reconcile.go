@@ -0,0 +1,86 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// RunReconciler repairs drift in every gateway each interval (see
+// Reconcile). Blocks until ctx is done.
+func (s *Service) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for name := range s.Gateways() {
+			if _, err := s.Reconcile(name); err != nil {
+				s.logger.Error("drift reconciliation failed", "gateway", name, "error", err)
+			}
+		}
+	}
+}
+
+// Reconcile compares the named gateway with the desired state (see
+// InjectedState) and repairs any drift: missing or changed values are
+// rewritten and stale ones, such as a read-write token left behind by an
+// expiry that never ran, are removed. Every repair is audited. Targets
+// whose current value can't be read are left alone.
+func (s *Service) Reconcile(name string) ([]InjectedTarget, error) {
+	// Approvals and expiries hold mu while they change the gateway
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.InjectedState(name)
+	if err != nil {
+		return nil, err
+	}
+	drifted := state.Drifted()
+	if len(drifted) == 0 {
+		return nil, nil
+	}
+	gw, err := s.GatewayFor(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var inj gateway.Injection
+	for _, t := range drifted {
+		switch {
+		case t.Status == DriftStale && t.Type == store.InjectionConfig:
+			inj.ClearConfig = append(inj.ClearConfig, t.Key)
+		case t.Status == DriftStale:
+			inj.ClearEnv = append(inj.ClearEnv, t.Key)
+		case t.Type == store.InjectionConfig:
+			inj.Config = append(inj.Config, gateway.ConfigCredential{Path: t.Key, Value: t.Desired})
+		default:
+			inj.Env = append(inj.Env, gateway.CredentialEnv{Name: t.Key, Value: t.Desired})
+		}
+	}
+	// A queued repair is applied once the gateway is back
+	if err := gw.Apply(inj, "OCM drift repair"); err != nil && !errors.Is(err, gateway.ErrQueued) {
+		return nil, fmt.Errorf("repair drift: %w", err)
+	}
+
+	for _, t := range drifted {
+		s.store.AddAuditEntry(&store.AuditEntry{
+			ID:        generateID("audit"),
+			Timestamp: time.Now(),
+			Action:    store.ActionInjectionDriftRepaired,
+			Service:   t.Service,
+			Details:   fmt.Sprintf("gateway: %s, %s %s was %s", name, t.Type, t.Key, t.Status),
+			Actor:     "system",
+		})
+		s.logger.Warn("repaired injection drift", "gateway", name, "service", t.Service, "type", t.Type, "key", t.Key, "status", t.Status)
+	}
+	return drifted, nil
+}
@@ -0,0 +1,357 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/rotate"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// ErrNoRotation is returned by Rotate for a credential without a rotation
+// hook.
+var ErrNoRotation = errors.New("credential has no rotation hook")
+
+// ErrCredentialNotFound is returned by Rotate for an unknown service.
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// ErrRotationInProgress is returned by Rotate while the credential is
+// already being rotated.
+var ErrRotationInProgress = errors.New("credential is already being rotated")
+
+const (
+	// rotationCheckInterval is how often RunRotations looks for credentials
+	// due for rotation.
+	rotationCheckInterval = 5 * time.Minute
+	// rotationRetryDelay is how long a failed scheduled rotation waits
+	// before it is tried again.
+	rotationRetryDelay = time.Hour
+)
+
+// RotationResult is the outcome of a rotation.
+type RotationResult struct {
+	Service string   `json:"service"`
+	Rotated []string `json:"rotated"`           // Levels given a new secret: read, write
+	Failed  []string `json:"failed,omitempty"`  // "<level>: <error>" for levels whose hook failed
+	Warning string   `json:"warning,omitempty"` // Rotated, but e.g. injecting the new secret or revoking the old one failed
+}
+
+// Rotate asks service's rotation hook or built-in rotator for a new secret for each access
+// level that has one, then stores the new secrets, archives the old ones
+// and re-injects them: read access always, read-write access while it is
+// elevated. Levels whose hook fails keep their secret; the error names
+// them if none was rotated. An old secret the rotator revokes separately is
+// revoked only once the new one is stored. A credential is rotated by one
+// caller at a time: refresh token rotators spend the stored refresh token.
+func (s *Service) Rotate(ctx context.Context, service, actor, reason string) (*RotationResult, error) {
+	if !s.startRotation(service) {
+		return nil, ErrRotationInProgress
+	}
+	defer s.endRotation(service)
+
+	cred, err := s.store.GetCredential(service)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrCredentialNotFound
+	}
+	if cred.Rotation == nil {
+		return nil, ErrNoRotation
+	}
+
+	// Mint outside mu: a hook may take a while
+	result := &RotationResult{Service: service, Rotated: []string{}}
+	secrets := make(map[string]*rotate.Secret)
+	var warnings []string
+	for _, level := range rotationLevels(cred) {
+		req := rotate.Request{
+			Service:      service,
+			Type:         cred.Type,
+			Level:        level.name,
+			Token:        level.access.Token,
+			RefreshToken: level.access.RefreshToken,
+			Access:       level.access,
+		}
+		for _, f := range level.access.AdditionalFields {
+			req.Fields = append(req.Fields, f.Name)
+		}
+		secret, err := rotate.Mint(ctx, cred.Rotation, req)
+		if err != nil {
+			result.Failed = append(result.Failed, level.name+": "+err.Error())
+			s.rotationFailed(service, level.name, actor, err)
+			continue
+		}
+		secrets[level.name] = secret
+		if secret.Warning != "" {
+			warnings = append(warnings, level.name+": "+secret.Warning)
+		}
+	}
+	if len(secrets) == 0 {
+		if len(result.Failed) == 0 {
+			return nil, errors.New("credential has no secret to rotate")
+		}
+		return result, fmt.Errorf("rotate %s: %s", service, strings.Join(result.Failed, "; "))
+	}
+
+	// Until the new secrets are stored, the old ones are the ones that work
+	applied := make(map[string]bool)
+	saved := false
+	defer func() {
+		for _, name := range []string{"read", "write"} {
+			secret := secrets[name]
+			if secret == nil {
+				continue
+			}
+			if saved && applied[name] {
+				if secret.Commit != nil {
+					if err := secret.Commit(ctx); err != nil {
+						s.logger.Error("failed to revoke old secret", "service", service, "level", name, "error", err)
+						result.Warning = joinWarning(result.Warning, name+": "+err.Error())
+					}
+				}
+			} else if secret.Abandon != nil {
+				if err := secret.Abandon(ctx); err != nil {
+					s.logger.Error("failed to revoke unused new secret", "service", service, "level", name, "error", err)
+				}
+			}
+		}
+	}()
+
+	// Approvals and expiries hold mu while they change the gateway
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Apply to the latest copy, in case it changed while minting
+	if cred, err = s.store.GetCredential(service); err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrCredentialNotFound
+	}
+	now := time.Now()
+	for _, level := range rotationLevels(cred) {
+		secret := secrets[level.name]
+		if secret == nil {
+			continue
+		}
+		old := *level.access
+		if err := s.store.ArchiveCredentialVersion(&store.CredentialVersion{
+			ID:         generateID("ver"),
+			Service:    service,
+			Level:      level.name,
+			Reason:     reason,
+			ArchivedAt: now,
+			Access:     &old,
+		}); err != nil {
+			return nil, fmt.Errorf("archive %s version: %w", level.name, err)
+		}
+		applySecret(level.access, secret)
+		applied[level.name] = true
+		result.Rotated = append(result.Rotated, level.name)
+	}
+	cred.UpdatedAt = now
+	if err := s.store.SaveCredential(cred); err != nil {
+		return nil, fmt.Errorf("save credential: %w", err)
+	}
+	saved = true
+
+	if err := s.injectRotated(ctx, cred); err != nil && !errors.Is(err, gateway.ErrQueued) {
+		s.logger.Error("failed to inject rotated credential", "service", service, "error", err)
+		warnings = append(warnings, "rotated, but injecting the new secret failed: "+err.Error())
+	}
+	result.Warning = strings.Join(warnings, "; ")
+	if n, err := s.store.RevokeServiceLeases(service, "credential rotated"); err != nil {
+		s.logger.Error("revoke leases failed", "error", err, "service", service)
+	} else if n > 0 {
+		s.logger.Info("leases revoked", "service", service, "count", n, "reason", "credential rotated")
+	}
+
+	details := "rotated: " + strings.Join(result.Rotated, ", ")
+	if reason != "" {
+		details += " (" + reason + ")"
+	}
+	s.store.AddAuditEntry(&store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: now,
+		Action:    store.ActionCredentialRotated,
+		Service:   service,
+		Details:   details,
+		Actor:     actor,
+	})
+	s.notifier.Publish(notify.Event{Type: notify.EventCredentialRotated, Service: service, Actor: actor, Details: details})
+	s.logger.Info("credential rotated", "service", service, "levels", result.Rotated, "actor", actor)
+	return result, nil
+}
+
+// startRotation marks service as being rotated, unless it already is.
+func (s *Service) startRotation(service string) bool {
+	s.rotatingMu.Lock()
+	defer s.rotatingMu.Unlock()
+	if s.rotating[service] {
+		return false
+	}
+	if s.rotating == nil {
+		s.rotating = make(map[string]bool)
+	}
+	s.rotating[service] = true
+	return true
+}
+
+func (s *Service) endRotation(service string) {
+	s.rotatingMu.Lock()
+	defer s.rotatingMu.Unlock()
+	delete(s.rotating, service)
+}
+
+// joinWarning appends w to warning.
+func joinWarning(warning, w string) string {
+	if warning == "" {
+		return w
+	}
+	return warning + "; " + w
+}
+
+type rotationLevel struct {
+	name   string
+	access *store.AccessLevel
+}
+
+// rotationLevels returns cred's access levels that hold a secret.
+func rotationLevels(cred *store.Credential) []rotationLevel {
+	var levels []rotationLevel
+	for _, level := range []rotationLevel{{"read", cred.Read}, {"write", cred.ReadWrite}} {
+		if level.access != nil && (level.access.Token != "" || level.access.RefreshToken != "") {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// applySecret replaces level's secrets with those a hook returned.
+func applySecret(level *store.AccessLevel, secret *rotate.Secret) {
+	level.Token = secret.Token
+	if secret.RefreshToken != "" {
+		level.RefreshToken = secret.RefreshToken
+	}
+	level.ExpiresAt = secret.ExpiresAt
+	for i, f := range level.AdditionalFields {
+		if v, ok := secret.Fields[f.Name]; ok {
+			level.AdditionalFields[i].Value = v
+		}
+	}
+}
+
+// injectRotated re-injects cred's read access, and its read-write access
+// over it while an elevation is active.
+func (s *Service) injectRotated(ctx context.Context, cred *store.Credential) error {
+	gw, err := s.GatewayFor(cred.Gateway)
+	if err != nil {
+		return err
+	}
+	levels := []*store.AccessLevel{cred.Read}
+	if cred.ReadWrite != nil {
+		active, err := s.store.CountActiveElevations(cred.Service)
+		if err != nil {
+			return fmt.Errorf("count active elevations: %w", err)
+		}
+		if active > 0 {
+			levels = append(levels, cred.ReadWrite)
+		}
+	}
+
+	// Read-write access overrides read access on a shared target
+	var inj gateway.Injection
+	envAt, configAt := make(map[string]int), make(map[string]int)
+	for _, level := range levels {
+		env, config := gateway.LevelCredentials(level)
+		for _, e := range env {
+			if i, ok := envAt[e.Name]; ok {
+				inj.Env[i] = e
+				continue
+			}
+			envAt[e.Name] = len(inj.Env)
+			inj.Env = append(inj.Env, e)
+		}
+		for _, c := range config {
+			if i, ok := configAt[c.Path]; ok {
+				inj.Config[i] = c
+				continue
+			}
+			configAt[c.Path] = len(inj.Config)
+			inj.Config = append(inj.Config, c)
+		}
+	}
+	if len(inj.Env) == 0 && len(inj.Config) == 0 {
+		return nil
+	}
+	if err := gw.ApplyContext(ctx, inj, "credential rotated: "+cred.Service); err != nil {
+		return err
+	}
+	s.VerifyInjection(cred, levels[len(levels)-1])
+	return nil
+}
+
+// rotationFailed audits and publishes a failed rotation of one level.
+func (s *Service) rotationFailed(service, level, actor string, err error) {
+	s.logger.Error("credential rotation failed", "service", service, "level", level, "error", err)
+	s.store.AddAuditEntry(&store.AuditEntry{
+		ID:        generateID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionRotationFailed,
+		Service:   service,
+		Scope:     level,
+		Details:   err.Error(),
+		Actor:     actor,
+	})
+	s.notifier.Publish(notify.Event{Type: notify.EventRotationFailed, Service: service, Scope: level, Actor: actor, Details: err.Error()})
+}
+
+// RunRotations rotates credentials whose rotation interval has passed since
+// they were last changed. A failed rotation is retried after an hour.
+// Blocks until ctx is done.
+func (s *Service) RunRotations(ctx context.Context) {
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+
+	failed := make(map[string]time.Time) // Service to when to retry
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		creds, err := s.store.ListCredentials()
+		if err != nil {
+			s.logger.Error("failed to list credentials for rotation", "error", err)
+			continue
+		}
+		now := time.Now()
+		for _, cred := range creds {
+			retryAt, retrying := failed[cred.Service]
+			if !rotationDue(cred, now) || (retrying && now.Before(retryAt)) {
+				continue
+			}
+			_, err := s.Rotate(ctx, cred.Service, "system", "scheduled")
+			switch {
+			case errors.Is(err, ErrRotationInProgress):
+				// Rotated by an admin meanwhile, or still being
+			case err != nil:
+				failed[cred.Service] = now.Add(rotationRetryDelay)
+			default:
+				delete(failed, cred.Service)
+			}
+		}
+	}
+}
+
+// rotationDue reports whether cred's scheduled rotation is due at now.
+func rotationDue(cred *store.Credential, now time.Time) bool {
+	r := cred.Rotation
+	return r != nil && r.Interval > 0 && !now.Before(cred.UpdatedAt.Add(r.Interval))
+}
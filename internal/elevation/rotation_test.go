@@ -0,0 +1,160 @@
+package elevation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/rotate"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The hook refuses to mint write tokens
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rotate.Request
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Level != "read" {
+			http.Error(w, "no", http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(rotate.Secret{Token: "rotated-" + req.Token})
+	}))
+	defer srv.Close()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+		Rotation:  &store.Rotation{URL: srv.URL},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-2", Service: "linear", DisplayName: "Linear", Read: &store.AccessLevel{EnvVar: "LINEAR_API_KEY", Token: "k"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger)
+	svc := NewService(db, gw, logger)
+
+	if _, err := svc.Rotate(context.Background(), "linear", "admin", ""); !errors.Is(err, ErrNoRotation) {
+		t.Errorf("linear: err = %v, want ErrNoRotation", err)
+	}
+
+	result, err := svc.Rotate(context.Background(), "github", "admin", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rotated) != 1 || result.Rotated[0] != "read" || len(result.Failed) != 1 {
+		t.Errorf("result = %+v, want read rotated and write failed", result)
+	}
+
+	cred, _ := db.GetCredential("github")
+	if cred.Read.Token != "rotated-read-token" || cred.ReadWrite.Token != "write-token" {
+		t.Errorf("tokens = %q, %q", cred.Read.Token, cred.ReadWrite.Token)
+	}
+	if env, _ := gw.GetCurrentCredentials(); env["GITHUB_TOKEN"] != "rotated-read-token" {
+		t.Errorf("env = %v, want the rotated read token injected", env)
+	}
+	versions, err := db.ListCredentialVersions("github")
+	if err != nil || len(versions) != 1 || versions[0].Access.Token != "read-token" || versions[0].Reason != "test" {
+		t.Fatalf("versions = %+v, %v; want the old read token archived", versions, err)
+	}
+
+	// Scheduling counts from the last change
+	cred.Rotation.Interval = 24 * time.Hour
+	if rotationDue(cred, cred.UpdatedAt.Add(23*time.Hour)) || !rotationDue(cred, cred.UpdatedAt.Add(24*time.Hour)) {
+		t.Error("rotationDue doesn't follow the interval")
+	}
+}
+
+func TestRotate_RevokesAfterSave(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var mu sync.Mutex
+	var created, deleted []string
+	var onCreate func()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Form.Get("Action") {
+		case "CreateAccessKey":
+			id := fmt.Sprintf("AKIANEW%d", len(created))
+			created = append(created, id)
+			if onCreate != nil {
+				onCreate()
+			}
+			fmt.Fprintf(w, `<CreateAccessKeyResponse><CreateAccessKeyResult><AccessKey>
+				<AccessKeyId>%s</AccessKeyId><SecretAccessKey>secret-%s</SecretAccessKey>
+			</AccessKey></CreateAccessKeyResult></CreateAccessKeyResponse>`, id, id)
+		case "DeleteAccessKey":
+			deleted = append(deleted, r.Form.Get("AccessKeyId"))
+			fmt.Fprint(w, `<DeleteAccessKeyResponse/>`)
+		}
+	}))
+	defer srv.Close()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "aws", DisplayName: "AWS", Type: "aws_access_key",
+		Read: &store.AccessLevel{EnvVar: "AWS_SECRET_ACCESS_KEY", Token: "old-secret",
+			AdditionalFields: []store.AdditionalField{{Name: "accessKeyId", EnvVar: "AWS_ACCESS_KEY_ID", Value: "AKIAOLD"}}},
+		Rotation: &store.Rotation{Provider: rotate.ProviderAWSIAM, Endpoint: srv.URL},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	ctx := context.Background()
+
+	// Another rotation of the same credential is refused while one runs
+	svc.startRotation("aws")
+	if _, err := svc.Rotate(ctx, "aws", "admin", ""); !errors.Is(err, ErrRotationInProgress) {
+		t.Errorf("concurrent rotation: err = %v, want ErrRotationInProgress", err)
+	}
+	svc.endRotation("aws")
+
+	// The old key is deleted once the new one is stored
+	if _, err := svc.Rotate(ctx, "aws", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+	cred, _ := db.GetCredential("aws")
+	if cred.Read.AdditionalFields[0].Value != "AKIANEW0" || len(deleted) != 1 || deleted[0] != "AKIAOLD" {
+		t.Errorf("key = %s, deleted %v; want AKIANEW0 stored and AKIAOLD deleted", cred.Read.AdditionalFields[0].Value, deleted)
+	}
+
+	// If the new key can't be stored, it is deleted and the old one kept
+	onCreate = func() { db.Close() }
+	if _, err := svc.Rotate(ctx, "aws", "admin", ""); err == nil {
+		t.Fatal("rotation succeeded without a store")
+	}
+	if len(deleted) != 2 || deleted[1] != "AKIANEW1" {
+		t.Errorf("deleted %v, want the unsaved AKIANEW1 deleted and AKIANEW0 kept", deleted)
+	}
+}
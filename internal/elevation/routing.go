@@ -0,0 +1,302 @@
+package elevation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// routingSettingKey is the settings key holding the RoutingPolicy.
+const routingSettingKey = "routing"
+
+const (
+	defaultEscalateAfter = 15 * time.Minute
+	routingInterval      = 30 * time.Second
+)
+
+// RoutingPolicy decides who is responsible for approving elevation requests.
+type RoutingPolicy struct {
+	// Shifts is the on-call schedule. Overlapping shifts share responsibility.
+	Shifts []Shift `json:"shifts"`
+	// Delegations temporarily hand an approver's responsibility to someone else.
+	Delegations []Delegation `json:"delegations"`
+	// Fallback receives requests when nobody is on call, and on escalation.
+	Fallback []string `json:"fallback"`
+	// EscalateAfter is how long a request may sit unactioned before it is
+	// escalated to the fallback group, e.g., "15m". Empty means 15m; "0" disables.
+	EscalateAfter string `json:"escalateAfter,omitempty"`
+	// Reminders are the ages at which a still-pending request is announced
+	// again, e.g., ["10m", "30m", "1h"]. Each is more urgent than the last;
+	// the final one is critical, so a notification route with minSeverity
+	// can page a secondary channel.
+	Reminders []string `json:"reminders,omitempty"`
+}
+
+// Shift is a period during which the listed approvers are on call.
+type Shift struct {
+	Approvers []string  `json:"approvers"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
+
+// Delegation routes From's responsibility to To until the given time.
+type Delegation struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Until time.Time `json:"until"`
+}
+
+// Validate checks the policy is well-formed.
+func (p *RoutingPolicy) Validate() error {
+	for i, sh := range p.Shifts {
+		if len(sh.Approvers) == 0 {
+			return fmt.Errorf("shift %d has no approvers", i)
+		}
+		if !sh.End.After(sh.Start) {
+			return fmt.Errorf("shift %d ends before it starts", i)
+		}
+	}
+	for i, d := range p.Delegations {
+		if d.From == "" || d.To == "" {
+			return fmt.Errorf("delegation %d needs from and to", i)
+		}
+		if strings.EqualFold(d.From, d.To) {
+			return fmt.Errorf("delegation %d delegates to itself", i)
+		}
+	}
+	if p.EscalateAfter != "" {
+		if _, err := time.ParseDuration(p.EscalateAfter); err != nil {
+			return fmt.Errorf("invalid escalateAfter: %w", err)
+		}
+	}
+	var prev time.Duration
+	for i, r := range p.Reminders {
+		d, err := time.ParseDuration(r)
+		if err != nil {
+			return fmt.Errorf("invalid reminder %d: %w", i, err)
+		}
+		if d <= prev {
+			return fmt.Errorf("reminder %d must be later than the one before it", i)
+		}
+		prev = d
+	}
+	return nil
+}
+
+// reminderAges returns the parsed reminder schedule.
+func (p *RoutingPolicy) reminderAges() []time.Duration {
+	ages := make([]time.Duration, 0, len(p.Reminders))
+	for _, r := range p.Reminders {
+		d, _ := time.ParseDuration(r)
+		ages = append(ages, d)
+	}
+	return ages
+}
+
+// reminderSeverity is the urgency of reminder n (1-based) of total: the
+// first is a warning, the last critical, anything between an error.
+func reminderSeverity(n, total int) notify.Severity {
+	switch {
+	case n >= total:
+		return notify.SeverityCritical
+	case n == 1:
+		return notify.SeverityWarning
+	default:
+		return notify.SeverityError
+	}
+}
+
+// escalateAfter returns the escalation timeout (0 = never escalate).
+func (p *RoutingPolicy) escalateAfter() time.Duration {
+	if p.EscalateAfter == "" {
+		return defaultEscalateAfter
+	}
+	d, _ := time.ParseDuration(p.EscalateAfter)
+	return d
+}
+
+// OnCall returns the approvers responsible at time t, after applying delegations.
+// Falls back to the fallback group when no shift covers t.
+func (p *RoutingPolicy) OnCall(t time.Time) []string {
+	var approvers []string
+	for _, sh := range p.Shifts {
+		if !t.Before(sh.Start) && t.Before(sh.End) {
+			approvers = append(approvers, sh.Approvers...)
+		}
+	}
+	if len(approvers) == 0 {
+		approvers = append(approvers, p.Fallback...)
+	}
+	return dedupe(p.delegate(approvers, t))
+}
+
+// delegate replaces approvers who have an active delegation. Chains are
+// followed (a→b→c) but bounded so a cycle can't loop forever.
+func (p *RoutingPolicy) delegate(approvers []string, t time.Time) []string {
+	out := make([]string, 0, len(approvers))
+	for _, a := range approvers {
+		for hops := 0; hops < len(p.Delegations); hops++ {
+			next := ""
+			for _, d := range p.Delegations {
+				if strings.EqualFold(d.From, a) && t.Before(d.Until) {
+					next = d.To
+					break
+				}
+			}
+			if next == "" {
+				break
+			}
+			a = next
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func dedupe(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	var out []string
+	for _, v := range in {
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// RoutingPolicy returns the stored routing policy (empty if none configured).
+func (s *Service) RoutingPolicy() (*RoutingPolicy, error) {
+	var p RoutingPolicy
+	if _, err := s.store.GetSetting(routingSettingKey, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetRoutingPolicy validates and stores the routing policy.
+func (s *Service) SetRoutingPolicy(p *RoutingPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return s.store.PutSetting(routingSettingKey, p)
+}
+
+// RunRouter assigns pending elevations to on-call approvers, escalates
+// those left unactioned past the policy's timeout and sends reminders.
+// Blocks until ctx is done.
+func (s *Service) RunRouter(ctx context.Context) {
+	ticker := time.NewTicker(routingInterval)
+	defer ticker.Stop()
+
+	for {
+		s.routePending(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// routePending runs one routing pass over all pending elevations.
+func (s *Service) routePending(now time.Time) {
+	policy, err := s.RoutingPolicy()
+	if err != nil {
+		s.logger.Error("failed to load routing policy", "error", err)
+		return
+	}
+	routing := len(policy.Shifts) > 0 || len(policy.Fallback) > 0
+	if !routing && len(policy.Reminders) == 0 {
+		return // Nothing configured
+	}
+
+	pending, err := s.store.ListPendingElevations()
+	if err != nil {
+		s.logger.Error("failed to list pending elevations for routing", "error", err)
+		return
+	}
+
+	s.remind(policy, pending, now)
+	if !routing {
+		return
+	}
+
+	for _, elev := range pending {
+		switch {
+		case elev.RoutedAt == nil:
+			approvers := policy.OnCall(now)
+			if len(approvers) == 0 {
+				continue
+			}
+			s.assign(elev, approvers, false, store.ActionElevationRouted)
+
+		case elev.EscalatedAt == nil && policy.escalateAfter() > 0 &&
+			now.Sub(*elev.RoutedAt) >= policy.escalateAfter() && len(policy.Fallback) > 0:
+			approvers := dedupe(append(append([]string{}, elev.AssignedTo...), policy.delegate(policy.Fallback, now)...))
+			s.assign(elev, approvers, true, store.ActionElevationEscalated)
+		}
+	}
+}
+
+func (s *Service) assign(elev *store.Elevation, approvers []string, escalated bool, action store.AuditAction) {
+	if err := s.store.AssignElevation(elev.ID, approvers, escalated); err != nil {
+		s.logger.Error("failed to assign elevation", "error", err, "elevation_id", elev.ID)
+		return
+	}
+
+	// Audit log
+	s.store.AddAuditEntry(&store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      action,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     fmt.Sprintf("elevation: %s, assigned to: %s", elev.ID, strings.Join(approvers, ", ")),
+		Actor:       "system",
+		ElevationID: elev.ID,
+	})
+
+	s.logger.Info("elevation routed", "elevation_id", elev.ID, "approvers", approvers, "escalated", escalated)
+}
+
+// remind re-announces pending elevations that have crossed the next age in
+// the policy's reminder schedule. Reminders missed while OCM was down are
+// collapsed into the latest one due.
+func (s *Service) remind(policy *RoutingPolicy, pending []*store.Elevation, now time.Time) {
+	ages := policy.reminderAges()
+	for _, elev := range pending {
+		age := now.Sub(elev.RequestedAt)
+		due := 0
+		for _, a := range ages {
+			if age >= a {
+				due++
+			}
+		}
+		if due <= elev.RemindersSent {
+			continue
+		}
+		if err := s.store.SetElevationReminders(elev.ID, due); err != nil {
+			s.logger.Error("failed to record elevation reminder", "error", err, "elevation_id", elev.ID)
+			continue
+		}
+
+		s.notifier.Publish(notify.Event{
+			Type:        notify.EventElevationReminder,
+			Service:     elev.Service,
+			Scope:       elev.Scope,
+			ElevationID: elev.ID,
+			Reason:      elev.Reason,
+			Actor:       "system",
+			Details:     fmt.Sprintf("reminder %d of %d, waiting %s", due, len(ages), age.Round(time.Minute)),
+			Severity:    reminderSeverity(due, len(ages)),
+		})
+		s.logger.Info("elevation reminder sent", "elevation_id", elev.ID, "reminder", due)
+	}
+}
@@ -0,0 +1,166 @@
+package elevation
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestRoutingPolicy_OnCall(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	policy := &RoutingPolicy{
+		Shifts: []Shift{
+			{Approvers: []string{"alice"}, Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+			{Approvers: []string{"bob"}, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+		},
+		Delegations: []Delegation{
+			{From: "alice", To: "carol", Until: now.Add(30 * time.Minute)},
+		},
+		Fallback: []string{"secops"},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if got := policy.OnCall(now); len(got) != 1 || got[0] != "carol" {
+		t.Errorf("OnCall(now) = %v, want [carol] (delegated)", got)
+	}
+	if got := policy.OnCall(now.Add(45 * time.Minute)); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("OnCall(+45m) = %v, want [alice] (delegation ended)", got)
+	}
+	if got := policy.OnCall(now.Add(3 * time.Hour)); len(got) != 1 || got[0] != "secops" {
+		t.Errorf("OnCall(+3h) = %v, want [secops] (fallback)", got)
+	}
+}
+
+func TestRoutePending_Escalates(t *testing.T) {
+	dir := t.TempDir()
+	masterKey := make([]byte, 32)
+	db, err := store.New(filepath.Join(dir, "ocm.db"), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release",
+		Status: "pending", RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := svc.SetRoutingPolicy(&RoutingPolicy{
+		Shifts:        []Shift{{Approvers: []string{"alice"}, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}},
+		Fallback:      []string{"secops"},
+		EscalateAfter: "10m",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.routePending(now)
+	elev, _ := db.GetElevation("elev-1")
+	if len(elev.AssignedTo) != 1 || elev.AssignedTo[0] != "alice" || elev.EscalatedAt != nil {
+		t.Fatalf("after routing: assigned=%v escalated=%v, want [alice] and not escalated", elev.AssignedTo, elev.EscalatedAt)
+	}
+
+	// Not yet past the escalation timeout
+	svc.routePending(now.Add(5 * time.Minute))
+	elev, _ = db.GetElevation("elev-1")
+	if elev.EscalatedAt != nil {
+		t.Fatal("escalated before timeout")
+	}
+
+	svc.routePending(now.Add(11 * time.Minute))
+	elev, _ = db.GetElevation("elev-1")
+	if elev.EscalatedAt == nil || len(elev.AssignedTo) != 2 {
+		t.Errorf("after timeout: assigned=%v escalated=%v, want alice+secops and escalated", elev.AssignedTo, elev.EscalatedAt)
+	}
+}
+
+func TestRoutePending_Reminders(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	d := notify.NewDispatcher(logger)
+	svc.SetNotifier(d)
+	events, cancel := d.Subscribe()
+	defer cancel()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	requested := time.Now()
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release",
+		Status: "pending", RequestedAt: requested,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Reminders only; no on-call routing
+	if err := svc.SetRoutingPolicy(&RoutingPolicy{Reminders: []string{"10m", "30m", "1h"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetRoutingPolicy(&RoutingPolicy{Reminders: []string{"30m", "10m"}}); err == nil {
+		t.Error("out-of-order reminders should not validate")
+	}
+
+	next := func() *notify.Event {
+		select {
+		case e := <-events:
+			return &e
+		default:
+			return nil
+		}
+	}
+
+	svc.routePending(requested.Add(5 * time.Minute))
+	if e := next(); e != nil {
+		t.Fatalf("reminder before first age: %+v", e)
+	}
+
+	svc.routePending(requested.Add(11 * time.Minute))
+	e := next()
+	if e == nil || e.Type != notify.EventElevationReminder || e.Severity != notify.SeverityWarning {
+		t.Fatalf("first reminder = %+v, want warning", e)
+	}
+	svc.routePending(requested.Add(12 * time.Minute))
+	if e := next(); e != nil {
+		t.Fatalf("repeated first reminder: %+v", e)
+	}
+
+	// Missed the 30m reminder: only the final one goes out
+	svc.routePending(requested.Add(2 * time.Hour))
+	e = next()
+	if e == nil || e.Severity != notify.SeverityCritical || next() != nil {
+		t.Fatalf("final reminder = %+v, want a single critical reminder", e)
+	}
+	if elev, _ := db.GetElevation("elev-1"); elev.RemindersSent != 3 {
+		t.Errorf("RemindersSent = %d, want 3", elev.RemindersSent)
+	}
+}
@@ -2,24 +2,52 @@
 package elevation
 
 import (
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/sentry"
 	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/tracing"
 )
 
 // Service manages credential elevation and injection.
 type Service struct {
-	store   *store.Store
-	gateway *gateway.Client
-	logger  *slog.Logger
+	store    *store.Store
+	gateway  *gateway.Client            // Default gateway
+	gateways map[string]*gateway.Client // Additional gateways by name
+	gwMu     sync.RWMutex               // Protects gateways; separate from mu, which is held while injecting
+	logger   *slog.Logger
 
 	// expiryTimers tracks active elevation expiry timers
 	expiryTimers map[string]*time.Timer
 	mu           sync.Mutex
+
+	// notifier receives lifecycle events (nil = notifications off)
+	notifier *notify.Dispatcher
+
+	// verifyGen counts injections per service so only the latest one's
+	// verification is recorded (see VerifyInjection)
+	verifyGen map[string]uint64
+	verifyMu  sync.Mutex
+
+	// receiptKey signs approval receipts (nil = no receipts)
+	receiptKey ed25519.PrivateKey
+
+	// leaksSeen holds the leaks the last scan found, by key, so each is
+	// alerted once (see ScanLeaks)
+	leaksSeen map[string]Leak
+	leakMu    sync.Mutex
+
+	// rotating holds the services being rotated (see Rotate)
+	rotating   map[string]bool
+	rotatingMu sync.Mutex
 }
 
 // NewService creates a new elevation service.
@@ -32,23 +60,95 @@ func NewService(s *store.Store, g *gateway.Client, logger *slog.Logger) *Service
 	}
 	
 	// On startup, sync current state to Gateway
-	svc.syncCredentialsToGateway()
+	svc.syncCredentialsToGateway(gateway.DefaultName, g)
 	
 	return svc
 }
 
-// Gateway returns the Gateway client for direct access (e.g., setup flow).
+// AddGateway registers an additional named gateway that credentials can
+// select, and syncs the credentials assigned to it.
+func (s *Service) AddGateway(name string, g *gateway.Client) {
+	g.SetName(name)
+	s.gwMu.Lock()
+	if s.gateways == nil {
+		s.gateways = make(map[string]*gateway.Client)
+	}
+	s.gateways[name] = g
+	s.gwMu.Unlock()
+
+	s.syncCredentialsToGateway(name, g)
+}
+
+// ErrUnknownGateway is returned when a credential selects a gateway that
+// isn't configured.
+var ErrUnknownGateway = fmt.Errorf("unknown gateway")
+
+// GatewayFor returns the gateway named name; "" and "default" mean the
+// default gateway.
+func (s *Service) GatewayFor(name string) (*gateway.Client, error) {
+	if name == "" || name == gateway.DefaultName {
+		return s.gateway, nil
+	}
+	s.gwMu.RLock()
+	defer s.gwMu.RUnlock()
+	if g, ok := s.gateways[name]; ok {
+		return g, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownGateway, name)
+}
+
+// Gateways returns every configured gateway by name, including the default.
+func (s *Service) Gateways() map[string]*gateway.Client {
+	s.gwMu.RLock()
+	defer s.gwMu.RUnlock()
+	all := map[string]*gateway.Client{gateway.DefaultName: s.gateway}
+	for name, g := range s.gateways {
+		all[name] = g
+	}
+	return all
+}
+
+// SetNotifier sets the dispatcher that receives elevation lifecycle events.
+func (s *Service) SetNotifier(d *notify.Dispatcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = d
+}
+
+// Gateway returns the default Gateway client for direct access (e.g., setup flow).
 func (s *Service) Gateway() *gateway.Client {
 	return s.gateway
 }
 
+// DefaultTTL is how long an approved elevation lasts when neither the
+// approver nor the requester chose a TTL.
+const DefaultTTL = 30 * time.Minute
+
 // ApproveElevation approves an elevation request and injects the credential.
+// A ttl of zero grants the TTL the requester asked for, or DefaultTTL.
 func (s *Service) ApproveElevation(elevationID string, ttl time.Duration, approvedBy string) error {
+	return s.ApproveElevationContext(context.Background(), elevationID, ttl, approvedBy)
+}
+
+// ApproveElevationContext is ApproveElevation, traced as part of the span
+// in ctx, with a child span for each store call and the injection.
+func (s *Service) ApproveElevationContext(ctx context.Context, elevationID string, ttl time.Duration, approvedBy string) (err error) {
+	ctx, span := tracing.Start(ctx, "elevation.approve", "elevation_id", elevationID, "approved_by", approvedBy)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	_, lockSpan := tracing.Start(ctx, "elevation.lock")
 	s.mu.Lock()
+	lockSpan.End()
 	defer s.mu.Unlock()
 
 	// Get the elevation request
+	_, storeSpan := tracing.Start(ctx, "store.GetElevation")
 	elev, err := s.store.GetElevation(elevationID)
+	storeSpan.RecordError(err)
+	storeSpan.End()
 	if err != nil {
 		return fmt.Errorf("get elevation: %w", err)
 	}
@@ -59,8 +159,13 @@ func (s *Service) ApproveElevation(elevationID string, ttl time.Duration, approv
 		return fmt.Errorf("elevation not pending (status: %s)", elev.Status)
 	}
 
+	span.SetAttributes("service", elev.Service, "scope", elev.Scope)
+
 	// Get the credential
+	_, storeSpan = tracing.Start(ctx, "store.GetCredential")
 	cred, err := s.store.GetCredential(elev.Service)
+	storeSpan.RecordError(err)
+	storeSpan.End()
 	if err != nil {
 		return fmt.Errorf("get credential: %w", err)
 	}
@@ -73,19 +178,39 @@ func (s *Service) ApproveElevation(elevationID string, ttl time.Duration, approv
 		return fmt.Errorf("credential has no read-write access configured")
 	}
 
+	// Enforce concurrency limit
+	if err := s.checkCapacity(cred); err != nil {
+		return err
+	}
+
+	if ttl <= 0 {
+		ttl = elev.RequestedTTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
 	// Enforce maxTTL
 	if cred.ReadWrite.MaxTTL > 0 && ttl > cred.ReadWrite.MaxTTL {
 		ttl = cred.ReadWrite.MaxTTL
 	}
 
 	// Update elevation status
-	expiresAt := time.Now().Add(ttl)
-	if err := s.store.UpdateElevation(elevationID, "approved", approvedBy, &expiresAt); err != nil {
+	approvedAt := time.Now()
+	expiresAt := approvedAt.Add(ttl)
+	_, storeSpan = tracing.Start(ctx, "store.UpdateElevation")
+	err = s.store.UpdateElevation(elevationID, "approved", approvedBy, &expiresAt)
+	storeSpan.RecordError(err)
+	storeSpan.End()
+	if err != nil {
 		return fmt.Errorf("update elevation: %w", err)
 	}
 
 	// Inject read-write credential into Gateway
-	if err := s.injectReadWriteCredential(cred); err != nil {
+	// A queued injection is applied once the Gateway is back; the grant stands
+	if err := s.injectReadWriteCredential(ctx, cred); errors.Is(err, gateway.ErrQueued) {
+		s.logger.Warn("gateway unreachable, credential injection queued", "elevation_id", elevationID)
+	} else if err != nil {
 		// Rollback elevation status on failure
 		s.store.UpdateElevation(elevationID, "pending", "", nil)
 		return fmt.Errorf("inject credential: %w", err)
@@ -93,17 +218,32 @@ func (s *Service) ApproveElevation(elevationID string, ttl time.Duration, approv
 
 	// Set up expiry timer
 	s.setExpiryTimer(elevationID, elev.Service, elev.Scope, ttl)
+	recordDecision(elev, OutcomeApproved, approvedAt)
 
 	// Audit log
-	s.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "elevation_approved",
-		Service:   elev.Service,
-		Scope:     elev.Scope,
-		Details:   fmt.Sprintf("TTL: %s, approved by: %s", ttl, approvedBy),
-		Actor:     approvedBy,
-	})
+	_, storeSpan = tracing.Start(ctx, "store.AddAuditEntry")
+	storeSpan.RecordError(s.store.AddAuditEntry(&store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionElevationApproved,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     fmt.Sprintf("TTL: %s, approved by: %s", ttl, approvedBy),
+		Actor:       approvedBy,
+		ElevationID: elev.ID,
+	}))
+	storeSpan.End()
+	if err := s.store.RecordCredentialUse(elev.Service, elev.Scope, store.UseElevation); err != nil {
+		s.logger.Error("failed to record credential use", "service", elev.Service, "error", err)
+	}
+
+	// The approval stands without a receipt; the failure is only logged
+	_, receiptSpan := tracing.Start(ctx, "elevation.receipt")
+	if err := s.issueReceipt(elev, approvedBy, ttl, approvedAt, expiresAt); err != nil {
+		receiptSpan.RecordError(err)
+		s.logger.Error("failed to issue approval receipt", "elevation_id", elevationID, "error", err)
+	}
+	receiptSpan.End()
 
 	s.logger.Info("elevation approved",
 		"elevation_id", elevationID,
@@ -112,6 +252,64 @@ func (s *Service) ApproveElevation(elevationID string, ttl time.Duration, approv
 		"ttl", ttl,
 	)
 
+	s.notifier.Publish(notify.Event{
+		Type:        notify.EventElevationApproved,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		ElevationID: elevationID,
+		Reason:      elev.Reason,
+		Actor:       approvedBy,
+		ExpiresAt:   &expiresAt,
+	})
+
+	return nil
+}
+
+// DenyElevation denies a pending (or queued) elevation request.
+func (s *Service) DenyElevation(elevationID, deniedBy, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elev, err := s.store.GetElevation(elevationID)
+	if err != nil {
+		return fmt.Errorf("get elevation: %w", err)
+	}
+	if elev == nil {
+		return fmt.Errorf("elevation not found")
+	}
+	if elev.Status != "pending" && elev.Status != "queued" {
+		return fmt.Errorf("elevation not pending (status: %s)", elev.Status)
+	}
+
+	if err := s.store.UpdateElevation(elevationID, "denied", deniedBy, nil); err != nil {
+		return fmt.Errorf("update elevation: %w", err)
+	}
+	recordDecision(elev, OutcomeDenied, time.Now())
+
+	// Audit log
+	s.store.AddAuditEntry(&store.AuditEntry{
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionElevationDenied,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		Details:     reason,
+		Actor:       deniedBy,
+		ElevationID: elev.ID,
+	})
+
+	s.logger.Info("elevation denied", "elevation_id", elevationID, "denied_by", deniedBy)
+
+	s.notifier.Publish(notify.Event{
+		Type:        notify.EventElevationDenied,
+		Service:     elev.Service,
+		Scope:       elev.Scope,
+		ElevationID: elevationID,
+		Reason:      reason,
+		Actor:       deniedBy,
+	})
+
+	s.promoteQueued(elev.Service)
 	return nil
 }
 
@@ -140,34 +338,50 @@ func (s *Service) RevokeElevation(service, scope string, reason string) error {
 	if err := s.store.UpdateElevation(active.ID, "revoked", "admin", nil); err != nil {
 		return fmt.Errorf("update elevation: %w", err)
 	}
+	s.releaseElevation(active.ID, "elevation revoked")
 
 	// Remove credential from Gateway (or downgrade to permanent scope)
-	if err := s.removeOrDowngradeCredential(service, scope); err != nil {
+	if err := s.removeOrDowngradeCredential(service, scope); errors.Is(err, gateway.ErrQueued) {
+		s.logger.Warn("gateway unreachable, credential removal queued", "service", service, "scope", scope)
+	} else if err != nil {
 		return fmt.Errorf("remove credential: %w", err)
 	}
 
 	// Audit log
 	s.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "elevation_revoked",
-		Service:   service,
-		Scope:     scope,
-		Details:   reason,
-		Actor:     "admin",
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionElevationRevoked,
+		Service:     service,
+		Scope:       scope,
+		Details:     reason,
+		Actor:       "admin",
+		ElevationID: active.ID,
 	})
 
 	s.logger.Info("elevation revoked", "service", service, "scope", scope)
 
+	s.notifier.Publish(notify.Event{
+		Type:        notify.EventElevationRevoked,
+		Service:     service,
+		Scope:       scope,
+		ElevationID: active.ID,
+		Reason:      reason,
+		Actor:       "admin",
+	})
+
+	s.promoteQueued(service)
+
 	return nil
 }
 
-// syncCredentialsToGateway syncs all read credentials to the Gateway on startup.
+// syncCredentialsToGateway syncs the read credentials assigned to the named
+// gateway on startup.
 // Env credentials go to .env file, config credentials would need a config patch.
 // This only writes to .env WITHOUT triggering a Gateway restart - we assume Gateway is
 // starting up at the same time and will read the .env file on its own startup.
 // Config credentials are NOT synced on startup (they should already be in config).
-func (s *Service) syncCredentialsToGateway() {
+func (s *Service) syncCredentialsToGateway(name string, g *gateway.Client) {
 	creds, err := s.store.ListCredentials()
 	if err != nil {
 		s.logger.Error("failed to list credentials for sync", "error", err)
@@ -177,62 +391,63 @@ func (s *Service) syncCredentialsToGateway() {
 	var envCreds []gateway.CredentialEnv
 	for _, cred := range creds {
 		fullCred, _ := s.store.GetCredential(cred.Service)
-		if fullCred == nil {
+		if fullCred == nil || !onGateway(fullCred, name) {
 			continue
 		}
-		// Sync read credentials (always available)
-		if fullCred.Read != nil && fullCred.Read.Token != "" {
-			injType := fullCred.Read.GetInjectionType()
-			if injType == store.InjectionEnv && fullCred.Read.EnvVar != "" {
-				envCreds = append(envCreds, gateway.CredentialEnv{
-					Name:  fullCred.Read.EnvVar,
-					Value: fullCred.Read.Token,
-				})
-			}
-			// Config credentials are already persisted in config file,
-			// no need to sync on startup
-		}
+		// Sync read credentials (always available). Config credentials
+		// are already persisted in the config file, no need to sync on startup
+		env, _ := gateway.LevelCredentials(fullCred.Read)
+		envCreds = append(envCreds, env...)
 	}
 
 	if len(envCreds) > 0 {
 		// Use WriteCredentialsToEnv which doesn't trigger restart
 		// Gateway will pick up the .env on its own startup
-		changed, err := s.gateway.WriteCredentialsToEnv(envCreds)
+		changed, err := g.WriteCredentialsToEnv(envCreds)
 		if err != nil {
-			s.logger.Error("failed to sync credentials to gateway", "error", err)
+			s.logger.Error("failed to sync credentials to gateway", "error", err, "gateway", name)
 		} else if changed {
-			s.logger.Info("synced credentials to gateway .env", "count", len(envCreds))
+			s.logger.Info("synced credentials to gateway .env", "count", len(envCreds), "gateway", name)
 		} else {
-			s.logger.Debug("credentials already synced to gateway .env", "count", len(envCreds))
+			s.logger.Debug("credentials already synced to gateway .env", "count", len(envCreds), "gateway", name)
 		}
 	}
 }
 
+// onGateway reports whether cred is injected into the named gateway.
+func onGateway(cred *store.Credential, name string) bool {
+	if cred.Gateway == "" {
+		return name == gateway.DefaultName
+	}
+	return cred.Gateway == name
+}
+
 // injectReadWriteCredential injects a read-write credential into the Gateway.
-func (s *Service) injectReadWriteCredential(cred *store.Credential) error {
+func (s *Service) injectReadWriteCredential(ctx context.Context, cred *store.Credential) error {
 	if cred.ReadWrite == nil {
 		return fmt.Errorf("no read-write access configured")
 	}
+	if cred.ReadWrite.Derive != nil {
+		return nil // Agents get minted tokens from the agent API instead
+	}
 	if cred.ReadWrite.Token == "" {
 		return fmt.Errorf("read-write has no token")
 	}
 
-	injType := cred.ReadWrite.GetInjectionType()
-	injKey := cred.ReadWrite.GetInjectionKey()
-	if injKey == "" {
+	if cred.ReadWrite.GetInjectionKey() == "" {
 		return fmt.Errorf("read-write has no injection target configured")
 	}
-
-	if injType == store.InjectionConfig {
-		return s.gateway.SetConfigCredentials([]gateway.ConfigCredential{
-			{Path: injKey, Value: cred.ReadWrite.Token},
-		})
+	gw, err := s.GatewayFor(cred.Gateway)
+	if err != nil {
+		return err
 	}
 
-	// Default: env injection
-	return s.gateway.SetCredentials([]gateway.CredentialEnv{
-		{Name: injKey, Value: cred.ReadWrite.Token},
-	})
+	env, config := gateway.LevelCredentials(cred.ReadWrite)
+	err = gw.ApplyContext(ctx, gateway.Injection{Env: env, Config: config}, "OCM credential update")
+	if err == nil {
+		s.VerifyInjection(cred, cred.ReadWrite)
+	}
+	return err
 }
 
 // removeOrDowngradeCredential removes a credential or downgrades to read-only.
@@ -245,35 +460,44 @@ func (s *Service) removeOrDowngradeCredential(service, scopeName string) error {
 	if cred.ReadWrite == nil {
 		return nil
 	}
+	gw, err := s.GatewayFor(cred.Gateway)
+	if err != nil {
+		return err
+	}
 
-	rwInjType := cred.ReadWrite.GetInjectionType()
-	rwInjKey := cred.ReadWrite.GetInjectionKey()
+	return gw.Apply(downgrade(cred), "OCM credential removal")
+}
 
-	// Check if Read and ReadWrite use the same injection target
-	sameTarget := false
-	if cred.Read != nil {
-		readInjType := cred.Read.GetInjectionType()
-		readInjKey := cred.Read.GetInjectionKey()
-		sameTarget = readInjType == rwInjType && readInjKey == rwInjKey
+// downgrade returns the changes that take cred back to read-only: every
+// read-write target that read access shares is restored to the read value,
+// the rest are removed.
+func downgrade(cred *store.Credential) gateway.Injection {
+	readEnv, readConfig := gateway.LevelCredentials(cred.Read)
+	readValues := make(map[string]string)
+	for _, c := range readEnv {
+		readValues["env:"+c.Name] = c.Value
+	}
+	for _, c := range readConfig {
+		readValues["config:"+c.Path] = c.Value
 	}
 
-	if sameTarget && cred.Read.Token != "" {
-		// Downgrade to read-only token (same injection target)
-		if rwInjType == store.InjectionConfig {
-			return s.gateway.SetConfigCredentials([]gateway.ConfigCredential{
-				{Path: rwInjKey, Value: cred.Read.Token},
-			})
+	rwEnv, rwConfig := gateway.LevelTargets(cred.ReadWrite)
+	var inj gateway.Injection
+	for _, name := range rwEnv {
+		if v, ok := readValues["env:"+name]; ok {
+			inj.Env = append(inj.Env, gateway.CredentialEnv{Name: name, Value: v})
+		} else {
+			inj.ClearEnv = append(inj.ClearEnv, name)
 		}
-		return s.gateway.SetCredentials([]gateway.CredentialEnv{
-			{Name: rwInjKey, Value: cred.Read.Token},
-		})
 	}
-
-	// Different targets or no read token - clear the read-write credential
-	if rwInjType == store.InjectionConfig {
-		return s.gateway.ClearConfigCredentials([]string{rwInjKey})
+	for _, path := range rwConfig {
+		if v, ok := readValues["config:"+path]; ok {
+			inj.Config = append(inj.Config, gateway.ConfigCredential{Path: path, Value: v})
+		} else {
+			inj.ClearConfig = append(inj.ClearConfig, path)
+		}
 	}
-	return s.gateway.ClearCredentials([]string{rwInjKey})
+	return inj
 }
 
 // setExpiryTimer sets a timer to auto-expire an elevation.
@@ -291,13 +515,27 @@ func (s *Service) setExpiryTimer(elevationID, service, scope string, ttl time.Du
 	})
 }
 
+// releaseElevation revokes the credential leases an ended elevation granted
+// and ends the checkouts made under it.
+func (s *Service) releaseElevation(elevationID, reason string) {
+	if _, err := s.store.RevokeElevationLeases(elevationID, reason); err != nil {
+		s.logger.Error("failed to revoke leases", "error", err, "elevation_id", elevationID)
+	}
+	if _, err := s.store.CheckInElevation(elevationID, reason); err != nil {
+		s.logger.Error("failed to end checkouts", "error", err, "elevation_id", elevationID)
+	}
+}
+
 // handleExpiry handles elevation expiry.
 func (s *Service) handleExpiry(elevationID, service, scope string) {
+	// Runs on a timer goroutine, where a panic would otherwise go unreported
+	defer sentry.Recover()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Update status
 	s.store.UpdateElevation(elevationID, "expired", "", nil)
+	s.releaseElevation(elevationID, "elevation expired")
 
 	// Remove/downgrade credential
 	if err := s.removeOrDowngradeCredential(service, scope); err != nil {
@@ -310,18 +548,29 @@ func (s *Service) handleExpiry(elevationID, service, scope string) {
 
 	// Audit log
 	s.store.AddAuditEntry(&store.AuditEntry{
-		ID:        generateID("audit"),
-		Timestamp: time.Now(),
-		Action:    "elevation_expired",
-		Service:   service,
-		Scope:     scope,
-		Actor:     "system",
+		ID:          generateID("audit"),
+		Timestamp:   time.Now(),
+		Action:      store.ActionElevationExpired,
+		Service:     service,
+		Scope:       scope,
+		Actor:       "system",
+		ElevationID: elevationID,
 	})
 
 	s.logger.Info("elevation expired", "service", service, "scope", scope)
+
+	s.notifier.Publish(notify.Event{
+		Type:        notify.EventElevationExpired,
+		Service:     service,
+		Scope:       scope,
+		ElevationID: elevationID,
+		Actor:       "system",
+	})
+
+	s.promoteQueued(service)
 }
 
 // generateID creates a unique ID with prefix.
 func generateID(prefix string) string {
-	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	return store.NewID(prefix)
 }
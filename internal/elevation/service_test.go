@@ -0,0 +1,236 @@
+package elevation
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestApproveElevation_SelectedGateway(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat", Gateway: "staging",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	prod := gateway.NewClient("", filepath.Join(dir, "prod.env"), nil, logger)
+	staging := gateway.NewClient("", filepath.Join(dir, "staging.env"), nil, logger)
+	svc := NewService(db, prod, logger)
+	svc.AddGateway("staging", staging)
+
+	if _, err := svc.GatewayFor("qa"); !errors.Is(err, ErrUnknownGateway) {
+		t.Errorf("GatewayFor(qa) = %v, want ErrUnknownGateway", err)
+	}
+
+	// Startup sync only writes to the credential's gateway
+	if env, _ := staging.GetCurrentCredentials(); env["GITHUB_TOKEN"] != "read-token" {
+		t.Errorf("staging env = %v, want GITHUB_TOKEN synced", env)
+	}
+	if env, _ := prod.GetCurrentCredentials(); len(env) != 0 {
+		t.Errorf("prod env = %v, want empty", env)
+	}
+
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release",
+		Status: "pending", RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ApproveElevation("elev-1", time.Minute, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if env, _ := staging.GetCurrentCredentials(); env["GITHUB_WRITE_TOKEN"] != "write-token" {
+		t.Errorf("staging env = %v, want write token injected", env)
+	}
+	if env, _ := prod.GetCurrentCredentials(); len(env) != 0 {
+		t.Errorf("prod env = %v, want empty", env)
+	}
+}
+
+func TestElevation_AdditionalFields(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "slack", DisplayName: "Slack", Type: "token",
+		Read: &store.AccessLevel{EnvVar: "SLACK_TOKEN", Token: "read-token", AdditionalFields: []store.AdditionalField{
+			{Name: "cookie", InjectionType: store.InjectionEnv, EnvVar: "SLACK_COOKIE", Value: "read-cookie"},
+		}},
+		ReadWrite: &store.AccessLevel{EnvVar: "SLACK_TOKEN", Token: "write-token", AdditionalFields: []store.AdditionalField{
+			{Name: "cookie", InjectionType: store.InjectionEnv, EnvVar: "SLACK_COOKIE", Value: "write-cookie"},
+			{Name: "workspace", InjectionType: store.InjectionEnv, EnvVar: "SLACK_ADMIN_WORKSPACE", Value: "acme"},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger)
+	svc := NewService(db, gw, logger)
+
+	if env, _ := gw.GetCurrentCredentials(); env["SLACK_COOKIE"] != "read-cookie" {
+		t.Errorf("env after sync = %v, want read cookie", env)
+	}
+
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "slack", Scope: "write", Reason: "admin task",
+		Status: "pending", RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ApproveElevation("elev-1", time.Minute, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	env, _ := gw.GetCurrentCredentials()
+	if env["SLACK_TOKEN"] != "write-token" || env["SLACK_COOKIE"] != "write-cookie" || env["SLACK_ADMIN_WORKSPACE"] != "acme" {
+		t.Errorf("env after approval = %v, want read-write token and fields", env)
+	}
+
+	lease := &store.Lease{ID: "lease-1", Service: "slack", Scope: "write", ElevationID: "elev-1",
+		IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)}
+	if err := db.CreateLease(lease); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoking restores the fields read access shares and removes the rest
+	if err := svc.RevokeElevation("slack", "write", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := db.GetLease("lease-1"); l == nil || l.Status(time.Now()) != store.LeaseRevoked {
+		t.Errorf("lease after revoke = %+v, want revoked", l)
+	}
+	env, _ = gw.GetCurrentCredentials()
+	if env["SLACK_TOKEN"] != "read-token" || env["SLACK_COOKIE"] != "read-cookie" {
+		t.Errorf("env after revoke = %v, want read token and cookie restored", env)
+	}
+	if _, ok := env["SLACK_ADMIN_WORKSPACE"]; ok {
+		t.Errorf("env after revoke = %v, want SLACK_ADMIN_WORKSPACE removed", env)
+	}
+}
+
+func TestApproveElevation_Receipt(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+	key, err := db.ReceiptSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := db.ReceiptSigningKey(); err != nil || !again.Equal(key) {
+		t.Fatalf("ReceiptSigningKey changed between calls (err %v)", err)
+	}
+	svc.SetReceiptKey(key)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release",
+		Status: "pending", RequestedAt: time.Now(), RequestedBy: "alice",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ApproveElevation("elev-1", 10*time.Minute, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := db.GetApprovalReceipt("elev-1")
+	if err != nil || receipt == nil {
+		t.Fatalf("GetApprovalReceipt = %v, %v", receipt, err)
+	}
+	p, err := VerifyReceipt(receipt, svc.ReceiptPublicKey())
+	if err != nil {
+		t.Fatalf("VerifyReceipt: %v", err)
+	}
+	if p.ElevationID != "elev-1" || p.ApprovedBy != "bob" || p.RequestedBy != "alice" || p.TTL != "10m0s" ||
+		p.ExpiresAt.Sub(p.ApprovedAt) != 10*time.Minute || p.KeyID != ReceiptKeyID(svc.ReceiptPublicKey()) {
+		t.Errorf("receipt payload = %+v", p)
+	}
+
+	tampered := *receipt
+	tampered.Payload = []byte(strings.Replace(string(receipt.Payload), `"approvedBy":"bob"`, `"approvedBy":"eve"`, 1))
+	if _, err := VerifyReceipt(&tampered, nil); !errors.Is(err, ErrBadReceipt) {
+		t.Errorf("VerifyReceipt(tampered) = %v, want ErrBadReceipt", err)
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := VerifyReceipt(receipt, other); !errors.Is(err, ErrBadReceipt) {
+		t.Errorf("VerifyReceipt(untrusted key) = %v, want ErrBadReceipt", err)
+	}
+}
+
+func TestDecisionMetrics(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := NewService(db, gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger), logger)
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "metered", DisplayName: "Metered", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "METERED_TOKEN"},
+		ReadWrite: &store.AccessLevel{EnvVar: "METERED_WRITE_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	requested := time.Now().Add(-90 * time.Second)
+	for _, id := range []string{"elev-1", "elev-2"} {
+		if err := db.CreateElevation(&store.Elevation{
+			ID: id, Service: "metered", Scope: "write", Status: "pending", RequestedAt: requested,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.ApproveElevation("elev-1", time.Minute, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DenyElevation("elev-2", "bob", "not now"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, outcome := range []string{OutcomeApproved, OutcomeDenied} {
+		if n := elevationOutcomes.Value("metered", outcome); n != 1 {
+			t.Errorf("%s count = %v, want 1", outcome, n)
+		}
+		// 90 seconds lands in the (60, 120] bucket
+		snap := decisionSeconds.Snapshot("metered", outcome)
+		if snap.Count != 1 || snap.Counts[4] != 0 || snap.Counts[5] != 1 {
+			t.Errorf("%s latency = %+v, want one observation of about 90s", outcome, snap)
+		}
+	}
+}
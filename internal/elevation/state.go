@@ -0,0 +1,187 @@
+package elevation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Drift statuses of an injection target.
+const (
+	DriftNone     = "ok"
+	DriftMissing  = "missing"  // Should be injected but isn't
+	DriftMismatch = "mismatch" // Injected with a different value
+	DriftStale    = "stale"    // Injected but shouldn't be, e.g. a read-write token after its elevation ended
+	DriftUnknown  = "unknown"  // The current value couldn't be read
+)
+
+// InjectedTarget is an env var or config path OCM manages in a gateway,
+// compared with what the store says it should hold.
+type InjectedTarget struct {
+	Type     store.InjectionType `json:"type"`
+	Key      string              `json:"key"` // Env var name or config path
+	Service  string              `json:"service"`
+	Level    string              `json:"level"`    // "read" or "readWrite"
+	Status   string              `json:"status"`   // DriftNone, DriftMissing, ...
+	Injected bool                `json:"injected"` // Present in the gateway
+
+	Desired string `json:"-"` // Value it should hold ("" if it shouldn't be injected)
+	Actual  string `json:"-"` // Value it holds
+}
+
+// InjectedState is the result of InjectedState.
+type InjectedState struct {
+	Targets     []InjectedTarget `json:"targets"`
+	EnvError    string           `json:"envError,omitempty"`    // Why the .env couldn't be read
+	ConfigError string           `json:"configError,omitempty"` // Why the config couldn't be read
+}
+
+// Drifted returns the targets whose status isn't DriftNone or DriftUnknown.
+func (st *InjectedState) Drifted() []InjectedTarget {
+	var drifted []InjectedTarget
+	for _, t := range st.Targets {
+		if t.Status != DriftNone && t.Status != DriftUnknown {
+			drifted = append(drifted, t)
+		}
+	}
+	return drifted
+}
+
+// InjectedState compares the env vars and config paths OCM manages in the
+// named gateway with the desired state: the read access of every credential
+// on it, plus read-write access of those with an active elevation. Read-write
+// targets of credentials without one are reported if still injected.
+func (s *Service) InjectedState(name string) (*InjectedState, error) {
+	gw, err := s.GatewayFor(name)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := s.store.ListCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+
+	var targets []*InjectedTarget
+	index := make(map[string]*InjectedTarget)
+	want := func(service, level string, injType store.InjectionType, key, value string) {
+		id := string(injType) + ":" + key
+		if t, ok := index[id]; ok {
+			// Read-write access overrides read access on a shared target
+			t.Service, t.Level, t.Desired = service, level, value
+			return
+		}
+		t := &InjectedTarget{Type: injType, Key: key, Service: service, Level: level, Desired: value}
+		index[id] = t
+		targets = append(targets, t)
+	}
+
+	for _, c := range creds {
+		cred, err := s.store.GetCredential(c.Service)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s: %w", c.Service, err)
+		}
+		if cred == nil || !onGateway(cred, name) {
+			continue
+		}
+
+		env, config := gateway.LevelCredentials(cred.Read)
+		for _, e := range env {
+			want(cred.Service, "read", store.InjectionEnv, e.Name, e.Value)
+		}
+		for _, c := range config {
+			want(cred.Service, "read", store.InjectionConfig, c.Path, c.Value)
+		}
+		if cred.ReadWrite == nil {
+			continue
+		}
+
+		active, err := s.store.CountActiveElevations(cred.Service)
+		if err != nil {
+			return nil, fmt.Errorf("count active elevations: %w", err)
+		}
+		if active > 0 {
+			env, config := gateway.LevelCredentials(cred.ReadWrite)
+			for _, e := range env {
+				want(cred.Service, "readWrite", store.InjectionEnv, e.Name, e.Value)
+			}
+			for _, c := range config {
+				want(cred.Service, "readWrite", store.InjectionConfig, c.Path, c.Value)
+			}
+			continue
+		}
+		// Read-write targets that read access doesn't share must be absent
+		envVars, configPaths := gateway.LevelTargets(cred.ReadWrite)
+		for _, key := range envVars {
+			if _, ok := index[string(store.InjectionEnv)+":"+key]; !ok {
+				want(cred.Service, "readWrite", store.InjectionEnv, key, "")
+			}
+		}
+		for _, key := range configPaths {
+			if _, ok := index[string(store.InjectionConfig)+":"+key]; !ok {
+				want(cred.Service, "readWrite", store.InjectionConfig, key, "")
+			}
+		}
+	}
+
+	state := &InjectedState{Targets: []InjectedTarget{}}
+	env, envErr := gw.GetCurrentCredentials()
+	if envErr != nil && !errors.Is(envErr, os.ErrNotExist) {
+		state.EnvError = envErr.Error()
+	}
+	var cfg map[string]interface{}
+	var cfgErr error
+	for _, t := range targets {
+		if t.Type == store.InjectionConfig {
+			if cfg, cfgErr = gw.CurrentConfig(); cfgErr != nil {
+				state.ConfigError = cfgErr.Error()
+			}
+			break
+		}
+	}
+
+	for _, t := range targets {
+		if t.Type == store.InjectionConfig {
+			if cfgErr != nil {
+				t.Status = DriftUnknown
+				state.Targets = append(state.Targets, *t)
+				continue
+			}
+			if v, ok := gateway.ConfigValue(cfg, t.Key); ok && v != nil {
+				t.Injected = true
+				t.Actual = fmt.Sprint(v)
+			}
+		} else {
+			if state.EnvError != "" {
+				t.Status = DriftUnknown
+				state.Targets = append(state.Targets, *t)
+				continue
+			}
+			t.Actual, t.Injected = env[t.Key]
+		}
+
+		switch {
+		case t.Desired == "" && t.Injected:
+			t.Status = DriftStale
+		case t.Desired == "":
+			t.Status = DriftNone
+		case !t.Injected:
+			t.Status = DriftMissing
+		case t.Actual != t.Desired:
+			t.Status = DriftMismatch
+		default:
+			t.Status = DriftNone
+		}
+		state.Targets = append(state.Targets, *t)
+	}
+	sort.Slice(state.Targets, func(i, j int) bool {
+		if state.Targets[i].Type != state.Targets[j].Type {
+			return state.Targets[i].Type < state.Targets[j].Type
+		}
+		return state.Targets[i].Key < state.Targets[j].Key
+	})
+	return state, nil
+}
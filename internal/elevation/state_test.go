@@ -0,0 +1,163 @@
+package elevation
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestInjectedState(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, cred := range []*store.Credential{
+		{
+			ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+			Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+			ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+		},
+		{
+			ID: "cred-2", Service: "linear", DisplayName: "Linear", Type: "api_key",
+			Read: &store.AccessLevel{EnvVar: "LINEAR_API_KEY", Token: "lin-key"},
+		},
+		{
+			ID: "cred-3", Service: "slack", DisplayName: "Slack", Type: "token",
+			Read: &store.AccessLevel{InjectionType: store.InjectionConfig, ConfigPath: "channels.slack.userToken", Token: "xoxp"},
+		},
+	} {
+		if err := db.SaveCredential(cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger)
+	svc := NewService(db, gw, logger)
+
+	// Hand edits after the startup sync: a changed token, a dropped one and a
+	// write token left behind without an elevation
+	if err := gw.ClearCredentials([]string{"LINEAR_API_KEY"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gw.WriteCredentialsToEnv([]gateway.CredentialEnv{
+		{Name: "GITHUB_TOKEN", Value: "edited"},
+		{Name: "GITHUB_WRITE_TOKEN", Value: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := svc.InjectedState(gateway.DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"GITHUB_TOKEN":             DriftMismatch,
+		"GITHUB_WRITE_TOKEN":       DriftStale,
+		"LINEAR_API_KEY":           DriftMissing,
+		"channels.slack.userToken": DriftUnknown, // No RPC client to read the config
+	}
+	if len(state.Targets) != len(want) {
+		t.Fatalf("targets = %+v, want %d", state.Targets, len(want))
+	}
+	for _, target := range state.Targets {
+		if target.Status != want[target.Key] {
+			t.Errorf("%s: status = %s, want %s", target.Key, target.Status, want[target.Key])
+		}
+	}
+	if state.ConfigError == "" {
+		t.Error("ConfigError not reported")
+	}
+	if n := len(state.Drifted()); n != 3 {
+		t.Errorf("Drifted() = %d targets, want 3", n)
+	}
+
+	// During an elevation the write token is wanted
+	if err := db.CreateElevation(&store.Elevation{
+		ID: "elev-1", Service: "github", Scope: "write", Reason: "release",
+		Status: "pending", RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ApproveElevation("elev-1", time.Minute, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	state, err = svc.InjectedState(gateway.DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range state.Targets {
+		if target.Key == "GITHUB_WRITE_TOKEN" && (target.Status != DriftNone || target.Level != "readWrite") {
+			t.Errorf("write token during elevation = %+v, want ok at readWrite", target)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gw := gateway.NewClient("", filepath.Join(dir, ".env"), nil, logger)
+	svc := NewService(db, gw, logger)
+
+	if _, err := gw.WriteCredentialsToEnv([]gateway.CredentialEnv{
+		{Name: "GITHUB_TOKEN", Value: "edited"},
+		{Name: "GITHUB_WRITE_TOKEN", Value: "write-token"},
+		{Name: "UNRELATED", Value: "kept"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := svc.Reconcile(gateway.DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired) != 2 {
+		t.Errorf("Reconcile() repaired %+v, want 2 targets", repaired)
+	}
+	env, _ := gw.GetCurrentCredentials()
+	if env["GITHUB_TOKEN"] != "read-token" || env["UNRELATED"] != "kept" {
+		t.Errorf("env = %v, want read token restored and other vars kept", env)
+	}
+	if _, ok := env["GITHUB_WRITE_TOKEN"]; ok {
+		t.Errorf("env = %v, want stale write token removed", env)
+	}
+
+	entries, err := db.ListAuditEntries(10, "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audited int
+	for _, e := range entries {
+		if e.Action == "injection_drift_repaired" {
+			audited++
+		}
+	}
+	if audited != 2 {
+		t.Errorf("%d repairs audited, want 2", audited)
+	}
+
+	if repaired, err := svc.Reconcile(gateway.DefaultName); err != nil || len(repaired) != 0 {
+		t.Errorf("second Reconcile() = %+v, %v; want nothing to repair", repaired, err)
+	}
+}
@@ -0,0 +1,90 @@
+package elevation
+
+import (
+	"context"
+	"time"
+
+	"github.com/openclaw/ocm/internal/gateway"
+	"github.com/openclaw/ocm/internal/notify"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// verifyTimeout bounds how long a verification waits for the Gateway to
+// restart and reconnect after an injection.
+const verifyTimeout = 2 * time.Minute
+
+// VerifyInjection records the injection as a use of cred, then checks in
+// the background that cred's gateway loaded level, and records the result
+// on the credential (see gateway.Client.VerifyInjection). A credential the
+// Gateway didn't load is audited and published as credential.not_loaded.
+func (s *Service) VerifyInjection(cred *store.Credential, level *store.AccessLevel) {
+	gw, err := s.GatewayFor(cred.Gateway)
+	if err != nil {
+		return
+	}
+	env, config := gateway.LevelCredentials(level)
+	if len(env) == 0 && len(config) == 0 {
+		return
+	}
+	service := cred.Service
+
+	scope := "read"
+	if level == cred.ReadWrite {
+		scope = "write"
+	}
+	if err := s.store.RecordCredentialUse(service, scope, store.UseInjection); err != nil {
+		s.logger.Error("failed to record credential use", "service", service, "error", err)
+	}
+
+	// Only the latest injection's result is recorded
+	s.verifyMu.Lock()
+	if s.verifyGen == nil {
+		s.verifyGen = make(map[string]uint64)
+	}
+	s.verifyGen[service]++
+	gen := s.verifyGen[service]
+	s.verifyMu.Unlock()
+
+	if err := s.store.SetInjectionStatus(service, &store.InjectionStatus{Status: store.InjectionPending}); err != nil {
+		s.logger.Error("failed to record injection status", "service", service, "error", err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+		defer cancel()
+		status := gw.VerifyInjection(ctx, env, config)
+
+		s.verifyMu.Lock()
+		latest := s.verifyGen[service] == gen
+		if latest {
+			if err := s.store.SetInjectionStatus(service, status); err != nil {
+				s.logger.Error("failed to record injection status", "service", service, "error", err)
+			}
+		}
+		s.verifyMu.Unlock()
+		if !latest {
+			return
+		}
+
+		switch status.Status {
+		case store.InjectionNotLoaded:
+			s.logger.Warn("credential injected but not loaded by gateway", "service", service, "detail", status.Detail)
+			s.store.AddAuditEntry(&store.AuditEntry{
+				ID:        generateID("audit"),
+				Timestamp: time.Now(),
+				Action:    store.ActionInjectionNotLoaded,
+				Service:   service,
+				Details:   status.Detail,
+				Actor:     "system",
+			})
+			s.mu.Lock()
+			n := s.notifier
+			s.mu.Unlock()
+			n.Publish(notify.Event{Type: notify.EventCredentialNotLoaded, Service: service, Details: status.Detail, Actor: "system"})
+		case store.InjectionUnverified:
+			s.logger.Debug("credential injection not verified", "service", service, "detail", status.Detail)
+		default:
+			s.logger.Info("credential injection verified", "service", service)
+		}
+	}()
+}
@@ -0,0 +1,69 @@
+// Package format checks that a credential's values look like what its
+// provider or type issues, so a token pasted into the wrong field is caught
+// before it is injected and the Gateway restarts for nothing.
+package format
+
+import (
+	"fmt"
+
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Fields returns the fields cred's values are checked against: those of
+// its type if the type has a fixed format, else those of the catalog
+// provider for its service.
+func Fields(cred *store.Credential) []catalog.Field {
+	if fields, ok := catalog.TypeFields(cred.Type); ok {
+		return fields
+	}
+	if p, ok := catalog.Lookup(cred.Service); ok {
+		return p.Fields
+	}
+	return nil
+}
+
+// Validate checks each of cred's access levels against Fields. The error
+// names the level and the field, and never contains the value.
+func Validate(cred *store.Credential) error {
+	fields := Fields(cred)
+	for _, level := range []struct {
+		name   string
+		access *store.AccessLevel
+	}{{"read", cred.Read}, {"readWrite", cred.ReadWrite}} {
+		// A derived level's token is what it mints from, not what the provider takes
+		if level.access == nil || level.access.Derive != nil {
+			continue
+		}
+		for _, f := range fields {
+			if err := f.Validate(Value(level.access, f, len(fields) == 1)); err != nil {
+				return fmt.Errorf("%s: %w", level.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Value returns level's value for f: its token if f is what the level
+// injects (or, with only, the provider's only field), else the additional
+// field for f.
+func Value(level *store.AccessLevel, f catalog.Field, only bool) string {
+	inj := f.Injection
+	if inj == nil {
+		inj = &catalog.Injection{}
+	}
+	switch {
+	case level.GetInjectionType() == store.InjectionEnv && inj.Type == "env" && level.EnvVar == inj.Var,
+		level.GetInjectionType() == store.InjectionConfig && inj.Type == "config" && level.ConfigPath == inj.Path:
+		return level.Token
+	}
+	for _, af := range level.AdditionalFields {
+		if af.Name == f.Name || (inj.Var != "" && af.EnvVar == inj.Var) || (inj.Path != "" && af.ConfigPath == inj.Path) {
+			return af.Value
+		}
+	}
+	if only {
+		return level.Token
+	}
+	return ""
+}
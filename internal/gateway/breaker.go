@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDegraded is returned without contacting the Gateway while the circuit
+// breaker is open after repeated RPC failures.
+var ErrDegraded = errors.New("gateway degraded")
+
+const (
+	// Consecutive failed calls that open the breaker
+	breakerThreshold = 3
+	// How long calls fail fast before a trial call is let through
+	breakerCooldown = 30 * time.Second
+)
+
+// breaker fails RPC calls fast after repeated transport failures, so admin
+// actions don't each wait out a timeout against a Gateway that isn't
+// answering. Once the cooldown passes, one trial call goes through; its
+// outcome closes the breaker or opens it for another cooldown.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int              // Consecutive failures
+	openUntil time.Time        // Zero while closed
+	probing   bool             // A trial call is in flight
+	now       func() time.Time // nil uses time.Now
+}
+
+func (b *breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// allow returns ErrDegraded if the call should fail fast.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if b.probing || b.clock().Before(b.openUntil) {
+		return fmt.Errorf("%w: %d consecutive RPC calls failed, retrying after %s",
+			ErrDegraded, b.failures, b.openUntil.Format(time.RFC3339))
+	}
+	b.probing = true
+	return nil
+}
+
+// record notes the outcome of a call and reports whether the breaker
+// opened or closed as a result.
+func (b *breaker) record(failed bool) (opened, closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		closed = !b.openUntil.IsZero()
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return opened, closed
+	}
+	b.failures++
+	if b.failures >= breakerThreshold {
+		opened = b.openUntil.IsZero()
+		b.openUntil = b.clock().Add(breakerCooldown)
+	}
+	return opened, closed
+}
+
+// isOpen reports whether calls are currently failing fast or on trial.
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
@@ -0,0 +1,176 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/tracing"
+)
+
+// A batch waits at most this many debounce windows after its first change,
+// so a steady stream of changes can't postpone the restart forever.
+const maxDebounceWindows = 4
+
+// restartBatch collects config changes and restart requests that are applied
+// together: one config.patch (which restarts the Gateway) if any change is
+// pending, otherwise one restart.
+type restartBatch struct {
+	ctx     context.Context // Carries the trace of the change that opened the batch
+	sets    []configSet     // Config changes in order; nil if only restarts
+	reasons []string
+	first   time.Time
+	timer   *time.Timer
+	waiters []chan error // Callers blocked until the batch is applied
+}
+
+// SetRestartDebounce makes config patches and restarts wait until no new
+// change has arrived for window, then applies them as a single config.patch
+// or restart, so a burst of credential changes restarts the Gateway once.
+// Patches and restarts still return the batch's result, so a grant the
+// Gateway rejects can be rolled back; they are queued if the Gateway is
+// unreachable and retried after a rate limit before returning. Removals
+// apply at once, flushing anything pending, so a revoked credential never
+// lingers. Zero (the default) applies every change immediately.
+func (c *Client) SetRestartDebounce(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.debounce = window
+}
+
+func (c *Client) debounced() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.debounce > 0
+}
+
+// schedule adds config changes (nil for a plain restart) to the pending
+// batch and returns the batch's result once it is applied. With now, the
+// batch is applied at once; otherwise its timer is pushed back by the
+// debounce window.
+func (c *Client) schedule(ctx context.Context, sets []configSet, reason string, now bool) error {
+	c.mu.Lock()
+	b := c.batch
+	if b == nil {
+		b = &restartBatch{ctx: tracing.Detach(ctx), first: time.Now()}
+		c.batch = b
+	}
+	b.sets = append(b.sets, sets...)
+	b.reasons = append(b.reasons, reason)
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+
+	done := make(chan error, 1)
+	b.waiters = append(b.waiters, done)
+
+	if !now {
+		window := c.debounce
+		delay := min(window, time.Until(b.first.Add(maxDebounceWindows*window)))
+		b.timer = time.AfterFunc(max(delay, 0), func() { c.flush(b) })
+		pending := len(b.reasons)
+		c.mu.Unlock()
+		c.logger.Info("gateway change scheduled", "reason", reason, "pending", pending, "window", window)
+		return <-done
+	}
+
+	c.mu.Unlock()
+	c.flush(b)
+	return <-done
+}
+
+// Flush applies any pending batch now, e.g., before shutdown.
+func (c *Client) Flush() {
+	c.mu.Lock()
+	b := c.batch
+	if b != nil && b.timer != nil {
+		b.timer.Stop()
+	}
+	c.mu.Unlock()
+	if b != nil {
+		c.flush(b)
+	}
+}
+
+// flush applies b unless it was already taken by another flush.
+func (c *Client) flush(b *restartBatch) {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	if c.batch != b {
+		c.mu.Unlock()
+		return
+	}
+	c.batch = nil
+	c.mu.Unlock()
+
+	err := c.applyBatch(b)
+	var rl *ErrRateLimited
+	if errors.As(err, &rl) {
+		// Waiters get the result of the retry
+		c.retryBatch(b, rl.RetryAfter)
+		return
+	}
+	for _, done := range b.waiters {
+		done <- err
+	}
+	if err != nil && !errors.Is(err, ErrQueued) {
+		c.logger.Error("batched gateway change failed", "error", err, "requests", len(b.reasons))
+	}
+}
+
+// applyBatch sends b to the Gateway as one config.patch, built from the
+// config as it is now, or one restart if it holds no config changes.
+func (c *Client) applyBatch(b *restartBatch) error {
+	reason := joinReasons(b.reasons)
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if b.sets == nil {
+		return c.restart(ctx, reason)
+	}
+
+	c.logger.Info("applying batched config patch", "requests", len(b.reasons))
+	if _, err := c.rpcClient.patchConfigSets(ctx, b.sets, reason); err != nil {
+		c.logger.Error("config patch failed", "error", err)
+		return c.deferConfigSets(b.sets, reason, err)
+	}
+	c.logger.Info("config patched successfully")
+	return nil
+}
+
+// retryBatch puts a rate-limited batch back ahead of any changes that
+// arrived since, to be applied once the rate limit allows.
+func (c *Client) retryBatch(b *restartBatch, after time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	retry := &restartBatch{ctx: b.ctx, sets: b.sets, reasons: b.reasons, first: time.Now(), waiters: b.waiters}
+	if next := c.batch; next != nil {
+		if next.timer != nil {
+			next.timer.Stop()
+		}
+		retry.sets = append(retry.sets, next.sets...)
+		retry.reasons = append(retry.reasons, next.reasons...)
+		retry.waiters = append(retry.waiters, next.waiters...)
+	}
+	c.batch = retry
+	retry.timer = time.AfterFunc(after, func() { c.flush(retry) })
+	c.logger.Warn("gateway rate limited, retrying batched changes", "retryAfter", after, "requests", len(retry.reasons))
+}
+
+// joinReasons returns the distinct reasons in order.
+func joinReasons(reasons []string) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, r := range reasons {
+		if !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	return strings.Join(out, "; ")
+}
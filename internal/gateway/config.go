@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// DefaultName is the name of the gateway configured by --gateway-url and
+// --env-file. Credentials that don't select a gateway are injected there.
+const DefaultName = "default"
+
+// Config describes an additional OpenClaw Gateway.
+type Config struct {
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`                  // Gateway RPC URL, e.g., http://staging:18789
+	TokenEnv   string     `json:"tokenEnv,omitempty"`   // Environment variable holding the gateway token; unset disables RPC
+	EnvFile    string     `json:"envFile"`              // The gateway's .env file
+	SecretsDir string     `json:"secretsDir,omitempty"` // See Client.SetSecretsDir
+	TLS        TLSOptions `json:"tls,omitempty"`        // Options for wss URLs
+
+	// Fallback when the Gateway can't restart itself; at most one is set
+	RestartContainer string `json:"restartContainer,omitempty"` // Docker container to restart
+	RestartCommand   string `json:"restartCommand,omitempty"`   // Shell command to run
+}
+
+// Restarter returns the gateway's restart fallback, or nil if none is set.
+func (c Config) Restarter(dockerHost string) (Restarter, error) {
+	return NewRestarter(c.RestartContainer, c.RestartCommand, dockerHost)
+}
+
+var gatewayNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LoadConfigs reads a JSON array of gateway configs from path.
+func LoadConfigs(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	seen := map[string]bool{DefaultName: true}
+	for i, c := range configs {
+		if !gatewayNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("gateway %d: name must be lowercase letters, digits, - or _", i)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("gateway %d: duplicate name %q", i, c.Name)
+		}
+		seen[c.Name] = true
+		if c.URL == "" || c.EnvFile == "" {
+			return nil, fmt.Errorf("gateway %q: url and envFile are required", c.Name)
+		}
+		if _, err := c.TLS.Config(); err != nil {
+			return nil, fmt.Errorf("gateway %q: tls: %w", c.Name, err)
+		}
+		if _, err := c.Restarter(""); err != nil {
+			return nil, fmt.Errorf("gateway %q: %w", c.Name, err)
+		}
+	}
+	return configs, nil
+}
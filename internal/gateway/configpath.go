@@ -0,0 +1,402 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a config path: an object key or an array index.
+type pathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// ParseConfigPath parses a config path such as "channels.slack[0].userToken".
+// A backslash escapes the next character, so "models.openai\.com.apiKey"
+// addresses the key "openai.com".
+func ParseConfigPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty config path")
+	}
+	var segs []pathSegment
+	var key strings.Builder
+	keyPending := true // A key is expected (start of path or after '.')
+
+	endKey := func() error {
+		if key.Len() == 0 {
+			return fmt.Errorf("config path %q: empty key", path)
+		}
+		segs = append(segs, pathSegment{Key: key.String()})
+		key.Reset()
+		keyPending = false
+		return nil
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch ch := path[i]; ch {
+		case '\\':
+			if i+1 == len(path) {
+				return nil, fmt.Errorf("config path %q: trailing backslash", path)
+			}
+			i++
+			key.WriteByte(path[i])
+		case '.':
+			if keyPending {
+				if err := endKey(); err != nil {
+					return nil, err
+				}
+			}
+			keyPending = true
+		case '[':
+			if keyPending {
+				if err := endKey(); err != nil {
+					return nil, err
+				}
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("config path %q: unclosed [", path)
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("config path %q: invalid index %q", path, path[i+1:i+end])
+			}
+			segs = append(segs, pathSegment{Index: n, IsIndex: true})
+			i += end
+			if i+1 < len(path) && path[i+1] != '.' && path[i+1] != '[' {
+				return nil, fmt.Errorf("config path %q: expected . or [ after ]", path)
+			}
+		default:
+			if !keyPending {
+				return nil, fmt.Errorf("config path %q: expected . or [ after ]", path)
+			}
+			key.WriteByte(ch)
+		}
+	}
+	if keyPending {
+		if err := endKey(); err != nil {
+			return nil, err
+		}
+	}
+	return segs, nil
+}
+
+// configSet sets a config path to Value, or deletes it if Value is nil.
+// Config changes are batched and queued as these rather than as merge
+// patches, so a path through an array is merged with the config as it is
+// when the change is applied, not when it was made.
+type configSet struct {
+	Path  string  `json:"path"`
+	Value *string `json:"value"`
+}
+
+// newConfigSets returns the changes that set each path to its value, or
+// with clear delete it. Paths are checked here; whether they fit the config
+// is only known when the changes are applied.
+func newConfigSets(values []ConfigCredential, clear bool) ([]configSet, error) {
+	sets := make([]configSet, len(values))
+	for i, v := range values {
+		segs, err := ParseConfigPath(v.Path)
+		if err != nil {
+			return nil, err
+		}
+		if segs[0].IsIndex {
+			return nil, fmt.Errorf("config path %q: must start with a key", v.Path)
+		}
+		sets[i].Path = v.Path
+		if !clear {
+			value := v.Value
+			sets[i].Value = &value
+		}
+	}
+	return sets, nil
+}
+
+// buildConfigPatch builds a JSON merge patch that sets each path to its
+// value, or with clear deletes it (see buildSetPatch).
+func buildConfigPatch(values []ConfigCredential, clear bool, current func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	sets, err := newConfigSets(values, clear)
+	if err != nil {
+		return nil, err
+	}
+	return buildSetPatch(sets, current)
+}
+
+// buildSetPatch builds a JSON merge patch applying sets in order, so later
+// ones win. Missing objects are created. Merge patches replace arrays
+// wholesale, so a path through an array index copies that array from the
+// current config, fetched at most once via current.
+func buildSetPatch(sets []configSet, current func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	var base map[string]interface{}
+	fetched := false
+	getBase := func() (map[string]interface{}, error) {
+		if !fetched {
+			cfg, err := current()
+			if err != nil {
+				return nil, fmt.Errorf("read current config: %w", err)
+			}
+			base, fetched = cfg, true
+		}
+		return base, nil
+	}
+
+	patch := make(map[string]interface{})
+	for _, set := range sets {
+		segs, err := ParseConfigPath(set.Path)
+		if err != nil {
+			return nil, err
+		}
+		if segs[0].IsIndex {
+			return nil, fmt.Errorf("config path %q: must start with a key", set.Path)
+		}
+		var value interface{}
+		if set.Value != nil {
+			value = *set.Value
+		}
+		if err := setPath(patch, segs, value, false, set.Path, getBase, nil); err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+// setPath sets segs[0] (a key) in node. Inside a copied array element
+// (literal), nil removes the key rather than writing a merge-patch null.
+// curPath leads from the config root to node, for looking up arrays; it is
+// unused inside an element, which is already a full copy.
+func setPath(node map[string]interface{}, segs []pathSegment, value interface{}, literal bool, path string,
+	getBase func() (map[string]interface{}, error), curPath []pathSegment) error {
+	key := segs[0].Key
+	curPath = append(curPath, segs[0])
+
+	if len(segs) == 1 {
+		if literal && value == nil {
+			delete(node, key)
+		} else {
+			node[key] = value
+		}
+		return nil
+	}
+
+	if !segs[1].IsIndex {
+		child, ok := node[key].(map[string]interface{})
+		if !ok {
+			if existing, set := node[key]; set && existing != nil {
+				return fmt.Errorf("config path %q: %s is already set to a value", path, key)
+			}
+			child = make(map[string]interface{})
+			node[key] = child
+		}
+		return setPath(child, segs[1:], value, literal, path, getBase, curPath)
+	}
+
+	// Array: start from the copy already in the patch, or the current config
+	arr, ok := node[key].([]interface{})
+	if !ok {
+		if existing, set := node[key]; set && existing != nil {
+			return fmt.Errorf("config path %q: %s is not an array", path, key)
+		}
+		if literal {
+			// Inside a copied element, a missing array is really missing
+			arr = []interface{}{}
+		} else {
+			base, err := getBase()
+			if err != nil {
+				return err
+			}
+			cur, err := lookup(base, curPath, path)
+			if err != nil {
+				return err
+			}
+			arr = deepCopy(cur).([]interface{})
+		}
+	}
+
+	idx := segs[1].Index
+	switch {
+	case idx == len(arr):
+		arr = append(arr, nil)
+	case idx > len(arr):
+		return fmt.Errorf("config path %q: index %d past the end of %s (length %d)", path, idx, key, len(arr))
+	}
+	node[key] = arr
+
+	if len(segs) == 2 {
+		arr[idx] = value
+		return nil
+	}
+	elem, ok := arr[idx].(map[string]interface{})
+	if !ok {
+		if arr[idx] != nil {
+			return fmt.Errorf("config path %q: %s[%d] is not an object", path, key, idx)
+		}
+		elem = make(map[string]interface{})
+		arr[idx] = elem
+	}
+	if segs[2].IsIndex {
+		return fmt.Errorf("config path %q: nested arrays are not supported", path)
+	}
+	return setPath(elem, segs[2:], value, true, path, getBase, nil)
+}
+
+// lookup returns the array at segs in cfg, or an empty array if it doesn't
+// exist yet.
+func lookup(cfg map[string]interface{}, segs []pathSegment, path string) ([]interface{}, error) {
+	var node interface{} = cfg
+	for _, seg := range segs {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("config path %q: %s is not an object in the current config", path, seg.Key)
+		}
+		if node, ok = obj[seg.Key]; !ok || node == nil {
+			return []interface{}{}, nil
+		}
+	}
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config path %q: not an array in the current config", path)
+	}
+	return arr, nil
+}
+
+// ConfigValue returns the value at path in cfg, as returned by config.get.
+func ConfigValue(cfg map[string]interface{}, path string) (interface{}, bool) {
+	segs, err := ParseConfigPath(path)
+	if err != nil {
+		return nil, false
+	}
+	return getPath(cfg, segs)
+}
+
+// getPath returns the value at segs in cfg.
+func getPath(cfg map[string]interface{}, segs []pathSegment) (interface{}, bool) {
+	var node interface{} = cfg
+	for _, seg := range segs {
+		if seg.IsIndex {
+			arr, ok := node.([]interface{})
+			if !ok || seg.Index >= len(arr) {
+				return nil, false
+			}
+			node = arr[seg.Index]
+			continue
+		}
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = obj[seg.Key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	}
+	return v
+}
+
+// validatePatch checks that raw is a non-empty JSON object, which is also
+// valid JSON5, before it is sent to config.patch.
+func validatePatch(raw string) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return fmt.Errorf("config patch is not a JSON object: %w", err)
+	}
+	if obj == nil {
+		return fmt.Errorf("config patch is not a JSON object")
+	}
+	return nil
+}
+
+// FindInConfig returns the path of every string in cfg, as returned by
+// config.get, that contains value, in a stable order. Paths are in
+// ParseConfigPath syntax, so they compare equal to a credential's config
+// path with SameConfigPath.
+func FindInConfig(cfg map[string]interface{}, value string) []string {
+	if value == "" {
+		return nil
+	}
+	var found []string
+	var walk func(node interface{}, path []pathSegment)
+	walk = func(node interface{}, path []pathSegment) {
+		switch v := node.(type) {
+		case string:
+			if strings.Contains(v, value) {
+				found = append(found, formatConfigPath(path))
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k], append(path[:len(path):len(path)], pathSegment{Key: k}))
+			}
+		case []interface{}:
+			for i, e := range v {
+				walk(e, append(path[:len(path):len(path)], pathSegment{Index: i, IsIndex: true}))
+			}
+		}
+	}
+	walk(cfg, nil)
+	return found
+}
+
+// formatConfigPath is the inverse of ParseConfigPath.
+func formatConfigPath(segs []pathSegment) string {
+	var b strings.Builder
+	for i, seg := range segs {
+		if seg.IsIndex {
+			fmt.Fprintf(&b, "[%d]", seg.Index)
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		for j := 0; j < len(seg.Key); j++ {
+			if ch := seg.Key[j]; ch == '\\' || ch == '.' || ch == '[' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(seg.Key[j])
+		}
+	}
+	return b.String()
+}
+
+// SameConfigPath reports whether a and b address the same value, however
+// they are escaped. Invalid paths address nothing.
+func SameConfigPath(a, b string) bool {
+	sa, err := ParseConfigPath(a)
+	if err != nil {
+		return false
+	}
+	sb, err := ParseConfigPath(b)
+	if err != nil || len(sa) != len(sb) {
+		return false
+	}
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
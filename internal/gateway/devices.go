@@ -3,14 +3,17 @@
 package gateway
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	mathrand "math/rand/v2"
 	"net/http"
 	"net/url"
 	"os"
@@ -21,6 +24,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/openclaw/ocm/internal/sentry"
+	"github.com/openclaw/ocm/internal/tracing"
 )
 
 // PendingDevice represents a device waiting for pairing approval.
@@ -80,6 +85,8 @@ type deviceIdentity struct {
 type RPCClient struct {
 	gatewayURL       string
 	token            string
+	tlsConfig        *tls.Config // For wss; nil uses the defaults
+	logger           *slog.Logger
 	identity         *deviceIdentity
 	conn             *websocket.Conn
 	mu               sync.Mutex   // Protects conn, nextID
@@ -91,93 +98,152 @@ type RPCClient struct {
 	needsPairing     bool   // True if last connect failed due to pairing requirement
 	tokenMismatch    bool   // True if last connect failed due to token mismatch
 	pendingRequestID string // Request ID for pending pairing, if known
+	caps             *Capabilities // Negotiated on the last successful connect
 	readDone         chan struct{}
+	stateListeners   []func(from, to string) // Guarded by statusMu
+	pairListeners    []func(PendingDevice)   // Guarded by statusMu
+	stop             chan struct{}           // Closed by Close to end supervision
+	stopOnce         sync.Once
+	pingInterval     time.Duration
+	pongWait         time.Duration
+	breaker          breaker // Fails calls fast while the Gateway isn't answering
+	configMu         sync.Mutex // Serializes config.get + config.patch pairs so baseHash stays current
+	name             string     // Gateway name in metrics; guarded by statusMu
+	lastErr          string     // Last failed operation, for Stats; guarded by statusMu
+	lastErrAt        time.Time
 }
 
-// NewRPCClient creates a new RPC client.
-func NewRPCClient(gatewayURL, token string) *RPCClient {
-	identity, err := loadOrCreateIdentity()
+const (
+	// Reconnect backoff bounds; each failed attempt doubles the delay
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+	// unreachableAfter consecutive failed attempts (about half a minute) are
+	// logged as an error rather than a warning
+	unreachableAfter = 6
+)
+
+// Heartbeat timing. The connection is considered dead if nothing, not even
+// a pong, arrives within pongWait, which catches half-open connections left
+// behind by NAT timeouts and Gateway restarts.
+const (
+	defaultPingInterval = 5 * time.Second
+	defaultPongWait     = 15 * time.Second
+)
+
+// NewRPCClient creates a new RPC client. tlsConfig applies to wss URLs and
+// may be nil.
+func NewRPCClient(gatewayURL, token string, tlsConfig *tls.Config, logger *slog.Logger) *RPCClient {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("gateway", gatewayURL)
+
+	identity, err := loadOrCreateIdentity(logger)
 	if err != nil {
 		// Log but continue - will fail on connect if identity is required
-		fmt.Fprintf(os.Stderr, "warning: failed to load device identity: %v\n", err)
+		logger.Warn("failed to load device identity", "error", err)
 	}
 
 	client := &RPCClient{
 		gatewayURL: gatewayURL,
 		token:      token,
+		tlsConfig:  tlsConfig,
+		logger:     logger,
 		identity:   identity,
 		pending:    make(map[string]chan *rpcMessage),
+		stop:       make(chan struct{}),
+
+		pingInterval: defaultPingInterval,
+		pongWait:     defaultPongWait,
 	}
 
-	// Start auto-connect in background
-	go client.autoConnect()
+	// Keep connected in background for the life of the client
+	go client.supervise()
 
 	return client
 }
 
-// autoConnect attempts to connect on startup and retries until successful.
-func (c *RPCClient) autoConnect() {
-	retryInterval := 5 * time.Second
-	maxRetries := 60 // Try for 5 minutes
+// supervise connects and reconnects whenever the connection drops, until
+// Close is called. Failed attempts back off exponentially with jitter so a
+// restarting Gateway isn't hammered by every client at once.
+func (c *RPCClient) supervise() {
+	defer sentry.Recover()
+	delay := reconnectMinDelay
+	attempt := 0
 	pairingInstructionsShown := false
 
-	for i := 0; i < maxRetries; i++ {
-		if c.IsConnected() {
-			fmt.Fprintf(os.Stderr, "INFO: gateway RPC connected successfully\n")
-			return
-		}
-
+	for {
 		err := c.Connect()
+		c.count(OpConnect, err)
 		if err == nil {
-			fmt.Fprintf(os.Stderr, "INFO: gateway RPC connected successfully\n")
+			if caps := c.Capabilities(); caps != nil {
+				c.logger.Info("gateway RPC connected", "protocol", caps.Protocol, "serverVersion", caps.ServerVersion)
+			}
+			if _, closed := c.breaker.record(false); closed {
+				c.logger.Info("gateway recovered")
+			}
+			delay = reconnectMinDelay
+			attempt = 0
+
+			// Wait for the connection to drop
+			c.mu.Lock()
+			done := c.readDone
+			c.mu.Unlock()
+			select {
+			case <-c.stop:
+				return
+			case <-done:
+			}
+			select {
+			case <-c.stop:
+				return
+			default:
+			}
+			c.logger.Warn("gateway RPC connection lost, reconnecting")
+			c.count(OpDisconnect, errConnectionLost)
+			continue
+		}
+		attempt++
+
+		// Pairing just needs user action: explain it once, then stay quiet
+		if strings.Contains(err.Error(), "pairing required") {
+			if !pairingInstructionsShown {
+				pairingInstructionsShown = true
+				c.logger.Warn("OCM device pairing required, waiting for approval",
+					"deviceId", c.GetDeviceID(),
+					"list", "docker exec -it openclaw node /app/dist/index.js devices list",
+					"approve", "docker exec -it openclaw node /app/dist/index.js devices approve <requestId>")
+			}
+		} else if attempt == unreachableAfter {
+			// Once per outage, at error so it is reported
+			c.logger.Error("gateway RPC unreachable", "attempts", attempt, "retryIn", delay, "error", err)
+		} else {
+			c.logger.Warn("gateway RPC connect failed", "attempt", attempt, "retryIn", delay, "error", err)
+		}
+
+		select {
+		case <-c.stop:
 			return
+		case <-time.After(withJitter(delay)):
 		}
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+}
 
-		errStr := err.Error()
-		
-		// Check if pairing is required and show helpful instructions (once)
-		if strings.Contains(errStr, "pairing required") && !pairingInstructionsShown {
-			pairingInstructionsShown = true
-			deviceID := c.GetDeviceID()
-			fmt.Fprintf(os.Stderr, "\n")
-			fmt.Fprintf(os.Stderr, "╔══════════════════════════════════════════════════════════════════╗\n")
-			fmt.Fprintf(os.Stderr, "║  🔐 OCM Device Pairing Required                                  ║\n")
-			fmt.Fprintf(os.Stderr, "╠══════════════════════════════════════════════════════════════════╣\n")
-			fmt.Fprintf(os.Stderr, "║  OCM needs to be approved to connect to OpenClaw.                ║\n")
-			fmt.Fprintf(os.Stderr, "║                                                                  ║\n")
-			fmt.Fprintf(os.Stderr, "║  Run this command to approve:                                    ║\n")
-			fmt.Fprintf(os.Stderr, "║                                                                  ║\n")
-			fmt.Fprintf(os.Stderr, "║    docker exec -it openclaw node /app/dist/index.js devices list ║\n")
-			fmt.Fprintf(os.Stderr, "║                                                                  ║\n")
-			fmt.Fprintf(os.Stderr, "║  Then approve the pending request for device:                    ║\n")
-			fmt.Fprintf(os.Stderr, "║    %.60s...  ║\n", deviceID)
-			fmt.Fprintf(os.Stderr, "║                                                                  ║\n")
-			fmt.Fprintf(os.Stderr, "║    docker exec -it openclaw node /app/dist/index.js devices      ║\n")
-			fmt.Fprintf(os.Stderr, "║      approve <requestId>                                         ║\n")
-			fmt.Fprintf(os.Stderr, "║                                                                  ║\n")
-			fmt.Fprintf(os.Stderr, "║  Waiting for approval...                                         ║\n")
-			fmt.Fprintf(os.Stderr, "╚══════════════════════════════════════════════════════════════════╝\n")
-			fmt.Fprintf(os.Stderr, "\n")
-		} else if !strings.Contains(errStr, "pairing required") {
-			// Only log non-pairing errors (pairing just needs user action)
-			fmt.Fprintf(os.Stderr, "INFO: gateway RPC connect attempt %d failed: %v\n", i+1, err)
-		}
-
-		time.Sleep(retryInterval)
-	}
-
-	fmt.Fprintf(os.Stderr, "ERROR: gateway RPC failed to connect after %d attempts\n", maxRetries)
+// withJitter returns a random duration in [d/2, d).
+func withJitter(d time.Duration) time.Duration {
+	return d/2 + mathrand.N(d/2)
 }
 
 // loadOrCreateIdentity loads or creates an Ed25519 keypair for device identity.
-func loadOrCreateIdentity() (*deviceIdentity, error) {
+func loadOrCreateIdentity(logger *slog.Logger) (*deviceIdentity, error) {
 	// Store identity in /data (mounted volume) or fallback to temp
 	keyPath := "/data/ocm-device.key"
 	if _, err := os.Stat("/data"); os.IsNotExist(err) {
 		keyPath = filepath.Join(os.TempDir(), "ocm-device.key")
-		fmt.Fprintf(os.Stderr, "INFO: /data not found, using temp path: %s\n", keyPath)
+		logger.Warn("/data not found, device identity will not survive a reboot", "path", keyPath)
 	} else {
-		fmt.Fprintf(os.Stderr, "INFO: using key path: %s\n", keyPath)
+		logger.Debug("using device identity path", "path", keyPath)
 	}
 
 	// Try to load existing key
@@ -185,14 +251,14 @@ func loadOrCreateIdentity() (*deviceIdentity, error) {
 		privateKey := ed25519.NewKeyFromSeed(data)
 		publicKey := privateKey.Public().(ed25519.PublicKey)
 		deviceID := computeDeviceID(publicKey)
-		fmt.Fprintf(os.Stderr, "INFO: loaded existing device identity: %s\n", deviceID)
+		logger.Info("loaded device identity", "deviceId", deviceID)
 		return &deviceIdentity{
 			PrivateKey: privateKey,
 			PublicKey:  publicKey,
 			DeviceID:   deviceID,
 		}, nil
 	} else if err != nil {
-		fmt.Fprintf(os.Stderr, "INFO: no existing key found (%v), generating new\n", err)
+		logger.Debug("no existing device key, generating one", "error", err)
 	}
 
 	// Generate new keypair
@@ -204,13 +270,11 @@ func loadOrCreateIdentity() (*deviceIdentity, error) {
 	// Save seed for persistence
 	seed := privateKey.Seed()
 	if err := os.WriteFile(keyPath, seed, 0600); err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: failed to save device identity to %s: %v\n", keyPath, err)
-	} else {
-		fmt.Fprintf(os.Stderr, "INFO: saved new device identity to %s\n", keyPath)
+		logger.Error("failed to save device identity", "path", keyPath, "error", err)
 	}
 
 	deviceID := computeDeviceID(publicKey)
-	fmt.Fprintf(os.Stderr, "INFO: generated new device identity: %s\n", deviceID)
+	logger.Info("generated device identity", "deviceId", deviceID, "path", keyPath)
 	return &deviceIdentity{
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
@@ -256,6 +320,11 @@ func (c *RPCClient) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// Another caller may have connected while we waited for mu
+	if c.IsConnected() {
+		return nil
+	}
+
 	// Convert HTTP URL to WebSocket URL
 	u, err := url.Parse(c.gatewayURL)
 	if err != nil {
@@ -269,6 +338,7 @@ func (c *RPCClient) Connect() error {
 
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:  c.tlsConfig,
 	}
 
 	conn, _, err := dialer.Dial(u.String(), http.Header{})
@@ -276,9 +346,6 @@ func (c *RPCClient) Connect() error {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
 
-	c.conn = conn
-	c.readDone = make(chan struct{})
-
 	// Wait for connect.challenge event from Gateway
 	var challengeMsg rpcMessage
 	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
@@ -304,13 +371,13 @@ func (c *RPCClient) Connect() error {
 	// Build connect params
 	clientID := "cli"
 	clientMode := "cli"
-	role := "operator"
-	scopes := []string{"operator.admin"}
+	role := clientRole
+	scopes := clientScopes
 	signedAt := time.Now().UnixMilli()
 
 	connectParams := map[string]interface{}{
-		"minProtocol": 3,
-		"maxProtocol": 3,
+		"minProtocol": minProtocol,
+		"maxProtocol": maxProtocol,
 		"client": map[string]interface{}{
 			"id":       clientID,
 			"version":  "0.1.0",
@@ -337,14 +404,11 @@ func (c *RPCClient) Connect() error {
 		pubKeyB64 := base64.RawURLEncoding.EncodeToString(c.identity.PublicKey)
 		sigB64 := base64.RawURLEncoding.EncodeToString(signature)
 
-		// Debug logging via slog
-		slog.Info("device auth debug",
-			"payload", payload,
+		// The payload embeds the gateway token, so it is never logged
+		c.logger.Debug("signing device auth",
 			"deviceId", c.identity.DeviceID,
-			"publicKey", pubKeyB64,
 			"signedAt", signedAt,
 			"nonce", challenge.Nonce,
-			"token", c.token,
 		)
 
 		// OpenClaw expects base64url encoding for public key and signature
@@ -392,51 +456,61 @@ func (c *RPCClient) Connect() error {
 		conn.Close()
 		
 		// Track connection status for UI
-		c.statusMu.Lock()
-		if strings.Contains(errMsg, "pairing required") {
-			c.needsPairing = true
-			c.tokenMismatch = false
-			// Try to extract requestId from error details if available
-			if helloMsg.Error != nil {
-				if payload, ok := helloMsg.Payload.(map[string]interface{}); ok {
-					if reqID, ok := payload["requestId"].(string); ok {
-						c.pendingRequestID = reqID
+		c.setStatus(func() {
+			if strings.Contains(errMsg, "pairing required") {
+				c.needsPairing = true
+				c.tokenMismatch = false
+				// Try to extract requestId from error details if available
+				if helloMsg.Error != nil {
+					if payload, ok := helloMsg.Payload.(map[string]interface{}); ok {
+						if reqID, ok := payload["requestId"].(string); ok {
+							c.pendingRequestID = reqID
+						}
 					}
 				}
+			} else if strings.Contains(errMsg, "token mismatch") || strings.Contains(errMsg, "unauthorized") {
+				c.tokenMismatch = true
+				c.needsPairing = false
 			}
-		} else if strings.Contains(errMsg, "token mismatch") || strings.Contains(errMsg, "unauthorized") {
-			c.tokenMismatch = true
-			c.needsPairing = false
-		}
-		c.statusMu.Unlock()
+		})
 		
 		return fmt.Errorf("connect rejected: %s", errMsg)
 	}
 
-	// Clear error flags and set connected on success
-	c.statusMu.Lock()
-	c.needsPairing = false
-	c.tokenMismatch = false
-	c.pendingRequestID = ""
-	c.connected = true
-	c.statusMu.Unlock()
+	caps, err := parseHello(helloMsg.Payload)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.conn = conn
+	c.readDone = make(chan struct{})
 	c.nextID = 1 // Start from 2 for subsequent calls (1 used for connect)
 
+	// Clear error flags and set connected on success
+	c.setStatus(func() {
+		c.needsPairing = false
+		c.tokenMismatch = false
+		c.pendingRequestID = ""
+		c.caps = caps
+		c.connected = true
+	})
+
 	// Start reading responses
-	go c.readLoop()
+	go c.readLoop(conn, c.readDone)
 
 	return nil
 }
 
-// Close closes the WebSocket connection.
+// Close closes the WebSocket connection and stops reconnecting.
 func (c *RPCClient) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.conn != nil {
-		c.statusMu.Lock()
-		c.connected = false
-		c.statusMu.Unlock()
+		c.setStatus(func() { c.connected = false })
 		err := c.conn.Close()
 		if c.readDone != nil {
 			<-c.readDone // Wait for read loop to exit
@@ -446,18 +520,27 @@ func (c *RPCClient) Close() error {
 	return nil
 }
 
-// readLoop reads messages from the WebSocket.
-func (c *RPCClient) readLoop() {
-	defer close(c.readDone)
+// readLoop reads messages from conn until it fails or goes quiet for
+// c.pongWait, then marks the client disconnected so the supervisor reconnects.
+func (c *RPCClient) readLoop(conn *websocket.Conn, done chan struct{}) {
+	defer close(done)
+
+	conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	})
+	go c.heartbeat(conn, done)
+
 	for {
 		var msg rpcMessage
-		err := c.conn.ReadJSON(&msg)
+		err := conn.ReadJSON(&msg)
 		if err != nil {
-			c.statusMu.Lock()
-			c.connected = false
-			c.statusMu.Unlock()
+			conn.Close()
+			c.setStatus(func() { c.connected = false })
+			c.failPending("connection lost: " + err.Error())
 			return
 		}
+		conn.SetReadDeadline(time.Now().Add(c.pongWait))
 
 		// Route response to waiting caller
 		if msg.Type == "res" && msg.ID != "" {
@@ -468,12 +551,121 @@ func (c *RPCClient) readLoop() {
 			}
 			c.pendingMu.Unlock()
 		}
-		// Ignore events for now (could handle node.pair.requested etc.)
+		if msg.Type == "event" {
+			c.handleEvent(&msg)
+		}
 	}
 }
 
-// call makes an RPC call and waits for response.
+// handleEvent reacts to events pushed by the Gateway. Unknown events are ignored.
+func (c *RPCClient) handleEvent(msg *rpcMessage) {
+	switch msg.Event {
+	case "device.pair.requested":
+		data, err := json.Marshal(msg.Payload)
+		if err != nil {
+			return
+		}
+		var device PendingDevice
+		if err := json.Unmarshal(data, &device); err != nil || device.RequestID == "" {
+			c.logger.Warn("ignoring malformed device.pair.requested event", "error", err)
+			return
+		}
+
+		c.statusMu.Lock()
+		if c.identity != nil && device.DeviceID == c.identity.DeviceID {
+			c.pendingRequestID = device.RequestID
+		}
+		listeners := c.pairListeners
+		c.statusMu.Unlock()
+
+		// Listeners may write audit entries or send notifications; none of
+		// that may hold up the read loop
+		for _, fn := range listeners {
+			go fn(device)
+		}
+	}
+}
+
+// OnPairRequest registers fn to be called, on its own goroutine, when a
+// device asks the Gateway to pair.
+func (c *RPCClient) OnPairRequest(fn func(PendingDevice)) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.pairListeners = append(c.pairListeners, fn)
+}
+
+// heartbeat pings conn every c.pingInterval until done is closed. A failed
+// ping closes conn, which ends readLoop.
+func (c *RPCClient) heartbeat(conn *websocket.Conn, done chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		// WriteControl is safe alongside WriteJSON in call
+		if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.pingInterval)); err != nil {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// failPending answers every in-flight call with an error so callers don't
+// wait out their timeout on a dead connection.
+func (c *RPCClient) failPending(reason string) {
+	ok := false
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	for id, ch := range c.pending {
+		ch <- &rpcMessage{Type: "res", ID: id, OK: &ok, Error: &rpcError{Code: "UNAVAILABLE", Message: reason}}
+		delete(c.pending, id)
+	}
+}
+
+// call makes an RPC call and waits for response. After repeated transport
+// failures it returns ErrDegraded immediately until the breaker's cooldown
+// has passed.
 func (c *RPCClient) call(method string, params interface{}) (*rpcMessage, error) {
+	return c.callContext(context.Background(), method, params)
+}
+
+// callContext is call, traced as part of the span in ctx.
+func (c *RPCClient) callContext(ctx context.Context, method string, params interface{}) (resp *rpcMessage, err error) {
+	_, span := tracing.StartClient(ctx, "gateway.rpc "+method, "rpc.method", method)
+	defer func() {
+		if err == nil && resp != nil && resp.Error != nil {
+			span.SetAttributes("rpc.error_code", resp.Error.Code)
+		}
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if !c.Supports(method) {
+		return nil, fmt.Errorf("%s: %w", method, ErrUnsupported)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err = c.roundTrip(method, params)
+	failed := err != nil || (resp.Error != nil && resp.Error.Code == "UNAVAILABLE")
+	switch opened, closed := c.breaker.record(failed); {
+	case opened:
+		c.logger.Warn("gateway degraded, failing RPC calls fast", "method", method, "error", err, "cooldown", breakerCooldown)
+	case closed:
+		c.logger.Info("gateway recovered")
+	}
+	return resp, err
+}
+
+// Degraded reports whether the circuit breaker is open.
+func (c *RPCClient) Degraded() bool {
+	return c.breaker.isOpen()
+}
+
+func (c *RPCClient) roundTrip(method string, params interface{}) (*rpcMessage, error) {
 	c.statusMu.RLock()
 	connected := c.connected
 	c.statusMu.RUnlock()
@@ -620,6 +812,51 @@ func (c *RPCClient) GetPendingRequestID() string {
 	return c.pendingRequestID
 }
 
+// ConnectionState summarizes the RPC connection: "connected", "disconnected",
+// "pairing_needed" or "token_mismatch".
+func (c *RPCClient) ConnectionState() string {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	return c.stateLocked()
+}
+
+func (c *RPCClient) stateLocked() string {
+	switch {
+	case c.connected:
+		return "connected"
+	case c.needsPairing:
+		return "pairing_needed"
+	case c.tokenMismatch:
+		return "token_mismatch"
+	}
+	return "disconnected"
+}
+
+// OnStateChange registers fn to be called with the previous and new
+// ConnectionState whenever it changes. fn must not block.
+func (c *RPCClient) OnStateChange(fn func(from, to string)) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.stateListeners = append(c.stateListeners, fn)
+}
+
+// setStatus applies update to the status fields and notifies state
+// listeners if the connection state changed as a result.
+func (c *RPCClient) setStatus(update func()) {
+	c.statusMu.Lock()
+	from := c.stateLocked()
+	update()
+	to := c.stateLocked()
+	listeners := c.stateListeners
+	c.statusMu.Unlock()
+
+	if from != to {
+		for _, fn := range listeners {
+			fn(from, to)
+		}
+	}
+}
+
 // ErrRestartDisabled is returned when Gateway restart is not enabled in OpenClaw config.
 var ErrRestartDisabled = fmt.Errorf("gateway restart disabled")
 
@@ -667,7 +904,12 @@ var ErrConfigFileLocked = fmt.Errorf("config file locked (EBUSY)")
 // The reason/note is logged by the Gateway.
 // Returns ErrRateLimited if rate limited, ErrConfigFileLocked if file is locked.
 func (c *RPCClient) RestartGateway(reason string) error {
-	err := c.tryRestartGateway(reason)
+	return c.restartGateway(context.Background(), reason)
+}
+
+func (c *RPCClient) restartGateway(ctx context.Context, reason string) (err error) {
+	defer func() { c.count(OpRestart, err) }()
+	err = c.tryRestartGateway(ctx, reason)
 	if err == nil {
 		return nil
 	}
@@ -693,8 +935,48 @@ func (c *RPCClient) RestartGateway(reason string) error {
 // The patch is merged with the existing config using JSON merge patch semantics.
 // Returns the new config hash on success.
 func (c *RPCClient) PatchConfig(patch string, reason string) (string, error) {
+	return c.patchConfig(context.Background(), patch, reason)
+}
+
+func (c *RPCClient) patchConfig(ctx context.Context, patch string, reason string) (string, error) {
+	if err := validatePatch(patch); err != nil {
+		c.count(OpConfigPatch, err)
+		return "", err
+	}
+	return c.patchConfigWith(ctx, reason, func(func() (map[string]interface{}, error)) (string, error) {
+		return patch, nil
+	})
+}
+
+// patchConfigSets applies sets as one config.patch. The patch is built from
+// the config read for its baseHash, so an array it copies is the one the
+// Gateway has now, not the one it had when the change was made.
+func (c *RPCClient) patchConfigSets(ctx context.Context, sets []configSet, reason string) (string, error) {
+	return c.patchConfigWith(ctx, reason, func(current func() (map[string]interface{}, error)) (string, error) {
+		patch, err := buildSetPatch(sets, current)
+		if err != nil {
+			return "", fmt.Errorf("build config patch: %w", err)
+		}
+		patchJSON, err := json.Marshal(patch)
+		if err != nil {
+			return "", fmt.Errorf("marshal config patch: %w", err)
+		}
+		return string(patchJSON), nil
+	})
+}
+
+// patchConfigWith applies the patch build returns under configMu. build may
+// read the config that was fetched for the baseHash via current.
+func (c *RPCClient) patchConfigWith(ctx context.Context, reason string,
+	build func(current func() (map[string]interface{}, error)) (string, error)) (hash string, err error) {
+	defer func() { c.count(OpConfigPatch, err) }()
+	if !c.Supports("config.patch") {
+		return "", fmt.Errorf("config.patch: %w", ErrUnsupported)
+	}
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
 	// First, get current config to get the baseHash
-	getResp, err := c.call("config.get", map[string]interface{}{})
+	getResp, err := c.callContext(ctx, "config.get", map[string]interface{}{})
 	if err != nil {
 		return "", fmt.Errorf("config.get failed: %w", err)
 	}
@@ -714,8 +996,13 @@ func (c *RPCClient) PatchConfig(patch string, reason string) (string, error) {
 		}
 	}
 
+	patch, err := build(func() (map[string]interface{}, error) { return configFromPayload(getResp.Payload) })
+	if err != nil {
+		return "", err
+	}
+
 	// Call config.patch
-	resp, err := c.call("config.patch", map[string]interface{}{
+	resp, err := c.callContext(ctx, "config.patch", map[string]interface{}{
 		"raw":            patch,
 		"baseHash":       baseHash,
 		"note":           reason,
@@ -766,8 +1053,12 @@ func (c *RPCClient) GetConfig() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("config.get error: %s", errMsg)
 	}
 
-	// Extract config from payload
-	if payload, ok := resp.Payload.(map[string]interface{}); ok {
+	return configFromPayload(resp.Payload)
+}
+
+// configFromPayload extracts the config from a config.get response payload.
+func configFromPayload(p interface{}) (map[string]interface{}, error) {
+	if payload, ok := p.(map[string]interface{}); ok {
 		if config, ok := payload["config"].(map[string]interface{}); ok {
 			return config, nil
 		}
@@ -779,10 +1070,83 @@ func (c *RPCClient) GetConfig() (map[string]interface{}, error) {
 	return nil, fmt.Errorf("unexpected config.get response format")
 }
 
+// GetEnv fetches the values of the named environment variables from the
+// running Gateway process via env.get. Variables it doesn't have are absent
+// from the result. Only Gateways that advertise env.get implement it.
+func (c *RPCClient) GetEnv(names []string) (map[string]string, error) {
+	if !c.Capabilities().Advertises("env.get") {
+		return nil, fmt.Errorf("env.get: %w", ErrUnsupported)
+	}
+	resp, err := c.call("env.get", map[string]interface{}{"keys": names})
+	if err != nil {
+		return nil, fmt.Errorf("env.get failed: %w", err)
+	}
+	if resp.OK != nil && !*resp.OK {
+		errMsg := "unknown error"
+		if resp.Error != nil {
+			errMsg = resp.Error.Message
+		}
+		return nil, fmt.Errorf("env.get error: %s", errMsg)
+	}
+
+	env := make(map[string]string)
+	if payload, ok := resp.Payload.(map[string]interface{}); ok {
+		if values, ok := payload["env"].(map[string]interface{}); ok {
+			for k, v := range values {
+				if s, ok := v.(string); ok {
+					env[k] = s
+				}
+			}
+			return env, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected env.get response format")
+}
+
+// TailLogs fetches up to limit of the Gateway's most recent log lines via
+// logs.tail, oldest first. Only Gateways that advertise logs.tail implement
+// it.
+func (c *RPCClient) TailLogs(limit int) ([]string, error) {
+	if !c.Capabilities().Advertises("logs.tail") {
+		return nil, fmt.Errorf("logs.tail: %w", ErrUnsupported)
+	}
+	resp, err := c.call("logs.tail", map[string]interface{}{"limit": limit})
+	if err != nil {
+		return nil, fmt.Errorf("logs.tail failed: %w", err)
+	}
+	if resp.OK != nil && !*resp.OK {
+		errMsg := "unknown error"
+		if resp.Error != nil {
+			errMsg = resp.Error.Message
+		}
+		return nil, fmt.Errorf("logs.tail error: %s", errMsg)
+	}
+
+	if payload, ok := resp.Payload.(map[string]interface{}); ok {
+		if raw, ok := payload["lines"].([]interface{}); ok {
+			lines := make([]string, 0, len(raw))
+			for _, l := range raw {
+				if s, ok := l.(string); ok {
+					lines = append(lines, s)
+				}
+			}
+			return lines, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected logs.tail response format")
+}
+
 // tryRestartGateway attempts a single Gateway restart via config.patch.
-func (c *RPCClient) tryRestartGateway(reason string) error {
+func (c *RPCClient) tryRestartGateway(ctx context.Context, reason string) error {
+	// Restarts ride on config.patch
+	if !c.Supports("config.patch") {
+		return ErrRestartDisabled
+	}
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+
 	// First, get current config to get the baseHash
-	getResp, err := c.call("config.get", map[string]interface{}{})
+	getResp, err := c.callContext(ctx, "config.get", map[string]interface{}{})
 	if err != nil {
 		return fmt.Errorf("config.get failed: %w", err)
 	}
@@ -804,7 +1168,7 @@ func (c *RPCClient) tryRestartGateway(reason string) error {
 	
 	// Use config.patch with empty patch to trigger restart
 	// The patch is an empty object "{}" which makes no changes but still triggers restart
-	resp, err := c.call("config.patch", map[string]interface{}{
+	resp, err := c.callContext(ctx, "config.patch", map[string]interface{}{
 		"raw":            "{}",  // Empty patch - no config changes
 		"baseHash":       baseHash,
 		"note":           reason,
@@ -820,7 +1184,8 @@ func (c *RPCClient) tryRestartGateway(reason string) error {
 			errMsg = resp.Error.Message
 			errCode = resp.Error.Code
 		}
-		// Detect various failure modes
+		// Detect various failure modes. Gateways that advertise their
+		// methods were already checked above; these cover older ones.
 		if errCode == "INVALID_REQUEST" && strings.Contains(errMsg, "unknown method") {
 			return ErrRestartDisabled
 		}
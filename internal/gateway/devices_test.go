@@ -0,0 +1,415 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeGateway speaks enough of the Gateway protocol to complete the
+// connect handshake. serve runs on each accepted connection afterwards.
+type fakeGateway struct {
+	*httptest.Server
+	connections atomic.Int32
+	down        atomic.Bool  // Refuse connections
+	hello       atomic.Value // hello-ok payload, if set
+}
+
+func newFakeGateway(t *testing.T, serve func(n int32, conn *websocket.Conn)) *fakeGateway {
+	t.Helper()
+	g := startFakeGateway(serve)
+	g.Start()
+	t.Cleanup(g.Close)
+	return g
+}
+
+// newFakeTLSGateway is newFakeGateway over TLS with a self-signed certificate.
+func newFakeTLSGateway(t *testing.T, serve func(n int32, conn *websocket.Conn)) *fakeGateway {
+	t.Helper()
+	g := startFakeGateway(serve)
+	g.StartTLS()
+	t.Cleanup(g.Close)
+	return g
+}
+
+func startFakeGateway(serve func(n int32, conn *websocket.Conn)) *fakeGateway {
+	g := &fakeGateway{}
+	upgrader := websocket.Upgrader{}
+	g.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if err := conn.WriteJSON(rpcMessage{Type: "event", Event: "connect.challenge", Payload: map[string]string{"nonce": "n"}}); err != nil {
+			return
+		}
+		var req rpcMessage
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "connect" {
+			return
+		}
+		ok := true
+		if err := conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: g.hello.Load()}); err != nil {
+			return
+		}
+		serve(g.connections.Add(1), conn)
+	}))
+	return g
+}
+
+// newTestRPCClient is NewRPCClient with the given identity (may be nil)
+// and heartbeat timing.
+func newTestRPCClient(url string, identity *deviceIdentity, pingInterval, pongWait time.Duration) *RPCClient {
+	c := &RPCClient{
+		gatewayURL:   url,
+		token:        "token",
+		logger:       slog.Default(),
+		identity:     identity,
+		pending:      make(map[string]chan *rpcMessage),
+		stop:         make(chan struct{}),
+		pingInterval: pingInterval,
+		pongWait:     pongWait,
+	}
+	go c.supervise()
+	return c
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRPCClient_Reconnects(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		if n == 1 {
+			return // Drop the first connection straight away
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	var changes atomic.Int32
+	client := NewRPCClient(gw.URL, "token", nil, nil)
+	client.OnStateChange(func(from, to string) { changes.Add(1) })
+	defer client.Close()
+
+	waitFor(t, "reconnect", func() bool { return gw.connections.Load() >= 2 && client.IsConnected() })
+	if client.ConnectionState() != "connected" {
+		t.Errorf("state = %q, want connected", client.ConnectionState())
+	}
+
+	client.Close()
+	time.Sleep(50 * time.Millisecond)
+	if n := gw.connections.Load(); n != 2 {
+		t.Errorf("connections after Close = %d, want 2 (no reconnect)", n)
+	}
+	if changes.Load() == 0 {
+		t.Error("no state change reported")
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := withJitter(time.Second); d < 500*time.Millisecond || d >= time.Second {
+			t.Fatalf("withJitter(1s) = %v, want [500ms, 1s)", d)
+		}
+	}
+}
+
+func TestRPCClient_DetectsStaleConnection(t *testing.T) {
+	release := make(chan struct{})
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		if n == 1 {
+			<-release // Half-open: never read, so pings go unanswered
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	t.Cleanup(func() { close(release) })
+
+	client := newTestRPCClient(gw.URL, nil, 20*time.Millisecond, 100*time.Millisecond)
+	defer client.Close()
+	waitFor(t, "first connection", client.IsConnected)
+
+	// A call in flight on the dead connection fails with it, not after 30s
+	start := time.Now()
+	if _, err := client.ListDevices(); err == nil {
+		t.Error("ListDevices on a stale connection succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stale call took %v", elapsed)
+	}
+
+	waitFor(t, "reconnect", func() bool { return gw.connections.Load() >= 2 && client.IsConnected() })
+
+	// Answered pings keep a healthy connection open
+	time.Sleep(3 * client.pongWait)
+	if n := gw.connections.Load(); n != 2 || !client.IsConnected() {
+		t.Errorf("healthy connection dropped: connections=%d connected=%v", n, client.IsConnected())
+	}
+}
+
+func TestRPCClient_PairRequestEvent(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		conn.WriteJSON(rpcMessage{Type: "event", Event: "device.pair.requested", Payload: map[string]interface{}{
+			"requestId": "req-1", "deviceId": "dev-1", "role": "node",
+		}})
+		conn.WriteJSON(rpcMessage{Type: "event", Event: "device.pair.requested", Payload: map[string]interface{}{}}) // Malformed
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	got := make(chan PendingDevice, 2)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	client := newTestRPCClient(gw.URL, &deviceIdentity{PrivateKey: priv, PublicKey: pub, DeviceID: "dev-1"}, defaultPingInterval, defaultPongWait)
+	// A listener that blocks holds up neither the others nor the read loop
+	stuck := make(chan struct{})
+	defer close(stuck)
+	client.OnPairRequest(func(PendingDevice) { <-stuck })
+	client.OnPairRequest(func(d PendingDevice) { got <- d })
+	defer client.Close()
+
+	select {
+	case d := <-got:
+		if d.RequestID != "req-1" || d.Role != "node" {
+			t.Errorf("pair request = %+v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no pair request delivered")
+	}
+	if id := client.GetPendingRequestID(); id != "req-1" {
+		t.Errorf("GetPendingRequestID() = %q, want req-1 (our own device)", id)
+	}
+	select {
+	case d := <-got:
+		t.Errorf("malformed event delivered: %+v", d)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRPCClient_TLS(t *testing.T) {
+	gw := newFakeTLSGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gw.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	connect := func(opts TLSOptions) error {
+		tlsConfig, err := opts.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Unsupervised, so Connect is the only dial
+		c := &RPCClient{
+			gatewayURL:   gw.URL,
+			token:        "token",
+			logger:       slog.Default(),
+			tlsConfig:    tlsConfig,
+			pending:      make(map[string]chan *rpcMessage),
+			stop:         make(chan struct{}),
+			pingInterval: defaultPingInterval,
+			pongWait:     defaultPongWait,
+		}
+		defer c.Close()
+		return c.Connect()
+	}
+
+	if err := connect(TLSOptions{}); err == nil {
+		t.Error("connected to a self-signed Gateway without trusting its CA")
+	}
+	if err := connect(TLSOptions{CAFile: caFile}); err != nil {
+		t.Errorf("connect with CA bundle: %v", err)
+	}
+	if err := connect(TLSOptions{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("connect with insecure-skip-verify: %v", err)
+	}
+
+	if _, err := (TLSOptions{CertFile: caFile}).Config(); err == nil {
+		t.Error("client certificate without a key accepted")
+	}
+	if _, err := (TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Config(); err == nil {
+		t.Error("missing CA bundle accepted")
+	}
+}
+
+func TestRPCClient_DoesNotLogToken(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	var buf bytes.Buffer
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	c := &RPCClient{
+		gatewayURL:   gw.URL,
+		token:        "s3cret-gateway-token",
+		logger:       slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		identity:     &deviceIdentity{PrivateKey: priv, PublicKey: pub, DeviceID: "dev-1"},
+		pending:      make(map[string]chan *rpcMessage),
+		stop:         make(chan struct{}),
+		pingInterval: defaultPingInterval,
+		pongWait:     defaultPongWait,
+	}
+	defer c.Close()
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("gateway token logged:\n%s", buf.String())
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := breaker{now: func() time.Time { return now }}
+
+	for i := 0; i < breakerThreshold-1; i++ {
+		if opened, _ := b.record(true); opened {
+			t.Fatalf("opened after %d failures", i+1)
+		}
+	}
+	if opened, _ := b.record(true); !opened || !b.isOpen() {
+		t.Fatal("not open after threshold failures")
+	}
+	if err := b.allow(); !errors.Is(err, ErrDegraded) {
+		t.Fatalf("allow() while open = %v, want ErrDegraded", err)
+	}
+
+	// After the cooldown a single trial call goes through
+	now = now.Add(breakerCooldown)
+	if err := b.allow(); err != nil {
+		t.Fatalf("trial call refused: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrDegraded) {
+		t.Fatal("second call allowed during trial")
+	}
+	b.record(true) // Trial failed: another cooldown
+	if err := b.allow(); !errors.Is(err, ErrDegraded) {
+		t.Fatal("allowed right after a failed trial")
+	}
+
+	now = now.Add(breakerCooldown)
+	b.allow()
+	if _, closed := b.record(false); !closed || b.isOpen() {
+		t.Fatal("successful trial did not close the breaker")
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after recovery = %v", err)
+	}
+}
+
+func TestRPCClient_FailsFastWhenDegraded(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {})
+	gw.down.Store(true)
+
+	client := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer client.Close()
+	for i := 0; i < breakerThreshold; i++ {
+		if _, err := client.ListDevices(); err == nil || errors.Is(err, ErrDegraded) {
+			t.Fatalf("call %d: err = %v, want a dial failure", i+1, err)
+		}
+	}
+	if !client.Degraded() {
+		t.Fatal("not degraded after repeated failures")
+	}
+	if _, err := client.ListDevices(); !errors.Is(err, ErrDegraded) {
+		t.Errorf("call while degraded = %v, want ErrDegraded", err)
+	}
+}
+
+func TestRPCClient_NegotiatesCapabilities(t *testing.T) {
+	var calls atomic.Int32
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			calls.Add(1)
+		}
+	})
+	gw.hello.Store(map[string]interface{}{
+		"type":     "hello-ok",
+		"protocol": 3,
+		"server":   map[string]string{"version": "2026.1.0"},
+		"features": map[string]interface{}{"methods": []string{"config.get", "device.pair.list"}},
+	})
+
+	client := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer client.Close()
+	waitFor(t, "connection", client.IsConnected)
+
+	caps := client.Capabilities()
+	if caps == nil || caps.Protocol != 3 || caps.ServerVersion != "2026.1.0" {
+		t.Fatalf("capabilities = %+v", caps)
+	}
+	if client.Supports("config.patch") || !client.Supports("config.get") {
+		t.Errorf("Supports() doesn't follow the advertised methods")
+	}
+
+	// Unsupported methods fail without a round trip
+	if err := client.RestartGateway("test"); err != ErrRestartDisabled {
+		t.Errorf("RestartGateway = %v, want ErrRestartDisabled", err)
+	}
+	if _, err := client.PatchConfig("{}", "test"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("PatchConfig = %v, want ErrUnsupported", err)
+	}
+	if _, err := client.call("device.pair.approve", nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("call(device.pair.approve) = %v, want ErrUnsupported", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("%d requests sent for unsupported methods", n)
+	}
+}
+
+func TestParseHello(t *testing.T) {
+	if caps, err := parseHello(nil); err != nil || caps.Protocol != minProtocol || caps.Methods != nil {
+		t.Errorf("parseHello(nil) = %+v, %v; want protocol %d, all methods", caps, err, minProtocol)
+	}
+	if _, err := parseHello(map[string]interface{}{"protocol": maxProtocol + 1}); err == nil {
+		t.Error("accepted a protocol newer than OCM supports")
+	}
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CheckEnvWritable reports whether OCM could write the .env file, and the
+// secrets dir if one is set, without changing either. Writes replace the
+// file through a temp file in its directory, so the directory must be
+// writable too; one that doesn't exist yet is checked through its nearest
+// existing parent, as it is created on the first write.
+func (c *Client) CheckEnvWritable() error {
+	dirs := []string{filepath.Dir(c.EnvFilePath)}
+	if dir := c.getSecretsDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		if err := checkDirWritable(dir); err != nil {
+			return err
+		}
+	}
+
+	// An existing file is written in place where it can't be replaced
+	f, err := os.OpenFile(c.EnvFilePath, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func checkDirWritable(dir string) error {
+	for {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if !errors.Is(err, fs.ErrNotExist) || parent == dir {
+			return err
+		}
+		dir = parent
+	}
+	tmp, err := os.CreateTemp(dir, ".ocm-check-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
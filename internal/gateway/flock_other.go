@@ -0,0 +1,10 @@
+//go:build !unix
+
+package gateway
+
+import "os"
+
+// Advisory locks are unix-only; elsewhere writers are serialized within the
+// process only.
+func lockFile(f *os.File) error   { return nil }
+func unlockFile(f *os.File) error { return nil }
@@ -2,12 +2,20 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/tracing"
 )
 
 // Client manages communication with OpenClaw Gateway.
@@ -19,6 +27,23 @@ type Client struct {
 	// RPC client for Gateway communication
 	rpcClient *RPCClient
 	logger    *slog.Logger
+
+	mu               sync.Mutex
+	restartFailures  int                              // Consecutive failed restarts
+	onRestartFailure func(consecutive int, err error) // See OnRestartFailure
+	queue            *store.Store                     // Offline operation queue (nil = don't queue)
+	name             string                           // Gateway name in the queue
+	debounce         time.Duration                    // See SetRestartDebounce
+	batch            *restartBatch                    // Pending coalesced changes
+	fallback         Restarter                        // See SetRestartFallback
+	secretsDir       string                           // See SetSecretsDir
+	lastErr          string                           // Last failed fallback restart, for Stats
+	lastErrAt        time.Time
+	replayTimer      *time.Timer // Pending retry of a stalled replay
+
+	replayMu sync.Mutex // Serializes ReplayQueue
+	flushMu  sync.Mutex // Serializes applying batches
+	envMu    sync.Mutex // Serializes .env read-modify-write cycles
 }
 
 // NewClient creates a new Gateway client.
@@ -38,6 +63,30 @@ func NewClient(gatewayURL, envFilePath string, rpcClient *RPCClient, logger *slo
 	}
 }
 
+// ConnectionState reports the RPC connection state (see
+// RPCClient.ConnectionState), or "disabled" without an RPC client.
+func (c *Client) ConnectionState() string {
+	if c.rpcClient == nil {
+		return "disabled"
+	}
+	return c.rpcClient.ConnectionState()
+}
+
+// Capabilities returns what the Gateway advertised when it last connected,
+// or nil without an RPC client or before the first connect.
+func (c *Client) Capabilities() *Capabilities {
+	if c.rpcClient == nil {
+		return nil
+	}
+	return c.rpcClient.Capabilities()
+}
+
+// Degraded reports whether RPC calls are failing fast after repeated
+// failures (see RPCClient.Degraded).
+func (c *Client) Degraded() bool {
+	return c.rpcClient != nil && c.rpcClient.Degraded()
+}
+
 // CredentialEnv represents a credential as an environment variable.
 type CredentialEnv struct {
 	Name  string // e.g., "GMAIL_TOKEN", "LINEAR_API_KEY"
@@ -47,20 +96,14 @@ type CredentialEnv struct {
 // SetCredentials writes credentials to the .env file and triggers a Gateway restart.
 // This is the core function for credential injection.
 func (c *Client) SetCredentials(creds []CredentialEnv) error {
-	// Read existing .env file
-	existing, err := c.readEnvFile()
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("read env file: %w", err)
-	}
-
-	// Update with new credentials
-	for _, cred := range creds {
-		existing[cred.Name] = cred.Value
-	}
-
-	// Write back
-	if err := c.writeEnvFile(existing); err != nil {
-		return fmt.Errorf("write env file: %w", err)
+	_, err := c.updateEnvFile(envNames(creds), func(env map[string]string) bool {
+		for _, cred := range creds {
+			env[cred.Name] = cred.Value
+		}
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
 	// Trigger Gateway restart
@@ -73,17 +116,14 @@ func (c *Client) SetCredentials(creds []CredentialEnv) error {
 
 // ClearCredentials removes credentials from the .env file and triggers a Gateway restart.
 func (c *Client) ClearCredentials(names []string) error {
-	existing, err := c.readEnvFile()
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("read env file: %w", err)
-	}
-
-	for _, name := range names {
-		delete(existing, name)
-	}
-
-	if err := c.writeEnvFile(existing); err != nil {
-		return fmt.Errorf("write env file: %w", err)
+	_, err := c.updateEnvFile(nil, func(env map[string]string) bool {
+		for _, name := range names {
+			delete(env, name)
+		}
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
 	if err := c.RestartGateway("OCM credential removal"); err != nil {
@@ -97,20 +137,19 @@ func (c *Client) ClearCredentials(names []string) error {
 // Use this during setup to accumulate credentials, then call SyncAndRestart when done.
 func (c *Client) WriteCredentialToEnv(name, value string) error {
 	c.logger.Info("writing credential to env file", "envVar", name, "path", c.EnvFilePath)
-	
-	existing, err := c.readEnvFile()
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("read env file: %w", err)
-	}
 
-	existing[name] = value
-
-	if err := c.writeEnvFile(existing); err != nil {
+	var total int
+	_, err := c.updateEnvFile([]string{name}, func(env map[string]string) bool {
+		env[name] = value
+		total = len(env)
+		return true
+	})
+	if err != nil {
 		c.logger.Error("failed to write env file", "error", err)
 		return err
 	}
-	
-	c.logger.Info("credential written to env file", "envVar", name, "totalVars", len(existing))
+
+	c.logger.Info("credential written to env file", "envVar", name, "totalVars", total)
 	return nil
 }
 
@@ -118,28 +157,144 @@ func (c *Client) WriteCredentialToEnv(name, value string) error {
 // Use this for startup sync where Gateway is expected to be starting/restarting anyway.
 // Returns true if any changes were made to the .env file.
 func (c *Client) WriteCredentialsToEnv(creds []CredentialEnv) (changed bool, err error) {
-	existing, err := c.readEnvFile()
-	if err != nil && !os.IsNotExist(err) {
-		return false, fmt.Errorf("read env file: %w", err)
+	return c.updateEnvFile(envNames(creds), func(env map[string]string) bool {
+		// Only write if anything actually changed
+		changed := false
+		for _, cred := range creds {
+			if env[cred.Name] != cred.Value {
+				changed = true
+				env[cred.Name] = cred.Value
+			}
+		}
+		return changed
+	})
+}
+
+// Injection is a set of credential changes applied to a Gateway together.
+type Injection struct {
+	Env         []CredentialEnv    // Env vars to set
+	Config      []ConfigCredential // Config paths to set
+	ClearEnv    []string           // Env vars to remove
+	ClearConfig []string           // Config paths to remove
+}
+
+// IsZero reports whether inj changes nothing.
+func (inj Injection) IsZero() bool {
+	return len(inj.Env) == 0 && len(inj.Config) == 0 && len(inj.ClearEnv) == 0 && len(inj.ClearConfig) == 0
+}
+
+// Apply writes inj's env changes to the .env file and its config changes to
+// the Gateway config, then makes sure the Gateway restarts: config patches
+// restart it themselves, otherwise it is restarted explicitly if the .env
+// changed.
+func (c *Client) Apply(inj Injection, reason string) error {
+	return c.ApplyContext(context.Background(), inj, reason)
+}
+
+// ApplyContext is Apply, traced as part of the span in ctx.
+func (c *Client) ApplyContext(ctx context.Context, inj Injection, reason string) (err error) {
+	if inj.IsZero() {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "gateway.apply", "reason", reason,
+		"env", len(inj.Env)+len(inj.ClearEnv), "config", len(inj.Config)+len(inj.ClearConfig))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	envChanged := false
+	if len(inj.Env) > 0 || len(inj.ClearEnv) > 0 {
+		_, envSpan := tracing.Start(ctx, "gateway.write_env")
+		envChanged, err = c.updateEnvFile(envNames(inj.Env), func(env map[string]string) bool {
+			changed := false
+			for _, name := range inj.ClearEnv {
+				if _, ok := env[name]; ok {
+					delete(env, name)
+					changed = true
+				}
+			}
+			for _, cred := range inj.Env {
+				if v, ok := env[cred.Name]; !ok || v != cred.Value {
+					env[cred.Name] = cred.Value
+					changed = true
+				}
+			}
+			return changed
+		})
+		envSpan.RecordError(err)
+		envSpan.End()
+		if err != nil {
+			return err
+		}
 	}
 
-	// Check if anything actually changed
-	for _, cred := range creds {
-		if existing[cred.Name] != cred.Value {
-			changed = true
-			existing[cred.Name] = cred.Value
+	if c.rpcClient != nil && (len(inj.Config) > 0 || len(inj.ClearConfig) > 0) {
+		if err := c.setConfigCredentials(ctx, inj.Config); err != nil {
+			return err
 		}
+		return c.clearConfigCredentials(ctx, inj.ClearConfig)
+	}
+	if envChanged {
+		return c.restartGateway(ctx, reason)
 	}
+	return nil
+}
+
+// updateEnvFile reads the .env file, lets update modify it, and writes it
+// back if update returns true. injected names the variables update sets;
+// with a secrets dir, they and those already in it are written to files,
+// and a plain entry for one of them is moved there even if unchanged.
+// Updates are serialized within the process and, through an advisory lock
+// on a sibling .lock file, across processes, so concurrent writers can't
+// drop each other's credentials.
+func (c *Client) updateEnvFile(injected []string, update func(env map[string]string) bool) (changed bool, err error) {
+	c.envMu.Lock()
+	defer c.envMu.Unlock()
 
-	if !changed {
-		return false, nil // Nothing to write
+	dir := filepath.Dir(c.EnvFilePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, fmt.Errorf("create env file directory: %w", err)
+	}
+	// Lock a separate file: the .env itself is replaced on every write
+	lock, err := os.OpenFile(c.EnvFilePath+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return false, fmt.Errorf("open env lock file: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return false, fmt.Errorf("lock env file: %w", err)
 	}
+	defer unlockFile(lock)
 
-	if err := c.writeEnvFile(existing); err != nil {
-		return true, fmt.Errorf("write env file: %w", err)
+	existing, managed, err := c.readEnvRefs()
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("read env file: %w", err)
+	}
+	changed = update(existing)
+	migrate := false
+	for _, name := range injected {
+		if _, ok := existing[name]; ok && !managed[name] && c.getSecretsDir() != "" {
+			migrate = true
+		}
+		managed[name] = true
 	}
+	if !changed && !migrate {
+		return false, nil
+	}
+	if err := c.writeEnv(existing, managed); err != nil {
+		return changed, fmt.Errorf("write env file: %w", err)
+	}
+	return changed, nil
+}
 
-	return true, nil
+// envNames returns the names of creds.
+func envNames(creds []CredentialEnv) []string {
+	names := make([]string, len(creds))
+	for i, cred := range creds {
+		names[i] = cred.Name
+	}
+	return names
 }
 
 // SyncAndRestart syncs current credentials to the .env file and restarts Gateway.
@@ -149,22 +304,311 @@ func (c *Client) SyncAndRestart(reason string) error {
 	return c.RestartGateway(reason)
 }
 
+// OnRestartFailure registers fn to be called after each failed Gateway
+// restart with the number of consecutive failures so far. Rate-limited
+// restarts are not failures and don't count.
+func (c *Client) OnRestartFailure(fn func(consecutive int, err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onRestartFailure = fn
+}
+
+// ErrQueued is returned when an operation couldn't reach the Gateway and
+// was queued to be replayed once the RPC connection is back.
+var ErrQueued = errors.New("gateway unreachable; change queued until it reconnects")
+
+// UseQueue makes operations that fail while the Gateway is unreachable
+// durable: they are queued in s under name and replayed, in order, whenever
+// the RPC connection is (re-)established.
+func (c *Client) UseQueue(s *store.Store, name string) {
+	c.mu.Lock()
+	c.queue, c.name = s, name
+	c.mu.Unlock()
+
+	if c.rpcClient == nil {
+		return
+	}
+	c.rpcClient.OnStateChange(func(from, to string) {
+		if to == "connected" {
+			go c.ReplayQueue()
+		}
+	})
+	if c.rpcClient.IsConnected() {
+		go c.ReplayQueue() // Left over from a previous run
+	}
+}
+
+// deferOp queues a failed operation if the Gateway is unreachable and
+// returns ErrQueued. Other failures (rate limits, rejected patches) are
+// returned unchanged, since replaying them wouldn't help.
+func (c *Client) deferOp(kind, payload, reason string, err error) error {
+	c.mu.Lock()
+	queue, name := c.queue, c.name
+	c.mu.Unlock()
+	if queue == nil || c.rpcClient.IsConnected() {
+		return err
+	}
+
+	op := &store.GatewayOp{Gateway: name, Kind: kind, Payload: payload, Reason: reason, LastError: err.Error()}
+	if qerr := queue.EnqueueGatewayOp(op); qerr != nil {
+		c.logger.Error("failed to queue gateway operation", "error", qerr, "kind", kind)
+		return err
+	}
+	c.logger.Warn("gateway unreachable, operation queued", "kind", kind, "reason", reason, "error", err)
+	return ErrQueued
+}
+
+// deferConfigSets queues config changes that failed to apply, as deferOp.
+func (c *Client) deferConfigSets(sets []configSet, reason string, err error) error {
+	payload, merr := json.Marshal(sets)
+	if merr != nil {
+		return err
+	}
+	return c.deferOp(store.GatewayOpConfigSet, string(payload), reason, err)
+}
+
+// replayConfigChange applies a queued config change.
+func (c *Client) replayConfigChange(op *store.GatewayOp) error {
+	if op.Kind == store.GatewayOpConfigPatch {
+		// Queued by an earlier OCM as a finished merge patch
+		_, err := c.rpcClient.PatchConfig(op.Payload, op.Reason)
+		return err
+	}
+	var sets []configSet
+	if err := json.Unmarshal([]byte(op.Payload), &sets); err != nil {
+		return fmt.Errorf("decode queued config change: %w", err)
+	}
+	_, err := c.rpcClient.patchConfigSets(context.Background(), sets, op.Reason)
+	return err
+}
+
+// maxReplayAttempts is how many times a queued operation is tried before it
+// is set aside as failed, so one the Gateway keeps refusing doesn't hold up
+// the rest of the queue.
+const maxReplayAttempts = 5
+
+// replayRetryDelay is how long a replay stopped by a failure waits before
+// trying again while the Gateway is still connected.
+var replayRetryDelay = 30 * time.Second
+
+// ReplayQueue applies queued operations: config changes in order, then a
+// single restart for any queued restarts, unless a change already restarted
+// the Gateway. It stops at a failure, leaving the rest queued, and tries
+// again once the Gateway reconnects or, if it is still connected, after a
+// delay. An operation that has failed maxReplayAttempts times is set aside
+// as failed and audited, and the replay goes on without it.
+func (c *Client) ReplayQueue() {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	c.mu.Lock()
+	queue, name := c.queue, c.name
+	c.mu.Unlock()
+	if queue == nil || c.rpcClient == nil {
+		return
+	}
+
+	listed, err := queue.ListGatewayOps(name)
+	if err != nil {
+		c.logger.Error("failed to list queued gateway operations", "error", err)
+		return
+	}
+	var ops []*store.GatewayOp
+	for _, op := range listed {
+		if op.FailedAt == nil {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return
+	}
+	c.logger.Info("replaying queued gateway operations", "gateway", name, "count", len(ops))
+
+	patched := false
+	var restarts []*store.GatewayOp
+	for _, op := range ops {
+		if op.Kind == store.GatewayOpRestart {
+			restarts = append(restarts, op)
+			continue
+		}
+		if err := c.replayConfigChange(op); err != nil {
+			c.logger.Error("queued config patch failed", "error", err, "id", op.ID)
+			if !c.replayFailed(queue, name, op, err) {
+				return
+			}
+			continue
+		}
+		queue.DeleteGatewayOp(op.ID)
+		patched = true
+	}
+
+	if len(restarts) == 0 {
+		return
+	}
+	if !patched {
+		if err := c.rpcClient.RestartGateway(restarts[len(restarts)-1].Reason); err != nil {
+			c.logger.Error("queued gateway restart failed", "error", err)
+			if !c.replayFailed(queue, name, restarts[0], err) {
+				return
+			}
+			// The rest were collapsed into the one that failed
+			restarts = restarts[1:]
+		}
+	}
+	for _, op := range restarts {
+		queue.DeleteGatewayOp(op.ID)
+	}
+}
+
+// replayFailed records a failed replay of op and reports whether the replay
+// should go on without it, which it should once op has failed
+// maxReplayAttempts times and been set aside. Otherwise, while the Gateway
+// is still connected, the replay is retried later.
+func (c *Client) replayFailed(queue *store.Store, name string, op *store.GatewayOp, err error) bool {
+	if op.Attempts+1 < maxReplayAttempts {
+		queue.RecordGatewayOpFailure(op.ID, err.Error())
+		if c.rpcClient.IsConnected() {
+			delay := replayRetryDelay
+			var rl *ErrRateLimited
+			if errors.As(err, &rl) {
+				delay = rl.RetryAfter
+			}
+			c.retryReplay(delay)
+		}
+		return false
+	}
+
+	queue.FailGatewayOp(op.ID, err.Error())
+	details := fmt.Sprintf("gateway %s: queued %s (%s) failed %d times, last: %v",
+		name, op.Kind, op.Reason, op.Attempts+1, err)
+	c.logger.Error("giving up on queued gateway operation", "gateway", name, "id", op.ID, "kind", op.Kind, "error", err)
+	queue.AddAuditEntry(&store.AuditEntry{
+		ID:        store.NewID("audit"),
+		Timestamp: time.Now(),
+		Action:    store.ActionGatewayOpFailed,
+		Details:   details,
+		Actor:     "system",
+	})
+	return true
+}
+
+// retryReplay replays the queue again after delay, replacing any retry
+// already pending.
+func (c *Client) retryReplay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replayTimer != nil {
+		c.replayTimer.Stop()
+	}
+	c.replayTimer = time.AfterFunc(delay, c.ReplayQueue)
+}
+
 // RestartGateway triggers a Gateway restart via WebSocket RPC.
 func (c *Client) RestartGateway(reason string) error {
+	return c.restartGateway(context.Background(), reason)
+}
+
+func (c *Client) restartGateway(ctx context.Context, reason string) error {
 	if c.rpcClient == nil {
+		if c.restartFallback() != nil {
+			return c.runFallback(ctx, reason, ErrRestartDisabled)
+		}
 		c.logger.Warn("gateway restart skipped: no RPC client configured")
 		return nil
 	}
-	
+	if c.debounced() {
+		return c.schedule(ctx, nil, reason, false)
+	}
+	return c.restart(ctx, reason)
+}
+
+func (c *Client) restart(ctx context.Context, reason string) (err error) {
+	ctx, span := tracing.Start(ctx, "gateway.restart", "reason", reason)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	c.logger.Info("triggering gateway restart", "reason", reason)
-	if err := c.rpcClient.RestartGateway(reason); err != nil {
+	if err := c.rpcClient.restartGateway(ctx, reason); err != nil {
 		c.logger.Error("gateway restart failed", "error", err)
-		return err
+		if errors.Is(err, ErrRestartDisabled) || errors.Is(err, ErrConfigFileLocked) {
+			if c.restartFallback() != nil {
+				return c.runFallback(ctx, reason, err)
+			}
+		}
+		c.recordRestart(err)
+		return c.deferOp(store.GatewayOpRestart, "", reason, err)
 	}
+	c.recordRestart(nil)
 	c.logger.Info("gateway restart triggered successfully")
 	return nil
 }
 
+// SetRestartFallback sets how to restart OpenClaw when the Gateway can't
+// restart itself (restart disabled or unsupported, config file locked, or
+// no RPC client). nil disables the fallback.
+func (c *Client) SetRestartFallback(r Restarter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallback = r
+}
+
+func (c *Client) restartFallback() Restarter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fallback
+}
+
+// runFallback restarts OpenClaw with the fallback restarter after the RPC
+// restart failed with cause. If the fallback fails too, the error still
+// matches cause.
+func (c *Client) runFallback(ctx context.Context, reason string, cause error) (err error) {
+	r := c.restartFallback()
+	c.logger.Info("restarting gateway with fallback", "restarter", r.String(), "reason", reason, "cause", cause)
+	ctx, span := tracing.Start(ctx, "gateway.restart_fallback", "restarter", r.String())
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(ctx), restartFallbackTimeout)
+	defer cancel()
+	if err := r.Restart(ctx, reason); err != nil {
+		c.count(OpRestartFallback, err)
+		err = fmt.Errorf("%w; fallback %q failed: %v", cause, r.String(), err)
+		c.logger.Error("fallback gateway restart failed", "error", err)
+		c.recordRestart(err)
+		return err
+	}
+	c.count(OpRestartFallback, nil)
+	c.recordRestart(nil)
+	c.logger.Info("gateway restarted by fallback", "restarter", r.String())
+	return nil
+}
+
+// recordRestart tracks consecutive restart failures and reports them.
+func (c *Client) recordRestart(err error) {
+	var rl *ErrRateLimited
+	if errors.As(err, &rl) {
+		return
+	}
+
+	c.mu.Lock()
+	if err == nil {
+		c.restartFailures = 0
+		c.mu.Unlock()
+		return
+	}
+	c.restartFailures++
+	n, fn := c.restartFailures, c.onRestartFailure
+	c.mu.Unlock()
+
+	if fn != nil {
+		fn(n, err)
+	}
+}
+
 // ConfigCredential represents a credential to inject into the config file.
 type ConfigCredential struct {
 	Path  string // JSON path, e.g., "channels.slack.userToken"
@@ -175,6 +619,10 @@ type ConfigCredential struct {
 // Each credential is written to its specified config path.
 // This triggers a Gateway restart after patching.
 func (c *Client) SetConfigCredentials(creds []ConfigCredential) error {
+	return c.setConfigCredentials(context.Background(), creds)
+}
+
+func (c *Client) setConfigCredentials(ctx context.Context, creds []ConfigCredential) error {
 	if c.rpcClient == nil {
 		c.logger.Warn("config patch skipped: no RPC client configured")
 		return nil
@@ -184,23 +632,19 @@ func (c *Client) SetConfigCredentials(creds []ConfigCredential) error {
 		return nil
 	}
 
-	// Build the patch object from paths
-	patch := make(map[string]interface{})
-	for _, cred := range creds {
-		setNestedValue(patch, cred.Path, cred.Value)
-	}
-
-	// Convert to JSON5
-	patchJSON, err := json.Marshal(patch)
+	// The patch itself is built when it's applied
+	sets, err := newConfigSets(creds, false)
 	if err != nil {
-		return fmt.Errorf("marshal config patch: %w", err)
+		return fmt.Errorf("build config patch: %w", err)
+	}
+	if c.debounced() {
+		return c.schedule(ctx, sets, "OCM credential injection", false)
 	}
 
 	c.logger.Info("patching config with credentials", "paths", len(creds))
-	_, err = c.rpcClient.PatchConfig(string(patchJSON), "OCM credential injection")
-	if err != nil {
+	if _, err := c.rpcClient.patchConfigSets(ctx, sets, "OCM credential injection"); err != nil {
 		c.logger.Error("config patch failed", "error", err)
-		return err
+		return c.deferConfigSets(sets, "OCM credential injection", err)
 	}
 	c.logger.Info("config patched successfully")
 	return nil
@@ -208,6 +652,10 @@ func (c *Client) SetConfigCredentials(creds []ConfigCredential) error {
 
 // ClearConfigCredentials removes credentials from the config by setting them to null.
 func (c *Client) ClearConfigCredentials(paths []string) error {
+	return c.clearConfigCredentials(context.Background(), paths)
+}
+
+func (c *Client) clearConfigCredentials(ctx context.Context, paths []string) error {
 	if c.rpcClient == nil {
 		c.logger.Warn("config patch skipped: no RPC client configured")
 		return nil
@@ -217,51 +665,54 @@ func (c *Client) ClearConfigCredentials(paths []string) error {
 		return nil
 	}
 
-	// Build the patch object with null values (JSON merge patch delete semantics)
-	patch := make(map[string]interface{})
-	for _, path := range paths {
-		setNestedValue(patch, path, nil)
+	// Null values delete (JSON merge patch semantics)
+	creds := make([]ConfigCredential, len(paths))
+	for i, path := range paths {
+		creds[i] = ConfigCredential{Path: path}
 	}
-
-	patchJSON, err := json.Marshal(patch)
+	sets, err := newConfigSets(creds, true)
 	if err != nil {
-		return fmt.Errorf("marshal config patch: %w", err)
+		return fmt.Errorf("build config patch: %w", err)
+	}
+	if c.debounced() {
+		// Apply removals now, along with anything pending
+		return c.schedule(ctx, sets, "OCM credential removal", true)
 	}
 
 	c.logger.Info("clearing config credentials", "paths", paths)
-	_, err = c.rpcClient.PatchConfig(string(patchJSON), "OCM credential removal")
-	if err != nil {
+	if _, err := c.rpcClient.patchConfigSets(ctx, sets, "OCM credential removal"); err != nil {
 		c.logger.Error("config clear failed", "error", err)
-		return err
+		return c.deferConfigSets(sets, "OCM credential removal", err)
 	}
 	c.logger.Info("config credentials cleared")
 	return nil
 }
 
-// setNestedValue sets a value at a nested path in a map.
-// Path format: "a.b.c" sets map["a"]["b"]["c"] = value
-func setNestedValue(m map[string]interface{}, path string, value interface{}) {
-	parts := strings.Split(path, ".")
-	current := m
-
-	for i, part := range parts {
-		if i == len(parts)-1 {
-			// Last part - set the value
-			current[part] = value
-		} else {
-			// Intermediate part - ensure nested map exists
-			if _, ok := current[part]; !ok {
-				current[part] = make(map[string]interface{})
-			}
-			current = current[part].(map[string]interface{})
-		}
+// ErrNoRPC is returned by operations that need the Gateway RPC connection
+// when none is configured.
+var ErrNoRPC = errors.New("no gateway RPC client configured")
+
+// CurrentConfig fetches the Gateway's config via config.get.
+func (c *Client) CurrentConfig() (map[string]interface{}, error) {
+	if c.rpcClient == nil {
+		return nil, ErrNoRPC
 	}
+	return c.rpcClient.GetConfig()
+}
+
+// RecentLogs fetches up to limit of the Gateway's most recent log lines via
+// logs.tail.
+func (c *Client) RecentLogs(limit int) ([]string, error) {
+	if c.rpcClient == nil {
+		return nil, ErrNoRPC
+	}
+	return c.rpcClient.TailLogs(limit)
 }
 
 // GetCurrentCredentials reads the current credentials from the .env file.
 // Returns map of credential name -> value (values are masked in logs).
 func (c *Client) GetCurrentCredentials() (map[string]string, error) {
-	return c.readEnvFile()
+	return c.readEnv()
 }
 
 // readEnvFile parses the .env file into a map.
@@ -314,7 +765,7 @@ func (c *Client) writeEnvFile(env map[string]string) error {
 
 	content := strings.Join(lines, "\n") + "\n"
 	// 0600 permissions - OCM and OpenClaw both run as uid 1000
-	if err := os.WriteFile(c.EnvFilePath, []byte(content), 0600); err != nil {
+	if err := c.writeFileAtomic(c.EnvFilePath, []byte(content), 0600); err != nil {
 		c.logger.Error("failed to write env file", "path", c.EnvFilePath, "error", err)
 		return err
 	}
@@ -322,3 +773,59 @@ func (c *Client) writeEnvFile(env map[string]string) error {
 	c.logger.Debug("env file written", "path", c.EnvFilePath, "bytes", len(content))
 	return nil
 }
+
+// writeFileAtomic replaces path so readers see either the old or the new
+// content, never a partial write: the data goes to a temp file in the same
+// directory, which is synced and renamed over path, then the directory is
+// synced so the rename survives a crash. An existing file keeps its mode.
+// Where path can't be replaced by rename (a file bind-mounted into a
+// container), it falls back to writing in place.
+func (c *Client) writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+			c.logger.Warn("cannot replace file atomically, writing in place", "path", path, "error", err)
+			return os.WriteFile(path, data, perm)
+		}
+		return err
+	}
+
+	// The new content is in place either way; a failed directory sync only
+	// weakens crash durability, and some filesystems don't support it
+	d, err := os.Open(dir)
+	if err != nil {
+		c.logger.Warn("cannot sync directory", "dir", dir, "error", err)
+		return nil
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		c.logger.Warn("cannot sync directory", "dir", dir, "error", err)
+	}
+	return nil
+}
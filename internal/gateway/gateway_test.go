@@ -1,12 +1,22 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/openclaw/ocm/internal/store"
 )
 
 func TestReadWriteEnvFile(t *testing.T) {
@@ -18,7 +28,7 @@ func TestReadWriteEnvFile(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	envPath := filepath.Join(tmpDir, ".env")
-	client := NewClient("http://localhost:18789", envPath)
+	client := NewClient("http://localhost:18789", envPath, nil, nil)
 
 	// Write some credentials
 	env := map[string]string{
@@ -59,21 +69,9 @@ func TestSetCredentials(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Mock Gateway server
-	restartCalled := false
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/rpc/gateway" {
-			restartCalled = true
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"ok":true}`))
-			return
-		}
-		w.WriteHeader(http.StatusNotFound)
-	}))
-	defer server.Close()
-
+	// No RPC client configured - restart is skipped, env file is still written
 	envPath := filepath.Join(tmpDir, ".env")
-	client := NewClient(server.URL, envPath)
+	client := NewClient("http://localhost:18789", envPath, nil, nil)
 
 	// Set credentials
 	err = client.SetCredentials([]CredentialEnv{
@@ -83,11 +81,6 @@ func TestSetCredentials(t *testing.T) {
 		t.Fatalf("SetCredentials() error = %v", err)
 	}
 
-	// Verify restart was called
-	if !restartCalled {
-		t.Error("Gateway restart was not called")
-	}
-
 	// Verify credential was written
 	got, err := client.readEnvFile()
 	if err != nil {
@@ -105,15 +98,8 @@ func TestClearCredentials(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Mock Gateway server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"ok":true}`))
-	}))
-	defer server.Close()
-
 	envPath := filepath.Join(tmpDir, ".env")
-	client := NewClient(server.URL, envPath)
+	client := NewClient("http://localhost:18789", envPath, nil, nil)
 
 	// Set initial credentials
 	client.writeEnvFile(map[string]string{
@@ -145,7 +131,7 @@ func TestEnvFileWithQuotes(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	envPath := filepath.Join(tmpDir, ".env")
-	client := NewClient("http://localhost:18789", envPath)
+	client := NewClient("http://localhost:18789", envPath, nil, nil)
 
 	// Write value with spaces
 	env := map[string]string{
@@ -165,3 +151,835 @@ func TestEnvFileWithQuotes(t *testing.T) {
 		t.Errorf("TOKEN_WITH_SPACE = %s, want 'has some spaces'", got["TOKEN_WITH_SPACE"])
 	}
 }
+
+func TestClient_QueuesWhileUnreachable(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	patches := make(chan string, 4)
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		ok := true
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "config.patch" {
+				patches <- req.Params.(map[string]interface{})["raw"].(string)
+			}
+			conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: map[string]string{"hash": "h"}})
+		}
+	})
+	gw.down.Store(true)
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	client := NewClient(gw.URL, filepath.Join(dir, ".env"), rpc, nil)
+	client.UseQueue(db, DefaultName)
+
+	err = client.SetConfigCredentials([]ConfigCredential{{Path: "channels.slack.token", Value: "xoxb"}})
+	if !errors.Is(err, ErrQueued) {
+		t.Fatalf("SetConfigCredentials while down = %v, want ErrQueued", err)
+	}
+	if err := client.RestartGateway("test"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("RestartGateway while down = %v, want ErrQueued", err)
+	}
+	if ops, _ := db.ListGatewayOps(DefaultName); len(ops) != 2 || ops[0].Payload == "" {
+		t.Fatalf("queued ops = %+v, want patch then restart", ops)
+	}
+
+	gw.down.Store(false)
+	select {
+	case raw := <-patches:
+		if !strings.Contains(raw, "xoxb") {
+			t.Errorf("replayed patch = %s", raw)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("queued patch not replayed after reconnect")
+	}
+
+	// The patch restarted the Gateway, so the queued restart is dropped
+	waitFor(t, "queue to drain", func() bool {
+		ops, _ := db.ListGatewayOps(DefaultName)
+		return len(ops) == 0
+	})
+	select {
+	case raw := <-patches:
+		t.Errorf("extra config.patch after replay: %s", raw)
+	default:
+	}
+}
+
+func TestClient_ReplaySetsAsideFailingOps(t *testing.T) {
+	defer func(d time.Duration) { replayRetryDelay = d }(replayRetryDelay)
+	replayRetryDelay = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The Gateway refuses one patch and accepts everything else
+	var refused atomic.Int32
+	applied := make(chan string, 4)
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			ok := true
+			resp := rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: map[string]string{"hash": "h"}}
+			if req.Method == "config.patch" {
+				raw := req.Params.(map[string]interface{})["raw"].(string)
+				if strings.Contains(raw, "bad") {
+					refused.Add(1)
+					ok = false
+					resp.Error = &rpcError{Code: "INVALID_REQUEST", Message: "invalid config"}
+				} else {
+					applied <- raw
+				}
+			}
+			conn.WriteJSON(resp)
+		}
+	})
+	gw.down.Store(true)
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	client := NewClient(gw.URL, filepath.Join(dir, ".env"), rpc, nil)
+	client.UseQueue(db, DefaultName)
+	for _, value := range []string{"bad", "good"} {
+		if err := client.SetConfigCredentials([]ConfigCredential{{Path: "channels.slack.token", Value: value}}); !errors.Is(err, ErrQueued) {
+			t.Fatalf("SetConfigCredentials while down = %v, want ErrQueued", err)
+		}
+	}
+
+	gw.down.Store(false)
+	select {
+	case raw := <-applied:
+		if !strings.Contains(raw, "good") {
+			t.Errorf("applied patch = %s", raw)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the rest of the queue never went ahead")
+	}
+	if n := refused.Load(); n != maxReplayAttempts {
+		t.Errorf("refused patch tried %d times, want %d", n, maxReplayAttempts)
+	}
+
+	waitFor(t, "queue to drain", func() bool {
+		ops, _ := db.ListGatewayOps(DefaultName)
+		return len(ops) == 1 && ops[0].FailedAt != nil
+	})
+	entries, err := db.ListAuditEntries(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audited []string
+	for _, e := range entries {
+		if e.Action == store.ActionGatewayOpFailed {
+			audited = append(audited, e.Details)
+		}
+	}
+	if len(audited) != 1 || !strings.Contains(audited[0], "invalid config") {
+		t.Errorf("gateway_op_failed entries = %q", audited)
+	}
+
+	// Set aside: not tried again
+	client.ReplayQueue()
+	if n := refused.Load(); n != maxReplayAttempts {
+		t.Errorf("failed op replayed again (%d attempts)", n)
+	}
+	if n, err := db.DeleteFailedGatewayOps(DefaultName); err != nil || n != 1 {
+		t.Errorf("DeleteFailedGatewayOps = %d, %v", n, err)
+	}
+}
+
+func TestClient_CoalescesRestarts(t *testing.T) {
+	patches := make(chan string, 8)
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		ok := true
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "config.patch" {
+				patches <- req.Params.(map[string]interface{})["raw"].(string)
+			}
+			conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: map[string]string{"hash": "h"}})
+		}
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	client.SetRestartDebounce(100 * time.Millisecond)
+
+	// Each change waits for the batch it joined and gets its result
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for _, path := range []string{"channels.slack.botToken", "channels.slack.appToken", "channels.discord.token"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			errs <- client.SetConfigCredentials([]ConfigCredential{{Path: path, Value: "v"}})
+		}(path)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- client.RestartGateway("env changed")
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case raw := <-patches:
+		var patch map[string]map[string]map[string]string
+		if err := json.Unmarshal([]byte(raw), &patch); err != nil {
+			t.Fatal(err)
+		}
+		if len(patch["channels"]["slack"]) != 2 || patch["channels"]["discord"]["token"] != "v" {
+			t.Errorf("batched patch = %s, want all three paths", raw)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch never applied")
+	}
+	time.Sleep(300 * time.Millisecond)
+	if len(patches) != 0 {
+		t.Errorf("%d extra config.patch calls, want one for the whole batch", len(patches))
+	}
+
+	// Removals apply at once, along with anything pending
+	go client.SetConfigCredentials([]ConfigCredential{{Path: "channels.slack.botToken", Value: "new"}})
+	waitFor(t, "pending injection", func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.batch != nil
+	})
+	if err := client.ClearConfigCredentials([]string{"channels.slack.botToken"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case raw := <-patches:
+		if raw != `{"channels":{"slack":{"botToken":null}}}` {
+			t.Errorf("removal patch = %s", raw)
+		}
+	default:
+		t.Fatal("removal not applied before ClearConfigCredentials returned")
+	}
+}
+
+func TestClient_CoalescedRejection(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			ok := req.Method != "config.patch"
+			resp := rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: map[string]interface{}{}}
+			if !ok {
+				resp.Error = &rpcError{Code: "INVALID_REQUEST", Message: "invalid config"}
+			}
+			conn.WriteJSON(resp)
+		}
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	client.SetRestartDebounce(50 * time.Millisecond)
+
+	// The caller learns the batch was rejected, e.g. to roll back a grant
+	err := client.SetConfigCredentials([]ConfigCredential{{Path: "channels.slack.botToken", Value: "v"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid config") {
+		t.Errorf("SetConfigCredentials = %v, want the rejection", err)
+	}
+}
+
+func TestClient_ArrayPatchUsesCurrentConfig(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.New(filepath.Join(dir, "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var cfg atomic.Value
+	account := func(fields ...string) map[string]interface{} {
+		cfg := map[string]interface{}{"accounts": []interface{}{map[string]interface{}{"name": "work"}}}
+		for _, f := range fields {
+			cfg["accounts"].([]interface{})[0].(map[string]interface{})[f] = "set"
+		}
+		return cfg
+	}
+	cfg.Store(account())
+	patches := make(chan string, 4)
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		ok := true
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			payload := map[string]interface{}{"hash": "h"}
+			switch req.Method {
+			case "config.get":
+				payload["config"] = cfg.Load()
+			case "config.patch":
+				patches <- req.Params.(map[string]interface{})["raw"].(string)
+			}
+			conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: payload})
+		}
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(dir, ".env"), rpc, nil)
+	client.UseQueue(db, DefaultName)
+	client.SetRestartDebounce(50 * time.Millisecond)
+
+	// The element changes while the injection waits in a batch
+	done := make(chan error, 1)
+	go func() {
+		done <- client.SetConfigCredentials([]ConfigCredential{{Path: "accounts[0].token", Value: "t"}})
+	}()
+	waitFor(t, "pending injection", func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.batch != nil
+	})
+	cfg.Store(account("region"))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if raw, want := <-patches, `{"accounts":[{"name":"work","region":"set","token":"t"}]}`; raw != want {
+		t.Errorf("batched patch = %s\nwant            %s", raw, want)
+	}
+
+	// And while it waits in the queue
+	client.SetRestartDebounce(0)
+	gw.down.Store(true)
+	rpc.Close()
+	rpc = newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	client = NewClient(gw.URL, filepath.Join(dir, ".env"), rpc, nil)
+	client.UseQueue(db, DefaultName)
+	if err := client.ClearConfigCredentials([]string{"accounts[0].token"}); !errors.Is(err, ErrQueued) {
+		t.Fatalf("ClearConfigCredentials while down = %v, want ErrQueued", err)
+	}
+	cfg.Store(account("region", "token", "plan"))
+	gw.down.Store(false)
+	select {
+	case raw := <-patches:
+		if want := `{"accounts":[{"name":"work","plan":"set","region":"set"}]}`; raw != want {
+			t.Errorf("replayed patch = %s\nwant             %s", raw, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("queued change not replayed after reconnect")
+	}
+}
+
+func TestClient_RestartFallback(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	// The Gateway doesn't offer config.patch, so it can't restart itself
+	gw.hello.Store(map[string]interface{}{"protocol": 3, "features": map[string]interface{}{"methods": []string{"config.get"}}})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+
+	if err := client.RestartGateway("test"); !errors.Is(err, ErrRestartDisabled) {
+		t.Fatalf("RestartGateway without fallback = %v, want ErrRestartDisabled", err)
+	}
+
+	marker := filepath.Join(t.TempDir(), "restarted")
+	client.SetRestartFallback(&CommandRestarter{Command: `printf '%s' "$OCM_RESTART_REASON" > ` + marker})
+	if err := client.RestartGateway("credential created: slack"); err != nil {
+		t.Fatalf("RestartGateway with fallback = %v", err)
+	}
+	if got, _ := os.ReadFile(marker); string(got) != "credential created: slack" {
+		t.Errorf("fallback saw reason %q", got)
+	}
+
+	client.SetRestartFallback(&CommandRestarter{Command: "echo nope >&2; exit 3"})
+	err := client.RestartGateway("test")
+	if !errors.Is(err, ErrRestartDisabled) || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("failed fallback = %v, want ErrRestartDisabled with the command output", err)
+	}
+}
+
+func TestDockerRestarter(t *testing.T) {
+	var path string
+	docker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		if strings.Contains(r.URL.Path, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No such container: missing"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer docker.Close()
+	host := "tcp://" + strings.TrimPrefix(docker.URL, "http://")
+
+	r, err := NewRestarter("openclaw", "", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Restart(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if path != "POST /containers/openclaw/restart" {
+		t.Errorf("request = %q", path)
+	}
+
+	r, _ = NewRestarter("missing", "", host)
+	if err := r.Restart(context.Background(), "test"); err == nil || !strings.Contains(err.Error(), "No such container") {
+		t.Errorf("Restart(missing) = %v", err)
+	}
+
+	if _, err := NewRestarter("openclaw", "systemctl restart openclaw", host); err == nil {
+		t.Error("accepted both a container and a command")
+	}
+}
+
+func TestWriteEnvFile_Atomic(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("OLD=1\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient("", envPath, nil, nil)
+
+	if err := client.WriteCredentialToEnv("NEW_KEY", "value"); err != nil {
+		t.Fatal(err)
+	}
+	env, err := client.readEnvFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["OLD"] != "1" || env["NEW_KEY"] != "value" {
+		t.Errorf("env = %v", env)
+	}
+
+	info, err := os.Stat(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want existing 0640 kept", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestCheckEnvWritable(t *testing.T) {
+	dir := t.TempDir()
+
+	// Not created yet, under a directory that isn't either
+	client := NewClient("", filepath.Join(dir, "config", ".env"), nil, nil)
+	if err := client.CheckEnvWritable(); err != nil {
+		t.Errorf("missing env file: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("check left %d entries behind", len(entries))
+	}
+
+	// Under a regular file, it can never be written
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client = NewClient("", filepath.Join(blocker, ".env"), nil, nil)
+	if err := client.CheckEnvWritable(); err == nil {
+		t.Error("env file under a regular file passed the check")
+	}
+}
+
+func TestUpdateEnvFile_ConcurrentWriters(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	// Separate clients share only the file, like separate processes
+	clients := []*Client{NewClient("", envPath, nil, nil), NewClient("", envPath, nil, nil)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := clients[i%2].WriteCredentialToEnv(fmt.Sprintf("KEY_%d", i), "v"); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	env, err := clients[0].readEnvFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 20 {
+		t.Errorf("env has %d keys, want 20 (a concurrent write was lost)", len(env))
+	}
+}
+
+func TestSecretsDir(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	secrets := filepath.Join(dir, "creds")
+	// A credential written before the switch moves to a file when next
+	// synced, even unchanged; a variable OCM doesn't manage stays put
+	if err := os.WriteFile(envPath, []byte("OLD_TOKEN=old\nGITHUB_TOKEN=ghp_plain\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient("", envPath, nil, nil)
+	if err := client.SetSecretsDir(secrets); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WriteCredentialsToEnv([]CredentialEnv{{Name: "GITHUB_TOKEN", Value: "ghp_plain"}}); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(envPath)
+	if strings.Contains(string(raw), "ghp_plain") || !strings.Contains(string(raw), "OLD_TOKEN=old") {
+		t.Errorf(".env after migration:\n%s", raw)
+	}
+
+	if err := client.WriteCredentialToEnv("GITHUB_TOKEN", "ghp_secret"); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = os.ReadFile(envPath)
+	if strings.Contains(string(raw), "ghp_secret") {
+		t.Errorf(".env contains a secret value:\n%s", raw)
+	}
+	if strings.Contains(string(raw), "OLD_TOKEN_FILE") || !strings.Contains(string(raw), "OLD_TOKEN=old") {
+		t.Errorf(".env moved a variable OCM doesn't manage:\n%s", raw)
+	}
+	if !strings.Contains(string(raw), "GITHUB_TOKEN_FILE="+filepath.Join(secrets, "GITHUB_TOKEN")) {
+		t.Errorf(".env lacks the file reference:\n%s", raw)
+	}
+	info, err := os.Stat(filepath.Join(secrets, "GITHUB_TOKEN"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("secret file: %v, %v", info, err)
+	}
+
+	got, err := client.GetCurrentCredentials()
+	if err != nil || got["GITHUB_TOKEN"] != "ghp_secret" || got["OLD_TOKEN"] != "old" {
+		t.Errorf("GetCurrentCredentials() = %v, %v", got, err)
+	}
+
+	if err := client.ClearCredentials([]string{"GITHUB_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(secrets, "GITHUB_TOKEN")); !os.IsNotExist(err) {
+		t.Errorf("secret file not removed with the credential: %v", err)
+	}
+}
+
+func TestParseConfigPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []pathSegment
+	}{
+		{"channels.slack.userToken", []pathSegment{{Key: "channels"}, {Key: "slack"}, {Key: "userToken"}}},
+		{"channels.slack[0].userToken", []pathSegment{{Key: "channels"}, {Key: "slack"}, {Index: 0, IsIndex: true}, {Key: "userToken"}}},
+		{`models.openai\.com.apiKey`, []pathSegment{{Key: "models"}, {Key: "openai.com"}, {Key: "apiKey"}}},
+		{"a[2]", []pathSegment{{Key: "a"}, {Index: 2, IsIndex: true}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseConfigPath(tt.path)
+		if err != nil {
+			t.Errorf("ParseConfigPath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseConfigPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "a..b", ".a", "a.", "a[", "a[x]", "a[-1]", "a[0]b", `a\`} {
+		if _, err := ParseConfigPath(bad); err == nil {
+			t.Errorf("ParseConfigPath(%q) accepted", bad)
+		}
+	}
+}
+
+func TestFindInConfig(t *testing.T) {
+	cfg := map[string]interface{}{
+		"channels": map[string]interface{}{"slack": map[string]interface{}{"botToken": "xoxb-1"}},
+		"models":   map[string]interface{}{"openai.com": map[string]interface{}{"apiKey": "sk-1"}},
+		"notes":    []interface{}{"todo", "old bot token was xoxb-1, rotate it"},
+		"port":     18789,
+	}
+	got := FindInConfig(cfg, "xoxb-1")
+	if want := []string{"channels.slack.botToken", "notes[1]"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("FindInConfig = %v, want %v", got, want)
+	}
+	got = FindInConfig(cfg, "sk-1")
+	if len(got) != 1 || got[0] != `models.openai\.com.apiKey` {
+		t.Fatalf("FindInConfig = %v, want the escaped path", got)
+	}
+	if v, ok := ConfigValue(cfg, got[0]); !ok || v != "sk-1" {
+		t.Errorf("ConfigValue(%q) = %v, %v", got[0], v, ok)
+	}
+	if got := FindInConfig(cfg, ""); got != nil {
+		t.Errorf("FindInConfig(\"\") = %v", got)
+	}
+
+	if !SameConfigPath("channels.slack[0].token", `channels.sl\ack[0].token`) || !SameConfigPath(`a\b`, "ab") {
+		t.Error("SameConfigPath: equivalent paths differ")
+	}
+	if SameConfigPath("a.b", "a.c") || SameConfigPath("a", "a.b") || SameConfigPath("a..b", "a..b") {
+		t.Error("SameConfigPath: different or invalid paths match")
+	}
+}
+
+func TestBuildConfigPatch(t *testing.T) {
+	fetches := 0
+	current := func() (map[string]interface{}, error) {
+		fetches++
+		return map[string]interface{}{
+			"channels": map[string]interface{}{
+				"slack": []interface{}{
+					map[string]interface{}{"name": "work", "userToken": "old"},
+					map[string]interface{}{"name": "home"},
+				},
+			},
+		}, nil
+	}
+
+	patch, err := buildConfigPatch([]ConfigCredential{
+		{Path: "channels.slack[0].userToken", Value: "a"},
+		{Path: "channels.slack[1].userToken", Value: "b"},
+		{Path: `models.openai\.com.apiKey`, Value: "c"},
+	}, false, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(patch)
+	want := `{"channels":{"slack":[{"name":"work","userToken":"a"},{"name":"home","userToken":"b"}]},"models":{"openai.com":{"apiKey":"c"}}}`
+	if string(got) != want {
+		t.Errorf("patch = %s\nwant    %s", got, want)
+	}
+	if fetches != 1 {
+		t.Errorf("current config fetched %d times, want 1", fetches)
+	}
+
+	// Clearing inside an array removes the key from the element copy
+	patch, err = buildConfigPatch([]ConfigCredential{{Path: "channels.slack[0].userToken"}, {Path: "channels.discord.token"}}, true, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = json.Marshal(patch)
+	want = `{"channels":{"discord":{"token":null},"slack":[{"name":"work"},{"name":"home"}]}}`
+	if string(got) != want {
+		t.Errorf("clear patch = %s\nwant          %s", got, want)
+	}
+
+	for _, bad := range [][]ConfigCredential{
+		{{Path: "channels.slack[5].userToken", Value: "x"}},                           // Past the end
+		{{Path: "a.b", Value: "x"}, {Path: "a.b.c", Value: "y"}},                      // Leaf then object
+		{{Path: "channels.slack.userToken", Value: "x"}, {Path: "channels.slack[0]"}}, // Object then array
+	} {
+		if _, err := buildConfigPatch(bad, false, current); err == nil {
+			t.Errorf("buildConfigPatch(%v) accepted", bad)
+		}
+	}
+}
+
+func TestClient_VerifyInjection(t *testing.T) {
+	defer func(d time.Duration) { verifySettle = d }(verifySettle)
+	verifySettle = 0
+
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		ok := true
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			var payload interface{}
+			switch req.Method {
+			case "config.get":
+				payload = map[string]interface{}{"config": map[string]interface{}{
+					"channels": map[string]interface{}{"slack": map[string]interface{}{"botToken": "xoxb-new"}},
+					"accounts": []interface{}{map[string]interface{}{"token": "old"}},
+				}}
+			case "env.get":
+				payload = map[string]interface{}{"env": map[string]string{"GITHUB_TOKEN": "ghp_new"}}
+			}
+			conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: payload})
+		}
+	})
+	gw.hello.Store(map[string]interface{}{
+		"features": map[string]interface{}{"methods": []string{"config.get", "config.patch", "env.get"}},
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	ctx := context.Background()
+
+	got := client.VerifyInjection(ctx,
+		[]CredentialEnv{{Name: "GITHUB_TOKEN", Value: "ghp_new"}},
+		[]ConfigCredential{{Path: "channels.slack.botToken", Value: "xoxb-new"}})
+	if got.Status != store.InjectionLoaded {
+		t.Errorf("all loaded: status = %+v, want loaded", got)
+	}
+
+	got = client.VerifyInjection(ctx,
+		[]CredentialEnv{{Name: "GITHUB_TOKEN", Value: "ghp_new"}, {Name: "GITHUB_ORG", Value: "openclaw"}},
+		[]ConfigCredential{{Path: "accounts[0].token", Value: "new"}})
+	if got.Status != store.InjectionNotLoaded || got.Detail != "not loaded: accounts[0].token, GITHUB_ORG" {
+		t.Errorf("stale values: status = %+v, want not_loaded for accounts[0].token and GITHUB_ORG", got)
+	}
+
+	// Without env.get, env injections can't be checked
+	gw.hello.Store(map[string]interface{}{
+		"features": map[string]interface{}{"methods": []string{"config.get", "config.patch"}},
+	})
+	rpc.Close()
+	rpc = newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "reconnection", rpc.IsConnected)
+	client = NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	got = client.VerifyInjection(ctx, []CredentialEnv{{Name: "GITHUB_TOKEN", Value: "ghp_new"}}, nil)
+	if got.Status != store.InjectionUnverified || got.Detail != "could not check: GITHUB_TOKEN" {
+		t.Errorf("no env.get: status = %+v, want unverified", got)
+	}
+
+	client = NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), nil, nil)
+	if got := client.VerifyInjection(ctx, nil, []ConfigCredential{{Path: "a", Value: "b"}}); got.Status != store.InjectionUnverified {
+		t.Errorf("no RPC client: status = %+v, want unverified", got)
+	}
+}
+
+func TestClient_Stats(t *testing.T) {
+	// config.patch is rate limited, then the config file is locked, then it works
+	failures := []string{"rate limit exceeded for config.patch; retry after 5s", "EBUSY: resource busy or locked"}
+	var patches atomic.Int32
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			ok := true
+			resp := rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: map[string]string{"hash": "h"}}
+			if req.Method == "config.patch" {
+				if i := int(patches.Add(1)) - 1; i < len(failures) {
+					ok = false
+					resp.Error = &rpcError{Code: "INVALID_REQUEST", Message: failures[i]}
+				}
+			}
+			conn.WriteJSON(resp)
+		}
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	client.SetName("stats-test")
+
+	var rl *ErrRateLimited
+	if err := client.RestartGateway("first"); !errors.As(err, &rl) {
+		t.Fatalf("first restart = %v, want rate limited", err)
+	}
+	if err := client.RestartGateway("second"); !errors.Is(err, ErrConfigFileLocked) {
+		t.Fatalf("second restart = %v, want locked", err)
+	}
+	if err := client.SetConfigCredentials([]ConfigCredential{{Path: "channels.slack.botToken", Value: "v"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := client.Stats()
+	if want := (OpStats{Attempted: 2, RateLimited: 1, Locked: 1}); stats.Restarts != want {
+		t.Errorf("restarts = %+v, want %+v", stats.Restarts, want)
+	}
+	if want := (OpStats{Attempted: 1, Succeeded: 1}); stats.ConfigPatches != want {
+		t.Errorf("config patches = %+v, want %+v", stats.ConfigPatches, want)
+	}
+	if !strings.HasPrefix(stats.LastError, "restart: config file locked") || stats.LastErrorAt == nil {
+		t.Errorf("last error = %q at %v", stats.LastError, stats.LastErrorAt)
+	}
+}
+
+func TestClient_RecentLogs(t *testing.T) {
+	gw := newFakeGateway(t, func(n int32, conn *websocket.Conn) {
+		ok := true
+		for {
+			var req rpcMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			var payload interface{}
+			if req.Method == "logs.tail" {
+				params, _ := req.Params.(map[string]interface{})
+				payload = map[string]interface{}{"lines": []string{"started", fmt.Sprintf("limit %v", params["limit"])}}
+			}
+			conn.WriteJSON(rpcMessage{Type: "res", ID: req.ID, OK: &ok, Payload: payload})
+		}
+	})
+	gw.hello.Store(map[string]interface{}{
+		"features": map[string]interface{}{"methods": []string{"config.get", "logs.tail"}},
+	})
+
+	rpc := newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "connection", rpc.IsConnected)
+	client := NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+
+	lines, err := client.RecentLogs(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"started", "limit 50"}; fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("RecentLogs = %v, want %v", lines, want)
+	}
+
+	gw.hello.Store(map[string]interface{}{
+		"features": map[string]interface{}{"methods": []string{"config.get"}},
+	})
+	rpc.Close()
+	rpc = newTestRPCClient(gw.URL, nil, defaultPingInterval, defaultPongWait)
+	defer rpc.Close()
+	waitFor(t, "reconnection", rpc.IsConnected)
+	client = NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), rpc, nil)
+	if _, err := client.RecentLogs(50); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("no logs.tail: err = %v, want ErrUnsupported", err)
+	}
+
+	client = NewClient(gw.URL, filepath.Join(t.TempDir(), ".env"), nil, nil)
+	if _, err := client.RecentLogs(50); !errors.Is(err, ErrNoRPC) {
+		t.Errorf("no RPC client: err = %v, want ErrNoRPC", err)
+	}
+}
@@ -0,0 +1,50 @@
+package gateway
+
+import "github.com/openclaw/ocm/internal/store"
+
+// LevelCredentials returns the env vars and config paths an access level is
+// injected into: its token and its additional fields. Levels with derived
+// tokens aren't injected; their secret stays in OCM.
+func LevelCredentials(level *store.AccessLevel) (env []CredentialEnv, config []ConfigCredential) {
+	if level == nil || level.Token == "" || level.Derive != nil {
+		return nil, nil
+	}
+	if key := level.GetInjectionKey(); key != "" {
+		if level.GetInjectionType() == store.InjectionConfig {
+			config = append(config, ConfigCredential{Path: key, Value: level.Token})
+		} else {
+			env = append(env, CredentialEnv{Name: key, Value: level.Token})
+		}
+	}
+	for _, af := range level.AdditionalFields {
+		if af.InjectionType == store.InjectionConfig && af.ConfigPath != "" {
+			config = append(config, ConfigCredential{Path: af.ConfigPath, Value: af.Value})
+		} else if af.InjectionType != store.InjectionConfig && af.EnvVar != "" {
+			env = append(env, CredentialEnv{Name: af.EnvVar, Value: af.Value})
+		}
+	}
+	return env, config
+}
+
+// LevelTargets returns the env vars and config paths an access level is
+// injected into, whether or not it has a token.
+func LevelTargets(level *store.AccessLevel) (envVars, configPaths []string) {
+	if level == nil {
+		return nil, nil
+	}
+	if key := level.GetInjectionKey(); key != "" {
+		if level.GetInjectionType() == store.InjectionConfig {
+			configPaths = append(configPaths, key)
+		} else {
+			envVars = append(envVars, key)
+		}
+	}
+	for _, af := range level.AdditionalFields {
+		if af.InjectionType == store.InjectionConfig && af.ConfigPath != "" {
+			configPaths = append(configPaths, af.ConfigPath)
+		} else if af.InjectionType != store.InjectionConfig && af.EnvVar != "" {
+			envVars = append(envVars, af.EnvVar)
+		}
+	}
+	return envVars, configPaths
+}
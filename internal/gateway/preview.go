@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Env change actions reported by PreviewInjection.
+const (
+	EnvAdd       = "add"
+	EnvUpdate    = "update"
+	EnvUnchanged = "unchanged"
+)
+
+// EnvChange is one .env variable an injection would write.
+type EnvChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`        // EnvAdd, EnvUpdate or EnvUnchanged
+	Old    string `json:"old,omitempty"` // Current value, if any
+	New    string `json:"new"`
+}
+
+// InjectionPreview is what injecting a set of credentials would do.
+type InjectionPreview struct {
+	EnvFile     string                 `json:"envFile,omitempty"`
+	SecretsDir  string                 `json:"secretsDir,omitempty"`
+	Env         []EnvChange            `json:"env,omitempty"`
+	ConfigPatch map[string]interface{} `json:"configPatch,omitempty"` // The config.patch body
+	Restart     bool                   `json:"restart"`               // Whether the Gateway would restart
+}
+
+// PreviewInjection computes the .env changes and config.patch body that
+// injecting env and config would apply, without writing anything. Values are
+// returned as they are; callers showing them to users should mask them.
+func (c *Client) PreviewInjection(env []CredentialEnv, config []ConfigCredential) (*InjectionPreview, error) {
+	p := &InjectionPreview{SecretsDir: c.getSecretsDir()}
+
+	if len(env) > 0 {
+		p.EnvFile = c.EnvFilePath
+		current, err := c.readEnv()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("read env file: %w", err)
+		}
+		seen := make(map[string]int)
+		for _, cred := range env {
+			change := EnvChange{Name: cred.Name, New: cred.Value, Action: EnvAdd}
+			if old, ok := current[cred.Name]; ok {
+				change.Old = old
+				change.Action = EnvUpdate
+				if old == cred.Value {
+					change.Action = EnvUnchanged
+				}
+			}
+			// A later write to the same variable wins, as in updateEnvFile
+			if i, ok := seen[cred.Name]; ok {
+				p.Env[i] = change
+				continue
+			}
+			seen[cred.Name] = len(p.Env)
+			p.Env = append(p.Env, change)
+		}
+		sort.Slice(p.Env, func(i, j int) bool { return p.Env[i].Name < p.Env[j].Name })
+		for _, change := range p.Env {
+			if change.Action != EnvUnchanged {
+				p.Restart = true
+			}
+		}
+	}
+
+	if len(config) > 0 && c.rpcClient != nil {
+		patch, err := buildConfigPatch(config, false, c.rpcClient.GetConfig)
+		if err != nil {
+			return nil, fmt.Errorf("build config patch: %w", err)
+		}
+		p.ConfigPatch = patch
+		p.Restart = true
+	}
+	return p, nil
+}
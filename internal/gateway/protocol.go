@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Protocol versions OCM speaks; the Gateway picks one in its hello-ok.
+const (
+	minProtocol = 3
+	maxProtocol = 3
+)
+
+// Role and scopes OCM connects with. Config patching and device pairing are
+// operator.admin methods.
+const clientRole = "operator"
+
+var clientScopes = []string{"operator.admin"}
+
+// ErrUnsupported is returned without contacting the Gateway when it doesn't
+// advertise the RPC method.
+var ErrUnsupported = errors.New("not supported by this gateway")
+
+// Capabilities describes the connected Gateway, as negotiated in hello-ok.
+type Capabilities struct {
+	Protocol      int      `json:"protocol"`
+	ServerVersion string   `json:"serverVersion,omitempty"`
+	Methods       []string `json:"methods,omitempty"` // nil if the Gateway doesn't advertise them
+}
+
+// Supports reports whether method is available. Gateways that don't
+// advertise their methods are assumed to support everything.
+func (caps *Capabilities) Supports(method string) bool {
+	if caps == nil || caps.Methods == nil {
+		return true
+	}
+	for _, m := range caps.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Advertises reports whether the Gateway explicitly listed method. Unlike
+// Supports it is false when the Gateway doesn't advertise its methods, for
+// optional methods that older Gateways lack.
+func (caps *Capabilities) Advertises(method string) bool {
+	return caps != nil && caps.Methods != nil && caps.Supports(method)
+}
+
+// helloOK is the payload of a successful connect response.
+type helloOK struct {
+	Protocol int `json:"protocol"`
+	Server   struct {
+		Version string `json:"version"`
+	} `json:"server"`
+	Features struct {
+		Methods []string `json:"methods"`
+	} `json:"features"`
+}
+
+// parseHello extracts the negotiated capabilities from hello-ok. A missing
+// protocol means a Gateway that predates negotiation and speaks minProtocol.
+func parseHello(payload interface{}) (*Capabilities, error) {
+	var hello helloOK
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("marshal hello: %w", err)
+		}
+		if err := json.Unmarshal(data, &hello); err != nil {
+			return nil, fmt.Errorf("parse hello: %w", err)
+		}
+	}
+	if hello.Protocol == 0 {
+		hello.Protocol = minProtocol
+	}
+	if hello.Protocol < minProtocol || hello.Protocol > maxProtocol {
+		return nil, fmt.Errorf("gateway negotiated protocol %d, OCM supports %d-%d", hello.Protocol, minProtocol, maxProtocol)
+	}
+	return &Capabilities{
+		Protocol:      hello.Protocol,
+		ServerVersion: hello.Server.Version,
+		Methods:       hello.Features.Methods,
+	}, nil
+}
+
+// Capabilities returns what the Gateway advertised on the last successful
+// connect, or nil before the first one.
+func (c *RPCClient) Capabilities() *Capabilities {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	return c.caps
+}
+
+// Supports reports whether the Gateway advertised method (see
+// Capabilities.Supports).
+func (c *RPCClient) Supports(method string) bool {
+	return c.Capabilities().Supports(method)
+}
@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultDockerHost is the Docker API socket used when DOCKER_HOST is unset.
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// restartFallbackTimeout bounds a single fallback restart.
+const restartFallbackTimeout = 60 * time.Second
+
+// Restarter restarts OpenClaw without the Gateway's help. It is the fallback
+// when the RPC restart is disabled, unsupported or blocked by a locked
+// config file.
+type Restarter interface {
+	Restart(ctx context.Context, reason string) error
+	String() string
+}
+
+// NewRestarter returns a Docker restarter for container, a command
+// restarter for command, or nil if neither is set. dockerHost may be empty
+// to use DOCKER_HOST or DefaultDockerHost.
+func NewRestarter(container, command, dockerHost string) (Restarter, error) {
+	switch {
+	case container != "" && command != "":
+		return nil, fmt.Errorf("set a restart container or a restart command, not both")
+	case container != "":
+		return NewDockerRestarter(dockerHost, container)
+	case command != "":
+		return &CommandRestarter{Command: command}, nil
+	}
+	return nil, nil
+}
+
+// DockerRestarter restarts a container through the Docker Engine API.
+type DockerRestarter struct {
+	Container string
+	baseURL   string
+	client    *http.Client
+}
+
+// NewDockerRestarter returns a restarter for container on the Docker daemon
+// at host: unix:///path/to/socket, tcp://host:port or http(s)://host:port.
+func NewDockerRestarter(host, container string) (*DockerRestarter, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = DefaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	r := &DockerRestarter{Container: container, client: &http.Client{Timeout: restartFallbackTimeout}}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		r.baseURL = "http://docker"
+		r.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+	case "tcp":
+		r.baseURL = "http://" + u.Host
+	case "http", "https":
+		r.baseURL = u.Scheme + "://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", u.Scheme)
+	}
+	return r, nil
+}
+
+// Restart asks Docker to restart the container, giving it 10 seconds to stop.
+func (r *DockerRestarter) Restart(ctx context.Context, reason string) error {
+	endpoint := fmt.Sprintf("%s/containers/%s/restart?t=10", r.baseURL, url.PathEscape(r.Container))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return fmt.Errorf("docker API: %s: %s", resp.Status, apiErr.Message)
+}
+
+func (r *DockerRestarter) String() string {
+	return "docker restart " + r.Container
+}
+
+// CommandRestarter runs a shell command, e.g., "systemctl restart openclaw".
+// The restart reason is passed in OCM_RESTART_REASON.
+type CommandRestarter struct {
+	Command string
+}
+
+// Restart runs the command with sh -c and fails if it exits non-zero.
+func (r *CommandRestarter) Restart(ctx context.Context, reason string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.Command)
+	cmd.Env = append(os.Environ(), "OCM_RESTART_REASON="+reason)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *CommandRestarter) String() string {
+	return r.Command
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fileRefSuffix marks a .env entry that points at a secret file, following
+// the Docker secrets convention (GITHUB_TOKEN_FILE=/run/secrets/GITHUB_TOKEN).
+const fileRefSuffix = "_FILE"
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetSecretsDir switches env injection to one 0600 file per credential in
+// dir, ideally a tmpfs such as /run/ocm/creds, so secrets never reach a
+// persistent .env. The .env then holds <VAR>_FILE=<dir>/<VAR> references.
+// The directory must be dedicated to OCM: files in it that no longer match a
+// credential are deleted. Empty keeps values in the .env.
+func (c *Client) SetSecretsDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("create secrets dir: %w", err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secretsDir = dir
+	return nil
+}
+
+func (c *Client) getSecretsDir() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.secretsDir
+}
+
+// readEnv returns the credentials in the .env file, resolving references to
+// files in the secrets dir. Plain entries are returned as they are, so
+// switching to a secrets dir migrates OCM's on their next write.
+func (c *Client) readEnv() (map[string]string, error) {
+	env, _, err := c.readEnvRefs()
+	return env, err
+}
+
+// readEnvRefs is readEnv, also returning the names whose values were read
+// from files in the secrets dir.
+func (c *Client) readEnvRefs() (map[string]string, map[string]bool, error) {
+	env, err := c.readEnvFile()
+	refs := make(map[string]bool)
+	dir := c.getSecretsDir()
+	if dir == "" {
+		return env, refs, err
+	}
+
+	for key, value := range env {
+		name, ok := strings.CutSuffix(key, fileRefSuffix)
+		if !ok || filepath.Dir(value) != filepath.Clean(dir) {
+			continue
+		}
+		data, rerr := os.ReadFile(value)
+		if rerr != nil {
+			// A tmpfs is empty after a reboot; the startup sync rewrites it
+			c.logger.Warn("secret file missing", "envVar", name, "path", value, "error", rerr)
+			delete(env, key)
+			continue
+		}
+		delete(env, key)
+		env[name] = string(data)
+		refs[name] = true
+	}
+	return env, refs, err
+}
+
+// writeEnv writes env to the .env file, or with a secrets dir, writes the
+// values of the managed names, those OCM injects, to their own files and
+// references them from the .env. Other entries are kept as they are: they
+// aren't OCM's to move.
+func (c *Client) writeEnv(env map[string]string, managed map[string]bool) error {
+	dir := c.getSecretsDir()
+	if dir == "" {
+		return c.writeEnvFile(env)
+	}
+
+	out := make(map[string]string, len(env))
+	for name, value := range env {
+		if !managed[name] {
+			out[name] = value
+			continue
+		}
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid env var name %q", name)
+		}
+		path := filepath.Join(dir, name)
+		if err := c.writeFileAtomic(path, []byte(value), 0600); err != nil {
+			return fmt.Errorf("write secret file: %w", err)
+		}
+		out[name+fileRefSuffix] = path
+	}
+	if err := c.writeEnvFile(out); err != nil {
+		return err
+	}
+
+	// Remove files for credentials that are gone, once the .env no longer
+	// references them
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if _, ok := env[e.Name()]; (!ok || !managed[e.Name()]) && !e.IsDir() && envNamePattern.MatchString(e.Name()) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				c.logger.Warn("failed to remove stale secret file", "name", e.Name(), "error", err)
+			}
+		}
+	}
+	return nil
+}
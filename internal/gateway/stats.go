@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openclaw/ocm/internal/metrics"
+)
+
+// Operations counted per gateway.
+const (
+	OpRestart         = "restart"          // Restart via config.patch
+	OpRestartFallback = "restart_fallback" // Restart via the fallback restarter
+	OpConfigPatch     = "config_patch"
+	OpConnect         = "connect"    // RPC connection attempt
+	OpDisconnect      = "disconnect" // Established RPC connection lost
+)
+
+// Operation results. Failures are classified so a flaky integration can be
+// told apart from rate limiting or a locked config file.
+const (
+	ResultOK          = "ok"
+	ResultRateLimited = "rate_limited"
+	ResultLocked      = "locked" // Config file locked (EBUSY)
+	ResultUnsupported = "unsupported"
+	ResultDegraded    = "degraded" // Failed fast by the circuit breaker
+	ResultFailed      = "failed"
+)
+
+var gatewayOps = metrics.NewCounterVec("ocm_gateway_operations_total",
+	"Gateway operations by gateway, operation (restart, restart_fallback, config_patch, connect, disconnect) and result.",
+	"gateway", "operation", "result")
+
+// classify returns the result an operation that returned err counts as.
+func classify(err error) string {
+	var rl *ErrRateLimited
+	switch {
+	case err == nil:
+		return ResultOK
+	case errors.As(err, &rl):
+		return ResultRateLimited
+	case errors.Is(err, ErrConfigFileLocked):
+		return ResultLocked
+	case errors.Is(err, ErrUnsupported), errors.Is(err, ErrRestartDisabled):
+		return ResultUnsupported
+	case errors.Is(err, ErrDegraded):
+		return ResultDegraded
+	}
+	return ResultFailed
+}
+
+// SetName names the gateway in metrics and stats; it is DefaultName until
+// set.
+func (c *Client) SetName(name string) {
+	c.mu.Lock()
+	c.name = name
+	c.mu.Unlock()
+	if c.rpcClient != nil {
+		c.rpcClient.setName(name)
+	}
+}
+
+func (c *Client) statsName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.name == "" {
+		return DefaultName
+	}
+	return c.name
+}
+
+func (c *RPCClient) setName(name string) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.name = name
+}
+
+func (c *RPCClient) statsName() string {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	if c.name == "" {
+		return DefaultName
+	}
+	return c.name
+}
+
+// errConnectionLost is what a disconnect counts as.
+var errConnectionLost = errors.New("connection lost")
+
+// count records the outcome of op, remembering the error if it failed.
+func (c *RPCClient) count(op string, err error) {
+	gatewayOps.Inc(c.statsName(), op, classify(err))
+	if err != nil {
+		c.statusMu.Lock()
+		c.lastErr, c.lastErrAt = op+": "+err.Error(), time.Now()
+		c.statusMu.Unlock()
+	}
+}
+
+// OpStats counts one kind of operation by result.
+type OpStats struct {
+	Attempted   int `json:"attempted"`
+	Succeeded   int `json:"succeeded"`
+	RateLimited int `json:"rateLimited"`
+	Locked      int `json:"locked"` // Config file locked (EBUSY)
+	Unsupported int `json:"unsupported"`
+	Degraded    int `json:"degraded"` // Failed fast while the gateway was degraded
+	Failed      int `json:"failed"`   // Any other failure
+}
+
+// Stats counts a gateway's operations since OCM started.
+type Stats struct {
+	Restarts         OpStats    `json:"restarts"`
+	FallbackRestarts OpStats    `json:"fallbackRestarts"`
+	ConfigPatches    OpStats    `json:"configPatches"`
+	Connects         int        `json:"connects"`        // Successful RPC connections
+	ConnectFailures  int        `json:"connectFailures"` // Failed connection attempts
+	Disconnects      int        `json:"disconnects"`     // Established connections lost
+	Reconnects       int        `json:"reconnects"`      // Successful connections after the first
+	LastError        string     `json:"lastError,omitempty"`
+	LastErrorAt      *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Stats returns the gateway's operation counts.
+func (c *Client) Stats() Stats {
+	name := c.statsName()
+	s := Stats{
+		Restarts:         opStats(name, OpRestart),
+		FallbackRestarts: opStats(name, OpRestartFallback),
+		ConfigPatches:    opStats(name, OpConfigPatch),
+		Connects:         int(gatewayOps.Value(name, OpConnect, ResultOK)),
+		Disconnects:      opStats(name, OpDisconnect).Attempted,
+	}
+	s.ConnectFailures = opStats(name, OpConnect).Attempted - s.Connects
+	if s.Connects > 1 {
+		s.Reconnects = s.Connects - 1
+	}
+
+	if c.rpcClient != nil {
+		c.rpcClient.statusMu.RLock()
+		if c.rpcClient.lastErr != "" {
+			at := c.rpcClient.lastErrAt
+			s.LastError, s.LastErrorAt = c.rpcClient.lastErr, &at
+		}
+		c.rpcClient.statusMu.RUnlock()
+	}
+	c.mu.Lock()
+	if c.lastErr != "" && (s.LastErrorAt == nil || c.lastErrAt.After(*s.LastErrorAt)) {
+		at := c.lastErrAt
+		s.LastError, s.LastErrorAt = c.lastErr, &at
+	}
+	c.mu.Unlock()
+	return s
+}
+
+// count records the outcome of op, for operations that don't go through
+// the RPC client.
+func (c *Client) count(op string, err error) {
+	gatewayOps.Inc(c.statsName(), op, classify(err))
+	if err != nil {
+		c.mu.Lock()
+		c.lastErr, c.lastErrAt = op+": "+err.Error(), time.Now()
+		c.mu.Unlock()
+	}
+}
+
+func opStats(name, op string) OpStats {
+	count := func(result string) int { return int(gatewayOps.Value(name, op, result)) }
+	s := OpStats{
+		Succeeded:   count(ResultOK),
+		RateLimited: count(ResultRateLimited),
+		Locked:      count(ResultLocked),
+		Unsupported: count(ResultUnsupported),
+		Degraded:    count(ResultDegraded),
+		Failed:      count(ResultFailed),
+	}
+	s.Attempted = s.Succeeded + s.RateLimited + s.Locked + s.Unsupported + s.Degraded + s.Failed
+	return s
+}
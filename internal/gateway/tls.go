@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSOptions configures wss connections to a Gateway behind an internal CA
+// or one that requires client certificates.
+type TLSOptions struct {
+	CAFile             string `json:"caFile,omitempty"`             // PEM bundle trusted in addition to the system roots
+	CertFile           string `json:"certFile,omitempty"`           // Client certificate (PEM), requires KeyFile
+	KeyFile            string `json:"keyFile,omitempty"`            // Client private key (PEM)
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"` // Don't verify the server certificate; testing only
+}
+
+// IsZero reports whether no option is set, i.e. the default TLS config applies.
+func (o TLSOptions) IsZero() bool {
+	return o == TLSOptions{}
+}
+
+// Config builds a tls.Config from the options. It returns nil, nil when no
+// option is set.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	if o.IsZero() {
+		return nil, nil
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// verifySettle is how long VerifyInjection waits for the restart an
+// injection triggered to begin, so it doesn't check the Gateway that is
+// about to go away.
+var verifySettle = 5 * time.Second
+
+const verifyPoll = 500 * time.Millisecond
+
+// VerifyInjection waits for the Gateway to come back from the restart an
+// injection triggered, then checks it loaded the values: config paths with
+// config.get, env vars with env.get if the Gateway advertises it. Values
+// the Gateway doesn't have are reported as not loaded; values it can't
+// report leave the result unverified.
+func (c *Client) VerifyInjection(ctx context.Context, env []CredentialEnv, config []ConfigCredential) *store.InjectionStatus {
+	if c.rpcClient == nil {
+		return &store.InjectionStatus{Status: store.InjectionUnverified, Detail: "no Gateway RPC connection"}
+	}
+	if err := c.waitRestarted(ctx); err != nil {
+		return &store.InjectionStatus{Status: store.InjectionUnverified, Detail: "gateway did not reconnect: " + err.Error()}
+	}
+
+	var missing, unchecked []string
+	if len(config) > 0 {
+		cfg, err := c.rpcClient.GetConfig()
+		for _, cred := range config {
+			if err != nil {
+				unchecked = append(unchecked, cred.Path)
+				continue
+			}
+			segs, perr := ParseConfigPath(cred.Path)
+			if perr != nil {
+				unchecked = append(unchecked, cred.Path)
+				continue
+			}
+			if v, ok := getPath(cfg, segs); !ok || v != cred.Value {
+				missing = append(missing, cred.Path)
+			}
+		}
+	}
+	if len(env) > 0 {
+		names := make([]string, len(env))
+		for i, cred := range env {
+			names[i] = cred.Name
+		}
+		loaded, err := c.rpcClient.GetEnv(names)
+		for _, cred := range env {
+			if err != nil {
+				unchecked = append(unchecked, cred.Name)
+			} else if v, ok := loaded[cred.Name]; !ok || v != cred.Value {
+				missing = append(missing, cred.Name)
+			}
+		}
+	}
+
+	var details []string
+	if len(missing) > 0 {
+		details = append(details, "not loaded: "+strings.Join(missing, ", "))
+	}
+	if len(unchecked) > 0 {
+		details = append(details, "could not check: "+strings.Join(unchecked, ", "))
+	}
+	status := &store.InjectionStatus{Status: store.InjectionLoaded, Detail: strings.Join(details, "; ")}
+	switch {
+	case len(missing) > 0:
+		status.Status = store.InjectionNotLoaded
+	case len(unchecked) > 0:
+		status.Status = store.InjectionUnverified
+	}
+	return status
+}
+
+// waitRestarted waits for pending coalesced changes to be applied and the
+// Gateway to restart and reconnect.
+func (c *Client) waitRestarted(ctx context.Context) error {
+	c.mu.Lock()
+	settle := verifySettle + c.debounce
+	c.mu.Unlock()
+
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	ticker := time.NewTicker(verifyPoll)
+	defer ticker.Stop()
+	for {
+		c.mu.Lock()
+		pending := c.batch != nil
+		c.mu.Unlock()
+		if !pending && c.rpcClient.ConnectionState() == "connected" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s", c.rpcClient.ConnectionState())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package health checks that stored credentials still work, by calling a
+// cheap, read-only endpoint of their provider with them (see
+// catalog.Check). A revoked or expired token is caught before the agent
+// hits a 401.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/format"
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// ErrNoCheck is returned by Check for a credential whose provider has no
+// check, or that has no token to check.
+var ErrNoCheck = errors.New("no health check for this credential")
+
+// maxBody bounds what is read of a check's response.
+const maxBody = 64 << 10
+
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// Check checks each of cred's access levels that holds a token against the
+// catalog check for cred's service. The result is the worst of the levels':
+// invalid, then error, then ok.
+func Check(ctx context.Context, cred *store.Credential) (*store.CredentialHealth, error) {
+	p, ok := catalog.Lookup(cred.Service)
+	if !ok || p.Check == nil {
+		return nil, ErrNoCheck
+	}
+	field, ok := p.Field(p.Check.Field)
+	if !ok {
+		return nil, ErrNoCheck
+	}
+
+	result := &store.CredentialHealth{Status: store.HealthOK, CheckedAt: time.Now()}
+	var details []string
+	checked := false
+	for _, level := range []struct {
+		name   string
+		access *store.AccessLevel
+	}{{"read", cred.Read}, {"write", cred.ReadWrite}} {
+		// A derived level's token is what it mints from, not what the provider takes
+		if level.access == nil || level.access.Derive != nil {
+			continue
+		}
+		value := format.Value(level.access, field, len(p.Fields) == 1)
+		if value == "" {
+			continue
+		}
+		checked = true
+		status, detail := Probe(ctx, p.Check, value)
+		if rank(status) > rank(result.Status) {
+			result.Status = status
+		}
+		if detail != "" {
+			details = append(details, level.name+": "+detail)
+		}
+	}
+	if !checked {
+		return nil, ErrNoCheck
+	}
+	result.Detail = strings.Join(details, "; ")
+	return result, nil
+}
+
+// rank orders statuses from best to worst.
+func rank(status string) int {
+	switch status {
+	case store.HealthInvalid:
+		return 2
+	case store.HealthError:
+		return 1
+	}
+	return 0
+}
+
+// Probe sends c with value and reports whether the provider accepted it:
+// ok, invalid if it was rejected, or error if the answer didn't say. The
+// detail never contains value.
+func Probe(ctx context.Context, c *catalog.Check, value string) (status, detail string) {
+	fill := func(s string) string { return strings.ReplaceAll(s, "{value}", value) }
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fill(c.URL), nil)
+	if err != nil {
+		return store.HealthError, "invalid check request"
+	}
+	for k, v := range c.Header {
+		req.Header.Set(k, fill(v))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// A url.Error quotes the URL, which may hold the value
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return store.HealthError, redact.String(err.Error())
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+
+	invalid := c.Invalid
+	if len(invalid) == 0 {
+		invalid = []int{http.StatusUnauthorized, http.StatusForbidden}
+	}
+	switch {
+	case slices.Contains(invalid, resp.StatusCode):
+		return store.HealthInvalid, "rejected: " + resp.Status
+	case resp.StatusCode/100 != 2:
+		return store.HealthError, "unexpected " + resp.Status
+	case c.OKField == "":
+		return store.HealthOK, ""
+	}
+
+	var answer map[string]interface{}
+	if err := json.Unmarshal(body, &answer); err != nil {
+		return store.HealthError, "unexpected response: " + err.Error()
+	}
+	if ok, _ := answer[c.OKField].(bool); !ok {
+		if reason, _ := answer["error"].(string); reason != "" {
+			return store.HealthInvalid, fmt.Sprintf("rejected: %s", reason)
+		}
+		return store.HealthInvalid, "rejected"
+	}
+	return store.HealthOK, ""
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openclaw/ocm/internal/catalog"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		switch {
+		case r.URL.Path == "/auth.test" && token == "xoxb-good":
+			w.Write([]byte(`{"ok":true}`))
+		case r.URL.Path == "/auth.test":
+			w.Write([]byte(`{"ok":false,"error":"token_revoked"}`))
+		case token == "good":
+			w.Write([]byte(`{}`))
+		case token == "flaky":
+			http.Error(w, "upstream down", http.StatusBadGateway)
+		default:
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	bearer := &catalog.Check{URL: srv.URL + "/user", Header: map[string]string{"Authorization": "Bearer {value}"}}
+	slack := &catalog.Check{Method: "POST", URL: srv.URL + "/auth.test", Header: bearer.Header, OKField: "ok"}
+	for _, tc := range []struct {
+		check         *catalog.Check
+		value, status string
+	}{
+		{bearer, "good", store.HealthOK},
+		{bearer, "ghp_revoked", store.HealthInvalid},
+		{bearer, "flaky", store.HealthError},
+		{slack, "xoxb-good", store.HealthOK},
+		{slack, "xoxb-revoked", store.HealthInvalid},
+	} {
+		status, detail := Probe(context.Background(), tc.check, tc.value)
+		if status != tc.status {
+			t.Errorf("%s: status = %s (%s), want %s", tc.value, status, detail, tc.status)
+		}
+		if strings.Contains(detail, tc.value) {
+			t.Errorf("%s: detail %q contains the value", tc.value, detail)
+		}
+	}
+
+	// A transport error quotes the URL, which holds the value here
+	status, detail := Probe(context.Background(), &catalog.Check{URL: "http://127.0.0.1:1/bot{value}/getMe"}, "123:secret")
+	if status != store.HealthError || strings.Contains(detail, "123:secret") {
+		t.Errorf("unreachable: %s, %q", status, detail)
+	}
+}
@@ -0,0 +1,68 @@
+// Package logging holds OCM's log level, which can be changed while OCM
+// runs: restarting to get debug logs would also reset the Gateway RPC state
+// being debugged.
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+var (
+	level slog.LevelVar
+
+	mu         sync.Mutex
+	configured slog.Level // From --log-level; Toggle returns to it
+)
+
+// Leveler is the level OCM's loggers are created with. It follows every
+// later change.
+func Leveler() slog.Leveler { return &level }
+
+// Configure sets the level OCM started with.
+func Configure(l slog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	configured = l
+	level.Set(l)
+}
+
+// Level returns the current level.
+func Level() slog.Level { return level.Level() }
+
+// Set changes the level and returns the previous one.
+func Set(l slog.Level) slog.Level {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := level.Level()
+	level.Set(l)
+	return prev
+}
+
+// Toggle switches to debug, or back to the configured level if already at
+// debug, and returns the new level. If OCM was started at debug, it
+// toggles between debug and info.
+func Toggle() slog.Level {
+	mu.Lock()
+	defer mu.Unlock()
+	next := slog.LevelDebug
+	if level.Level() <= slog.LevelDebug {
+		next = configured
+		if next <= slog.LevelDebug {
+			next = slog.LevelInfo
+		}
+	}
+	level.Set(next)
+	return next
+}
+
+// Parse reads a level as --log-level takes it: debug, info, warn or error.
+func Parse(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
+
+// Name returns l as Parse reads it, e.g., "debug".
+func Name(l slog.Level) string { return strings.ToLower(l.String()) }
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestToggle(t *testing.T) {
+	for _, tc := range []struct {
+		configured slog.Level
+		want       []slog.Level
+	}{
+		{slog.LevelInfo, []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelDebug}},
+		{slog.LevelWarn, []slog.Level{slog.LevelDebug, slog.LevelWarn}},
+		{slog.LevelDebug, []slog.Level{slog.LevelInfo, slog.LevelDebug, slog.LevelInfo}},
+	} {
+		Configure(tc.configured)
+		for i, want := range tc.want {
+			if got := Toggle(); got != want {
+				t.Errorf("configured %v: toggle %d = %v, want %v", tc.configured, i+1, got, want)
+			}
+		}
+	}
+
+	// A level set by an admin toggles to debug, then back to the
+	// configured level
+	Configure(slog.LevelInfo)
+	if prev := Set(slog.LevelError); prev != slog.LevelInfo {
+		t.Errorf("Set returned %v, want INFO", prev)
+	}
+	if got := Toggle(); got != slog.LevelDebug {
+		t.Errorf("toggle from error = %v, want DEBUG", got)
+	}
+	if got := Toggle(); got != slog.LevelInfo {
+		t.Errorf("toggle from debug = %v, want INFO", got)
+	}
+	if got := Leveler().Level(); got != slog.LevelInfo {
+		t.Errorf("Leveler = %v, want INFO", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"debug", "info", "warn", "error"} {
+		l, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", s, err)
+		}
+		if Name(l) != s {
+			t.Errorf("Name(Parse(%q)) = %q", s, Name(l))
+		}
+	}
+	if _, err := Parse("verbose"); err == nil {
+		t.Error("Parse(verbose) succeeded")
+	}
+}
@@ -0,0 +1,287 @@
+// Package metrics keeps in-process counters and histograms and serves them
+// in the Prometheus text exposition format. Metrics live for the life of
+// the process; Prometheus is expected to keep history across restarts.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric is a registered metric family.
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]metric)
+)
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[m.name()]; ok {
+		panic("metrics: " + m.name() + " registered twice")
+	}
+	registry[m.name()] = m
+}
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+// Write writes every registered metric in the Prometheus text format,
+// sorted by name.
+func Write(w io.Writer) {
+	registryMu.Lock()
+	all := make([]metric, 0, len(registry))
+	for _, m := range registry {
+		all = append(all, m)
+	}
+	registryMu.Unlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].name() < all[j].name() })
+	for _, m := range all {
+		m.write(w)
+	}
+}
+
+// family holds what counters and histograms share: a name, help text,
+// label names, and one series per distinct set of label values.
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f *family) name() string { return f.metricName }
+
+// key joins label values into a map key; 0xff can't occur in UTF-8.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (f *family) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, f.help, f.metricName, kind)
+}
+
+// labelString renders {a="x",b="y"}, with extra appended (e.g., le).
+func (f *family) labelString(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, f.labels[i], labelEscaper.Replace(v))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extra[i], extra[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec registers a counter. It panics if name is already taken.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: family{metricName: name, help: help, labels: labels}, series: make(map[string]*counterSeries)}
+	register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counters can't decrease")
+	}
+	k := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[k]
+	if !ok {
+		s = &counterSeries{labels: append([]string(nil), labelValues...)}
+		c.series[k] = s
+	}
+	s.value += v
+}
+
+// Value returns the series' current value, zero if it was never touched.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	k := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[k]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// LabelValues returns the label values of every series, sorted.
+func (c *CounterVec) LabelValues() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([][]string, 0, len(c.series))
+	for _, s := range c.series {
+		out = append(out, s.labels)
+	}
+	sortLabelValues(out)
+	return out
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.header(w, "counter")
+	for _, lv := range c.LabelValues() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(lv), formatFloat(c.Value(lv...)))
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	family
+	buckets []float64 // Upper bounds, ascending, without +Inf
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Per bucket, not cumulative; the last is +Inf
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds,
+// which must be ascending. It panics if name is already taken.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: " + name + " buckets must be ascending")
+	}
+	h := &HistogramVec{family: family{metricName: name, help: help, labels: labels}, buckets: buckets, series: make(map[string]*histogramSeries)}
+	register(h)
+	return h
+}
+
+// Observe records v in the series with the given label values.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[k] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.count++
+	s.sum += v
+}
+
+// HistogramSnapshot is a copy of one histogram series.
+type HistogramSnapshot struct {
+	Buckets []float64 // Upper bounds, without +Inf
+	Counts  []uint64  // Cumulative count at or below each bound
+	Count   uint64
+	Sum     float64
+}
+
+// Snapshot copies the series with the given label values; a series never
+// observed has a zero Count.
+func (h *HistogramVec) Snapshot(labelValues ...string) HistogramSnapshot {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snap := HistogramSnapshot{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets))}
+	s, ok := h.series[k]
+	if !ok {
+		return snap
+	}
+	var cum uint64
+	for i := range h.buckets {
+		cum += s.counts[i]
+		snap.Counts[i] = cum
+	}
+	snap.Count, snap.Sum = s.count, s.sum
+	return snap
+}
+
+// LabelValues returns the label values of every series, sorted.
+func (h *HistogramVec) LabelValues() [][]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([][]string, 0, len(h.series))
+	for _, s := range h.series {
+		out = append(out, s.labels)
+	}
+	sortLabelValues(out)
+	return out
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.header(w, "histogram")
+	for _, lv := range h.LabelValues() {
+		snap := h.Snapshot(lv...)
+		for i, upper := range snap.Buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(lv, "le", formatFloat(upper)), snap.Counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(lv, "le", "+Inf"), snap.Count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(lv), formatFloat(snap.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(lv), snap.Count)
+	}
+}
+
+func sortLabelValues(lvs [][]string) {
+	sort.Slice(lvs, func(i, j int) bool {
+		return strings.Join(lvs[i], "\xff") < strings.Join(lvs[j], "\xff")
+	})
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	c := NewCounterVec("test_requests_total", "Requests.", "service", "outcome")
+	c.Inc("github", "approved")
+	c.Add(2, `we"ird\`, "denied")
+	h := NewHistogramVec("test_wait_seconds", "Wait.", []float64{1, 10}, "service")
+	h.Observe(0.5, "github")
+	h.Observe(5, "github")
+	h.Observe(50, "github")
+
+	if v := c.Value("github", "approved"); v != 1 {
+		t.Errorf("Value = %v, want 1", v)
+	}
+	if snap := h.Snapshot("github"); snap.Count != 3 || snap.Sum != 55.5 || snap.Counts[0] != 1 || snap.Counts[1] != 2 {
+		t.Errorf("Snapshot = %+v", snap)
+	}
+
+	var b strings.Builder
+	Write(&b)
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{service="github",outcome="approved"} 1` + "\n",
+		`test_requests_total{service="we\"ird\\",outcome="denied"} 2` + "\n",
+		"# TYPE test_wait_seconds histogram\n",
+		`test_wait_seconds_bucket{service="github",le="1"} 1` + "\n",
+		`test_wait_seconds_bucket{service="github",le="10"} 2` + "\n",
+		`test_wait_seconds_bucket{service="github",le="+Inf"} 3` + "\n",
+		`test_wait_seconds_sum{service="github"} 55.5` + "\n",
+		`test_wait_seconds_count{service="github"} 3` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// AnomalySettingKey is the settings key holding AnomalyConfig.
+const AnomalySettingKey = "notify.anomaly"
+
+const anomalyCheckInterval = 30 * time.Second
+
+// Kinds of AnomalyRule.
+const (
+	AnomalyRate     = "rate"      // Threshold matching entries within Window
+	AnomalyOffHours = "off_hours" // A matching entry outside business hours
+)
+
+// AnomalyConfig holds the rules the AnomalyDetector applies.
+type AnomalyConfig struct {
+	Rules []AnomalyRule `json:"rules"`
+}
+
+// AnomalyRule flags a pattern in the audit log. Each alert is published as
+// an audit.anomaly event; after one, the rule stays quiet for Cooldown for
+// the same group.
+type AnomalyRule struct {
+	Name    string            `json:"name"`
+	Enabled bool              `json:"enabled"`
+	Kind    string            `json:"kind"`              // AnomalyRate or AnomalyOffHours
+	Action  store.AuditAction `json:"action"`            // The entries the rule looks at
+	Service string            `json:"service,omitempty"` // Only this service ("" = all)
+
+	// rate: alert when Threshold entries fall within Window, counted per
+	// GroupBy ("service", "actor", or "" for all together)
+	Threshold int    `json:"threshold,omitempty"`
+	Window    string `json:"window,omitempty"` // e.g., "1m"
+	GroupBy   string `json:"groupBy,omitempty"`
+
+	// off_hours: business hours are [StartHour, EndHour) in Timezone (IANA
+	// name, server's by default), Monday to Friday unless Weekends is set
+	StartHour int    `json:"startHour,omitempty"`
+	EndHour   int    `json:"endHour,omitempty"`
+	Weekends  bool   `json:"weekends,omitempty"`
+	Timezone  string `json:"timezone,omitempty"`
+
+	Severity Severity `json:"severity,omitempty"` // Default "warning"
+	Cooldown string   `json:"cooldown,omitempty"` // Default 1h
+}
+
+// DefaultAnomalyRules are used until an admin saves their own.
+var DefaultAnomalyRules = []AnomalyRule{
+	{Name: "access-burst", Enabled: true, Kind: AnomalyRate, Action: store.ActionCredentialAccess,
+		Threshold: 60, Window: "1m", GroupBy: "service", Severity: SeverityWarning},
+	{Name: "repeated-denials", Enabled: true, Kind: AnomalyRate, Action: store.ActionElevationDenied,
+		Threshold: 3, Window: "1h", GroupBy: "service", Severity: SeverityWarning},
+	{Name: "off-hours-access", Enabled: false, Kind: AnomalyOffHours, Action: store.ActionCredentialAccess,
+		StartHour: 8, EndHour: 19, Severity: SeverityInfo},
+}
+
+// LoadAnomalyConfig reads the stored AnomalyConfig, or the default rules if
+// none is stored.
+func LoadAnomalyConfig(s *store.Store) (*AnomalyConfig, error) {
+	var cfg AnomalyConfig
+	found, err := s.GetSetting(AnomalySettingKey, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		cfg.Rules = append([]AnomalyRule(nil), DefaultAnomalyRules...)
+	}
+	return &cfg, nil
+}
+
+// Validate checks every rule.
+func (c *AnomalyConfig) Validate() error {
+	names := make(map[string]bool, len(c.Rules))
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule %d: duplicate name %q", i, r.Name)
+		}
+		names[r.Name] = true
+		if !r.Action.Valid() {
+			return fmt.Errorf("rule %s: unknown action %q", r.Name, r.Action)
+		}
+		switch r.Kind {
+		case AnomalyRate:
+			if r.Threshold < 1 {
+				return fmt.Errorf("rule %s: threshold must be at least 1", r.Name)
+			}
+			if d, err := time.ParseDuration(r.Window); err != nil || d <= 0 {
+				return fmt.Errorf("rule %s: invalid window %q", r.Name, r.Window)
+			}
+			switch r.GroupBy {
+			case "", "service", "actor":
+			default:
+				return fmt.Errorf("rule %s: groupBy must be \"service\", \"actor\" or empty", r.Name)
+			}
+		case AnomalyOffHours:
+			if r.StartHour < 0 || r.EndHour > 24 || r.StartHour >= r.EndHour {
+				return fmt.Errorf("rule %s: need 0 <= startHour < endHour <= 24", r.Name)
+			}
+			if _, err := time.LoadLocation(r.Timezone); err != nil {
+				return fmt.Errorf("rule %s: unknown timezone %q", r.Name, r.Timezone)
+			}
+		default:
+			return fmt.Errorf("rule %s: kind must be %q or %q", r.Name, AnomalyRate, AnomalyOffHours)
+		}
+		if _, ok := severityRank[r.Severity]; r.Severity != "" && !ok {
+			return fmt.Errorf("rule %s: unknown severity %q", r.Name, r.Severity)
+		}
+		if r.Cooldown != "" {
+			if d, err := time.ParseDuration(r.Cooldown); err != nil || d < 0 {
+				return fmt.Errorf("rule %s: invalid cooldown %q", r.Name, r.Cooldown)
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule looks at e.
+func (r *AnomalyRule) matches(e *store.AuditEntry) bool {
+	return e.Action == r.Action && (r.Service == "" || e.Service == r.Service)
+}
+
+// group returns the key e is counted under.
+func (r *AnomalyRule) group(e *store.AuditEntry) string {
+	switch r.GroupBy {
+	case "service":
+		return e.Service
+	case "actor":
+		return e.Actor
+	}
+	return ""
+}
+
+func (r *AnomalyRule) cooldown() time.Duration {
+	if d, err := time.ParseDuration(r.Cooldown); err == nil {
+		return d
+	}
+	return time.Hour
+}
+
+// offHours reports whether t falls outside the rule's business hours.
+func (r *AnomalyRule) offHours(t time.Time) bool {
+	if loc, err := time.LoadLocation(r.Timezone); err == nil {
+		t = t.In(loc)
+	}
+	if !r.Weekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return true
+	}
+	return t.Hour() < r.StartHour || t.Hour() >= r.EndHour
+}
+
+// AnomalyDetector applies the stored AnomalyConfig to new audit entries
+// every 30 seconds and publishes an audit.anomaly event for each match.
+// Its windows and cooldowns are in memory, so a restart starts afresh.
+type AnomalyDetector struct {
+	store      *store.Store
+	dispatcher *Dispatcher
+	logger     *slog.Logger
+
+	last    time.Time              // Entries before this have been checked
+	windows map[string][]time.Time // rule + group → recent entry times
+	quiet   map[string]time.Time   // rule + group → end of cooldown
+}
+
+// NewAnomalyDetector creates a detector that starts with entries written
+// from now on.
+func NewAnomalyDetector(s *store.Store, d *Dispatcher, logger *slog.Logger) *AnomalyDetector {
+	return &AnomalyDetector{
+		store:      s,
+		dispatcher: d,
+		logger:     logger,
+		last:       time.Now(),
+		windows:    make(map[string][]time.Time),
+		quiet:      make(map[string]time.Time),
+	}
+}
+
+// Run checks for anomalies until ctx is done.
+func (a *AnomalyDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(anomalyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(time.Now())
+		}
+	}
+}
+
+// check applies the rules to the entries written since the last check.
+func (a *AnomalyDetector) check(now time.Time) {
+	cfg, err := LoadAnomalyConfig(a.store)
+	if err != nil {
+		a.logger.Error("failed to load anomaly rules", "error", err)
+		return
+	}
+	entries, err := a.store.ListAuditEntriesBetween(a.last, now)
+	if err != nil {
+		a.logger.Error("failed to list audit entries for anomaly check", "error", err)
+		return
+	}
+	a.last = now
+
+	for _, e := range entries {
+		for i := range cfg.Rules {
+			if r := &cfg.Rules[i]; r.Enabled && r.matches(e) {
+				a.apply(r, e)
+			}
+		}
+	}
+
+	// Forget windows that have gone quiet
+	for key, times := range a.windows {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > 24*time.Hour {
+			delete(a.windows, key)
+		}
+	}
+	for key, until := range a.quiet {
+		if now.After(until) {
+			delete(a.quiet, key)
+		}
+	}
+}
+
+// apply evaluates one rule against one matching entry.
+func (a *AnomalyDetector) apply(r *AnomalyRule, e *store.AuditEntry) {
+	group := r.group(e)
+	key := r.Name + "\x00" + group
+	if e.Timestamp.Before(a.quiet[key]) {
+		return
+	}
+
+	var details string
+	switch r.Kind {
+	case AnomalyRate:
+		window, _ := time.ParseDuration(r.Window)
+		times := append(a.windows[key], e.Timestamp)
+		for len(times) > 0 && e.Timestamp.Sub(times[0]) >= window {
+			times = times[1:]
+		}
+		a.windows[key] = times
+		if len(times) < r.Threshold {
+			return
+		}
+		delete(a.windows, key)
+		details = fmt.Sprintf("%s: %d %s within %s", r.Name, len(times), r.Action, window)
+	case AnomalyOffHours:
+		if !r.offHours(e.Timestamp) {
+			return
+		}
+		at := e.Timestamp
+		if loc, err := time.LoadLocation(r.Timezone); err == nil {
+			at = at.In(loc)
+		}
+		details = fmt.Sprintf("%s: %s outside business hours at %s", r.Name, r.Action, at.Format("Mon 15:04 MST"))
+	}
+	if group != "" {
+		details += fmt.Sprintf(" (%s %s)", r.GroupBy, group)
+	}
+	a.quiet[key] = e.Timestamp.Add(r.cooldown())
+
+	severity := r.Severity
+	if severity == "" {
+		severity = SeverityWarning
+	}
+	a.logger.Warn("audit anomaly detected", "rule", r.Name, "service", e.Service, "actor", e.Actor)
+	a.dispatcher.Publish(Event{
+		Type:        EventAuditAnomaly,
+		Time:        e.Timestamp,
+		Service:     e.Service,
+		Scope:       e.Scope,
+		ElevationID: e.ElevationID,
+		Actor:       e.Actor,
+		Details:     details,
+		Severity:    severity,
+	})
+}
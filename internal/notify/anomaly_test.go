@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	db := newTestStore(t)
+	if err := db.PutSetting(AnomalySettingKey, &AnomalyConfig{Rules: []AnomalyRule{
+		{Name: "burst", Enabled: true, Kind: AnomalyRate, Action: store.ActionCredentialAccess, Threshold: 3, Window: "1m", GroupBy: "service"},
+		{Name: "denials", Enabled: false, Kind: AnomalyRate, Action: store.ActionElevationDenied, Threshold: 1, Window: "1h"},
+		{Name: "night", Enabled: true, Kind: AnomalyOffHours, Action: store.ActionElevationApproved, StartHour: 9, EndHour: 17, Timezone: "UTC", Severity: SeverityInfo},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(nil)
+	events, cancel := d.Subscribe()
+	defer cancel()
+	a := NewAnomalyDetector(db, d, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	// Monday 10:00 UTC
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	a.last = base
+	add := func(id string, action store.AuditAction, service string, at time.Time) {
+		if err := db.InsertAuditEntry(&store.AuditEntry{ID: id, Timestamp: at, Action: action, Service: service, Actor: "agent"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Three github reads within a minute (a fourth during the cooldown), two
+	// slack reads, a denial for a disabled rule, one approval in hours and
+	// one at night
+	for i := 0; i < 4; i++ {
+		add(fmt.Sprintf("gh-%d", i), store.ActionCredentialAccess, "github", base.Add(time.Duration(i)*10*time.Second))
+	}
+	add("slack-0", store.ActionCredentialAccess, "slack", base)
+	add("slack-1", store.ActionCredentialAccess, "slack", base.Add(2*time.Minute))
+	add("denied", store.ActionElevationDenied, "github", base)
+	add("day", store.ActionElevationApproved, "github", base.Add(time.Hour))
+	add("night", store.ActionElevationApproved, "github", base.Add(12*time.Hour))
+	a.check(base.Add(13 * time.Hour))
+
+	var got []Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if len(got) != 2 {
+		t.Fatalf("events = %+v, want 2", got)
+	}
+	if e := got[0]; e.Type != EventAuditAnomaly || e.Service != "github" || e.Severity != SeverityWarning ||
+		!strings.HasPrefix(e.Details, "burst: 3 credential_access within 1m0s (service github)") {
+		t.Errorf("burst event = %+v", e)
+	}
+	if e := got[1]; e.Severity != SeverityInfo || !strings.Contains(Summary(e), "outside business hours at Mon 22:00 UTC") {
+		t.Errorf("off-hours event = %+v (%s)", e, Summary(e))
+	}
+
+	// Nothing new, nothing published
+	a.check(base.Add(14 * time.Hour))
+	if len(events) != 0 {
+		t.Errorf("repeat check published %d events", len(events))
+	}
+}
+
+func TestAnomalyConfig_Validate(t *testing.T) {
+	if err := (&AnomalyConfig{Rules: DefaultAnomalyRules}).Validate(); err != nil {
+		t.Errorf("default rules: %v", err)
+	}
+	for _, r := range []AnomalyRule{
+		{Name: "a", Kind: AnomalyRate, Action: "credential_acess", Threshold: 1, Window: "1m"},
+		{Name: "a", Kind: AnomalyRate, Action: store.ActionCredentialAccess, Threshold: 0, Window: "1m"},
+		{Name: "a", Kind: AnomalyRate, Action: store.ActionCredentialAccess, Threshold: 1, Window: "soon"},
+		{Name: "a", Kind: AnomalyRate, Action: store.ActionCredentialAccess, Threshold: 1, Window: "1m", GroupBy: "scope"},
+		{Name: "a", Kind: AnomalyOffHours, Action: store.ActionCredentialAccess, StartHour: 18, EndHour: 9},
+		{Name: "a", Kind: AnomalyOffHours, Action: store.ActionCredentialAccess, StartHour: 9, EndHour: 17, Timezone: "Mars/Base"},
+		{Name: "a", Kind: "spike", Action: store.ActionCredentialAccess},
+	} {
+		if err := (&AnomalyConfig{Rules: []AnomalyRule{r}}).Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", r)
+		}
+	}
+}
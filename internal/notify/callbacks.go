@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+// AgentCallbackConfig configures callbacks to requesting agents.
+type AgentCallbackConfig struct {
+	// Allow lists the URL prefixes an agent may ask to be called back on,
+	// e.g., "https://agent.internal:8443/ocm/". Scheme and host must match
+	// exactly; the path must match whole segments.
+	Allow []string
+	// Secret signs every callback (see webhook.Sign) so the agent can tell
+	// OCM's callbacks from forgeries.
+	Secret string
+}
+
+// agentCallbackEvents are the elevation events an agent is called back on.
+var agentCallbackEvents = []EventType{EventElevationApproved, EventElevationDenied, EventElevationRevoked}
+
+// AgentCallbackPayload is the JSON body POSTed to an elevation's callback URL.
+type AgentCallbackPayload struct {
+	ID        string     `json:"id"` // Delivery ID, also sent as X-OCM-Delivery
+	Event     EventType  `json:"event"`
+	Time      time.Time  `json:"time"`
+	RequestID string     `json:"requestId"` // The elevation's ID, as returned by POST /elevate
+	Status    string     `json:"status"`    // approved, denied or revoked
+	Service   string     `json:"service"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // Set when approved
+	Reason    string     `json:"reason,omitempty"`    // The denial or revocation reason
+}
+
+// AgentCallbacks POSTs elevation decisions to the callback URL the
+// requesting agent supplied, so agents can react without polling or
+// holding an event stream open. Deliveries are retried with the same
+// backoff as admin webhooks but aren't recorded; a callback that never
+// lands leaves the agent to find out the next time it asks.
+type AgentCallbacks struct {
+	store   *store.Store
+	allow   []*url.URL
+	secret  string
+	client  *http.Client
+	backoff []time.Duration
+	logger  *slog.Logger
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewAgentCallbacks creates the agent callback notifier. It fails if an
+// allowlist entry isn't an absolute http(s) URL or no secret is set.
+func NewAgentCallbacks(s *store.Store, cfg AgentCallbackConfig, logger *slog.Logger) (*AgentCallbacks, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("agent callbacks need a signing secret")
+	}
+	c := &AgentCallbacks{
+		store:   s,
+		secret:  cfg.Secret,
+		client:  webhook.DefaultClient,
+		backoff: defaultWebhookBackoff,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+	for _, entry := range cfg.Allow {
+		u, err := parseCallbackURL(entry)
+		if err != nil {
+			return nil, fmt.Errorf("callback allowlist entry %q: %w", entry, err)
+		}
+		c.allow = append(c.allow, u)
+	}
+	return c, nil
+}
+
+// Name implements Notifier.
+func (c *AgentCallbacks) Name() string { return "agent-callbacks" }
+
+// Allowed returns an error unless raw is an http(s) URL under one of the
+// allowlisted prefixes.
+func (c *AgentCallbacks) Allowed(raw string) error {
+	u, err := parseCallbackURL(raw)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range c.allow {
+		if underPrefix(u, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("callback URL is not on the allowlist")
+}
+
+func parseCallbackURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL must have a host")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("URL must not contain credentials")
+	}
+	return u, nil
+}
+
+// underPrefix reports whether u is prefix or beneath it. "/hooks" allows
+// "/hooks" and "/hooks/x" but not "/hooksmith".
+func underPrefix(u, prefix *url.URL) bool {
+	if u.Scheme != prefix.Scheme || !strings.EqualFold(u.Host, prefix.Host) {
+		return false
+	}
+	base := strings.TrimSuffix(prefix.Path, "/")
+	return u.Path == base || strings.HasPrefix(u.Path, base+"/")
+}
+
+// Notify implements Notifier. Deliveries run in the background because
+// retries outlive the dispatcher's per-notification timeout.
+func (c *AgentCallbacks) Notify(ctx context.Context, e Event) error {
+	wanted := false
+	for _, t := range agentCallbackEvents {
+		wanted = wanted || e.Type == t
+	}
+	if !wanted || e.ElevationID == "" {
+		return nil
+	}
+	elev, err := c.store.GetElevation(e.ElevationID)
+	if err != nil {
+		return err
+	}
+	if elev == nil || elev.CallbackURL == "" {
+		return nil
+	}
+	// The allowlist may have shrunk since the request was made
+	if err := c.Allowed(elev.CallbackURL); err != nil {
+		c.logger.Warn("agent callback dropped", "elevation_id", elev.ID, "error", err)
+		return nil
+	}
+
+	payload := AgentCallbackPayload{
+		ID:        newDeliveryID(),
+		Event:     e.Type,
+		Time:      e.Time,
+		RequestID: elev.ID,
+		Status:    strings.TrimPrefix(string(e.Type), "elevation."),
+		Service:   elev.Service,
+		Scope:     elev.Scope,
+	}
+	if e.Type == EventElevationApproved {
+		payload.ExpiresAt = e.ExpiresAt
+	} else {
+		payload.Reason = e.Reason
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.deliver(elev.CallbackURL, payload, body)
+	}()
+	return nil
+}
+
+// deliver POSTs body until it succeeds, retries run out, or Close is called.
+func (c *AgentCallbacks) deliver(target string, p AgentCallbackPayload, body []byte) {
+	header := http.Header{}
+	header.Set(WebhookEventHeader, string(p.Event))
+	header.Set(WebhookDeliveryHeader, p.ID)
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		err := webhook.PostRawHeaders(ctx, c.client, target, c.secret, body, header)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= len(c.backoff) {
+			c.logger.Warn("agent callback failed", "elevation_id", p.RequestID, "event", p.Event, "attempts", attempt+1, "error", err)
+			return
+		}
+		select {
+		case <-time.After(c.backoff[attempt]):
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close abandons outstanding retries and waits for in-flight attempts.
+func (c *AgentCallbacks) Close() {
+	c.once.Do(func() { close(c.done) })
+	c.wg.Wait()
+}
+
+// Wait blocks until every delivery has finished (used in tests).
+func (c *AgentCallbacks) Wait() { c.wg.Wait() }
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+func TestAgentCallbacks_Allowed(t *testing.T) {
+	c, err := NewAgentCallbacks(nil, AgentCallbackConfig{Allow: []string{"https://agent.internal/ocm/", "http://localhost:9000"}, Secret: "s"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for raw, want := range map[string]bool{
+		"https://agent.internal/ocm":            true,
+		"https://agent.internal/ocm/elev_1":     true,
+		"https://AGENT.internal/ocm/x":          true,
+		"http://localhost:9000/anything":        true,
+		"https://agent.internal/ocmx":           false,
+		"http://agent.internal/ocm/x":           false,
+		"https://agent.internal.evil.com/ocm/x": false,
+		"https://user:pw@agent.internal/ocm/x":  false,
+		"http://localhost:9001/":                false,
+		"/ocm/x":                                false,
+	} {
+		if got := c.Allowed(raw) == nil; got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", raw, got, want)
+		}
+	}
+
+	if _, err := NewAgentCallbacks(nil, AgentCallbackConfig{Allow: []string{"https://a/"}}, nil); err == nil {
+		t.Error("expected an error without a secret")
+	}
+	if _, err := NewAgentCallbacks(nil, AgentCallbackConfig{Allow: []string{"ftp://a/"}, Secret: "s"}, nil); err == nil {
+		t.Error("expected an error for a non-http allowlist entry")
+	}
+}
+
+func TestAgentCallbacks_Deliver(t *testing.T) {
+	db := newTestStore(t)
+
+	got := make(chan AgentCallbackPayload, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+		if r.Header.Get(webhook.SignatureHeader) != webhook.Sign("agent-secret", ts, body) {
+			t.Error("bad signature")
+		}
+		var p AgentCallbackPayload
+		json.Unmarshal(body, &p)
+		got <- p
+	}))
+	defer srv.Close()
+
+	c, err := NewAgentCallbacks(db, AgentCallbackConfig{Allow: []string{srv.URL + "/cb"}, Secret: "agent-secret"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "t"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, elev := range []*store.Elevation{
+		{ID: "elev_cb", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now(), CallbackURL: srv.URL + "/cb/elev_cb"},
+		{ID: "elev_none", Service: "github", Scope: "write", Status: "pending", RequestedAt: time.Now()},
+	} {
+		if err := db.CreateElevation(elev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expires := time.Now().Add(time.Hour)
+	ctx := context.Background()
+	c.Notify(ctx, Event{Type: EventElevationRequested, ElevationID: "elev_cb"})
+	c.Notify(ctx, Event{Type: EventElevationApproved, ElevationID: "elev_none", ExpiresAt: &expires})
+	c.Notify(ctx, Event{Type: EventElevationApproved, ElevationID: "elev_cb", ExpiresAt: &expires})
+	c.Wait()
+	c.Notify(ctx, Event{Type: EventElevationRevoked, ElevationID: "elev_cb", Reason: "done"})
+	c.Wait()
+	close(got)
+
+	var payloads []AgentCallbackPayload
+	for p := range got {
+		payloads = append(payloads, p)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 callbacks, got %+v", payloads)
+	}
+	if p := payloads[0]; p.RequestID != "elev_cb" || p.Status != "approved" || p.ExpiresAt == nil {
+		t.Errorf("approved callback = %+v", p)
+	}
+	if p := payloads[1]; p.Status != "revoked" || p.Reason != "done" || p.ExpiresAt != nil {
+		t.Errorf("revoked callback = %+v", p)
+	}
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// DigestReport summarizes activity over a period, built from the audit log.
+type DigestReport struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Accesses     map[string]int `json:"accesses"` // Credential accesses per service
+	Requested    int            `json:"requested"`
+	Granted      int            `json:"granted"`
+	Denied       int            `json:"denied"`
+	Revoked      int            `json:"revoked"`
+	Expired      int            `json:"expired"`
+	Unused       []string       `json:"unused"`       // Credentials not accessed during the period
+	AdminChanges int            `json:"adminChanges"` // Credential create/update/delete
+}
+
+// BuildDigest summarizes the audit log between from and to.
+func BuildDigest(s *store.Store, from, to time.Time) (*DigestReport, error) {
+	entries, err := s.ListAuditEntriesBetween(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	creds, err := s.ListCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+
+	r := &DigestReport{From: from, To: to, Accesses: make(map[string]int), Unused: []string{}}
+	for _, e := range entries {
+		switch e.Action {
+		case store.ActionCredentialAccess:
+			r.Accesses[e.Service]++
+		case store.ActionElevationRequested, store.ActionElevationQueued:
+			r.Requested++
+		case store.ActionElevationApproved:
+			r.Granted++
+		case store.ActionElevationDenied, store.ActionElevationRejected:
+			r.Denied++
+		case store.ActionElevationRevoked:
+			r.Revoked++
+		case store.ActionElevationExpired:
+			r.Expired++
+		case store.ActionCredentialCreated, store.ActionCredentialUpdated, store.ActionCredentialDeleted:
+			r.AdminChanges++
+		}
+	}
+	for _, c := range creds {
+		if r.Accesses[c.Service] == 0 {
+			r.Unused = append(r.Unused, c.Service)
+		}
+	}
+	sort.Strings(r.Unused)
+	return r, nil
+}
+
+// Text renders the report for email and chat.
+func (r *DigestReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "OCM digest %s to %s\n\n", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))
+
+	total := 0
+	services := make([]string, 0, len(r.Accesses))
+	for svc, n := range r.Accesses {
+		total += n
+		services = append(services, svc)
+	}
+	sort.Strings(services)
+	fmt.Fprintf(&b, "Credential accesses: %d\n", total)
+	for _, svc := range services {
+		fmt.Fprintf(&b, "  %s: %d\n", svc, r.Accesses[svc])
+	}
+
+	fmt.Fprintf(&b, "\nElevations requested: %d\n", r.Requested)
+	fmt.Fprintf(&b, "Elevations granted: %d\n", r.Granted)
+	fmt.Fprintf(&b, "Elevations denied: %d\n", r.Denied)
+	fmt.Fprintf(&b, "Elevations revoked: %d\n", r.Revoked)
+	fmt.Fprintf(&b, "Elevations expired: %d\n", r.Expired)
+	fmt.Fprintf(&b, "Credential changes: %d\n", r.AdminChanges)
+
+	if len(r.Unused) > 0 {
+		fmt.Fprintf(&b, "\nUnused credentials (consider removing): %s\n", strings.Join(r.Unused, ", "))
+	}
+	return b.String()
+}
+
+// DigestPeriod is how often digests go out.
+type DigestPeriod string
+
+const (
+	DigestDaily  DigestPeriod = "daily"
+	DigestWeekly DigestPeriod = "weekly"
+)
+
+// Duration is the length of the period a digest covers.
+func (p DigestPeriod) Duration() time.Duration {
+	if p == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// nextDigest returns the next send time after now: the given hour each day,
+// or on Mondays for weekly digests.
+func nextDigest(now time.Time, p DigestPeriod, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	for !next.After(now) || (p == DigestWeekly && next.Weekday() != time.Monday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// DigestScheduler publishes a report.digest event on schedule. Email and
+// webhooks subscribed to "report.*" deliver it.
+type DigestScheduler struct {
+	store      *store.Store
+	dispatcher *Dispatcher
+	period     DigestPeriod
+	hour       int
+	logger     *slog.Logger
+}
+
+// NewDigestScheduler creates a scheduler sending at hour (0-23, local time).
+func NewDigestScheduler(s *store.Store, d *Dispatcher, period DigestPeriod, hour int, logger *slog.Logger) *DigestScheduler {
+	return &DigestScheduler{store: s, dispatcher: d, period: period, hour: hour, logger: logger}
+}
+
+// Run sends digests until ctx is done.
+func (ds *DigestScheduler) Run(ctx context.Context) {
+	for {
+		next := nextDigest(time.Now(), ds.period, ds.hour)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		report, err := BuildDigest(ds.store, next.Add(-ds.period.Duration()), next)
+		if err != nil {
+			ds.logger.Error("failed to build digest", "error", err)
+			continue
+		}
+		ds.dispatcher.Publish(Event{
+			Type:    EventReportDigest,
+			Time:    next,
+			Actor:   "system",
+			Details: report.Text(),
+			Report:  report,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestBuildDigest(t *testing.T) {
+	db := newTestStore(t)
+	for _, svc := range []string{"github", "linear"} {
+		if err := db.SaveCredential(&store.Credential{
+			ID: "cred-" + svc, Service: svc, DisplayName: svc, Type: "pat",
+			Read: &store.AccessLevel{EnvVar: strings.ToUpper(svc) + "_TOKEN", Token: "t"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	for i, action := range []store.AuditAction{"credential_access", "credential_access", "elevation_requested", "elevation_approved", "elevation_denied"} {
+		db.InsertAuditEntry(&store.AuditEntry{ID: "a" + string(rune('0'+i)), Timestamp: now.Add(-time.Hour), Action: action, Service: "github"})
+	}
+	// Outside the period
+	db.InsertAuditEntry(&store.AuditEntry{ID: "old", Timestamp: now.Add(-48 * time.Hour), Action: "credential_access", Service: "linear"})
+
+	r, err := BuildDigest(db, now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Accesses["github"] != 2 || r.Requested != 1 || r.Granted != 1 || r.Denied != 1 {
+		t.Errorf("unexpected counts: %+v", r)
+	}
+	if len(r.Unused) != 1 || r.Unused[0] != "linear" {
+		t.Errorf("unused = %v, want [linear]", r.Unused)
+	}
+	if !strings.Contains(r.Text(), "Unused credentials (consider removing): linear") {
+		t.Errorf("text missing unused credentials:\n%s", r.Text())
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	// Wednesday 2024-05-15 09:30
+	now := time.Date(2024, 5, 15, 9, 30, 0, 0, time.UTC)
+	if got := nextDigest(now, DigestDaily, 8); !got.Equal(time.Date(2024, 5, 16, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily after 8am = %v", got)
+	}
+	if got := nextDigest(now, DigestDaily, 17); !got.Equal(time.Date(2024, 5, 15, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily before 5pm = %v", got)
+	}
+	if got := nextDigest(now, DigestWeekly, 8); !got.Equal(time.Date(2024, 5, 20, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly = %v, want Monday 2024-05-20 08:00", got)
+	}
+}
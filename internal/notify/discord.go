@@ -0,0 +1,287 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discordAPIURL = "https://discord.com/api/v10"
+
+	// Interaction and response types from the Discord API
+	discordInteractionPing        = 1
+	discordInteractionComponent   = 3
+	discordResponsePong           = 1
+	discordResponseDeferredUpdate = 6
+	discordFlagEphemeral          = 64
+
+	discordActionApprove = "ocm_approve"
+	discordActionDeny    = "ocm_deny"
+
+	// discordMaxSkew is how old a signed interaction may be (replay protection).
+	discordMaxSkew = 5 * time.Minute
+)
+
+// DiscordConfig configures the Discord notifier. Set WebhookURL for
+// fire-and-forget messages, or BotToken, ChannelID and PublicKey for
+// messages with Approve/Deny buttons.
+type DiscordConfig struct {
+	WebhookURL string        // Channel webhook URL
+	BotToken   string        // Bot token; messages are posted as the bot
+	ChannelID  string        // Channel the bot posts to
+	PublicKey  string        // Application public key (hex); verifies interactions
+	ApproveTTL time.Duration // TTL granted by the Approve button (clamped to the credential's maxTTL)
+}
+
+// Interactive reports whether the bot (rather than a webhook) is configured.
+func (c DiscordConfig) Interactive() bool {
+	return c.BotToken != "" && c.ChannelID != ""
+}
+
+// Discord posts elevation events to a Discord channel. In bot mode pending
+// requests get Approve/Deny buttons, handled by InteractionHandler.
+type Discord struct {
+	cfg       DiscordConfig
+	apiURL    string
+	publicKey ed25519.PublicKey
+	client    *http.Client
+	logger    *slog.Logger
+}
+
+// NewDiscord creates a Discord notifier.
+func NewDiscord(cfg DiscordConfig, logger *slog.Logger) (*Discord, error) {
+	if cfg.ApproveTTL <= 0 {
+		cfg.ApproveTTL = 30 * time.Minute
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	d := &Discord{
+		cfg:    cfg,
+		apiURL: discordAPIURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+	if cfg.Interactive() {
+		key, err := hex.DecodeString(cfg.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("discord public key must be %d hex-encoded bytes", ed25519.PublicKeySize)
+		}
+		d.publicKey = key
+	} else if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("discord requires a webhook URL or a bot token and channel")
+	}
+	return d, nil
+}
+
+// Name implements Notifier.
+func (d *Discord) Name() string { return "discord" }
+
+// Notify implements Notifier. Like Slack, only chatEvents are posted
+// unless routed otherwise.
+func (d *Discord) Notify(ctx context.Context, e Event) error {
+	if !wants(e, chatEvents...) {
+		return nil
+	}
+	msg := map[string]interface{}{"content": Summary(e)}
+
+	if !d.cfg.Interactive() {
+		return d.post(ctx, d.cfg.WebhookURL, "", msg)
+	}
+	if awaitsDecision(e) {
+		msg["components"] = d.requestComponents(e)
+	}
+	return d.post(ctx, d.apiURL+"/channels/"+d.cfg.ChannelID+"/messages", "Bot "+d.cfg.BotToken, msg)
+}
+
+func (d *Discord) requestComponents(e Event) []interface{} {
+	button := func(label string, style int, action string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":      2, // Button
+			"label":     label,
+			"style":     style,
+			"custom_id": action + ":" + e.ElevationID,
+		}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"type": 1, // Action row
+			"components": []interface{}{
+				button("Approve ("+d.cfg.ApproveTTL.String()+")", 3, discordActionApprove), // Success (green)
+				button("Deny", 4, discordActionDeny),                                       // Danger (red)
+			},
+		},
+	}
+}
+
+func (d *Discord) post(ctx context.Context, url, auth string, body interface{}) error {
+	return d.send(ctx, http.MethodPost, url, auth, body)
+}
+
+func (d *Discord) send(ctx context.Context, method, url, auth string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// Webhook URLs embed their token; don't let it reach the logs
+		return fmt.Errorf("discord: request failed")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("discord: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// discordUser is the subset of a Discord user we use.
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// discordInteraction is the subset of an interaction payload we use.
+type discordInteraction struct {
+	Type          int    `json:"type"`
+	ApplicationID string `json:"application_id"`
+	Token         string `json:"token"` // Authorizes follow-ups for 15 minutes
+	Member        *struct {
+		User discordUser `json:"user"`
+	} `json:"member"` // Set in guilds
+	User *discordUser `json:"user"` // Set in DMs
+	Data struct {
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+}
+
+// InteractionHandler handles Discord's interactions endpoint (button clicks).
+// Requests must carry a fresh, valid Ed25519 signature from the
+// application; decisions are attributed to "discord:<username>". A click is
+// answered with a deferred update at once and decided afterwards, the
+// outcome then edited into the message.
+func (d *Discord) InteractionHandler(dec Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err := d.verify(r.Header, body, time.Now()); err != nil {
+			d.logger.Warn("rejected discord interaction", "error", err)
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+			return
+		}
+
+		var in discordInteraction
+		if err := json.Unmarshal(body, &in); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if in.Type == discordInteractionPing {
+			json.NewEncoder(w).Encode(map[string]int{"type": discordResponsePong})
+			return
+		}
+		if in.Type != discordInteractionComponent {
+			http.Error(w, "unsupported interaction", http.StatusBadRequest)
+			return
+		}
+
+		user := in.User
+		if in.Member != nil {
+			user = &in.Member.User
+		}
+		if user == nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		action, _, _ := strings.Cut(in.Data.CustomID, ":")
+		if action != discordActionApprove && action != discordActionDeny {
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+
+		// Defer first: Discord expects a response within 3s, and a decision
+		// may take longer (e.g. restarting the Gateway to inject)
+		json.NewEncoder(w).Encode(map[string]int{"type": discordResponseDeferredUpdate})
+		go d.decide(dec, in, *user)
+	})
+}
+
+// decide applies the decision of an interaction's button, then edits the
+// outcome into the message, or on failure leaves the buttons in place and
+// tells only the clicker.
+func (d *Discord) decide(dec Decider, in discordInteraction, user discordUser) {
+	actor := "discord:" + user.Username
+	action, id, _ := strings.Cut(in.Data.CustomID, ":")
+
+	var err error
+	var reply string
+	switch action {
+	case discordActionApprove:
+		err = dec.ApproveElevation(id, d.cfg.ApproveTTL, actor)
+		reply = fmt.Sprintf(":white_check_mark: Request `%s` approved by <@%s> for %s", id, user.ID, d.cfg.ApproveTTL)
+	case discordActionDeny:
+		err = dec.DenyElevation(id, actor, "denied from Discord")
+		reply = fmt.Sprintf(":no_entry: Request `%s` denied by <@%s>", id, user.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	webhook := d.apiURL + "/webhooks/" + in.ApplicationID + "/" + in.Token
+	if err != nil {
+		d.logger.Warn("discord decision failed", "elevation", id, "error", err)
+		err = d.post(ctx, webhook, "", map[string]interface{}{
+			"content": fmt.Sprintf("Could not update request `%s`: %s", id, err),
+			"flags":   discordFlagEphemeral,
+		})
+	} else {
+		err = d.send(ctx, http.MethodPatch, webhook+"/messages/@original", "",
+			map[string]interface{}{"content": reply, "components": []interface{}{}})
+	}
+	if err != nil {
+		d.logger.Warn("discord interaction follow-up failed", "elevation", id, "error", err)
+	}
+}
+
+// verify checks Discord's request signature, Ed25519 over timestamp+body,
+// and that the timestamp is recent.
+func (d *Discord) verify(h http.Header, body []byte, now time.Time) error {
+	sig, err := hex.DecodeString(h.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize || d.publicKey == nil {
+		return fmt.Errorf("missing signature")
+	}
+	ts := h.Get("X-Signature-Timestamp")
+	msg := append([]byte(ts), body...)
+	if !ed25519.Verify(d.publicKey, msg, sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing timestamp")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > discordMaxSkew || age < -discordMaxSkew {
+		return fmt.Errorf("stale timestamp")
+	}
+	return nil
+}
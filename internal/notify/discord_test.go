@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscord_InteractionApprove(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	d, err := NewDiscord(DiscordConfig{BotToken: "bot", ChannelID: "c1", PublicKey: hex.EncodeToString(pub)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := &fakeDecider{}
+	edits := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Content string `json:"content"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		if r.Method != http.MethodPatch || r.URL.Path != "/webhooks/app-1/tok-1/messages/@original" {
+			t.Errorf("unexpected follow-up %s %s", r.Method, r.URL.Path)
+		}
+		edits <- msg.Content
+	}))
+	defer api.Close()
+	d.apiURL = api.URL
+
+	interaction := func(body string, key ed25519.PrivateKey, sent time.Time) *httptest.ResponseRecorder {
+		ts := strconv.FormatInt(sent.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/discord/interactions", strings.NewReader(body))
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+		rec := httptest.NewRecorder()
+		d.InteractionHandler(dec).ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := interaction(`{"type":1}`, priv, time.Now()); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"type":1`) {
+		t.Fatalf("ping: %d %s", rec.Code, rec.Body)
+	}
+
+	click := `{"type":3,"application_id":"app-1","token":"tok-1",` +
+		`"member":{"user":{"id":"42","username":"alice"}},"data":{"custom_id":"ocm_approve:elev-1"}}`
+	_, forged, _ := ed25519.GenerateKey(nil)
+	if rec := interaction(click, forged, time.Now()); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("forged signature: got %d", rec.Code)
+	}
+	if rec := interaction(click, priv, time.Now().Add(-time.Hour)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("stale timestamp: got %d", rec.Code)
+	}
+	if dec.approved != "" {
+		t.Fatal("rejected click was applied")
+	}
+
+	// Deferred at once; decided and edited into the message after
+	rec := interaction(click, priv, time.Now())
+	var resp struct {
+		Type int `json:"type"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Type != discordResponseDeferredUpdate {
+		t.Fatalf("approve: %d %s, want a deferred update", rec.Code, rec.Body)
+	}
+	select {
+	case content := <-edits:
+		if !strings.Contains(content, "approved") {
+			t.Errorf("edited message = %q", content)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not edited")
+	}
+	if dec.approved != "elev-1" || dec.actor != "discord:alice" {
+		t.Errorf("decider = %+v", dec)
+	}
+}
+
+func TestDiscord_NotifyWebhook(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d, err := NewDiscord(DiscordConfig{WebhookURL: srv.URL + "/api/webhooks/1/tok"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Event{Type: EventElevationRequested, Service: "github", Scope: "write", ElevationID: "elev-1"}
+	if err := d.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if got["content"] != Summary(e) || got["components"] != nil {
+		t.Errorf("unexpected webhook message: %v", got)
+	}
+}
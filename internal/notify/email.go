@@ -0,0 +1,255 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// EmailSettingKey is the settings key holding EmailSettings.
+const EmailSettingKey = "notify.email"
+
+// EmailConfig is the SMTP connection for the email notifier.
+type EmailConfig struct {
+	Addr     string // host:port
+	Username string // Optional; enables PLAIN auth
+	Password string
+	From     string   // RFC 5322 address, e.g. "OCM <ocm@example.com>"
+	To       []string // Default recipients when settings don't name any
+}
+
+// Validate checks the sender and default recipients are RFC 5322 addresses.
+func (c EmailConfig) Validate() error {
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid sender %q: %w", c.From, err)
+	}
+	_, err := parseRecipients(c.To)
+	return err
+}
+
+// parseRecipients parses each of to as an RFC 5322 address, with or
+// without a display name. An address that doesn't parse, including one
+// smuggling in another header, is an error.
+func parseRecipients(to []string) ([]*mail.Address, error) {
+	addrs := make([]*mail.Address, 0, len(to))
+	for _, addr := range to {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", addr, err)
+		}
+		addrs = append(addrs, a)
+	}
+	return addrs, nil
+}
+
+// EmailSettings are the runtime-editable email preferences.
+type EmailSettings struct {
+	To     []string                 `json:"to,omitempty"`
+	Events map[EventType]EmailEvent `json:"events"`
+}
+
+// EmailEvent toggles one event type and sets its subject template.
+// Templates see the Event, e.g., "[OCM] {{.Service}} elevated by {{.Actor}}".
+type EmailEvent struct {
+	Enabled bool   `json:"enabled"`
+	Subject string `json:"subject,omitempty"`
+}
+
+// defaultEmailSubjects lists the events sent by email and their default subjects.
+var defaultEmailSubjects = map[EventType]string{
+	EventElevationRequested: "[OCM] Elevation requested: {{.Service}} ({{.Scope}})",
+	EventElevationReminder:  "[OCM] Reminder: elevation pending: {{.Service}} ({{.Scope}})",
+	EventElevationApproved:  "[OCM] Elevation approved: {{.Service}} ({{.Scope}}) by {{.Actor}}",
+	EventElevationDenied:    "[OCM] Elevation denied: {{.Service}} ({{.Scope}}) by {{.Actor}}",
+	EventElevationExpired:   "[OCM] Elevation expired: {{.Service}} ({{.Scope}})",
+	EventCredentialExpiring: "[OCM] Credential expiring: {{.Service}} ({{.Scope}})",
+	EventReportDigest:       "[OCM] Activity digest {{.Time.Format \"2006-01-02\"}}",
+	EventReportStale:        "[OCM] Stale credentials {{.Time.Format \"2006-01-02\"}}",
+}
+
+// routedEmailSubject is used for routed events with no subject configured.
+const routedEmailSubject = "[OCM] {{.Type}} {{.Service}}"
+
+// DefaultEmailSettings enables every supported event with its default subject.
+func DefaultEmailSettings() *EmailSettings {
+	settings := &EmailSettings{Events: make(map[EventType]EmailEvent, len(defaultEmailSubjects))}
+	for t, subject := range defaultEmailSubjects {
+		settings.Events[t] = EmailEvent{Enabled: true, Subject: subject}
+	}
+	return settings
+}
+
+// Validate checks event names, subject templates and recipients, filling
+// in default subjects.
+func (s *EmailSettings) Validate() error {
+	for t, ev := range s.Events {
+		def, ok := defaultEmailSubjects[t]
+		if !ok {
+			return fmt.Errorf("unsupported email event %q", t)
+		}
+		if ev.Subject == "" {
+			ev.Subject = def
+			s.Events[t] = ev
+		}
+		if _, err := template.New("subject").Parse(ev.Subject); err != nil {
+			return fmt.Errorf("invalid subject for %s: %w", t, err)
+		}
+	}
+	_, err := parseRecipients(s.To)
+	return err
+}
+
+// LoadEmailSettings returns the stored email settings, or the defaults.
+func LoadEmailSettings(s *store.Store) (*EmailSettings, error) {
+	settings := DefaultEmailSettings()
+	var stored EmailSettings
+	found, err := s.GetSetting(EmailSettingKey, &stored)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		settings.To = stored.To
+		for t, ev := range stored.Events {
+			settings.Events[t] = ev
+		}
+	}
+	return settings, nil
+}
+
+// Email sends lifecycle events over SMTP.
+type Email struct {
+	cfg    EmailConfig
+	store  *store.Store
+	logger *slog.Logger
+
+	// send is smtp.SendMail, swappable in tests
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an email notifier. Per-event settings are read from the
+// store on every event so admin changes apply immediately.
+func NewEmail(cfg EmailConfig, s *store.Store, logger *slog.Logger) *Email {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Email{cfg: cfg, store: s, logger: logger, send: smtp.SendMail}
+}
+
+// Name implements Notifier.
+func (m *Email) Name() string { return "email" }
+
+// Notify implements Notifier.
+func (m *Email) Notify(ctx context.Context, e Event) error {
+	settings, err := LoadEmailSettings(m.store)
+	if err != nil {
+		return fmt.Errorf("load email settings: %w", err)
+	}
+	ev, ok := settings.Events[e.Type]
+	if e.routed {
+		// A routing rule overrides the per-event toggles
+		ev.Enabled = true
+		if ev.Subject == "" {
+			ev.Subject = routedEmailSubject
+		}
+	} else if !ok || !ev.Enabled {
+		return nil
+	}
+	to := settings.To
+	if len(to) == 0 {
+		to = m.cfg.To
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	// Settings stored before recipients were validated may not parse
+	recipients, err := parseRecipients(to)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", m.cfg.From, err)
+	}
+	subject, err := renderSubject(ev.Subject, e)
+	if err != nil {
+		return err
+	}
+	msg := m.message(from, recipients, subject, e)
+	envelope := make([]string, len(recipients))
+	for i, a := range recipients {
+		envelope[i] = a.Address
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(m.cfg.Addr)
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)
+	}
+
+	// net/smtp has no context support; run it so ctx still bounds the wait
+	done := make(chan error, 1)
+	go func() { done <- m.send(m.cfg.Addr, auth, from.Address, envelope, msg) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func renderSubject(tmpl string, e Event) (string, error) {
+	t, err := template.New("subject").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return "", fmt.Errorf("render subject: %w", err)
+	}
+	// Keep header injection out of the subject line
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(buf.String()), nil
+}
+
+func (m *Email) message(from *mail.Address, to []*mail.Address, subject string, e Event) []byte {
+	var body strings.Builder
+	body.WriteString(strings.ReplaceAll(Summary(e), "\n", "\r\n") + "\r\n\r\n")
+	for _, kv := range [][2]string{
+		{"Event", string(e.Type)},
+		{"Service", e.Service},
+		{"Scope", e.Scope},
+		{"Request", e.ElevationID},
+		{"Reason", e.Reason},
+		{"Actor", e.Actor},
+	} {
+		if kv[1] != "" {
+			body.WriteString(kv[0] + ": " + kv[1] + "\r\n")
+		}
+	}
+	if e.ExpiresAt != nil {
+		body.WriteString("Expires: " + e.ExpiresAt.Format(time.RFC1123Z) + "\r\n")
+	}
+
+	var msg bytes.Buffer
+	header := make([]string, len(to))
+	for i, a := range to {
+		header[i] = a.String()
+	}
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(header, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(body.String())
+	return msg.Bytes()
+}
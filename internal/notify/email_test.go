@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	db, err := store.New(filepath.Join(t.TempDir(), "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestEmail_SettingsAndTemplates(t *testing.T) {
+	db := newTestStore(t)
+
+	settings := &EmailSettings{Events: map[EventType]EmailEvent{
+		EventElevationRequested: {Enabled: true, Subject: "OCM: {{.Service}} wants {{.Scope}}"},
+		EventElevationApproved:  {Enabled: false},
+	}}
+	if err := settings.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutSetting(EmailSettingKey, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	var envelope []string
+	m := NewEmail(EmailConfig{Addr: "smtp.example.com:587", From: "OCM <ocm@example.com>", To: []string{"Ops Team <ops@example.com>"}}, db, nil)
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, string(msg))
+		envelope = append([]string{from}, to...)
+		return nil
+	}
+
+	ctx := context.Background()
+	m.Notify(ctx, Event{Type: EventElevationRequested, Service: "github", Scope: "write", Reason: "release", Time: time.Now()})
+	m.Notify(ctx, Event{Type: EventElevationApproved, Service: "github", Scope: "write", Actor: "admin", Time: time.Now()})
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1 (approved is disabled)", len(sent))
+	}
+	if !strings.Contains(sent[0], "Subject: OCM: github wants write\r\n") {
+		t.Errorf("email missing rendered subject:\n%s", sent[0])
+	}
+	if !strings.Contains(sent[0], `To: "Ops Team" <ops@example.com>`) || !strings.Contains(sent[0], "Reason: release") {
+		t.Errorf("email missing recipient or reason:\n%s", sent[0])
+	}
+	// Display names stay in the headers, out of the SMTP envelope
+	if strings.Join(envelope, " ") != "ocm@example.com ops@example.com" {
+		t.Errorf("envelope = %v", envelope)
+	}
+
+	if err := (&EmailSettings{Events: map[EventType]EmailEvent{"device.paired": {Enabled: true}}}).Validate(); err == nil {
+		t.Error("Validate() accepted an unsupported event")
+	}
+	for _, addr := range []string{"ops", "ops@example.com\r\nBcc: eve@example.com", "a@example.com, b@example.com"} {
+		if err := (&EmailSettings{To: []string{addr}}).Validate(); err == nil {
+			t.Errorf("Validate() accepted recipient %q", addr)
+		}
+	}
+}
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Notify(ctx context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestExpiryWatcher_NotifiesOnce(t *testing.T) {
+	db := newTestStore(t)
+
+	now := time.Now()
+	soon, later := now.Add(time.Hour), now.Add(30*24*time.Hour)
+	if err := db.SaveCredential(&store.Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub", Type: "pat",
+		Read:      &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "t", ExpiresAt: &soon},
+		ReadWrite: &store.AccessLevel{EnvVar: "GITHUB_WRITE_TOKEN", Token: "t", ExpiresAt: &later},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recordingNotifier{}
+	d := NewDispatcher(nil)
+	d.Register(rec)
+	w := NewExpiryWatcher(db, d, 72*time.Hour, d.logger)
+
+	w.check(now)
+	w.check(now.Add(time.Minute))
+	d.Wait()
+
+	if len(rec.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(rec.events))
+	}
+	if e := rec.events[0]; e.Type != EventCredentialExpiring || e.Service != "github" || e.Scope != "read" {
+		t.Errorf("event = %+v, want credential.expiring for github read", e)
+	}
+}
+
+func TestExpiring(t *testing.T) {
+	now := time.Now()
+	expired, soon, later := now.Add(-time.Hour), now.Add(24*time.Hour), now.Add(30*24*time.Hour)
+	creds := []*store.Credential{
+		{Service: "github", Read: &store.AccessLevel{ExpiresAt: &soon}, ReadWrite: &store.AccessLevel{ExpiresAt: &later}},
+		{Service: "slack", Read: &store.AccessLevel{ExpiresAt: &expired}},
+		{Service: "notion", Read: &store.AccessLevel{}},
+	}
+
+	got := Expiring(creds, now, 72*time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("got %+v, want slack then github", got)
+	}
+	if got[0].Service != "slack" || !got[0].Expired || got[1].Service != "github" || got[1].Level != "read" || got[1].Expired {
+		t.Errorf("got %+v, want expired slack read then github read", got)
+	}
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+const expiryCheckInterval = time.Hour
+
+// ExpiringToken is a credential's access level whose token expires within
+// the warning window, or already has.
+type ExpiringToken struct {
+	Service   string    `json:"service"`
+	Level     string    `json:"level"` // read or write
+	ExpiresAt time.Time `json:"expiresAt"`
+	Expired   bool      `json:"expired"`
+}
+
+// Expiring lists the tokens in creds that expire within `within` of now,
+// including those already expired, soonest first.
+func Expiring(creds []*store.Credential, now time.Time, within time.Duration) []ExpiringToken {
+	tokens := []ExpiringToken{}
+	for _, cred := range creds {
+		for _, level := range []struct {
+			name   string
+			access *store.AccessLevel
+		}{{"read", cred.Read}, {"write", cred.ReadWrite}} {
+			if level.access == nil || level.access.ExpiresAt == nil {
+				continue
+			}
+			expiresAt := *level.access.ExpiresAt
+			if expiresAt.Sub(now) > within {
+				continue
+			}
+			tokens = append(tokens, ExpiringToken{
+				Service:   cred.Service,
+				Level:     level.name,
+				ExpiresAt: expiresAt,
+				Expired:   !now.Before(expiresAt),
+			})
+		}
+	}
+	sort.SliceStable(tokens, func(i, j int) bool { return tokens[i].ExpiresAt.Before(tokens[j].ExpiresAt) })
+	return tokens
+}
+
+// ExpiryWatcher publishes credential.expiring once per token when a stored
+// credential's expiresAt falls within the warning window.
+type ExpiryWatcher struct {
+	store      *store.Store
+	dispatcher *Dispatcher
+	within     time.Duration
+	logger     *slog.Logger
+
+	// notified remembers "service:level:expiresAt" already announced.
+	// In-memory, so a restart may repeat a warning.
+	notified map[string]bool
+}
+
+// NewExpiryWatcher creates a watcher that warns `within` ahead of expiry.
+func NewExpiryWatcher(s *store.Store, d *Dispatcher, within time.Duration, logger *slog.Logger) *ExpiryWatcher {
+	return &ExpiryWatcher{store: s, dispatcher: d, within: within, logger: logger, notified: make(map[string]bool)}
+}
+
+// Run checks hourly until ctx is done.
+func (w *ExpiryWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ExpiryWatcher) check(now time.Time) {
+	creds, err := w.store.ListCredentials()
+	if err != nil {
+		w.logger.Error("failed to list credentials for expiry check", "error", err)
+		return
+	}
+
+	for _, token := range Expiring(creds, now, w.within) {
+		key := token.Service + ":" + token.Level + ":" + token.ExpiresAt.UTC().Format(time.RFC3339)
+		if w.notified[key] {
+			continue
+		}
+		w.notified[key] = true
+		w.logger.Warn("credential token expiring", "service", token.Service, "level", token.Level,
+			"expires_at", token.ExpiresAt, "expired", token.Expired)
+		expiresAt := token.ExpiresAt
+		w.dispatcher.Publish(Event{
+			Type:      EventCredentialExpiring,
+			Service:   token.Service,
+			Scope:     token.Level,
+			Actor:     "system",
+			ExpiresAt: &expiresAt,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import "time"
+
+// NotifierHealth summarizes a notifier's deliveries since OCM started,
+// including test notifications.
+type NotifierHealth struct {
+	Name                string     `json:"name"`
+	Delivered           int        `json:"delivered"`
+	Failed              int        `json:"failed"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"` // Since the last success
+	LastDeliveredAt     *time.Time `json:"lastDeliveredAt,omitempty"`
+	LastFailedAt        *time.Time `json:"lastFailedAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// record counts a delivery to the notifier named name.
+func (d *Dispatcher) record(name string, err error) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.health == nil {
+		d.health = make(map[string]*NotifierHealth)
+	}
+	h, ok := d.health[name]
+	if !ok {
+		h = &NotifierHealth{Name: name}
+		d.health[name] = h
+	}
+	if err != nil {
+		h.Failed++
+		h.ConsecutiveFailures++
+		h.LastFailedAt, h.LastError = &now, err.Error()
+		return
+	}
+	h.Delivered++
+	h.ConsecutiveFailures = 0
+	h.LastDeliveredAt = &now
+}
+
+// Health returns each registered notifier's delivery health, in
+// registration order. A notifier that hasn't been sent anything yet has
+// zero counts.
+func (d *Dispatcher) Health() []NotifierHealth {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]NotifierHealth, 0, len(d.notifiers))
+	for _, n := range d.notifiers {
+		h := NotifierHealth{Name: n.Name()}
+		if recorded, ok := d.health[n.Name()]; ok {
+			h = *recorded
+		}
+		out = append(out, h)
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// failingNotifier fails every delivery.
+type failingNotifier struct{}
+
+func (failingNotifier) Name() string                              { return "failing" }
+func (failingNotifier) Notify(ctx context.Context, e Event) error { return errors.New("channel down") }
+
+func TestDispatcher_Health(t *testing.T) {
+	d := NewDispatcher(nil)
+	d.Register(&recordingNotifier{})
+	d.Register(failingNotifier{})
+
+	d.Publish(Event{Type: EventCredentialDeleted, Service: "github"})
+	d.Wait()
+	if _, err := d.Test(context.Background(), Event{Type: EventCredentialDeleted, Service: "github"}, "failing"); err != nil {
+		t.Fatal(err)
+	}
+	d.Register(&namedNotifier{name: "idle"}) // Registered after the deliveries
+
+	health := d.Health()
+	if len(health) != 3 {
+		t.Fatalf("health = %+v, want 3 notifiers", health)
+	}
+	if h := health[0]; h.Name != "recording" || h.Delivered != 1 || h.Failed != 0 || h.LastDeliveredAt == nil {
+		t.Errorf("recording = %+v", h)
+	}
+	if h := health[1]; h.Name != "failing" || h.Failed != 2 || h.ConsecutiveFailures != 2 || h.LastError != "channel down" {
+		t.Errorf("failing = %+v", h)
+	}
+	if h := health[2]; h.Name != "idle" || h.Delivered != 0 || h.LastDeliveredAt != nil {
+		t.Errorf("idle = %+v", h)
+	}
+}
@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// Severity is an incident severity, using PagerDuty's names.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityError    Severity = "error"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// incidentSeverity lists the events that open an incident and how severe
+// they are. Anything else never pages anyone.
+var incidentSeverity = map[EventType]Severity{
+	EventStoreDecryptFailed:   SeverityCritical,
+	EventGatewayRestartFailed: SeverityError,
+	EventAuditAnomaly:         SeverityWarning,
+}
+
+// incidentFor returns the severity of the incident e should open, if any.
+// Routed events always open one, at the route's severity or "warning".
+func incidentFor(e Event) (Severity, bool) {
+	severity, ok := incidentSeverity[e.Type]
+	if e.Severity != "" {
+		severity = e.Severity
+	}
+	if e.routed && severity == "" {
+		severity = SeverityWarning
+	}
+	return severity, ok || e.routed
+}
+
+// dedupKey groups repeats of the same problem into one open incident.
+func dedupKey(e Event) string {
+	key := "ocm:" + string(e.Type)
+	if e.Service != "" {
+		key += ":" + e.Service
+	}
+	return key
+}
+
+// PagerDuty opens incidents through the PagerDuty Events API v2.
+type PagerDuty struct {
+	routingKey string
+	apiURL     string
+	client     *http.Client
+}
+
+// NewPagerDuty creates a PagerDuty notifier for an Events API v2 integration.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{routingKey: routingKey, apiURL: pagerDutyEventsURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (p *PagerDuty) Name() string { return "pagerduty" }
+
+// Notify implements Notifier.
+func (p *PagerDuty) Notify(ctx context.Context, e Event) error {
+	severity, ok := incidentFor(e)
+	if !ok {
+		return nil
+	}
+	return postIncident(ctx, p.client, p.apiURL, "", "pagerduty", map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    dedupKey(e),
+		"payload": map[string]interface{}{
+			"summary":        Summary(e),
+			"source":         "ocm",
+			"severity":       severity,
+			"timestamp":      e.Time.Format(time.RFC3339),
+			"component":      e.Service,
+			"class":          string(e.Type),
+			"custom_details": e,
+		},
+	})
+}
+
+// opsgeniePriority maps severities to Opsgenie priorities.
+var opsgeniePriority = map[Severity]string{
+	SeverityCritical: "P1",
+	SeverityError:    "P2",
+	SeverityWarning:  "P3",
+	SeverityInfo:     "P5",
+}
+
+// Opsgenie opens alerts through the Opsgenie Alert API.
+type Opsgenie struct {
+	apiKey string
+	apiURL string
+	client *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie notifier. apiURL may be empty for the
+// default (US) instance.
+func NewOpsgenie(apiKey, apiURL string) *Opsgenie {
+	if apiURL == "" {
+		apiURL = opsgenieAlertsURL
+	}
+	return &Opsgenie{apiKey: apiKey, apiURL: apiURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (o *Opsgenie) Name() string { return "opsgenie" }
+
+// Notify implements Notifier.
+func (o *Opsgenie) Notify(ctx context.Context, e Event) error {
+	severity, ok := incidentFor(e)
+	if !ok {
+		return nil
+	}
+	message := Summary(e)
+	if len(message) > 130 { // Opsgenie's message limit
+		message = message[:130]
+	}
+	return postIncident(ctx, o.client, o.apiURL, "GenieKey "+o.apiKey, "opsgenie", map[string]interface{}{
+		"message":     message,
+		"alias":       dedupKey(e),
+		"description": Summary(e),
+		"source":      "ocm",
+		"priority":    opsgeniePriority[severity],
+		"tags":        []string{"ocm", string(e.Type)},
+	})
+}
+
+func postIncident(ctx context.Context, client *http.Client, url, auth, service string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: status %d", service, resp.StatusCode)
+	}
+	return nil
+}
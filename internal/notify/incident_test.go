@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIncident_SeverityMapping(t *testing.T) {
+	var got []map[string]interface{}
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	pd := NewPagerDuty("rk_123")
+	pd.apiURL = srv.URL
+	og := NewOpsgenie("og_key", srv.URL)
+
+	ctx := context.Background()
+	decrypt := Event{Type: EventStoreDecryptFailed, Time: time.Now(), Details: "cipher: message authentication failed"}
+	for _, n := range []Notifier{pd, og} {
+		if err := n.Notify(ctx, Event{Type: EventElevationApproved, Service: "github"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Notify(ctx, decrypt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected one incident per notifier, got %d", len(got))
+	}
+	payload, _ := got[0]["payload"].(map[string]interface{})
+	if got[0]["routing_key"] != "rk_123" || payload["severity"] != "critical" || got[0]["dedup_key"] != "ocm:store.decrypt_failed" {
+		t.Errorf("unexpected pagerduty event: %v", got[0])
+	}
+	if got[1]["priority"] != "P1" || got[1]["alias"] != "ocm:store.decrypt_failed" || auth[1] != "GenieKey og_key" {
+		t.Errorf("unexpected opsgenie alert: %v (auth %q)", got[1], auth[1])
+	}
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMatrixEvents are posted when MatrixConfig.Events is empty: the
+// elevation lifecycle plus audited admin changes.
+var DefaultMatrixEvents = []string{"elevation.*", "credential.*", "device.*"}
+
+// MatrixConfig configures the Matrix notifier.
+type MatrixConfig struct {
+	Homeserver  string   // e.g., https://matrix.example.org
+	AccessToken string   // Access token of the bot account
+	RoomID      string   // e.g., !abc123:example.org
+	Events      []string // Event filters, e.g., "elevation.*"
+}
+
+// Matrix posts events to a Matrix room as m.notice messages.
+type Matrix struct {
+	cfg    MatrixConfig
+	client *http.Client
+}
+
+// NewMatrix creates a Matrix notifier.
+func NewMatrix(cfg MatrixConfig) *Matrix {
+	cfg.Homeserver = strings.TrimSuffix(cfg.Homeserver, "/")
+	if len(cfg.Events) == 0 {
+		cfg.Events = DefaultMatrixEvents
+	}
+	return &Matrix{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (m *Matrix) Name() string { return "matrix" }
+
+// Notify implements Notifier.
+func (m *Matrix) Notify(ctx context.Context, e Event) error {
+	if !wants(e, m.cfg.Events...) {
+		return nil
+	}
+	body, err := json.Marshal(map[string]string{
+		"msgtype": "m.notice", // Bots send notices so other bots don't reply
+		"body":    Summary(e),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The transaction ID makes the send idempotent if a retry reaches the server twice
+	txn := make([]byte, 8)
+	rand.Read(txn)
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.cfg.Homeserver, url.PathEscape(m.cfg.RoomID), hex.EncodeToString(txn))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.cfg.AccessToken)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("matrix: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var merr struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&merr)
+		return fmt.Errorf("matrix: status %d %s %s", resp.StatusCode, merr.ErrCode, merr.Error)
+	}
+	return nil
+}
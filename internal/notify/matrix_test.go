@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatrix_Notify(t *testing.T) {
+	var paths []string
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer syt_tok" {
+			t.Errorf("unexpected request %s auth=%q", r.Method, r.Header.Get("Authorization"))
+		}
+		paths = append(paths, r.URL.EscapedPath())
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"event_id":"$1"}`))
+	}))
+	defer srv.Close()
+
+	m := NewMatrix(MatrixConfig{Homeserver: srv.URL + "/", AccessToken: "syt_tok", RoomID: "!ops:example.org"})
+	ctx := context.Background()
+	deleted := Event{Type: EventCredentialDeleted, Service: "github", Actor: "admin"}
+	if err := m.Notify(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	// Not in the default filters
+	if err := m.Notify(ctx, Event{Type: EventGatewayRestartFailed}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) != 1 || !strings.HasPrefix(paths[0], "/_matrix/client/v3/rooms/%21ops:example.org/send/m.room.message/") {
+		t.Fatalf("unexpected requests: %v", paths)
+	}
+	if body["msgtype"] != "m.notice" || body["body"] != Summary(deleted) {
+		t.Errorf("unexpected message: %v", body)
+	}
+}
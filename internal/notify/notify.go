@@ -0,0 +1,389 @@
+// Package notify delivers elevation lifecycle events to chat, email and
+// other out-of-band channels so approvers don't have to watch the admin UI.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// EventType names an event as "<domain>.<verb>", e.g., "elevation.requested".
+type EventType string
+
+const (
+	EventElevationRequested EventType = "elevation.requested"
+	EventElevationApproved  EventType = "elevation.approved"
+	EventElevationDenied    EventType = "elevation.denied"
+	EventElevationExpired   EventType = "elevation.expired"
+	EventElevationRevoked   EventType = "elevation.revoked"
+	EventElevationReminder  EventType = "elevation.reminder"
+
+	EventCredentialCreated   EventType = "credential.created"
+	EventCredentialUpdated   EventType = "credential.updated"
+	EventCredentialDeleted   EventType = "credential.deleted"
+	EventCredentialExpiring  EventType = "credential.expiring"
+	EventCredentialNotLoaded EventType = "credential.not_loaded"
+	EventCredentialRotated   EventType = "credential.rotated"
+	EventRotationFailed      EventType = "credential.rotation_failed"
+	EventCredentialInvalid   EventType = "credential.invalid"
+	EventCredentialLeaked    EventType = "credential.leaked"
+
+	EventDeviceRequested EventType = "device.requested"
+	EventDeviceApproved  EventType = "device.approved"
+	EventDeviceRejected  EventType = "device.rejected"
+
+	EventGatewayStatus        EventType = "gateway.status"
+	EventGatewayRestartFailed EventType = "gateway.restart_failed"
+	EventStoreDecryptFailed   EventType = "store.decrypt_failed"
+
+	EventReportDigest EventType = "report.digest"
+	EventReportStale  EventType = "report.stale"
+
+	EventAuditAnomaly EventType = "audit.anomaly"
+)
+
+// eventTypes lists every event type, for validating subscription filters.
+var eventTypes = []EventType{
+	EventElevationRequested, EventElevationApproved, EventElevationDenied, EventElevationExpired, EventElevationRevoked,
+	EventElevationReminder,
+	EventCredentialCreated, EventCredentialUpdated, EventCredentialDeleted, EventCredentialExpiring, EventCredentialNotLoaded,
+	EventCredentialRotated, EventRotationFailed, EventCredentialInvalid, EventCredentialLeaked,
+	EventDeviceRequested, EventDeviceApproved, EventDeviceRejected,
+	EventGatewayStatus, EventGatewayRestartFailed, EventStoreDecryptFailed,
+	EventReportDigest, EventReportStale,
+	EventAuditAnomaly,
+}
+
+// ValidFilter reports whether f is "*", "<domain>.*" for a known domain, or a known event type.
+func ValidFilter(f string) bool {
+	if f == "*" {
+		return true
+	}
+	for _, t := range eventTypes {
+		if f == string(t) || (strings.HasSuffix(f, ".*") && strings.HasPrefix(string(t), strings.TrimSuffix(f, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryTimeout bounds a single notifier delivery.
+const deliveryTimeout = 15 * time.Second
+
+// Event is a single notification.
+type Event struct {
+	Type        EventType   `json:"type"`
+	Time        time.Time   `json:"time"`
+	Service     string      `json:"service,omitempty"`
+	Scope       string      `json:"scope,omitempty"`
+	ElevationID string      `json:"elevationId,omitempty"`
+	Reason      string      `json:"reason,omitempty"` // Requester's reason, or the denial/revocation reason
+	Actor       string      `json:"actor,omitempty"`
+	ExpiresAt   *time.Time  `json:"expiresAt,omitempty"` // Elevation expiry, or token expiry for credential.expiring
+	Details     string      `json:"details,omitempty"`   // Anything else, e.g., the device pairing request ID
+	Report      interface{} `json:"report,omitempty"`    // Structured body of report events, e.g., *DigestReport, *StaleReport
+
+	// Set by routing rules (see RoutingConfig)
+	Message  string   `json:"message,omitempty"`  // Rendered message body, replacing Summary
+	Severity Severity `json:"severity,omitempty"` // Route severity, or the urgency of reminders
+	routed   bool     // A rule sent e to this notifier, overriding its default filters
+}
+
+// Matches reports whether the event matches a filter such as "elevation.*",
+// "elevation.requested" or "*".
+func (e Event) Matches(filter string) bool {
+	if filter == "*" || filter == string(e.Type) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(filter, ".*"); ok {
+		return strings.HasPrefix(string(e.Type), prefix+".")
+	}
+	return false
+}
+
+// chatEvents are posted by chat notifiers (Slack, Telegram, Discord) when
+// no routing rule says otherwise.
+var chatEvents = []string{"elevation.*", string(EventCredentialExpiring), string(EventRotationFailed), string(EventCredentialInvalid), string(EventCredentialLeaked), string(EventDeviceRequested), string(EventAuditAnomaly)}
+
+// matchesAny reports whether e matches any of filters.
+func matchesAny(e Event, filters []string) bool {
+	for _, f := range filters {
+		if e.Matches(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// wants reports whether a notifier with the given default filters should
+// deliver e. Routed events are always delivered.
+func wants(e Event, defaults ...string) bool {
+	return e.routed || matchesAny(e, defaults)
+}
+
+// awaitsDecision reports whether e asks approvers to act, so interactive
+// channels attach approve/deny controls.
+func awaitsDecision(e Event) bool {
+	return e.Type == EventElevationRequested || e.Type == EventElevationReminder
+}
+
+// Summary is a one-line, human-readable description of e shared by the
+// notifiers. A routing template's rendered message takes precedence.
+func Summary(e Event) string {
+	if e.Message != "" {
+		return e.Message
+	}
+	target := e.Service
+	if e.Scope != "" {
+		target += " (" + e.Scope + ")"
+	}
+	switch e.Type {
+	case EventElevationRequested:
+		s := fmt.Sprintf("Elevation requested for %s", target)
+		if e.Reason != "" {
+			s += ": " + e.Reason
+		}
+		return s
+	case EventElevationReminder:
+		s := fmt.Sprintf("Reminder: elevation for %s is still pending (%s)", target, e.Details)
+		if e.Reason != "" {
+			s += ": " + e.Reason
+		}
+		return s
+	case EventElevationApproved:
+		s := fmt.Sprintf("Elevation for %s approved by %s", target, e.Actor)
+		if e.ExpiresAt != nil {
+			s += fmt.Sprintf(", expires %s", e.ExpiresAt.Format(time.RFC3339))
+		}
+		return s
+	case EventElevationDenied:
+		s := fmt.Sprintf("Elevation for %s denied by %s", target, e.Actor)
+		if e.Reason != "" {
+			s += ": " + e.Reason
+		}
+		return s
+	case EventElevationExpired:
+		return fmt.Sprintf("Elevation for %s expired", target)
+	case EventElevationRevoked:
+		return fmt.Sprintf("Elevation for %s revoked by %s", target, e.Actor)
+	case EventCredentialCreated, EventCredentialUpdated, EventCredentialDeleted, EventCredentialRotated:
+		return fmt.Sprintf("Credential %s %s by %s", e.Service, strings.TrimPrefix(string(e.Type), "credential."), e.Actor)
+	case EventRotationFailed:
+		return fmt.Sprintf("Rotating credential %s failed: %s", target, e.Details)
+	case EventDeviceRequested:
+		return "Device pairing requested: " + e.Details
+	case EventDeviceApproved, EventDeviceRejected:
+		return fmt.Sprintf("Device pairing %s %s by %s", e.Details, strings.TrimPrefix(string(e.Type), "device."), e.Actor)
+	case EventReportDigest, EventReportStale:
+		return e.Details
+	case EventAuditAnomaly:
+		return "Anomaly: " + e.Details
+	case EventGatewayStatus:
+		return "Gateway " + e.Details
+	case EventGatewayRestartFailed:
+		return "Gateway restart failed: " + e.Details
+	case EventStoreDecryptFailed:
+		return "Stored data failed to decrypt (wrong master key or tampering): " + e.Details
+	case EventCredentialInvalid:
+		return fmt.Sprintf("Credential %s was rejected by its provider (%s)", target, e.Details)
+	case EventCredentialLeaked:
+		return fmt.Sprintf("Credential %s leaked: %s", target, e.Details)
+	case EventCredentialNotLoaded:
+		return fmt.Sprintf("Credential %s was injected but the Gateway didn't load it (%s)", target, e.Details)
+	case EventCredentialExpiring:
+		if e.ExpiresAt != nil {
+			return fmt.Sprintf("Credential %s expires %s", target, e.ExpiresAt.Format(time.RFC3339))
+		}
+		return fmt.Sprintf("Credential %s is expiring", target)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, target)
+}
+
+// Notifier delivers events to one channel. Implementations decide which
+// event types they care about and ignore the rest.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, e Event) error
+}
+
+// Decider applies approve/deny decisions coming back from interactive
+// channels. It is satisfied by *elevation.Service.
+type Decider interface {
+	ApproveElevation(elevationID string, ttl time.Duration, approvedBy string) error
+	DenyElevation(elevationID, deniedBy, reason string) error
+}
+
+// Dispatcher fans events out to registered notifiers. Delivery is
+// asynchronous so a slow channel never holds up an approval. A nil
+// *Dispatcher is valid and drops everything.
+type Dispatcher struct {
+	logger *slog.Logger
+	store  *store.Store // Routing config source; nil means no routing
+
+	mu          sync.RWMutex
+	notifiers   []Notifier
+	callbacks   *AgentCallbacks
+	subscribers map[chan Event]struct{}
+	health      map[string]*NotifierHealth // By notifier name
+	wg          sync.WaitGroup
+}
+
+// NewDispatcher creates an empty dispatcher.
+func NewDispatcher(logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Dispatcher{logger: logger}
+}
+
+// UseRouting applies the RoutingConfig stored in s to every event.
+func (d *Dispatcher) UseRouting(s *store.Store) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.store = s
+}
+
+// UseAgentCallbacks registers c and makes it available to the agent API,
+// which checks requested callback URLs against its allowlist.
+func (d *Dispatcher) UseAgentCallbacks(c *AgentCallbacks) {
+	d.Register(c)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.callbacks = c
+}
+
+// AgentCallbacks returns the notifier set by UseAgentCallbacks, or nil if
+// agent callbacks aren't enabled.
+func (d *Dispatcher) AgentCallbacks() *AgentCallbacks {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.callbacks
+}
+
+// Names lists the registered notifiers.
+func (d *Dispatcher) Names() []string {
+	if d == nil {
+		return nil
+	}
+	names := []string{}
+	for _, n := range d.snapshot() {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+func (d *Dispatcher) snapshot() []Notifier {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Notifier(nil), d.notifiers...)
+}
+
+func (d *Dispatcher) loadRouting() (*RoutingConfig, error) {
+	d.mu.RLock()
+	s := d.store
+	d.mu.RUnlock()
+	if s == nil {
+		return nil, nil
+	}
+	return LoadRouting(s)
+}
+
+// Subscribe returns a channel that receives every published event, before
+// routing, until cancel is called. A subscriber that falls behind misses
+// events rather than holding up publishers.
+func (d *Dispatcher) Subscribe() (events <-chan Event, cancel func()) {
+	ch := make(chan Event, 32)
+	d.mu.Lock()
+	if d.subscribers == nil {
+		d.subscribers = make(map[chan Event]struct{})
+	}
+	d.subscribers[ch] = struct{}{}
+	d.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			d.mu.Lock()
+			delete(d.subscribers, ch)
+			d.mu.Unlock()
+		})
+	}
+}
+
+// Register adds a notifier.
+func (d *Dispatcher) Register(n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers = append(d.notifiers, n)
+}
+
+// Publish delivers e to every notifier in the background.
+func (d *Dispatcher) Publish(e Event) {
+	if d == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	d.mu.RLock()
+	for ch := range d.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	d.mu.RUnlock()
+
+	// Routing is read from the store off the caller's goroutine: Publish is
+	// called with store locks held, e.g. on a decrypt failure
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.deliver(e)
+	}()
+}
+
+// deliver routes e to each notifier and delivers it in the background.
+func (d *Dispatcher) deliver(e Event) {
+	routing, err := d.loadRouting()
+	if err != nil {
+		// Fall back to each notifier's defaults rather than dropping the event
+		d.logger.Error("failed to load notification routing", "error", err)
+	}
+
+	for _, n := range d.snapshot() {
+		routed, ok := routing.route(e, n)
+		if !ok {
+			continue
+		}
+		d.wg.Add(1)
+		go func(n Notifier, e Event) {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+			defer cancel()
+			err := n.Notify(ctx, e)
+			d.record(n.Name(), err)
+			if err != nil {
+				d.logger.Warn("notification failed", "notifier", n.Name(), "event", e.Type, "error", err)
+			}
+		}(n, routed)
+	}
+}
+
+// Wait blocks until in-flight deliveries finish (used on shutdown and in tests).
+func (d *Dispatcher) Wait() {
+	if d == nil {
+		return
+	}
+	d.wg.Wait()
+}
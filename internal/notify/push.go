@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+// pushEvents are the events worth a phone notification: someone is waiting
+// on a human decision.
+var pushEvents = []string{string(EventElevationRequested), string(EventElevationReminder), string(EventDeviceRequested)}
+
+// NtfyConfig configures the ntfy notifier.
+type NtfyConfig struct {
+	TopicURL string // Full topic URL, e.g., https://ntfy.sh/my-ocm-alerts
+	Token    string // Optional access token for protected topics
+}
+
+// Ntfy publishes push notifications to an ntfy topic.
+type Ntfy struct {
+	cfg    NtfyConfig
+	client *http.Client
+}
+
+// NewNtfy creates an ntfy notifier.
+func NewNtfy(cfg NtfyConfig) *Ntfy {
+	return &Ntfy{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (n *Ntfy) Name() string { return "ntfy" }
+
+// Notify implements Notifier.
+func (n *Ntfy) Notify(ctx context.Context, e Event) error {
+	if !wants(e, pushEvents...) {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.TopicURL, strings.NewReader(Summary(e)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", "OCM: "+string(e.Type))
+	req.Header.Set("Priority", "high")
+	req.Header.Set("Tags", "key")
+	if n.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.Token)
+	}
+	return doPush(n.client, req, "ntfy")
+}
+
+// PushoverConfig configures the Pushover notifier.
+type PushoverConfig struct {
+	Token string // Application API token
+	User  string // User or group key
+}
+
+// Pushover sends push notifications through Pushover.
+type Pushover struct {
+	cfg    PushoverConfig
+	apiURL string
+	client *http.Client
+}
+
+// NewPushover creates a Pushover notifier.
+func NewPushover(cfg PushoverConfig) *Pushover {
+	return &Pushover{cfg: cfg, apiURL: pushoverAPIURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (p *Pushover) Name() string { return "pushover" }
+
+// Notify implements Notifier.
+func (p *Pushover) Notify(ctx context.Context, e Event) error {
+	if !wants(e, pushEvents...) {
+		return nil
+	}
+	form := url.Values{
+		"token":    {p.cfg.Token},
+		"user":     {p.cfg.User},
+		"title":    {"OCM: " + string(e.Type)},
+		"message":  {Summary(e)},
+		"priority": {"1"}, // High: bypasses quiet hours
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doPush(p.client, req, "pushover")
+}
+
+func doPush(client *http.Client, req *http.Request, service string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: status %d", service, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPush_NtfyAndPushover(t *testing.T) {
+	var ntfyBody, ntfyAuth string
+	var pushover url.Values
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/ocm-alerts":
+			b, _ := io.ReadAll(r.Body)
+			ntfyBody, ntfyAuth = string(b), r.Header.Get("Authorization")
+		case "/pushover":
+			r.ParseForm()
+			pushover = r.PostForm
+		}
+	}))
+	defer srv.Close()
+
+	ntfy := NewNtfy(NtfyConfig{TopicURL: srv.URL + "/ocm-alerts", Token: "tk_1"})
+	po := NewPushover(PushoverConfig{Token: "app", User: "user"})
+	po.apiURL = srv.URL + "/pushover"
+
+	ctx := context.Background()
+	requested := Event{Type: EventElevationRequested, Service: "github", Scope: "write", Reason: "release"}
+	for _, n := range []Notifier{ntfy, po} {
+		if err := n.Notify(ctx, requested); err != nil {
+			t.Fatalf("%s: %v", n.Name(), err)
+		}
+		// Not a push-worthy event
+		if err := n.Notify(ctx, Event{Type: EventElevationExpired, Service: "github"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 pushes, got %d", calls)
+	}
+	if ntfyBody != Summary(requested) || ntfyAuth != "Bearer tk_1" {
+		t.Errorf("unexpected ntfy push: %q %q", ntfyBody, ntfyAuth)
+	}
+	if pushover.Get("token") != "app" || pushover.Get("user") != "user" || pushover.Get("message") != Summary(requested) {
+		t.Errorf("unexpected pushover push: %v", pushover)
+	}
+}
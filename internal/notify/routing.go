@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// RoutingSettingKey is the settings key holding RoutingConfig.
+const RoutingSettingKey = "notify.routing"
+
+// RoutingConfig decides which notifiers receive which events. With no
+// routes, every notifier gets every event and applies its own defaults
+// (e.g., Slack posts elevation events). Once any route exists, a notifier
+// only receives events a route sends it, and then always delivers them.
+// Webhooks are exempt: each webhook has its own subscriptions.
+type RoutingConfig struct {
+	Routes []Route `json:"routes"`
+}
+
+// Route sends events matching Event to Notifiers. The first route matching
+// both the event and a notifier decides that notifier's message.
+type Route struct {
+	Event       string   `json:"event"`                 // Filter, e.g., "elevation.*"
+	Notifiers   []string `json:"notifiers"`             // Notifier names, or "*" for all
+	Template    string   `json:"template,omitempty"`    // Go template over Event for the message body
+	Severity    Severity `json:"severity,omitempty"`    // Passed to notifiers (incident tools use it)
+	MinSeverity Severity `json:"minSeverity,omitempty"` // Only events at least this severe, e.g., late reminders
+}
+
+// severityRank orders severities; unset ranks lowest.
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityError:    3,
+	SeverityCritical: 4,
+}
+
+// selfRouting is implemented by notifiers that do their own routing and are
+// exempt from RoutingConfig.
+type selfRouting interface {
+	selfRouting()
+}
+
+func (w *Webhooks) selfRouting()       {}
+func (c *AgentCallbacks) selfRouting() {}
+
+// Validate checks filters, notifier names, templates and severities.
+// known lists the registered notifier names.
+func (c *RoutingConfig) Validate(known []string) error {
+	names := make(map[string]bool, len(known))
+	for _, n := range known {
+		names[n] = true
+	}
+	for i, r := range c.Routes {
+		if !ValidFilter(r.Event) {
+			return fmt.Errorf("route %d: unknown event filter %q", i, r.Event)
+		}
+		if len(r.Notifiers) == 0 {
+			return fmt.Errorf("route %d: notifiers is required", i)
+		}
+		for _, n := range r.Notifiers {
+			if n != "*" && !names[n] {
+				return fmt.Errorf("route %d: unknown notifier %q", i, n)
+			}
+		}
+		if r.Template != "" {
+			if _, err := template.New("message").Parse(r.Template); err != nil {
+				return fmt.Errorf("route %d: invalid template: %w", i, err)
+			}
+		}
+		if _, ok := severityRank[r.Severity]; r.Severity != "" && !ok {
+			return fmt.Errorf("route %d: unknown severity %q", i, r.Severity)
+		}
+		if _, ok := severityRank[r.MinSeverity]; r.MinSeverity != "" && !ok {
+			return fmt.Errorf("route %d: unknown minSeverity %q", i, r.MinSeverity)
+		}
+	}
+	return nil
+}
+
+// LoadRouting returns the stored routing config (empty if none).
+func LoadRouting(s *store.Store) (*RoutingConfig, error) {
+	var cfg RoutingConfig
+	if _, err := s.GetSetting(RoutingSettingKey, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// route returns the event to deliver to notifier n, or false if n should
+// not receive e.
+func (c *RoutingConfig) route(e Event, n Notifier) (Event, bool) {
+	if _, ok := n.(selfRouting); ok || c == nil || len(c.Routes) == 0 {
+		return e, true
+	}
+	for _, r := range c.Routes {
+		if !e.Matches(r.Event) || !routesTo(r, n.Name()) || severityRank[e.Severity] < severityRank[r.MinSeverity] {
+			continue
+		}
+		e.routed = true
+		if r.Severity != "" {
+			e.Severity = r.Severity
+		}
+		if r.Template != "" {
+			msg, err := renderMessage(r.Template, e)
+			if err != nil {
+				// Better an untemplated message than none
+				msg = Summary(e) + " (" + err.Error() + ")"
+			}
+			e.Message = msg
+		}
+		return e, true
+	}
+	return e, false
+}
+
+func routesTo(r Route, name string) bool {
+	for _, n := range r.Notifiers {
+		if n == "*" || n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func renderMessage(tmpl string, e Event) (string, error) {
+	t, err := template.New("message").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return "", fmt.Errorf("render message: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// TestResult is the outcome of test-firing an event at one notifier.
+type TestResult struct {
+	Notifier string   `json:"notifier"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Test routes e like Publish but delivers synchronously and reports each
+// routed notifier's result. If only is set, other notifiers are skipped.
+func (d *Dispatcher) Test(ctx context.Context, e Event, only string) ([]TestResult, error) {
+	routing, err := d.loadRouting()
+	if err != nil {
+		return nil, err
+	}
+	results := []TestResult{}
+	for _, n := range d.snapshot() {
+		if only != "" && n.Name() != only {
+			continue
+		}
+		routed, ok := routing.route(e, n)
+		if !ok {
+			continue
+		}
+		res := TestResult{Notifier: n.Name(), Message: Summary(routed), Severity: routed.Severity}
+		err := n.Notify(ctx, routed)
+		d.record(n.Name(), err)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
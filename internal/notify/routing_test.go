@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// namedNotifier is a recordingNotifier with a configurable name.
+type namedNotifier struct {
+	recordingNotifier
+	name string
+}
+
+func (n *namedNotifier) Name() string { return n.name }
+
+func TestDispatcher_Routing(t *testing.T) {
+	db := newTestStore(t)
+	chat, pager := &namedNotifier{name: "chat"}, &namedNotifier{name: "pager"}
+	d := NewDispatcher(nil)
+	d.Register(chat)
+	d.Register(pager)
+	d.UseRouting(db)
+
+	// No routes: everyone gets everything
+	d.Publish(Event{Type: EventCredentialDeleted, Service: "github"})
+	d.Wait()
+	if len(chat.events) != 1 || len(pager.events) != 1 {
+		t.Fatalf("without routes: chat=%d pager=%d", len(chat.events), len(pager.events))
+	}
+
+	cfg := &RoutingConfig{Routes: []Route{
+		{Event: "elevation.requested", Notifiers: []string{"pager"}, Template: "{{.Service}} needs {{.Scope}}", Severity: SeverityCritical},
+		{Event: "elevation.*", Notifiers: []string{"*"}},
+	}}
+	if err := cfg.Validate(d.Names()); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&RoutingConfig{Routes: []Route{{Event: "elevation.*", Notifiers: []string{"fax"}}}}).Validate(d.Names()); err == nil {
+		t.Error("unknown notifier should not validate")
+	}
+	if err := db.PutSetting(RoutingSettingKey, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(Event{Type: EventElevationRequested, Service: "github", Scope: "write"})
+	d.Publish(Event{Type: EventCredentialDeleted, Service: "github"}) // Unrouted now
+	d.Wait()
+
+	if len(chat.events) != 2 || len(pager.events) != 2 {
+		t.Fatalf("with routes: chat=%d pager=%d", len(chat.events), len(pager.events))
+	}
+	got := pager.events[1]
+	if Summary(got) != "github needs write" || got.Severity != SeverityCritical || !got.routed {
+		t.Errorf("pager got %+v", got)
+	}
+	if chat.events[1].Message != "" || !chat.events[1].routed {
+		t.Errorf("chat got %+v", chat.events[1])
+	}
+
+	results, err := d.Test(context.Background(), Event{Type: EventElevationRequested, Service: "github", Scope: "read"}, "pager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Message != "github needs read" || results[0].Error != "" {
+		t.Errorf("test results: %+v", results)
+	}
+}
+
+func TestRoutingConfig_MinSeverity(t *testing.T) {
+	pager := &namedNotifier{name: "pager"}
+	cfg := &RoutingConfig{Routes: []Route{
+		{Event: "elevation.reminder", Notifiers: []string{"pager"}, MinSeverity: SeverityCritical},
+	}}
+	if err := cfg.Validate([]string{"pager"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cfg.route(Event{Type: EventElevationReminder, Severity: SeverityWarning}, pager); ok {
+		t.Error("warning reminder routed past minSeverity critical")
+	}
+	e, ok := cfg.route(Event{Type: EventElevationReminder, Severity: SeverityCritical}, pager)
+	if !ok || e.Severity != SeverityCritical {
+		t.Errorf("critical reminder: ok=%v severity=%q, want routed and critical kept", ok, e.Severity)
+	}
+}
+
+func TestDispatcher_PublishUnderStoreLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ocm.db")
+	db, err := store.New(path, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCredential(&store.Credential{ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read: &store.AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "ghp_x"}}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// Reopened with the wrong key, rekeying fails to decrypt while holding
+	// the store's write lock, and the failure is published from there
+	wrong := make([]byte, 32)
+	wrong[0] = 1
+	db, err = store.New(path, wrong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rec := &recordingNotifier{}
+	d := NewDispatcher(nil)
+	d.Register(rec)
+	d.UseRouting(db)
+	db.OnDecryptError(func(err error) {
+		d.Publish(Event{Type: EventStoreDecryptFailed, Actor: "system", Details: err.Error()})
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- db.Rekey(make([]byte, 32), nil) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Rekey with the wrong key succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Rekey deadlocked publishing the decrypt failure")
+	}
+	d.Wait()
+	if len(rec.events) == 0 {
+		t.Error("decrypt failure not delivered")
+	}
+}
@@ -0,0 +1,264 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	slackAPIURL = "https://slack.com/api"
+
+	slackActionApprove = "ocm_approve"
+	slackActionDeny    = "ocm_deny"
+
+	// slackMaxSkew is how old a signed interaction may be (replay protection).
+	slackMaxSkew = 5 * time.Minute
+)
+
+// SlackConfig configures the Slack notifier.
+type SlackConfig struct {
+	BotToken      string        // xoxb- token with chat:write
+	SigningSecret string        // Verifies interaction callbacks
+	Channel       string        // Channel ID or name to post to
+	ApproveTTL    time.Duration // TTL granted by the Approve button (clamped to the credential's maxTTL)
+}
+
+// Slack posts elevation events to a channel. Pending requests get
+// Approve/Deny buttons, handled by InteractionHandler.
+type Slack struct {
+	cfg    SlackConfig
+	apiURL string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewSlack creates a Slack notifier.
+func NewSlack(cfg SlackConfig, logger *slog.Logger) *Slack {
+	if cfg.ApproveTTL <= 0 {
+		cfg.ApproveTTL = 30 * time.Minute
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Slack{
+		cfg:    cfg,
+		apiURL: slackAPIURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+// Name implements Notifier.
+func (s *Slack) Name() string { return "slack" }
+
+// Notify implements Notifier. Unless routed otherwise, only chatEvents are
+// posted; administrative changes go to webhooks and the audit log.
+func (s *Slack) Notify(ctx context.Context, e Event) error {
+	if !wants(e, chatEvents...) {
+		return nil
+	}
+	msg := map[string]interface{}{
+		"channel": s.cfg.Channel,
+		"text":    Summary(e),
+	}
+	if awaitsDecision(e) {
+		msg["blocks"] = s.requestBlocks(e)
+	}
+	return s.call(ctx, "chat.postMessage", msg)
+}
+
+func (s *Slack) requestBlocks(e Event) []interface{} {
+	title := "Elevation requested"
+	if e.Type == EventElevationReminder {
+		title = "Reminder: elevation still pending (" + e.Details + ")"
+	}
+	text := fmt.Sprintf("*%s*\n*Service:* %s (%s)\n*Request:* `%s`", title, e.Service, e.Scope, e.ElevationID)
+	if e.Reason != "" {
+		text += "\n*Reason:* " + e.Reason
+	}
+	button := func(label, style, actionID string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":      "button",
+			"text":      map[string]string{"type": "plain_text", "text": label},
+			"style":     style,
+			"action_id": actionID,
+			"value":     e.ElevationID,
+		}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text},
+		},
+		map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				button("Approve ("+s.cfg.ApproveTTL.String()+")", "primary", slackActionApprove),
+				button("Deny", "danger", slackActionDeny),
+			},
+		},
+	}
+}
+
+// call invokes a Slack Web API method.
+func (s *Slack) call(ctx context.Context, method string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.cfg.BotToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("slack %s: status %d", method, resp.StatusCode)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack %s: %s", method, result.Error)
+	}
+	return nil
+}
+
+// slackInteraction is the subset of a block_actions payload we use.
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// InteractionHandler handles Slack's interactivity callbacks (button clicks).
+// Requests must carry a valid Slack signature; decisions are attributed to
+// "slack:<username>". A click is acknowledged at once and decided
+// afterwards, the outcome replacing the message through its response_url.
+func (s *Slack) InteractionHandler(d Decider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err := s.verify(r.Header, body, time.Now()); err != nil {
+			s.logger.Warn("rejected slack interaction", "error", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var in slackInteraction
+		if err := json.Unmarshal([]byte(form.Get("payload")), &in); err != nil || len(in.Actions) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		// Acknowledge first: Slack expects a response within 3s, and a
+		// decision may take longer (e.g. restarting the Gateway to inject)
+		w.WriteHeader(http.StatusOK)
+		go s.decide(d, in)
+	})
+}
+
+// decide applies the decision of an interaction's button and reports the
+// outcome through its response_url.
+func (s *Slack) decide(d Decider, in slackInteraction) {
+	action := in.Actions[0]
+	actor := "slack:" + in.User.Username
+	if in.User.Username == "" {
+		actor = "slack:" + in.User.ID
+	}
+
+	var err error
+	var reply string
+	switch action.ActionID {
+	case slackActionApprove:
+		err = d.ApproveElevation(action.Value, s.cfg.ApproveTTL, actor)
+		reply = fmt.Sprintf(":white_check_mark: Request `%s` approved by <@%s> for %s", action.Value, in.User.ID, s.cfg.ApproveTTL)
+	case slackActionDeny:
+		err = d.DenyElevation(action.Value, actor, "denied from Slack")
+		reply = fmt.Sprintf(":no_entry: Request `%s` denied by <@%s>", action.Value, in.User.ID)
+	default:
+		return
+	}
+	if err != nil {
+		s.logger.Warn("slack decision failed", "elevation", action.Value, "error", err)
+		reply = fmt.Sprintf(":warning: Could not update request `%s`: %s", action.Value, err)
+	}
+	if in.ResponseURL != "" {
+		s.respond(in.ResponseURL, reply, err == nil)
+	}
+}
+
+// respond updates the original message through the interaction's response_url.
+func (s *Slack) respond(responseURL, text string, replace bool) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"text":             text,
+		"replace_original": replace,
+		"response_type":    "in_channel",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Warn("slack response_url failed", "error", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// verify checks Slack's request signature: v0=HMAC-SHA256(secret, "v0:<ts>:<body>").
+func (s *Slack) verify(h http.Header, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(h.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing timestamp")
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return fmt.Errorf("stale timestamp")
+	}
+	mac := hmac.New(sha256.New, []byte(s.cfg.SigningSecret))
+	fmt.Fprintf(mac, "v0:%d:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeDecider struct {
+	approved, denied string
+	actor            string
+	ttl              time.Duration
+}
+
+func (d *fakeDecider) ApproveElevation(id string, ttl time.Duration, by string) error {
+	d.approved, d.ttl, d.actor = id, ttl, by
+	return nil
+}
+
+func (d *fakeDecider) DenyElevation(id, by, reason string) error {
+	d.denied, d.actor = id, by
+	return nil
+}
+
+func signedInteraction(t *testing.T, secret, actionID, value, responseURL string) *http.Request {
+	t.Helper()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": "U123", "username": "alice"},
+		"actions":      []map[string]string{{"action_id": actionID, "value": value}},
+		"response_url": responseURL,
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+
+	req := httptest.NewRequest("POST", "/slack/interactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlack_InteractionApprove(t *testing.T) {
+	s := NewSlack(SlackConfig{SigningSecret: "shh", ApproveTTL: 15 * time.Minute}, nil)
+	d := &fakeDecider{}
+	h := s.InteractionHandler(d)
+	responses := make(chan map[string]interface{}, 1)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		responses <- msg
+	}))
+	defer responder.Close()
+
+	// Acknowledged at once; decided and reported through response_url after
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedInteraction(t, "shh", slackActionApprove, "elev-1", responder.URL))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	select {
+	case msg := <-responses:
+		if text, _ := msg["text"].(string); !strings.Contains(text, "approved") || msg["replace_original"] != true {
+			t.Errorf("response = %v, want the approval replacing the message", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response_url update")
+	}
+	if d.approved != "elev-1" || d.actor != "slack:alice" || d.ttl != 15*time.Minute {
+		t.Errorf("decider got id=%q actor=%q ttl=%s, want elev-1 slack:alice 15m", d.approved, d.actor, d.ttl)
+	}
+
+	// Wrong secret is rejected before any decision
+	d = &fakeDecider{}
+	h = s.InteractionHandler(d)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signedInteraction(t, "wrong", slackActionDeny, "elev-2", responder.URL))
+	if w.Code != http.StatusUnauthorized || d.denied != "" {
+		t.Errorf("bad signature: status=%d denied=%q, want 401 and no decision", w.Code, d.denied)
+	}
+}
+
+func TestSlack_NotifyRequested(t *testing.T) {
+	var got map[string]interface{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.postMessage" || r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("unexpected request %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	s := NewSlack(SlackConfig{BotToken: "xoxb-test", Channel: "#approvals"}, nil)
+	s.apiURL = api.URL
+
+	err := s.Notify(context.Background(), Event{
+		Type: EventElevationRequested, Service: "github", Scope: "write",
+		ElevationID: "elev-1", Reason: "release",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["channel"] != "#approvals" {
+		t.Errorf("channel = %v, want #approvals", got["channel"])
+	}
+	blocks, _ := json.Marshal(got["blocks"])
+	if !strings.Contains(string(blocks), `"value":"elev-1"`) || !strings.Contains(string(blocks), slackActionApprove) {
+		t.Errorf("blocks missing approve button for elev-1: %s", blocks)
+	}
+}
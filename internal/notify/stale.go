@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// DefaultStaleDays is how long a credential goes unaccessed before the
+// stale report lists it, unless asked otherwise.
+const DefaultStaleDays = 90
+
+// StaleCredential is a credential no agent has read and no elevation has
+// been approved on within the report's window.
+type StaleCredential struct {
+	Service        string     `json:"service"`
+	DisplayName    string     `json:"displayName"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"` // nil = never accessed
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// StaleReport lists credentials not accessed or elevated in Days days:
+// candidates for removal.
+type StaleReport struct {
+	Days        int               `json:"days"`
+	Since       time.Time         `json:"since"`
+	Credentials []StaleCredential `json:"credentials"` // Least recently accessed first
+}
+
+// Stale lists the credentials in creds not accessed since days before now.
+// A credential never accessed counts from its creation, so a new one isn't
+// listed straight away. Injections don't count as access.
+func Stale(creds []*store.Credential, now time.Time, days int) *StaleReport {
+	r := &StaleReport{Days: days, Since: now.AddDate(0, 0, -days), Credentials: []StaleCredential{}}
+	for _, cred := range creds {
+		last := cred.CreatedAt
+		if cred.LastAccessedAt != nil {
+			last = *cred.LastAccessedAt
+		}
+		if !last.Before(r.Since) {
+			continue
+		}
+		r.Credentials = append(r.Credentials, StaleCredential{
+			Service:        cred.Service,
+			DisplayName:    cred.DisplayName,
+			LastAccessedAt: cred.LastAccessedAt,
+			CreatedAt:      cred.CreatedAt,
+		})
+	}
+	sort.SliceStable(r.Credentials, func(i, j int) bool {
+		return r.Credentials[i].lastSeen().Before(r.Credentials[j].lastSeen())
+	})
+	return r
+}
+
+// lastSeen is when c was last accessed, or else created.
+func (c StaleCredential) lastSeen() time.Time {
+	if c.LastAccessedAt != nil {
+		return *c.LastAccessedAt
+	}
+	return c.CreatedAt
+}
+
+// BuildStaleReport lists the stored credentials not accessed in days days.
+func BuildStaleReport(s *store.Store, now time.Time, days int) (*StaleReport, error) {
+	creds, err := s.ListCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+	return Stale(creds, now, days), nil
+}
+
+// Text renders the report for email and chat.
+func (r *StaleReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "OCM stale credentials: %d not accessed or elevated in %d days (consider removing)\n\n", len(r.Credentials), r.Days)
+	for _, c := range r.Credentials {
+		if c.LastAccessedAt != nil {
+			fmt.Fprintf(&b, "  %s: last accessed %s\n", c.Service, c.LastAccessedAt.Format("2006-01-02"))
+		} else {
+			fmt.Fprintf(&b, "  %s: never accessed (created %s)\n", c.Service, c.CreatedAt.Format("2006-01-02"))
+		}
+	}
+	return b.String()
+}
+
+// StaleScheduler publishes a report.stale event on Mondays when any
+// credential is stale. Email and webhooks subscribed to "report.*" deliver
+// it.
+type StaleScheduler struct {
+	store      *store.Store
+	dispatcher *Dispatcher
+	days       int
+	hour       int
+	logger     *slog.Logger
+}
+
+// NewStaleScheduler creates a scheduler reporting credentials not accessed
+// in days days, sending at hour (0-23, local time).
+func NewStaleScheduler(s *store.Store, d *Dispatcher, days, hour int, logger *slog.Logger) *StaleScheduler {
+	return &StaleScheduler{store: s, dispatcher: d, days: days, hour: hour, logger: logger}
+}
+
+// Run sends reports until ctx is done.
+func (ss *StaleScheduler) Run(ctx context.Context) {
+	for {
+		next := nextDigest(time.Now(), DigestWeekly, ss.hour)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		report, err := BuildStaleReport(ss.store, next, ss.days)
+		if err != nil {
+			ss.logger.Error("failed to build stale credential report", "error", err)
+			continue
+		}
+		if len(report.Credentials) == 0 {
+			continue
+		}
+		ss.dispatcher.Publish(Event{
+			Type:    EventReportStale,
+			Time:    next,
+			Actor:   "system",
+			Details: report.Text(),
+			Report:  report,
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestStale(t *testing.T) {
+	now := time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	creds := []*store.Credential{
+		{Service: "github", CreatedAt: *ago(400), LastAccessedAt: ago(5)},
+		{Service: "linear", CreatedAt: *ago(400), LastAccessedAt: ago(120)},
+		{Service: "notion", CreatedAt: *ago(200)},
+		{Service: "openai", CreatedAt: *ago(10)}, // New, not yet due
+		{Service: "slack", CreatedAt: *ago(400), LastAccessedAt: ago(95)},
+	}
+
+	r := Stale(creds, now, 90)
+	var got []string
+	for _, c := range r.Credentials {
+		got = append(got, c.Service)
+	}
+	if strings.Join(got, ",") != "notion,linear,slack" {
+		t.Errorf("stale = %v, want notion, linear, slack (least recently accessed first)", got)
+	}
+
+	text := r.Text()
+	for _, want := range []string{"3 not accessed or elevated in 90 days", "linear: last accessed 2024-01-16", "notion: never accessed (created 2023-10-28)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+
+	if r := Stale(creds, now, 180); len(r.Credentials) != 1 || r.Credentials[0].Service != "notion" {
+		t.Errorf("180 days: %+v, want only notion", r.Credentials)
+	}
+}
@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	telegramAPIURL = "https://api.telegram.org"
+
+	// telegramPollTimeout is the long-poll timeout passed to getUpdates.
+	telegramPollTimeout = 50 * time.Second
+)
+
+// TelegramConfig configures the Telegram bot.
+type TelegramConfig struct {
+	BotToken   string        // From @BotFather
+	ChatID     int64         // Admin chat; commands from any other chat are ignored
+	ApproveTTL time.Duration // TTL for /approve without an explicit duration
+}
+
+// Telegram posts elevation events to an admin chat and accepts
+// /approve and /deny commands from it. Commands are received by
+// long-polling, so OCM needs no public URL.
+type Telegram struct {
+	cfg    TelegramConfig
+	apiURL string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewTelegram creates a Telegram notifier.
+func NewTelegram(cfg TelegramConfig, logger *slog.Logger) *Telegram {
+	if cfg.ApproveTTL <= 0 {
+		cfg.ApproveTTL = 30 * time.Minute
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Telegram{
+		cfg:    cfg,
+		apiURL: telegramAPIURL,
+		client: &http.Client{Timeout: telegramPollTimeout + 10*time.Second},
+		logger: logger,
+	}
+}
+
+// Name implements Notifier.
+func (t *Telegram) Name() string { return "telegram" }
+
+// Notify implements Notifier. Like Slack, only chatEvents are posted
+// unless routed otherwise.
+func (t *Telegram) Notify(ctx context.Context, e Event) error {
+	if !wants(e, chatEvents...) {
+		return nil
+	}
+	text := Summary(e)
+	if awaitsDecision(e) {
+		text += fmt.Sprintf("\n\n/approve %s %s\n/deny %s <reason>", e.ElevationID, t.cfg.ApproveTTL, e.ElevationID)
+	}
+	return t.send(ctx, text)
+}
+
+func (t *Telegram) send(ctx context.Context, text string) error {
+	return t.call(ctx, "sendMessage", map[string]interface{}{"chat_id": t.cfg.ChatID, "text": text}, nil)
+}
+
+// call invokes a Bot API method, decoding its result into out if non-nil.
+func (t *Telegram) call(ctx context.Context, method string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL+"/bot"+t.cfg.BotToken+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL embeds the bot token; don't let it reach the logs
+		return fmt.Errorf("telegram %s: request failed", method)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("telegram %s: status %d", method, resp.StatusCode)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
+
+// telegramUpdate is the subset of an Update we use.
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	From struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"from"`
+	Text string `json:"text"`
+}
+
+// Run long-polls for commands until ctx is done, applying them through d.
+func (t *Telegram) Run(ctx context.Context, d Decider) {
+	var offset int64
+	for {
+		var updates []telegramUpdate
+		err := t.call(ctx, "getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         int(telegramPollTimeout.Seconds()),
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			t.logger.Warn("telegram poll failed", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || u.Message.Chat.ID != t.cfg.ChatID {
+				continue
+			}
+			if reply := t.handleCommand(d, u.Message); reply != "" {
+				if err := t.send(ctx, reply); err != nil {
+					t.logger.Warn("telegram reply failed", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// handleCommand applies "/approve <id> [ttl]" or "/deny <id> [reason]" and
+// returns the reply. Other messages are ignored. Decisions are attributed
+// to "telegram:<username>".
+func (t *Telegram) handleCommand(d Decider, m *telegramMessage) string {
+	fields := strings.Fields(m.Text)
+	if len(fields) == 0 {
+		return ""
+	}
+	// Commands in groups may be addressed as /approve@botname
+	cmd, _, _ := strings.Cut(fields[0], "@")
+	if cmd != "/approve" && cmd != "/deny" {
+		return ""
+	}
+	if len(fields) < 2 {
+		return "Usage: /approve <id> [ttl] or /deny <id> [reason]"
+	}
+	id := fields[1]
+
+	actor := "telegram:" + m.From.Username
+	if m.From.Username == "" {
+		actor = fmt.Sprintf("telegram:%d", m.From.ID)
+	}
+
+	if cmd == "/approve" {
+		ttl := t.cfg.ApproveTTL
+		if len(fields) > 2 {
+			var err error
+			if ttl, err = time.ParseDuration(fields[2]); err != nil || ttl <= 0 {
+				return fmt.Sprintf("Invalid TTL %q (use e.g. 30m or 2h)", fields[2])
+			}
+		}
+		if err := d.ApproveElevation(id, ttl, actor); err != nil {
+			return fmt.Sprintf("Could not approve %s: %s", id, err)
+		}
+		return fmt.Sprintf("Approved %s for %s", id, ttl)
+	}
+
+	reason := strings.Join(fields[2:], " ")
+	if reason == "" {
+		reason = "denied from Telegram"
+	}
+	if err := d.DenyElevation(id, actor, reason); err != nil {
+		return fmt.Sprintf("Could not deny %s: %s", id, err)
+	}
+	return fmt.Sprintf("Denied %s", id)
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegram_Commands(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polls := 0
+	var replies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bot123:abc/getUpdates":
+			polls++
+			if polls > 1 {
+				cancel()
+				w.Write([]byte(`{"ok":true,"result":[]}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"result":[
+				{"update_id":1,"message":{"chat":{"id":999},"from":{"id":7,"username":"mallory"},"text":"/approve elev-1 8h"}},
+				{"update_id":2,"message":{"chat":{"id":42},"from":{"id":5,"username":"alice"},"text":"/approve@ocm_bot elev-1 45m"}},
+				{"update_id":3,"message":{"chat":{"id":42},"from":{"id":5,"username":"alice"},"text":"/approve elev-2 soon"}}
+			]}`))
+		case "/bot123:abc/sendMessage":
+			var msg struct {
+				ChatID int64  `json:"chat_id"`
+				Text   string `json:"text"`
+			}
+			json.NewDecoder(r.Body).Decode(&msg)
+			if msg.ChatID != 42 {
+				t.Errorf("reply sent to chat %d", msg.ChatID)
+			}
+			replies = append(replies, msg.Text)
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	tg := NewTelegram(TelegramConfig{BotToken: "123:abc", ChatID: 42}, nil)
+	tg.apiURL = api.URL
+
+	d := &fakeDecider{}
+	tg.Run(ctx, d)
+
+	if d.approved != "elev-1" || d.ttl != 45*time.Minute || d.actor != "telegram:alice" {
+		t.Errorf("unexpected decision: %+v", d)
+	}
+	if len(replies) != 2 || !strings.HasPrefix(replies[1], "Invalid TTL") {
+		t.Errorf("unexpected replies: %q", replies)
+	}
+
+	reply := tg.handleCommand(d, &telegramMessage{Text: "/deny elev-3 not during the freeze"})
+	if d.denied != "elev-3" || reply != "Denied elev-3" {
+		t.Errorf("deny: %q %+v", reply, d)
+	}
+}
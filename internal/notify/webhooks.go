@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+// Delivery headers sent alongside the webhook package's signature headers.
+const (
+	WebhookEventHeader    = "X-OCM-Event"
+	WebhookDeliveryHeader = "X-OCM-Delivery"
+)
+
+// defaultWebhookBackoff is the wait before each retry; a delivery gets
+// len(backoff)+1 attempts before it is marked failed.
+var defaultWebhookBackoff = []time.Duration{2 * time.Second, 10 * time.Second, time.Minute, 5 * time.Minute}
+
+// WebhookPayload is the JSON body POSTed to registered webhooks.
+type WebhookPayload struct {
+	ID    string    `json:"id"` // Delivery ID, also sent as X-OCM-Delivery
+	Event EventType `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  Event     `json:"data"`
+}
+
+// Webhooks delivers events to the admin-registered webhooks in the store.
+// Each matching webhook gets its own signed delivery, retried with backoff;
+// every attempt is recorded so admins can see what happened.
+type Webhooks struct {
+	store   *store.Store
+	client  *http.Client
+	backoff []time.Duration
+	logger  *slog.Logger
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewWebhooks creates the webhook notifier.
+func NewWebhooks(s *store.Store, logger *slog.Logger) *Webhooks {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Webhooks{
+		store:   s,
+		client:  webhook.DefaultClient,
+		backoff: defaultWebhookBackoff,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+}
+
+// Name implements Notifier.
+func (w *Webhooks) Name() string { return "webhooks" }
+
+// Notify implements Notifier. Deliveries run in the background because
+// retries outlive the dispatcher's per-notification timeout.
+func (w *Webhooks) Notify(ctx context.Context, e Event) error {
+	hooks, err := w.store.ListWebhooks()
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !hook.Enabled || !matchesAny(e, hook.Events) {
+			continue
+		}
+
+		now := time.Now()
+		delivery := &store.WebhookDelivery{
+			ID:        newDeliveryID(),
+			WebhookID: hook.ID,
+			Event:     string(e.Type),
+			Status:    "pending",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		body, err := json.Marshal(WebhookPayload{ID: delivery.ID, Event: e.Type, Time: e.Time, Data: e})
+		if err != nil {
+			return err
+		}
+		if err := w.store.SaveWebhookDelivery(delivery); err != nil {
+			w.logger.Error("failed to record webhook delivery", "error", err, "webhook_id", hook.ID)
+		}
+
+		w.wg.Add(1)
+		go func(hook *store.Webhook) {
+			defer w.wg.Done()
+			w.deliver(hook, delivery, body)
+		}(hook)
+	}
+	return nil
+}
+
+// deliver POSTs body until it succeeds, retries run out, or Close is called.
+func (w *Webhooks) deliver(hook *store.Webhook, d *store.WebhookDelivery, body []byte) {
+	header := http.Header{}
+	header.Set(WebhookEventHeader, d.Event)
+	header.Set(WebhookDeliveryHeader, d.ID)
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		err := webhook.PostRawHeaders(ctx, w.client, hook.URL, hook.Secret, body, header)
+		cancel()
+
+		d.Attempts++
+		d.UpdatedAt = time.Now()
+		d.ResponseCode, d.LastError = 0, ""
+		var statusErr *webhook.StatusError
+		if errors.As(err, &statusErr) {
+			d.ResponseCode = statusErr.StatusCode
+		}
+		switch {
+		case err == nil:
+			d.Status = "delivered"
+		case attempt >= len(w.backoff):
+			d.Status = "failed"
+			d.LastError = err.Error()
+		default:
+			d.LastError = err.Error()
+		}
+		if err := w.store.SaveWebhookDelivery(d); err != nil {
+			w.logger.Error("failed to record webhook delivery", "error", err, "delivery_id", d.ID)
+		}
+		if d.Status != "pending" {
+			if d.Status == "failed" {
+				w.logger.Warn("webhook delivery failed", "webhook_id", hook.ID, "event", d.Event, "attempts", d.Attempts, "error", d.LastError)
+			}
+			return
+		}
+
+		select {
+		case <-time.After(w.backoff[attempt]):
+		case <-w.done:
+			return // Left pending; the record shows how far it got
+		}
+	}
+}
+
+// Close abandons outstanding retries and waits for in-flight attempts.
+func (w *Webhooks) Close() {
+	w.once.Do(func() { close(w.done) })
+	w.wg.Wait()
+}
+
+// Wait blocks until every delivery has finished (used in tests).
+func (w *Webhooks) Wait() { w.wg.Wait() }
+
+func newDeliveryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "whd_" + hex.EncodeToString(b)
+}
+
+// NewWebhookSecret returns a random signing secret for a new webhook.
+func NewWebhookSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
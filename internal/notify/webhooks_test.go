@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+func TestWebhooks_RetriesAndRecordsDelivery(t *testing.T) {
+	db := newTestStore(t)
+
+	var calls atomic.Int32
+	var got WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+		if r.Header.Get(webhook.SignatureHeader) != webhook.Sign("s3cret", ts, body) {
+			t.Error("bad signature")
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.Unmarshal(body, &got)
+		if r.Header.Get(WebhookDeliveryHeader) != got.ID {
+			t.Errorf("delivery header %q != payload id %q", r.Header.Get(WebhookDeliveryHeader), got.ID)
+		}
+	}))
+	defer srv.Close()
+
+	for _, hook := range []*store.Webhook{
+		{ID: "wh_elev", URL: srv.URL, Secret: "s3cret", Events: []string{"elevation.*"}, Enabled: true, CreatedAt: time.Now()},
+		{ID: "wh_dev", URL: srv.URL, Secret: "s3cret", Events: []string{"device.*"}, Enabled: true, CreatedAt: time.Now()},
+		{ID: "wh_off", URL: srv.URL, Secret: "s3cret", Events: []string{"*"}, Enabled: false, CreatedAt: time.Now()},
+	} {
+		if err := db.SaveWebhook(hook); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := NewWebhooks(db, nil)
+	w.backoff = []time.Duration{10 * time.Millisecond}
+	if err := w.Notify(context.Background(), Event{Type: EventElevationApproved, Service: "github", Scope: "write", Actor: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Wait()
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 attempts (one retry), got %d", calls.Load())
+	}
+	if got.Event != EventElevationApproved || got.Data.Service != "github" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+
+	deliveries, err := db.ListWebhookDeliveries("wh_elev", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != "delivered" || deliveries[0].Attempts != 2 {
+		t.Fatalf("unexpected deliveries: %+v", deliveries)
+	}
+	for _, id := range []string{"wh_dev", "wh_off"} {
+		if d, _ := db.ListWebhookDeliveries(id, 10); len(d) != 0 {
+			t.Errorf("%s should not have received the event", id)
+		}
+	}
+
+	// Out of retries: marked failed with the last response code
+	calls.Store(0)
+	w.backoff = nil
+	w.Notify(context.Background(), Event{Type: EventElevationDenied, Service: "github", Actor: "admin"})
+	w.Wait()
+	deliveries, _ = db.ListWebhookDeliveries("wh_elev", 10)
+	if deliveries[0].Status != "failed" || deliveries[0].ResponseCode != http.StatusBadGateway {
+		t.Fatalf("expected failed delivery with 502, got %+v", deliveries[0])
+	}
+}
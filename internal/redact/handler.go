@@ -0,0 +1,84 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Handler wraps a slog.Handler, redacting every record's message and
+// attributes, including those added with WithAttrs and nested in groups.
+type Handler struct {
+	inner slog.Handler
+}
+
+// NewHandler returns a Handler writing redacted records to inner.
+func NewHandler(inner slog.Handler) *Handler {
+	return &Handler{inner: inner}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, String(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(Attr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = Attr(a)
+	}
+	return &Handler{inner: h.inner.WithAttrs(redacted)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name)}
+}
+
+// Attr returns a with its value redacted: replaced outright if the key names
+// a secret, otherwise scrubbed of registered and token-shaped secrets.
+func Attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	if SensitiveKey(a.Key) && !isEmpty(v) {
+		return slog.String(a.Key, Placeholder)
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, String(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]interface{}, len(group))
+		for i, ga := range group {
+			redacted[i] = Attr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		// Errors and structs are logged through their string form; only
+		// replace the value if that form gave something away
+		var s string
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = fmt.Sprint(v.Any())
+		}
+		if clean := String(s); clean != s {
+			return slog.String(a.Key, clean)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func isEmpty(v slog.Value) bool {
+	return v.Kind() == slog.KindString && v.String() == ""
+}
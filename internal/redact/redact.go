@@ -0,0 +1,127 @@
+// Package redact keeps secrets out of logs. Known secret values (credential
+// tokens, Gateway tokens, webhook secrets) are registered as they are loaded,
+// and a slog.Handler wrapper replaces them, values under secret-looking keys,
+// and anything shaped like a token or signature with a placeholder before a
+// record reaches the real handler, whatever its level.
+package redact
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// minSecretLen is the shortest value Register accepts; shorter values
+// (flags, IDs, "true") would redact too much to be useful.
+const minSecretLen = 8
+
+var (
+	mu       sync.RWMutex
+	secrets  = make(map[string]struct{})
+	replacer *strings.Replacer // Rebuilt after Register; nil if no secrets
+)
+
+// Register adds values that must never appear in logs. Empty and very short
+// values are ignored.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	changed := false
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLen {
+			continue
+		}
+		if _, ok := secrets[v]; !ok {
+			secrets[v] = struct{}{}
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	// Longest first, so a secret containing another is replaced whole
+	sorted := make([]string, 0, len(secrets))
+	for v := range secrets {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, v := range sorted {
+		pairs = append(pairs, v, Placeholder)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// RegisterEnv registers the values of environment variables whose names mark
+// them as secrets, e.g., OPENCLAW_GATEWAY_TOKEN or OCM_SLACK_SIGNING_SECRET.
+func RegisterEnv() {
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if SensitiveKey(name) {
+			Register(value)
+		}
+	}
+}
+
+// sensitiveSuffixes end the (normalized) names of keys whose values are secret.
+var sensitiveSuffixes = []string{
+	"token", "secret", "password", "passwd", "signature", "authorization",
+	"cookie", "apikey", "privatekey", "masterkey", "routingkey", "accesskey",
+	"webhookurl", // Discord and Slack webhook URLs carry their own token
+}
+
+// SensitiveKey reports whether a log attribute or environment variable named
+// key holds a secret: "token", "refresh_token", "clientSecret" and
+// "X-OCM-Signature" do; "tokenEnv", "key" and "keyId" don't.
+func SensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	k = strings.NewReplacer("_", "", "-", "", ".", "").Replace(k)
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(k, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// patterns match secrets by shape, for values never registered (a token in
+// an upstream error message, a header echoed back).
+var patterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9._~+/=-]{8,}`), "$1 " + Placeholder},
+	{regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`), Placeholder},
+	{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), Placeholder},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`), Placeholder},
+	{regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`), Placeholder},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), Placeholder},
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`), Placeholder},
+	{regexp.MustCompile(`\bsha256=[0-9a-f]{64}\b`), "sha256=" + Placeholder},
+	{regexp.MustCompile(`\bwhsec_[0-9a-f]{16,}`), Placeholder},
+	// Signed links and tokens: "<id>.<base64url HMAC-SHA256>", e.g., guest invites
+	{regexp.MustCompile(`\b([a-z]+_[0-9a-f]{16}_[0-9]+)\.[A-Za-z0-9_-]{43}\b`), "$1." + Placeholder},
+}
+
+// String returns s with registered secrets and token-shaped values replaced.
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+	if r != nil {
+		s = r.Replace(s)
+	}
+	for _, p := range patterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
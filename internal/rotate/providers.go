@@ -0,0 +1,297 @@
+package rotate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/sigv4"
+	"github.com/openclaw/ocm/internal/store"
+)
+
+// Built-in rotators, for Rotation.Provider.
+const (
+	// ProviderGitHub refreshes a GitHub App user access token. GitHub has
+	// no API to create personal access tokens, fine-grained or classic;
+	// user access tokens carry the same fine-grained permissions and can be
+	// rotated with their refresh token.
+	ProviderGitHub = "github"
+	// ProviderAWSIAM creates a new IAM access key with the current one, and
+	// deletes the current one once the new one is stored.
+	ProviderAWSIAM = "aws_iam"
+	// ProviderSlack rotates a Slack app's token with its refresh token: a
+	// bot or user token with token rotation enabled, or, without a client
+	// ID, an app configuration token.
+	ProviderSlack = "slack"
+)
+
+// providerTypes maps credential types to the built-in rotator for them.
+var providerTypes = map[string]string{
+	"github_user_token": ProviderGitHub,
+	"aws_access_key":    ProviderAWSIAM,
+	"slack_app_token":   ProviderSlack,
+}
+
+// ProviderFor returns the built-in rotator for credentials of type
+// credType, or "" if there is none.
+func ProviderFor(credType string) string {
+	return providerTypes[credType]
+}
+
+// accessKeyIDField is the additional field holding an IAM access key's ID
+// when it isn't injected as AWS_ACCESS_KEY_ID.
+const accessKeyIDField = "accessKeyId"
+
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// validateProvider checks that r's built-in rotator is known and has what
+// it needs.
+func validateProvider(r *store.Rotation) error {
+	switch r.Provider {
+	case ProviderGitHub:
+		if r.ClientID == "" || r.ClientSecret == "" {
+			return errors.New("github rotation needs the app's clientId and clientSecret")
+		}
+	case ProviderSlack:
+		if (r.ClientID == "") != (r.ClientSecret == "") {
+			return errors.New("slack rotation needs both clientId and clientSecret, or neither for an app configuration token")
+		}
+	case ProviderAWSIAM:
+	default:
+		return fmt.Errorf("unknown rotation provider %q (github, aws_iam or slack)", r.Provider)
+	}
+	if r.Endpoint != "" {
+		if u, err := url.Parse(r.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid rotation endpoint %q", r.Endpoint)
+		}
+	}
+	return nil
+}
+
+// mintBuiltin mints req's replacement with r's built-in rotator.
+func mintBuiltin(ctx context.Context, r *store.Rotation, req Request) (*Secret, error) {
+	switch r.Provider {
+	case ProviderGitHub:
+		return rotateGitHub(ctx, r, req)
+	case ProviderAWSIAM:
+		return rotateAWSIAM(ctx, r, req)
+	case ProviderSlack:
+		return rotateSlack(ctx, r, req)
+	}
+	return nil, fmt.Errorf("unknown rotation provider %q", r.Provider)
+}
+
+// rotateGitHub exchanges the level's refresh token for a new user access
+// token and refresh token. GitHub invalidates the old pair.
+func rotateGitHub(ctx context.Context, r *store.Rotation, req Request) (*Secret, error) {
+	if req.RefreshToken == "" {
+		return nil, errors.New("github rotation needs the level's refreshToken")
+	}
+	base := strings.TrimRight(firstNonEmpty(r.Endpoint, "https://github.com"), "/")
+	body, err := postForm(ctx, base+"/login/oauth/access_token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {req.RefreshToken},
+		"client_id":     {r.ClientID},
+		"client_secret": {r.ClientSecret},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	// GitHub reports errors with a 200
+	var resp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		RefreshToken     string `json:"refresh_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("github: %s: %s", resp.Error, resp.ErrorDescription)
+	}
+	return &Secret{Token: resp.AccessToken, RefreshToken: resp.RefreshToken, ExpiresAt: expiresIn(resp.ExpiresIn)}, nil
+}
+
+// rotateSlack exchanges the level's refresh token for a new token and
+// refresh token: through oauth.v2.access for a bot or user token, or
+// tooling.tokens.rotate for an app configuration token.
+func rotateSlack(ctx context.Context, r *store.Rotation, req Request) (*Secret, error) {
+	if req.RefreshToken == "" {
+		return nil, errors.New("slack rotation needs the level's refreshToken")
+	}
+	base := strings.TrimRight(firstNonEmpty(r.Endpoint, "https://slack.com/api"), "/")
+	method := "/tooling.tokens.rotate"
+	form := url.Values{"refresh_token": {req.RefreshToken}}
+	if r.ClientID != "" {
+		method = "/oauth.v2.access"
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", r.ClientID)
+		form.Set("client_secret", r.ClientSecret)
+	}
+	body, err := postForm(ctx, base+method, form)
+	if err != nil {
+		return nil, fmt.Errorf("slack: %w", err)
+	}
+	// Slack reports errors with a 200 and ok false
+	var resp struct {
+		OK           bool   `json:"ok"`
+		Error        string `json:"error"`
+		AccessToken  string `json:"access_token"` // oauth.v2.access
+		ExpiresIn    int    `json:"expires_in"`
+		Token        string `json:"token"` // tooling.tokens.rotate
+		Exp          int64  `json:"exp"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("slack: %w", err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("slack: %s", firstNonEmpty(resp.Error, "request failed"))
+	}
+	secret := &Secret{Token: firstNonEmpty(resp.AccessToken, resp.Token), RefreshToken: resp.RefreshToken, ExpiresAt: expiresIn(resp.ExpiresIn)}
+	if resp.Exp > 0 {
+		exp := time.Unix(resp.Exp, 0)
+		secret.ExpiresAt = &exp
+	}
+	return secret, nil
+}
+
+// rotateAWSIAM creates a new access key for the IAM user whose key the
+// level holds. The level's token is the secret access key; its key ID is
+// the additional field injected as AWS_ACCESS_KEY_ID, or named accessKeyId.
+// The old key is deleted by the secret's Commit, once the new one is
+// stored; Abandon deletes the new one instead, since it counts against the
+// user's limit of two keys.
+func rotateAWSIAM(ctx context.Context, r *store.Rotation, req Request) (*Secret, error) {
+	field := accessKeyField(req.Access)
+	if field == nil || field.Value == "" || req.Token == "" {
+		return nil, errors.New("aws_iam rotation needs the secret access key as the token and the key ID in a field injected as AWS_ACCESS_KEY_ID")
+	}
+	oldID, oldSecret := field.Value, req.Token
+
+	body, err := iamCall(ctx, r.Endpoint, url.Values{"Action": {"CreateAccessKey"}}, oldID, oldSecret)
+	if err != nil {
+		return nil, fmt.Errorf("aws_iam: CreateAccessKey: %w", err)
+	}
+	var resp struct {
+		AccessKey struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+		} `xml:"CreateAccessKeyResult>AccessKey"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("aws_iam: parse CreateAccessKey response: %w", err)
+	}
+	key := resp.AccessKey
+	if key.AccessKeyID == "" || key.SecretAccessKey == "" {
+		return nil, errors.New("aws_iam: no access key in CreateAccessKey response")
+	}
+
+	// Both deletes are signed with the old key, which is still valid and,
+	// unlike a new key, already usable everywhere
+	deleteKey := func(ctx context.Context, id, which string) error {
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		if _, err := iamCall(ctx, r.Endpoint, url.Values{"Action": {"DeleteAccessKey"}, "AccessKeyId": {id}}, oldID, oldSecret); err != nil {
+			return fmt.Errorf("%s access key %s was not deleted: %w", which, id, err)
+		}
+		return nil
+	}
+	return &Secret{
+		Token:   key.SecretAccessKey,
+		Fields:  map[string]string{field.Name: key.AccessKeyID},
+		Commit:  func(ctx context.Context) error { return deleteKey(ctx, oldID, "old") },
+		Abandon: func(ctx context.Context) error { return deleteKey(ctx, key.AccessKeyID, "new") },
+	}, nil
+}
+
+// accessKeyField returns level's additional field holding the access key
+// ID, or nil.
+func accessKeyField(level *store.AccessLevel) *store.AdditionalField {
+	if level == nil {
+		return nil
+	}
+	for i, f := range level.AdditionalFields {
+		if f.EnvVar == "AWS_ACCESS_KEY_ID" || f.Name == accessKeyIDField {
+			return &level.AdditionalFields[i]
+		}
+	}
+	return nil
+}
+
+// iamCall calls the IAM query API, signed with the given key. IAM is
+// global; its requests are signed for us-east-1.
+func iamCall(ctx context.Context, endpoint string, form url.Values, keyID, secretKey string) ([]byte, error) {
+	endpoint = strings.TrimRight(firstNonEmpty(endpoint, "https://iam.amazonaws.com"), "/")
+	form.Set("Version", "2010-05-08")
+	payload := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sum := sha256.Sum256([]byte(payload))
+	sigv4.Sign(req, hex.EncodeToString(sum[:]), "us-east-1", "iam", keyID, secretKey, time.Now())
+	return send(req)
+}
+
+// postForm POSTs form to u and returns the body of a 2xx response.
+func postForm(ctx context.Context, u string, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return send(req)
+}
+
+// send sends req and returns the body of a 2xx response, or an error
+// quoting the start of any other.
+func send(req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		if len(body) > 200 {
+			body = body[:200]
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// expiresIn returns the time seconds from now, or nil for 0.
+func expiresIn(seconds int) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := time.Now().Add(time.Duration(seconds) * time.Second)
+	return &t
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
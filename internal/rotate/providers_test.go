@@ -0,0 +1,160 @@
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+)
+
+func TestProviderFor(t *testing.T) {
+	if got := ProviderFor("aws_access_key"); got != ProviderAWSIAM {
+		t.Errorf("ProviderFor(aws_access_key) = %q", got)
+	}
+	if got := ProviderFor("pat"); got != "" {
+		t.Errorf("ProviderFor(pat) = %q, want none", got)
+	}
+}
+
+func TestMint_GitHub(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/login/oauth/access_token" || r.Form.Get("client_secret") != "app-secret" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Form.Get("refresh_token") != "ghr_old" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_refresh_token", "error_description": "The refresh token passed is incorrect or expired."})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "ghu_new", "expires_in": 28800, "refresh_token": "ghr_new"})
+	}))
+	defer srv.Close()
+
+	r := &store.Rotation{Provider: ProviderGitHub, ClientID: "Iv1.abc", ClientSecret: "app-secret", Endpoint: srv.URL}
+	secret, err := Mint(context.Background(), r, Request{Level: "read", Token: "ghu_old", RefreshToken: "ghr_old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Token != "ghu_new" || secret.RefreshToken != "ghr_new" {
+		t.Errorf("secret = %+v", secret)
+	}
+	if secret.ExpiresAt == nil || time.Until(*secret.ExpiresAt) < 7*time.Hour {
+		t.Errorf("expiresAt = %v, want about 8h from now", secret.ExpiresAt)
+	}
+
+	_, err = Mint(context.Background(), r, Request{Level: "read", Token: "ghu_old", RefreshToken: "ghr_stale"})
+	if err == nil || !strings.Contains(err.Error(), "bad_refresh_token") {
+		t.Errorf("stale refresh token: err = %v", err)
+	}
+}
+
+func TestMint_Slack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("refresh_token") != "xoxe-1-old" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "invalid_refresh_token"})
+			return
+		}
+		switch r.URL.Path {
+		case "/oauth.v2.access":
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "access_token": "xoxe.xoxb-new", "refresh_token": "xoxe-1-new", "expires_in": 43200})
+		case "/tooling.tokens.rotate":
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "token": "xoxe.xoxp-new", "refresh_token": "xoxe-1-new", "exp": time.Now().Add(12 * time.Hour).Unix()})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	req := Request{Level: "read", Token: "xoxe.xoxb-old", RefreshToken: "xoxe-1-old"}
+	for _, tc := range []struct {
+		name     string
+		rotation *store.Rotation
+		token    string
+	}{
+		{"bot token", &store.Rotation{Provider: ProviderSlack, ClientID: "123.456", ClientSecret: "app-secret", Endpoint: srv.URL}, "xoxe.xoxb-new"},
+		{"configuration token", &store.Rotation{Provider: ProviderSlack, Endpoint: srv.URL}, "xoxe.xoxp-new"},
+	} {
+		secret, err := Mint(context.Background(), tc.rotation, req)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if secret.Token != tc.token || secret.RefreshToken != "xoxe-1-new" || secret.ExpiresAt == nil {
+			t.Errorf("%s: secret = %+v", tc.name, secret)
+		}
+	}
+
+	req.RefreshToken = "xoxe-1-stale"
+	_, err := Mint(context.Background(), &store.Rotation{Provider: ProviderSlack, Endpoint: srv.URL}, req)
+	if err == nil || !strings.Contains(err.Error(), "invalid_refresh_token") {
+		t.Errorf("stale refresh token: err = %v", err)
+	}
+}
+
+func TestMint_AWSIAM(t *testing.T) {
+	var deleted string
+	failDelete := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIAOLD/") || !strings.Contains(r.Header.Get("Authorization"), "/us-east-1/iam/") {
+			http.Error(w, "<ErrorResponse><Error><Code>InvalidClientTokenId</Code></Error></ErrorResponse>", http.StatusForbidden)
+			return
+		}
+		switch r.Form.Get("Action") {
+		case "CreateAccessKey":
+			fmt.Fprint(w, `<CreateAccessKeyResponse><CreateAccessKeyResult><AccessKey>
+				<UserName>ocm</UserName><AccessKeyId>AKIANEW</AccessKeyId><Status>Active</Status><SecretAccessKey>new-secret</SecretAccessKey>
+			</AccessKey></CreateAccessKeyResult></CreateAccessKeyResponse>`)
+		case "DeleteAccessKey":
+			if failDelete {
+				http.Error(w, "<ErrorResponse><Error><Code>AccessDenied</Code></Error></ErrorResponse>", http.StatusForbidden)
+				return
+			}
+			deleted = r.Form.Get("AccessKeyId")
+			fmt.Fprint(w, `<DeleteAccessKeyResponse/>`)
+		}
+	}))
+	defer srv.Close()
+
+	r := &store.Rotation{Provider: ProviderAWSIAM, Endpoint: srv.URL}
+	level := &store.AccessLevel{
+		EnvVar: "AWS_SECRET_ACCESS_KEY", Token: "old-secret",
+		AdditionalFields: []store.AdditionalField{{Name: "keyId", EnvVar: "AWS_ACCESS_KEY_ID", Value: "AKIAOLD"}},
+	}
+	req := Request{Level: "read", Token: level.Token, Access: level}
+	secret, err := Mint(context.Background(), r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Token != "new-secret" || secret.Fields["keyId"] != "AKIANEW" || secret.Warning != "" {
+		t.Errorf("secret = %+v", secret)
+	}
+
+	// Nothing is deleted until the new key is stored, or given up on
+	if deleted != "" {
+		t.Errorf("deleted %q while minting", deleted)
+	}
+	if err := secret.Commit(context.Background()); err != nil || deleted != "AKIAOLD" {
+		t.Errorf("Commit = %v, deleted %q, want the old key", err, deleted)
+	}
+	if err := secret.Abandon(context.Background()); err != nil || deleted != "AKIANEW" {
+		t.Errorf("Abandon = %v, deleted %q, want the new key", err, deleted)
+	}
+
+	failDelete = true
+	if err := secret.Commit(context.Background()); err == nil || !strings.Contains(err.Error(), "AKIAOLD") {
+		t.Errorf("Commit = %v, want an error naming the old key", err)
+	}
+
+	if _, err := Mint(context.Background(), r, Request{Level: "read", Token: "old-secret", Access: &store.AccessLevel{}}); err == nil {
+		t.Error("rotated without an access key ID")
+	}
+}
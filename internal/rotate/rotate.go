@@ -0,0 +1,200 @@
+// Package rotate mints replacement secrets for credentials through
+// admin-configured hooks, a command OCM runs or a webhook it calls, or
+// through built-in rotators for GitHub, AWS IAM and Slack. OCM stores what
+// it gets, re-injects it and archives the old value.
+package rotate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+// commandTimeout bounds a rotation command or webhook call.
+const commandTimeout = time.Minute
+
+// maxOutput bounds what is read from a command's stdout and stderr.
+const maxOutput = 64 << 10
+
+// MinInterval is the shortest scheduled rotation interval accepted.
+const MinInterval = time.Hour
+
+// commandsAllowed gates command hooks: anyone with admin API access could
+// otherwise run commands on the host. Serve enables it with a flag.
+var commandsAllowed atomic.Bool
+
+// AllowCommands enables or disables command hooks.
+func AllowCommands(allow bool) {
+	commandsAllowed.Store(allow)
+}
+
+// Request is what a hook is given: the access level being rotated, with
+// its current secret so the hook can use it to mint the next one or revoke
+// it. It is a command's stdin and a webhook's body.
+type Request struct {
+	Service      string   `json:"service"`
+	Type         string   `json:"type"`
+	Level        string   `json:"level"` // read or write
+	Token        string   `json:"token,omitempty"`
+	RefreshToken string   `json:"refreshToken,omitempty"`
+	Fields       []string `json:"fields,omitempty"` // Names of the level's additional fields
+
+	Access *store.AccessLevel `json:"-"` // The level itself, for built-in rotators
+}
+
+// Secret is a hook's answer. A command may instead print just the new
+// token.
+type Secret struct {
+	Token        string            `json:"token"`
+	RefreshToken string            `json:"refreshToken,omitempty"` // Replaces the stored one if set
+	ExpiresAt    *time.Time        `json:"expiresAt,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`  // New additional field values, by name
+	Warning      string            `json:"warning,omitempty"` // Minted, but something needs the admin's attention
+
+	// Set by built-in rotators whose old secret stays valid until revoked:
+	// Commit revokes the old secret once the new one is stored, and Abandon
+	// revokes the new one if it couldn't be, so a failed save never loses
+	// the only working secret
+	Commit  func(ctx context.Context) error `json:"-"`
+	Abandon func(ctx context.Context) error `json:"-"`
+}
+
+// Validate checks that r names exactly one hook or built-in rotator that
+// can be run.
+func Validate(r *store.Rotation) error {
+	if r == nil {
+		return nil
+	}
+	hooks := 0
+	for _, set := range []bool{len(r.Command) > 0, r.URL != "", r.Provider != ""} {
+		if set {
+			hooks++
+		}
+	}
+	switch {
+	case hooks > 1:
+		return errors.New("rotation takes one of a command, a url or a provider")
+	case r.Provider != "":
+		if err := validateProvider(r); err != nil {
+			return err
+		}
+	case len(r.Command) > 0:
+		if !commandsAllowed.Load() {
+			return errors.New("rotation commands are disabled (start OCM with --allow-rotation-commands)")
+		}
+		if r.Command[0] == "" {
+			return errors.New("rotation command is empty")
+		}
+	case r.URL != "":
+		if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid rotation url %q", r.URL)
+		}
+	default:
+		return errors.New("rotation needs a command, a url or a provider")
+	}
+	if r.Interval != 0 && r.Interval < MinInterval {
+		return fmt.Errorf("rotation interval must be at least %s", MinInterval)
+	}
+	return nil
+}
+
+// Mint asks r's hook or built-in rotator for a replacement for the secret
+// in req.
+func Mint(ctx context.Context, r *store.Rotation, req Request) (*Secret, error) {
+	if err := Validate(r); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var secret *Secret
+	var err error
+	switch {
+	case r.Provider != "":
+		secret, err = mintBuiltin(ctx, r, req)
+	case len(r.Command) > 0:
+		secret, err = runCommand(ctx, r.Command, req)
+	default:
+		secret = &Secret{}
+		if err = webhook.Call(ctx, nil, r.URL, r.Secret, req, secret); err != nil {
+			err = fmt.Errorf("rotation webhook: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret.Token == "" {
+		return nil, errors.New("rotation returned no token")
+	}
+	redact.Register(secret.Token, secret.RefreshToken)
+	for _, v := range secret.Fields {
+		redact.Register(v)
+	}
+	return secret, nil
+}
+
+// runCommand runs argv with req as JSON on stdin and OCM_SERVICE and
+// OCM_LEVEL in its environment. Its stdout is a JSON Secret, or the bare
+// token.
+func runCommand(ctx context.Context, argv []string, req Request) (*Secret, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), "OCM_SERVICE="+req.Service, "OCM_LEVEL="+req.Level)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout, stderr := &limitedBuffer{max: maxOutput}, &limitedBuffer{max: maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("rotation command: %w: %s", err, redact.String(msg))
+		}
+		return nil, fmt.Errorf("rotation command: %w", err)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if bytes.HasPrefix(out, []byte("{")) {
+		var secret Secret
+		if err := json.Unmarshal(out, &secret); err != nil {
+			return nil, fmt.Errorf("rotation command output: %w", err)
+		}
+		return &secret, nil
+	}
+	return &Secret{Token: string(out)}, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and drops the
+// rest, so a chatty command can't exhaust memory.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
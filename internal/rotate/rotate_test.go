@@ -0,0 +1,101 @@
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openclaw/ocm/internal/store"
+	"github.com/openclaw/ocm/internal/webhook"
+)
+
+func TestMint_Command(t *testing.T) {
+	req := Request{Service: "github", Level: "read", Token: "old-token"}
+
+	AllowCommands(false)
+	if _, err := Mint(context.Background(), &store.Rotation{Command: []string{"echo", "new"}}, req); err == nil {
+		t.Fatal("ran a command while commands are disabled")
+	}
+
+	AllowCommands(true)
+	t.Cleanup(func() { AllowCommands(false) })
+	for _, tc := range []struct {
+		name, script, token, field string
+	}{
+		{"bare token", `grep -q '"token":"old-token"' && echo "new-$OCM_SERVICE-$OCM_LEVEL"`, "new-github-read", ""},
+		{"json", `cat >/dev/null; echo '{"token":"new","fields":{"cookie":"c2"}}'`, "new", "c2"},
+	} {
+		secret, err := Mint(context.Background(), &store.Rotation{Command: []string{"sh", "-c", tc.script}}, req)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if secret.Token != tc.token || secret.Fields["cookie"] != tc.field {
+			t.Errorf("%s: secret = %+v", tc.name, secret)
+		}
+	}
+
+	_, err := Mint(context.Background(), &store.Rotation{Command: []string{"sh", "-c", "echo revoked old-token >&2; exit 3"}}, req)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("failing command: err = %v", err)
+	}
+}
+
+func TestMint_Webhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get(webhook.SignatureHeader) == "" || req.Token != "old-token" {
+			http.Error(w, "unsigned", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(Secret{Token: "new-" + req.Level})
+	}))
+	defer srv.Close()
+
+	hook := &store.Rotation{URL: srv.URL, Secret: "hook-secret"}
+	secret, err := Mint(context.Background(), hook, Request{Service: "github", Level: "write", Token: "old-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Token != "new-write" {
+		t.Errorf("token = %q, want new-write", secret.Token)
+	}
+
+	hook.Secret = ""
+	if _, err := Mint(context.Background(), hook, Request{Token: "old-token"}); err == nil {
+		t.Error("accepted a 401")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, r := range []*store.Rotation{
+		{},
+		{URL: "ftp://host/rotate"},
+		{URL: "https://host/rotate", Command: []string{"rotate"}},
+		{URL: "https://host/rotate", Interval: MinInterval / 2},
+		{URL: "https://host/rotate", Provider: ProviderAWSIAM},
+		{Provider: "vault"},
+		{Provider: ProviderGitHub, ClientID: "Iv1.abc"},
+		{Provider: ProviderSlack, ClientSecret: "s"},
+		{Provider: ProviderAWSIAM, Endpoint: "iam.internal"},
+	} {
+		if err := Validate(r); err == nil {
+			t.Errorf("Validate(%+v) accepted", r)
+		}
+	}
+	for _, r := range []*store.Rotation{
+		{URL: "https://host/rotate", Interval: 30 * 24 * MinInterval},
+		{Provider: ProviderGitHub, ClientID: "Iv1.abc", ClientSecret: "s"},
+		{Provider: ProviderSlack},
+		{Provider: ProviderAWSIAM, Interval: 90 * 24 * time.Hour},
+	} {
+		if err := Validate(r); err != nil {
+			t.Errorf("Validate(%+v): %v", r, err)
+		}
+	}
+}
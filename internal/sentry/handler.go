@@ -0,0 +1,82 @@
+package sentry
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler wraps a slog.Handler, reporting every record at error or above to
+// the default reporter as well as writing it to inner. Wrap it in a
+// redact.Handler so what it reports is already scrubbed.
+type Handler struct {
+	inner slog.Handler
+	attrs []slog.Attr // From WithAttrs, keys qualified by group
+	group string      // Qualifies later keys, e.g., "gateway."
+}
+
+// NewHandler returns a Handler writing to inner.
+func NewHandler(inner slog.Handler) *Handler {
+	return &Handler{inner: inner}
+}
+
+// Enabled implements slog.Handler. Errors are reported even if inner would
+// drop them.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level) || (level >= slog.LevelError && Enabled())
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if rep := defaultReporter.Load(); rep != nil && r.Level >= slog.LevelError {
+		rep.enqueue(h.event(ctx, rep, r))
+	}
+	if !h.inner.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+// event converts r to an event: the message is the title and groups
+// events, an "error" attribute is the exception, and the other attributes
+// are extra data.
+func (h *Handler) event(ctx context.Context, rep *Reporter, r slog.Record) *Event {
+	var kv []interface{}
+	errValue := ""
+	for _, a := range h.attrs {
+		kv = append(kv, a.Key, a.Value.Resolve().Any())
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "error" && h.group == "" {
+			errValue = a.Value.String()
+		} else {
+			kv = append(kv, h.group+a.Key, a.Value.Resolve().Any())
+		}
+		return true
+	})
+
+	value := r.Message
+	if errValue != "" {
+		value += ": " + errValue
+	}
+	var stack *stacktrace
+	if r.PC != 0 {
+		stack = stackOf([]uintptr{r.PC})
+	}
+	e := rep.newEvent(ctx, "error", r.Message, "error", value, stack)
+	addExtra(e, kv)
+	return e
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	all := append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		all = append(all, slog.Attr{Key: h.group + a.Key, Value: a.Value})
+	}
+	return &Handler{inner: h.inner.WithAttrs(attrs), attrs: all, group: h.group}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), attrs: h.attrs, group: h.group + name + "."}
+}
@@ -0,0 +1,409 @@
+// Package sentry reports errors and panics to Sentry, or anything that
+// speaks its protocol such as GlitchTip. Every event is scrubbed of secrets
+// before it leaves the process, as log lines are.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openclaw/ocm/internal/redact"
+	"github.com/openclaw/ocm/internal/tracing"
+)
+
+const (
+	// queueSize events may wait to be sent before new ones are dropped
+	queueSize = 256
+	// repeatWindow is how long an event with the same fingerprint is
+	// suppressed after being sent, so a failure that repeats every few
+	// seconds doesn't exhaust the project's quota
+	repeatWindow = 5 * time.Minute
+)
+
+// Config configures the reporter.
+type Config struct {
+	// DSN is the project's client key URL,
+	// https://<key>@<host>/<project id>
+	DSN string
+	// Environment tags every event, e.g., production
+	Environment string
+	// Release tags every event, e.g., ocm@1.4.0
+	Release string
+}
+
+// Reporter sends events to a Sentry project in the background. Events are
+// dropped, not queued without bound, if the server falls behind.
+type Reporter struct {
+	url         string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+	logger      *slog.Logger
+
+	queue   chan *Event
+	flushes chan chan struct{}
+	dropped sync.Once
+
+	mu   sync.Mutex
+	sent map[string]time.Time // By fingerprint
+}
+
+// New creates a reporter for cfg.DSN. Call Run to start sending.
+func New(cfg Config, logger *slog.Logger) (*Reporter, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	u, err := url.Parse(cfg.DSN)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sentry DSN must be http(s)://<key>@<host>/<project id>")
+	}
+	prefix, project := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("sentry DSN has no project id")
+	}
+	hostname, _ := os.Hostname()
+	return &Reporter{
+		url:         fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=ocm, sentry_key=%s", u.User.Username()),
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		logger:      logger,
+		queue:       make(chan *Event, queueSize),
+		flushes:     make(chan chan struct{}),
+		sent:        make(map[string]time.Time),
+	}, nil
+}
+
+var defaultReporter atomic.Pointer[Reporter]
+
+// SetDefault installs r as the reporter used by the package functions; nil
+// turns reporting off.
+func SetDefault(r *Reporter) {
+	defaultReporter.Store(r)
+}
+
+// Enabled reports whether a default reporter is installed.
+func Enabled() bool {
+	return defaultReporter.Load() != nil
+}
+
+// Event is a Sentry event (https://develop.sentry.dev/sdk/event-payloads/).
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"` // error or fatal
+	Logger      string                 `json:"logger,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Exception   *exceptions            `json:"exception,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"` // Oldest call first
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// CaptureError reports err, described by msg, with extra context given as
+// alternating keys and values, as with slog. It does nothing without a
+// default reporter.
+func CaptureError(ctx context.Context, msg string, err error, kv ...interface{}) {
+	r := defaultReporter.Load()
+	if r == nil {
+		return
+	}
+	value := msg
+	if err != nil {
+		value += ": " + err.Error()
+	}
+	e := r.newEvent(ctx, "error", msg, "error", value, callers())
+	addExtra(e, kv)
+	r.enqueue(e)
+}
+
+// CapturePanic reports a recovered panic, with extra context given as
+// alternating keys and values. Call it from the deferred function that
+// recovered, so the stack trace shows where the panic happened.
+func CapturePanic(ctx context.Context, recovered interface{}, kv ...interface{}) {
+	r := defaultReporter.Load()
+	if r == nil {
+		return
+	}
+	value := fmt.Sprint(recovered)
+	e := r.newEvent(ctx, "fatal", "panic: "+value, "panic", value, callers())
+	e.Fingerprint = []string{"panic", topFrame(e)}
+	addExtra(e, kv)
+	r.enqueue(e)
+}
+
+// Recover reports a panic in a background goroutine, waits briefly for the
+// report to be sent, then panics again so the process still crashes rather
+// than carrying on in an unknown state. Use it as `defer sentry.Recover()`.
+func Recover() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if r := defaultReporter.Load(); r != nil {
+		value := fmt.Sprint(recovered)
+		e := r.newEvent(context.Background(), "fatal", "panic: "+value, "panic", value, callers())
+		e.Fingerprint = []string{"panic", topFrame(e)}
+		r.enqueue(e)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		r.Flush(ctx)
+		cancel()
+	}
+	panic(recovered)
+}
+
+// newEvent builds a scrubbed event.
+func (r *Reporter) newEvent(ctx context.Context, level, msg, typ, value string, stack *stacktrace) *Event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	e := &Event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC(),
+		Platform:    "go",
+		Level:       level,
+		Logger:      "ocm",
+		ServerName:  r.serverName,
+		Release:     r.release,
+		Environment: r.environment,
+		Message:     redact.String(msg),
+		Exception: &exceptions{Values: []exception{{
+			Type:       typ,
+			Value:      redact.String(value),
+			Stacktrace: stack,
+		}}},
+		Tags: map[string]string{},
+	}
+	e.Fingerprint = []string{e.Message}
+	if id := tracing.TraceID(ctx); id != "" {
+		e.Tags["trace_id"] = id
+	}
+	return e
+}
+
+// addExtra adds scrubbed key-value pairs to e's extra data.
+func addExtra(e *Event, kv []interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		a := redact.Attr(slog.Any(key, kv[i+1]))
+		if e.Extra == nil {
+			e.Extra = make(map[string]interface{})
+		}
+		e.Extra[key] = a.Value.String()
+	}
+}
+
+// callers returns the stack of whoever called the function calling callers.
+func callers() *stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs) // Skip Callers, callers and its caller
+	return stackOf(pcs[:n])
+}
+
+// stackOf converts program counters, innermost first, to a stack trace.
+func stackOf(pcs []uintptr) *stacktrace {
+	frames := runtime.CallersFrames(pcs)
+	var out []frame
+	for {
+		f, more := frames.Next()
+		module, function := splitFunction(f.Function)
+		out = append(out, frame{
+			Function: function,
+			Module:   module,
+			Filename: trimGoPath(f.File),
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, "github.com/openclaw/ocm/"),
+		})
+		if !more {
+			break
+		}
+	}
+	// Sentry lists the oldest call first
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return &stacktrace{Frames: out}
+}
+
+// splitFunction splits "github.com/x/y/pkg.(*T).M" into the package path and
+// "(*T).M".
+func splitFunction(fn string) (module, function string) {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return "", fn
+	}
+	return fn[:slash+1+dot], fn[slash+1+dot+1:]
+}
+
+// trimGoPath shortens a source path to start at the module or package path.
+func trimGoPath(file string) string {
+	if i := strings.Index(file, "github.com/"); i >= 0 {
+		return file[i:]
+	}
+	if i := strings.LastIndex(file, "/src/"); i >= 0 {
+		return file[i+len("/src/"):]
+	}
+	return file
+}
+
+// topFrame names the innermost in-app frame, where a panic happened.
+func topFrame(e *Event) string {
+	frames := e.Exception.Values[0].Stacktrace.Frames
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].InApp {
+			return frames[i].Module + "." + frames[i].Function
+		}
+	}
+	return ""
+}
+
+func (r *Reporter) enqueue(e *Event) {
+	key := strings.Join(e.Fingerprint, "\xff")
+	now := time.Now()
+	r.mu.Lock()
+	if last, ok := r.sent[key]; ok && now.Sub(last) < repeatWindow {
+		r.mu.Unlock()
+		return
+	}
+	r.sent[key] = now
+	r.mu.Unlock()
+
+	select {
+	case r.queue <- e:
+	default:
+		r.dropped.Do(func() { r.logger.Warn("error reporting falling behind, dropping events") })
+	}
+}
+
+// Run sends events until ctx is cancelled, then sends what is left.
+func (r *Reporter) Run(ctx context.Context) {
+	drain := func() {
+		for {
+			select {
+			case e := <-r.queue:
+				r.sendLogged(e)
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			drain()
+			return
+		case done := <-r.flushes:
+			drain()
+			close(done)
+		case e := <-r.queue:
+			r.sendLogged(e)
+		}
+	}
+}
+
+// Flush sends every event captured so far and waits for it, or for ctx.
+// It needs Run to be running.
+func (r *Reporter) Flush(ctx context.Context) {
+	done := make(chan struct{})
+	select {
+	case r.flushes <- done:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// sendLogged sends e, logging a failure at warn: an error would be
+// reported, and fail to send, again.
+func (r *Reporter) sendLogged(e *Event) {
+	if err := r.send(e); err != nil {
+		r.logger.Warn("error report failed", "event_id", e.EventID, "error", err)
+	}
+}
+
+// send posts e as an envelope
+// (https://develop.sentry.dev/sdk/envelopes/).
+func (r *Reporter) send(e *Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]string{"event_id": e.EventID, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, r.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned %s", resp.Status)
+	}
+	return nil
+}
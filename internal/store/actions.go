@@ -0,0 +1,113 @@
+package store
+
+import "fmt"
+
+// AuditAction is the kind of event an AuditEntry records. Only the actions
+// below may be written, so a typo can't fragment the history.
+type AuditAction string
+
+// Credential and elevation actions.
+const (
+	ActionCredentialAccess  AuditAction = "credential_access"
+	ActionCredentialCreated AuditAction = "credential_created"
+	ActionCredentialUpdated AuditAction = "credential_updated"
+	ActionCredentialDeleted AuditAction = "credential_deleted"
+	ActionCredentialRotated AuditAction = "credential_rotated"
+	ActionRotationFailed    AuditAction = "credential_rotation_failed"
+	ActionCredentialInvalid AuditAction = "credential_invalid"
+	ActionCredentialLeaked  AuditAction = "credential_leaked"
+	ActionPresetSaved       AuditAction = "preset_saved"
+	ActionPresetDeleted     AuditAction = "preset_deleted"
+
+	ActionElevationRequested AuditAction = "elevation_requested"
+	ActionElevationQueued    AuditAction = "elevation_queued"
+	ActionElevationDequeued  AuditAction = "elevation_dequeued"
+	ActionElevationRejected  AuditAction = "elevation_rejected" // Refused by a concurrency limit
+	ActionElevationRouted    AuditAction = "elevation_routed"
+	ActionElevationEscalated AuditAction = "elevation_escalated"
+	ActionElevationApproved  AuditAction = "elevation_approved"
+	ActionElevationDenied    AuditAction = "elevation_denied"
+	ActionElevationRevoked   AuditAction = "elevation_revoked"
+	ActionElevationExpired   AuditAction = "elevation_expired"
+
+	ActionGuestInviteCreated AuditAction = "guest_invite_created"
+	ActionGuestInviteUsed    AuditAction = "guest_invite_used"
+
+	ActionLeaseRevoked AuditAction = "lease_revoked"
+
+	ActionCredentialCheckedOut AuditAction = "credential_checked_out"
+	ActionCredentialCheckedIn  AuditAction = "credential_checked_in"
+
+	ActionInjectionNotLoaded     AuditAction = "injection_not_loaded"
+	ActionInjectionDriftRepaired AuditAction = "injection_drift_repaired"
+	ActionGatewayOpFailed        AuditAction = "gateway_op_failed" // A queued Gateway change given up on
+)
+
+// Administrative actions.
+const (
+	ActionSetupCompleted       AuditAction = "setup_completed"
+	ActionSetupReset           AuditAction = "setup_reset"
+	ActionNotificationsUpdated AuditAction = "notifications_updated"
+	ActionNotificationsTested  AuditAction = "notifications_tested"
+	ActionRoutingUpdated       AuditAction = "routing_updated"
+	ActionWebhookCreated       AuditAction = "webhook_created"
+	ActionWebhookUpdated       AuditAction = "webhook_updated"
+	ActionWebhookDeleted       AuditAction = "webhook_deleted"
+	ActionLogLevelChanged      AuditAction = "log_level_changed"
+
+	ActionDevicePairRequested AuditAction = "device_pair_requested"
+	ActionDeviceApproved      AuditAction = "device_approved"
+	ActionDeviceRejected      AuditAction = "device_rejected"
+
+	ActionAuditDeviceUpdated AuditAction = "audit_device_updated"
+	ActionAuditDeviceRemoved AuditAction = "audit_device_removed"
+	ActionAuditExported      AuditAction = "audit_exported"
+	ActionAuditPruned        AuditAction = "audit_pruned"
+)
+
+// auditActions is the catalog of valid actions, in display order.
+var auditActions = []AuditAction{
+	ActionCredentialAccess, ActionCredentialCreated, ActionCredentialUpdated, ActionCredentialDeleted,
+	ActionCredentialRotated, ActionRotationFailed, ActionCredentialInvalid, ActionCredentialLeaked,
+	ActionPresetSaved, ActionPresetDeleted,
+	ActionElevationRequested, ActionElevationQueued, ActionElevationDequeued, ActionElevationRejected,
+	ActionElevationRouted, ActionElevationEscalated, ActionElevationApproved, ActionElevationDenied,
+	ActionElevationRevoked, ActionElevationExpired,
+	ActionGuestInviteCreated, ActionGuestInviteUsed, ActionLeaseRevoked,
+	ActionCredentialCheckedOut, ActionCredentialCheckedIn,
+	ActionInjectionNotLoaded, ActionInjectionDriftRepaired, ActionGatewayOpFailed,
+	ActionSetupCompleted, ActionSetupReset, ActionNotificationsUpdated, ActionNotificationsTested, ActionRoutingUpdated,
+	ActionWebhookCreated, ActionWebhookUpdated, ActionWebhookDeleted, ActionLogLevelChanged,
+	ActionDevicePairRequested, ActionDeviceApproved, ActionDeviceRejected,
+	ActionAuditDeviceUpdated, ActionAuditDeviceRemoved, ActionAuditExported, ActionAuditPruned,
+}
+
+var validAuditActions = func() map[AuditAction]bool {
+	m := make(map[AuditAction]bool, len(auditActions))
+	for _, a := range auditActions {
+		m[a] = true
+	}
+	return m
+}()
+
+// AuditActions returns every valid audit action.
+func AuditActions() []AuditAction {
+	return append([]AuditAction(nil), auditActions...)
+}
+
+// Valid reports whether a is in the catalog.
+func (a AuditAction) Valid() bool {
+	return validAuditActions[a]
+}
+
+// ErrUnknownAuditAction is returned when writing or filtering by an action
+// that isn't in the catalog.
+var ErrUnknownAuditAction = fmt.Errorf("unknown audit action")
+
+// ParseAuditAction returns s as an AuditAction if it is in the catalog.
+func ParseAuditAction(s string) (AuditAction, error) {
+	if a := AuditAction(s); a.Valid() {
+		return a, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownAuditAction, s)
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timestamps are stored as text in the zone they were written in, which for
+// entries stamped with time.Now() is the local one. Bounds are converted to
+// it so that they compare correctly however the caller built them.
+
+// auditColumns are the audit_log columns read by scanAuditEntry.
+const auditColumns = `id, timestamp, action, service, scope, details, actor,
+	request_id, source_ip, user_agent, elevation_id`
+
+func scanAuditEntry(row rowScanner) (*AuditEntry, error) {
+	var e AuditEntry
+	if err := row.Scan(&e.ID, &e.Timestamp, &e.Action, &e.Service, &e.Scope, &e.Details, &e.Actor,
+		&e.RequestID, &e.SourceIP, &e.UserAgent, &e.ElevationID); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ErrInvalidCursor is returned by QueryAuditEntries for a malformed cursor.
+var ErrInvalidCursor = fmt.Errorf("invalid audit cursor")
+
+// Limits on the number of entries QueryAuditEntries returns.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditQuery filters and pages QueryAuditEntries. Zero fields don't filter.
+type AuditQuery struct {
+	Service string
+	Action  AuditAction
+	Actor   string
+	From    time.Time // Inclusive
+	To      time.Time // Exclusive
+	Cursor  string    // From a previous page; continues after its last entry
+	Limit   int       // DefaultAuditLimit if zero, capped at MaxAuditLimit
+}
+
+// QueryAuditEntries returns audit entries matching q, newest first, and the
+// cursor of the next page ("" if this is the last one).
+func (s *Store) QueryAuditEntries(q AuditQuery) ([]*AuditEntry, string, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	if limit > MaxAuditLimit {
+		limit = MaxAuditLimit
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Service != "" {
+		where = append(where, "service = ?")
+		args = append(args, q.Service)
+	}
+	if q.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, q.Action)
+	}
+	if q.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, q.Actor)
+	}
+	if !q.From.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, q.From.Local())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "timestamp < ?")
+		args = append(args, q.To.Local())
+	}
+	if q.Cursor != "" {
+		ts, id, err := decodeAuditCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, "(timestamp < ? OR (timestamp = ? AND id < ?))")
+		args = append(args, ts, ts, id)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	// One extra row tells whether there is another page
+	query += ` ORDER BY timestamp DESC, id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		next = encodeAuditCursor(last.Timestamp, last.ID)
+	}
+	return entries, next, nil
+}
+
+// encodeAuditCursor returns an opaque cursor for the entry with the given
+// timestamp and ID. The timestamp keeps its offset so that it compares equal
+// to the stored value.
+func encodeAuditCursor(ts time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ts.Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	tsPart, id, ok := strings.Cut(string(data), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339Nano, tsPart)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return ts, id, nil
+}
+
+// DeleteAuditEntriesBefore deletes audit entries older than cutoff and
+// returns how many were removed.
+func (s *Store) DeleteAuditEntriesBefore(cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`DELETE FROM audit_log WHERE timestamp < ?`, cutoff.Local())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// OldestAuditTimestamp returns the timestamp of the oldest audit entry, or
+// false if there are none.
+func (s *Store) OldestAuditTimestamp() (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ts time.Time
+	err := s.db.QueryRow(`SELECT timestamp FROM audit_log ORDER BY timestamp LIMIT 1`).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return ts, true, nil
+}
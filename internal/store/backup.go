@@ -0,0 +1,204 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrStoreNotEmpty is returned by ImportSnapshot when the store already
+// holds credentials, elevations or audit entries.
+var ErrStoreNotEmpty = errors.New("store is not empty")
+
+// Snapshot is the data ExportSnapshot reads and ImportSnapshot restores. It
+// holds decrypted secrets, so it must itself be encrypted at rest.
+type Snapshot struct {
+	CreatedAt    time.Time          `json:"createdAt"`
+	Credentials  []*Credential      `json:"credentials"` // With their tokens
+	Presets      []*ElevationPreset `json:"presets"`
+	Elevations   []*Elevation       `json:"elevations"`   // Oldest first
+	Receipts     []*ApprovalReceipt `json:"receipts"`     // Signed by the exporting instance's key
+	AuditEntries []*AuditEntry      `json:"auditEntries"` // In chain order
+}
+
+// ExportSnapshot reads every credential, preset, elevation, approval
+// receipt and audit entry. Settings, webhooks and guest invites are not
+// included.
+func (s *Store) ExportSnapshot() (*Snapshot, error) {
+	snap := &Snapshot{CreatedAt: time.Now()}
+
+	creds, err := s.listCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+	for _, c := range creds {
+		// listCredentials clears secrets; GetCredential keeps them
+		cred, err := s.GetCredential(c.Service)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s: %w", c.Service, err)
+		}
+		if cred == nil {
+			continue
+		}
+		cred.Injection = nil
+		snap.Credentials = append(snap.Credentials, cred)
+
+		presets, err := s.ListPresets(cred.Service)
+		if err != nil {
+			return nil, fmt.Errorf("list presets for %s: %w", cred.Service, err)
+		}
+		snap.Presets = append(snap.Presets, presets...)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := queryEach(s.db, `SELECT `+elevationColumns+` FROM elevations ORDER BY requested_at, id`, func(row rowScanner) error {
+		elev, err := scanElevation(row)
+		if err == nil {
+			snap.Elevations = append(snap.Elevations, elev)
+		}
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("list elevations: %w", err)
+	}
+	if err := queryEach(s.db, `SELECT elevation_id, payload, signature, public_key, created_at FROM approval_receipts ORDER BY created_at`, func(row rowScanner) error {
+		var r ApprovalReceipt
+		if err := row.Scan(&r.ElevationID, &r.Payload, &r.Signature, &r.PublicKey, &r.CreatedAt); err != nil {
+			return err
+		}
+		snap.Receipts = append(snap.Receipts, &r)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("list receipts: %w", err)
+	}
+	// Entries from before chaining have no seq and come first
+	if err := queryEach(s.db, `SELECT `+auditColumns+` FROM audit_log ORDER BY seq IS NOT NULL, seq, timestamp, id`, func(row rowScanner) error {
+		e, err := scanAuditEntry(row)
+		if err == nil {
+			snap.AuditEntries = append(snap.AuditEntries, e)
+		}
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	return snap, nil
+}
+
+// ImportSnapshot restores snap into an empty store in one transaction.
+// Credentials are re-encrypted with this store's master key and the audit
+// entries are chained afresh in their original order, so the chain verifies
+// here; their original hashes are not kept.
+func (s *Store) ImportSnapshot(snap *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	if err := s.db.QueryRow(`SELECT (SELECT COUNT(*) FROM credentials) + (SELECT COUNT(*) FROM elevations) +
+		(SELECT COUNT(*) FROM audit_log)`).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrStoreNotEmpty
+	}
+	for _, e := range snap.AuditEntries {
+		if !e.Action.Valid() {
+			return fmt.Errorf("audit entry %s: %w %q", e.ID, ErrUnknownAuditAction, e.Action)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, cred := range snap.Credentials {
+		encrypted, err := s.sealCredentialData(cred)
+		if err != nil {
+			return fmt.Errorf("credential %s: %w", cred.Service, err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO credentials (id, service, display_name, type, scopes_encrypted, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, cred.ID, cred.Service, cred.DisplayName, cred.Type, encrypted, cred.CreatedAt, cred.UpdatedAt); err != nil {
+			return fmt.Errorf("credential %s: %w", cred.Service, err)
+		}
+	}
+	for _, p := range snap.Presets {
+		if _, err := tx.Exec(`INSERT INTO elevation_presets (service, name, ttl, created_at) VALUES (?, ?, ?, ?)`,
+			p.Service, p.Name, int64(p.TTL), p.CreatedAt); err != nil {
+			return fmt.Errorf("preset %s/%s: %w", p.Service, p.Name, err)
+		}
+	}
+	for _, e := range snap.Elevations {
+		if _, err := tx.Exec(`
+			INSERT INTO elevations (id, service, scope, reason, status, requested_at, approved_at, expires_at, approved_by,
+				assigned_to, routed_at, escalated_at, requested_by, reminders_sent, requested_ttl, callback_url)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.ID, e.Service, e.Scope, e.Reason, e.Status, e.RequestedAt, nullTime(e.ApprovedAt), nullTime(e.ExpiresAt),
+			nullString(e.ApprovedBy), nullString(strings.Join(e.AssignedTo, ",")), nullTime(e.RoutedAt),
+			nullTime(e.EscalatedAt), nullString(e.RequestedBy), e.RemindersSent, int64(e.RequestedTTL), e.CallbackURL); err != nil {
+			return fmt.Errorf("elevation %s: %w", e.ID, err)
+		}
+	}
+	for _, r := range snap.Receipts {
+		if _, err := tx.Exec(`
+			INSERT INTO approval_receipts (elevation_id, payload, signature, public_key, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, r.ElevationID, r.Payload, r.Signature, r.PublicKey, r.CreatedAt); err != nil {
+			return fmt.Errorf("receipt for %s: %w", r.ElevationID, err)
+		}
+	}
+	prevHash := ""
+	for i, e := range snap.AuditEntries {
+		seq := int64(i + 1)
+		hash := s.auditHash(seq, prevHash, e)
+		if _, err := tx.Exec(`
+			INSERT INTO audit_log (id, timestamp, action, service, scope, details, actor,
+				request_id, source_ip, user_agent, elevation_id, seq, prev_hash, hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.ID, e.Timestamp, e.Action, e.Service, e.Scope, e.Details, e.Actor,
+			e.RequestID, e.SourceIP, e.UserAgent, e.ElevationID, seq, prevHash, hash); err != nil {
+			return fmt.Errorf("audit entry %s: %w", e.ID, err)
+		}
+		prevHash = hash
+	}
+	if err := s.rebuildFingerprints(tx); err != nil {
+		return fmt.Errorf("credential fingerprints: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.InvalidateCache()
+	return nil
+}
+
+// queryEach runs query and calls fn for each row.
+func queryEach(db *sql.DB, query string, fn func(row rowScanner) error) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long read-path cache entries live unless overridden.
+const DefaultCacheTTL = 5 * time.Second
+
+// readCache holds short-lived copies of decrypted credential metadata (never
+// tokens) and active-elevation lookups for the hot agent endpoints.
+// Every write path that could change a cached answer invalidates it. A
+// read that raced an invalidation isn't cached: each kind of entry has a
+// generation, bumped on invalidation, that a miss checks before filling in.
+type readCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+
+	creds        []*Credential // ListCredentials result (tokens cleared)
+	credsExpires time.Time
+	credsGen     uint64
+
+	active    map[string]activeEntry // "service:scope" -> active elevation (nil = none)
+	activeGen uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type activeEntry struct {
+	elev    *Elevation
+	expires time.Time
+}
+
+// CacheStats reports read-path cache effectiveness.
+type CacheStats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hitRate"` // 0..1
+	TTL     string  `json:"ttl"`
+}
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{ttl: ttl, active: make(map[string]activeEntry)}
+}
+
+// SetCacheTTL changes the read-path cache TTL. Zero disables caching.
+func (s *Store) SetCacheTTL(ttl time.Duration) {
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+	s.cache.ttl = ttl
+	s.cache.creds = nil
+	s.cache.credsGen++
+	s.cache.active = make(map[string]activeEntry)
+	s.cache.activeGen++
+}
+
+// InvalidateCache drops all cached credential metadata and elevation lookups.
+func (s *Store) InvalidateCache() {
+	s.invalidateCredentials()
+	s.invalidateElevations()
+}
+
+// CacheStats returns hit/miss counters for the read-path cache.
+func (s *Store) CacheStats() CacheStats {
+	hits, misses := s.cache.hits.Load(), s.cache.misses.Load()
+	stats := CacheStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	s.cache.mu.Lock()
+	stats.TTL = s.cache.ttl.String()
+	s.cache.mu.Unlock()
+	return stats
+}
+
+func (s *Store) invalidateCredentials() {
+	s.cache.mu.Lock()
+	s.cache.creds = nil
+	s.cache.credsGen++
+	s.cache.mu.Unlock()
+}
+
+func (s *Store) invalidateElevations() {
+	s.cache.mu.Lock()
+	s.cache.active = make(map[string]activeEntry)
+	s.cache.activeGen++
+	s.cache.mu.Unlock()
+}
+
+// ListCredentials returns all credentials (without decrypted tokens).
+// Results are served from the read-path cache when fresh.
+func (s *Store) ListCredentials() ([]*Credential, error) {
+	now := time.Now()
+	s.cache.mu.Lock()
+	if s.cache.ttl > 0 && s.cache.creds != nil && now.Before(s.cache.credsExpires) {
+		creds := cloneCredentials(s.cache.creds)
+		s.cache.mu.Unlock()
+		s.cache.hits.Add(1)
+		return creds, nil
+	}
+	gen := s.cache.credsGen
+	s.cache.mu.Unlock()
+	s.cache.misses.Add(1)
+
+	creds, err := s.listCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.mu.Lock()
+	if s.cache.ttl > 0 && s.cache.credsGen == gen {
+		s.cache.creds = cloneCredentials(creds)
+		s.cache.credsExpires = now.Add(s.cache.ttl)
+	}
+	s.cache.mu.Unlock()
+	return creds, nil
+}
+
+// activeElevationRead runs between GetActiveElevation reading the database
+// and filling in the cache, replaced in tests.
+var activeElevationRead = func() {}
+
+// GetActiveElevation returns an active (approved, not expired) elevation for a service/scope.
+// Lookups (including "no active elevation") are served from the read-path cache when fresh.
+func (s *Store) GetActiveElevation(service, scope string) (*Elevation, error) {
+	key := service + ":" + scope
+	now := time.Now()
+
+	s.cache.mu.Lock()
+	if e, ok := s.cache.active[key]; ok && s.cache.ttl > 0 && now.Before(e.expires) &&
+		(e.elev == nil || e.elev.ExpiresAt == nil || now.Before(*e.elev.ExpiresAt)) {
+		s.cache.mu.Unlock()
+		s.cache.hits.Add(1)
+		return cloneElevation(e.elev), nil
+	}
+	gen := s.cache.activeGen
+	s.cache.mu.Unlock()
+	s.cache.misses.Add(1)
+
+	elev, err := s.getActiveElevation(service, scope)
+	if err != nil {
+		return nil, err
+	}
+	activeElevationRead()
+
+	// Don't cache a read that a revocation or expiry may have overtaken
+	s.cache.mu.Lock()
+	if s.cache.ttl > 0 && s.cache.activeGen == gen {
+		s.cache.active[key] = activeEntry{elev: cloneElevation(elev), expires: now.Add(s.cache.ttl)}
+	}
+	s.cache.mu.Unlock()
+	return elev, nil
+}
+
+func cloneCredentials(in []*Credential) []*Credential {
+	if in == nil {
+		return nil
+	}
+	out := make([]*Credential, len(in))
+	for i, c := range in {
+		cp := *c
+		cp.Read = cloneAccessLevel(c.Read)
+		cp.ReadWrite = cloneAccessLevel(c.ReadWrite)
+		if c.AccessWebhook != nil {
+			hook := *c.AccessWebhook
+			cp.AccessWebhook = &hook
+		}
+		out[i] = &cp
+	}
+	return out
+}
+
+func cloneAccessLevel(a *AccessLevel) *AccessLevel {
+	if a == nil {
+		return nil
+	}
+	cp := *a
+	cp.AdditionalFields = append([]AdditionalField(nil), a.AdditionalFields...)
+	return &cp
+}
+
+func cloneElevation(e *Elevation) *Elevation {
+	if e == nil {
+		return nil
+	}
+	cp := *e
+	cp.AssignedTo = append([]string(nil), e.AssignedTo...)
+	return &cp
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// The audit_log is a hash chain: each entry stores the hash of the one
+// before it and its own hash over that and its content. The hash is an HMAC
+// keyed from the master key, so someone who can write to the database but
+// doesn't hold the key can't rewrite history and recompute the chain.
+// Entries written before the chain existed have no hash.
+
+// chainAuditEntry assigns entry the next sequence number and returns it
+// with the previous and new chain hashes. Called with s.mu held for writing.
+func (s *Store) chainAuditEntry(entry *AuditEntry) (seq int64, prevHash, hash string, err error) {
+	var lastSeq sql.NullInt64
+	err = s.db.QueryRow(`SELECT seq, hash FROM audit_log WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1`).Scan(&lastSeq, &prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, "", "", err
+	}
+	seq = lastSeq.Int64 + 1
+	return seq, prevHash, s.auditHash(seq, prevHash, entry), nil
+}
+
+// auditHash returns the chain hash of entry at position seq after prevHash.
+// Fields are length-prefixed so that no two entries encode the same way.
+func (s *Store) auditHash(seq int64, prevHash string, entry *AuditEntry) string {
+	var buf []byte
+	buf = binary.BigEndian.AppendUint64(buf, uint64(seq))
+	for _, f := range []string{
+		prevHash,
+		entry.ID,
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		string(entry.Action),
+		entry.Service,
+		entry.Scope,
+		entry.Details,
+		entry.Actor,
+		entry.RequestID,
+		entry.SourceIP,
+		entry.UserAgent,
+		entry.ElevationID,
+	} {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(f)))
+		buf = append(buf, f...)
+	}
+	return hex.EncodeToString(s.MAC("audit-chain", buf))
+}
+
+// Kinds of AuditChainProblem.
+const (
+	ChainHashMismatch     = "hash_mismatch"        // The entry was altered
+	ChainGap              = "gap"                  // Entries before this one are missing or out of order
+	ChainUnchained        = "unchained"            // An entry without a hash after the chain began
+	ChainTimestampRegress = "timestamp_regression" // Earlier than the entry before it
+)
+
+// AuditChainProblem is one inconsistency found by VerifyAuditChain.
+type AuditChainProblem struct {
+	Seq     int64  `json:"seq,omitempty"`
+	EntryID string `json:"entryId"`
+	Kind    string `json:"kind"`
+	Detail  string `json:"detail"`
+}
+
+// AuditChainReport is the result of VerifyAuditChain.
+type AuditChainReport struct {
+	Entries    int                 `json:"entries"`
+	Unchained  int                 `json:"unchained"`  // Entries written before the chain existed
+	HeadPruned bool                `json:"headPruned"` // The chain's first entries were removed by retention
+	FirstSeq   int64               `json:"firstSeq,omitempty"`
+	LastSeq    int64               `json:"lastSeq,omitempty"`
+	LastHash   string              `json:"lastHash,omitempty"` // Record it elsewhere to detect a truncated tail
+	Problems   []AuditChainProblem `json:"problems"`
+}
+
+// OK reports whether the chain verified without problems.
+func (r *AuditChainReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// VerifyAuditChain walks the audit_log in chain order, recomputing every
+// hash, and reports altered entries, gaps, unchained entries and timestamps
+// that go backwards. A missing head is expected after pruning and isn't a
+// problem; a missing tail can only be detected by comparing LastHash with a
+// value recorded earlier.
+func (s *Store) VerifyAuditChain() (*AuditChainReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT ` + auditColumns + `, seq, prev_hash, hash FROM audit_log ORDER BY seq IS NOT NULL, seq, rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &AuditChainReport{Problems: []AuditChainProblem{}}
+	problem := func(seq int64, id, kind, detail string) {
+		report.Problems = append(report.Problems, AuditChainProblem{Seq: seq, EntryID: id, Kind: kind, Detail: detail})
+	}
+	var (
+		started  bool
+		lastHash string
+		lastTS   time.Time
+	)
+	for rows.Next() {
+		var (
+			e              AuditEntry
+			seq            sql.NullInt64
+			prevHash, hash string
+		)
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.Service, &e.Scope, &e.Details, &e.Actor,
+			&e.RequestID, &e.SourceIP, &e.UserAgent, &e.ElevationID, &seq, &prevHash, &hash); err != nil {
+			return nil, err
+		}
+		report.Entries++
+
+		if !seq.Valid {
+			// Unsequenced rows sort first, so these predate the chain
+			report.Unchained++
+			continue
+		}
+		if hash == "" {
+			problem(seq.Int64, e.ID, ChainUnchained, "entry has no chain hash")
+			continue
+		}
+
+		if !started {
+			report.FirstSeq = seq.Int64
+			report.HeadPruned = prevHash != ""
+		} else {
+			if seq.Int64 != report.LastSeq+1 {
+				problem(seq.Int64, e.ID, ChainGap, fmt.Sprintf("sequence jumps from %d to %d", report.LastSeq, seq.Int64))
+			} else if prevHash != lastHash {
+				problem(seq.Int64, e.ID, ChainGap, "previous hash doesn't match the entry before it")
+			}
+			if e.Timestamp.Before(lastTS) {
+				problem(seq.Int64, e.ID, ChainTimestampRegress, fmt.Sprintf("%s is before the previous entry's %s",
+					e.Timestamp.UTC().Format(time.RFC3339Nano), lastTS.UTC().Format(time.RFC3339Nano)))
+			}
+		}
+		if want := s.auditHash(seq.Int64, prevHash, &e); !hmac.Equal([]byte(hash), []byte(want)) {
+			problem(seq.Int64, e.ID, ChainHashMismatch, "content doesn't match its hash")
+		}
+
+		started = true
+		report.LastSeq = seq.Int64
+		lastHash, lastTS = hash, e.Timestamp
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.LastHash = lastHash
+	return report, nil
+}
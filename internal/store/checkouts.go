@@ -0,0 +1,143 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Checkout is an agent's exclusive hold on a credential's write access.
+// While it is active, only requests presenting its ID get the write token.
+type Checkout struct {
+	ID            string     `json:"id"`
+	Service       string     `json:"service"`
+	Holder        string     `json:"holder"`                // The agent holding it, as it named itself
+	ElevationID   string     `json:"elevationId,omitempty"` // Elevation it was checked out under
+	CheckedOutAt  time.Time  `json:"checkedOutAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	CheckedInAt   *time.Time `json:"checkedInAt,omitempty"`
+	CheckInReason string     `json:"checkInReason,omitempty"`
+}
+
+// Active reports whether c still holds its credential at now.
+func (c *Checkout) Active(now time.Time) bool {
+	return c.CheckedInAt == nil && now.Before(c.ExpiresAt)
+}
+
+const checkoutColumns = `id, service, holder, elevation_id, checked_out_at, expires_at, checked_in_at, check_in_reason`
+
+func scanCheckout(row rowScanner) (*Checkout, error) {
+	var c Checkout
+	var checkedInAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Service, &c.Holder, &c.ElevationID, &c.CheckedOutAt, &c.ExpiresAt,
+		&checkedInAt, &c.CheckInReason); err != nil {
+		return nil, err
+	}
+	if checkedInAt.Valid {
+		c.CheckedInAt = &checkedInAt.Time
+	}
+	return &c, nil
+}
+
+// CheckOut records c unless its service is already checked out. It
+// returns the checkout that holds the service: c, or the existing one.
+func (s *Store) CheckOut(c *Checkout) (*Checkout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	held, err := scanCheckout(s.db.QueryRow(`SELECT `+checkoutColumns+` FROM checkouts
+		WHERE service = ? AND checked_in_at IS NULL AND expires_at > ?`, c.Service, time.Now()))
+	if err == nil {
+		return held, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO checkouts (id, service, holder, elevation_id, checked_out_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, c.ID, c.Service, c.Holder, c.ElevationID, c.CheckedOutAt.Local(), c.ExpiresAt.Local())
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCheckout returns the checkout with the given ID, or nil if there is
+// none.
+func (s *Store) GetCheckout(id string) (*Checkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := scanCheckout(s.db.QueryRow(`SELECT `+checkoutColumns+` FROM checkouts WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// ActiveCheckout returns the checkout holding service, or nil if it isn't
+// checked out.
+func (s *Store) ActiveCheckout(service string) (*Checkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := scanCheckout(s.db.QueryRow(`SELECT `+checkoutColumns+` FROM checkouts
+		WHERE service = ? AND checked_in_at IS NULL AND expires_at > ?`, service, time.Now()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// CheckIn ends an active checkout. It reports false if the checkout doesn't
+// exist or had already ended.
+func (s *Store) CheckIn(id, reason string) (bool, error) {
+	n, err := s.checkIn(reason, `id = ?`, id)
+	return n > 0, err
+}
+
+// CheckInElevation ends the active checkouts made under an elevation and
+// returns how many there were.
+func (s *Store) CheckInElevation(elevationID, reason string) (int, error) {
+	return s.checkIn(reason, `elevation_id = ?`, elevationID)
+}
+
+// checkIn ends the active checkouts matching where.
+func (s *Store) checkIn(reason, where string, args ...interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	res, err := s.db.Exec(`UPDATE checkouts SET checked_in_at = ?, check_in_reason = ?
+		WHERE checked_in_at IS NULL AND expires_at > ? AND `+where,
+		append([]interface{}{now, reason, now}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ListActiveCheckouts returns the checkouts still holding their
+// credentials, newest first.
+func (s *Store) ListActiveCheckouts() ([]*Checkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT `+checkoutColumns+` FROM checkouts
+		WHERE checked_in_at IS NULL AND expires_at > ? ORDER BY checked_out_at DESC`, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkouts []*Checkout
+	for rows.Next() {
+		c, err := scanCheckout(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkouts = append(checkouts, c)
+	}
+	return checkouts, rows.Err()
+}
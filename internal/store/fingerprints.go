@@ -0,0 +1,156 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// fingerprintPurpose domain-separates secret fingerprints from other MACs.
+const fingerprintPurpose = "secret-fingerprint"
+
+// DuplicateSecret is a token of one of a credential's access levels that is
+// also stored under another service.
+type DuplicateSecret struct {
+	Level        string `json:"level"`        // read or write
+	Service      string `json:"service"`      // The other service
+	ServiceLevel string `json:"serviceLevel"` // Its level holding the token
+}
+
+// execer is a *sql.DB or *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// fingerprints returns an HMAC of each of cred's level tokens under the
+// master key, keyed by level. Equal tokens have equal fingerprints; a
+// fingerprint reveals nothing about its token without the key.
+func (s *Store) fingerprints(cred *Credential) map[string]string {
+	fps := make(map[string]string)
+	for _, level := range []struct {
+		name   string
+		access *AccessLevel
+	}{{"read", cred.Read}, {"write", cred.ReadWrite}} {
+		if level.access == nil || level.access.Token == "" {
+			continue
+		}
+		fps[level.name] = hex.EncodeToString(s.MAC(fingerprintPurpose, []byte(level.access.Token)))
+	}
+	return fps
+}
+
+// saveFingerprints replaces service's fingerprints with fps.
+func saveFingerprints(db execer, service string, fps map[string]string) error {
+	if _, err := db.Exec(`DELETE FROM credential_fingerprints WHERE service = ?`, service); err != nil {
+		return err
+	}
+	for level, fp := range fps {
+		if _, err := db.Exec(`INSERT INTO credential_fingerprints (service, level, fingerprint) VALUES (?, ?, ?)`,
+			service, level, fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDuplicateSecrets returns the tokens of cred that are already stored
+// under another service, comparing fingerprints only. cred need not be
+// saved yet.
+func (s *Store) FindDuplicateSecrets(cred *Credential) ([]DuplicateSecret, error) {
+	fps := s.fingerprints(cred)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dups []DuplicateSecret
+	for _, level := range []string{"read", "write"} {
+		fp, ok := fps[level]
+		if !ok {
+			continue
+		}
+		rows, err := s.db.Query(`
+			SELECT service, level FROM credential_fingerprints
+			WHERE fingerprint = ? AND service != ?
+			ORDER BY service, level
+		`, fp, cred.Service)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			dup := DuplicateSecret{Level: level}
+			if err := rows.Scan(&dup.Service, &dup.ServiceLevel); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			dups = append(dups, dup)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return dups, nil
+}
+
+// fingerprintAll recomputes every credential's fingerprints.
+func (s *Store) fingerprintAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.rebuildFingerprints(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebuildFingerprints recomputes every credential's fingerprints, as tx
+// sees them, with s's key. Credentials that don't decrypt with it, or are
+// still in the legacy scopes format, are skipped; they get fingerprints
+// when next saved.
+func (s *Store) rebuildFingerprints(tx *sql.Tx) error {
+	type row struct {
+		service   string
+		encrypted []byte
+	}
+	rows, err := tx.Query(`SELECT service, scopes_encrypted FROM credentials`)
+	if err != nil {
+		return err
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.service, &r.encrypted); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM credential_fingerprints`); err != nil {
+		return err
+	}
+	for _, r := range all {
+		plain, err := s.decrypt(r.encrypted)
+		if err != nil {
+			continue
+		}
+		var data credentialData
+		if err := json.Unmarshal(plain, &data); err != nil || data.Read == nil {
+			continue
+		}
+		cred := &Credential{Read: data.Read, ReadWrite: data.ReadWrite}
+		if err := saveFingerprints(tx, r.service, s.fingerprints(cred)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GatewayOp kinds.
+const (
+	GatewayOpRestart     = "restart"      // Restart the Gateway
+	GatewayOpConfigPatch = "config_patch" // Apply a config merge patch (which also restarts)
+	GatewayOpConfigSet   = "config_set"   // Set config paths, merged with the config at replay (which also restarts)
+)
+
+// GatewayOp is a Gateway RPC operation deferred while the Gateway was
+// unreachable, to be replayed in order once it reconnects.
+type GatewayOp struct {
+	ID        int64      `json:"id"`
+	Gateway   string     `json:"gateway"`
+	Kind      string     `json:"kind"`
+	Payload   string     `json:"-"` // Config patch or config set JSON (may hold secrets; encrypted at rest)
+	Reason    string     `json:"reason"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"lastError,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	FailedAt  *time.Time `json:"failedAt,omitempty"` // Set when replay gave up on it; it is kept but not replayed
+}
+
+// EnqueueGatewayOp durably queues op.
+func (s *Store) EnqueueGatewayOp(op *GatewayOp) error {
+	var payload []byte
+	if op.Payload != "" {
+		var err error
+		if payload, err = s.encrypt([]byte(op.Payload)); err != nil {
+			return err
+		}
+	}
+	if op.CreatedAt.IsZero() {
+		op.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT INTO gateway_ops (gateway, kind, payload_encrypted, reason, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, op.Gateway, op.Kind, payload, op.Reason, op.LastError, op.CreatedAt)
+	if err != nil {
+		return err
+	}
+	op.ID, err = res.LastInsertId()
+	return err
+}
+
+// ListGatewayOps returns the queued operations for gateway, oldest first,
+// including failed ones. An empty gateway lists every queue.
+func (s *Store) ListGatewayOps(gateway string) ([]*GatewayOp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, gateway, kind, payload_encrypted, reason, attempts, last_error, created_at, failed_at
+		FROM gateway_ops WHERE ? = '' OR gateway = ? ORDER BY id
+	`, gateway, gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ops []*GatewayOp
+	for rows.Next() {
+		var op GatewayOp
+		var payload []byte
+		var reason, lastError sql.NullString
+		var failedAt sql.NullTime
+		if err := rows.Scan(&op.ID, &op.Gateway, &op.Kind, &payload, &reason, &op.Attempts, &lastError, &op.CreatedAt, &failedAt); err != nil {
+			return nil, err
+		}
+		op.Reason, op.LastError = reason.String, lastError.String
+		if failedAt.Valid {
+			op.FailedAt = &failedAt.Time
+		}
+		if len(payload) > 0 {
+			plain, err := s.decrypt(payload)
+			if err != nil {
+				return nil, err
+			}
+			op.Payload = string(plain)
+		}
+		ops = append(ops, &op)
+	}
+	return ops, rows.Err()
+}
+
+// DeleteGatewayOp removes a replayed (or abandoned) operation.
+func (s *Store) DeleteGatewayOp(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`DELETE FROM gateway_ops WHERE id = ?`, id)
+	return err
+}
+
+// RecordGatewayOpFailure counts a failed replay attempt.
+func (s *Store) RecordGatewayOpFailure(id int64, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`UPDATE gateway_ops SET attempts = attempts + 1, last_error = ? WHERE id = ?`, lastError, id)
+	return err
+}
+
+// FailGatewayOp counts a failed replay attempt and sets the operation aside
+// as failed, so replays skip it.
+func (s *Store) FailGatewayOp(id int64, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`UPDATE gateway_ops SET attempts = attempts + 1, last_error = ?, failed_at = ? WHERE id = ?`,
+		lastError, time.Now(), id)
+	return err
+}
+
+// DeleteFailedGatewayOps removes gateway's failed operations and returns
+// how many there were.
+func (s *Store) DeleteFailedGatewayOps(gateway string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.db.Exec(`DELETE FROM gateway_ops WHERE gateway = ? AND failed_at IS NOT NULL`, gateway)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
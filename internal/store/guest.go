@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ErrInviteUsed is returned when a guest invite has already been redeemed.
+var ErrInviteUsed = fmt.Errorf("guest invite already used")
+
+// GuestInvite is a time-boxed invitation letting one external person act on
+// a single pending elevation without an admin account.
+type GuestInvite struct {
+	ID          string        `json:"id"`
+	ElevationID string        `json:"elevationId"`
+	Guest       string        `json:"guest"`  // Identity the guest must present (e.g., email)
+	MaxTTL      time.Duration `json:"maxTTL"` // Upper bound on the TTL the guest may grant
+	CreatedBy   string        `json:"createdBy"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	ExpiresAt   time.Time     `json:"expiresAt"`
+	UsedAt      *time.Time    `json:"usedAt,omitempty"`
+	Decision    string        `json:"decision,omitempty"` // approved, denied
+}
+
+// CreateGuestInvite stores a new guest invite.
+func (s *Store) CreateGuestInvite(inv *GuestInvite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO guest_invites (id, elevation_id, guest, max_ttl, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, inv.ID, inv.ElevationID, inv.Guest, int64(inv.MaxTTL), inv.CreatedBy, inv.CreatedAt, inv.ExpiresAt)
+	return err
+}
+
+// GetGuestInvite retrieves a guest invite by ID.
+func (s *Store) GetGuestInvite(id string) (*GuestInvite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var inv GuestInvite
+	var maxTTL int64
+	var usedAt sql.NullTime
+	var decision sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, elevation_id, guest, max_ttl, created_by, created_at, expires_at, used_at, decision
+		FROM guest_invites WHERE id = ?
+	`, id).Scan(&inv.ID, &inv.ElevationID, &inv.Guest, &maxTTL, &inv.CreatedBy,
+		&inv.CreatedAt, &inv.ExpiresAt, &usedAt, &decision)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inv.MaxTTL = time.Duration(maxTTL)
+	if usedAt.Valid {
+		inv.UsedAt = &usedAt.Time
+	}
+	if decision.Valid {
+		inv.Decision = decision.String
+	}
+	return &inv, nil
+}
+
+// RedeemGuestInvite marks an invite as used with the guest's decision.
+// Redemption is single-use: returns ErrInviteUsed if the invite was already redeemed.
+func (s *Store) RedeemGuestInvite(id, decision string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE guest_invites SET used_at = ?, decision = ?
+		WHERE id = ? AND used_at IS NULL
+	`, time.Now(), decision, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInviteUsed
+	}
+	return nil
+}
+
+// ReleaseGuestInvite clears a redemption so the invite can be retried.
+// Used to roll back when the decision could not be applied.
+func (s *Store) ReleaseGuestInvite(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`UPDATE guest_invites SET used_at = NULL, decision = NULL WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Credential health statuses.
+const (
+	HealthOK      = "ok"      // The provider accepted the token
+	HealthInvalid = "invalid" // The provider rejected the token, e.g. it was revoked
+	HealthError   = "error"   // The check couldn't tell, e.g. the provider was unreachable
+)
+
+// CredentialHealth is the result of checking that a credential's token
+// still works with its provider.
+type CredentialHealth struct {
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"` // e.g. the provider's answer
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// healthRow scans the credential_health columns of a LEFT JOIN.
+type healthRow struct {
+	state     sql.NullString
+	detail    sql.NullString
+	checkedAt sql.NullTime
+}
+
+func (r healthRow) toHealth() *CredentialHealth {
+	if !r.state.Valid {
+		return nil
+	}
+	return &CredentialHealth{Status: r.state.String, Detail: r.detail.String, CheckedAt: r.checkedAt.Time}
+}
+
+// SetCredentialHealth records the health check result for service.
+func (s *Store) SetCredentialHealth(service string, health *CredentialHealth) error {
+	if health.CheckedAt.IsZero() {
+		health.CheckedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO credential_health (service, status, detail, checked_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(service) DO UPDATE SET
+			status = excluded.status,
+			detail = excluded.detail,
+			checked_at = excluded.checked_at
+	`, service, health.Status, health.Detail, health.CheckedAt)
+	s.invalidateCredentials()
+	return err
+}
@@ -0,0 +1,17 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// NewID returns a unique ID with prefix, e.g. "audit". The random part
+// keeps IDs made in the same instant, e.g. audit entries written from
+// different goroutines, from colliding.
+func NewID(prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%s_%s_%d", prefix, hex.EncodeToString(b), time.Now().UnixMilli()%10000)
+}
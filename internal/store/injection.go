@@ -0,0 +1,57 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Injection verification statuses.
+const (
+	InjectionPending    = "pending"    // Waiting for the Gateway to restart
+	InjectionLoaded     = "loaded"     // The Gateway reports the injected values
+	InjectionNotLoaded  = "not_loaded" // Injected, but the Gateway didn't load it
+	InjectionUnverified = "unverified" // The Gateway can't report what it loaded
+)
+
+// InjectionStatus is the result of checking that the Gateway loaded a
+// credential after it was injected.
+type InjectionStatus struct {
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"` // e.g. the env vars or config paths not loaded
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// injectionRow scans the injection_status columns of a LEFT JOIN.
+type injectionRow struct {
+	state     sql.NullString
+	detail    sql.NullString
+	checkedAt sql.NullTime
+}
+
+func (r injectionRow) toStatus() *InjectionStatus {
+	if !r.state.Valid {
+		return nil
+	}
+	return &InjectionStatus{Status: r.state.String, Detail: r.detail.String, CheckedAt: r.checkedAt.Time}
+}
+
+// SetInjectionStatus records the injection verification result for service.
+func (s *Store) SetInjectionStatus(service string, status *InjectionStatus) error {
+	if status.CheckedAt.IsZero() {
+		status.CheckedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO injection_status (service, status, detail, checked_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(service) DO UPDATE SET
+			status = excluded.status,
+			detail = excluded.detail,
+			checked_at = excluded.checked_at
+	`, service, status.Status, status.Detail, status.CheckedAt)
+	s.invalidateCredentials()
+	return err
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Lease records one copy of a credential handed to an agent. Agents that
+// ask for a lease renew it while they use the token; revoking an elevation
+// or changing the credential revokes its leases, so the live leases are the
+// copies that may still be in use.
+type Lease struct {
+	ID           string     `json:"id"`
+	Service      string     `json:"service"`
+	Scope        string     `json:"scope"`
+	ElevationID  string     `json:"elevationId,omitempty"` // Elevation granting write access
+	IssuedAt     time.Time  `json:"issuedAt"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	RenewedAt    *time.Time `json:"renewedAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	RevokeReason string     `json:"revokeReason,omitempty"`
+}
+
+// Lease statuses.
+const (
+	LeaseLive    = "live"
+	LeaseExpired = "expired"
+	LeaseRevoked = "revoked"
+)
+
+// Status returns whether l is live, expired or revoked at now.
+func (l *Lease) Status(now time.Time) string {
+	switch {
+	case l.RevokedAt != nil:
+		return LeaseRevoked
+	case !now.Before(l.ExpiresAt):
+		return LeaseExpired
+	default:
+		return LeaseLive
+	}
+}
+
+const leaseColumns = `id, service, scope, elevation_id, issued_at, expires_at, renewed_at, revoked_at, revoke_reason`
+
+func scanLease(row rowScanner) (*Lease, error) {
+	var l Lease
+	var renewedAt, revokedAt sql.NullTime
+	if err := row.Scan(&l.ID, &l.Service, &l.Scope, &l.ElevationID, &l.IssuedAt, &l.ExpiresAt,
+		&renewedAt, &revokedAt, &l.RevokeReason); err != nil {
+		return nil, err
+	}
+	if renewedAt.Valid {
+		l.RenewedAt = &renewedAt.Time
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Time
+	}
+	return &l, nil
+}
+
+// CreateLease stores a new lease.
+func (s *Store) CreateLease(l *Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO leases (id, service, scope, elevation_id, issued_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, l.ID, l.Service, l.Scope, l.ElevationID, l.IssuedAt.Local(), l.ExpiresAt.Local())
+	return err
+}
+
+// GetLease returns the lease with the given ID, or nil if there is none.
+func (s *Store) GetLease(id string) (*Lease, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, err := scanLease(s.db.QueryRow(`SELECT `+leaseColumns+` FROM leases WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// RenewLease moves a live lease's expiry to expiresAt. It reports false if
+// the lease has expired or been revoked.
+func (s *Store) RenewLease(id string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	res, err := s.db.Exec(`
+		UPDATE leases SET expires_at = ?, renewed_at = ?
+		WHERE id = ? AND revoked_at IS NULL AND expires_at > ?
+	`, expiresAt.Local(), now, id, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeLease revokes one lease. It reports false if the lease doesn't
+// exist or was already revoked.
+func (s *Store) RevokeLease(id, reason string) (bool, error) {
+	n, err := s.revokeLeases(reason, `id = ?`, id)
+	return n > 0, err
+}
+
+// RevokeElevationLeases revokes the live leases an elevation granted and
+// returns how many there were.
+func (s *Store) RevokeElevationLeases(elevationID, reason string) (int, error) {
+	return s.revokeLeases(reason, `elevation_id = ? AND expires_at > ?`, elevationID, time.Now())
+}
+
+// RevokeServiceLeases revokes the live leases on a service's credential and
+// returns how many there were.
+func (s *Store) RevokeServiceLeases(service, reason string) (int, error) {
+	return s.revokeLeases(reason, `service = ? AND expires_at > ?`, service, time.Now())
+}
+
+// revokeLeases revokes the unrevoked leases matching where.
+func (s *Store) revokeLeases(reason, where string, args ...interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`UPDATE leases SET revoked_at = ?, revoke_reason = ? WHERE revoked_at IS NULL AND `+where,
+		append([]interface{}{time.Now(), reason}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ListLiveLeases returns the leases that are neither expired nor revoked,
+// newest first.
+func (s *Store) ListLiveLeases() ([]*Lease, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT `+leaseColumns+` FROM leases
+		WHERE revoked_at IS NULL AND expires_at > ? ORDER BY issued_at DESC`, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leases []*Lease
+	for rows.Next() {
+		l, err := scanLease(rows)
+		if err != nil {
+			return nil, err
+		}
+		leases = append(leases, l)
+	}
+	return leases, rows.Err()
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// DecryptFailure is a stored value that doesn't decrypt under the master
+// key.
+type DecryptFailure struct {
+	Table string `json:"table"`
+	Key   string `json:"key"` // The row's id, or the setting's key
+	Error string `json:"error"`
+}
+
+// EncryptionReport is the result of VerifyEncryption.
+type EncryptionReport struct {
+	Checked  int              `json:"checked"`
+	Failures []DecryptFailure `json:"failures"`
+}
+
+// OK reports whether every value decrypted.
+func (r *EncryptionReport) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// VerifyEncryption tries to decrypt every value stored under the master
+// key, without changing anything. Failures mean a wrong key, a value
+// written under another key, or corruption.
+func (s *Store) VerifyEncryption() (*EncryptionReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := &EncryptionReport{Failures: []DecryptFailure{}}
+	check := func(table, key string, sealed []byte) {
+		report.Checked++
+		if _, err := s.decrypt(sealed); err != nil {
+			report.Failures = append(report.Failures, DecryptFailure{Table: table, Key: key, Error: err.Error()})
+		}
+	}
+
+	for _, c := range encryptedColumns {
+		err := queryEach(s.db, `SELECT `+c.key+`, `+c.column+` FROM `+c.table+` WHERE length(`+c.column+`) > 0`, func(row rowScanner) error {
+			var key string
+			var sealed []byte
+			if err := row.Scan(&key, &sealed); err != nil {
+				return err
+			}
+			check(c.table, key, sealed)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.stage, err)
+		}
+	}
+
+	for _, key := range encryptedSettings {
+		var value string
+		err := s.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		default:
+			var sealed []byte
+			if err := json.Unmarshal([]byte(value), &sealed); err != nil {
+				report.Checked++
+				report.Failures = append(report.Failures, DecryptFailure{Table: "settings", Key: key, Error: err.Error()})
+			} else {
+				check("settings", key, sealed)
+			}
+		}
+	}
+	return report, nil
+}
+
+// Compact checkpoints the write-ahead log into the database, truncating it,
+// and rebuilds the database file to reclaim free pages. Writers wait until
+// it finishes.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	// VACUUM goes through the WAL too
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ElevationPreset is a named approval TTL for a service (e.g., "quick fix" = 10m).
+type ElevationPreset struct {
+	Service   string        `json:"service"`
+	Name      string        `json:"name"`
+	TTL       time.Duration `json:"ttl"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// SavePreset creates or updates an elevation preset.
+func (s *Store) SavePreset(p *ElevationPreset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO elevation_presets (service, name, ttl, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(service, name) DO UPDATE SET ttl = excluded.ttl
+	`, p.Service, p.Name, int64(p.TTL), p.CreatedAt)
+	return err
+}
+
+// GetPreset retrieves a preset by service and name.
+func (s *Store) GetPreset(service, name string) (*ElevationPreset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var p ElevationPreset
+	var ttl int64
+	err := s.db.QueryRow(`
+		SELECT service, name, ttl, created_at FROM elevation_presets
+		WHERE service = ? AND name = ?
+	`, service, name).Scan(&p.Service, &p.Name, &ttl, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.TTL = time.Duration(ttl)
+	return &p, nil
+}
+
+// ListPresets returns all presets for a service, shortest TTL first.
+func (s *Store) ListPresets(service string) ([]*ElevationPreset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT service, name, ttl, created_at FROM elevation_presets
+		WHERE service = ? ORDER BY ttl, name
+	`, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []*ElevationPreset
+	for rows.Next() {
+		var p ElevationPreset
+		var ttl int64
+		if err := rows.Scan(&p.Service, &p.Name, &ttl, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.TTL = time.Duration(ttl)
+		presets = append(presets, &p)
+	}
+	return presets, rows.Err()
+}
+
+// DeletePreset removes a preset. Returns false if it did not exist.
+func (s *Store) DeletePreset(service, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`DELETE FROM elevation_presets WHERE service = ? AND name = ?`, service, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// receiptKeySettingKey is the settings key holding the encrypted seed of
+// the receipt signing key.
+const receiptKeySettingKey = "receipt_signing_key"
+
+// ApprovalReceipt is a signed record of an elevation approval. Payload is
+// the exact JSON that was signed; it is kept verbatim so the signature can
+// be checked later.
+type ApprovalReceipt struct {
+	ElevationID string    `json:"elevationId"`
+	Payload     []byte    `json:"payload"`
+	Signature   []byte    `json:"signature"` // Ed25519 over Payload
+	PublicKey   []byte    `json:"publicKey"` // Key that made Signature
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// SaveApprovalReceipt stores r. An elevation has at most one receipt.
+func (s *Store) SaveApprovalReceipt(r *ApprovalReceipt) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO approval_receipts (elevation_id, payload, signature, public_key, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, r.ElevationID, r.Payload, r.Signature, r.PublicKey, r.CreatedAt)
+	return err
+}
+
+// GetApprovalReceipt returns the receipt for an elevation, or nil if it has
+// none.
+func (s *Store) GetApprovalReceipt(elevationID string) (*ApprovalReceipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r := &ApprovalReceipt{ElevationID: elevationID}
+	err := s.db.QueryRow(`
+		SELECT payload, signature, public_key, created_at FROM approval_receipts WHERE elevation_id = ?
+	`, elevationID).Scan(&r.Payload, &r.Signature, &r.PublicKey, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReceiptSigningKey returns the Ed25519 key approval receipts are signed
+// with, generating it on first use. Its seed is stored encrypted with the
+// master key.
+func (s *Store) ReceiptSigningKey() (ed25519.PrivateKey, error) {
+	var sealed []byte
+	ok, err := s.GetSetting(receiptKeySettingKey, &sealed)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		seed, err := s.decrypt(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt receipt signing key: %w", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("receipt signing key: bad seed length %d", len(seed))
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("generate receipt signing key: %w", err)
+	}
+	if sealed, err = s.encrypt(seed); err != nil {
+		return nil, err
+	}
+	if err := s.PutSetting(receiptKeySettingKey, sealed); err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
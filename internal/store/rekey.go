@@ -0,0 +1,216 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrAuditChainBroken is returned by Rekey when the audit chain doesn't
+// verify under the current key. Re-hashing it would hide the damage.
+var ErrAuditChainBroken = errors.New("audit chain has problems; run `ocm audit verify`")
+
+// RekeyProgress reports how far Rekey has got through one kind of data.
+type RekeyProgress struct {
+	Stage string // e.g. "credentials", "audit log"
+	Done  int
+	Total int
+}
+
+// encryptedColumns are the columns holding values encrypted under the
+// master key. Some settings are too (see encryptedSettings).
+var encryptedColumns = []struct {
+	stage, table, key, column string
+}{
+	{"credentials", "credentials", "id", "scopes_encrypted"},
+	{"archived credential versions", "credential_versions", "id", "data_encrypted"},
+	{"webhook secrets", "webhooks", "id", "secret_encrypted"},
+	{"queued gateway operations", "gateway_ops", "id", "payload_encrypted"},
+}
+
+// Rekey re-encrypts everything stored under the master key with newKey, and
+// re-hashes the audit chain with it, in one transaction. The audit chain
+// must verify first. On success the store uses newKey from then on.
+// Outstanding guest invite links are signed with the old key and stop
+// working.
+func (s *Store) Rekey(newKey []byte, progress func(RekeyProgress)) error {
+	if len(newKey) != 32 {
+		return fmt.Errorf("master key must be 32 bytes")
+	}
+	if progress == nil {
+		progress = func(RekeyProgress) {}
+	}
+	report, err := s.VerifyAuditChain()
+	if err != nil {
+		return fmt.Errorf("verify audit chain: %w", err)
+	}
+	if !report.OK() {
+		return fmt.Errorf("%w (%d problems)", ErrAuditChainBroken, len(report.Problems))
+	}
+
+	block, err := aes.NewCipher(newKey)
+	if err != nil {
+		return fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("create GCM: %w", err)
+	}
+	// next encrypts and MACs with the new key; it is never used for queries
+	next := &Store{masterKey: newKey, gcm: gcm}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range encryptedColumns {
+		if err := s.reencryptColumn(tx, next, c.stage, c.table, c.key, c.column, progress); err != nil {
+			return fmt.Errorf("%s: %w", c.stage, err)
+		}
+	}
+	for _, key := range encryptedSettings {
+		if err := s.reencryptSetting(tx, next, key); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	if err := s.rehashAuditChain(tx, next, progress); err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	// After re-encryption, so the credentials decrypt with next's key
+	if err := next.rebuildFingerprints(tx); err != nil {
+		return fmt.Errorf("credential fingerprints: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.masterKey, s.gcm = newKey, gcm
+	s.InvalidateCache()
+	return nil
+}
+
+// reencryptColumn re-encrypts every non-empty value of table.column.
+func (s *Store) reencryptColumn(tx *sql.Tx, next *Store, stage, table, key, column string, progress func(RekeyProgress)) error {
+	type row struct {
+		key   interface{}
+		value []byte
+	}
+	rows, err := tx.Query(`SELECT ` + key + `, ` + column + ` FROM ` + table + ` WHERE length(` + column + `) > 0`)
+	if err != nil {
+		return err
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.key, &r.value); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, r := range all {
+		plain, err := s.decrypt(r.value)
+		if err != nil {
+			return fmt.Errorf("decrypt %v: %w", r.key, err)
+		}
+		sealed, err := next.encrypt(plain)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = ? WHERE `+key+` = ?`, sealed, r.key); err != nil {
+			return err
+		}
+		progress(RekeyProgress{Stage: stage, Done: i + 1, Total: len(all)})
+	}
+	return nil
+}
+
+// reencryptSetting re-encrypts a setting holding an encrypted []byte, if it
+// is set.
+func (s *Store) reencryptSetting(tx *sql.Tx, next *Store, key string) error {
+	var value string
+	err := tx.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var sealed []byte
+	if err := json.Unmarshal([]byte(value), &sealed); err != nil {
+		return err
+	}
+	plain, err := s.decrypt(sealed)
+	if err != nil {
+		return err
+	}
+	if sealed, err = next.encrypt(plain); err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE settings SET value = ? WHERE key = ?`, string(data), key)
+	return err
+}
+
+// rehashAuditChain recomputes the chain hashes with next's key. The first
+// entry keeps its previous hash, which after pruning refers to an entry that
+// no longer exists.
+func (s *Store) rehashAuditChain(tx *sql.Tx, next *Store, progress func(RekeyProgress)) error {
+	type link struct {
+		entry    *AuditEntry
+		seq      int64
+		prevHash string
+	}
+	rows, err := tx.Query(`SELECT ` + auditColumns + `, seq, prev_hash FROM audit_log WHERE seq IS NOT NULL ORDER BY seq`)
+	if err != nil {
+		return err
+	}
+	var chain []link
+	for rows.Next() {
+		var e AuditEntry
+		var l link
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.Service, &e.Scope, &e.Details, &e.Actor,
+			&e.RequestID, &e.SourceIP, &e.UserAgent, &e.ElevationID, &l.seq, &l.prevHash); err != nil {
+			rows.Close()
+			return err
+		}
+		l.entry = &e
+		chain = append(chain, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	prevHash := ""
+	for i, l := range chain {
+		if i == 0 {
+			prevHash = l.prevHash
+		}
+		hash := next.auditHash(l.seq, prevHash, l.entry)
+		if _, err := tx.Exec(`UPDATE audit_log SET prev_hash = ?, hash = ? WHERE id = ?`, prevHash, hash, l.entry.ID); err != nil {
+			return err
+		}
+		prevHash = hash
+		if (i+1)%500 == 0 || i == len(chain)-1 {
+			progress(RekeyProgress{Stage: "audit log", Done: i + 1, Total: len(chain)})
+		}
+	}
+	return nil
+}
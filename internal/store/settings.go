@@ -0,0 +1,89 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AuditDeviceSecretsKey is the settings key holding the audit devices'
+// signing secrets, encrypted, by device name.
+const AuditDeviceSecretsKey = "audit_device_secrets"
+
+// encryptedSettings are the settings whose value is encrypted under the
+// master key, so Rekey re-encrypts them.
+var encryptedSettings = []string{receiptKeySettingKey, AuditDeviceSecretsKey}
+
+// GetSetting decodes the JSON setting stored under key into v.
+// Returns false if the setting does not exist.
+func (s *Store) GetSetting(key string, v interface{}) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var raw string
+	err := s.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return false, fmt.Errorf("unmarshal setting %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// PutSetting stores v as JSON under key, replacing any previous value.
+func (s *Store) PutSetting(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal setting %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, string(raw), time.Now())
+	return err
+}
+
+// GetEncryptedSetting decodes the setting stored under key by
+// PutEncryptedSetting into v. Returns false if the setting does not exist.
+func (s *Store) GetEncryptedSetting(key string, v interface{}) (bool, error) {
+	var sealed []byte
+	ok, err := s.GetSetting(key, &sealed)
+	if err != nil || !ok {
+		return false, err
+	}
+	plain, err := s.decrypt(sealed)
+	if err != nil {
+		return false, fmt.Errorf("decrypt setting %s: %w", key, err)
+	}
+	if err := json.Unmarshal(plain, v); err != nil {
+		return false, fmt.Errorf("unmarshal setting %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// PutEncryptedSetting stores v as JSON encrypted under the master key,
+// replacing any previous value. key must be one of encryptedSettings.
+func (s *Store) PutEncryptedSetting(key string, v interface{}) error {
+	if !slices.Contains(encryptedSettings, key) {
+		return fmt.Errorf("setting %s is not an encrypted setting", key)
+	}
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal setting %s: %w", key, err)
+	}
+	sealed, err := s.encrypt(plain)
+	if err != nil {
+		return err
+	}
+	return s.PutSetting(key, sealed)
+}
@@ -0,0 +1,87 @@
+package store
+
+import "time"
+
+// SetupStep is a step of the first-run setup wizard.
+type SetupStep string
+
+const (
+	SetupWelcome       SetupStep = "welcome"
+	SetupModelProvider SetupStep = "model_provider" // At least one LLM API key
+)
+
+// SetupSteps lists the wizard's steps in order. Finishing setup, which
+// restarts the Gateway, follows the last one.
+var SetupSteps = []SetupStep{SetupWelcome, SetupModelProvider}
+
+// SetupState is the wizard's progress, kept so that a partial setup
+// survives restarts.
+type SetupState struct {
+	Completed   map[SetupStep]time.Time `json:"completed"`
+	Skipped     map[SetupStep]time.Time `json:"skipped"`
+	StartedAt   time.Time               `json:"startedAt"`
+	CompletedAt *time.Time              `json:"completedAt,omitempty"` // Set when setup was finished
+}
+
+// setupStateSettingKey is the setting the wizard's progress is stored under.
+const setupStateSettingKey = "setup_state"
+
+// NewSetupState returns the state of a wizard started at now.
+func NewSetupState(now time.Time) *SetupState {
+	return &SetupState{
+		Completed: map[SetupStep]time.Time{},
+		Skipped:   map[SetupStep]time.Time{},
+		StartedAt: now,
+	}
+}
+
+// Done reports whether step was completed or skipped.
+func (st *SetupState) Done(step SetupStep) bool {
+	_, completed := st.Completed[step]
+	_, skipped := st.Skipped[step]
+	return completed || skipped
+}
+
+// Current returns the first step neither completed nor skipped, or "" once
+// every step is.
+func (st *SetupState) Current() SetupStep {
+	for _, step := range SetupSteps {
+		if !st.Done(step) {
+			return step
+		}
+	}
+	return ""
+}
+
+// ParseSetupStep returns the wizard step named name.
+func ParseSetupStep(name string) (SetupStep, bool) {
+	for _, step := range SetupSteps {
+		if string(step) == name {
+			return step, true
+		}
+	}
+	return "", false
+}
+
+// GetSetupState returns the wizard's progress, or false if none has been
+// recorded.
+func (s *Store) GetSetupState() (*SetupState, bool, error) {
+	st := NewSetupState(time.Time{})
+	found, err := s.GetSetting(setupStateSettingKey, st)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	if st.Completed == nil {
+		st.Completed = map[SetupStep]time.Time{}
+	}
+	if st.Skipped == nil {
+		st.Skipped = map[SetupStep]time.Time{}
+	}
+	return st, true, nil
+}
+
+// SaveSetupState records the wizard's progress, replacing any previous
+// state.
+func (s *Store) SaveSetupState(st *SetupState) error {
+	return s.PutSetting(setupStateSettingKey, st)
+}
@@ -4,15 +4,21 @@ package store
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/openclaw/ocm/internal/redact"
 )
 
 // Store manages encrypted credential storage.
@@ -21,6 +27,35 @@ type Store struct {
 	masterKey []byte
 	gcm       cipher.AEAD
 	mu        sync.RWMutex
+	cache     *readCache
+	auditSink AuditSink
+
+	// onDecryptError is called when stored ciphertext fails to decrypt
+	// (wrong master key or tampering). Set once at startup.
+	onDecryptError func(err error)
+
+	// schemaUpgraded is set if New had to change the schema
+	schemaUpgraded bool
+}
+
+// AuditSink receives audit entries in place of the built-in audit_log table
+// (see internal/audit). A returned error means the entry was not recorded.
+type AuditSink interface {
+	WriteAudit(entry *AuditEntry) error
+}
+
+// SetAuditSink routes AddAuditEntry through sink. Nil restores direct writes.
+func (s *Store) SetAuditSink(sink AuditSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditSink = sink
+}
+
+// OnDecryptError registers fn to be called whenever stored data fails to
+// decrypt. It must be set before the store is in use. fn may be called with
+// the store lock held, so it must not call back into the store.
+func (s *Store) OnDecryptError(fn func(err error)) {
+	s.onDecryptError = fn
 }
 
 // Credential represents a stored credential with read and optional read-write access.
@@ -36,6 +71,42 @@ type Credential struct {
 	// ReadWrite access - requires elevation, injected temporarily (optional)
 	ReadWrite *AccessLevel `json:"readWrite,omitempty"`
 
+	// AccessWebhook is notified on every agent access to this credential (optional)
+	AccessWebhook *AccessWebhook `json:"accessWebhook,omitempty"`
+
+	// Rotation mints replacement secrets through a hook (optional)
+	Rotation *Rotation `json:"rotation,omitempty"`
+
+	// MaxConcurrentElevations caps simultaneous active elevations (0 = unlimited).
+	// ElevationOverflow decides what happens to requests beyond the cap.
+	MaxConcurrentElevations int               `json:"maxConcurrentElevations,omitempty"`
+	ElevationOverflow       ElevationOverflow `json:"elevationOverflow,omitempty"`
+
+	// ExclusiveCheckout makes write access exclusive: an agent checks the
+	// credential out, and no other agent gets the write token until it is
+	// checked in or the checkout expires
+	ExclusiveCheckout bool `json:"exclusiveCheckout,omitempty"`
+
+	// Gateway names the OpenClaw Gateway the credential is injected into
+	// (empty = the default gateway).
+	Gateway string `json:"gateway,omitempty"`
+
+	// Injection is the result of checking the Gateway loaded the credential
+	// after its last injection (nil = never checked).
+	Injection *InjectionStatus `json:"injection,omitempty"`
+
+	// Health is the result of the last check that the credential's token
+	// still works with its provider (nil = never checked).
+	Health *CredentialHealth `json:"health,omitempty"`
+
+	// LastUsedAt is when the credential was last read by an agent,
+	// injected or elevated (nil = never), and Usage the last use of each
+	// scope, most recent first. LastAccessedAt leaves out injections, which
+	// follow admin changes and rotations rather than anyone needing it.
+	LastUsedAt     *time.Time      `json:"lastUsedAt,omitempty"`
+	LastAccessedAt *time.Time      `json:"lastAccessedAt,omitempty"`
+	Usage          []CredentialUse `json:"usage,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -63,6 +134,42 @@ type AccessLevel struct {
 	// Additional fields that get injected alongside the primary token
 	// Used for multi-field credentials like Slack (userToken + cookie)
 	AdditionalFields []AdditionalField `json:"additionalFields,omitempty"`
+
+	// Derive, if set, has agents get a short-lived token minted from Token
+	// (or RefreshToken) instead of the stored secret, which then never
+	// leaves OCM and isn't injected into the Gateway
+	Derive *Derivation `json:"derive,omitempty"`
+}
+
+// DerivationKind is how a short-lived token is minted.
+type DerivationKind string
+
+const (
+	DeriveGitHubApp    DerivationKind = "github_app"    // Token is the app's private key (PEM)
+	DeriveAWSSTS       DerivationKind = "aws_sts"       // Token is the secret access key
+	DeriveOAuthRefresh DerivationKind = "oauth_refresh" // RefreshToken is exchanged for an access token
+)
+
+// Derivation configures minting short-lived tokens for an access level.
+type Derivation struct {
+	Kind DerivationKind `json:"kind"`
+
+	// github_app
+	AppID          string `json:"appId,omitempty"`
+	InstallationID string `json:"installationId,omitempty"`
+
+	// aws_sts: AssumeRole into RoleARN with the access key
+	AccessKeyID string `json:"accessKeyId,omitempty"`
+	RoleARN     string `json:"roleArn,omitempty"`
+	Region      string `json:"region,omitempty"`
+
+	// oauth_refresh
+	TokenURL     string `json:"tokenUrl,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"` // Encrypted at rest
+
+	Endpoint string        `json:"endpoint,omitempty"` // API base URL, e.g. for GitHub Enterprise (default: the provider's)
+	TTL      time.Duration `json:"ttl,omitempty"`      // Lifetime to ask for, where the provider takes one
 }
 
 // AdditionalField is an extra field that gets injected with the primary token.
@@ -74,6 +181,36 @@ type AdditionalField struct {
 	Value         string        `json:"value"`                   // The field value (encrypted at rest)
 }
 
+// ElevationOverflow is the policy for elevation requests beyond a credential's concurrency limit.
+type ElevationOverflow string
+
+const (
+	OverflowReject ElevationOverflow = "reject" // Refuse the request (default)
+	OverflowQueue  ElevationOverflow = "queue"  // Hold as "queued" until capacity frees up
+)
+
+// AccessWebhook is a per-credential endpoint notified on every credential_access.
+type AccessWebhook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"` // HMAC signing secret (encrypted at rest)
+}
+
+// Rotation mints a replacement secret for each of a credential's access
+// levels: through a command OCM runs, a webhook it calls, or a built-in
+// provider rotator.
+type Rotation struct {
+	Command  []string      `json:"command,omitempty"`  // Argv, run without a shell
+	URL      string        `json:"url,omitempty"`      // Webhook, instead of Command
+	Secret   string        `json:"secret,omitempty"`   // HMAC signing secret for URL (encrypted at rest)
+	Interval time.Duration `json:"interval,omitempty"` // Rotate this long after the last change (0 = on demand only)
+
+	// Built-in rotator, instead of Command or URL: github, aws_iam or slack
+	Provider     string `json:"provider,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`     // github, slack: the app's OAuth client
+	ClientSecret string `json:"clientSecret,omitempty"` // Encrypted at rest
+	Endpoint     string `json:"endpoint,omitempty"`     // API base URL, e.g. for GitHub Enterprise (default: the provider's)
+}
+
 // GetInjectionType returns the injection type, defaulting to "env" for backwards compat.
 func (a *AccessLevel) GetInjectionType() InjectionType {
 	if a.InjectionType == "" {
@@ -110,22 +247,50 @@ type Elevation struct {
 	Reason      string    `json:"reason"`
 	Status      string    `json:"status"` // pending, approved, denied, expired, revoked
 	RequestedAt time.Time `json:"requestedAt"`
+	RequestedBy string    `json:"requestedBy,omitempty"` // Identity that raised the request
 	ApprovedAt  *time.Time `json:"approvedAt,omitempty"`
 	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
 	ApprovedBy  string    `json:"approvedBy,omitempty"`
+
+	// TTL the requester asked for, already capped at the credential's
+	// MaxTTL; approvers default to it. Zero if none was asked for.
+	RequestedTTL time.Duration `json:"requestedTTL,omitempty"`
+
+	// URL the requesting agent asked to be called back on when the request
+	// is decided or revoked; checked against the allowlist when requested
+	CallbackURL string `json:"callbackUrl,omitempty"`
+
+	// On-call routing: who the request is currently assigned to
+	AssignedTo  []string   `json:"assignedTo,omitempty"`
+	RoutedAt    *time.Time `json:"routedAt,omitempty"`
+	EscalatedAt *time.Time `json:"escalatedAt,omitempty"`
+
+	// Reminders sent while the request sat unactioned
+	RemindersSent int `json:"remindersSent,omitempty"`
 }
 
 // AuditEntry represents an audit log entry.
 type AuditEntry struct {
-	ID        string    `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	Action    string    `json:"action"` // credential_access, elevation_request, elevation_approved, etc.
-	Service   string    `json:"service,omitempty"`
-	Scope     string    `json:"scope,omitempty"`
-	Details   string    `json:"details,omitempty"`
-	Actor     string    `json:"actor"` // agent, admin:<user>, system
+	ID        string      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Action    AuditAction `json:"action"` // One of the Action* constants
+	Service   string      `json:"service,omitempty"`
+	Scope     string      `json:"scope,omitempty"`
+	Details   string      `json:"details,omitempty"`
+	Actor     string      `json:"actor"` // agent, admin:<user>, system
+
+	// Request context, for entries recorded while handling an API request
+	RequestID   string `json:"requestId,omitempty"`
+	SourceIP    string `json:"sourceIp,omitempty"`
+	UserAgent   string `json:"userAgent,omitempty"`
+	ElevationID string `json:"elevationId,omitempty"` // The elevation the entry is about, if any
 }
 
+// busyTimeoutMillis is how long a statement waits for another connection's
+// lock, e.g. 'ocm serve' writing during 'ocm db compact', before failing
+// with "database is locked".
+const busyTimeoutMillis = 60000
+
 // New creates a new Store with the given database path and master key.
 func New(dbPath string, masterKey []byte) (*Store, error) {
 	if len(masterKey) != 32 {
@@ -143,7 +308,7 @@ func New(dbPath string, masterKey []byte) (*Store, error) {
 	}
 
 	// Open database
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_foreign_keys=on")
+	db, err := sql.Open("sqlite3", fmt.Sprintf("%s?_journal_mode=WAL&_foreign_keys=on&_busy_timeout=%d", dbPath, busyTimeoutMillis))
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -152,12 +317,20 @@ func New(dbPath string, masterKey []byte) (*Store, error) {
 		db:        db,
 		masterKey: masterKey,
 		gcm:       gcm,
+		cache:     newReadCache(DefaultCacheTTL),
 	}
 
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
+	// Fingerprint credentials saved before fingerprints were kept
+	if s.schemaUpgraded {
+		if err := s.fingerprintAll(); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("fingerprint credentials: %w", err)
+		}
+	}
 
 	return s, nil
 }
@@ -167,8 +340,69 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
+// BackupTo writes a consistent copy of the database to path, which must not
+// exist. The copy is encrypted with the same master key.
+func (s *Store) BackupTo(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`VACUUM INTO ?`, path)
+	return err
+}
+
+// Check verifies that the database answers and passes SQLite's quick
+// integrity check.
+func (s *Store) Check() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result string
+	if err := s.db.QueryRow(`PRAGMA quick_check`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// Ready verifies that the database answers and that the master key decrypts
+// a stored credential. It is cheap enough for a readiness probe, unlike
+// Check and VerifyEncryption. With no credentials stored yet, it checks that
+// the key can seal and open a value.
+func (s *Store) Ready() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sealed []byte
+	err := s.db.QueryRow(`SELECT scopes_encrypted FROM credentials WHERE length(scopes_encrypted) > 0 LIMIT 1`).Scan(&sealed)
+	switch {
+	case err == sql.ErrNoRows:
+		if sealed, err = s.encrypt([]byte("ready")); err != nil {
+			return fmt.Errorf("encrypt: %w", err)
+		}
+	case err != nil:
+		return err
+	}
+	if _, err := s.decrypt(sealed); err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	return nil
+}
+
+// SchemaUpgraded reports whether opening the store created or changed the
+// schema.
+func (s *Store) SchemaUpgraded() bool {
+	return s.schemaUpgraded
+}
+
 // migrate runs database migrations.
 func (s *Store) migrate() error {
+	// schema_version changes whenever the schema does
+	var before, after int
+	if err := s.db.QueryRow(`PRAGMA schema_version`).Scan(&before); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
 	migrations := []string{
 		`CREATE TABLE IF NOT EXISTS credentials (
 			id TEXT PRIMARY KEY,
@@ -204,13 +438,160 @@ func (s *Store) migrate() error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_log(timestamp)`,
 		`CREATE INDEX IF NOT EXISTS idx_audit_service ON audit_log(service)`,
+		`CREATE TABLE IF NOT EXISTS guest_invites (
+			id TEXT PRIMARY KEY,
+			elevation_id TEXT NOT NULL,
+			guest TEXT NOT NULL,
+			max_ttl INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME,
+			decision TEXT,
+			FOREIGN KEY (elevation_id) REFERENCES elevations(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_guest_invites_elevation ON guest_invites(elevation_id)`,
+		`ALTER TABLE elevations ADD COLUMN assigned_to TEXT`,
+		`ALTER TABLE elevations ADD COLUMN routed_at DATETIME`,
+		`ALTER TABLE elevations ADD COLUMN escalated_at DATETIME`,
+		`ALTER TABLE elevations ADD COLUMN requested_by TEXT`,
+		`ALTER TABLE elevations ADD COLUMN reminders_sent INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE elevations ADD COLUMN requested_ttl INTEGER NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS elevation_presets (
+			service TEXT NOT NULL,
+			name TEXT NOT NULL,
+			ttl INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (service, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret_encrypted BLOB,
+			events TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER,
+			last_error TEXT,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at)`,
+		`CREATE TABLE IF NOT EXISTS gateway_ops (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			gateway TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			payload_encrypted BLOB,
+			reason TEXT,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS injection_status (
+			service TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			detail TEXT,
+			checked_at DATETIME NOT NULL
+		)`,
+		`ALTER TABLE audit_log ADD COLUMN request_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN source_ip TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN user_agent TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN elevation_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN seq INTEGER`,
+		`ALTER TABLE audit_log ADD COLUMN prev_hash TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN hash TEXT NOT NULL DEFAULT ''`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_seq ON audit_log(seq)`,
+		`CREATE TABLE IF NOT EXISTS approval_receipts (
+			elevation_id TEXT PRIMARY KEY,
+			payload BLOB NOT NULL,
+			signature BLOB NOT NULL,
+			public_key BLOB NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS leases (
+			id TEXT PRIMARY KEY,
+			service TEXT NOT NULL,
+			scope TEXT NOT NULL,
+			elevation_id TEXT NOT NULL DEFAULT '',
+			issued_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			renewed_at DATETIME,
+			revoked_at DATETIME,
+			revoke_reason TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_leases_service ON leases(service, expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_leases_elevation ON leases(elevation_id)`,
+		`CREATE TABLE IF NOT EXISTS checkouts (
+			id TEXT PRIMARY KEY,
+			service TEXT NOT NULL,
+			holder TEXT NOT NULL,
+			elevation_id TEXT NOT NULL DEFAULT '',
+			checked_out_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			checked_in_at DATETIME,
+			check_in_reason TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_checkouts_service ON checkouts(service, expires_at)`,
+		`ALTER TABLE elevations ADD COLUMN callback_url TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS credential_versions (
+			id TEXT PRIMARY KEY,
+			service TEXT NOT NULL,
+			level TEXT NOT NULL,
+			data_encrypted BLOB NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			archived_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_credential_versions_service ON credential_versions(service, level, archived_at)`,
+		`CREATE TABLE IF NOT EXISTS credential_usage (
+			service TEXT NOT NULL,
+			scope TEXT NOT NULL,
+			via TEXT NOT NULL,
+			used_at DATETIME NOT NULL,
+			PRIMARY KEY (service, scope)
+		)`,
+		`CREATE TABLE IF NOT EXISTS credential_health (
+			service TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			detail TEXT,
+			checked_at DATETIME NOT NULL
+		)`,
+		`ALTER TABLE credential_usage ADD COLUMN accessed_at DATETIME`,
+		`CREATE TABLE IF NOT EXISTS credential_fingerprints (
+			service TEXT NOT NULL,
+			level TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			PRIMARY KEY (service, level)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_credential_fingerprints ON credential_fingerprints(fingerprint)`,
+		`ALTER TABLE gateway_ops ADD COLUMN failed_at DATETIME`,
 	}
 
 	for _, m := range migrations {
 		if _, err := s.db.Exec(m); err != nil {
+			// ADD COLUMN has no IF NOT EXISTS; re-running it on an upgraded DB is a no-op
+			if strings.HasPrefix(m, "ALTER TABLE") && strings.Contains(err.Error(), "duplicate column name") {
+				continue
+			}
 			return fmt.Errorf("execute migration: %w", err)
 		}
 	}
+
+	if err := s.db.QueryRow(`PRAGMA schema_version`).Scan(&after); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	s.schemaUpgraded = after != before
 	return nil
 }
 
@@ -229,13 +610,35 @@ func (s *Store) decrypt(ciphertext []byte) ([]byte, error) {
 		return nil, fmt.Errorf("ciphertext too short")
 	}
 	nonce, ciphertext := ciphertext[:s.gcm.NonceSize()], ciphertext[s.gcm.NonceSize():]
-	return s.gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := s.gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil && s.onDecryptError != nil {
+		s.onDecryptError(err)
+	}
+	return plaintext, err
+}
+
+// MAC returns an HMAC-SHA256 of data using a key derived from the master key.
+// The purpose string domain-separates derived keys, so a MAC minted for one
+// use (e.g., guest links) can never be replayed as another.
+func (s *Store) MAC(purpose string, data []byte) []byte {
+	kdf := hmac.New(sha256.New, s.masterKey)
+	kdf.Write([]byte("ocm:" + purpose))
+	mac := hmac.New(sha256.New, kdf.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil)
 }
 
 // credentialData is the internal storage format for credentials.
 type credentialData struct {
-	Read      *AccessLevel `json:"read"`
-	ReadWrite *AccessLevel `json:"readWrite,omitempty"`
+	Read          *AccessLevel   `json:"read"`
+	ReadWrite     *AccessLevel   `json:"readWrite,omitempty"`
+	AccessWebhook *AccessWebhook `json:"accessWebhook,omitempty"`
+	Rotation      *Rotation      `json:"rotation,omitempty"`
+
+	MaxConcurrentElevations int               `json:"maxConcurrentElevations,omitempty"`
+	ElevationOverflow       ElevationOverflow `json:"elevationOverflow,omitempty"`
+	ExclusiveCheckout       bool              `json:"exclusiveCheckout,omitempty"`
+	Gateway                 string            `json:"gateway,omitempty"`
 }
 
 // SaveCredential saves or updates a credential.
@@ -243,18 +646,9 @@ func (s *Store) SaveCredential(cred *Credential) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Serialize and encrypt access levels
-	data := credentialData{
-		Read:      cred.Read,
-		ReadWrite: cred.ReadWrite,
-	}
-	dataJSON, err := json.Marshal(data)
+	encrypted, err := s.sealCredentialData(cred)
 	if err != nil {
-		return fmt.Errorf("marshal credential data: %w", err)
-	}
-	encrypted, err := s.encrypt(dataJSON)
-	if err != nil {
-		return fmt.Errorf("encrypt credential data: %w", err)
+		return err
 	}
 
 	now := time.Now()
@@ -270,10 +664,63 @@ func (s *Store) SaveCredential(cred *Credential) error {
 	if err != nil {
 		return fmt.Errorf("save credential: %w", err)
 	}
+	if err := saveFingerprints(s.db, cred.Service, s.fingerprints(cred)); err != nil {
+		return fmt.Errorf("save credential fingerprints: %w", err)
+	}
+	s.invalidateCredentials()
 
 	return nil
 }
 
+// redactSecrets registers cred's secret values with the log redactor, so
+// they can't leak into logs once they have been loaded or saved.
+func redactSecrets(cred *Credential) {
+	for _, level := range []*AccessLevel{cred.Read, cred.ReadWrite} {
+		if level == nil {
+			continue
+		}
+		redact.Register(level.Token, level.RefreshToken)
+		for _, f := range level.AdditionalFields {
+			redact.Register(f.Value)
+		}
+		if level.Derive != nil {
+			redact.Register(level.Derive.ClientSecret)
+		}
+	}
+	if cred.AccessWebhook != nil {
+		redact.Register(cred.AccessWebhook.Secret)
+	}
+	if cred.Rotation != nil {
+		redact.Register(cred.Rotation.Secret, cred.Rotation.ClientSecret)
+	}
+}
+
+// sealCredentialData serializes and encrypts a credential's access levels
+// and settings for the scopes_encrypted column.
+func (s *Store) sealCredentialData(cred *Credential) ([]byte, error) {
+	redactSecrets(cred)
+	data := credentialData{
+		Read:          cred.Read,
+		ReadWrite:     cred.ReadWrite,
+		AccessWebhook: cred.AccessWebhook,
+		Rotation:      cred.Rotation,
+
+		MaxConcurrentElevations: cred.MaxConcurrentElevations,
+		ElevationOverflow:       cred.ElevationOverflow,
+		ExclusiveCheckout:       cred.ExclusiveCheckout,
+		Gateway:                 cred.Gateway,
+	}
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential data: %w", err)
+	}
+	encrypted, err := s.encrypt(dataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt credential data: %w", err)
+	}
+	return encrypted, nil
+}
+
 // GetCredential retrieves a credential by service name.
 func (s *Store) GetCredential(service string) (*Credential, error) {
 	s.mu.RLock()
@@ -281,16 +728,29 @@ func (s *Store) GetCredential(service string) (*Credential, error) {
 
 	var cred Credential
 	var encrypted []byte
+	var inj injectionRow
+	var health healthRow
 	err := s.db.QueryRow(`
-		SELECT id, service, display_name, type, scopes_encrypted, created_at, updated_at
-		FROM credentials WHERE service = ?
-	`, service).Scan(&cred.ID, &cred.Service, &cred.DisplayName, &cred.Type, &encrypted, &cred.CreatedAt, &cred.UpdatedAt)
+		SELECT c.id, c.service, c.display_name, c.type, c.scopes_encrypted, c.created_at, c.updated_at,
+			i.status, i.detail, i.checked_at, h.status, h.detail, h.checked_at
+		FROM credentials c LEFT JOIN injection_status i ON i.service = c.service
+		LEFT JOIN credential_health h ON h.service = c.service
+		WHERE c.service = ?
+	`, service).Scan(&cred.ID, &cred.Service, &cred.DisplayName, &cred.Type, &encrypted, &cred.CreatedAt, &cred.UpdatedAt,
+		&inj.state, &inj.detail, &inj.checkedAt, &health.state, &health.detail, &health.checkedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("query credential: %w", err)
 	}
+	cred.Injection = inj.toStatus()
+	cred.Health = health.toHealth()
+	usage, err := s.credentialUsage(service)
+	if err != nil {
+		return nil, fmt.Errorf("query credential usage: %w", err)
+	}
+	cred.setUsage(usage[service])
 
 	// Decrypt and deserialize
 	decrypted, err := s.decrypt(encrypted)
@@ -303,6 +763,13 @@ func (s *Store) GetCredential(service string) (*Credential, error) {
 	if err := json.Unmarshal(decrypted, &data); err == nil && data.Read != nil {
 		cred.Read = data.Read
 		cred.ReadWrite = data.ReadWrite
+		cred.AccessWebhook = data.AccessWebhook
+		cred.Rotation = data.Rotation
+		cred.MaxConcurrentElevations = data.MaxConcurrentElevations
+		cred.ElevationOverflow = data.ElevationOverflow
+		cred.ExclusiveCheckout = data.ExclusiveCheckout
+		cred.Gateway = data.Gateway
+		redactSecrets(&cred)
 		return &cred, nil
 	}
 
@@ -342,14 +809,21 @@ func (s *Store) GetCredential(service string) (*Credential, error) {
 	return &cred, nil
 }
 
-// ListCredentials returns all credentials (without decrypted tokens).
-func (s *Store) ListCredentials() ([]*Credential, error) {
+// listCredentials queries and decrypts all credentials, clearing secret values.
+func (s *Store) listCredentials() ([]*Credential, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	usage, err := s.credentialUsage("")
+	if err != nil {
+		return nil, fmt.Errorf("query credential usage: %w", err)
+	}
 	rows, err := s.db.Query(`
-		SELECT id, service, display_name, type, scopes_encrypted, created_at, updated_at
-		FROM credentials ORDER BY service
+		SELECT c.id, c.service, c.display_name, c.type, c.scopes_encrypted, c.created_at, c.updated_at,
+			i.status, i.detail, i.checked_at, h.status, h.detail, h.checked_at
+		FROM credentials c LEFT JOIN injection_status i ON i.service = c.service
+		LEFT JOIN credential_health h ON h.service = c.service
+		ORDER BY c.service
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("query credentials: %w", err)
@@ -360,9 +834,15 @@ func (s *Store) ListCredentials() ([]*Credential, error) {
 	for rows.Next() {
 		var cred Credential
 		var encrypted []byte
-		if err := rows.Scan(&cred.ID, &cred.Service, &cred.DisplayName, &cred.Type, &encrypted, &cred.CreatedAt, &cred.UpdatedAt); err != nil {
+		var inj injectionRow
+		var health healthRow
+		if err := rows.Scan(&cred.ID, &cred.Service, &cred.DisplayName, &cred.Type, &encrypted, &cred.CreatedAt, &cred.UpdatedAt,
+			&inj.state, &inj.detail, &inj.checkedAt, &health.state, &health.detail, &health.checkedAt); err != nil {
 			return nil, fmt.Errorf("scan credential: %w", err)
 		}
+		cred.Injection = inj.toStatus()
+		cred.Health = health.toHealth()
+		cred.setUsage(usage[cred.Service])
 
 		decrypted, err := s.decrypt(encrypted)
 		if err != nil {
@@ -374,6 +854,13 @@ func (s *Store) ListCredentials() ([]*Credential, error) {
 		if err := json.Unmarshal(decrypted, &data); err == nil && data.Read != nil {
 			cred.Read = data.Read
 			cred.ReadWrite = data.ReadWrite
+			cred.AccessWebhook = data.AccessWebhook
+			cred.Rotation = data.Rotation
+			cred.MaxConcurrentElevations = data.MaxConcurrentElevations
+			cred.ElevationOverflow = data.ElevationOverflow
+			cred.ExclusiveCheckout = data.ExclusiveCheckout
+			cred.Gateway = data.Gateway
+			redactSecrets(&cred)
 		} else {
 			// Fall back to legacy format
 			var scopes map[string]*Scope
@@ -406,6 +893,24 @@ func (s *Store) ListCredentials() ([]*Credential, error) {
 			cred.ReadWrite.Token = ""
 			cred.ReadWrite.RefreshToken = ""
 		}
+		for _, level := range []*AccessLevel{cred.Read, cred.ReadWrite} {
+			if level == nil {
+				continue
+			}
+			for i := range level.AdditionalFields {
+				level.AdditionalFields[i].Value = ""
+			}
+			if level.Derive != nil {
+				level.Derive.ClientSecret = ""
+			}
+		}
+		if cred.AccessWebhook != nil {
+			cred.AccessWebhook.Secret = ""
+		}
+		if cred.Rotation != nil {
+			cred.Rotation.Secret = ""
+			cred.Rotation.ClientSecret = ""
+		}
 
 		creds = append(creds, &cred)
 	}
@@ -418,7 +923,26 @@ func (s *Store) DeleteCredential(service string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, err := s.db.Exec(`DELETE FROM elevation_presets WHERE service = ?`, service); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM injection_status WHERE service = ?`, service); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM credential_versions WHERE service = ?`, service); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM credential_health WHERE service = ?`, service); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM credential_usage WHERE service = ?`, service); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM credential_fingerprints WHERE service = ?`, service); err != nil {
+		return err
+	}
 	_, err := s.db.Exec(`DELETE FROM credentials WHERE service = ?`, service)
+	s.InvalidateCache()
 	return err
 }
 
@@ -428,31 +952,35 @@ func (s *Store) CreateElevation(elev *Elevation) error {
 	defer s.mu.Unlock()
 
 	_, err := s.db.Exec(`
-		INSERT INTO elevations (id, service, scope, reason, status, requested_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, elev.ID, elev.Service, elev.Scope, elev.Reason, elev.Status, elev.RequestedAt)
+		INSERT INTO elevations (id, service, scope, reason, status, requested_at, requested_by, requested_ttl, callback_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, elev.ID, elev.Service, elev.Scope, elev.Reason, elev.Status, elev.RequestedAt, elev.RequestedBy, int64(elev.RequestedTTL), elev.CallbackURL)
+	s.invalidateElevations()
 	return err
 }
 
-// GetElevation retrieves an elevation by ID.
-func (s *Store) GetElevation(id string) (*Elevation, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// elevationColumns is the column list scanned by scanElevation.
+const elevationColumns = `id, service, scope, reason, status, requested_at, approved_at, expires_at, approved_by,
+	assigned_to, routed_at, escalated_at, requested_by, reminders_sent, requested_ttl, callback_url`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanElevation scans a row selected with elevationColumns.
+func scanElevation(row rowScanner) (*Elevation, error) {
 	var elev Elevation
-	var approvedAt, expiresAt sql.NullTime
-	var approvedBy sql.NullString
-	err := s.db.QueryRow(`
-		SELECT id, service, scope, reason, status, requested_at, approved_at, expires_at, approved_by
-		FROM elevations WHERE id = ?
-	`, id).Scan(&elev.ID, &elev.Service, &elev.Scope, &elev.Reason, &elev.Status,
-		&elev.RequestedAt, &approvedAt, &expiresAt, &approvedBy)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
+	var approvedAt, expiresAt, routedAt, escalatedAt sql.NullTime
+	var approvedBy, assignedTo, requestedBy sql.NullString
+	var requestedTTL int64
+	if err := row.Scan(&elev.ID, &elev.Service, &elev.Scope, &elev.Reason, &elev.Status,
+		&elev.RequestedAt, &approvedAt, &expiresAt, &approvedBy,
+		&assignedTo, &routedAt, &escalatedAt, &requestedBy, &elev.RemindersSent, &requestedTTL, &elev.CallbackURL); err != nil {
 		return nil, err
 	}
+	elev.RequestedTTL = time.Duration(requestedTTL)
+	elev.RequestedBy = requestedBy.String
 	if approvedAt.Valid {
 		elev.ApprovedAt = &approvedAt.Time
 	}
@@ -462,40 +990,48 @@ func (s *Store) GetElevation(id string) (*Elevation, error) {
 	if approvedBy.Valid {
 		elev.ApprovedBy = approvedBy.String
 	}
+	if assignedTo.Valid && assignedTo.String != "" {
+		elev.AssignedTo = strings.Split(assignedTo.String, ",")
+	}
+	if routedAt.Valid {
+		elev.RoutedAt = &routedAt.Time
+	}
+	if escalatedAt.Valid {
+		elev.EscalatedAt = &escalatedAt.Time
+	}
 	return &elev, nil
 }
 
-// GetActiveElevation returns an active (approved, not expired) elevation for a service/scope.
-func (s *Store) GetActiveElevation(service, scope string) (*Elevation, error) {
+// GetElevation retrieves an elevation by ID.
+func (s *Store) GetElevation(id string) (*Elevation, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var elev Elevation
-	var approvedAt, expiresAt sql.NullTime
-	var approvedBy sql.NullString
-	err := s.db.QueryRow(`
-		SELECT id, service, scope, reason, status, requested_at, approved_at, expires_at, approved_by
+	elev, err := scanElevation(s.db.QueryRow(`
+		SELECT `+elevationColumns+`
+		FROM elevations WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return elev, err
+}
+
+// getActiveElevation queries the active elevation for a service/scope, bypassing the cache.
+func (s *Store) getActiveElevation(service, scope string) (*Elevation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	elev, err := scanElevation(s.db.QueryRow(`
+		SELECT `+elevationColumns+`
 		FROM elevations 
 		WHERE service = ? AND scope = ? AND status = 'approved' AND expires_at > datetime('now')
 		ORDER BY expires_at DESC LIMIT 1
-	`, service, scope).Scan(&elev.ID, &elev.Service, &elev.Scope, &elev.Reason, &elev.Status,
-		&elev.RequestedAt, &approvedAt, &expiresAt, &approvedBy)
+	`, service, scope))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	if approvedAt.Valid {
-		elev.ApprovedAt = &approvedAt.Time
-	}
-	if expiresAt.Valid {
-		elev.ExpiresAt = &expiresAt.Time
-	}
-	if approvedBy.Valid {
-		elev.ApprovedBy = approvedBy.String
-	}
-	return &elev, nil
+	return elev, err
 }
 
 // UpdateElevation updates an elevation's status.
@@ -509,16 +1045,129 @@ func (s *Store) UpdateElevation(id string, status string, approvedBy string, exp
 		SET status = ?, approved_at = ?, expires_at = ?, approved_by = ?
 		WHERE id = ?
 	`, status, now, expiresAt, approvedBy, id)
+	s.invalidateElevations()
 	return err
 }
 
+// AssignElevation records the approvers a pending elevation is routed to.
+// When escalated is true the escalation time is recorded as well.
+func (s *Store) AssignElevation(id string, approvers []string, escalated bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var escalatedAt interface{}
+	if escalated {
+		escalatedAt = now
+	}
+	_, err := s.db.Exec(`
+		UPDATE elevations
+		SET assigned_to = ?, routed_at = ?, escalated_at = COALESCE(?, escalated_at)
+		WHERE id = ? AND status = 'pending'
+	`, strings.Join(approvers, ","), now, escalatedAt, id)
+	return err
+}
+
+// SetElevationReminders records how many reminders a pending elevation has had.
+func (s *Store) SetElevationReminders(id string, sent int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`UPDATE elevations SET reminders_sent = ? WHERE id = ? AND status = 'pending'`, sent, id)
+	return err
+}
+
+// CountActiveElevations returns the number of approved, unexpired elevations
+// for a service across all scopes.
+func (s *Store) CountActiveElevations(service string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM elevations
+		WHERE service = ? AND status = 'approved' AND expires_at > datetime('now')
+	`, service).Scan(&n)
+	return n, err
+}
+
+// ListElevationsByStatus returns elevations in the given status for a service, oldest first.
+func (s *Store) ListElevationsByStatus(status, service string) ([]*Elevation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT `+elevationColumns+`
+		FROM elevations WHERE status = ? AND service = ? ORDER BY requested_at ASC
+	`, status, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var elevs []*Elevation
+	for rows.Next() {
+		elev, err := scanElevation(rows)
+		if err != nil {
+			return nil, err
+		}
+		elevs = append(elevs, elev)
+	}
+	return elevs, rows.Err()
+}
+
+// ListActiveElevations returns the approved elevations that haven't expired
+// yet, soonest to expire first.
+func (s *Store) ListActiveElevations() ([]*Elevation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT ` + elevationColumns + ` FROM elevations WHERE status = 'approved'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var elevs []*Elevation
+	for rows.Next() {
+		elev, err := scanElevation(rows)
+		if err != nil {
+			return nil, err
+		}
+		if elev.ExpiresAt != nil && elev.ExpiresAt.After(now) {
+			elevs = append(elevs, elev)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(elevs, func(i, j int) bool { return elevs[i].ExpiresAt.Before(*elevs[j].ExpiresAt) })
+	return elevs, nil
+}
+
+// TransitionElevation moves an elevation from one status to another without
+// touching approval fields. Returns false if it was not in the from status.
+func (s *Store) TransitionElevation(id, from, to string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`UPDATE elevations SET status = ? WHERE id = ? AND status = ?`, to, id, from)
+	if err != nil {
+		return false, err
+	}
+	s.invalidateElevations()
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 // ListPendingElevations returns all pending elevation requests.
 func (s *Store) ListPendingElevations() ([]*Elevation, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	rows, err := s.db.Query(`
-		SELECT id, service, scope, reason, status, requested_at, approved_at, expires_at, approved_by
+		SELECT ` + elevationColumns + `
 		FROM elevations WHERE status = 'pending' ORDER BY requested_at DESC
 	`)
 	if err != nil {
@@ -528,54 +1177,61 @@ func (s *Store) ListPendingElevations() ([]*Elevation, error) {
 
 	var elevs []*Elevation
 	for rows.Next() {
-		var elev Elevation
-		var approvedAt, expiresAt sql.NullTime
-		var approvedBy sql.NullString
-		if err := rows.Scan(&elev.ID, &elev.Service, &elev.Scope, &elev.Reason, &elev.Status,
-			&elev.RequestedAt, &approvedAt, &expiresAt, &approvedBy); err != nil {
+		elev, err := scanElevation(rows)
+		if err != nil {
 			return nil, err
 		}
-		if approvedAt.Valid {
-			elev.ApprovedAt = &approvedAt.Time
-		}
-		if expiresAt.Valid {
-			elev.ExpiresAt = &expiresAt.Time
-		}
-		if approvedBy.Valid {
-			elev.ApprovedBy = approvedBy.String
-		}
-		elevs = append(elevs, &elev)
+		elevs = append(elevs, elev)
 	}
 	return elevs, rows.Err()
 }
 
-// AddAuditEntry adds an entry to the audit log.
+// AddAuditEntry adds an entry to the audit log, via the audit sink if one is set.
 func (s *Store) AddAuditEntry(entry *AuditEntry) error {
+	if !entry.Action.Valid() {
+		return fmt.Errorf("%w %q", ErrUnknownAuditAction, entry.Action)
+	}
+	s.mu.RLock()
+	sink := s.auditSink
+	s.mu.RUnlock()
+	if sink != nil {
+		return sink.WriteAudit(entry)
+	}
+	return s.InsertAuditEntry(entry)
+}
+
+// InsertAuditEntry writes an entry straight to the audit_log table, bypassing the sink.
+func (s *Store) InsertAuditEntry(entry *AuditEntry) error {
+	if !entry.Action.Valid() {
+		return fmt.Errorf("%w %q", ErrUnknownAuditAction, entry.Action)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec(`
-		INSERT INTO audit_log (id, timestamp, action, service, scope, details, actor)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, entry.ID, entry.Timestamp, entry.Action, entry.Service, entry.Scope, entry.Details, entry.Actor)
+	seq, prevHash, hash, err := s.chainAuditEntry(entry)
+	if err != nil {
+		return fmt.Errorf("chain audit entry: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO audit_log (id, timestamp, action, service, scope, details, actor,
+			request_id, source_ip, user_agent, elevation_id, seq, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.Timestamp, entry.Action, entry.Service, entry.Scope, entry.Details, entry.Actor,
+		entry.RequestID, entry.SourceIP, entry.UserAgent, entry.ElevationID, seq, prevHash, hash)
 	return err
 }
 
-// ListAuditEntries returns recent audit entries.
-func (s *Store) ListAuditEntries(limit int, service string) ([]*AuditEntry, error) {
+// ListAuditEntriesBetween returns audit entries in [from, to), oldest first.
+func (s *Store) ListAuditEntriesBetween(from, to time.Time) ([]*AuditEntry, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	query := `SELECT id, timestamp, action, service, scope, details, actor FROM audit_log`
-	args := []interface{}{}
-	if service != "" {
-		query += ` WHERE service = ?`
-		args = append(args, service)
-	}
-	query += ` ORDER BY timestamp DESC LIMIT ?`
-	args = append(args, limit)
-
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.Query(`
+		SELECT `+auditColumns+` FROM audit_log
+		WHERE timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp
+	`, from.Local(), to.Local())
 	if err != nil {
 		return nil, err
 	}
@@ -583,12 +1239,17 @@ func (s *Store) ListAuditEntries(limit int, service string) ([]*AuditEntry, erro
 
 	var entries []*AuditEntry
 	for rows.Next() {
-		var entry AuditEntry
-		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Action, &entry.Service,
-			&entry.Scope, &entry.Details, &entry.Actor); err != nil {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
 			return nil, err
 		}
-		entries = append(entries, &entry)
+		entries = append(entries, entry)
 	}
 	return entries, rows.Err()
 }
+
+// ListAuditEntries returns recent audit entries.
+func (s *Store) ListAuditEntries(limit int, service string) ([]*AuditEntry, error) {
+	entries, _, err := s.QueryAuditEntries(AuditQuery{Service: service, Limit: limit})
+	return entries, err
+}
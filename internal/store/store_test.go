@@ -1,7 +1,12 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -74,6 +79,14 @@ func TestEncryptDecrypt(t *testing.T) {
 	if string(decrypted) != string(plaintext) {
 		t.Errorf("decrypt() = %s, want %s", decrypted, plaintext)
 	}
+
+	// Tampered ciphertext fails and is reported
+	var reported error
+	s.OnDecryptError(func(err error) { reported = err })
+	encrypted[len(encrypted)-1] ^= 0xff
+	if _, err := s.decrypt(encrypted); err == nil || reported == nil {
+		t.Errorf("tampered ciphertext: err = %v, reported = %v", err, reported)
+	}
 }
 
 func TestCredentialCRUD(t *testing.T) {
@@ -101,19 +114,14 @@ func TestCredentialCRUD(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail (Personal)",
 		Type:        "oauth2",
-		Scopes: map[string]*Scope{
-			"read": {
-				Name:      "read",
-				Permanent: true,
-				Token:     "read-token-123",
-			},
-			"write": {
-				Name:             "write",
-				Permanent:        false,
-				RequiresApproval: true,
-				MaxTTL:           time.Hour,
-				Token:            "write-token-456",
-			},
+		Read: &AccessLevel{
+			EnvVar: "GMAIL_TOKEN",
+			Token:  "read-token-123",
+		},
+		ReadWrite: &AccessLevel{
+			EnvVar: "GMAIL_WRITE_TOKEN",
+			Token:  "write-token-456",
+			MaxTTL: time.Hour,
 		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -135,11 +143,11 @@ func TestCredentialCRUD(t *testing.T) {
 	if got.Service != "gmail" {
 		t.Errorf("GetCredential().Service = %s, want gmail", got.Service)
 	}
-	if got.Scopes["read"].Token != "read-token-123" {
-		t.Errorf("GetCredential().Scopes[read].Token = %s, want read-token-123", got.Scopes["read"].Token)
+	if got.Read.Token != "read-token-123" {
+		t.Errorf("GetCredential().Read.Token = %s, want read-token-123", got.Read.Token)
 	}
-	if got.Scopes["write"].RequiresApproval != true {
-		t.Error("GetCredential().Scopes[write].RequiresApproval should be true")
+	if got.ReadWrite == nil || got.ReadWrite.MaxTTL != time.Hour {
+		t.Error("GetCredential().ReadWrite.MaxTTL should be 1h")
 	}
 
 	// List
@@ -151,7 +159,7 @@ func TestCredentialCRUD(t *testing.T) {
 		t.Errorf("ListCredentials() len = %d, want 1", len(list))
 	}
 	// Tokens should be cleared in list
-	if list[0].Scopes["read"].Token != "" {
+	if list[0].Read.Token != "" {
 		t.Error("ListCredentials() should not include tokens")
 	}
 
@@ -193,7 +201,8 @@ func TestElevationWorkflow(t *testing.T) {
 		Service:     "gmail",
 		DisplayName: "Gmail",
 		Type:        "oauth2",
-		Scopes:      map[string]*Scope{"write": {Name: "write", RequiresApproval: true}},
+		Read:        &AccessLevel{EnvVar: "GMAIL_TOKEN"},
+		ReadWrite:   &AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN"},
 	}
 	if err := s.SaveCredential(cred); err != nil {
 		t.Fatal(err)
@@ -311,3 +320,858 @@ func TestAuditLog(t *testing.T) {
 		t.Errorf("ListAuditEntries(gmail) len = %d, want 2", len(entries))
 	}
 }
+
+func TestQueryAuditEntries(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	masterKey := make([]byte, 32)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	s, err := New(tmpFile.Name(), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Five entries a minute apart; the last two share a timestamp
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []AuditEntry{
+		{ID: "audit-1", Action: "credential_access", Service: "gmail", Actor: "agent"},
+		{ID: "audit-2", Action: "elevation_approved", Service: "gmail", Actor: "admin:alice"},
+		{ID: "audit-3", Action: "credential_access", Service: "slack", Actor: "agent"},
+		{ID: "audit-4", Action: "credential_access", Service: "gmail", Actor: "agent"},
+		{ID: "audit-5", Action: "elevation_approved", Service: "slack", Actor: "admin:bob"},
+	} {
+		e := e
+		e.Timestamp = base.Add(time.Duration(min(i, 3)) * time.Minute)
+		if err := s.AddAuditEntry(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(entries []*AuditEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+
+	// Page through everything two at a time
+	var got []string
+	cursor := ""
+	for page := 0; ; page++ {
+		entries, next, err := s.QueryAuditEntries(AuditQuery{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ids(entries)...)
+		if next == "" {
+			break
+		}
+		if page > 3 {
+			t.Fatal("too many pages")
+		}
+		cursor = next
+	}
+	if want := []string{"audit-5", "audit-4", "audit-3", "audit-2", "audit-1"}; !equalStrings(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		q    AuditQuery
+		want []string
+	}{
+		{"action", AuditQuery{Action: "elevation_approved"}, []string{"audit-5", "audit-2"}},
+		{"actor", AuditQuery{Actor: "agent"}, []string{"audit-4", "audit-3", "audit-1"}},
+		{"service and action", AuditQuery{Service: "gmail", Action: "credential_access"}, []string{"audit-4", "audit-1"}},
+		{"time range", AuditQuery{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)}, []string{"audit-3", "audit-2"}},
+	}
+	for _, tt := range tests {
+		entries, next, err := s.QueryAuditEntries(tt.q)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := ids(entries); !equalStrings(got, tt.want) || next != "" {
+			t.Errorf("%s: got %v (next %q), want %v", tt.name, got, next, tt.want)
+		}
+	}
+
+	if _, _, err := s.QueryAuditEntries(AuditQuery{Cursor: "not-a-cursor"}); err != ErrInvalidCursor {
+		t.Errorf("bad cursor: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestElevationPresets(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	masterKey := make([]byte, 32)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	s, err := New(tmpFile.Name(), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, p := range []*ElevationPreset{
+		{Service: "github", Name: "deploy window", TTL: time.Hour, CreatedAt: time.Now()},
+		{Service: "github", Name: "quick fix", TTL: 10 * time.Minute, CreatedAt: time.Now()},
+		{Service: "gmail", Name: "quick fix", TTL: 5 * time.Minute, CreatedAt: time.Now()},
+	} {
+		if err := s.SavePreset(p); err != nil {
+			t.Fatalf("SavePreset() error = %v", err)
+		}
+	}
+
+	presets, err := s.ListPresets("github")
+	if err != nil {
+		t.Fatalf("ListPresets() error = %v", err)
+	}
+	if len(presets) != 2 || presets[0].Name != "quick fix" {
+		t.Fatalf("ListPresets(github) = %v, want 2 presets ordered by TTL", presets)
+	}
+
+	// Saving again updates the TTL
+	if err := s.SavePreset(&ElevationPreset{Service: "github", Name: "quick fix", TTL: 15 * time.Minute, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetPreset("github", "quick fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.TTL != 15*time.Minute {
+		t.Errorf("GetPreset() = %v, want TTL 15m", got)
+	}
+
+	deleted, err := s.DeletePreset("github", "quick fix")
+	if err != nil || !deleted {
+		t.Fatalf("DeletePreset() = %v, %v; want true, nil", deleted, err)
+	}
+	got, _ = s.GetPreset("github", "quick fix")
+	if got != nil {
+		t.Error("GetPreset() after delete should return nil")
+	}
+}
+
+func TestInjectionStatus(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	masterKey := make([]byte, 32)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	s, err := New(tmpFile.Name(), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	cred := &Credential{ID: "cred-1", Service: "github", DisplayName: "GitHub", Read: &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "t"}}
+	if err := s.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetCredential("github")
+	if err != nil || got.Injection != nil {
+		t.Fatalf("GetCredential() injection = %v, %v; want nil before any check", got.Injection, err)
+	}
+
+	// Populate the list cache; setting a status must invalidate it
+	if _, err := s.ListCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetInjectionStatus("github", &InjectionStatus{Status: InjectionNotLoaded, Detail: "GITHUB_TOKEN"}); err != nil {
+		t.Fatalf("SetInjectionStatus() error = %v", err)
+	}
+	creds, err := s.ListCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 || creds[0].Injection == nil || creds[0].Injection.Status != InjectionNotLoaded {
+		t.Fatalf("ListCredentials() injection = %+v, want not_loaded", creds[0].Injection)
+	}
+
+	if err := s.SetInjectionStatus("github", &InjectionStatus{Status: InjectionLoaded}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetCredential("github")
+	if got.Injection == nil || got.Injection.Status != InjectionLoaded || got.Injection.Detail != "" || got.Injection.CheckedAt.IsZero() {
+		t.Errorf("GetCredential() injection = %+v, want loaded", got.Injection)
+	}
+
+	// Deleting the credential drops its status, so a re-created one starts unchecked
+	if err := s.DeleteCredential("github"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetCredential("github")
+	if got.Injection != nil {
+		t.Errorf("GetCredential() after re-create injection = %+v, want nil", got.Injection)
+	}
+}
+
+func TestCredentialVersions(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	s, err := New(tmpFile.Name(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.SaveCredential(&Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read:     &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "t"},
+		Rotation: &Rotation{URL: "https://rotate.internal/github", Secret: "rotation-secret", Interval: 24 * time.Hour},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := s.ListCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := creds[0].Rotation; r == nil || r.Interval != 24*time.Hour || r.Secret != "" {
+		t.Errorf("ListCredentials() rotation = %+v, want the interval without the secret", r)
+	}
+
+	base := time.Now()
+	for i := 0; i < MaxCredentialVersions+2; i++ {
+		if err := s.ArchiveCredentialVersion(&CredentialVersion{
+			ID: fmt.Sprintf("ver-%02d", i), Service: "github", Level: "read", Reason: "rotated",
+			ArchivedAt: base.Add(time.Duration(i) * time.Second),
+			Access:     &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: fmt.Sprintf("token-%d", i)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := s.ListCredentialVersions("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != MaxCredentialVersions {
+		t.Fatalf("got %d versions, want %d", len(versions), MaxCredentialVersions)
+	}
+	if v := versions[0]; v.ID != fmt.Sprintf("ver-%02d", MaxCredentialVersions+1) || v.Access.Token != fmt.Sprintf("token-%d", MaxCredentialVersions+1) {
+		t.Errorf("newest version = %+v (%+v)", v, v.Access)
+	}
+
+	if err := s.DeleteCredential("github"); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := s.ListCredentialVersions("github"); len(versions) != 0 {
+		t.Errorf("versions after delete = %d, want 0", len(versions))
+	}
+}
+
+func TestReadCache(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	masterKey := make([]byte, 32)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	s, err := New(tmpFile.Name(), masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetCacheTTL(time.Minute)
+
+	cred := &Credential{
+		ID:          "cred-1",
+		Service:     "gmail",
+		DisplayName: "Gmail",
+		Type:        "oauth2",
+		Read:        &AccessLevel{EnvVar: "GMAIL_TOKEN", Token: "t"},
+		ReadWrite:   &AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "w"},
+	}
+	if err := s.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	// Miss then hit
+	if _, err := s.ListCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.ListCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := s.CacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("CacheStats() = %+v, want 1 hit and 1 miss", stats)
+	}
+
+	// Mutating a returned copy must not affect the cache
+	list[0].DisplayName = "mutated"
+	list, _ = s.ListCredentials()
+	if list[0].DisplayName != "Gmail" {
+		t.Error("cached credentials were mutated through a returned copy")
+	}
+
+	// SaveCredential invalidates
+	cred.DisplayName = "Gmail (Work)"
+	if err := s.SaveCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = s.ListCredentials()
+	if list[0].DisplayName != "Gmail (Work)" {
+		t.Errorf("ListCredentials() after save = %s, want updated name", list[0].DisplayName)
+	}
+
+	// Negative active-elevation lookups are cached, UpdateElevation invalidates
+	if active, _ := s.GetActiveElevation("gmail", "write"); active != nil {
+		t.Fatal("unexpected active elevation")
+	}
+	s.CreateElevation(&Elevation{ID: "elev-1", Service: "gmail", Scope: "write", Reason: "r", Status: "pending", RequestedAt: time.Now()})
+	expiresAt := time.Now().Add(time.Hour)
+	if err := s.UpdateElevation("elev-1", "approved", "admin", &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := s.GetActiveElevation("gmail", "write"); active == nil {
+		t.Error("GetActiveElevation() after approval should not serve stale negative entry")
+	}
+}
+
+func TestReadCache_RevokeRacesLookup(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "ocm.db"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetCacheTTL(time.Minute)
+	if err := s.SaveCredential(&Credential{ID: "cred-1", Service: "gmail", DisplayName: "Gmail", Type: "oauth2",
+		ReadWrite: &AccessLevel{EnvVar: "GMAIL_WRITE_TOKEN", Token: "w"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := s.CreateElevation(&Elevation{ID: "elev-window", Service: "gmail", Scope: "write", Status: "pending", RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateElevation("elev-window", "approved", "admin", &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoked between a lookup's read and its cache fill: the lookup
+	// itself may still see it, but it mustn't be cached
+	var once sync.Once
+	activeElevationRead = func() {
+		once.Do(func() { s.UpdateElevation("elev-window", "revoked", "admin", nil) })
+	}
+	t.Cleanup(func() { activeElevationRead = func() {} })
+	if active, _ := s.GetActiveElevation("gmail", "write"); active == nil {
+		t.Fatal("approved elevation not found")
+	}
+	activeElevationRead = func() {}
+	if active, _ := s.GetActiveElevation("gmail", "write"); active != nil {
+		t.Fatalf("revoked elevation %s served from the cache", active.ID)
+	}
+
+	// Nor may any of several lookups racing a revocation
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("elev-%d", i)
+		if err := s.CreateElevation(&Elevation{ID: id, Service: "gmail", Scope: "write", Status: "pending", RequestedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.UpdateElevation(id, "approved", "admin", &expiresAt); err != nil {
+			t.Fatal(err)
+		}
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						s.GetActiveElevation("gmail", "write")
+					}
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		if err := s.UpdateElevation(id, "revoked", "admin", nil); err != nil {
+			t.Fatal(err)
+		}
+		close(stop)
+		wg.Wait()
+
+		if active, _ := s.GetActiveElevation("gmail", "write"); active != nil {
+			t.Fatalf("round %d: revoked elevation %s still served from the cache", i, active.ID)
+		}
+	}
+}
+
+func TestAuditActionCatalog(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	s, err := New(tmpFile.Name(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	seen := make(map[AuditAction]bool)
+	for _, a := range AuditActions() {
+		if seen[a] {
+			t.Errorf("%s listed twice", a)
+		}
+		seen[a] = true
+		if parsed, err := ParseAuditAction(string(a)); err != nil || parsed != a {
+			t.Errorf("ParseAuditAction(%s) = %q, %v", a, parsed, err)
+		}
+	}
+
+	if err := s.AddAuditEntry(&AuditEntry{ID: "a1", Timestamp: time.Now(), Action: ActionElevationApproved}); err != nil {
+		t.Errorf("known action: %v", err)
+	}
+	if err := s.AddAuditEntry(&AuditEntry{ID: "a2", Timestamp: time.Now(), Action: "elevation_approve"}); !errors.Is(err, ErrUnknownAuditAction) {
+		t.Errorf("AddAuditEntry(typo) = %v, want ErrUnknownAuditAction", err)
+	}
+	if err := s.InsertAuditEntry(&AuditEntry{ID: "a3", Timestamp: time.Now()}); !errors.Is(err, ErrUnknownAuditAction) {
+		t.Errorf("InsertAuditEntry(no action) = %v, want ErrUnknownAuditAction", err)
+	}
+	if entries, _ := s.ListAuditEntries(10, ""); len(entries) != 1 {
+		t.Errorf("entries = %d, want 1", len(entries))
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	s, err := New(tmpFile.Name(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// An entry from before the chain existed
+	if _, err := s.db.Exec(`INSERT INTO audit_log (id, timestamp, action, service, scope, details, actor) VALUES ('legacy', ?, 'credential_access', '', '', '', 'agent')`,
+		time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now()
+	for i := 1; i <= 5; i++ {
+		if err := s.InsertAuditEntry(&AuditEntry{
+			ID: fmt.Sprintf("audit-%d", i), Timestamp: base.Add(time.Duration(i) * time.Second),
+			Action: ActionCredentialAccess, Service: "github", Actor: "agent", Details: "read",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := s.VerifyAuditChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Entries != 6 || report.Unchained != 1 || report.FirstSeq != 1 || report.LastSeq != 5 || report.HeadPruned {
+		t.Fatalf("intact chain: %+v", report)
+	}
+
+	// Pruning the head isn't a problem
+	if _, err := s.db.Exec(`DELETE FROM audit_log WHERE id IN ('legacy', 'audit-1')`); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = s.VerifyAuditChain(); !report.OK() || !report.HeadPruned || report.FirstSeq != 2 {
+		t.Fatalf("pruned head: %+v", report)
+	}
+
+	// Altering an entry, or removing one from the middle, is
+	s.db.Exec(`UPDATE audit_log SET details = 'write' WHERE id = 'audit-3'`)
+	s.db.Exec(`DELETE FROM audit_log WHERE id = 'audit-4'`)
+	report, _ = s.VerifyAuditChain()
+	var kinds []string
+	for _, p := range report.Problems {
+		kinds = append(kinds, p.EntryID+":"+p.Kind)
+	}
+	if want := []string{"audit-3:" + ChainHashMismatch, "audit-5:" + ChainGap}; !equalStrings(kinds, want) {
+		t.Errorf("problems = %v, want %v", kinds, want)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	oldKey := make([]byte, 32)
+	s, err := New(tmpFile.Name(), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.SaveCredential(&Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read: &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "read-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveWebhook(&Webhook{ID: "hook-1", URL: "https://example.com", Secret: "hook-secret", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnqueueGatewayOp(&GatewayOp{Gateway: "default", Kind: "config.patch", Payload: `{"token":"x"}`}); err != nil {
+		t.Fatal(err)
+	}
+	receiptKey, err := s.ReceiptSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now()
+	for i := 1; i <= 3; i++ {
+		if err := s.InsertAuditEntry(&AuditEntry{
+			ID: fmt.Sprintf("audit-%d", i), Timestamp: base.Add(time.Duration(i) * time.Second),
+			Action: ActionCredentialAccess, Service: "github", Actor: "agent",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A pruned head keeps its anchor
+	if _, err := s.db.Exec(`DELETE FROM audit_log WHERE id = 'audit-1'`); err != nil {
+		t.Fatal(err)
+	}
+
+	newKey := make([]byte, 32)
+	newKey[0] = 1
+	var stages []string
+	if err := s.Rekey(newKey, func(p RekeyProgress) {
+		if p.Done == p.Total {
+			stages = append(stages, p.Stage)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 4 {
+		t.Errorf("completed stages = %v", stages)
+	}
+	s.Close()
+
+	// Only the new key opens it now
+	s, err = New(tmpFile.Name(), newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	cred, err := s.GetCredential("github")
+	if err != nil || cred.Read.Token != "read-token" {
+		t.Fatalf("credential after rekey = %+v, %v", cred, err)
+	}
+	hooks, err := s.ListWebhooks()
+	if err != nil || len(hooks) != 1 || hooks[0].Secret != "hook-secret" {
+		t.Errorf("webhooks after rekey = %+v, %v", hooks, err)
+	}
+	ops, err := s.ListGatewayOps("")
+	if err != nil || len(ops) != 1 || ops[0].Payload != `{"token":"x"}` {
+		t.Errorf("gateway ops after rekey = %+v, %v", ops, err)
+	}
+	if k, err := s.ReceiptSigningKey(); err != nil || !k.Equal(receiptKey) {
+		t.Errorf("receipt key after rekey changed (err %v)", err)
+	}
+	if report, err := s.VerifyAuditChain(); err != nil || !report.OK() || !report.HeadPruned || report.Entries != 2 {
+		t.Errorf("audit chain after rekey = %+v, %v", report, err)
+	}
+
+	old, err := New(tmpFile.Name(), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if _, err := old.GetCredential("github"); err == nil {
+		t.Error("old key still decrypts credentials")
+	}
+
+	// A tampered chain isn't re-hashed
+	if _, err := s.db.Exec(`UPDATE audit_log SET details = 'edited' WHERE id = 'audit-3'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rekey(oldKey, nil); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("rekey over a broken chain: err = %v, want ErrAuditChainBroken", err)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	key := make([]byte, 32)
+	s, err := New(tmpFile.Name(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !s.SchemaUpgraded() {
+		t.Error("new database: SchemaUpgraded = false")
+	}
+
+	for _, service := range []string{"github", "linear"} {
+		if err := s.SaveCredential(&Credential{
+			ID: "cred-" + service, Service: service, DisplayName: service,
+			Read: &AccessLevel{EnvVar: "TOKEN", Token: "t"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.ReceiptSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.VerifyEncryption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Checked != 3 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// A value sealed under another key
+	other := make([]byte, 32)
+	other[0] = 1
+	o, err := New(tmpFile.Name()+"-other", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name() + "-other")
+	sealed, err := o.encrypt([]byte(`{}`))
+	o.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`UPDATE credentials SET scopes_encrypted = ? WHERE service = 'linear'`, sealed); err != nil {
+		t.Fatal(err)
+	}
+	report, err = s.VerifyEncryption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || len(report.Failures) != 1 || report.Failures[0].Key != "cred-linear" {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Compacting alongside a running server waits on its locks
+	var busyTimeout int
+	if err := s.db.QueryRow(`PRAGMA busy_timeout`).Scan(&busyTimeout); err != nil || busyTimeout != busyTimeoutMillis {
+		t.Errorf("busy_timeout = %d, %v; want %d", busyTimeout, err, busyTimeoutMillis)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(); err != nil {
+		t.Errorf("after compact: %v", err)
+	}
+	s.Close()
+
+	s, err = New(tmpFile.Name(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.SchemaUpgraded() {
+		t.Error("reopened database: SchemaUpgraded = true")
+	}
+}
+
+func TestCredentialUsage(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	s, err := New(tmpFile.Name(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetCacheTTL(0)
+
+	for _, service := range []string{"github", "linear"} {
+		if err := s.SaveCredential(&Credential{
+			ID: "cred-" + service, Service: service, DisplayName: service,
+			Read: &AccessLevel{EnvVar: "TOKEN", Token: "t"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.RecordCredentialUse("github", "read", UseInjection); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := s.RecordCredentialUse("github", "write", UseElevation); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := s.RecordCredentialUse("github", "read", UseAgent); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.ListCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gh, linear := creds[0], creds[1]
+	if len(gh.Usage) != 2 || gh.Usage[0].Scope != "read" || gh.Usage[0].Via != UseAgent || gh.Usage[1].Scope != "write" {
+		t.Errorf("github usage = %+v, want read by an agent, then the write elevation", gh.Usage)
+	}
+	if gh.LastUsedAt == nil || !gh.LastUsedAt.Equal(gh.Usage[0].UsedAt) {
+		t.Errorf("github lastUsedAt = %v, want the read", gh.LastUsedAt)
+	}
+	if gh.LastAccessedAt == nil || !gh.LastAccessedAt.Equal(gh.Usage[0].UsedAt) {
+		t.Errorf("github lastAccessedAt = %v, want the read", gh.LastAccessedAt)
+	}
+
+	// An injection is a use but not an access
+	if err := s.RecordCredentialUse("github", "read", UseInjection); err != nil {
+		t.Fatal(err)
+	}
+	cred, err := s.GetCredential("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Usage[0].Via != UseInjection || cred.LastAccessedAt == nil || !cred.LastAccessedAt.Equal(gh.Usage[0].UsedAt) {
+		t.Errorf("after injection: usage %+v, lastAccessedAt %v, want the agent read kept", cred.Usage, cred.LastAccessedAt)
+	}
+	if linear.LastUsedAt != nil || linear.LastAccessedAt != nil || linear.Usage != nil {
+		t.Errorf("linear was never used, got %v %+v", linear.LastUsedAt, linear.Usage)
+	}
+
+	if err := s.DeleteCredential("github"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveCredential(&Credential{ID: "cred-2", Service: "github", DisplayName: "GitHub", Read: &AccessLevel{EnvVar: "TOKEN", Token: "t"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cred, _ := s.GetCredential("github"); cred.LastUsedAt != nil {
+		t.Errorf("recreated credential inherited usage %v", cred.LastUsedAt)
+	}
+}
+
+func TestFindDuplicateSecrets(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ocm-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	s, err := New(tmpFile.Name(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.SaveCredential(&Credential{
+		ID: "cred-1", Service: "github", DisplayName: "GitHub",
+		Read:      &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "shared-token"},
+		ReadWrite: &AccessLevel{EnvVar: "GITHUB_TOKEN", Token: "write-token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored string
+	if err := s.db.QueryRow(`SELECT group_concat(fingerprint) FROM credential_fingerprints`).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stored, "shared-token") || strings.Contains(stored, "write-token") {
+		t.Fatal("fingerprints hold the plaintext")
+	}
+
+	gh := &Credential{Service: "gh-actions", Read: &AccessLevel{EnvVar: "GH_TOKEN", Token: "write-token"}}
+	dups, err := s.FindDuplicateSecrets(gh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dups) != 1 || dups[0] != (DuplicateSecret{Level: "read", Service: "github", ServiceLevel: "write"}) {
+		t.Errorf("dups = %+v, want github's write token", dups)
+	}
+
+	// Saving a credential again isn't a duplicate of itself
+	self := &Credential{Service: "github", Read: &AccessLevel{Token: "shared-token"}}
+	if dups, _ := s.FindDuplicateSecrets(self); len(dups) != 0 {
+		t.Errorf("dups of itself = %+v", dups)
+	}
+
+	// Fingerprints follow the key
+	newKey := make([]byte, 32)
+	newKey[0] = 1
+	if err := s.Rekey(newKey, nil); err != nil {
+		t.Fatal(err)
+	}
+	if dups, _ := s.FindDuplicateSecrets(gh); len(dups) != 1 {
+		t.Errorf("after rekey: dups = %+v, want github's write token", dups)
+	}
+
+	if err := s.DeleteCredential("github"); err != nil {
+		t.Fatal(err)
+	}
+	if dups, _ := s.FindDuplicateSecrets(gh); len(dups) != 0 {
+		t.Errorf("after delete: dups = %+v", dups)
+	}
+}
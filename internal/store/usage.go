@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// How a credential was used.
+const (
+	UseAgent     = "agent"     // An agent read it from the agent API
+	UseInjection = "injection" // It was injected into the Gateway
+	UseElevation = "elevation" // An elevation on it was approved
+)
+
+// CredentialUse is when a credential's scope was last used, and how.
+type CredentialUse struct {
+	Scope      string     `json:"scope"`
+	Via        string     `json:"via"` // agent, injection or elevation
+	UsedAt     time.Time  `json:"usedAt"`
+	AccessedAt *time.Time `json:"accessedAt,omitempty"` // Last agent read or elevation; nil = only ever injected
+}
+
+// RecordCredentialUse records that service's scope was used just now. The
+// read-path cache isn't invalidated, so listed credentials may show the
+// previous use for up to its TTL.
+func (s *Store) RecordCredentialUse(service, scope, via string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var accessedAt *time.Time
+	if via != UseInjection {
+		accessedAt = &now
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO credential_usage (service, scope, via, used_at, accessed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(service, scope) DO UPDATE SET
+			via = excluded.via,
+			used_at = excluded.used_at,
+			accessed_at = COALESCE(excluded.accessed_at, credential_usage.accessed_at)
+	`, service, scope, via, now, accessedAt)
+	return err
+}
+
+// credentialUsage returns the last use of each scope of service, or of
+// every credential for service "", most recent first, keyed by service.
+// The caller holds s.mu.
+func (s *Store) credentialUsage(service string) (map[string][]CredentialUse, error) {
+	var rows *sql.Rows
+	var err error
+	if service == "" {
+		rows, err = s.db.Query(`SELECT service, scope, via, used_at, accessed_at FROM credential_usage ORDER BY used_at DESC`)
+	} else {
+		rows, err = s.db.Query(`SELECT service, scope, via, used_at, accessed_at FROM credential_usage WHERE service = ? ORDER BY used_at DESC`, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string][]CredentialUse)
+	for rows.Next() {
+		var svc string
+		var use CredentialUse
+		var accessedAt sql.NullTime
+		if err := rows.Scan(&svc, &use.Scope, &use.Via, &use.UsedAt, &accessedAt); err != nil {
+			return nil, err
+		}
+		if accessedAt.Valid {
+			use.AccessedAt = &accessedAt.Time
+		}
+		usage[svc] = append(usage[svc], use)
+	}
+	return usage, rows.Err()
+}
+
+// setUsage sets cred's usage fields from its uses, most recent first.
+func (cred *Credential) setUsage(uses []CredentialUse) {
+	cred.Usage = uses
+	if len(uses) > 0 {
+		last := uses[0].UsedAt
+		cred.LastUsedAt = &last
+	}
+	for _, use := range uses {
+		if use.AccessedAt != nil && (cred.LastAccessedAt == nil || use.AccessedAt.After(*cred.LastAccessedAt)) {
+			cred.LastAccessedAt = use.AccessedAt
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MaxCredentialVersions is how many archived versions are kept per
+// credential access level; older ones are dropped as new ones are archived.
+const MaxCredentialVersions = 10
+
+// CredentialVersion is an access level as it was before a rotation
+// replaced its secret.
+type CredentialVersion struct {
+	ID         string       `json:"id"`
+	Service    string       `json:"service"`
+	Level      string       `json:"level"` // read or write
+	Reason     string       `json:"reason,omitempty"`
+	ArchivedAt time.Time    `json:"archivedAt"`
+	Access     *AccessLevel `json:"-"` // Secrets included (encrypted at rest)
+}
+
+// ArchiveCredentialVersion stores v, dropping the level's oldest versions
+// beyond MaxCredentialVersions.
+func (s *Store) ArchiveCredentialVersion(v *CredentialVersion) error {
+	data, err := json.Marshal(v.Access)
+	if err != nil {
+		return fmt.Errorf("marshal access level: %w", err)
+	}
+	sealed, err := s.encrypt(data)
+	if err != nil {
+		return fmt.Errorf("encrypt access level: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`
+		INSERT INTO credential_versions (id, service, level, data_encrypted, reason, archived_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, v.ID, v.Service, v.Level, sealed, v.Reason, v.ArchivedAt); err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		DELETE FROM credential_versions WHERE service = ? AND level = ? AND id NOT IN (
+			SELECT id FROM credential_versions WHERE service = ? AND level = ?
+			ORDER BY archived_at DESC, id DESC LIMIT ?
+		)
+	`, v.Service, v.Level, v.Service, v.Level, MaxCredentialVersions)
+	return err
+}
+
+// ListCredentialVersions returns service's archived versions, newest first,
+// with their secrets.
+func (s *Store) ListCredentialVersions(service string) ([]*CredentialVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, service, level, data_encrypted, reason, archived_at
+		FROM credential_versions WHERE service = ? ORDER BY archived_at DESC, id DESC
+	`, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*CredentialVersion
+	for rows.Next() {
+		var v CredentialVersion
+		var sealed []byte
+		if err := rows.Scan(&v.ID, &v.Service, &v.Level, &sealed, &v.Reason, &v.ArchivedAt); err != nil {
+			return nil, err
+		}
+		data, err := s.decrypt(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt version %s: %w", v.ID, err)
+		}
+		if err := json.Unmarshal(data, &v.Access); err != nil {
+			return nil, fmt.Errorf("unmarshal version %s: %w", v.ID, err)
+		}
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/openclaw/ocm/internal/redact"
+)
+
+// Webhook is an admin-registered endpoint that receives matching events.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`      // HMAC signing secret (encrypted at rest)
+	Events    []string  `json:"events"` // Filters, e.g., "elevation.*", "credential.deleted", "*"
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery records one event delivery to a webhook.
+type WebhookDelivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhookId"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"` // pending, delivered, failed
+	Attempts     int       `json:"attempts"`
+	ResponseCode int       `json:"responseCode,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// SaveWebhook creates or updates a webhook.
+func (s *Store) SaveWebhook(hook *Webhook) error {
+	var secret []byte
+	if hook.Secret != "" {
+		redact.Register(hook.Secret)
+		var err error
+		if secret, err = s.encrypt([]byte(hook.Secret)); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO webhooks (id, url, secret_encrypted, events, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			url = excluded.url,
+			secret_encrypted = excluded.secret_encrypted,
+			events = excluded.events,
+			enabled = excluded.enabled
+	`, hook.ID, hook.URL, secret, strings.Join(hook.Events, ","), hook.Enabled, hook.CreatedAt)
+	return err
+}
+
+// GetWebhook retrieves a webhook (with decrypted secret) by ID.
+func (s *Store) GetWebhook(id string) (*Webhook, error) {
+	hooks, err := s.queryWebhooks(`WHERE id = ?`, id)
+	if err != nil || len(hooks) == 0 {
+		return nil, err
+	}
+	return hooks[0], nil
+}
+
+// ListWebhooks returns all webhooks (with decrypted secrets), oldest first.
+func (s *Store) ListWebhooks() ([]*Webhook, error) {
+	return s.queryWebhooks(``)
+}
+
+func (s *Store) queryWebhooks(where string, args ...interface{}) ([]*Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, url, secret_encrypted, events, enabled, created_at
+		FROM webhooks `+where+` ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*Webhook
+	for rows.Next() {
+		var hook Webhook
+		var secret []byte
+		var events string
+		if err := rows.Scan(&hook.ID, &hook.URL, &secret, &events, &hook.Enabled, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(secret) > 0 {
+			plain, err := s.decrypt(secret)
+			if err != nil {
+				return nil, err
+			}
+			hook.Secret = string(plain)
+			redact.Register(hook.Secret)
+		}
+		if events != "" {
+			hook.Events = strings.Split(events, ",")
+		}
+		hooks = append(hooks, &hook)
+	}
+	return hooks, rows.Err()
+}
+
+// DeleteWebhook removes a webhook and its delivery history. Returns false if it did not exist.
+func (s *Store) DeleteWebhook(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.db.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveWebhookDelivery creates or updates a delivery record.
+func (s *Store) SaveWebhookDelivery(d *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event, status, attempts, response_code, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			attempts = excluded.attempts,
+			response_code = excluded.response_code,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+	`, d.ID, d.WebhookID, d.Event, d.Status, d.Attempts, d.ResponseCode, d.LastError, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries, newest first.
+func (s *Store) ListWebhookDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, webhook_id, event, status, attempts, response_code, last_error, created_at, updated_at
+		FROM webhook_deliveries WHERE webhook_id = ?
+		ORDER BY created_at DESC LIMIT ?
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var code sql.NullInt64
+		var lastError sql.NullString
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Status, &d.Attempts, &code, &lastError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		d.ResponseCode = int(code.Int64)
+		d.LastError = lastError.String
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
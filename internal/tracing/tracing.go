@@ -0,0 +1,220 @@
+// Package tracing records OpenTelemetry-compatible spans and exports them
+// over OTLP/HTTP (JSON), so an approval can be followed from the HTTP
+// request through the store and the Gateway RPC calls and restarts it
+// causes. Until a Tracer is installed with SetDefault, Start returns a nil
+// *Span and every Span method is a no-op.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kind is the OTLP span kind.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether sc has a trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Span is one timed operation. A nil *Span is valid and records nothing.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent [8]byte
+	kind   Kind
+	start  time.Time
+
+	mu     sync.Mutex
+	name   string
+	attrs  []attribute
+	errMsg string
+	end    time.Time
+}
+
+type attribute struct {
+	Key   string
+	Value interface{}
+}
+
+type ctxKey struct{}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault installs t as the tracer used by Start; nil turns tracing off.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Start begins an internal span as a child of the span in ctx, if any.
+// Attributes are given as alternating keys and values, as with slog.
+func Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return start(ctx, KindInternal, name, kv)
+}
+
+// StartServer begins a span for handling an inbound request.
+func StartServer(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return start(ctx, KindServer, name, kv)
+}
+
+// StartClient begins a span for an outbound call, e.g., a Gateway RPC.
+func StartClient(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return start(ctx, KindClient, name, kv)
+}
+
+func start(ctx context.Context, kind Kind, name string, kv []interface{}) (context.Context, *Span) {
+	t := defaultTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFrom(ctx)
+	if parent.IsValid() && !parent.Sampled {
+		return ctx, nil
+	}
+
+	s := &Span{tracer: t, kind: kind, name: name, start: time.Now()}
+	s.sc.Sampled = true
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.parent = parent.SpanID
+	} else {
+		rand.Read(s.sc.TraceID[:])
+	}
+	rand.Read(s.sc.SpanID[:])
+	s.SetAttributes(kv...)
+	return context.WithValue(ctx, ctxKey{}, s.sc), s
+}
+
+// SpanContextFrom returns the span context carried by ctx: the current
+// span's, or a remote parent's set by Extract.
+func SpanContextFrom(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(ctxKey{}).(SpanContext)
+	return sc
+}
+
+// Detach returns a background context carrying only ctx's span context, for
+// work that outlives the request that caused it (e.g., a debounced restart).
+func Detach(ctx context.Context) context.Context {
+	sc := SpanContextFrom(ctx)
+	if !sc.IsValid() {
+		return context.Background()
+	}
+	return context.WithValue(context.Background(), ctxKey{}, sc)
+}
+
+// SetName renames the span, e.g., once the HTTP route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttributes adds attributes given as alternating keys and values.
+func (s *Span) SetAttributes(kv ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		s.attrs = append(s.attrs, attribute{Key: key, Value: kv[i+1]})
+	}
+}
+
+// RecordError marks the span failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or "" if none.
+func TraceID(ctx context.Context) string {
+	sc := SpanContextFrom(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// TraceparentHeader is the W3C Trace Context header.
+const TraceparentHeader = "traceparent"
+
+// Extract returns ctx carrying the remote parent from a traceparent header,
+// or ctx unchanged if there is none or it is malformed.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, ok := parseTraceparent(h.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, sc)
+}
+
+// parseTraceparent parses "00-<32 hex trace ID>-<16 hex span ID>-<2 hex flags>".
+func parseTraceparent(v string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// collector is a fake OTLP/HTTP endpoint that keeps what it receives.
+type collector struct {
+	mu      sync.Mutex
+	spans   []otlpSpan
+	service string
+	auth    string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	var req otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = r.Header.Get("Authorization")
+	for _, rs := range req.ResourceSpans {
+		c.service = *rs.Resource.Attributes[0].Value.StringValue
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+// startTracer installs a tracer exporting to a fake collector until the
+// test ends.
+func startTracer(t *testing.T) (*Tracer, *collector) {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	headers, err := ParseHeaders("Authorization=Bearer%20abc, x-extra = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer, err := New(Config{Endpoint: srv.URL, Headers: headers}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracer.Run(ctx)
+		close(done)
+	}()
+	SetDefault(tracer)
+	t.Cleanup(func() {
+		SetDefault(nil)
+		cancel()
+		<-done
+	})
+	return tracer, c
+}
+
+func traceparent(v string) http.Header {
+	h := http.Header{}
+	h.Set(TraceparentHeader, v)
+	return h
+}
+
+func TestSpansExportWithParents(t *testing.T) {
+	tracer, c := startTracer(t)
+
+	ctx := Extract(context.Background(), traceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+	ctx, root := StartServer(ctx, "POST /approve", "http.method", "POST")
+	_, child := Start(ctx, "store.GetElevation", "elevation_id", "elev_1", "attempt", 2)
+	child.RecordError(errors.New("database is locked"))
+	child.End()
+	root.End()
+	root.End() // Ignored
+	tracer.Flush(context.Background())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(c.spans))
+	}
+	if c.service != "ocm" || c.auth != "Bearer abc" {
+		t.Errorf("service %q, auth %q", c.service, c.auth)
+	}
+	gotChild, gotRoot := c.spans[0], c.spans[1]
+	if gotRoot.TraceID != "0af7651916cd43dd8448eb211c80319c" || gotRoot.ParentSpanID != "b7ad6b7169203331" || gotRoot.Kind != KindServer {
+		t.Errorf("root span = %+v, want it to continue the remote trace", gotRoot)
+	}
+	if gotChild.TraceID != gotRoot.TraceID || gotChild.ParentSpanID != gotRoot.SpanID {
+		t.Errorf("child span = %+v, want a child of %s", gotChild, gotRoot.SpanID)
+	}
+	if gotChild.Status == nil || gotChild.Status.Code != 2 || gotChild.Status.Message != "database is locked" {
+		t.Errorf("child status = %+v", gotChild.Status)
+	}
+	if len(gotChild.Attributes) != 2 || *gotChild.Attributes[1].Value.IntValue != "2" {
+		t.Errorf("child attributes = %+v", gotChild.Attributes)
+	}
+}
+
+func TestUnsampledAndDisabled(t *testing.T) {
+	// No tracer: nil spans, and every method is safe
+	_, span := Start(context.Background(), "noop")
+	if span != nil {
+		t.Fatal("expected a nil span with tracing off")
+	}
+	span.SetAttributes("k", "v")
+	span.RecordError(errors.New("x"))
+	span.End()
+
+	tracer, c := startTracer(t)
+	ctx := Extract(context.Background(), traceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00"))
+	if _, span := Start(ctx, "unsampled"); span != nil {
+		t.Error("expected no span under an unsampled parent")
+	}
+	tracer.Flush(context.Background())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 0 {
+		t.Errorf("exported %d spans, want none", len(c.spans))
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for v, ok := range map[string]bool{
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01":       true,
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra": true,
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra": false,
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01":       false,
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333z-01":       false,
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01":       false,
+		"": false,
+	} {
+		if _, got := parseTraceparent(v); got != ok {
+			t.Errorf("parseTraceparent(%q) ok = %v, want %v", v, got, ok)
+		}
+	}
+}
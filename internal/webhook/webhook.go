@@ -0,0 +1,117 @@
+// Package webhook delivers signed JSON payloads to outbound HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC of timestamp.body>" when a secret is configured.
+	SignatureHeader = "X-OCM-Signature"
+	// TimestampHeader carries the unix timestamp included in the signature (replay protection).
+	TimestampHeader = "X-OCM-Timestamp"
+)
+
+// DefaultClient is used when no client is supplied.
+var DefaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Sign returns the signature header value for a body sent at the given unix time.
+// Receivers verify by recomputing HMAC-SHA256(secret, "<timestamp>.<body>").
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post marshals payload as JSON and POSTs it to url, signing it if secret is set.
+// Any non-2xx response is returned as an error.
+func Post(ctx context.Context, client *http.Client, url, secret string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	return PostRaw(ctx, client, url, secret, body)
+}
+
+// PostRaw POSTs an already-encoded JSON body to url, signing it if secret is set.
+func PostRaw(ctx context.Context, client *http.Client, url, secret string, body []byte) error {
+	return PostRawHeaders(ctx, client, url, secret, body, nil)
+}
+
+// PostRawHeaders is PostRaw with extra request headers.
+func PostRawHeaders(ctx context.Context, client *http.Client, url, secret string, body []byte, header http.Header) error {
+	return post(ctx, client, url, secret, body, header, nil)
+}
+
+// Call POSTs payload like Post and decodes the receiver's JSON response
+// into out.
+func Call(ctx context.Context, client *http.Client, url, secret string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	return post(ctx, client, url, secret, body, nil, out)
+}
+
+// maxResponse bounds the response body read by Call.
+const maxResponse = 64 << 10
+
+func post(ctx context.Context, client *http.Client, url, secret string, body []byte, header http.Header, out interface{}) error {
+	if client == nil {
+		client = DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ocm-webhook/1")
+	if secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(SignatureHeader, Sign(secret, ts, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponse))
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	if out == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponse))
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponse)).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// StatusError is returned when the receiver responds with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
@@ -1,6 +1,8 @@
 // API client for OCM admin endpoints
 
-const BASE_URL = '/admin/api';
+import type { ServiceCatalog } from '$lib/serviceTemplates';
+
+const BASE_URL = '/admin/api/v1';
 
 export interface Credential {
 	id: string;
@@ -10,18 +12,79 @@ export interface Credential {
 	// New model: read and optional readWrite access levels
 	read?: AccessLevel;
 	readWrite?: AccessLevel;
+	// Gateway the credential is injected into (absent = default)
+	gateway?: string;
+	// Whether the Gateway loaded the credential after its last injection
+	injection?: InjectionStatus;
+	// Whether the provider accepted the token at its last health check
+	health?: CredentialHealth;
+	// When an agent read, injection or elevation last used it (absent = never)
+	lastUsedAt?: string;
+	// Last agent read or elevation; injections don't count
+	lastAccessedAt?: string;
+	usage?: CredentialUse[];
+	// Hook that mints replacement secrets (secret omitted)
+	rotation?: Rotation;
 	// Legacy (for backwards compat in display)
 	scopes?: Record<string, Scope>;
 	createdAt: string;
 	updatedAt: string;
 }
 
+export interface Rotation {
+	command?: string[];
+	url?: string;
+	interval?: number; // Nanoseconds from Go; absent = on demand only
+	provider?: 'github' | 'aws_iam' | 'slack'; // Built-in rotator
+	clientId?: string;
+	endpoint?: string;
+}
+
+export interface RotationResult {
+	service: string;
+	rotated: ('read' | 'write')[];
+	failed?: string[];
+	warning?: string;
+}
+
+export interface InjectionStatus {
+	status: 'pending' | 'loaded' | 'not_loaded' | 'unverified';
+	detail?: string;
+	checkedAt: string;
+}
+
+export interface CredentialUse {
+	scope: string;
+	via: 'agent' | 'injection' | 'elevation';
+	usedAt: string;
+	accessedAt?: string;
+}
+
+export interface CredentialHealth {
+	status: 'ok' | 'invalid' | 'error';
+	detail?: string;
+	checkedAt: string;
+}
+
 export interface AccessLevel {
 	envVar: string;
 	token?: string;
 	refreshToken?: string;
 	expiresAt?: string;
 	maxTTL?: number; // Only for readWrite, in nanoseconds from Go
+	additionalFields?: AdditionalFieldConfig[];
+}
+
+// What injecting a credential would change; secret values are masked
+export interface InjectionPreview {
+	service: string;
+	level: 'read' | 'readWrite';
+	gateway: string;
+	envFile?: string;
+	secretsDir?: string;
+	env?: { name: string; action: 'add' | 'update' | 'unchanged'; old?: string; new: string }[];
+	configPatch?: Record<string, unknown>;
+	restart: boolean;
 }
 
 export interface Scope {
@@ -45,6 +108,7 @@ export interface Elevation {
 	approvedAt?: string;
 	expiresAt?: string;
 	approvedBy?: string;
+	requestedTTL?: number; // In nanoseconds from Go
 }
 
 export interface AuditEntry {
@@ -55,6 +119,48 @@ export interface AuditEntry {
 	scope?: string;
 	details?: string;
 	actor: string;
+	requestId?: string;
+	sourceIp?: string;
+	userAgent?: string;
+	elevationId?: string;
+}
+
+export interface AuditFilters {
+	service?: string;
+	action?: string;
+	actor?: string;
+	from?: string; // RFC 3339
+	to?: string;
+	limit?: number;
+	cursor?: string;
+}
+
+export interface ElevationStats {
+	requested: number;
+	approved: number;
+	denied: number;
+	rejected: number;
+	approvalRate?: number;
+	medianSecondsToApprove?: number;
+	p90SecondsToApprove?: number;
+}
+
+export interface ServiceStats extends ElevationStats {
+	service: string;
+	accesses: number;
+	readAccesses: number;
+	writeAccesses: number;
+}
+
+export interface AccessStats {
+	from: string;
+	to: string;
+	timezone: string;
+	accesses: number;
+	services: ServiceStats[];
+	elevations: ElevationStats;
+	accessesByHour: number[];
+	busiestHours: number[];
 }
 
 export interface DashboardData {
@@ -63,22 +169,70 @@ export interface DashboardData {
 	activeElevations: number;
 	recentAudit: AuditEntry[];
 	pending: Elevation[];
+	expiring: ExpiringToken[]; // Soonest first
+}
+
+// A credential token expiring within the warning window, or already expired
+export interface ExpiringToken {
+	service: string;
+	level: 'read' | 'write';
+	expiresAt: string;
+	expired: boolean;
 }
 
-export interface GatewayStatusInfo {
-	connected: boolean;
-	pairingNeeded: boolean;
-	tokenMismatch?: boolean;
+export type ComponentStatus = 'ok' | 'disabled' | 'warn' | 'fail';
+
+export interface GatewayComponent {
+	name: string;
+	status: ComponentStatus;
+	detail?: string;
+	state: 'connected' | 'disconnected' | 'pairing_needed' | 'token_mismatch' | 'disabled';
+	degraded: boolean;
 	deviceId?: string;
 	approveCommand?: string;
 	fixCommand?: string;
+	envFile: string;
+	envWritable: boolean;
+	envError?: string;
+}
+
+export interface NotifierComponent {
+	name: string;
+	status: ComponentStatus;
+	delivered: number;
+	failed: number;
+	consecutiveFailures: number;
+	lastDeliveredAt?: string;
+	lastFailedAt?: string;
+	lastError?: string;
+}
+
+export interface SystemStatus {
+	status: ComponentStatus;
+	version: string;
+	commit: string;
+	store: { status: ComponentStatus; detail?: string; credentials: number };
+	gateways: GatewayComponent[]; // Default first
+	notifiers: NotifierComponent[];
+}
+
+export type SetupStep = 'welcome' | 'model_provider';
+
+export interface SetupStepStatus {
+	step: SetupStep;
+	status: 'pending' | 'completed' | 'skipped';
+	at?: string;
+	skippable: boolean;
 }
 
 export interface SetupStatus {
 	setupComplete: boolean;
+	currentStep?: SetupStep; // First step neither completed nor skipped
+	steps: SetupStepStatus[];
+	startedAt: string;
+	completedAt?: string;
 	missingKeys: string[];
 	configuredKeys: string[];
-	gatewayStatus?: GatewayStatusInfo;
 }
 
 export interface PendingDevice {
@@ -116,6 +270,29 @@ export interface ChannelStatusResponse {
 	channels: ChannelStatus[];
 }
 
+export interface ApprovalReceipt {
+	receipt: {
+		type: string;
+		version: number;
+		elevationId: string;
+		service: string;
+		scope: string;
+		reason?: string;
+		requestedBy?: string;
+		requestedAt: string;
+		approvedBy: string;
+		approvedAt: string;
+		ttl: string;
+		expiresAt: string;
+		keyId: string;
+	};
+	payload: string;    // Base64 of the signed bytes
+	signature: string;  // Base64 Ed25519 signature
+	publicKey: string;  // Base64
+	algorithm: 'Ed25519';
+	keyId: string;
+}
+
 export interface AdditionalFieldConfig {
 	name: string;
 	injectionType?: 'env' | 'config';
@@ -131,6 +308,7 @@ export interface AccessLevelConfig {
 	token: string;
 	refreshToken?: string;
 	maxTTL?: string;         // e.g., "1h", "30m" - only for readWrite
+	expiresAt?: string;      // When the token expires, for expiry warnings
 	additionalFields?: AdditionalFieldConfig[];
 }
 
@@ -170,10 +348,60 @@ async function request<T>(path: string, options?: RequestInit): Promise<T> {
 	return JSON.parse(text);
 }
 
+// Server-sent event types that change what the dashboard shows
+export const DASHBOARD_EVENTS = [
+	'elevation.requested',
+	'elevation.approved',
+	'elevation.denied',
+	'elevation.expired',
+	'elevation.revoked',
+	'credential.created',
+	'credential.updated',
+	'credential.deleted',
+	'credential.not_loaded',
+	'credential.rotated',
+	'credential.invalid',
+	'device.requested',
+	'device.approved',
+	'device.rejected',
+	'gateway.status'
+];
+
+export interface OcmEvent {
+	type: string;
+	time: string;
+	service?: string;
+	scope?: string;
+	elevationId?: string;
+	actor?: string;
+	details?: string;
+}
+
+// subscribeEvents opens the admin event stream and calls onEvent for each of
+// the given event types. EventSource reconnects on its own. Returns a function
+// that closes the stream.
+export function subscribeEvents(types: string[], onEvent: (event: OcmEvent) => void): () => void {
+	const source = new EventSource(`${BASE_URL}/events`);
+	for (const type of types) {
+		source.addEventListener(type, (msg) => onEvent(JSON.parse((msg as MessageEvent).data)));
+	}
+	return () => source.close();
+}
+
 export const api = {
 	// Setup
 	getSetupStatus: () => request<SetupStatus>('/setup/status'),
 	completeSetup: () => request<{ status: string; message: string }>('/setup/complete', { method: 'POST' }),
+	completeSetupStep: (step: SetupStep) =>
+		request<SetupStatus>(`/setup/steps/${step}/complete`, { method: 'POST' }),
+	skipSetupStep: (step: SetupStep) => request<SetupStatus>(`/setup/steps/${step}/skip`, { method: 'POST' }),
+	resetSetup: () => request<SetupStatus>('/setup/reset', { method: 'POST' }),
+
+	// System status
+	getSystemStatus: () => request<SystemStatus>('/system/status'),
+
+	// Service catalog
+	getCatalog: () => request<ServiceCatalog>('/catalog'),
 
 	// Dashboard
 	getDashboard: () => request<DashboardData>('/dashboard'),
@@ -191,24 +419,51 @@ export const api = {
 			method: 'PUT',
 			body: JSON.stringify(data)
 		}),
+	rotateCredential: (service: string) =>
+		request<RotationResult>(`/credentials/${service}/rotate`, { method: 'POST' }),
+	checkCredential: (service: string) =>
+		request<CredentialHealth>(`/credentials/${service}/check`, { method: 'POST' }),
 	deleteCredential: (service: string) =>
 		request<void>(`/credentials/${service}`, { method: 'DELETE' }),
+	previewInjection: (service: string, level: 'read' | 'readWrite' = 'read') =>
+		request<InjectionPreview>(`/credentials/${service}/preview-injection?level=${level}`, {
+			method: 'POST'
+		}),
 
 	// Elevation requests
 	listPendingRequests: () => request<Elevation[]>('/requests'),
-	approveRequest: (id: string, ttl: string = '30m') =>
-		request<{ status: string; expiresAt: string }>(`/requests/${id}/approve`, {
+	// Without a ttl, the elevation lasts as long as the agent requested
+	approveRequest: (id: string, ttl?: string) =>
+		request<{ status: string; expiresAt: string; ttl: string }>(`/requests/${id}/approve`, {
 			method: 'POST',
-			body: JSON.stringify({ ttl })
+			body: JSON.stringify(ttl ? { ttl } : {})
 		}),
 	denyRequest: (id: string) =>
 		request<{ status: string }>(`/requests/${id}/deny`, { method: 'POST' }),
+	getApprovalReceipt: (id: string) => request<ApprovalReceipt>(`/requests/${id}/receipt`),
+	approvalReceiptUrl: (id: string) => `${BASE_URL}/requests/${id}/receipt?download=1`,
 	revokeElevation: (service: string, scope: string) =>
 		request<{ status: string }>(`/revoke/${service}/${scope}`, { method: 'POST' }),
 
 	// Audit
-	listAuditEntries: (service?: string) =>
-		request<AuditEntry[]>(`/audit${service ? `?service=${service}` : ''}`),
+	listAuditEntries: (filters: AuditFilters = {}) => {
+		const params = new URLSearchParams();
+		for (const [key, value] of Object.entries(filters)) {
+			if (value !== undefined && value !== '') params.set(key, String(value));
+		}
+		const query = params.toString();
+		return request<AuditEntry[]>(`/audit${query ? `?${query}` : ''}`);
+	},
+	listAuditActions: () => request<string[]>('/audit/actions'),
+	getAccessStats: (from?: string, to?: string) => {
+		const params = new URLSearchParams();
+		if (from) params.set('from', from);
+		if (to) params.set('to', to);
+		params.set('tz', Intl.DateTimeFormat().resolvedOptions().timeZone);
+		return request<AccessStats>(`/stats/access?${params}`);
+	},
+	auditExportUrl: (format: 'csv' | 'jsonl', service?: string) =>
+		`${BASE_URL}/audit/export?format=${format}${service ? `&service=${encodeURIComponent(service)}` : ''}`,
 
 	// Device pairing
 	listDevices: () => request<DeviceList>('/devices'),